@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+const (
+	alertInterval       = time.Minute
+	alertWebhookTimeout = 10 * time.Second
+)
+
+// alertEngine watches for degraded service (long queue waits, a full room,
+// devices out of service) for the staff lead.
+var alertEngine = newAlertEngine()
+
+var alertsButton *widget.Button
+
+func newAlertEngine() *state.AlertEngine {
+	engine := state.NewAlertEngine()
+	engine.Quiet = func(now time.Time) bool { return quietFor("alerts", now) }
+	return engine
+}
+
+func configureAlerts() {
+	alertEngine.Configure(
+		time.Duration(appSettings.AlertQueueWaitMinutes)*time.Minute,
+		time.Duration(appSettings.AlertAllBusyMinutes)*time.Minute,
+		appSettings.AlertMaintenanceDevices,
+	)
+	refreshAlertsButton()
+}
+
+// checkAlerts evaluates the rules once a minute and announces every rule that
+// fired or cleared with a notification and, if configured, a webhook post.
+func checkAlerts() {
+	for _, event := range alertEngine.Evaluate(store, time.Now()) {
+		title := "Alert: " + event.Rule.Name
+		if !event.Fired {
+			title = "Cleared: " + event.Rule.Name
+		}
+		notify("alerts", title, event.Detail)
+		if appSettings.AlertWebhookURL != "" {
+			go postAlertWebhook(appSettings.AlertWebhookURL, title, event)
+		}
+	}
+	refreshAlertsButton()
+}
+
+// postAlertWebhook sends one alert as JSON. The text field makes the post
+// readable in Slack and Discord incoming webhooks as is.
+func postAlertWebhook(url, title string, event state.AlertEvent) {
+	status := "cleared"
+	if event.Fired {
+		status = "fired"
+	}
+	body, err := json.Marshal(map[string]any{
+		"text":   fmt.Sprintf("%s (%s)", title, event.Detail),
+		"rule":   event.Rule.ID,
+		"status": status,
+		"detail": event.Detail,
+		"time":   event.Time,
+	})
+	if err != nil {
+		slog.Error("encoding alert webhook", "err", err)
+		return
+	}
+	client := &http.Client{Timeout: alertWebhookTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Error("posting alert webhook", "err", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		slog.Error("posting alert webhook", "status", resp.Status)
+	}
+}
+
+func newAlertsButton() *widget.Button {
+	alertsButton = widget.NewButtonWithIcon("", theme.InfoIcon(), showAlertsDialog)
+	refreshAlertsButton()
+	return alertsButton
+}
+
+func refreshAlertsButton() {
+	if alertsButton == nil {
+		return
+	}
+	if len(alertEngine.Rules) == 0 {
+		alertsButton.Hide()
+		return
+	}
+	alertsButton.Show()
+	if n := alertEngine.ActiveCount(); n > 0 {
+		alertsButton.SetText(fmt.Sprintf("Alerts (%d)", n))
+		alertsButton.SetIcon(theme.WarningIcon())
+		alertsButton.Importance = widget.WarningImportance
+	} else {
+		alertsButton.SetText("Alerts")
+		alertsButton.SetIcon(theme.InfoIcon())
+		alertsButton.Importance = widget.LowImportance
+	}
+	alertsButton.Refresh()
+}
+
+// showAlertsDialog lists when each rule fired and cleared this session,
+// newest first.
+func showAlertsDialog() {
+	history := alertEngine.History()
+	lines := make([]string, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		event := history[i]
+		status := "CLEARED"
+		if event.Fired {
+			status = "FIRED  "
+		}
+		line := fmt.Sprintf("%s  %s  %s - %s", formatClock(event.Time), status, event.Rule.Name, event.Detail)
+		if event.Muted {
+			line += " (muted)"
+		}
+		lines = append(lines, line)
+	}
+	text := "No alerts have fired since the app started."
+	if len(lines) > 0 {
+		text = strings.Join(lines, "\n")
+	}
+	var rules []string
+	for _, rule := range alertEngine.Rules {
+		rules = append(rules, rule.Name)
+	}
+	historyLabel := widget.NewLabel(text)
+	scroll := container.NewVScroll(historyLabel)
+	scroll.SetMinSize(fyne.NewSize(520, 260))
+	header := widget.NewLabel("Watching: " + strings.Join(rules, "; "))
+	header.Wrapping = fyne.TextWrapWord
+	dialog.ShowCustom("Alerts", "Close", container.NewBorder(header, nil, nil, nil, scroll), mainWindow)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// exportAnonymizer is loaded on first use; in-app views never use it.
+var exportAnonymizer *state.Anonymizer
+
+func loadExportAnonymizer() (*state.Anonymizer, error) {
+	if exportAnonymizer != nil {
+		return exportAnonymizer, nil
+	}
+	if err := store.EnsureLogDir(); err != nil {
+		return nil, err
+	}
+	anon, err := state.LoadAnonymizer(exportSaltFile)
+	if err != nil {
+		return nil, err
+	}
+	exportAnonymizer = anon
+	return anon, nil
+}
+
+// newAnonymizeCheck is the per-export toggle, starting from the setting.
+func newAnonymizeCheck(onChanged func(bool)) *widget.Check {
+	check := widget.NewCheck("Anonymize IDs and names", onChanged)
+	check.Checked = appSettings.AnonymizeExports
+	return check
+}
+
+// exportAnonymizerFor returns the anonymizer when on is set, or nil for a
+// plain export.
+func exportAnonymizerFor(on bool) (*state.Anonymizer, error) {
+	if !on {
+		return nil, nil
+	}
+	return loadExportAnonymizer()
+}
+
+func showRotateSaltDialog() {
+	dialog.ShowConfirm("Rotate Salt",
+		"Anonymized IDs in new exports will no longer match earlier exports. Rows already in Google Sheets today are added again under the new IDs. Continue?",
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := store.EnsureLogDir(); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			anon, err := state.RotateAnonymizerSalt(exportSaltFile)
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			exportAnonymizer = anon
+			if err := configureSheetsMirror(); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+		}, mainWindow)
+}
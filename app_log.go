@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/applog"
+)
+
+// appLogTailLines is how much of log/app.log the Diagnostics viewer shows.
+const appLogTailLines = 300
+
+var logLevelOptions = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// showAppLogDialog shows the end of log/app.log, newest at the bottom, for
+// staff to copy when asking for help.
+func showAppLogDialog() {
+	lines, err := applog.Tail(logDir, appLogTailLines)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("read application log: %w", err), mainWindow)
+		return
+	}
+	text := strings.Join(lines, "\n")
+	view := widget.NewMultiLineEntry()
+	view.SetText(text)
+	view.TextStyle = fyne.TextStyle{Monospace: true}
+	view.Wrapping = fyne.TextWrapOff
+	view.CursorRow = len(lines)
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(text)
+	})
+	header := widget.NewLabel(fmt.Sprintf("Last %d lines of %s/%s", len(lines), logDir, applog.FileName))
+	content := container.NewBorder(header, container.NewHBox(copyButton), nil, nil, view)
+	dlg := dialog.NewCustom("Application Log", "Close", content, mainWindow)
+	dlg.Resize(fyne.NewSize(760, 520))
+	dlg.Show()
+}
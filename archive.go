@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// runLogArchival archives in the background and refreshes the date picker.
+func runLogArchival() {
+	go func() {
+		if _, err := store.ArchiveOldLogs(appSettings.ArchiveAfterDays, nil); err != nil {
+			slog.Error("archiving logs", "err", err)
+		}
+		fyne.Do(refreshLogDateOptions)
+	}()
+}
+
+func showCompactLogsDialog() {
+	bar := widget.NewProgressBar()
+	status := widget.NewLabel("Archiving old daily logs...")
+	dlg := dialog.NewCustomWithoutButtons("Compact Logs", container.NewVBox(status, bar), mainWindow)
+	dlg.Resize(fyne.NewSize(360, dlg.MinSize().Height))
+	dlg.Show()
+	days := appSettings.ArchiveAfterDays
+	go func() {
+		count, err := store.ArchiveOldLogs(days, func(done, total int) {
+			fyne.Do(func() { bar.SetValue(float64(done) / float64(total)) })
+		})
+		fyne.Do(func() {
+			dlg.Hide()
+			refreshLogDateOptions()
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			dialog.ShowInformation("Compact Logs", fmt.Sprintf("Archived %d daily log file(s).", count), mainWindow)
+		})
+	}()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+
+	"lounge/internal/state"
+)
+
+// awayShown is whether the map showed an away badge on the last tick, so it
+// is redrawn once more when the last one lapses.
+var awayShown bool
+
+// deviceAwayUntil returns when the away flag of a user on deviceID lapses, the
+// latest if several players on a console are away, or false when none is.
+func deviceAwayUntil(deviceID int, now time.Time) (time.Time, bool) {
+	var latest time.Time
+	for _, u := range store.UsersOnDevice(deviceID) {
+		if until, ok := store.AwayUntil(u, now); ok && until.After(latest) {
+			latest = until
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// awayText is what lists show after an away user, e.g. "away until 14:32",
+// or "" when they are not away.
+func awayText(u state.User, now time.Time) string {
+	until, ok := store.AwayUntil(u, now)
+	if !ok {
+		return ""
+	}
+	return "away until " + formatClock(until)
+}
+
+// newAwayBadge is the clock drawn on a device whose user stepped away.
+func newAwayBadge() *canvas.Image {
+	badge := canvas.NewImageFromResource(theme.NewColoredResource(theme.HistoryIcon(), theme.ColorNameWarning))
+	badge.FillMode = canvas.ImageFillContain
+	badge.Hide()
+	return badge
+}
+
+// awayBadgeBounds is where the away badge sits on a device icon: its top left
+// corner, opposite the status marker. Tapping it clears the flag.
+func awayBadgeBounds(center fyne.Position, size float32) (fyne.Position, fyne.Size) {
+	side := clampFloat(size*0.3, 14, 26)
+	return fyne.NewPos(center.X-size/2-side/4, center.Y-size/2-side/4), fyne.NewSize(side, side)
+}
+
+func (renderer *deviceStatusRenderer) updateAwayBadge(device state.Device, badge *canvas.Image, center fyne.Position, size float32) {
+	if _, away := deviceAwayUntil(device.ID, time.Now()); !away || renderer.widget.blankMap || renderer.widget.hideOccupants {
+		badge.Hide()
+		return
+	}
+	pos, badgeSize := awayBadgeBounds(center, size)
+	badge.Resize(badgeSize)
+	badge.Move(pos)
+	badge.Show()
+	badge.Refresh()
+}
+
+// tappedAwayBadge clears the away flags on the device whose badge was tapped
+// and reports whether one was.
+func (layoutWidget *DeviceStatusLayoutWidget) tappedAwayBadge(pos fyne.Position) bool {
+	now := time.Now()
+	for _, device := range store.Devices {
+		if _, away := deviceAwayUntil(device.ID, now); !away {
+			continue
+		}
+		topLeft, size := awayBadgeBounds(layoutWidget.positionForDevice(device.ID), layoutWidget.iconSizeForDevice(device.ID))
+		if pos.X < topLeft.X || pos.X > topLeft.X+size.Width || pos.Y < topLeft.Y || pos.Y > topLeft.Y+size.Height {
+			continue
+		}
+		if readOnly {
+			showReadOnlyNotice()
+			return true
+		}
+		for _, u := range store.UsersOnDevice(device.ID) {
+			if err := store.ClearAway(u.ID); err != nil {
+				dialog.ShowError(err, mainWindow)
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// refreshAwayBadges redraws the map while anyone is away, so badges vanish
+// when their flag lapses. It runs every second.
+func refreshAwayBadges() {
+	now := time.Now()
+	away := false
+	for _, u := range store.ActiveUsers {
+		if _, ok := store.AwayUntil(u, now); ok {
+			away = true
+			break
+		}
+	}
+	if (away || awayShown) && deviceLayoutWidget != nil {
+		deviceLayoutWidget.Refresh()
+	}
+	awayShown = away
+}
+
+// markSelfAway holds a member's device while they step out, from the kiosk.
+// The member is looked up when they tap Away, not when the dialog opened.
+func markSelfAway(userID string) {
+	found := store.UserByID(userID)
+	if found == nil {
+		showNoLongerCheckedIn(userID)
+		return
+	}
+	u, now := *found, time.Now()
+	if err := store.MarkAway(u.ID, now); err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	u.AwaySince = now
+	until, _ := store.AwayUntil(u, now)
+	dialog.ShowInformation("Away", fmt.Sprintf("Got it, %s - %s is yours until %s. Your session keeps running.",
+		firstLastNonEmpty(u.Name), deviceNameByID(u.PCID), formatClock(until)), mainWindow)
+}
+
+// awayWarning is added to a checkout question when the user stepped away,
+// since they are most likely coming back.
+func awayWarning(userID string) string {
+	u := store.UserByID(userID)
+	if u == nil {
+		return ""
+	}
+	if until, ok := store.AwayUntil(*u, time.Now()); ok {
+		return fmt.Sprintf("\n\nThey marked themselves away until %s and should be back soon.", formatClock(until))
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// backupStatus is how the backups of this run went, for Diagnostics.
+var backupStatus struct {
+	mu      sync.Mutex
+	running bool
+	failed  time.Time
+	err     error
+}
+
+// runBackup copies the data files to a timestamped folder under BackupDir
+// and prunes old backups, then passes the outcome to done (if not nil) on
+// the UI goroutine. The snapshot is taken here, on the UI goroutine, so the files agree with
+// each other; the copy to what may be a slow network drive runs in the
+// background. It does nothing when no destination is set or the desk is
+// read-only.
+func runBackup(reason string, done func(error)) {
+	finish := func(err error) {
+		if err != nil {
+			slog.Error("backing up data files", "reason", reason, "err", err)
+			backupStatus.mu.Lock()
+			backupStatus.failed, backupStatus.err = time.Now(), err
+			backupStatus.mu.Unlock()
+		}
+		if done != nil {
+			done(err)
+		}
+	}
+	dest := appSettings.BackupDir
+	if dest == "" || readOnly {
+		finish(nil)
+		return
+	}
+	backupStatus.mu.Lock()
+	if backupStatus.running {
+		backupStatus.mu.Unlock()
+		if done != nil {
+			done(fmt.Errorf("a backup is already running"))
+		}
+		return
+	}
+	backupStatus.running = true
+	backupStatus.mu.Unlock()
+
+	if err := deviceLayout.flush(); err != nil {
+		writeFailed("saving device layout", err)
+	}
+	snap, err := store.SnapshotForBackup(time.Now(), deviceLayoutFile, settingsFile)
+	if err != nil {
+		backupStatus.mu.Lock()
+		backupStatus.running = false
+		backupStatus.mu.Unlock()
+		finish(err)
+		return
+	}
+	keepDays := appSettings.BackupKeepDays
+	go func() {
+		dir, err := state.WriteBackup(dest, snap)
+		if err == nil {
+			slog.Info("backed up data files", "reason", reason, "dir", dir)
+			if _, pruneErr := state.PruneBackups(dest, keepDays, snap.Time); pruneErr != nil {
+				err = pruneErr
+			}
+		}
+		backupStatus.mu.Lock()
+		backupStatus.running = false
+		backupStatus.mu.Unlock()
+		fyne.Do(func() { finish(err) })
+	}()
+}
+
+// backupChecks reports the newest backup and any failure since, for
+// Diagnostics.
+func backupChecks() []diagnosticCheck {
+	if appSettings.BackupDir == "" {
+		return nil
+	}
+	check := diagnosticCheck{Name: "Last backup", Detail: "none yet",
+		Hint: "Check that the backup folder exists and this computer can write to it, then use Back up now in Settings."}
+	latest, err := state.LatestBackup(appSettings.BackupDir)
+	switch {
+	case err != nil:
+		check.Detail = err.Error()
+	case !latest.IsZero():
+		check.Detail = fmt.Sprintf("%s in %s", formatDateTime(latest), absPath(appSettings.BackupDir))
+		check.OK = true
+	}
+	backupStatus.mu.Lock()
+	failed, failErr := backupStatus.failed, backupStatus.err
+	backupStatus.mu.Unlock()
+	checks := []diagnosticCheck{check}
+	if failErr != nil && failed.After(latest) {
+		checks = append(checks, diagnosticCheck{Name: "Backup failed",
+			Detail: fmt.Sprintf("%s: %v", formatDateTime(failed), failErr), Hint: check.Hint})
+	}
+	return checks
+}
+
+func showBackupNowDialog() {
+	if appSettings.BackupDir == "" {
+		dialog.ShowError(fmt.Errorf("set a backup folder first"), mainWindow)
+		return
+	}
+	progress := dialog.NewCustomWithoutButtons("Backup", widget.NewLabel("Backing up to "+appSettings.BackupDir+"..."), mainWindow)
+	progress.Show()
+	runBackup("on demand", func(err error) {
+		progress.Hide()
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		dialog.ShowInformation("Backup", "The data files are backed up.", mainWindow)
+	})
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// The queue check-in form is built once and moved, not rebuilt, so what
+// staff have typed survives the device tab's refreshes and the panel being
+// hidden. On the Device Status tab it sits above the queue; on the other
+// tabs it slides over the right edge of the window.
+var (
+	checkInPanel fyne.CanvasObject
+	// checkInPanelSlot is the device tab's place for the panel, replaced on
+	// every rebuild of the tab.
+	checkInPanelSlot *fyne.Container
+	// checkInSlideOver holds the panel over the other tabs.
+	checkInSlideOver *fyne.Container
+	deviceTabActive  = true
+)
+
+// checkInPanelShown reports the Check-In Panel toggle.
+func checkInPanelShown() bool { return !appSettings.HideCheckInPanel }
+
+func checkInPanelContent() fyne.CanvasObject {
+	if checkInPanel == nil {
+		checkInPanel = buildInlineCheckInForm()
+	}
+	return checkInPanel
+}
+
+// rebuildCheckInPanel builds the form again for changed settings, keeping
+// the name and ID typed so far.
+func rebuildCheckInPanel() {
+	if checkInPanel == nil {
+		return
+	}
+	name, id := checkInNameEntry.Text, checkInIDEntry.Text
+	checkInPanel = nil
+	checkInPanelContent()
+	checkInNameEntry.SetText(name)
+	checkInIDEntry.SetText(id)
+	placeCheckInPanel()
+}
+
+// newCheckInPanelSlot is the device tab's place for the panel.
+func newCheckInPanelSlot() *fyne.Container {
+	checkInPanelSlot = container.NewVBox()
+	placeCheckInPanel()
+	return checkInPanelSlot
+}
+
+// newCheckInSlideOver lays the panel over content when another tab is
+// showing.
+func newCheckInSlideOver(content fyne.CanvasObject) fyne.CanvasObject {
+	checkInSlideOver = container.New(&slideOverLayout{width: compactDrawerWidth})
+	placeCheckInPanel()
+	return container.NewStack(content, checkInSlideOver)
+}
+
+// setDeviceTabActive moves the panel when the selected tab changes.
+func setDeviceTabActive(active bool) {
+	deviceTabActive = active
+	placeCheckInPanel()
+}
+
+// setCheckInPanelShown is the Check-In Panel toggle and the form's Hide
+// button. The setting lasts across restarts.
+func setCheckInPanelShown(shown bool) {
+	appSettings.HideCheckInPanel = !shown
+	if err := saveSettings(); err != nil {
+		writeFailed("saving settings", err)
+	}
+	placeCheckInPanel()
+	if shown && checkInSearchEntry != nil && mainWindow != nil {
+		mainWindow.Canvas().Focus(checkInSearchEntry)
+	}
+}
+
+func toggleCheckInPanel() { setCheckInPanelShown(!checkInPanelShown()) }
+
+// placeCheckInPanel puts the panel where the toggle and tab say it goes.
+func placeCheckInPanel() {
+	shown := checkInPanelShown()
+	if checkInPanelSlot != nil {
+		checkInPanelSlot.RemoveAll()
+		if shown && deviceTabActive {
+			checkInPanelSlot.Add(checkInPanelContent())
+			checkInPanelSlot.Add(widget.NewSeparator())
+		}
+	}
+	if checkInSlideOver != nil {
+		checkInSlideOver.RemoveAll()
+		if shown && !deviceTabActive {
+			checkInSlideOver.Add(newCompactDrawer(container.NewVScroll(container.NewPadded(checkInPanelContent()))))
+		}
+	}
+	if button := toolbarActionButtons["check-in-panel"]; button != nil {
+		button.Importance = widget.MediumImportance
+		if shown {
+			button.Importance = widget.HighImportance
+		}
+		button.Refresh()
+	}
+}
+
+// slideOverLayout holds its one object against the right edge, full
+// height, leaving the rest of the window to take taps.
+type slideOverLayout struct {
+	width float32
+}
+
+func (l *slideOverLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	if len(objects) == 0 {
+		return
+	}
+	width := l.width
+	if width > size.Width {
+		width = size.Width
+	}
+	objects[0].Resize(fyne.NewSize(width, size.Height))
+	objects[0].Move(fyne.NewPos(size.Width-width, 0))
+}
+
+func (l *slideOverLayout) MinSize([]fyne.CanvasObject) fyne.Size { return fyne.NewSize(0, 0) }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lounge/internal/state"
+)
+
+// deviceChoice is how a device is listed in the check-in dialog's device
+// field, e.g. "7 (PC)" or "18 (PS5)"; checkDeviceField reads the ID back
+// from it.
+func deviceChoice(d state.Device) string {
+	if d.Label != "" {
+		return fmt.Sprintf("%d (%s)", d.ID, d.Label)
+	}
+	return fmt.Sprintf("%d (%s)", d.ID, d.Type)
+}
+
+// freeDeviceChoices lists the devices a user can be seated on now, PCs first,
+// then consoles, then any other type, each by ID. Consoles take several
+// players, so only maintenance takes them off the list.
+func freeDeviceChoices() []string {
+	rank := func(t string) int {
+		switch t {
+		case "PC":
+			return 0
+		case "Console":
+			return 1
+		}
+		return 2
+	}
+	var free []state.Device
+	for _, d := range store.Devices {
+		if d.Status == state.StatusMaintenance || (d.Type == "PC" && d.Status != "free") {
+			continue
+		}
+		free = append(free, d)
+	}
+	sort.SliceStable(free, func(i, j int) bool {
+		if rank(free[i].Type) != rank(free[j].Type) {
+			return rank(free[i].Type) < rank(free[j].Type)
+		}
+		return free[i].ID < free[j].ID
+	})
+	choices := make([]string, len(free))
+	for i, d := range free {
+		choices[i] = deviceChoice(d)
+	}
+	return choices
+}
+
+// shortUserName is a name as the device field shows it, e.g. "Ana L." for
+// "Ana Lopez".
+func shortUserName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return name
+	}
+	last := []rune(parts[len(parts)-1])
+	return fmt.Sprintf("%s %c.", parts[0], last[0])
+}
+
+// checkDeviceField reads the check-in dialog's device field, typed or chosen,
+// and returns the device ID when a user can be seated there, or why not.
+// Empty text returns 0 and no problem.
+func checkDeviceField(text string) (int, string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0, ""
+	}
+	id, err := strconv.Atoi(fields[0])
+	if err != nil || (len(fields) > 1 && strings.TrimSpace(text) != deviceChoiceFor(id)) {
+		return 0, "Enter a device number"
+	}
+	device := store.DeviceByID(id)
+	switch {
+	case device == nil:
+		return 0, "No such device"
+	case device.Status == state.StatusMaintenance:
+		return 0, device.Name() + " is under maintenance"
+	case device.Type == "PC" && device.Status != "free":
+		if user := store.UserByID(device.UserID); user != nil {
+			return 0, fmt.Sprintf("PC %d is occupied by %s", device.ID, shortUserName(user.Name))
+		}
+		return 0, fmt.Sprintf("PC %d is occupied", device.ID)
+	}
+	return id, ""
+}
+
+// deviceChoiceFor is deviceChoice by ID, or "" for an unknown device.
+func deviceChoiceFor(id int) string {
+	if device := store.DeviceByID(id); device != nil {
+		return deviceChoice(*device)
+	}
+	return ""
+}
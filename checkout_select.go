@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// checkoutLabel describes u for a checkout selector: name, ID, where they
+// are and for how long, so two people with the same name are told apart.
+func checkoutLabel(u state.User, now time.Time) string {
+	name := u.Name
+	if len(name) > 25 {
+		name = name[:22] + "..."
+	}
+	where := "queue"
+	if u.PCID != 0 {
+		where = deviceNameByID(u.PCID)
+	}
+	label := fmt.Sprintf("%s (ID: %s) - %s, %s", name, u.ID, where, state.FormatDuration(now.Sub(u.CheckInTime)))
+	if away := awayText(u, now); away != "" {
+		label += " - " + away
+	}
+	return label
+}
+
+// newCheckoutSelector lists users and a Check Out button that passes the
+// picked user's ID to checkOut. The choice maps to a user by its position in
+// the list, never by its text, and Check Out stays disabled until someone is
+// picked.
+func newCheckoutSelector(users []state.User, now time.Time, checkOut func(userID string)) (*widget.Select, *widget.Button) {
+	labels := make([]string, len(users))
+	for i, u := range users {
+		labels[i] = checkoutLabel(u, now)
+	}
+	var confirmButton *widget.Button
+	selector := widget.NewSelect(labels, func(string) { confirmButton.Enable() })
+	selector.PlaceHolder = "Select User to Check Out"
+	confirmButton = widget.NewButtonWithIcon("Check Out", theme.ConfirmIcon(), func() {
+		if i := selector.SelectedIndex(); i >= 0 {
+			checkOut(users[i].ID)
+		}
+	})
+	confirmButton.Importance = widget.HighImportance
+	confirmButton.Disable()
+	return selector, confirmButton
+}
+
+// showCheckoutPicker asks which of users to check out. deviceID is the
+// tapped device, or 0.
+func showCheckoutPicker(title, field string, users []state.User, deviceID int) {
+	var dlg *dialog.CustomDialog
+	selector, confirmButton := newCheckoutSelector(users, time.Now(), func(target string) {
+		dlg.Hide()
+		requireStaffPIN("Check Out", func() {
+			if err := store.Checkout(target); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+		})
+	})
+	form := widget.NewForm(widget.NewFormItem(field, selector))
+	dlg = dialog.NewCustomWithoutButtons(title, container.NewPadded(form), mainWindow)
+	cancelButton := widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), dlg.Hide)
+	dlg.SetButtons([]fyne.CanvasObject{cancelButton, confirmButton})
+	if deviceID != 0 {
+		trackDeviceDialog(deviceID, dlg)
+	}
+	dlg.Resize(fyne.NewSize(480, dlg.MinSize().Height))
+	dlg.Show()
+}
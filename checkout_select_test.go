@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+
+	"lounge/internal/state"
+)
+
+func TestCheckoutSelectorTellsTwinsApart(t *testing.T) {
+	test.NewTempApp(t)
+	savedStore := store
+	t.Cleanup(func() { store = savedStore })
+	store = state.NewStore(t.TempDir(), "")
+	store.Devices = []state.Device{{ID: 17, Type: "Console", Status: "occupied"}}
+	now := time.Now()
+	twins := []state.User{
+		{ID: "2001", Name: "Sam Lee", PCID: 17, CheckInTime: now.Add(-40 * time.Minute)},
+		{ID: "2002", Name: "Sam Lee", PCID: 17, CheckInTime: now.Add(-10 * time.Minute)},
+	}
+	store.ActiveUsers = twins
+
+	var checkedOut []string
+	selector, confirm := newCheckoutSelector(twins, now, func(userID string) { checkedOut = append(checkedOut, userID) })
+	if selector.Options[0] == selector.Options[1] {
+		t.Fatalf("both twins are listed as %q", selector.Options[0])
+	}
+	if !confirm.Disabled() {
+		t.Fatal("Check Out is enabled before anyone is picked")
+	}
+	test.Tap(confirm)
+	if len(checkedOut) != 0 {
+		t.Fatalf("checked out %v with nobody picked", checkedOut)
+	}
+
+	selector.SetSelectedIndex(1)
+	if confirm.Disabled() {
+		t.Fatal("Check Out is still disabled after picking")
+	}
+	test.Tap(confirm)
+	selector.SetSelectedIndex(0)
+	test.Tap(confirm)
+	if len(checkedOut) != 2 || checkedOut[0] != "2002" || checkedOut[1] != "2001" {
+		t.Errorf("checked out %v, want 2002 then 2001", checkedOut)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+var (
+	// cleaningColor marks a device during its cleaning cooldown: its
+	// countdown, and at low alpha the tint behind its icon.
+	cleaningColor = color.NRGBA{R: 23, G: 146, B: 153, A: 255}
+	cleaningTint  = color.NRGBA{R: 23, G: 146, B: 153, A: 60}
+)
+
+// cleaningShown is whether the map showed a cleaning countdown on the last
+// tick, so it is redrawn once more when the last one ends.
+var cleaningShown bool
+
+// cleaningLabel is the countdown under a device being cleaned, e.g.
+// "cleaning 4m12s"; ok is false when it is not being cleaned.
+func cleaningLabel(deviceID int, now time.Time) (text string, ok bool) {
+	until, ok := store.CleaningUntil(deviceID, now)
+	if !ok {
+		return "", false
+	}
+	return "cleaning " + state.FormatDuration(until.Sub(now)), true
+}
+
+// refreshCleaningCountdowns keeps the cleaning countdowns on the map current.
+// It runs every second.
+func refreshCleaningCountdowns() {
+	now := time.Now()
+	cleaning := false
+	for _, device := range store.Devices {
+		if _, ok := store.CleaningUntil(device.ID, now); ok {
+			cleaning = true
+			break
+		}
+	}
+	if (cleaning || cleaningShown) && deviceLayoutWidget != nil {
+		deviceLayoutWidget.Refresh()
+	}
+	cleaningShown = cleaning
+}
+
+// cleanedEarlyButton ends a device's cleaning cooldown from its context menu,
+// or is nil when it is not being cleaned.
+func cleanedEarlyButton(device state.Device, onDone func()) fyne.CanvasObject {
+	if _, ok := store.CleaningUntil(device.ID, time.Now()); !ok {
+		return nil
+	}
+	return widget.NewButton("Cleaned Early", func() {
+		onDone()
+		if err := store.ClearCleaning(device.ID); err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"lounge/internal/state"
+)
+
+// Environment variables that stand in for -data-dir and -config, for
+// launchers that cannot pass flags.
+const (
+	dataDirEnv = "LOUNGE_DATA_DIR"
+	configEnv  = "LOUNGE_CONFIG"
+)
+
+// commandUsage follows the flag list in -help.
+const commandUsage = `
+Commands (run without the window, e.g. from cron):
+  lounge [flags] export -date 2025-03-01 [-format csv|json] [-anonymize]
+        write a day's log to stdout, anonymized when -anonymize or the
+        "Anonymize exports by default" setting is on
+  lounge [flags] report -from 2025-03-01 -to 2025-03-31
+        write the usage report for a range of days to stdout as CSV
+  lounge [flags] stats -from 2025-01-01 -to 2025-12-31
+        write the Stats figures for a range of days to stdout as JSON
+        (schema_version 1; see state.StatsExport)
+`
+
+// headlessCommands are the subcommands that do not start the GUI.
+var headlessCommands = map[string]func(args []string, out io.Writer) error{
+	"export": runExportCommand,
+	"report": runReportCommand,
+	"stats":  runStatsCommand,
+}
+
+// usePaths applies -data-dir and -config, falling back to their environment
+// variables. The config path wins over the one under the data directory.
+func usePaths(dataDir, config string) {
+	if dataDir != "" {
+		useDataRoot(dataDir)
+	}
+	if config != "" {
+		settingsFile = config
+	}
+}
+
+// runCommand runs the subcommand in args and returns the exit code.
+func runCommand(args []string) int {
+	run, ok := headlessCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "lounge: unknown command %q\n", args[0])
+		flag.Usage()
+		return 2
+	}
+	initHeadlessStore()
+	if err := run(args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "lounge %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+// initHeadlessStore loads the settings, the members (to tell members,
+// guests and staff apart) and a read-only store, so a command never writes
+// to the data files a running desk is using.
+func initHeadlessStore() {
+	store = state.NewStore(logDir, memberFile)
+	store.ReadOnly = true
+	loadSettings()
+	applySettings()
+	store.LoadMembers()
+}
+
+// parseCommandDate reads a -date, -from or -to value; blank means today.
+func parseCommandDate(name, value string) (string, error) {
+	if value == "" {
+		return state.TodaysLogDate(), nil
+	}
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		return "", fmt.Errorf("-%s must be a date like 2025-03-01", name)
+	}
+	return value, nil
+}
+
+func runExportCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	date := flags.String("date", "", "the day to export, e.g. 2025-03-01 (default today)")
+	format := flags.String("format", "csv", "csv (every column) or json (the log as stored)")
+	anonymize := flags.Bool("anonymize", appSettings.AnonymizeExports, "replace IDs and names as in-app exports do (default from the settings)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	day, err := parseCommandDate("date", *date)
+	if err != nil {
+		return err
+	}
+	entries, err := store.ReadLogEntries(day)
+	if err != nil {
+		return err
+	}
+	anon, err := exportAnonymizerFor(*anonymize)
+	if err != nil {
+		return err
+	}
+	if anon != nil {
+		entries = anon.Entries(entries)
+	}
+	switch *format {
+	case "csv":
+		w := csv.NewWriter(out)
+		return w.WriteAll(logExportRows(entries, true))
+	case "json":
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", data)
+		return err
+	}
+	return fmt.Errorf("unknown -format %q; use csv or json", *format)
+}
+
+func runReportCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	from := flags.String("from", "", "first day, e.g. 2025-03-01 (default today)")
+	to := flags.String("to", "", "last day, inclusive (default today)")
+	includeTest := flags.Bool("include-test", false, "count staff and test sessions")
+	flags.BoolVar(&statsIncludeLounge, "lounge", false, "count lounge visitors without a device")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	start, err := parseCommandDate("from", *from)
+	if err != nil {
+		return err
+	}
+	end, err := parseCommandDate("to", *to)
+	if err != nil {
+		return err
+	}
+	if start > end {
+		return fmt.Errorf("-from %s is after -to %s", start, end)
+	}
+	all, err := store.ReadLogEntriesInRange(start, end)
+	if err != nil {
+		return err
+	}
+	store.ClassifyVisitors(all)
+	statsIncludeTest = *includeTest
+	entries, excluded := state.StatsEntries(all, true), 0
+	if !statsIncludeTest {
+		entries, excluded = state.StatsEntries(all, false), state.TestSessions(all)
+	}
+	term := state.Term{Name: fmt.Sprintf("%s..%s", start, end), Start: start, End: end}
+	var openDays [][2]string
+	if start != end {
+		openDays = termOpenDayRows(term, entries)
+	}
+	w := csv.NewWriter(out)
+	return w.WriteAll(rangeReportRows(term.Name, openDays, entries, excluded))
+}
+
+func runStatsCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	from := flags.String("from", "", "first day, e.g. 2025-01-01 (default January 1 this year)")
+	to := flags.String("to", "", "last day, inclusive (default today)")
+	includeTest := flags.Bool("include-test", false, "count staff and test sessions")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	data, err := statsJSON(store, *from, *to, *includeTest)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", data)
+	return err
+}
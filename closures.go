@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// parseClosures reads one "Label: date" or "Label: start..end" closure per
+// non-blank line.
+func parseClosures(text string) ([]state.Closure, error) {
+	var closures []state.Closure
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		closure, err := state.ParseClosure(line)
+		if err != nil {
+			return nil, err
+		}
+		closures = append(closures, closure)
+	}
+	return closures, nil
+}
+
+// newClosureBanner tells staff on the Device Status tab that the calendar has
+// the lounge closed today, so a check-in for a special event is knowingly
+// overriding it. The tab is rebuilt on every change and at midnight.
+func newClosureBanner() fyne.CanvasObject {
+	closure, closed := store.ClosureOn(time.Now().Format("2006-01-02"))
+	if !closed {
+		return layout.NewSpacer()
+	}
+	text := fmt.Sprintf("Closed today: %s. Check-ins override the closures calendar and need confirming.", closure.Label)
+	if store.Event != "" {
+		text = fmt.Sprintf("Closed today: %s. %s is running, so check-ins override the closures calendar.", closure.Label, store.Event)
+	}
+	message := widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	message.Wrapping = fyne.TextWrapWord
+	return container.NewBorder(nil, nil, widget.NewIcon(theme.WarningIcon()), nil, message)
+}
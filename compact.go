@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Compact mode is for laptops and small desk screens: the check-in form and
+// queue fold into a drawer over the map, and the map packs its devices
+// closer together.
+const (
+	compactAutomatic = "Automatic"
+	compactAlways    = "Always"
+	compactNever     = "Never"
+
+	// compactBelowWidth is the room width under which Automatic goes compact.
+	compactBelowWidth float32 = 1000
+	// compactDrawerWidth is the widest the check-in drawer gets.
+	compactDrawerWidth float32 = 420
+)
+
+var compactModeOptions = []string{compactAutomatic, compactAlways, compactNever}
+
+var (
+	// compactDrawerOpen survives the rebuild of the device tab, so a refresh
+	// does not snap the drawer shut under staff.
+	compactDrawerOpen   bool
+	compactDrawerButton *widget.Button
+)
+
+// compactModeSetting is the CompactMode setting, where empty is Automatic.
+func compactModeSetting() string {
+	switch appSettings.CompactMode {
+	case compactAlways, compactNever:
+		return appSettings.CompactMode
+	}
+	return compactAutomatic
+}
+
+// newCompactBar holds the drawer button above the map; roomLayout shows it
+// only in compact mode.
+func newCompactBar() fyne.CanvasObject {
+	compactDrawerButton = widget.NewButtonWithIcon("Check In & Queue", theme.MenuIcon(), nil)
+	bar := container.NewHBox(compactDrawerButton)
+	bar.Hide()
+	return bar
+}
+
+// newCompactDrawer backs the left pane so it reads as a panel when it opens
+// over the map.
+func newCompactDrawer(pane fyne.CanvasObject) fyne.CanvasObject {
+	return container.NewStack(canvas.NewRectangle(theme.Color(theme.ColorNameBackground)), pane)
+}
+
+func refreshCompactDrawerButton() {
+	if compactDrawerButton == nil {
+		return
+	}
+	if compactDrawerOpen {
+		compactDrawerButton.SetText("Hide Check In & Queue")
+	} else {
+		compactDrawerButton.SetText("Check In & Queue")
+	}
+}
+
+// roomLayout lays out the device tab: the map and, beside it, the check-in
+// form and queue. In compact mode the map takes the whole tab and the form
+// and queue open over it as a drawer. Objects are the map pane and the left
+// pane; bar sits inside the map pane.
+type roomLayout struct {
+	twoPane twoPaneLayout
+	mapView *DeviceStatusLayoutWidget
+	bar     fyne.CanvasObject
+}
+
+func (l *roomLayout) isCompact(size fyne.Size) bool {
+	switch compactModeSetting() {
+	case compactAlways:
+		return true
+	case compactNever:
+		return false
+	}
+	return size.Width < compactBelowWidth
+}
+
+func (l *roomLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	if len(objects) < 2 {
+		return
+	}
+	mapPane, left, bar := objects[0], objects[1], l.bar
+	compact := l.isCompact(size)
+	l.mapView.setCompact(compact)
+	if !compact {
+		bar.Hide()
+		left.Show()
+		l.twoPane.Layout([]fyne.CanvasObject{left, mapPane}, size)
+		return
+	}
+	bar.Show()
+	mapPane.Resize(size)
+	mapPane.Move(fyne.NewPos(0, 0))
+	if !compactDrawerOpen {
+		left.Hide()
+		return
+	}
+	width := compactDrawerWidth
+	if width > size.Width {
+		width = size.Width
+	}
+	top := bar.MinSize().Height + theme.Padding()
+	left.Resize(fyne.NewSize(width, size.Height-top))
+	left.Move(fyne.NewPos(0, top))
+	left.Show()
+}
+
+// MinSize lets the window shrink into compact mode unless it is switched
+// off.
+func (l *roomLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	if len(objects) < 2 {
+		return fyne.NewSize(0, 0)
+	}
+	if compactModeSetting() == compactNever {
+		return l.twoPane.MinSize([]fyne.CanvasObject{objects[1], objects[0]})
+	}
+	return objects[0].MinSize()
+}
+
+// newRoomContent puts the map pane and left pane in a roomLayout and wires
+// the drawer button to it.
+func newRoomContent(mapView *DeviceStatusLayoutWidget, mapPane, left, bar fyne.CanvasObject) fyne.CanvasObject {
+	room := container.New(&roomLayout{
+		twoPane: twoPaneLayout{leftRatio: 0.30, leftMin: 340, leftMax: 520},
+		mapView: mapView,
+		bar:     bar,
+	}, mapPane, left)
+	compactDrawerButton.OnTapped = func() {
+		compactDrawerOpen = !compactDrawerOpen
+		refreshCompactDrawerButton()
+		room.Refresh()
+	}
+	refreshCompactDrawerButton()
+	return room
+}
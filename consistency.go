@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// checkConsistency shows the consistency dialog when devices and active
+// users disagree; with quiet set it says nothing when they agree.
+func checkConsistency(quiet bool) {
+	if len(store.CheckConsistency()) == 0 {
+		if !quiet {
+			dialog.ShowInformation("Check Occupancy", "Every occupied device has its user checked in, and every checked-in user is on their device.", mainWindow)
+		}
+		return
+	}
+	showConsistencyDialog()
+}
+
+// consistencyCheck is the Diagnostics line for the same check.
+func consistencyCheck() diagnosticCheck {
+	found := store.CheckConsistency()
+	if len(found) == 0 {
+		return diagnosticCheck{Name: "Device occupancy", Detail: "matches the checked-in users", OK: true}
+	}
+	return diagnosticCheck{Name: "Device occupancy", Detail: fmt.Sprintf("%d mismatch(es) between devices and checked-in users", len(found)),
+		Hint: "Choose Check occupancy to repair them."}
+}
+
+// showConsistencyDialog lists each discrepancy with a button per repair. The
+// list is checked again after every repair, since one fix can settle another.
+func showConsistencyDialog() {
+	rows := container.NewVBox()
+	var rebuild func()
+	rebuild = func() {
+		rows.Objects = nil
+		found := store.CheckConsistency()
+		if len(found) == 0 {
+			rows.Add(widget.NewLabel("Everything matches now."))
+		}
+		for _, d := range found {
+			detail := widget.NewLabel(d.Detail)
+			detail.Wrapping = fyne.TextWrapWord
+			buttons := container.NewHBox()
+			for _, repair := range d.Repairs {
+				button := widget.NewButton(string(repair), func() {
+					if err := store.RepairDiscrepancy(d, repair); err != nil {
+						dialog.ShowError(err, mainWindow)
+					}
+					rebuild()
+				})
+				if repair == state.RepairDropUser {
+					button.Importance = widget.DangerImportance
+				}
+				disableWhenReadOnly(button)
+				buttons.Add(button)
+			}
+			rows.Add(container.NewBorder(nil, nil, nil, buttons, detail))
+		}
+		rows.Refresh()
+	}
+	rebuild()
+	intro := widget.NewLabel("These devices and checked-in users disagree, usually after the app stopped while saving. " +
+		"Each repair is recorded in log/repairs.log; dropping a user closes their session in the log.")
+	intro.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(600, 240))
+	dlg := dialog.NewCustom("Check Occupancy", "Close", container.NewBorder(intro, nil, nil, nil, scroll), mainWindow)
+	dlg.Resize(fyne.NewSize(680, 380))
+	dlg.Show()
+}
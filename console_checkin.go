@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// consoleChoiceText labels a console in the quick check-in list with how
+// many are playing, e.g. "Switch — 2 playing" or "PS5 — free".
+func consoleChoiceText(device state.Device) string {
+	switch players := len(store.ActiveUserIDsOnDevice(device.ID)); {
+	case device.Status == state.StatusMaintenance:
+		return device.Name() + " — maintenance"
+	case players == 0:
+		return device.Name() + " — free"
+	default:
+		return fmt.Sprintf("%s — %d playing", device.Name(), players)
+	}
+}
+
+// showConsoleCheckInDialog lists the consoles by name so staff need not
+// remember their numbers; picking one opens the check-in dialog fixed to it.
+func showConsoleCheckInDialog() {
+	var consoles []state.Device
+	for _, device := range store.Devices {
+		if device.Type == "Console" {
+			consoles = append(consoles, device)
+		}
+	}
+	if len(consoles) == 0 {
+		dialog.ShowInformation("Console Check-In", "The lounge has no consoles.", mainWindow)
+		return
+	}
+	var dlg dialog.Dialog
+	list := container.NewVBox()
+	for _, device := range consoles {
+		id := device.ID
+		button := widget.NewButton(consoleChoiceText(device), func() {
+			dlg.Hide()
+			showCheckInDialogShared(id, true)
+		})
+		if device.Status == state.StatusMaintenance {
+			button.Disable()
+		}
+		list.Add(button)
+	}
+	dlg = dialog.NewCustom("Console Check-In", "Cancel", list, mainWindow)
+	dlg.Resize(fyne.NewSize(360, dlg.MinSize().Height))
+	dlg.Show()
+}
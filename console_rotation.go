@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"time"
+
+	"lounge/internal/state"
+)
+
+// rotationNotified remembers the due time already announced per console, so
+// each overdue rotation sends one notification.
+var rotationNotified = make(map[int]time.Time)
+
+// rotationShown is the countdown last drawn per console, so the map is
+// redrawn only when one of them changes.
+var rotationShown = make(map[int]string)
+
+// rotationLabel is the countdown shown under a console, e.g. "rotate in 12m".
+// ok is false when the console has no timer running.
+func rotationLabel(deviceID int, now time.Time) (text string, overdue, ok bool) {
+	due, ok := store.RotationDue(deviceID)
+	if !ok {
+		return "", false, false
+	}
+	left := due.Sub(now)
+	if left <= 0 {
+		return "rotate now", true, true
+	}
+	return "rotate in " + state.FormatDuration(left.Round(time.Minute)), false, true
+}
+
+// checkConsoleRotations notifies the desk once per overdue console and keeps
+// the countdowns on the map current. It runs every second but redraws the map
+// only when a countdown's text changes.
+func checkConsoleRotations() {
+	now := time.Now()
+	shown := make(map[int]string)
+	for _, device := range store.Devices {
+		due, ok := store.RotationDue(device.ID)
+		if !ok {
+			delete(rotationNotified, device.ID)
+			continue
+		}
+		shown[device.ID], _, _ = rotationLabel(device.ID, now)
+		if due.After(now) || rotationNotified[device.ID].Equal(due) {
+			continue
+		}
+		rotationNotified[device.ID] = due
+		notify("console-rotation", "Console rotation",
+			fmt.Sprintf("Time to rotate players on %s: %s", device.Name(), occupantNames(device)))
+	}
+	if maps.Equal(shown, rotationShown) {
+		return
+	}
+	rotationShown = shown
+	if deviceLayoutWidget != nil {
+		deviceLayoutWidget.Refresh()
+	}
+}
+
+// rotationEntryLine describes a rotation entry for the log view.
+func rotationEntryLine(entry state.LogEntry) string {
+	return fmt.Sprintf("%s rotated (%s)    At: %s    Players: %s",
+		deviceNameByID(entry.PCID), entry.Note, formatDateTime(entry.CheckInTime), entry.UserName)
+}
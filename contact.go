@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"lounge/internal/state"
+)
+
+// noContactOnFile stands in for the contact details of a user without any.
+const noContactOnFile = "none on file"
+
+// callFormat lays a phone number out for reading aloud and dialling, e.g.
+// "(613) 555-0123" or "+1 (613) 555-0123"; numbers of any other length are
+// shown as entered.
+func callFormat(phone string) string {
+	var digits []rune
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			digits = append(digits, r)
+		}
+	}
+	d := string(digits)
+	switch {
+	case len(d) == 10:
+		return fmt.Sprintf("(%s) %s-%s", d[:3], d[3:6], d[6:])
+	case len(d) == 11 && d[0] == '1':
+		return fmt.Sprintf("+1 (%s) %s-%s", d[1:4], d[4:7], d[7:])
+	}
+	return strings.TrimSpace(phone)
+}
+
+// contactText is how to reach u, e.g. "(613) 555-0123 · ana@example.com",
+// or noContactOnFile.
+func contactText(u state.User) string {
+	email, phone := store.Contact(u)
+	var parts []string
+	if phone != "" {
+		parts = append(parts, callFormat(phone))
+	}
+	if email != "" {
+		parts = append(parts, email)
+	}
+	if len(parts) == 0 {
+		return noContactOnFile
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// noCourse is the course picker's choice for a session that earns no
+// course credit.
+const noCourse = "No course"
+
+// newCourseSelect builds the optional Course picker, or returns nil when no
+// course codes are configured so check-in forms leave it out.
+func newCourseSelect() *widget.Select {
+	if len(appSettings.CourseCodes) == 0 {
+		return nil
+	}
+	sel := widget.NewSelect(append([]string{noCourse}, appSettings.CourseCodes...), nil)
+	sel.PlaceHolder = "(optional)"
+	return sel
+}
+
+// selectedCourse is the course picked in sel, or "" for none.
+func selectedCourse(sel *widget.Select) string {
+	if sel == nil || sel.Selected == noCourse {
+		return ""
+	}
+	return sel.Selected
+}
+
+// lastCoursePrefill returns the function an ID entry calls as it changes to
+// preselect the course that member last checked in for, if it is still
+// offered. A course staff picked themselves is never replaced; only the
+// prefilled one follows the ID as it is typed.
+func lastCoursePrefill(sel *widget.Select) func(memberID string) {
+	prefilled := ""
+	return func(memberID string) {
+		if sel == nil || (sel.Selected != "" && sel.Selected != prefilled) {
+			return
+		}
+		last := ""
+		if member := store.MemberByID(strings.TrimSpace(memberID)); member != nil && slices.Contains(sel.Options, member.LastCourse) {
+			last = member.LastCourse
+		}
+		if last == sel.Selected {
+			return
+		}
+		prefilled = last
+		if last == "" {
+			sel.ClearSelected()
+			return
+		}
+		sel.SetSelected(last)
+	}
+}
+
+// rememberCourse makes course the default for memberID's next check-in. It
+// is kept on the member and written with the next member file flush.
+func rememberCourse(memberID, course string) {
+	if err := store.SetLastCourse(memberID, course); err != nil {
+		writeFailed("remembering the course", err)
+	}
+}
+
+func parseCourseCodes(text string) []string {
+	var out []string
+	for _, code := range strings.Split(text, ",") {
+		code = strings.TrimSpace(code)
+		if code == "" || strings.EqualFold(code, noCourse) || slices.ContainsFunc(out, func(c string) bool { return strings.EqualFold(c, code) }) {
+			continue
+		}
+		out = append(out, code)
+	}
+	return out
+}
+
+// courseReportRows is the per-student report for course over the selected
+// Stats range, with names and IDs anonymized by anon unless it is nil.
+func courseReportRows(course string, credits []state.CourseCredit, anon *state.Anonymizer) [][]string {
+	rows := [][]string{
+		{"Course hours report"},
+		{"Course", course},
+		{"Range", statsRangeTitle()},
+		{"Generated", formatLongDateTime(time.Now())},
+		{"Time zone", reportTimeZone(time.Now())},
+		{},
+		{"Name", "ID", "Student Number", "Sessions", "Hours"},
+	}
+	var sessions int
+	var total time.Duration
+	for _, credit := range credits {
+		var studentNumber string
+		if member := store.MemberByID(credit.UserID); member != nil {
+			studentNumber = member.StudentNumber
+		}
+		if anon != nil {
+			credit.UserName, credit.UserID = state.AnonymizeName(credit.UserName), anon.ID(credit.UserID)
+			studentNumber = ""
+		}
+		rows = append(rows, []string{credit.UserName, credit.UserID, studentNumber, fmt.Sprintf("%d", credit.Sessions), fmt.Sprintf("%.2f", credit.Usage.Hours())})
+		sessions += credit.Sessions
+		total += credit.Usage
+	}
+	return append(rows, []string{"Total", "", "", fmt.Sprintf("%d", sessions), fmt.Sprintf("%.2f", total.Hours())})
+}
+
+// showCourseReportDialog shows each student's sessions and hours for one
+// course over the Stats range, and saves them as CSV.
+func showCourseReportDialog() {
+	entries, _, err := statsRangeEntries()
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	courses := append([]string{}, appSettings.CourseCodes...)
+	for _, course := range state.LoggedCourses(entries) {
+		if !slices.Contains(courses, course) {
+			courses = append(courses, course)
+		}
+	}
+	if len(courses) == 0 {
+		dialog.ShowInformation("Course Hours", "No course codes are set up. Add them under Settings to tag check-ins with a course.", mainWindow)
+		return
+	}
+	bold := fyne.TextStyle{Bold: true}
+	grid := container.NewGridWithColumns(4)
+	summary := widget.NewLabel("")
+	var credits []state.CourseCredit
+	show := func(course string) {
+		credits = state.CourseCredits(entries, course, time.Now())
+		grid.RemoveAll()
+		for _, title := range []string{"Name", "ID", "Sessions", "Hours"} {
+			grid.Add(widget.NewLabelWithStyle(title, fyne.TextAlignLeading, bold))
+		}
+		for _, credit := range credits {
+			grid.Add(widget.NewLabel(credit.UserName))
+			grid.Add(widget.NewLabel(credit.UserID))
+			grid.Add(widget.NewLabel(fmt.Sprintf("%d", credit.Sessions)))
+			grid.Add(widget.NewLabel(fmt.Sprintf("%.2f", credit.Usage.Hours())))
+		}
+		summary.SetText(fmt.Sprintf("%d student(s) in %s", len(credits), statsRangeTitle()))
+	}
+	courseSelect := widget.NewSelect(courses, show)
+	anonymize := newAnonymizeCheck(nil)
+	export := widget.NewButton("Export CSV", func() {
+		course := courseSelect.Selected
+		anon, err := exportAnonymizerFor(anonymize.Checked)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		rows := courseReportRows(course, credits, anon)
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+			w := csv.NewWriter(writer)
+			if err := w.WriteAll(rows); err != nil {
+				dialog.ShowError(fmt.Errorf("write course report: %w", err), mainWindow)
+			}
+		}, mainWindow)
+		save.SetFileName(reportFileName("course-" + course))
+		save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+		save.Show()
+	})
+	courseSelect.SetSelected(courses[0])
+	top := container.NewBorder(nil, nil, widget.NewLabel("Course:"), container.NewHBox(anonymize, export), courseSelect)
+	content := container.NewBorder(container.NewVBox(top, summary), nil, nil, nil, container.NewVScroll(grid))
+	dlg := dialog.NewCustom("Course Hours", "Close", content, mainWindow)
+	dlg.Resize(fyne.NewSize(620, 480))
+	dlg.Show()
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// memoryOnly is set when staff chose to run without a writable data folder:
+// the data files live in a scratch copy that is removed on exit.
+var memoryOnly bool
+
+// writeErrors counts the data file writes that failed since startup, so a
+// desk that cannot save says so instead of carrying on as if it could.
+var writeErrors struct {
+	sync.Mutex
+	count  int
+	last   error
+	button *widget.Button
+}
+
+// memoryOnlyDir is the scratch copy of the data for a memory-only desk.
+func memoryOnlyDir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("lounge-memory-%d", os.Getpid()))
+}
+
+// probeWrite creates, writes and removes a file in dir, which is the only
+// sure way to know the app can save there.
+func probeWrite(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".probe-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_, err = f.WriteString("ok\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	os.Remove(name)
+	return err
+}
+
+// writeFailed logs a failed write and counts it with noteWriteError, as the
+// store's own writeFailed does.
+func writeFailed(msg string, err error) {
+	slog.Error(msg, "err", err)
+	noteWriteError(fmt.Errorf("%s: %w", msg, err))
+}
+
+// noteWriteError counts a failed write and shows it in the status bar. It is
+// the store's OnWriteError, so it may run on a background goroutine.
+func noteWriteError(err error) {
+	if errors.Is(err, state.ErrReadOnly) {
+		return
+	}
+	writeErrors.Lock()
+	writeErrors.count++
+	writeErrors.last = err
+	writeErrors.Unlock()
+	fyne.Do(refreshWriteErrorsButton)
+}
+
+// newWriteErrorsButton is hidden until a write fails. Tapping it shows the
+// last failure.
+func newWriteErrorsButton() *widget.Button {
+	button := widget.NewButtonWithIcon("", theme.ErrorIcon(), func() {
+		writeErrors.Lock()
+		count, last := writeErrors.count, writeErrors.last
+		writeErrors.Unlock()
+		message := widget.NewLabel(fmt.Sprintf(
+			"%d write(s) to the data folder failed since the app started, so some changes may not be saved.\n\n"+
+				"Last failure: %v\n\nData folder: %s\nRun Diagnostics from the toolbar to check the folder.",
+			count, last, absPath(logDir)))
+		message.Wrapping = fyne.TextWrapWord
+		dlg := dialog.NewCustom("Failed Writes", "Close", message, mainWindow)
+		dlg.Resize(fyne.NewSize(520, dlg.MinSize().Height))
+		dlg.Show()
+	})
+	button.Importance = widget.DangerImportance
+	writeErrors.Lock()
+	writeErrors.button = button
+	writeErrors.Unlock()
+	refreshWriteErrorsButton()
+	return button
+}
+
+func refreshWriteErrorsButton() {
+	writeErrors.Lock()
+	button, count := writeErrors.button, writeErrors.count
+	writeErrors.Unlock()
+	if button == nil {
+		return
+	}
+	if count == 0 {
+		button.Hide()
+		return
+	}
+	button.SetText(fmt.Sprintf("%d failed write(s)", count))
+	button.Show()
+}
+
+// checkDataDir reports whether the app can save to the data folder. When it
+// cannot, a window explains why and offers another folder or memory-only,
+// calling start once staff have chosen.
+func checkDataDir(start func()) bool {
+	err := probeWrite(logDir)
+	if err == nil {
+		return true
+	}
+	slog.Error("data folder is not writable", "dir", absPath(logDir), "err", err)
+	showUnwritableDataWindow(err, start)
+	return false
+}
+
+func showUnwritableDataWindow(probeErr error, start func()) {
+	w := fyne.CurrentApp().NewWindow("Data Folder Not Writable")
+	message := widget.NewLabel("")
+	message.Wrapping = fyne.TextWrapWord
+	explain := func(err error) {
+		message.SetText(fmt.Sprintf(
+			"The app cannot save to its data folder, so check-ins and logs would be lost:\n%s\n%v\n\n"+
+				"Choose a folder the app can write to. To use it every time, start the app with -data-dir or set %s.\n\n"+
+				"Memory-Only opens the lounge anyway, but nothing is saved once the app closes.",
+			absPath(filepath.Dir(logDir)), err, dataDirEnv))
+	}
+	explain(probeErr)
+	// proceed takes the instance lock on the folder now in use and starts.
+	proceed := func() {
+		w.Close()
+		if acquireInstanceLock(false, start) {
+			start()
+		}
+	}
+	quit := widget.NewButton("Quit", func() { fyne.CurrentApp().Quit() })
+	memory := widget.NewButtonWithIcon("Memory-Only", theme.WarningIcon(), func() {
+		if err := startMemoryOnly(); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		proceed()
+	})
+	memory.Importance = widget.DangerImportance
+	choose := widget.NewButtonWithIcon("Choose Folder...", theme.FolderOpenIcon(), func() {
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if dir == nil {
+				return
+			}
+			root := dir.Path()
+			if err := probeWrite(filepath.Join(root, "log")); err != nil {
+				explain(err)
+				return
+			}
+			useDataRoot(root)
+			slog.Info("data folder chosen", "dir", root)
+			proceed()
+		}, w)
+	})
+	choose.Importance = widget.HighImportance
+	buttons := container.NewHBox(layout.NewSpacer(), quit, memory, choose)
+	w.SetContent(container.NewPadded(container.NewBorder(nil, buttons, nil, nil, message)))
+	w.Resize(fyne.NewSize(560, 400))
+	w.Show()
+}
+
+// startMemoryOnly points the data files at a scratch copy of whatever could
+// be read from the real folder.
+func startMemoryOnly() error {
+	dir := memoryOnlyDir()
+	os.RemoveAll(dir)
+	if err := seedSandbox(dir); err != nil {
+		return err
+	}
+	useDataRoot(dir)
+	memoryOnly = true
+	slog.Warn("memory-only mode: nothing will be saved", "scratch", dir)
+	return nil
+}
+
+func discardMemoryOnlyDir() {
+	if err := os.RemoveAll(memoryOnlyDir()); err != nil {
+		slog.Error("removing the memory-only scratch folder", "err", err)
+	}
+}
+
+// newMemoryOnlyBanner stays above the tabs for as long as nothing is saved.
+func newMemoryOnlyBanner() fyne.CanvasObject {
+	if !memoryOnly {
+		return layout.NewSpacer()
+	}
+	title := canvas.NewText("MEMORY-ONLY", latteRed)
+	title.TextStyle = fyne.TextStyle{Bold: true}
+	title.TextSize = 18
+	message := widget.NewLabel("Nothing will be saved: the data folder is not writable, and every change is lost when the app closes.")
+	message.Wrapping = fyne.TextWrapWord
+	return container.NewBorder(nil, nil, container.NewCenter(title), nil, message)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Display format choices. "System" follows the OS locale; stored files always
+// keep RFC 3339 timestamps, so these only change what staff see and export.
+const (
+	formatSystem     = "System"
+	dateMonthDay     = "Jan 02"
+	dateDayMonth     = "02 Jan"
+	dateISO          = "2006-01-02"
+	clock24Hour      = "24-hour"
+	clock12Hour      = "12-hour"
+	weekStartsMonday = "Monday"
+	weekStartsSunday = "Sunday"
+)
+
+var (
+	dateStyleOptions = []string{formatSystem, dateMonthDay, dateDayMonth, dateISO}
+	clockOptions     = []string{formatSystem, clock24Hour, clock12Hour}
+	weekStartOptions = []string{formatSystem, weekStartsMonday, weekStartsSunday}
+)
+
+// systemUsesUSFormats reports whether the OS locale is US English, the one
+// common locale with month-first dates, a 12-hour clock and Sunday weeks.
+var systemUsesUSFormats = sync.OnceValue(func() bool {
+	return strings.EqualFold(string(lang.SystemLocale()), "en-US")
+})
+
+func dateLayout() string {
+	switch appSettings.DateStyle {
+	case dateMonthDay, dateDayMonth, dateISO:
+		return appSettings.DateStyle
+	}
+	if systemUsesUSFormats() {
+		return dateMonthDay
+	}
+	return dateDayMonth
+}
+
+func clockLayout(seconds bool) string {
+	twelve := appSettings.ClockStyle == clock12Hour || (appSettings.ClockStyle != clock24Hour && systemUsesUSFormats())
+	switch {
+	case twelve && seconds:
+		return "3:04:05 PM"
+	case twelve:
+		return "3:04 PM"
+	case seconds:
+		return "15:04:05"
+	}
+	return "15:04"
+}
+
+// formatClock renders a time of day in the configured clock style.
+func formatClock(t time.Time) string { return t.Local().Format(clockLayout(false)) }
+
+// formatDateTime renders a date and time of day, e.g. "Jan 02 15:04".
+func formatDateTime(t time.Time) string {
+	return t.Local().Format(dateLayout() + " " + clockLayout(false))
+}
+
+// zoneLabel names the local zone's offset at t, e.g. "UTC+2" or
+// "UTC-3:30", for headers of exported times.
+func zoneLabel(t time.Time) string {
+	_, offset := t.Local().Zone()
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	label := fmt.Sprintf("UTC%s%d", sign, offset/3600)
+	if minutes := offset % 3600 / 60; minutes != 0 {
+		label += fmt.Sprintf(":%02d", minutes)
+	}
+	if offset == 0 {
+		label = "UTC"
+	}
+	return label
+}
+
+// formatLongDateTime adds the weekday and seconds, for headers of lists that
+// are printed or handed over.
+func formatLongDateTime(t time.Time) string {
+	return t.Local().Format("Mon " + dateLayout() + " " + clockLayout(true))
+}
+
+func weekStartDay() time.Weekday {
+	switch appSettings.WeekStart {
+	case weekStartsMonday:
+		return time.Monday
+	case weekStartsSunday:
+		return time.Sunday
+	}
+	if systemUsesUSFormats() {
+		return time.Sunday
+	}
+	return time.Monday
+}
+
+// startOfDay is local midnight at the start of t's day.
+func startOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// startOfWeek is midnight on the first day of t's week.
+func startOfWeek(t time.Time) time.Time {
+	t = t.Local()
+	offset := (int(t.Weekday()) - int(weekStartDay()) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.Local)
+}
+
+func newFormatSelect(options []string, current string) *widget.Select {
+	sel := widget.NewSelect(options, nil)
+	if current == "" {
+		current = formatSystem
+	}
+	sel.SetSelected(current)
+	return sel
+}
+
+// formatSetting stores "System" as empty, so the setting keeps following the
+// locale.
+func formatSetting(sel *widget.Select) string {
+	if sel.Selected == formatSystem {
+		return ""
+	}
+	return sel.Selected
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZoneLabel(t *testing.T) {
+	saved := time.Local
+	t.Cleanup(func() { time.Local = saved })
+	tests := []struct {
+		offset int
+		want   string
+	}{
+		{0, "UTC"},
+		{2 * 3600, "UTC+2"},
+		{-5 * 3600, "UTC-5"},
+		{5*3600 + 30*60, "UTC+5:30"},
+		{-(3*3600 + 30*60), "UTC-3:30"},
+	}
+	for _, tt := range tests {
+		time.Local = time.FixedZone("test", tt.offset)
+		if got := zoneLabel(time.Now()); got != tt.want {
+			t.Errorf("offset %d: got %q, want %q", tt.offset, got, tt.want)
+		}
+	}
+}
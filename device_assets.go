@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// showDeviceDetailsDialog shows a device's status and recent usage, and lets
+// staff record the asset tag and serial number from the university's asset
+// sheet.
+func showDeviceDetailsDialog(deviceID int) {
+	device := store.DeviceByID(deviceID)
+	if device == nil {
+		return
+	}
+	status := device.Status
+	if device.Status == "occupied" {
+		status = fmt.Sprintf("occupied by %s", occupantNames(*device))
+	}
+	usageText := "unknown"
+	if usage, err := store.DeviceUsage(state.AuditUsageDays, time.Now()); err != nil {
+		slog.Error("reading device usage", "err", err)
+	} else {
+		usageText = state.FormatMinutes(usage[device.ID])
+	}
+	assetTag := widget.NewEntry()
+	assetTag.SetText(device.AssetTag)
+	serial := widget.NewEntry()
+	serial.SetText(device.SerialNumber)
+	label := widget.NewEntry()
+	label.SetText(device.Label)
+	label.SetPlaceHolder(fmt.Sprintf("%s %d", device.Type, device.ID))
+	disableWhenReadOnly(label, assetTag, serial)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Device", widget.NewLabel(device.Name())),
+		widget.NewFormItem("Status", widget.NewLabel(status)),
+		widget.NewFormItem(fmt.Sprintf("Used (%d days)", state.AuditUsageDays), widget.NewLabel(usageText)),
+		widget.NewFormItem("Label", label),
+		widget.NewFormItem("Asset Tag", assetTag),
+		widget.NewFormItem("Serial Number", serial),
+	}
+	if device.Status == "occupied" {
+		var contacts []string
+		for _, user := range store.UsersOnDevice(device.ID) {
+			contacts = append(contacts, fmt.Sprintf("%s: %s", firstLast(user.Name), contactText(user)))
+		}
+		items = slices.Insert(items, 2, widget.NewFormItem("Contact", widget.NewLabel(strings.Join(contacts, "\n"))))
+	}
+	dlg := dialog.NewForm("Device Details", "Save", "Close", items, func(ok bool) {
+		if !ok || readOnly {
+			return
+		}
+		if err := store.SetDeviceAsset(deviceID, assetTag.Text, serial.Text); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if strings.TrimSpace(label.Text) != device.Label {
+			if err := store.SetDeviceLabel(deviceID, label.Text); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+		}
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(420, 0))
+	dlg.Show()
+}
+
+// deviceInventoryRows is devices.csv for the annual inventory audit: every
+// device with its asset records, current status and hours of use over the
+// last AuditUsageDays days.
+func deviceInventoryRows(now time.Time) ([][]string, error) {
+	usage, err := store.DeviceUsage(state.AuditUsageDays, now)
+	if err != nil {
+		return nil, err
+	}
+	rows := [][]string{{"Device ID", "Type", "Asset Tag", "Serial Number", "Status",
+		fmt.Sprintf("Usage Hours (%d days)", state.AuditUsageDays)}}
+	for _, device := range store.Devices {
+		rows = append(rows, []string{strconv.Itoa(device.ID), device.Type, device.AssetTag, device.SerialNumber,
+			device.Status, fmt.Sprintf("%.1f", usage[device.ID].Hours())})
+	}
+	return rows, nil
+}
+
+func showExportDevicesDialog() {
+	rows, err := deviceInventoryRows(time.Now())
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+		w := csv.NewWriter(writer)
+		if err := w.WriteAll(rows); err != nil {
+			dialog.ShowError(fmt.Errorf("write devices: %w", err), mainWindow)
+		}
+	}, mainWindow)
+	save.SetFileName("devices.csv")
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	save.Show()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// deviceMovesText flattens a session's device moves for the log column and
+// CSV, e.g. "3>9@14:05; 9>12@15:30".
+func deviceMovesText(entry state.LogEntry) string {
+	moves := make([]string, len(entry.DeviceHistory))
+	for i, move := range entry.DeviceHistory {
+		moves[i] = fmt.Sprintf("%d>%d@%s", move.From, move.To, formatClock(move.At))
+	}
+	return strings.Join(moves, "; ")
+}
+
+// Tapped shows where a session that moved between devices sat and when.
+func (c *logEntryCard) Tapped(ev *fyne.PointEvent) {
+	entry := c.entry
+	if len(entry.DeviceHistory) == 0 {
+		return
+	}
+	at := entry.CheckInTime
+	rows := container.NewVBox(widget.NewLabelWithStyle(entry.UserName+": devices used", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	for _, move := range entry.DeviceHistory {
+		rows.Add(widget.NewLabel(fmt.Sprintf("Device %d  %s - %s", move.From, formatClock(at), formatClock(move.At))))
+		at = move.At
+	}
+	until := "now"
+	if !entry.CheckOutTime.IsZero() {
+		until = formatClock(entry.CheckOutTime)
+	}
+	rows.Add(widget.NewLabel(fmt.Sprintf("Device %d  %s - %s", entry.PCID, formatClock(at), until)))
+	widget.ShowPopUpAtPosition(rows, mainWindow.Canvas(), ev.AbsolutePosition)
+}
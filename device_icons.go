@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// defaultDeviceImage is the built-in image for a device type and status.
+func defaultDeviceImage(deviceType string, busy bool) string {
+	switch {
+	case deviceType == "PC" && busy:
+		return "busy.png"
+	case deviceType == "PC":
+		return "free.png"
+	case busy:
+		return "console_busy.png"
+	}
+	return "console.png"
+}
+
+// resolveDeviceImage returns the image file name for device in the given
+// state: <base>_free.png or <base>_busy.png from imgBaseDir when the device or
+// its type has an icon base and the file exists, else the built-in image.
+// custom reports whether the icon set was used.
+func resolveDeviceImage(device state.Device, busy bool) (name string, custom bool) {
+	if base := store.IconBase(device); base != "" {
+		suffix := "_free.png"
+		if busy {
+			suffix = "_busy.png"
+		}
+		if fileExists(filepath.Join(imgBaseDir, base+suffix)) {
+			return base + suffix, true
+		}
+	}
+	return defaultDeviceImage(device.Type, busy), false
+}
+
+func deviceImageName(device state.Device) string {
+	name, _ := resolveDeviceImage(device, device.Status == "occupied")
+	return name
+}
+
+// deviceGlyph stands in for a device image that cannot be loaded: the type's
+// first letter, green when free and red when busy.
+func deviceGlyph(deviceType string, busy bool) glyph {
+	letter := "?"
+	if deviceType != "" {
+		letter = strings.ToUpper(deviceType[:1])
+	}
+	if busy {
+		return glyph{Letter: letter, Color: latteRed}
+	}
+	return glyph{Letter: letter, Color: latteGreen}
+}
+
+// deviceIcon is the image for device in the given state, from iconCache.
+func deviceIcon(device state.Device, busy bool) fyne.Resource {
+	name, _ := resolveDeviceImage(device, busy)
+	return iconCache.Get(name, deviceGlyph(device.Type, busy))
+}
+
+// deviceIconPreview shows a thumbnail and the resolved file name for one state
+// of device, flagging a configured icon base whose file is missing.
+func deviceIconPreview(device state.Device, busy bool) fyne.CanvasObject {
+	name, custom := resolveDeviceImage(device, busy)
+	img := canvas.NewImageFromResource(deviceIcon(device, busy))
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(fyne.NewSize(40, 40))
+	caption := name
+	if base := store.IconBase(device); base != "" && !custom {
+		want := base + "_free.png"
+		if busy {
+			want = base + "_busy.png"
+		}
+		caption = fmt.Sprintf("%s (%s missing)", name, want)
+	}
+	label := widget.NewLabel(caption)
+	label.TextStyle = fyne.TextStyle{Italic: !custom}
+	return container.NewHBox(img, label)
+}
+
+// showDeviceContextMenu pops up the device's details, its resolved icons and
+// the actions available for it at pos.
+func showDeviceContextMenu(device state.Device, pos fyne.Position) {
+	var popup *widget.PopUp
+	title := widget.NewLabelWithStyle(device.Name(), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	items := []fyne.CanvasObject{
+		title,
+		deviceIconPreview(device, false),
+		deviceIconPreview(device, true),
+	}
+	if device.Status == "occupied" {
+		items = append(items, widget.NewSeparator(), widget.NewButton("Check Out...", func() {
+			popup.Hide()
+			if device.Type == "Console" {
+				showConsoleCheckoutDialog(device)
+				return
+			}
+			dialog.ShowConfirm("Confirm Checkout", fmt.Sprintf("Checkout %s from PC %d?", occupantNames(device), device.ID)+awayWarning(device.UserID), func(ok bool) {
+				if !ok {
+					return
+				}
+				requireStaffPIN("Check Out", func() {
+					if err := store.Checkout(device.UserID); err != nil {
+						dialog.ShowError(err, mainWindow)
+					}
+				})
+			}, mainWindow)
+		}))
+	}
+	if waiting := store.WaitingForDevice(device.ID); waiting > 0 {
+		items = append(items, widget.NewLabel(fmt.Sprintf("%d waiting for this device", waiting)))
+	}
+	if button := seatNextButton(device, func() { popup.Hide() }); button != nil {
+		items = append(items, widget.NewSeparator(), button)
+	}
+	if button := cleanedEarlyButton(device, func() { popup.Hide() }); button != nil {
+		items = append(items, button)
+	}
+	items = append(items, maintenanceButton(device, func() { popup.Hide() }), widget.NewButton("Details...", func() {
+		popup.Hide()
+		showDeviceDetailsDialog(device.ID)
+	}))
+	if _, _, ok := rotationLabel(device.ID, time.Now()); ok {
+		items = append(items, widget.NewButton("Rotated", func() {
+			popup.Hide()
+			if err := store.MarkRotated(device.ID); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+		}))
+	}
+	popup = widget.NewPopUp(container.NewPadded(container.NewVBox(items...)), mainWindow.Canvas())
+	popup.ShowAtPosition(pos)
+}
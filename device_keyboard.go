@@ -0,0 +1,186 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// deviceFocusLabel spells out the focused device under the map. Fyne has no
+// screen-reader API, so this visible line is the accessible name.
+var deviceFocusLabel *widget.Label
+
+// focusedDeviceID is the device the keyboard focus ring is on. The map is
+// rebuilt on every refresh, so it lives here rather than on the widget.
+var focusedDeviceID int
+
+// restoreDeviceMapFocus gives the map rebuilt by refresh keyboard focus back
+// when the map it replaced had it.
+func restoreDeviceMapFocus(refresh func()) {
+	hadFocus := deviceLayoutWidget != nil && deviceLayoutWidget.focused
+	refresh()
+	if hadFocus && deviceLayoutWidget != nil && mainWindow != nil {
+		mainWindow.Canvas().Focus(deviceLayoutWidget)
+	}
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) FocusGained() {
+	layoutWidget.focused = true
+	if store.DeviceByID(focusedDeviceID) == nil && len(store.Devices) > 0 {
+		focusedDeviceID = store.Devices[0].ID
+	}
+	layoutWidget.announceFocus()
+	layoutWidget.Refresh()
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) FocusLost() {
+	layoutWidget.focused = false
+	layoutWidget.shiftDown = false
+	layoutWidget.announceFocus()
+	layoutWidget.Refresh()
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) TypedRune(rune) {}
+
+func (layoutWidget *DeviceStatusLayoutWidget) TypedKey(event *fyne.KeyEvent) {
+	switch event.Name {
+	case fyne.KeyUp:
+		layoutWidget.moveFocus(0, -1)
+	case fyne.KeyDown:
+		layoutWidget.moveFocus(0, 1)
+	case fyne.KeyLeft:
+		layoutWidget.moveFocus(-1, 0)
+	case fyne.KeyRight:
+		layoutWidget.moveFocus(1, 0)
+	case fyne.KeyReturn, fyne.KeyEnter, fyne.KeySpace:
+		if device := store.DeviceByID(focusedDeviceID); device != nil && !deviceDialogRecentlyOpened(device.ID) {
+			layoutWidget.activateDevice(*device)
+		}
+	case desktop.KeyMenu:
+		layoutWidget.showFocusedContextMenu()
+	case fyne.KeyF10:
+		if layoutWidget.shiftDown {
+			layoutWidget.showFocusedContextMenu()
+		}
+	}
+}
+
+// KeyDown and KeyUp only track Shift so Shift+F10 can open the context menu.
+func (layoutWidget *DeviceStatusLayoutWidget) KeyDown(event *fyne.KeyEvent) {
+	if event.Name == desktop.KeyShiftLeft || event.Name == desktop.KeyShiftRight {
+		layoutWidget.shiftDown = true
+	}
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) KeyUp(event *fyne.KeyEvent) {
+	if event.Name == desktop.KeyShiftLeft || event.Name == desktop.KeyShiftRight {
+		layoutWidget.shiftDown = false
+	}
+}
+
+// setFocusDevice moves keyboard focus to deviceID, so tabbing back into the
+// map after a click continues from the device last used.
+func (layoutWidget *DeviceStatusLayoutWidget) setFocusDevice(deviceID int) {
+	focusedDeviceID = deviceID
+	if c := fyne.CurrentApp().Driver().CanvasForObject(layoutWidget); c != nil {
+		c.Focus(layoutWidget)
+	}
+	layoutWidget.announceFocus()
+	layoutWidget.Refresh()
+}
+
+// moveFocus jumps to the nearest device in the direction (dx, dy) following
+// the on-screen arrangement rather than the device numbering. Devices far off
+// the arrow's axis are penalised so Down picks the one below, not the one
+// diagonally closer.
+func (layoutWidget *DeviceStatusLayoutWidget) moveFocus(dx, dy float32) {
+	from := layoutWidget.positionForDevice(focusedDeviceID)
+	bestID, bestScore := 0, float32(math.MaxFloat32)
+	for _, device := range store.Devices {
+		if device.ID == focusedDeviceID {
+			continue
+		}
+		pos := layoutWidget.positionForDevice(device.ID)
+		along := (pos.X-from.X)*dx + (pos.Y-from.Y)*dy
+		if along <= 0 {
+			continue
+		}
+		across := float32(math.Abs(float64((pos.X-from.X)*dy + (pos.Y-from.Y)*dx)))
+		if score := along + 2*across; score < bestScore {
+			bestID, bestScore = device.ID, score
+		}
+	}
+	if bestID != 0 {
+		layoutWidget.setFocusDevice(bestID)
+	}
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) showFocusedContextMenu() {
+	device := store.DeviceByID(focusedDeviceID)
+	if device == nil {
+		return
+	}
+	origin := fyne.CurrentApp().Driver().AbsolutePositionForObject(layoutWidget)
+	center := layoutWidget.positionForDevice(device.ID)
+	showDeviceContextMenu(*device, origin.Add(center))
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) announceFocus() {
+	if deviceFocusLabel == nil || layoutWidget != deviceLayoutWidget {
+		return
+	}
+	device := store.DeviceByID(focusedDeviceID)
+	if !layoutWidget.focused || device == nil {
+		deviceFocusLabel.SetText("")
+		return
+	}
+	deviceFocusLabel.SetText(accessibleDeviceLabel(*device))
+}
+
+// accessibleDeviceLabel describes a device in words, e.g.
+// "PC 7, occupied by Ana Li, 1h12m00s".
+func accessibleDeviceLabel(device state.Device) string {
+	label := device.Name()
+	if device.Status == state.StatusMaintenance {
+		return label + ", under maintenance"
+	}
+	if _, ok := store.CleaningUntil(device.ID, time.Now()); ok {
+		return label + ", being cleaned"
+	}
+	if device.Status != "occupied" {
+		return label + ", free"
+	}
+	users := store.UsersOnDevice(device.ID)
+	if len(users) == 0 {
+		return label + ", occupied"
+	}
+	label += ", occupied by " + occupantNames(device)
+	if !users[0].CheckInTime.IsZero() {
+		label += ", " + state.FormatDuration(time.Since(users[0].CheckInTime))
+	}
+	return label
+}
+
+// updateFocusRing outlines the focused device while the map has keyboard focus.
+func (renderer *deviceStatusRenderer) updateFocusRing() {
+	ring := renderer.focusRing
+	layoutWidget := renderer.widget
+	if !layoutWidget.focused || store.DeviceByID(focusedDeviceID) == nil {
+		ring.Hide()
+		return
+	}
+	center := layoutWidget.positionForDevice(focusedDeviceID)
+	size := layoutWidget.iconSizeForDevice(focusedDeviceID) + 12
+	ring.StrokeColor = theme.FocusColor()
+	ring.Resize(fyne.NewSize(size, size))
+	ring.Move(fyne.NewPos(center.X-size/2, center.Y-size/2))
+	ring.Show()
+	canvas.Refresh(ring)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// deviceSelection is the set of devices picked for a group action with
+// Ctrl+click or a rubber band. Like deviceLayout it outlives the map widget,
+// which is rebuilt on every refresh, but it is never written to disk.
+var deviceSelection = map[int]bool{}
+
+var (
+	selectionBar   *fyne.Container
+	selectionLabel *widget.Label
+)
+
+// isMultiSelect reports whether a click with these modifiers adds to the
+// selection instead of acting on the device.
+func isMultiSelect(modifier fyne.KeyModifier) bool {
+	return modifier&(fyne.KeyModifierControl|fyne.KeyModifierSuper) != 0
+}
+
+func toggleDeviceSelection(deviceID int) {
+	if deviceSelection[deviceID] {
+		delete(deviceSelection, deviceID)
+	} else {
+		deviceSelection[deviceID] = true
+	}
+	selectionChanged()
+}
+
+func clearDeviceSelection() {
+	if len(deviceSelection) == 0 {
+		return
+	}
+	clear(deviceSelection)
+	selectionChanged()
+}
+
+func selectionChanged() {
+	refreshSelectionBar()
+	if deviceLayoutWidget != nil {
+		deviceLayoutWidget.Refresh()
+	}
+}
+
+// selectedDevices returns the selected devices that still exist, by ID.
+func selectedDevices() []state.Device {
+	var devices []state.Device
+	for _, device := range store.Devices {
+		if deviceSelection[device.ID] {
+			devices = append(devices, device)
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	return devices
+}
+
+// selectInBand selects every device whose centre lies in the rectangle
+// spanned by a and b, adding to the selection when extend is set.
+func (layoutWidget *DeviceStatusLayoutWidget) selectInBand(a, b fyne.Position, extend bool) {
+	if !extend {
+		clear(deviceSelection)
+	}
+	minX, maxX := min(a.X, b.X), max(a.X, b.X)
+	minY, maxY := min(a.Y, b.Y), max(a.Y, b.Y)
+	for _, device := range store.Devices {
+		center := layoutWidget.positionForDevice(device.ID)
+		if center.X >= minX && center.X <= maxX && center.Y >= minY && center.Y <= maxY {
+			deviceSelection[device.ID] = true
+		}
+	}
+	selectionChanged()
+}
+
+// newSelectionBar holds the group actions; it shows only while something is
+// selected.
+func newSelectionBar() *fyne.Container {
+	selectionLabel = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	checkout := widget.NewButtonWithIcon("Check Out All", theme.LogoutIcon(), showGroupCheckoutDialog)
+	setMaintenance := widget.NewButtonWithIcon("Set Maintenance", theme.WarningIcon(), func() { applyGroupMaintenance(true) })
+	clearMaintenance := widget.NewButtonWithIcon("Clear Maintenance", theme.ConfirmIcon(), func() { applyGroupMaintenance(false) })
+	deselect := widget.NewButtonWithIcon("", theme.CancelIcon(), clearDeviceSelection)
+	disableWhenReadOnly(checkout, setMaintenance, clearMaintenance)
+	selectionBar = container.NewHBox(selectionLabel, checkout, setMaintenance, clearMaintenance, deselect)
+	refreshSelectionBar()
+	return selectionBar
+}
+
+func refreshSelectionBar() {
+	if selectionBar == nil {
+		return
+	}
+	devices := selectedDevices()
+	if len(devices) == 0 {
+		selectionBar.Hide()
+		return
+	}
+	ids := make([]string, len(devices))
+	for i, device := range devices {
+		ids[i] = fmt.Sprint(device.ID)
+	}
+	selectionLabel.SetText(fmt.Sprintf("%d selected (%s)", len(devices), strings.Join(ids, ", ")))
+	selectionBar.Show()
+}
+
+// groupCheckout is one user a group checkout will end, with their device.
+type groupCheckout struct {
+	device state.Device
+	user   state.User
+}
+
+func (c groupCheckout) String() string {
+	return fmt.Sprintf("%s %d: %s (%s)", c.device.Type, c.device.ID, firstLastNonEmpty(c.user.Name), c.user.ID)
+}
+
+// showGroupCheckoutDialog lists exactly who is on the selected devices and
+// checks out those users, and only those, once confirmed.
+func showGroupCheckoutDialog() {
+	devices := selectedDevices()
+	var checkouts []groupCheckout
+	for _, device := range devices {
+		for _, user := range store.UsersOnDevice(device.ID) {
+			checkouts = append(checkouts, groupCheckout{device: device, user: user})
+		}
+	}
+	if len(checkouts) == 0 {
+		dialog.ShowInformation("Check Out All", "Nobody is on the selected devices.", mainWindow)
+		return
+	}
+	lines := make([]string, len(checkouts))
+	for i, c := range checkouts {
+		lines[i] = c.String()
+	}
+	list := widget.NewLabel(strings.Join(lines, "\n"))
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(380, min(float32(len(lines))*28+16, 260)))
+	thenMaintenance := widget.NewCheck("Then set the devices to maintenance", nil)
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("These %d user(s) will be checked out:", len(checkouts))),
+		scroll,
+		thenMaintenance,
+	)
+	dialog.ShowCustomConfirm("Check Out All", "Check Out", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		requireStaffPIN("Check Out All", func() {
+			var failures []string
+			for _, c := range checkouts {
+				if err := store.Checkout(c.user.ID); err != nil {
+					failures = append(failures, fmt.Sprintf("%s: %v", c, err))
+				}
+			}
+			if thenMaintenance.Checked {
+				failures = append(failures, setGroupMaintenance(devices, true)...)
+			}
+			showGroupFailures("Check Out All", failures)
+		})
+	}, mainWindow)
+}
+
+func applyGroupMaintenance(on bool) {
+	title := "Clear Maintenance"
+	if on {
+		title = "Set Maintenance"
+	}
+	showGroupFailures(title, setGroupMaintenance(selectedDevices(), on))
+}
+
+// setGroupMaintenance applies SetMaintenance to each device and returns one
+// line per device that failed.
+func setGroupMaintenance(devices []state.Device, on bool) []string {
+	var failures []string
+	for _, device := range devices {
+		if err := store.SetMaintenance(device.ID, on); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", device.Name(), err))
+		}
+	}
+	return failures
+}
+
+// showGroupFailures reports the devices a group action could not handle; the
+// rest went through.
+func showGroupFailures(title string, failures []string) {
+	if len(failures) == 0 {
+		return
+	}
+	dialog.ShowInformation(title, fmt.Sprintf("%d could not be completed:\n\n%s", len(failures), strings.Join(failures, "\n")), mainWindow)
+}
+
+// maintenanceButton toggles a single device from its context menu.
+func maintenanceButton(device state.Device, onDone func()) fyne.CanvasObject {
+	label, on := "Set Maintenance", true
+	if device.Status == state.StatusMaintenance {
+		label, on = "Clear Maintenance", false
+	}
+	button := widget.NewButton(label, func() {
+		onDone()
+		if err := store.SetMaintenance(device.ID, on); err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+	})
+	if device.Status == "occupied" {
+		button.Disable()
+	}
+	return button
+}
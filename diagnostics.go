@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// builtinImages are the images the app loads from imgBaseDir; each has a
+// fallback, which is why a missing one looks broken rather than failing.
+var builtinImages = []string{"free.png", "busy.png", "console.png", "console_busy.png", "move.png", "racoon.png"}
+
+// diagnosticCheck is one line of the Diagnostics report. Hint says how to fix
+// a failed check.
+type diagnosticCheck struct {
+	Name   string
+	Detail string
+	OK     bool
+	Hint   string
+}
+
+func (c diagnosticCheck) String() string {
+	status := "OK  "
+	if !c.OK {
+		status = "FAIL"
+	}
+	line := fmt.Sprintf("[%s] %s: %s", status, c.Name, c.Detail)
+	if !c.OK && c.Hint != "" {
+		line += "\n       -> " + c.Hint
+	}
+	return line
+}
+
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+func runDiagnostics() []diagnosticCheck {
+	var checks []diagnosticCheck
+	wd, _ := os.Getwd()
+	checks = append(checks, diagnosticCheck{Name: "Working directory", Detail: wd, OK: true})
+
+	for _, dir := range []struct{ name, path, hint string }{
+		{"Image directory", imgBaseDir, "Copy the src/ folder next to the app, or start the app from the folder that contains it."},
+		{"Log directory", logDir, "Copy the log/ folder next to the app, or start the app from the folder that contains it."},
+	} {
+		check := diagnosticCheck{Name: dir.name, Detail: absPath(dir.path), Hint: dir.hint}
+		if info, err := os.Stat(dir.path); err != nil {
+			check.Detail += " (missing)"
+		} else if !info.IsDir() {
+			check.Detail += " (not a directory)"
+		} else {
+			check.OK = true
+		}
+		checks = append(checks, check)
+	}
+
+	checks = append(checks, imageChecks()...)
+	checks = append(checks, logDirWritableCheck(), failedWritesCheck())
+	checks = append(checks, memberFileChecks()...)
+	checks = append(checks, todaysLogCheck())
+	checks = append(checks, consistencyCheck())
+	checks = append(checks, receiptChecks()...)
+	checks = append(checks, backupChecks()...)
+	return checks
+}
+
+// imageChecks covers the built-in images and every icon set named in the
+// device inventory.
+func imageChecks() []diagnosticCheck {
+	var checks []diagnosticCheck
+	for _, name := range builtinImages {
+		check := diagnosticCheck{Name: "Image " + name, Detail: "found", OK: true}
+		if !fileExists(filepath.Join(imgBaseDir, name)) {
+			check = diagnosticCheck{Name: "Image " + name, Detail: "missing from " + absPath(imgBaseDir),
+				Hint: "Restore " + name + " from the release archive into the src/ folder."}
+		}
+		checks = append(checks, check)
+	}
+	bases := make(map[string]bool)
+	for _, device := range store.Devices {
+		if base := store.IconBase(device); base != "" {
+			bases[base] = true
+		}
+	}
+	names := make([]string, 0, len(bases))
+	for base := range bases {
+		names = append(names, base)
+	}
+	sort.Strings(names)
+	for _, base := range names {
+		var missing []string
+		for _, suffix := range []string{"_free.png", "_busy.png"} {
+			if !fileExists(filepath.Join(imgBaseDir, base+suffix)) {
+				missing = append(missing, base+suffix)
+			}
+		}
+		check := diagnosticCheck{Name: "Icon set " + base, Detail: "found", OK: true}
+		if len(missing) > 0 {
+			check = diagnosticCheck{Name: "Icon set " + base, Detail: "missing " + strings.Join(missing, ", "),
+				Hint: "Add the files to src/ or fix the icon name in log/devices.json; the default icons are used meanwhile."}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// logDirWritableCheck writes and removes a temporary file, which catches
+// read-only folders and permission problems before a check-in is lost.
+func logDirWritableCheck() diagnosticCheck {
+	check := diagnosticCheck{Name: "Log directory writable", Detail: "yes", OK: true}
+	fail := func(err error) diagnosticCheck {
+		return diagnosticCheck{Name: check.Name, Detail: err.Error(),
+			Hint: "Move the app to a folder you can write to, or fix the permissions on " + absPath(logDir) + "."}
+	}
+	if err := probeWrite(logDir); err != nil {
+		return fail(err)
+	}
+	return check
+}
+
+func memberFileChecks() []diagnosticCheck {
+	check := diagnosticCheck{Name: "Membership file", Detail: absPath(memberFile)}
+	info, err := os.Stat(memberFile)
+	switch {
+	case os.IsNotExist(err):
+		check.Detail += " (missing)"
+		check.Hint = "Put membership.csv next to the app; new members will be added to it."
+		return []diagnosticCheck{check}
+	case err != nil:
+		check.Detail = err.Error()
+		check.Hint = "Check that membership.csv is readable."
+		return []diagnosticCheck{check}
+	}
+	check.OK = true
+	check.Detail += fmt.Sprintf(" (%d members, %d bytes)", len(store.Members), info.Size())
+	checks := []diagnosticCheck{check}
+	for _, warning := range store.MemberWarnings {
+		checks = append(checks, diagnosticCheck{Name: "Membership file", Detail: warning,
+			Hint: "Open membership.csv and make sure it has Name and ID columns with a value in each row."})
+	}
+	if len(store.MemberChanges) > 0 && memberFlushFailed {
+		checks = append(checks, diagnosticCheck{Name: "Unsaved members", Detail: fmt.Sprintf("%d change(s) waiting to be written", len(store.MemberChanges)),
+			Hint: "Close membership.csv in Excel or any other program that has it open."})
+	}
+	return checks
+}
+
+// failedWritesCheck reports the background writes that failed for good
+// since startup.
+func failedWritesCheck() diagnosticCheck {
+	writeErrors.Lock()
+	count, last := writeErrors.count, writeErrors.last
+	writeErrors.Unlock()
+	if count == 0 {
+		return diagnosticCheck{Name: "Failed writes", Detail: "none", OK: true}
+	}
+	return diagnosticCheck{Name: "Failed writes", Detail: fmt.Sprintf("%d since startup; last: %v", count, last),
+		Hint: fmt.Sprintf("Changes were kept on screen but may not be on disk. Free up space or fix the permissions on %s.", absPath(logDir))}
+}
+
+func todaysLogCheck() diagnosticCheck {
+	p := store.LogFilePathForDate(state.TodaysLogDate())
+	check := diagnosticCheck{Name: "Today's log", Detail: absPath(p)}
+	info, err := os.Stat(p)
+	if os.IsNotExist(err) {
+		check.OK = true
+		check.Detail += " (not created yet)"
+		return check
+	}
+	if err != nil {
+		check.Detail = err.Error()
+		check.Hint = "Check the permissions on the log folder."
+		return check
+	}
+	entries, err := store.ReadDailyLogEntriesLocked()
+	if err != nil {
+		check.Detail = err.Error()
+		check.Hint = "The log file is damaged; if the app crashed, restart it to recover from the journal, or restore the file from a backup."
+		return check
+	}
+	check.OK = true
+	check.Detail += fmt.Sprintf(" (%d entries, %d bytes)", len(entries), info.Size())
+	return check
+}
+
+func showDiagnosticsDialog() {
+	checks := runDiagnostics()
+	rows := container.NewVBox()
+	lines := make([]string, 0, len(checks))
+	failed := 0
+	for _, check := range checks {
+		lines = append(lines, check.String())
+		icon := widget.NewIcon(theme.ConfirmIcon())
+		if !check.OK {
+			icon.SetResource(theme.ErrorIcon())
+			failed++
+		}
+		name := widget.NewLabelWithStyle(check.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		detail := widget.NewLabel(check.Detail)
+		detail.Wrapping = fyne.TextWrapWord
+		text := container.NewVBox(name, detail)
+		if !check.OK && check.Hint != "" {
+			hint := widget.NewLabelWithStyle(check.Hint, fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+			hint.Wrapping = fyne.TextWrapWord
+			text.Add(hint)
+		}
+		rows.Add(container.NewBorder(nil, nil, container.NewCenter(icon), nil, text))
+	}
+	summary := "All checks passed."
+	if failed > 0 {
+		summary = fmt.Sprintf("%d check(s) failed. Take a screenshot or copy the report when asking for help.", failed)
+	}
+	copyButton := widget.NewButtonWithIcon("Copy report", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(summary + "\n\n" + strings.Join(lines, "\n"))
+	})
+	occupancyButton := widget.NewButton("Check occupancy", func() { checkConsistency(false) })
+	appLogButton := widget.NewButton("View application log", showAppLogDialog)
+	repairButton := widget.NewButton("Repair log", showLogRepairDialog)
+	// Images are cached, so ones replaced in imgBaseDir show only after this.
+	reloadImagesButton := widget.NewButton("Reload images", func() {
+		iconCache.Invalidate()
+		refreshTrigger <- true
+	})
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(560, 420))
+	content := container.NewBorder(widget.NewLabel(summary), container.NewHBox(copyButton, occupancyButton, appLogButton, repairButton, reloadImagesButton), nil, nil, scroll)
+	dialog.ShowCustom("Diagnostics", "Close", content, mainWindow)
+}
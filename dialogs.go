@@ -0,0 +1,603 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+func buildInlineCheckInForm() *fyne.Container {
+	checkInNameEntry = widget.NewEntry()
+	checkInNameEntry.SetPlaceHolder("Full Name")
+
+	checkInIDEntry = widget.NewEntry()
+	checkInIDEntry.SetPlaceHolder("User ID")
+
+	checkInSearchEntry = widget.NewEntry()
+	checkInSearchEntry.SetPlaceHolder("Search Member (Name or ID)")
+
+	filteredMembersForInline = nil
+	footer := ""
+	var inlineUsage state.DailyUsage
+	noteBanner := newMemberNoteBanner()
+	courseSelect := newCourseSelect()
+	prefillCourse := lastCoursePrefill(courseSelect)
+	checkInIDEntry.OnChanged = func(id string) {
+		noteBanner.SetMemberID(id)
+		prefillCourse(id)
+	}
+
+	checkInResultsList = widget.NewList(
+		func() int {
+			if footer != "" {
+				return len(filteredMembersForInline) + 1
+			}
+			return len(filteredMembersForInline)
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= 0 && i < len(filteredMembersForInline) {
+				m := filteredMembersForInline[i]
+				o.(*widget.Label).SetText(fmt.Sprintf("%s (%s)%s", m.Name, m.ID, allowanceText(inlineUsage, m.ID)))
+			} else if i == len(filteredMembersForInline) {
+				o.(*widget.Label).SetText(footer)
+			}
+		},
+	)
+	resultsScroll := container.NewScroll(checkInResultsList)
+	resultsScroll.SetMinSize(fyne.NewSize(0, 120))
+	resultsScroll.Hide()
+
+	checkInResultsList.OnSelected = func(i widget.ListItemID) {
+		if i < 0 || i >= len(filteredMembersForInline) {
+			checkInResultsList.UnselectAll()
+			return
+		}
+		m := filteredMembersForInline[i]
+		checkInNameEntry.SetText(m.Name)
+		checkInIDEntry.SetText(m.ID)
+		checkInSearchEntry.SetText("")
+		filteredMembersForInline = nil
+		footer = ""
+		checkInResultsList.UnselectAll()
+		checkInResultsList.Refresh()
+		resultsScroll.Hide()
+		if mainWindow != nil {
+			mainWindow.Canvas().Focus(checkInIDEntry)
+		}
+	}
+
+	search := store.NewMemberSearch()
+	searchDelay := &debouncer{delay: memberSearchDelay}
+	checkInSearchEntry.OnChanged = func(string) {
+		searchDelay.call(func() {
+			if checkInSearchEntry.Text != "" && membersStillLoading(func() { checkInSearchEntry.OnChanged(checkInSearchEntry.Text) }) {
+				filteredMembersForInline, footer = nil, membersLoadingText
+				checkInResultsList.Refresh()
+				resultsScroll.Show()
+				return
+			}
+			matches, total := search.Find(checkInSearchEntry.Text, memberSearchLimit)
+			filteredMembersForInline = matches
+			inlineUsage = store.DailyUsageToday(time.Now())
+			footer = memberSearchFooter(len(matches), total)
+			checkInResultsList.Refresh()
+			if len(matches) > 0 {
+				resultsScroll.Show()
+			} else {
+				resultsScroll.Hide()
+			}
+		})
+	}
+
+	noIDButton := widget.NewButton("No ID?", func() {
+		whenMembersReady(func() { checkInIDEntry.SetText(state.GeneratedIDPrefix + store.NextMemberID()) })
+	})
+	checkInPurposeSelect = newPurposeSelect()
+	participantCheck := newEventParticipantCheck()
+	testCheck := newTestSessionCheck()
+	waitingSelect, waitingFor := newWaitingForSelect()
+	addButton := newSingleFlightButton("Add to Queue", nil, func(done func()) {
+		name := strings.TrimSpace(checkInNameEntry.Text)
+		id := strings.TrimSpace(checkInIDEntry.Text)
+		if name == "" || id == "" {
+			dialog.ShowError(fmt.Errorf("name and ID are required"), mainWindow)
+			done()
+			return
+		}
+		purpose := checkInPurposeSelect.Selected
+		course := selectedCourse(courseSelect)
+		opts := state.RegisterOptions{Purpose: purpose, Course: course, Source: state.SourceQueue, ExcludeFromStats: testCheck.Checked, WaitingFor: waitingFor()}
+		registerUserWithChecks(name, id, 0, opts, isWalkIn(participantCheck), func(queued bool) {
+			done()
+			if !queued {
+				return
+			}
+			rememberPurpose(purpose)
+			if courseSelect != nil {
+				rememberCourse(id, course)
+				courseSelect.ClearSelected()
+			}
+			checkInNameEntry.SetText("")
+			checkInIDEntry.SetText("")
+			testCheck.SetChecked(false)
+			participantCheck.SetChecked(false)
+			waitingSelect.SetSelected(waitingForAny)
+			if pendingIconsBox != nil {
+				refreshPendingIcons()
+			}
+		})
+	})
+	hideButton := widget.NewButton("Hide", func() { setCheckInPanelShown(false) })
+
+	idRow := container.NewBorder(nil, nil, nil, noIDButton, checkInIDEntry)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", checkInNameEntry),
+		widget.NewFormItem("ID", idRow),
+		widget.NewFormItem("Purpose", checkInPurposeSelect),
+	}
+	if courseSelect != nil {
+		items = append(items, widget.NewFormItem("Course", courseSelect))
+	}
+	items = append(items,
+		widget.NewFormItem("Waiting for", waitingSelect),
+		widget.NewFormItem("", participantCheck),
+		widget.NewFormItem("", testCheck),
+	)
+	form := widget.NewForm(items...)
+
+	header := widget.NewLabelWithStyle("Queue Check-In", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	bar := container.NewBorder(nil, nil, nil, hideButton, header)
+	body := container.NewVBox(
+		checkInSearchEntry,
+		resultsScroll,
+		noteBanner.container,
+		form,
+		container.NewHBox(layout.NewSpacer(), addButton),
+	)
+	card := container.NewVBox(bar, widget.NewSeparator(), body)
+
+	wrapper := container.New(&leftRatioLayout{ratio: 0.25, minW: 360, maxW: 560}, container.NewPadded(card))
+	return wrapper
+}
+
+func showConsoleCheckoutDialog(d state.Device) {
+	userIDs := store.ActiveUserIDsOnDevice(d.ID)
+	if len(userIDs) == 0 {
+		return
+	}
+	users := make([]state.User, 0, len(userIDs))
+	for _, id := range userIDs {
+		if u := store.UserByID(id); u != nil {
+			users = append(users, *u)
+		} else {
+			users = append(users, state.User{ID: id, Name: "Unknown User", PCID: d.ID})
+		}
+	}
+	showCheckoutPicker("Checkout From "+d.Name(), "User on "+d.Name(), users, d.ID)
+}
+
+func showCheckInDialogShared(deviceID int, fixed bool) {
+	showCheckInDialogFor(deviceID, fixed, nil)
+}
+
+// showCheckInDialogFor is the check-in dialog, pre-filled from prefill when it
+// is not nil.
+func showCheckInDialogFor(deviceID int, fixed bool, prefill *state.User) {
+	const (
+		dialogWidth             float32 = 460
+		dialogBaseHeight        float32 = 300
+		dialogResultsListHeight float32 = 110
+	)
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Search Existing Member (Name/ID)...")
+	nameEntry := widget.NewEntry()
+	idEntry := widget.NewEntry()
+	deviceEntry := widget.NewEntry()
+	var deviceField fyne.CanvasObject = deviceEntry
+	deviceHint := widget.NewLabel("")
+	deviceHint.Importance = widget.DangerImportance
+	deviceHint.Hide()
+	purposeSelect := newPurposeSelect()
+	courseSelect := newCourseSelect()
+	prefillCourse := lastCoursePrefill(courseSelect)
+	participantCheck := newEventParticipantCheck()
+	testCheck := newTestSessionCheck()
+
+	nameEntry.SetPlaceHolder("Full Name")
+	idEntry.SetPlaceHolder("ID")
+
+	noID := widget.NewButton("No ID?", func() {
+		whenMembersReady(func() { idEntry.SetText(state.GeneratedIDPrefix + store.NextMemberID()) })
+	})
+	noID.Resize(fyne.NewSize(55, 25))
+
+	if fixed {
+		deviceEntry.SetText(strconv.Itoa(deviceID))
+		deviceEntry.Disable()
+	} else {
+		// Free devices to pick from, or any ID typed by hand.
+		deviceSelect := widget.NewSelectEntry(freeDeviceChoices())
+		deviceSelect.SetPlaceHolder("Choose or enter Device ID")
+		deviceEntry = &deviceSelect.Entry
+		deviceField = container.NewVBox(deviceSelect, deviceHint)
+	}
+	if prefill != nil {
+		nameEntry.SetText(prefill.Name)
+		idEntry.SetText(prefill.ID)
+		if prefill.Purpose != "" {
+			purposeSelect.SetSelected(prefill.Purpose)
+		}
+		if courseSelect != nil && prefill.Course != "" {
+			courseSelect.SetSelected(prefill.Course)
+		}
+		if device := store.DeviceByID(prefill.PCID); !fixed && device != nil && device.Status == "free" {
+			deviceEntry.SetText(strconv.Itoa(device.ID))
+		}
+	}
+
+	var filtered []state.Member
+	var footer string
+	var usage state.DailyUsage
+	var results *widget.List
+	var dlg *dialog.CustomDialog
+	var confirmButton *widget.Button
+	noteBanner := newMemberNoteBanner()
+
+	results = widget.NewList(
+		func() int {
+			if footer != "" {
+				return len(filtered) + 1
+			}
+			return len(filtered)
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i >= 0 && i < len(filtered) {
+				o.(*widget.Label).SetText(fmt.Sprintf("%s (%s)%s", filtered[i].Name, filtered[i].ID, allowanceText(usage, filtered[i].ID)))
+			} else if i == len(filtered) {
+				o.(*widget.Label).SetText(footer)
+			}
+		})
+
+	scroll := container.NewScroll(results)
+	scroll.SetMinSize(fyne.NewSize(dialogWidth-40, dialogResultsListHeight-10))
+	scroll.Hide()
+
+	resizeDialog := func() {
+		if dlg == nil {
+			return
+		}
+		height := dialogBaseHeight
+		if scroll.Visible() {
+			height += dialogResultsListHeight
+		}
+		if noteBanner.container.Visible() {
+			height += noteBanner.container.MinSize().Height
+		}
+		if deviceHint.Visible() {
+			height += deviceHint.MinSize().Height
+		}
+		dlg.Resize(fyne.NewSize(dialogWidth, height))
+	}
+	// checkDevice flags the device field as it is typed and keeps Check In
+	// disabled until it names a device the user can be seated on.
+	checkDevice := func() {
+		if fixed || confirmButton == nil {
+			return
+		}
+		id, problem := checkDeviceField(deviceEntry.Text)
+		deviceHint.SetText(problem)
+		deviceHint.Hidden = problem == ""
+		deviceHint.Refresh()
+		if id == 0 {
+			confirmButton.Disable()
+		} else {
+			confirmButton.Enable()
+		}
+		resizeDialog()
+	}
+	deviceEntry.OnChanged = func(string) { checkDevice() }
+	idEntry.OnChanged = func(id string) {
+		noteBanner.SetMemberID(id)
+		prefillCourse(id)
+		resizeDialog()
+	}
+
+	results.OnSelected = func(i widget.ListItemID) {
+		if i >= 0 && i < len(filtered) {
+			m := filtered[i]
+			nameEntry.SetText(m.Name)
+			idEntry.SetText(m.ID)
+			search.SetText("")
+			scroll.Hide()
+			results.UnselectAll()
+			filtered = []state.Member{}
+			footer = ""
+			results.Refresh()
+			resizeDialog()
+			return
+		}
+		results.UnselectAll()
+	}
+
+	memberSearch := store.NewMemberSearch()
+	searchDelay := &debouncer{delay: memberSearchDelay}
+	search.OnChanged = func(string) {
+		searchDelay.call(func() {
+			if search.Text != "" && membersStillLoading(func() { search.OnChanged(search.Text) }) {
+				filtered, footer = nil, membersLoadingText
+				results.Refresh()
+				scroll.Show()
+				resizeDialog()
+				return
+			}
+			var total int
+			filtered, total = memberSearch.Find(search.Text, memberSearchLimit)
+			footer = memberSearchFooter(len(filtered), total)
+			usage = store.DailyUsageToday(time.Now())
+			results.Refresh()
+
+			if len(filtered) > 0 {
+				scroll.Show()
+			} else {
+				scroll.Hide()
+			}
+			resizeDialog()
+		})
+	}
+
+	userIDRow := container.NewBorder(nil, nil, nil, noID, idEntry)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name:", nameEntry),
+		widget.NewFormItem("User ID:", userIDRow),
+		widget.NewFormItem("Device ID:", deviceField),
+		widget.NewFormItem("Purpose:", purposeSelect),
+	}
+	if courseSelect != nil {
+		items = append(items, widget.NewFormItem("Course:", courseSelect))
+	}
+	items = append(items,
+		widget.NewFormItem("", participantCheck),
+		widget.NewFormItem("", testCheck),
+	)
+	form := widget.NewForm(items...)
+
+	// onConfirm hides the dialog as soon as the input is valid so a second
+	// click cannot register the same user again; it comes back with the
+	// input intact if the check-in fails.
+	onConfirm := func(flightDone func()) {
+		done := func() {
+			flightDone()
+			checkDevice()
+		}
+		uid := strings.TrimSpace(idEntry.Text)
+		name := strings.TrimSpace(nameEntry.Text)
+
+		if name == "" || uid == "" {
+			dialog.ShowError(fmt.Errorf("name and ID are required"), mainWindow)
+			done()
+			return
+		}
+
+		targetDeviceID := 0
+		if fixed {
+			targetDeviceID = deviceID
+		} else {
+			var problem string
+			targetDeviceID, problem = checkDeviceField(deviceEntry.Text)
+			if targetDeviceID == 0 {
+				if problem == "" {
+					problem = "device ID is required"
+				}
+				dialog.ShowError(errors.New(problem), mainWindow)
+				done()
+				return
+			}
+		}
+
+		purpose := purposeSelect.Selected
+		course := selectedCourse(courseSelect)
+		dlg.Hide()
+		finish := func(ok bool) {
+			done()
+			if !ok {
+				dlg.Show()
+				return
+			}
+			rememberPurpose(purpose)
+			if courseSelect != nil {
+				rememberCourse(uid, course)
+			}
+		}
+		opts := state.RegisterOptions{Purpose: purpose, Course: course, Source: state.SourceDesk, ExcludeFromStats: testCheck.Checked}
+		if fixed {
+			opts.Source = state.SourceMap
+		}
+		registerUserWithChecks(name, uid, targetDeviceID, opts, isWalkIn(participantCheck), finish)
+	}
+
+	content := container.NewVBox(search, scroll, noteBanner.container, form)
+
+	dlg = dialog.NewCustomWithoutButtons("Check In User", content, mainWindow)
+	confirmButton = newSingleFlightButton("Check In", theme.ConfirmIcon(), onConfirm)
+	confirmButton.Importance = widget.HighImportance
+	cancelButton := widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), dlg.Hide)
+	dlg.SetButtons([]fyne.CanvasObject{cancelButton, confirmButton})
+	checkDevice()
+	if fixed {
+		trackDeviceDialog(deviceID, dlg)
+	}
+	dlg.Resize(fyne.NewSize(dialogWidth, dialogBaseHeight))
+	dlg.Show()
+}
+
+func showCheckInDialog() { showCheckInDialogShared(0, false) }
+
+func showCheckOutDialog() {
+	if len(store.ActiveUsers) == 0 {
+		dialog.ShowInformation("Check Out", "No active users to check out.", mainWindow)
+		return
+	}
+	users := append([]state.User{}, store.ActiveUsers...)
+	showCheckoutPicker("Check Out User", "User", users, 0)
+}
+
+func showSwitchStationDialog() {
+	if len(store.ActiveUsers) == 0 {
+		dialog.ShowInformation("Switch Station", "No active users to move.", mainWindow)
+		return
+	}
+	freeDevices := []state.Device{}
+	for _, d := range store.Devices {
+		if d.Status == "free" {
+			freeDevices = append(freeDevices, d)
+		}
+	}
+	if len(freeDevices) == 0 {
+		dialog.ShowInformation("Switch Station", "No available stations.", mainWindow)
+		return
+	}
+
+	userLabels := make([]string, len(store.ActiveUsers))
+	userIDs := make([]string, len(store.ActiveUsers))
+	for i, u := range store.ActiveUsers {
+		name := u.Name
+		if len(name) > 25 {
+			name = name[:22] + "..."
+		}
+		userLabels[i] = fmt.Sprintf("%s (Current: %d)", name, u.PCID)
+		userIDs[i] = u.ID
+	}
+	deviceLabels := make([]string, len(freeDevices))
+	deviceIDs := make([]int, len(freeDevices))
+	for i, d := range freeDevices {
+		deviceLabels[i] = d.Name()
+		deviceIDs[i] = d.ID
+	}
+
+	userSelector := widget.NewSelectEntry(userLabels)
+	userSelector.SetPlaceHolder("Select user")
+	deviceSelector := widget.NewSelectEntry(deviceLabels)
+	deviceSelector.SetPlaceHolder("Select available station")
+
+	items := []*widget.FormItem{
+		{Text: "User", Widget: userSelector},
+		{Text: "Target Station", Widget: deviceSelector},
+	}
+
+	dlg := dialog.NewForm("Switch Station", "Switch", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		userChoice := strings.TrimSpace(userSelector.Text)
+		deviceChoice := strings.TrimSpace(deviceSelector.Text)
+		if userChoice == "" || deviceChoice == "" {
+			dialog.ShowError(fmt.Errorf("select a user and a station"), mainWindow)
+			return
+		}
+		var userID string
+		for i, label := range userLabels {
+			if label == userChoice {
+				userID = userIDs[i]
+				break
+			}
+		}
+		if userID == "" {
+			dialog.ShowError(fmt.Errorf("invalid user selection"), mainWindow)
+			return
+		}
+		var deviceID int
+		for i, label := range deviceLabels {
+			if label == deviceChoice {
+				deviceID = deviceIDs[i]
+				break
+			}
+		}
+		if deviceID == 0 {
+			dialog.ShowError(fmt.Errorf("invalid station selection"), mainWindow)
+			return
+		}
+		switchDeviceConfirming(userID, deviceID)
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(460, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+// showCloseDialog intercepts closing the main window. With users still active
+// staff choose between checking everyone out, keeping the sessions for the next
+// launch, or cancelling.
+func showCloseDialog() {
+	if trainingMode {
+		showExitTrainingDialog()
+		return
+	}
+	if readOnly {
+		// Sessions and the day's summary belong to the desk holding the lock.
+		mainWindow.Close()
+		return
+	}
+	if len(store.ActiveUsers) == 0 {
+		shutdown()
+		return
+	}
+	lines := make([]string, 0, len(store.ActiveUsers))
+	for _, u := range store.ActiveUsers {
+		where := "queue"
+		if u.PCID != 0 {
+			where = fmt.Sprintf("device %d", u.PCID)
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s) - %s, %s", u.Name, u.ID, where, state.FormatDuration(time.Since(u.CheckInTime))))
+	}
+	list := widget.NewLabel(strings.Join(lines, "\n"))
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(420, 160))
+
+	var dlg dialog.Dialog
+	checkoutAll := widget.NewButton("Check out all and exit", func() {
+		dlg.Hide()
+		requireStaffPIN("Check Out All", func() {
+			ids := make([]string, 0, len(store.ActiveUsers))
+			for _, u := range store.ActiveUsers {
+				ids = append(ids, u.ID)
+			}
+			for _, id := range ids {
+				if err := store.Checkout(id); err != nil {
+					slog.Error("checking out at close", "user", id, "err", err)
+				}
+			}
+			// Everyone has left; an exit keeping sessions keeps the
+			// headcount too, for a restart later in the day.
+			store.ResetLoungeCount()
+			shutdown()
+		})
+	})
+	checkoutAll.Importance = widget.DangerImportance
+	keep := widget.NewButton("Exit keeping sessions", func() {
+		dlg.Hide()
+		shutdown()
+	})
+	cancel := widget.NewButton("Cancel", func() { dlg.Hide() })
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("%d user(s) are still checked in:", len(store.ActiveUsers))),
+		scroll,
+		container.NewHBox(layout.NewSpacer(), checkoutAll, keep, cancel),
+	)
+	dlg = dialog.NewCustomWithoutButtons("Close Lounge", container.NewPadded(content), mainWindow)
+	dlg.Show()
+}
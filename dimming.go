@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// dimScrimColor is laid over the desk while it is dimmed, taking the edge off
+// icons and text that the dark theme leaves bright.
+var dimScrimColor = color.NRGBA{A: 90}
+
+var (
+	// dimmed is whether the wall display (and with DimMainWindow the desk)
+	// is dimmed right now. It is presentation only; nothing is saved.
+	dimmed    bool
+	dimScrim  *canvas.Rectangle
+	dimButton *widget.Button
+	// dimManual is staff's "Dim now" or "Undim" choice, which holds until
+	// dimManualUntil, the next boundary of the schedule; zero means until
+	// staff toggle again.
+	dimManual      bool
+	dimManualOn    bool
+	dimManualUntil time.Time
+)
+
+// parseScheduleTime reads a schedule boundary, e.g. "21:30"; blank is allowed.
+func parseScheduleTime(label, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", nil
+	}
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		return "", fmt.Errorf("%s must be a time like 21:30", label)
+	}
+	return t.Format("15:04"), nil
+}
+
+// scheduleBoundaries returns a daily schedule's "15:04" start and end on
+// now's date, or false when the schedule is off.
+func scheduleBoundaries(fromText, untilText string, now time.Time) (from, until time.Time, ok bool) {
+	start, err1 := time.Parse("15:04", fromText)
+	end, err2 := time.Parse("15:04", untilText)
+	if err1 != nil || err2 != nil || start.Equal(end) {
+		return time.Time{}, time.Time{}, false
+	}
+	on := func(t time.Time) time.Time {
+		return time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	}
+	return on(start), on(end), true
+}
+
+// inSchedule reports whether now falls in a daily schedule, which may run
+// past midnight, e.g. 21:00 to 07:00.
+func inSchedule(fromText, untilText string, now time.Time) bool {
+	from, until, ok := scheduleBoundaries(fromText, untilText, now)
+	switch {
+	case !ok:
+		return false
+	case from.Before(until):
+		return !now.Before(from) && now.Before(until)
+	}
+	return !now.Before(from) || now.Before(until)
+}
+
+// nextDimBoundary is the next time the schedule dims or brightens, or zero
+// when it is off.
+func nextDimBoundary(now time.Time) time.Time {
+	from, until, ok := scheduleBoundaries(appSettings.DimFrom, appSettings.DimUntil, now)
+	if !ok {
+		return time.Time{}
+	}
+	next := time.Time{}
+	for _, t := range []time.Time{from, until, from.AddDate(0, 0, 1), until.AddDate(0, 0, 1)} {
+		if t.After(now) && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return next
+}
+
+// newDimScrim covers the main window while dimmed. A rectangle takes no
+// input, so everything underneath still works.
+func newDimScrim() fyne.CanvasObject {
+	dimScrim = canvas.NewRectangle(dimScrimColor)
+	dimScrim.Hide()
+	return dimScrim
+}
+
+func newDimButton() *widget.Button {
+	dimButton = widget.NewButtonWithIcon("", theme.VisibilityOffIcon(), toggleDimNow)
+	dimButton.Importance = widget.LowImportance
+	refreshDimButton()
+	return dimButton
+}
+
+func refreshDimButton() {
+	if dimButton == nil {
+		return
+	}
+	if dimmed {
+		dimButton.SetText("Undim")
+		dimButton.SetIcon(theme.VisibilityIcon())
+	} else {
+		dimButton.SetText("Dim now")
+		dimButton.SetIcon(theme.VisibilityOffIcon())
+	}
+}
+
+// toggleDimNow overrides the schedule until its next boundary.
+func toggleDimNow() {
+	now := time.Now()
+	dimManual, dimManualOn, dimManualUntil = true, !dimmed, nextDimBoundary(now)
+	applyDimming()
+}
+
+// applyDimming runs on the ticker and after the settings change. It works
+// out whether to dim and, on a change, tells the wall displays and restyles
+// the desk.
+func applyDimming() {
+	now := time.Now()
+	if dimManual && !dimManualUntil.IsZero() && !now.Before(dimManualUntil) {
+		dimManual = false
+	}
+	want := inSchedule(appSettings.DimFrom, appSettings.DimUntil, now)
+	if dimManual {
+		want = dimManualOn
+	}
+	changed := want != dimmed
+	dimmed = want
+	desk := dimmed && appSettings.DimMainWindow
+	if dimScrim != nil && desk != dimScrim.Visible() {
+		if desk {
+			fyne.CurrentApp().Settings().SetTheme(NewCatppuccinMochaTheme())
+			dimScrim.Show()
+		} else {
+			fyne.CurrentApp().Settings().SetTheme(NewCatppuccinLatteTheme())
+			dimScrim.Hide()
+		}
+	}
+	refreshDimButton()
+	if changed {
+		publishDisplayState()
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"time"
+
+	"lounge/internal/display"
+	"lounge/internal/state"
+)
+
+// displayServer feeds wall displays; nil while no address is configured.
+var displayServer *display.Server
+
+// displayLink is the phone availability page the wall displays show as a QR
+// code; see availabilityLink.
+var displayLink string
+
+// displayDevice and displayQueued are the public view of the lounge: first
+// and last names only, never IDs.
+type displayDevice struct {
+	ID        int      `json:"id"`
+	Type      string   `json:"type"`
+	Status    string   `json:"status"`
+	Occupants []string `json:"occupants,omitempty"`
+}
+
+type displayQueued struct {
+	Name  string    `json:"name"`
+	Since time.Time `json:"since"`
+}
+
+type displaySnapshot struct {
+	Time    time.Time       `json:"time"`
+	Event   string          `json:"event,omitempty"`
+	Devices []displayDevice `json:"devices"`
+	Queue   []displayQueued `json:"queue"`
+	// Dimmed asks the display to switch to its night look.
+	Dimmed bool `json:"dimmed,omitempty"`
+	// Scale multiplies the display's text sizes, from DisplayScale.
+	Scale float32 `json:"scale"`
+	// Link is the phone availability page; the display shows /qr.svg,
+	// which encodes it, in a corner.
+	Link string `json:"link,omitempty"`
+}
+
+// displayEvent is one change pushed on /events; State is the snapshot after
+// it, so a display can simply redraw.
+type displayEvent struct {
+	Device int             `json:"device,omitempty"`
+	Name   string          `json:"name,omitempty"`
+	On     bool            `json:"on,omitempty"`
+	State  displaySnapshot `json:"state"`
+}
+
+// displaySeen is what the last publish saw, for working out what changed.
+var displaySeen struct {
+	users   map[string]int // user ID -> device, 0 = queued
+	names   map[string]string
+	devices map[int]string // device ID -> status
+	dimmed  bool
+}
+
+// configureDisplayServer (re)starts the wall display server on
+// appSettings.DisplayServerAddr; blank stops it.
+func configureDisplayServer() error {
+	if displayServer != nil {
+		if err := displayServer.Close(); err != nil {
+			slog.Error("stopping wall display server", "err", err)
+		}
+		displayServer = nil
+	}
+	displayLink = ""
+	if appSettings.DisplayServerAddr == "" {
+		return nil
+	}
+	server := display.NewServer()
+	server.Stats = displayStatsJSON
+	if err := server.Start(appSettings.DisplayServerAddr); err != nil {
+		return err
+	}
+	if link := availabilityLink(appSettings.DisplayServerAddr); link != "" {
+		if err := server.SetLink(link); err != nil {
+			slog.Error("drawing availability QR code", "link", link, "err", err)
+		} else {
+			displayLink = link
+			slog.Info("phone availability page", "link", link)
+		}
+	}
+	displayServer = server
+	publishDisplayState()
+	return nil
+}
+
+// availabilityLink is where phones reach /availability on the server
+// listening on addr. An address without a host, like ":8080", listens on
+// every interface, so the link uses this computer's LAN address.
+func availabilityLink(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return ""
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = lanAddress()
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/availability"
+}
+
+// lanAddress is this computer's first non-loopback IPv4 address, or
+// "localhost" when it has none.
+func lanAddress() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		slog.Warn("listing network addresses", "err", err)
+		return "localhost"
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+	return "localhost"
+}
+
+// buildAvailability is the counts-only view for /availability.
+func buildAvailability() display.Availability {
+	a := display.Availability{Time: time.Now(), Queue: len(store.PendingUsers())}
+	for _, device := range store.Devices {
+		switch device.Type {
+		case "PC":
+			a.PCs++
+			if device.Status == "free" {
+				a.FreePCs++
+			}
+		case "Console":
+			a.Consoles = append(a.Consoles, display.ConsoleAvailability{Name: device.Name(), Status: device.Status})
+		}
+	}
+	return a
+}
+
+func buildDisplaySnapshot() displaySnapshot {
+	snapshot := displaySnapshot{Time: time.Now(), Event: store.Event, Dimmed: dimmed, Scale: scaleFactor(appSettings.DisplayScale),
+		Link: displayLink, Devices: []displayDevice{}, Queue: []displayQueued{}}
+	for _, device := range store.Devices {
+		entry := displayDevice{ID: device.ID, Type: device.Type, Status: device.Status}
+		for _, user := range store.UsersOnDevice(device.ID) {
+			entry.Occupants = append(entry.Occupants, firstLastNonEmpty(user.Name))
+		}
+		snapshot.Devices = append(snapshot.Devices, entry)
+	}
+	for _, user := range store.PendingUsers() {
+		snapshot.Queue = append(snapshot.Queue, displayQueued{Name: firstLastNonEmpty(user.Name), Since: user.CheckInTime})
+	}
+	return snapshot
+}
+
+// publishDisplayState runs on the UI goroutine with every state refresh. It
+// compares the store with what it saw last time and pushes one event per
+// check-in, checkout, queue change and maintenance toggle.
+func publishDisplayState() {
+	if displayServer == nil {
+		return
+	}
+	snapshot := buildDisplaySnapshot()
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		slog.Error("encoding wall display state", "err", err)
+		return
+	}
+	displayServer.SetSnapshot(data)
+	displayServer.SetAvailability(buildAvailability())
+
+	users := make(map[string]int, len(store.ActiveUsers))
+	names := make(map[string]string, len(store.ActiveUsers))
+	for _, user := range store.ActiveUsers {
+		users[user.ID] = user.PCID
+		names[user.ID] = firstLastNonEmpty(user.Name)
+	}
+	devices := make(map[int]string, len(store.Devices))
+	for _, device := range store.Devices {
+		devices[device.ID] = device.Status
+	}
+	if displaySeen.users != nil {
+		publish := func(kind string, event displayEvent) {
+			event.State = snapshot
+			if data, err := json.Marshal(event); err == nil {
+				displayServer.Publish(kind, data)
+			}
+		}
+		for id, deviceID := range users {
+			before, seen := displaySeen.users[id]
+			switch {
+			case !seen && deviceID == 0:
+				publish("queue", displayEvent{Name: names[id], On: true})
+			case !seen:
+				publish("checkin", displayEvent{Device: deviceID, Name: names[id]})
+			case before == 0 && deviceID != 0:
+				publish("queue", displayEvent{Name: names[id]})
+				publish("checkin", displayEvent{Device: deviceID, Name: names[id]})
+			case before != deviceID:
+				publish("switch", displayEvent{Device: deviceID, Name: names[id]})
+			}
+		}
+		for id, deviceID := range displaySeen.users {
+			if _, ok := users[id]; ok {
+				continue
+			}
+			if deviceID == 0 {
+				publish("queue", displayEvent{Name: displaySeen.names[id]})
+			} else {
+				publish("checkout", displayEvent{Device: deviceID, Name: displaySeen.names[id]})
+			}
+		}
+		for id, status := range devices {
+			if was := displaySeen.devices[id]; (was == state.StatusMaintenance) != (status == state.StatusMaintenance) {
+				publish("maintenance", displayEvent{Device: id, On: status == state.StatusMaintenance})
+			}
+		}
+		if dimmed != displaySeen.dimmed {
+			publish("dim", displayEvent{On: dimmed})
+		}
+	}
+	displaySeen.users, displaySeen.names, displaySeen.devices, displaySeen.dimmed = users, names, devices, dimmed
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// endingSoonBox lists the sessions near the session limit on the Device
+// Status tab; rebuilt with the tab.
+var endingSoonBox *fyne.Container
+
+// endingSoonText is how long a session has left, e.g. "12m left" or
+// "over by 5m".
+func endingSoonText(left time.Duration) string {
+	if left < 0 {
+		return "over by " + state.FormatDuration((-left).Round(time.Minute))
+	}
+	return state.FormatDuration(left.Round(time.Minute)) + " left"
+}
+
+// newEndingSoonBadge is the amber dot drawn on a device whose session is
+// near the limit.
+func newEndingSoonBadge() *canvas.Circle {
+	badge := canvas.NewCircle(latteYellow)
+	badge.StrokeColor = latteBase
+	badge.StrokeWidth = 2
+	badge.Hide()
+	return badge
+}
+
+// updateEndingSoonBadge places the badge in the icon's bottom right corner
+// while anyone on device is within the warning window.
+func (renderer *deviceStatusRenderer) updateEndingSoonBadge(device state.Device, badge *canvas.Circle, center fyne.Position, size float32) {
+	soon := false
+	now := time.Now()
+	for _, u := range store.UsersOnDevice(device.ID) {
+		if _, ok := store.SessionEndingSoon(u, now); ok {
+			soon = true
+			break
+		}
+	}
+	if !soon || renderer.widget.blankMap || renderer.widget.hideOccupants {
+		badge.Hide()
+		return
+	}
+	radius := clampFloat(size*0.11, 5, 11)
+	badge.Resize(fyne.NewSize(radius*2, radius*2))
+	badge.Move(fyne.NewPos(center.X+size/2-radius*1.5, center.Y+size/2-radius*1.5))
+	badge.Show()
+	badge.Refresh()
+}
+
+// newEndingSoonView is the "Ending Soon" list under the queue, least time
+// left first, so staff know whom to warn.
+func newEndingSoonView() fyne.CanvasObject {
+	endingSoonBox = container.NewVBox()
+	refreshEndingSoon()
+	return endingSoonBox
+}
+
+// refreshEndingSoon fills the list; the live tick keeps its times current.
+func refreshEndingSoon() {
+	if endingSoonBox == nil {
+		return
+	}
+	endingSoonBox.RemoveAll()
+	sessions := store.SessionsEndingSoon(time.Now())
+	if len(sessions) == 0 {
+		endingSoonBox.Refresh()
+		return
+	}
+	endingSoonBox.Add(widget.NewSeparator())
+	endingSoonBox.Add(widget.NewLabelWithStyle("Ending Soon", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	for _, session := range sessions {
+		text := fmt.Sprintf("%s · %s · %s", firstLastNonEmpty(session.User.Name), deviceNameByID(session.User.PCID), endingSoonText(session.Left))
+		label := canvas.NewText(text, readableColor(latteYellow))
+		if session.Left < 0 {
+			label.Color = latteRed
+		}
+		endingSoonBox.Add(label)
+	}
+	endingSoonBox.Refresh()
+}
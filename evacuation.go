@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	defaultEvacuationShortcut = "Ctrl+Shift+E"
+	evacuationQueueArea       = "Waiting in queue"
+)
+
+// evacuationWindow is the open evacuation list, so the hotkey raises it
+// rather than opening another.
+var evacuationWindow fyne.Window
+
+// evacuationGroup is one area of the room and the people in it.
+type evacuationGroup struct {
+	Area   string
+	People []evacuee
+}
+
+// evacuee is one person on the list: their name and device, and how to
+// reach them if they are missing.
+type evacuee struct {
+	Line    string
+	Contact string
+}
+
+// evacuationGroups lists everyone in the room by device type, then the queue.
+// It only reads in-memory state, so it works when the disk does not.
+func evacuationGroups() []evacuationGroup {
+	byArea := make(map[string][]evacuee)
+	var areas []string
+	for _, u := range store.ActiveUsers {
+		area, line := evacuationQueueArea, u.Name
+		if u.PCID != 0 {
+			area = deviceTypeName(u.PCID) + "s"
+			line = fmt.Sprintf("%s (%s)", u.Name, deviceNameByID(u.PCID))
+		}
+		if _, ok := byArea[area]; !ok {
+			areas = append(areas, area)
+		}
+		byArea[area] = append(byArea[area], evacuee{Line: line, Contact: contactText(u)})
+	}
+	sort.SliceStable(areas, func(i, j int) bool {
+		// The queue goes last; device areas are alphabetical.
+		if (areas[i] == evacuationQueueArea) != (areas[j] == evacuationQueueArea) {
+			return areas[j] == evacuationQueueArea
+		}
+		return areas[i] < areas[j]
+	})
+	groups := make([]evacuationGroup, 0, len(areas))
+	for _, area := range areas {
+		people := byArea[area]
+		sort.Slice(people, func(i, j int) bool { return people[i].Line < people[j].Line })
+		groups = append(groups, evacuationGroup{Area: area, People: people})
+	}
+	return groups
+}
+
+func evacuationText(now time.Time, groups []evacuationGroup) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "EVACUATION LIST - %s\n", formatLongDateTime(now))
+	fmt.Fprintf(&b, "People in the room: %d\n", store.Headcount().Total())
+	for _, group := range groups {
+		fmt.Fprintf(&b, "\n%s (%d)\n", group.Area, len(group.People))
+		for _, person := range group.People {
+			fmt.Fprintf(&b, "  [ ] %s - contact: %s\n", person.Line, person.Contact)
+		}
+	}
+	if store.LoungeCount > 0 {
+		fmt.Fprintf(&b, "\nLounge visitors without a device: %d\n", store.LoungeCount)
+	}
+	return b.String()
+}
+
+func evacuationHeading(text string, size float32) *canvas.Text {
+	heading := canvas.NewText(text, theme.ForegroundColor())
+	heading.TextSize = size
+	heading.TextStyle = fyne.TextStyle{Bold: true}
+	return heading
+}
+
+// showEvacuationList opens a full-screen list of everyone in the room for a
+// drill or an alarm. Dismissing it changes no session.
+func showEvacuationList() {
+	if evacuationWindow != nil {
+		evacuationWindow.RequestFocus()
+		return
+	}
+	now := time.Now()
+	groups := evacuationGroups()
+	text := evacuationText(now, groups)
+
+	title := evacuationHeading("EVACUATION LIST", 40)
+	title.Color = latteRed
+	summary := evacuationHeading(fmt.Sprintf("%d people in the room - %s", store.Headcount().Total(), now.Format(clockLayout(true))), 28)
+	body := container.NewVBox()
+	for _, group := range groups {
+		body.Add(evacuationHeading(fmt.Sprintf("%s (%d)", group.Area, len(group.People)), 28))
+		names := container.NewGridWrap(fyne.NewSize(460, 72))
+		for _, person := range group.People {
+			label := canvas.NewText(person.Line, theme.ForegroundColor())
+			label.TextSize = 26
+			contact := canvas.NewText(person.Contact, theme.ForegroundColor())
+			contact.TextSize = 18
+			names.Add(container.NewVBox(label, contact))
+		}
+		body.Add(names)
+	}
+	if store.LoungeCount > 0 {
+		body.Add(evacuationHeading(fmt.Sprintf("Lounge visitors without a device: %d", store.LoungeCount), 28))
+	}
+	if len(groups) == 0 && store.LoungeCount == 0 {
+		body.Add(evacuationHeading("Nobody is checked in.", 28))
+	}
+
+	w := fyne.CurrentApp().NewWindow("Evacuation List")
+	evacuationWindow = w
+	w.SetOnClosed(func() { evacuationWindow = nil })
+	save := widget.NewButtonWithIcon("Save for printing", theme.DocumentSaveIcon(), func() {
+		dlg := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+			if _, err := writer.Write([]byte(text)); err != nil {
+				dialog.ShowError(fmt.Errorf("write evacuation list: %w", err), w)
+			}
+		}, w)
+		dlg.SetFileName("evacuation-" + now.Format("2006-01-02-150405") + ".txt")
+		dlg.SetFilter(storage.NewExtensionFileFilter([]string{".txt"}))
+		dlg.Show()
+	})
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(text)
+	})
+	done := widget.NewButtonWithIcon("Mark all accounted for", theme.ConfirmIcon(), w.Close)
+	done.Importance = widget.HighImportance
+	buttons := container.NewHBox(save, copyButton, layout.NewSpacer(), done)
+	header := container.NewVBox(title, summary, widget.NewSeparator())
+	w.SetContent(container.NewPadded(container.NewBorder(header, buttons, nil, nil, container.NewVScroll(body))))
+	w.SetFullScreen(true)
+	w.Show()
+}
+
+// parseShortcut reads a hotkey such as "Ctrl+Shift+E" or "Alt+F12". Fyne
+// only delivers shortcuts with Ctrl, Alt or Super held, so one is required.
+func parseShortcut(text string) (*desktop.CustomShortcut, error) {
+	parts := strings.Split(text, "+")
+	shortcut := &desktop.CustomShortcut{}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i == len(parts)-1 {
+			if part == "" {
+				return nil, fmt.Errorf("shortcut %q has no key", text)
+			}
+			shortcut.KeyName = fyne.KeyName(strings.ToUpper(part[:1]) + part[1:])
+			continue
+		}
+		switch strings.ToLower(part) {
+		case "ctrl", "control":
+			shortcut.Modifier |= fyne.KeyModifierControl
+		case "shift":
+			shortcut.Modifier |= fyne.KeyModifierShift
+		case "alt":
+			shortcut.Modifier |= fyne.KeyModifierAlt
+		case "super", "cmd", "win":
+			shortcut.Modifier |= fyne.KeyModifierSuper
+		default:
+			return nil, fmt.Errorf("unknown modifier %q in shortcut %q", part, text)
+		}
+	}
+	if shortcut.Modifier&^fyne.KeyModifierShift == 0 {
+		return nil, fmt.Errorf("shortcut %q needs Ctrl, Alt or Super", text)
+	}
+	return shortcut, nil
+}
+
+// applyEvacuationShortcut puts the evacuation list in the main menu under the
+// configured hotkey. A menu shortcut works wherever focus is, which a canvas
+// shortcut does not while an entry is focused.
+func applyEvacuationShortcut() {
+	item := fyne.NewMenuItem("Evacuation List", showEvacuationList)
+	if appSettings.EvacuationShortcut != "" {
+		if shortcut, err := parseShortcut(appSettings.EvacuationShortcut); err == nil {
+			item.Shortcut = shortcut
+		} else {
+			slog.Error("reading evacuation shortcut", "err", err)
+		}
+	}
+	mainWindow.SetMainMenu(fyne.NewMainMenu(fyne.NewMenu("Emergency", item)))
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// eventBanner is the status bar notice shown while event mode is on.
+var eventBanner *canvas.Text
+
+func refreshEventBanner() {
+	if eventBanner == nil {
+		return
+	}
+	if store.Event == "" {
+		eventBanner.Text = ""
+		eventBanner.Hide()
+		return
+	}
+	eventBanner.Text = fmt.Sprintf("EVENT: %s - lounge reserved", store.Event)
+	eventBanner.Show()
+	eventBanner.Refresh()
+}
+
+func newEventBanner() *canvas.Text {
+	eventBanner = canvas.NewText("", latteRed)
+	eventBanner.TextStyle = fyne.TextStyle{Bold: true}
+	eventBanner.TextSize = 14
+	refreshEventBanner()
+	return eventBanner
+}
+
+// newEventParticipantCheck marks a check-in as part of the running event. It
+// is hidden outside event mode and starts unticked: a check-in is a walk-in,
+// which staff must confirm, unless they tick it.
+func newEventParticipantCheck() *widget.Check {
+	check := widget.NewCheck("Event participant", nil)
+	if store.Event == "" {
+		check.Hide()
+	}
+	return check
+}
+
+// isWalkIn reports whether a check-in made with participant is a walk-in
+// during an event.
+func isWalkIn(participant *widget.Check) bool {
+	return store.Event != "" && !participant.Checked
+}
+
+func setEventMode(name string) {
+	appSettings.EventName = strings.TrimSpace(name)
+	applySettings()
+	if err := saveSettings(); err != nil {
+		dialog.ShowError(err, mainWindow)
+	}
+	refreshEventBanner()
+	// The inline check-in form shows the participant check only in event
+	// mode, and it is built once, so build it again.
+	rebuildCheckInPanel()
+	refreshTrigger <- true
+}
+
+// showEventModeDialog starts or ends event mode. Ending it leaves sessions
+// already tagged with the event as they are.
+func showEventModeDialog() {
+	if store.Event != "" {
+		dialog.ShowConfirm("End Event",
+			fmt.Sprintf("End %s and reopen the lounge? Sessions already tagged with the event keep their tag.", store.Event),
+			func(ok bool) {
+				if ok {
+					event := store.Event
+					setEventMode("")
+					offerPreregNoShows(event)
+				}
+			}, mainWindow)
+		return
+	}
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Smash Weekly")
+	items := []*widget.FormItem{widget.NewFormItem("Event name", nameEntry)}
+	dlg := dialog.NewForm("Start Event", "Start", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		if strings.TrimSpace(nameEntry.Text) == "" {
+			dialog.ShowError(fmt.Errorf("event name is required"), mainWindow)
+			return
+		}
+		setEventMode(nameEntry.Text)
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(420, dlg.MinSize().Height))
+	dlg.Show()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	forecastStep    = 10 * time.Minute
+	forecastHorizon = time.Hour
+)
+
+// forecastStrip shows the expected free PCs for the next hour under the map,
+// for answering "should I wait or come back later?".
+var forecastStrip *fyne.Container
+
+func newForecastStrip() fyne.CanvasObject {
+	forecastStrip = container.NewHBox()
+	refreshForecastStrip()
+	return forecastStrip
+}
+
+// refreshForecastStrip recomputes the forecast; it runs with every refresh
+// and on the queue expiry tick so the times keep moving.
+func refreshForecastStrip() {
+	if forecastStrip == nil {
+		return
+	}
+	points := store.ForecastFreePCs(time.Now(), forecastStep, forecastHorizon)
+	if len(points) == 0 {
+		forecastStrip.Hide()
+		return
+	}
+	objects := []fyne.CanvasObject{
+		widget.NewLabelWithStyle("Estimate, next hour:", fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
+	}
+	for _, point := range points {
+		at := canvas.NewText(formatClock(point.Time), latteSubtext1)
+		at.TextSize = 10
+		at.Alignment = fyne.TextAlignCenter
+		free := canvas.NewText(fmt.Sprintf("%d free", point.Free), latteGreen)
+		if point.Free == 0 {
+			free.Color = latteRed
+		}
+		free.TextStyle = fyne.TextStyle{Bold: true}
+		free.Alignment = fyne.TextAlignCenter
+		objects = append(objects, container.NewPadded(container.NewVBox(at, free)))
+	}
+	forecastStrip.Objects = objects
+	forecastStrip.Show()
+	forecastStrip.Refresh()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// handoverText is the shift handover snapshot. It reads the same in-memory
+// state the device map draws from, so it always agrees with the screen. A
+// non-nil anon hides IDs and full names.
+func handoverText(now time.Time, anon *state.Anonymizer) string {
+	who := func(u state.User) string {
+		if anon != nil {
+			return fmt.Sprintf("%s (%s)", state.AnonymizeName(u.Name), anon.ID(u.ID))
+		}
+		return fmt.Sprintf("%s (%s)", u.Name, u.ID)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Shift handover - %s\n", formatLongDateTime(now))
+	if store.Event != "" {
+		fmt.Fprintf(&b, "Event: %s\n", store.Event)
+	}
+
+	var seated []state.User
+	for _, u := range store.ActiveUsers {
+		if u.PCID != 0 {
+			seated = append(seated, u)
+		}
+	}
+	sort.SliceStable(seated, func(i, j int) bool {
+		if seated[i].PCID != seated[j].PCID {
+			return seated[i].PCID < seated[j].PCID
+		}
+		return seated[i].CheckInTime.Before(seated[j].CheckInTime)
+	})
+	fmt.Fprintf(&b, "\nIn use (%d)\n", len(seated))
+	if len(seated) == 0 {
+		b.WriteString("  none\n")
+	}
+	for _, u := range seated {
+		fmt.Fprintf(&b, "  %s: %s since %s, %s", deviceNameByID(u.PCID), who(u),
+			formatClock(u.CheckInTime), state.FormatDuration(now.Sub(u.CheckInTime)))
+		if notes := handoverSessionNotes(u); notes != "" {
+			b.WriteString(" - " + notes)
+		}
+		b.WriteString("\n")
+	}
+
+	queued := store.PendingUsers()
+	fmt.Fprintf(&b, "\nQueue (%d)\n", len(queued))
+	if len(queued) == 0 {
+		b.WriteString("  empty\n")
+	}
+	for i, u := range queued {
+		fmt.Fprintf(&b, "  %02d. %s waiting %s", i+1, who(u), state.FormatDuration(now.Sub(store.QueueTime(u.ID))))
+		if store.QueueStale(u.ID, now) {
+			b.WriteString(" - past the queue timeout")
+		}
+		b.WriteString("\n")
+	}
+
+	var outOfService []string
+	for _, device := range store.Devices {
+		if device.Status != "free" && device.Status != "occupied" {
+			outOfService = append(outOfService, fmt.Sprintf("  %s: %s\n", device.Name(), device.Status))
+		}
+	}
+	if len(outOfService) > 0 {
+		fmt.Fprintf(&b, "\nOut of service (%d)\n", len(outOfService))
+		b.WriteString(strings.Join(outOfService, ""))
+	}
+	if notes := openShiftNotes(); notes != "" {
+		b.WriteString("\nOpen shift notes\n" + notes)
+	}
+	return b.String()
+}
+
+// handoverSessionNotes lists what the next shift should know about a session.
+func handoverSessionNotes(u state.User) string {
+	var notes []string
+	if u.Purpose != "" {
+		notes = append(notes, u.Purpose)
+	}
+	if u.CheckInTime.After(time.Now()) {
+		notes = append(notes, "clock changed")
+	}
+	return strings.Join(notes, ", ")
+}
+
+func showHandoverDialog() {
+	now := time.Now()
+	text := handoverText(now, nil)
+	view := widget.NewMultiLineEntry()
+	view.Wrapping = fyne.TextWrapOff
+	view.TextStyle = fyne.TextStyle{Monospace: true}
+	setAnonymized := func(on bool) {
+		anon, err := exportAnonymizerFor(on)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		text = handoverText(now, anon)
+		view.SetText(text)
+	}
+	anonymizeCheck := newAnonymizeCheck(setAnonymized)
+	setAnonymized(anonymizeCheck.Checked)
+	copyButton := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		fyne.CurrentApp().Clipboard().SetContent(text)
+	})
+	saveButton := widget.NewButtonWithIcon("Save as text", theme.DocumentSaveIcon(), func() {
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+			if _, err := writer.Write([]byte(text)); err != nil {
+				dialog.ShowError(fmt.Errorf("write handover: %w", err), mainWindow)
+			}
+		}, mainWindow)
+		save.SetFileName("handover-" + time.Now().Format("2006-01-02-1504") + ".txt")
+		save.SetFilter(storage.NewExtensionFileFilter([]string{".txt"}))
+		save.Show()
+	})
+	content := container.NewBorder(nil, container.NewHBox(copyButton, saveButton, anonymizeCheck), nil, nil, view)
+	dlg := dialog.NewCustom("Shift Handover", "Close", content, mainWindow)
+	dlg.Resize(fyne.NewSize(600, 480))
+	dlg.Show()
+}
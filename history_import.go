@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// showImportHistoryDialog picks a CSV from the old spreadsheet, shows a dry
+// run of the import and writes it once staff confirm.
+func showImportHistoryDialog() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("read import file: %w", err), mainWindow)
+			return
+		}
+		report, err := store.ImportHistoricalSessions(bytes.NewReader(data), true)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		showImportPreview(data, report)
+	}, mainWindow)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	open.Show()
+}
+
+func importSummary(report state.ImportReport) string {
+	return fmt.Sprintf("%d session(s) to import, %d already in the logs.\n%d log file(s) created, %d modified.\n%d row(s) could not be read.",
+		report.Imported, report.Duplicates, len(report.Created), len(report.Modified), len(report.Errors))
+}
+
+func showImportPreview(data []byte, report state.ImportReport) {
+	summary := widget.NewLabel(importSummary(report))
+	content := container.NewVBox(summary)
+	if len(report.Errors) > 0 {
+		lines := make([]string, 0, len(report.Errors))
+		for _, rowErr := range report.Errors {
+			lines = append(lines, fmt.Sprintf("Line %d: %s", rowErr.Line, rowErr.Message))
+		}
+		errorsView := widget.NewMultiLineEntry()
+		errorsView.SetText(strings.Join(lines, "\n"))
+		errorsView.Wrapping = fyne.TextWrapWord
+		scroll := container.NewVScroll(errorsView)
+		scroll.SetMinSize(fyne.NewSize(480, 200))
+		content.Add(widget.NewLabel("Rows that will be skipped:"))
+		content.Add(scroll)
+	}
+	if report.Imported == 0 {
+		dialog.ShowCustom("Import Historical Sessions", "Close", content, mainWindow)
+		return
+	}
+	dialog.ShowCustomConfirm("Import Historical Sessions", "Import", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		done, err := store.ImportHistoricalSessions(bytes.NewReader(data), false)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		refreshLogDateOptions()
+		refreshStatsRangeOptions()
+		checkConsistency(true)
+		dialog.ShowInformation("Import Historical Sessions",
+			fmt.Sprintf("Imported %d session(s) into %d log file(s).", done.Imported, len(done.Created)+len(done.Modified)), mainWindow)
+	}, mainWindow)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+var instanceLock *state.InstanceLock
+
+// acquireInstanceLock takes log/.lounge.lock and reports whether the app may
+// start now. When another copy holds it, a window explains which PID does and
+// offers to start anyway or read-only, calling start if staff choose to.
+func acquireInstanceLock(force bool, start func()) bool {
+	lock, err := state.AcquireInstanceLock(logDir, force)
+	var locked *state.LockedError
+	switch {
+	case errors.As(err, &locked):
+		showAlreadyRunningWindow(locked, start)
+		return false
+	case err != nil:
+		// A lock we cannot write is no reason to keep the desk closed.
+		slog.Error("taking instance lock", "err", err)
+	}
+	instanceLock = lock
+	return true
+}
+
+func showAlreadyRunningWindow(locked *state.LockedError, start func()) {
+	w := fyne.CurrentApp().NewWindow("Lounge Already Running")
+	where := fmt.Sprintf("(PID %d)", locked.PID)
+	if locked.Host != "" {
+		where = fmt.Sprintf("on %s (PID %d)", locked.Host, locked.PID)
+	}
+	message := widget.NewLabel(fmt.Sprintf(
+		"The lounge app is already running %s.\n\n"+
+			"Switch to that window instead: two copies overwrite each other's check-ins. "+
+			"Open Read-Only watches the lounge from here without changing anything.\n\n"+
+			"If the other copy crashed or is frozen, end it or choose Start Anyway.\n"+
+			"Lock file: %s", where, locked.Path))
+	message.Wrapping = fyne.TextWrapWord
+	quit := widget.NewButton("Quit", func() { fyne.CurrentApp().Quit() })
+	startAnyway := widget.NewButtonWithIcon("Start Anyway", theme.WarningIcon(), func() {
+		if acquireInstanceLock(true, start) {
+			start()
+			w.Close()
+		}
+	})
+	startAnyway.Importance = widget.DangerImportance
+	openReadOnly := widget.NewButtonWithIcon("Open Read-Only", theme.VisibilityIcon(), func() {
+		readOnly = true
+		start()
+		w.Close()
+	})
+	openReadOnly.Importance = widget.HighImportance
+	buttons := container.NewHBox(layout.NewSpacer(), quit, startAnyway, openReadOnly)
+	w.SetContent(container.NewPadded(container.NewBorder(nil, buttons, nil, nil, message)))
+	w.Resize(fyne.NewSize(520, 260))
+	w.Show()
+}
@@ -0,0 +1,133 @@
+// Package applog writes the application's own log, log/app.log, through
+// log/slog: leveled, timestamped lines that survive a launch from a desktop
+// shortcut, where nobody sees stderr. The file is rotated at MaxSize, keeping
+// Backups old copies.
+package applog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	FileName = "app.log"
+	// MaxSize is the size at which app.log is rotated to app.log.1.
+	MaxSize = 4 << 20
+	// Backups is how many rotated files are kept.
+	Backups = 3
+)
+
+// level is shared by the handler so SetLevel applies at once.
+var level = new(slog.LevelVar)
+
+// Setup makes slog's default logger write to dir/app.log and stderr at the
+// level set with SetLevel, info until then.
+func Setup(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	file := &rotatingFile{path: filepath.Join(dir, FileName)}
+	if err := file.open(); err != nil {
+		return err
+	}
+	handler := slog.NewTextHandler(io.MultiWriter(os.Stderr, file), &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// ParseLevel reads a level setting: "debug", "info", "warn" or "error";
+// anything else is info.
+func ParseLevel(name string) slog.Level {
+	var l slog.Level
+	if l.UnmarshalText([]byte(name)) != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+func SetLevel(l slog.Level) { level.Set(l) }
+
+// Tail returns up to the last n lines of dir/app.log, oldest first.
+func Tail(dir string, n int) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	lines := make([]string, 0, n)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(lines) == n {
+			lines = append(lines[:0], lines[1:]...)
+		}
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// rotatingFile appends to path and rotates it once it would grow past
+// MaxSize.
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size > 0 && r.size+int64(len(p)) > MaxSize {
+		if err := r.rotate(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error rotating application log:", err)
+		}
+	}
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts app.log.1 to app.log.2 and so on, dropping the oldest, and
+// starts a new app.log.
+func (r *rotatingFile) rotate() error {
+	r.f.Close()
+	r.f = nil
+	for i := Backups - 1; i >= 1; i-- {
+		os.Rename(backupName(r.path, i), backupName(r.path, i+1))
+	}
+	if err := os.Rename(r.path, backupName(r.path, 1)); err != nil && !os.IsNotExist(err) {
+		// Keep appending to the old file rather than lose lines.
+		if openErr := r.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	return r.open()
+}
+
+func backupName(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
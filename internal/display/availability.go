@@ -0,0 +1,114 @@
+package display
+
+import (
+	"bytes"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"lounge/internal/qr"
+)
+
+// availabilityRefresh is how often the phone page reloads itself. It does
+// not use /events, which carries names.
+const availabilityRefresh = 30
+
+// Availability is what /availability shows on phones. Anyone on the network
+// can open it, so it holds counts and device states only, never names.
+type Availability struct {
+	Time     time.Time
+	FreePCs  int
+	PCs      int
+	Consoles []ConsoleAvailability
+	Queue    int
+}
+
+// ConsoleAvailability is one console on the page, by name and status.
+type ConsoleAvailability struct {
+	Name   string
+	Status string
+}
+
+// SetAvailability replaces what /availability shows.
+func (s *Server) SetAvailability(a Availability) {
+	s.mu.Lock()
+	s.availability = a
+	s.mu.Unlock()
+}
+
+// SetLink sets the address phones open /availability at, e.g.
+// "http://192.168.1.20:8080/availability", and draws it as the QR code
+// served at /qr.svg; "" serves no code.
+func (s *Server) SetLink(link string) error {
+	var svg []byte
+	if link != "" {
+		code, err := qr.Encode(link)
+		if err != nil {
+			return err
+		}
+		svg = code.SVG()
+	}
+	s.mu.Lock()
+	s.qrSVG = svg
+	s.mu.Unlock()
+	return nil
+}
+
+var availabilityPage = template.Must(template.New("availability").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>Lounge availability</title>
+<style>
+  body { font-family: sans-serif; background: #eff1f5; color: #4c4f69; margin: 1em; }
+  .big { font-size: 3em; font-weight: bold; }
+  .free { color: #40a02b; } .occupied { color: #d20f39; } .maintenance { color: #9ca0b0; }
+  li { font-size: 1.2em; margin: .3em 0; }
+  small { color: #8c8fa1; }
+</style>
+</head>
+<body>
+<h1>Lounge</h1>
+<p><span class="big {{if .FreePCs}}free{{else}}occupied{{end}}">{{.FreePCs}}</span> of {{.PCs}} PCs free</p>
+<p><span class="big">{{.Queue}}</span> waiting in the queue</p>
+{{if .Consoles}}<h2>Consoles</h2>
+<ul>{{range .Consoles}}<li>{{.Name}}: <span class="{{.Status}}">{{.Status}}</span></li>{{end}}</ul>{{end}}
+<p><small>Updated {{.Time.Format "15:04"}}</small></p>
+</body>
+</html>
+`))
+
+func (s *Server) serveAvailability(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	a := s.availability
+	s.mu.Unlock()
+	var b bytes.Buffer
+	if err := availabilityPage.Execute(&b, struct {
+		Availability
+		Refresh int
+	}{a, availabilityRefresh}); err != nil {
+		slog.Error("rendering availability page", "err", err)
+		http.Error(w, "could not render the page", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(b.Bytes())
+}
+
+func (s *Server) serveQR(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	svg := s.qrSVG
+	s.mu.Unlock()
+	if svg == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(svg)
+}
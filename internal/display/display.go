@@ -0,0 +1,182 @@
+// Package display serves the lounge state to wall displays over HTTP: the
+// latest snapshot at /status and a server-sent event stream at /events, and
+// optionally the usage figures for a range of days at /stats. Phones get a
+// page of counts at /availability, linked from the QR code at /qr.svg.
+// Publishing never waits on a client; a client that falls behind loses
+// events rather than holding up the desk.
+package display
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// clientBuffer is how many events a client may fall behind before
+	// further events are dropped for it.
+	clientBuffer = 32
+	// HeartbeatInterval keeps idle connections open through proxies.
+	HeartbeatInterval = 30 * time.Second
+)
+
+type message struct {
+	event string
+	data  []byte
+}
+
+// Server fans events out to every connected /events client.
+type Server struct {
+	mu       sync.Mutex
+	snapshot []byte
+	clients  map[chan message]struct{}
+	http     *http.Server
+
+	availability Availability
+	qrSVG        []byte
+	// Stats, when set before Start, serves /stats?from=&to= with the JSON
+	// it returns for the range; blank dates are left to it.
+	Stats func(from, to string) ([]byte, error)
+}
+
+func NewServer() *Server {
+	return &Server{clients: make(map[chan message]struct{})}
+}
+
+// Start listens on addr, e.g. ":8080", until Close.
+func (s *Server) Start(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("display server: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.serveStatus)
+	mux.HandleFunc("/events", s.serveEvents)
+	mux.HandleFunc("/availability", s.serveAvailability)
+	mux.HandleFunc("/qr.svg", s.serveQR)
+	if s.Stats != nil {
+		mux.HandleFunc("/stats", s.serveStats)
+	}
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	s.mu.Lock()
+	s.http = srv
+	s.mu.Unlock()
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("serving wall display", "err", err)
+		}
+	}()
+	return nil
+}
+
+// Close stops the listener and disconnects every client.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	srv := s.http
+	s.http = nil
+	s.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	// Event streams never finish on their own, so Shutdown would hang;
+	// Close ends them straight away.
+	return srv.Close()
+}
+
+// SetSnapshot replaces the full state sent to new clients and by /status.
+func (s *Server) SetSnapshot(data []byte) {
+	s.mu.Lock()
+	s.snapshot = data
+	s.mu.Unlock()
+}
+
+// Publish sends an event to every client without blocking. Clients whose
+// buffer is full miss it.
+func (s *Server) Publish(event string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.clients {
+		select {
+		case ch <- message{event: event, data: data}:
+		default:
+		}
+	}
+}
+
+func (s *Server) serveStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	snapshot := s.snapshot
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(snapshot)
+}
+
+func (s *Server) serveStats(w http.ResponseWriter, r *http.Request) {
+	data, err := s.Stats(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Write(data)
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	ch := make(chan message, clientBuffer)
+	s.mu.Lock()
+	snapshot := s.snapshot
+	s.clients[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	if writeEvent(w, "snapshot", snapshot) != nil {
+		return
+	}
+	flusher.Flush()
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg := <-ch:
+			if writeEvent(w, msg.event, msg.data) != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes one SSE event; data must be a single line, which
+// encoding/json output is.
+func writeEvent(w http.ResponseWriter, event string, data []byte) error {
+	if len(data) == 0 {
+		data = []byte("null")
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,429 @@
+// Package qr encodes text as a QR code, for the wall display's link to the
+// phone availability page. It only does what a URL needs: byte mode at
+// error correction level M, versions 1 to 10 (up to 213 bytes).
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// ErrTooLong is returned for text that does not fit in version 10.
+var ErrTooLong = errors.New("text too long for a QR code")
+
+// versionM describes one version at level M: the error correction
+// codewords per block and the data codewords of each block.
+type versionM struct {
+	ecc    int
+	blocks []int
+	align  []int
+}
+
+var versions = [...]versionM{
+	1:  {10, []int{16}, nil},
+	2:  {16, []int{28}, []int{6, 18}},
+	3:  {26, []int{44}, []int{6, 22}},
+	4:  {18, []int{32, 32}, []int{6, 26}},
+	5:  {24, []int{43, 43}, []int{6, 30}},
+	6:  {16, []int{27, 27, 27, 27}, []int{6, 34}},
+	7:  {18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	8:  {22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	9:  {22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	10: {26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v versionM) dataCodewords() int {
+	n := 0
+	for _, b := range v.blocks {
+		n += b
+	}
+	return n
+}
+
+// Code is an encoded QR code, Size modules square, without the quiet zone.
+type Code struct {
+	Size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark.
+func (c *Code) Dark(x, y int) bool { return c.modules[y][x] }
+
+// Encode encodes text in the smallest version it fits, with the mask that
+// scores best.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v < len(versions); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*versions[v].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLong, len(data))
+	}
+	codewords := addECC(encodeData(data, version), versions[version])
+
+	c := &Code{Size: 17 + 4*version}
+	c.modules = make([][]bool, c.Size)
+	c.function = make([][]bool, c.Size)
+	for i := range c.modules {
+		c.modules[i] = make([]bool, c.Size)
+		c.function[i] = make([]bool, c.Size)
+	}
+	c.drawFunctionPatterns(version)
+	c.placeCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask)
+	}
+	c.applyMask(best)
+	c.drawFormat(best)
+	return c, nil
+}
+
+// encodeData is the byte mode segment, terminated and padded to the
+// version's data capacity.
+func encodeData(data []byte, version int) []byte {
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	if version >= 10 {
+		bits.append(len(data), 16)
+	} else {
+		bits.append(len(data), 8)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := 8 * versions[version].dataCodewords()
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	out := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+// addECC splits data into the version's blocks, adds each block's error
+// correction and interleaves the lot.
+func addECC(data []byte, v versionM) []byte {
+	divisor := rsDivisor(v.ecc)
+	var blocks, eccs [][]byte
+	for _, n := range v.blocks {
+		blocks = append(blocks, data[:n])
+		eccs = append(eccs, rsRemainder(data[:n], divisor))
+		data = data[n:]
+	}
+	var out []byte
+	for i := 0; i < v.blocks[len(v.blocks)-1]; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecc; i++ {
+		for _, ecc := range eccs {
+			out = append(out, ecc[i])
+		}
+	}
+	return out
+}
+
+// gfMul multiplies in GF(256) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor is the Reed-Solomon generator polynomial of degree n, highest
+// term first and its leading 1 left out.
+func rsDivisor(n int) []byte {
+	result := make([]byte, n)
+	result[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < n {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return result
+}
+
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns(version int) {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	for _, corner := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := corner[0]+dx, corner[1]+dy
+				if x >= 0 && x < c.Size && y >= 0 && y < c.Size {
+					dist := max(abs(dx), abs(dy))
+					c.set(x, y, dist != 2 && dist != 4)
+				}
+			}
+		}
+	}
+	align := versions[version].align
+	last := len(align) - 1
+	for i, ay := range align {
+		for j, ax := range align {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(ax+dx, ay+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	// Reserve the format areas; drawFormat fills them in.
+	c.drawFormat(0)
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := c.Size-11+i%3, i/3
+			c.set(a, b, dark)
+			c.set(b, a, dark)
+		}
+	}
+}
+
+// drawFormat writes both copies of the format information for level M and
+// mask.
+func (c *Code) drawFormat(mask int) {
+	data := 0b00<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true)
+}
+
+// placeCodewords fills the non-function modules in the zigzag order, two
+// columns at a time from the bottom right. Remainder modules stay light.
+func (c *Code) placeCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !c.function[y][x] && i < len(data)*8 {
+					c.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flips the non-function modules mask selects; applying it twice
+// undoes it.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var flip bool
+			switch mask {
+			case 0:
+				flip = (x+y)%2 == 0
+			case 1:
+				flip = y%2 == 0
+			case 2:
+				flip = x%3 == 0
+			case 3:
+				flip = (x+y)%3 == 0
+			case 4:
+				flip = (x/3+y/2)%2 == 0
+			case 5:
+				flip = x*y%2+x*y%3 == 0
+			case 6:
+				flip = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				flip = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if flip && !c.function[y][x] {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the code by the standard's four rules; lower reads more
+// reliably.
+func (c *Code) penalty() int {
+	score := 0
+	finder := []bool{true, false, true, true, true, false, true}
+	for pass := 0; pass < 2; pass++ {
+		at := func(i, j int) bool {
+			if pass == 0 {
+				return c.modules[i][j]
+			}
+			return c.modules[j][i]
+		}
+		for i := 0; i < c.Size; i++ {
+			run := 1
+			for j := 1; j <= c.Size; j++ {
+				if j < c.Size && at(i, j) == at(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			// A finder-like 1:1:3:1:1 run with four light modules on
+			// either side.
+			for j := 0; j+7 <= c.Size; j++ {
+				match := true
+				for k, dark := range finder {
+					if at(i, j+k) != dark {
+						match = false
+						break
+					}
+				}
+				if match && (c.light(at, i, j-4, j) || c.light(at, i, j+7, j+11)) {
+					score += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				m := c.modules[y][x]
+				if c.modules[y][x+1] == m && c.modules[y+1][x] == m && c.modules[y+1][x+1] == m {
+					score += 3
+				}
+			}
+		}
+	}
+	percent := dark * 100 / (c.Size * c.Size)
+	return score + 10*(abs(percent-50)/5)
+}
+
+// light reports whether modules from to to (exclusive) of line i are all
+// light, counting those outside the code as light.
+func (c *Code) light(at func(i, j int) bool, i, from, to int) bool {
+	for j := from; j < to; j++ {
+		if j >= 0 && j < c.Size && at(i, j) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// SVG draws the code black on white with the four-module quiet zone
+// scanners expect, scaling to whatever size it is shown at.
+func (c *Code) SVG() []byte {
+	const quiet = 4
+	n := c.Size + 2*quiet
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/><path fill="#000000" d="`, n, n)
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x+quiet, y+quiet)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.Bytes()
+}
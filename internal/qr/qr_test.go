@@ -0,0 +1,299 @@
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// The format information for level M, by mask, as tabled in ISO/IEC 18004.
+var formatM = [8]int{
+	0b101010000010010, 0b101000100100101, 0b101111001111100, 0b101101101001011,
+	0b100010111111001, 0b100000011001110, 0b100111110010111, 0b100101010100000,
+}
+
+// The version information for versions 7 to 10, as tabled in the standard.
+var versionInfo = map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3}
+
+// The byte mode capacity at level M of versions 1 to 10.
+var capacityM = []int{1: 14, 26, 42, 62, 84, 106, 122, 152, 180, 213}
+
+// decode reads c back as a scanner would: format and version information,
+// unmasking, the zigzag codeword order, de-interleaving, a Reed-Solomon
+// syndrome check on every block and the byte mode segment.
+func decode(c *Code) (string, error) {
+	version := (c.Size - 17) / 4
+	if version < 1 || version >= len(versions) || c.Size != 17+4*version {
+		return "", fmt.Errorf("size %d is no version", c.Size)
+	}
+	dark := func(x, y int) int {
+		if c.Dark(x, y) {
+			return 1
+		}
+		return 0
+	}
+
+	var first, second int
+	firstAt := [15][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}}
+	for i, at := range firstAt {
+		first |= dark(at[0], at[1]) << i
+	}
+	for i := 0; i < 8; i++ {
+		second |= dark(c.Size-1-i, 8) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= dark(8, c.Size-15+i) << i
+	}
+	if first != second {
+		return "", fmt.Errorf("format copies differ: %015b and %015b", first, second)
+	}
+	mask := -1
+	for m, format := range formatM {
+		if format == first {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		return "", fmt.Errorf("format %015b is not level M", first)
+	}
+	if !c.Dark(8, c.Size-8) {
+		return "", errors.New("the dark module is light")
+	}
+
+	if version >= 7 {
+		var got, gotT int
+		for i := 0; i < 18; i++ {
+			a, b := c.Size-11+i%3, i/3
+			got |= dark(a, b) << i
+			gotT |= dark(b, a) << i
+		}
+		if got != versionInfo[version] || gotT != versionInfo[version] {
+			return "", fmt.Errorf("version information %05X and %05X, want %05X", got, gotT, versionInfo[version])
+		}
+	}
+
+	reserved := functionModules(c.Size, version)
+	var raw []byte
+	var bits, n int
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		// Column pairs alternate upward and downward, counted from the
+		// right; the vertical timing column is skipped, not counted.
+		pair := (c.Size - 1 - right) / 2
+		if right < 6 {
+			pair = (c.Size - 2 - right) / 2
+		}
+		upward := pair%2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for x := right; x >= right-1; x-- {
+				if reserved[y][x] {
+					continue
+				}
+				bit := dark(x, y)
+				if masked(mask, x, y) {
+					bit ^= 1
+				}
+				bits = bits<<1 | bit
+				if n++; n%8 == 0 {
+					raw = append(raw, byte(bits))
+					bits = 0
+				}
+			}
+		}
+	}
+
+	v := versions[version]
+	total := v.dataCodewords() + v.ecc*len(v.blocks)
+	if len(raw) < total {
+		return "", fmt.Errorf("read %d codewords, want %d", len(raw), total)
+	}
+	blocks := make([][]byte, len(v.blocks))
+	i := 0
+	for k := 0; k < v.blocks[len(v.blocks)-1]; k++ {
+		for b, size := range v.blocks {
+			if k < size {
+				blocks[b] = append(blocks[b], raw[i])
+				i++
+			}
+		}
+	}
+	for k := 0; k < v.ecc; k++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], raw[i])
+			i++
+		}
+	}
+	var data []byte
+	for b, block := range blocks {
+		if err := checkSyndromes(block, v.ecc); err != nil {
+			return "", fmt.Errorf("block %d: %w", b, err)
+		}
+		data = append(data, block[:v.blocks[b]]...)
+	}
+
+	read := func(from, n int) int {
+		value := 0
+		for i := from; i < from+n; i++ {
+			value = value<<1 | int(data[i/8]>>(7-i%8)&1)
+		}
+		return value
+	}
+	if mode := read(0, 4); mode != 0b0100 {
+		return "", fmt.Errorf("mode %04b, want byte mode", mode)
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	length := read(4, countBits)
+	if 4+countBits+8*length > 8*len(data) {
+		return "", fmt.Errorf("length %d overruns the data", length)
+	}
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = byte(read(4+countBits+8*i, 8))
+	}
+	return string(text), nil
+}
+
+// functionModules marks the modules that carry no data in a code of the
+// given size and version.
+func functionModules(size, version int) [][]bool {
+	reserved := make([][]bool, size)
+	for y := range reserved {
+		reserved[y] = make([]bool, size)
+		for x := range reserved[y] {
+			reserved[y][x] = x < 9 && y < 9 || x >= size-8 && y < 9 || x < 9 && y >= size-8 ||
+				x == 6 || y == 6 ||
+				version >= 7 && (x >= size-11 && x < size-8 && y < 6 || y >= size-11 && y < size-8 && x < 6)
+		}
+	}
+	centres := versions[version].align
+	for _, cy := range centres {
+		for _, cx := range centres {
+			if cx < 9 && cy < 9 || cx >= size-9 && cy < 9 || cx < 9 && cy >= size-9 {
+				continue
+			}
+			for y := cy - 2; y <= cy+2; y++ {
+				for x := cx - 2; x <= cx+2; x++ {
+					reserved[y][x] = true
+				}
+			}
+		}
+	}
+	return reserved
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (y/2+x/3)%2 == 0
+	case 5:
+		return (x*y)%2+(x*y)%3 == 0
+	case 6:
+		return ((x*y)%2+(x*y)%3)%2 == 0
+	default:
+		return ((x+y)%2+(x*y)%3)%2 == 0
+	}
+}
+
+// checkSyndromes evaluates block, data then error correction, at the first
+// ecc powers of the generator; a valid codeword is zero at each.
+func checkSyndromes(block []byte, ecc int) error {
+	alpha := byte(1)
+	for i := 0; i < ecc; i++ {
+		var sum byte
+		for _, b := range block {
+			sum = gfMul(sum, alpha) ^ b
+		}
+		if sum != 0 {
+			return fmt.Errorf("syndrome %d is %d", i, sum)
+		}
+		alpha = gfMul(alpha, 2)
+	}
+	return nil
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	texts := []string{
+		"",
+		"http://10.0.0.5:8080/availability",
+		"http://192.168.1.20:8080/availability",
+		"https://lounge.example.edu/availability?desk=front&lang=en",
+		"héllo wörld · ünïcode",
+		strings.Repeat("A", 100),
+		strings.Repeat("0123456789", 21) + "xyz",
+	}
+	for n := 1; n < len(capacityM); n++ {
+		texts = append(texts, strings.Repeat("a", capacityM[n]))
+	}
+	for _, text := range texts {
+		code, err := Encode(text)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", len(text), err)
+		}
+		got, err := decode(code)
+		if err != nil {
+			t.Fatalf("decoding %d bytes: %v", len(text), err)
+		}
+		if got != text {
+			t.Errorf("round trip of %q gave %q", text, got)
+		}
+	}
+}
+
+// TestEncodePicksSmallestVersion checks each version's byte capacity at
+// level M against the standard.
+func TestEncodePicksSmallestVersion(t *testing.T) {
+	for version := 1; version < len(capacityM); version++ {
+		for _, n := range []int{capacityM[version-1] + 1, capacityM[version]} {
+			code, err := Encode(strings.Repeat("x", n))
+			if err != nil {
+				t.Fatalf("%d bytes: %v", n, err)
+			}
+			if want := 17 + 4*version; code.Size != want {
+				t.Errorf("%d bytes made a %d-module code, want version %d (%d)", n, code.Size, version, want)
+			}
+		}
+	}
+	if _, err := Encode(strings.Repeat("x", capacityM[10]+1)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("%d bytes: got %v, want ErrTooLong", capacityM[10]+1, err)
+	}
+}
+
+// TestErrorCorrection checks the Reed-Solomon codewords against the worked
+// "HELLO WORLD" 1-M example.
+func TestErrorCorrection(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSVGHasQuietZone(t *testing.T) {
+	code, err := Encode("http://192.168.1.20:8080/availability")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := code.Size + 8
+	if svg := string(code.SVG()); !strings.Contains(svg, fmt.Sprintf(`viewBox="0 0 %d %d"`, n, n)) {
+		t.Errorf("SVG lacks the quiet zone: %.120s", svg)
+	}
+}
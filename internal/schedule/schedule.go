@@ -0,0 +1,77 @@
+// Package schedule wakes the desk on a steady tick and exactly at each local
+// midnight. The clock is injectable so a day rollover can be driven without
+// waiting for one.
+package schedule
+
+import "time"
+
+// Clock is the time source a Scheduler reads and sleeps on.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock is the wall clock.
+var SystemClock Clock = systemClock{}
+
+// Scheduler calls OnTick every Tick and OnNewDay once the local date
+// changes, as close to midnight as the clock allows. The callbacks run on
+// the scheduler's goroutine; GUI callers hand the work to fyne.Do. Tick
+// must be positive.
+type Scheduler struct {
+	Clock    Clock
+	Tick     time.Duration
+	OnTick   func(now time.Time)
+	OnNewDay func(now time.Time)
+}
+
+// NextMidnight is the start of the local day after now's.
+func NextMidnight(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+}
+
+func dateOf(t time.Time) string { return t.Format("2006-01-02") }
+
+// Run calls the callbacks until stop is closed. A nil stop runs forever.
+// A wall clock that is moved across midnight is caught on the next tick.
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	clock := s.Clock
+	if clock == nil {
+		clock = SystemClock
+	}
+	now := clock.Now()
+	day := dateOf(now)
+	nextTick := now.Add(s.Tick)
+	for {
+		wake := NextMidnight(now)
+		if nextTick.Before(wake) {
+			wake = nextTick
+		}
+		select {
+		case <-stop:
+			return
+		case <-clock.After(wake.Sub(now)):
+		}
+		now = clock.Now()
+		if today := dateOf(now); today != day {
+			day = today
+			if s.OnNewDay != nil {
+				s.OnNewDay(now)
+			}
+		}
+		// A clock set back would otherwise hold the tick off until it
+		// caught up again.
+		if !now.Before(nextTick) || now.Before(nextTick.Add(-s.Tick)) {
+			nextTick = now.Add(s.Tick)
+			if s.OnTick != nil {
+				s.OnTick(now)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package schedule
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock hands each After to the test, which moves the time and fires it.
+// Once stopped is closed, After returns a channel that never fires.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	sleeps  chan sleep
+	stopped chan struct{}
+}
+
+type sleep struct {
+	d    time.Duration
+	wake chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, sleeps: make(chan sleep), stopped: make(chan struct{})}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	wake := make(chan time.Time, 1)
+	select {
+	case c.sleeps <- sleep{d, wake}:
+	case <-c.stopped:
+	}
+	return wake
+}
+
+// wakeAt waits for the scheduler's next After, sets the clock to to and wakes
+// it; it returns how long the scheduler asked to sleep.
+func (c *fakeClock) wakeAt(t *testing.T, to time.Time) time.Duration {
+	t.Helper()
+	select {
+	case s := <-c.sleeps:
+		c.mu.Lock()
+		c.now = to
+		c.mu.Unlock()
+		s.wake <- to
+		return s.d
+	case <-time.After(5 * time.Second):
+		t.Fatal("the scheduler never went to sleep")
+		return 0
+	}
+}
+
+type event struct {
+	newDay bool
+	at     time.Time
+}
+
+func runScheduler(t *testing.T, clock *fakeClock, tick time.Duration) <-chan event {
+	t.Helper()
+	events := make(chan event, 16)
+	s := &Scheduler{
+		Clock:    clock,
+		Tick:     tick,
+		OnTick:   func(now time.Time) { events <- event{false, now} },
+		OnNewDay: func(now time.Time) { events <- event{true, now} },
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		s.Run(stop)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		close(clock.stopped)
+		<-done
+	})
+	return events
+}
+
+// drain returns the events the last wake caused, in order.
+func drain(events <-chan event) []event {
+	var out []event
+	for {
+		select {
+		case e := <-events:
+			out = append(out, e)
+		case <-time.After(50 * time.Millisecond):
+			return out
+		}
+	}
+}
+
+func TestRolloverAtMidnight(t *testing.T) {
+	loc := time.FixedZone("desk", 2*3600)
+	start := time.Date(2026, 3, 9, 23, 59, 10, 0, loc)
+	midnight := time.Date(2026, 3, 10, 0, 0, 0, 0, loc)
+	clock := newFakeClock(start)
+	events := runScheduler(t, clock, 30*time.Second)
+
+	if d := clock.wakeAt(t, start.Add(30*time.Second)); d != 30*time.Second {
+		t.Fatalf("first sleep %s, want the 30s tick", d)
+	}
+	if got := drain(events); len(got) != 1 || got[0].newDay {
+		t.Fatalf("at 23:59:40 got %+v, want one tick", got)
+	}
+	if d := clock.wakeAt(t, midnight); d != 20*time.Second {
+		t.Fatalf("slept %s before midnight, want 20s", d)
+	}
+	got := drain(events)
+	if len(got) == 0 || !got[0].newDay || !got[0].at.Equal(midnight) {
+		t.Fatalf("at midnight got %+v, want the new day first", got)
+	}
+	for _, e := range got[1:] {
+		if e.newDay {
+			t.Fatalf("new day fired twice: %+v", got)
+		}
+	}
+}
+
+func TestRolloverWhenClockMovesAcrossMidnight(t *testing.T) {
+	start := time.Date(2026, 3, 9, 22, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	events := runScheduler(t, clock, 30*time.Second)
+
+	jumped := time.Date(2026, 3, 10, 7, 30, 0, 0, time.UTC)
+	clock.wakeAt(t, jumped)
+	got := drain(events)
+	if len(got) != 2 || !got[0].newDay || got[1].newDay {
+		t.Fatalf("after the jump got %+v, want the new day then a tick", got)
+	}
+}
+
+func TestTicksContinueAfterClockSetBack(t *testing.T) {
+	start := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	events := runScheduler(t, clock, 30*time.Second)
+
+	clock.wakeAt(t, start.Add(-time.Hour))
+	if got := drain(events); len(got) != 1 || got[0].newDay {
+		t.Fatalf("after setting the clock back got %+v, want one tick", got)
+	}
+	if d := clock.wakeAt(t, start.Add(-time.Hour+30*time.Second)); d != 30*time.Second {
+		t.Fatalf("next sleep %s, want 30s", d)
+	}
+	if got := drain(events); len(got) != 1 {
+		t.Fatalf("got %+v, want the next tick", got)
+	}
+}
+
+func TestNextMidnight(t *testing.T) {
+	loc := time.FixedZone("desk", -5*3600)
+	tests := []struct {
+		now, want time.Time
+	}{
+		{time.Date(2026, 3, 9, 0, 0, 0, 0, loc), time.Date(2026, 3, 10, 0, 0, 0, 0, loc)},
+		{time.Date(2026, 3, 9, 23, 59, 59, 0, loc), time.Date(2026, 3, 10, 0, 0, 0, 0, loc)},
+		{time.Date(2026, 12, 31, 12, 0, 0, 0, loc), time.Date(2027, 1, 1, 0, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := NextMidnight(tt.now); !got.Equal(tt.want) {
+			t.Errorf("NextMidnight(%s) = %s, want %s", tt.now, got, tt.want)
+		}
+	}
+}
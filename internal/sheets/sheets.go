@@ -0,0 +1,241 @@
+// Package sheets is a minimal Google Sheets v4 client authenticated with a
+// service account. It signs its own RS256 JWTs so the app does not need the
+// Google client libraries.
+package sheets
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	sheetsScope     = "https://www.googleapis.com/auth/spreadsheets"
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+	apiBase         = "https://sheets.googleapis.com/v4/spreadsheets/"
+)
+
+// Credentials are the fields of a service-account key file the client needs.
+type Credentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// LoadCredentials reads a service-account JSON key file.
+func LoadCredentials(path string) (*Credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read credentials: %s: %w", path, err)
+	}
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("parse credentials: %s: %w", path, err)
+	}
+	if creds.ClientEmail == "" || creds.PrivateKey == "" {
+		return nil, fmt.Errorf("credentials %s: not a service-account key file", path)
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = defaultTokenURI
+	}
+	return &creds, nil
+}
+
+// StatusError is a non-2xx response from Google.
+type StatusError struct {
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("sheets: HTTP %d: %s", e.Code, e.Body)
+}
+
+// Retryable reports whether err is worth retrying: network failures, rate
+// limiting and server errors. Auth and request errors are not.
+func Retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= 500
+	}
+	return err != nil
+}
+
+// Client talks to one spreadsheet. It is safe for concurrent use.
+type Client struct {
+	creds         *Credentials
+	key           *rsa.PrivateKey
+	spreadsheetID string
+	http          *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+func NewClient(creds *Credentials, spreadsheetID string) (*Client, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return nil, errors.New("credentials: private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("credentials: parse private key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("credentials: private key is not RSA")
+	}
+	return &Client{
+		creds:         creds,
+		key:           key,
+		spreadsheetID: spreadsheetID,
+		http:          &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *Client) signedAssertion(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   c.creds.ClientEmail,
+		"scope": sheetsScope,
+		"aud":   c.creds.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("sign token: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// token returns a cached access token, exchanging a fresh JWT when it is
+// about to expire.
+func (c *Client) token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.accessToken != "" && time.Until(c.expiry) > time.Minute {
+		return c.accessToken, nil
+	}
+	assertion, err := c.signedAssertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	resp, err := c.http.PostForm(c.creds.TokenURI, form)
+	if err != nil {
+		return "", fmt.Errorf("fetch token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return "", &StatusError{Code: resp.StatusCode, Body: string(body)}
+	}
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("parse token: %w", err)
+	}
+	c.accessToken = tok.AccessToken
+	c.expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+func (c *Client) do(method, endpoint string, payload, out any) error {
+	tok, err := c.token()
+	if err != nil {
+		return err
+	}
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, apiBase+url.PathEscape(c.spreadsheetID)+endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("sheets request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return &StatusError{Code: resp.StatusCode, Body: string(data)}
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("parse response: %w", err)
+		}
+	}
+	return nil
+}
+
+// ValueRange is a block of cells in A1 notation.
+type ValueRange struct {
+	Range  string     `json:"range"`
+	Values [][]string `json:"values"`
+}
+
+// Values returns the cells in rangeA1 as strings.
+func (c *Client) Values(rangeA1 string) ([][]string, error) {
+	var out struct {
+		Values [][]string `json:"values"`
+	}
+	err := c.do(http.MethodGet, "/values/"+url.PathEscape(rangeA1)+"?valueRenderOption=FORMATTED_VALUE", nil, &out)
+	return out.Values, err
+}
+
+// Append adds rows after the last row of the table in rangeA1.
+func (c *Client) Append(rangeA1 string, rows [][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	endpoint := "/values/" + url.PathEscape(rangeA1) + ":append?valueInputOption=RAW&insertDataOption=INSERT_ROWS"
+	return c.do(http.MethodPost, endpoint, map[string]any{"values": rows}, nil)
+}
+
+// BatchUpdate overwrites several ranges in one request.
+func (c *Client) BatchUpdate(data []ValueRange) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return c.do(http.MethodPost, "/values:batchUpdate", map[string]any{
+		"valueInputOption": "RAW",
+		"data":             data,
+	}, nil)
+}
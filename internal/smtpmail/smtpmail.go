@@ -0,0 +1,124 @@
+// Package smtpmail sends plain-text email through an SMTP server, with
+// STARTTLS when the server offers it and implicit TLS on port 465.
+package smtpmail
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dialTimeout = 15 * time.Second
+
+// Config is the server to send through. Username and Password may be empty
+// for a relay that does not ask for them.
+type Config struct {
+	Host     string
+	Port     int
+	From     string
+	Username string
+	Password string
+}
+
+// Validate checks that c names a server and a usable From address.
+func (c Config) Validate() error {
+	if strings.TrimSpace(c.Host) == "" {
+		return errors.New("smtp: no server set")
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("smtp: invalid port %d", c.Port)
+	}
+	if _, err := mail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("smtp: invalid from address %q", c.From)
+	}
+	return nil
+}
+
+// Retryable reports whether err is worth retrying: network failures and the
+// server's 4xx "try again later" replies. Rejections (5xx) and bad
+// configuration are not.
+func Retryable(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Send delivers one message to to.
+func Send(c Config, to, subject, body string) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("smtp: invalid address %q", to)
+	}
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	var conn net.Conn
+	tlsConfig := &tls.Config{ServerName: c.Host}
+	if c.Port == 465 {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: dialTimeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, dialTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok && c.Port != 465 {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if c.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.Username, c.Password, c.Host)); err != nil {
+			return err
+		}
+	}
+	from, _ := mail.ParseAddress(c.From)
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message(c.From, to, subject, body)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func message(from, to, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	subject = strings.Join(strings.Fields(subject), " ")
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,165 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// AlertRule fires when its metric rises above Fire and clears only once it
+// drops below Clear. The gap between the two is the hysteresis that keeps a
+// value hovering at the threshold from alerting every minute.
+type AlertRule struct {
+	ID    string
+	Name  string
+	Fire  float64
+	Clear float64
+	// Metric measures the rule against the store; detail describes the
+	// value for the notification.
+	Metric func(s *Store, e *AlertEngine, now time.Time) (value float64, detail string)
+}
+
+// AlertEvent is a rule firing or clearing.
+type AlertEvent struct {
+	Rule   AlertRule
+	Fired  bool
+	Time   time.Time
+	Detail string
+	// Muted marks an event that happened in quiet hours, so no notification
+	// went out straight away.
+	Muted bool
+}
+
+// AlertEngine evaluates rules over the in-memory state. It is only used from
+// the UI goroutine.
+type AlertEngine struct {
+	Rules []AlertRule
+	// Quiet reports whether notifications are held back at now; nil means
+	// never.
+	Quiet   func(now time.Time) bool
+	active  map[string]bool
+	history []AlertEvent
+	// allBusySince is when every in-service PC became occupied; zero while
+	// one is free.
+	allBusySince time.Time
+}
+
+func NewAlertEngine() *AlertEngine {
+	return &AlertEngine{active: make(map[string]bool)}
+}
+
+// Configure replaces the rules with the standard ones at these thresholds,
+// keeping the history; a threshold of 0 leaves its rule out.
+func (e *AlertEngine) Configure(queueWait, allBusy time.Duration, maintenance int) {
+	e.Rules = nil
+	if queueWait > 0 {
+		limit := queueWait.Minutes()
+		e.Rules = append(e.Rules, AlertRule{
+			ID: "queue-wait", Name: fmt.Sprintf("Queue wait over %.0f min", limit),
+			Fire: limit, Clear: limit * 5 / 6, Metric: longestQueueWait,
+		})
+	}
+	if allBusy > 0 {
+		e.Rules = append(e.Rules, AlertRule{
+			ID: "all-busy", Name: fmt.Sprintf("All PCs busy for over %.0f min", allBusy.Minutes()),
+			Fire: allBusy.Minutes(), Clear: 1, Metric: allPCsBusyFor,
+		})
+	}
+	if maintenance > 0 {
+		e.Rules = append(e.Rules, AlertRule{
+			ID: "maintenance", Name: fmt.Sprintf("More than %d devices in maintenance", maintenance),
+			Fire: float64(maintenance), Clear: float64(maintenance), Metric: devicesInMaintenance,
+		})
+	}
+	for id := range e.active {
+		if !e.hasRule(id) {
+			delete(e.active, id)
+		}
+	}
+}
+
+func (e *AlertEngine) hasRule(id string) bool {
+	for _, rule := range e.Rules {
+		if rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate checks every rule and returns the ones that fired or cleared,
+// recording them in the history.
+func (e *AlertEngine) Evaluate(s *Store, now time.Time) []AlertEvent {
+	e.trackAllBusy(s, now)
+	var events []AlertEvent
+	muted := e.Quiet != nil && e.Quiet(now)
+	for _, rule := range e.Rules {
+		value, detail := rule.Metric(s, e, now)
+		switch {
+		case !e.active[rule.ID] && value > rule.Fire:
+			e.active[rule.ID] = true
+			events = append(events, AlertEvent{Rule: rule, Fired: true, Time: now, Detail: detail, Muted: muted})
+		case e.active[rule.ID] && value < rule.Clear:
+			delete(e.active, rule.ID)
+			events = append(events, AlertEvent{Rule: rule, Time: now, Detail: detail, Muted: muted})
+		}
+	}
+	e.history = append(e.history, events...)
+	return events
+}
+
+// History returns every firing and clearing so far, oldest first.
+func (e *AlertEngine) History() []AlertEvent { return e.history }
+
+// ActiveCount is the number of rules currently firing.
+func (e *AlertEngine) ActiveCount() int { return len(e.active) }
+
+func (e *AlertEngine) trackAllBusy(s *Store, now time.Time) {
+	inService, busy := 0, 0
+	for _, device := range s.Devices {
+		if device.Type != "PC" || device.Status == StatusMaintenance {
+			continue
+		}
+		inService++
+		if device.Status == "occupied" {
+			busy++
+		}
+	}
+	switch {
+	case inService == 0 || busy < inService:
+		e.allBusySince = time.Time{}
+	case e.allBusySince.IsZero():
+		e.allBusySince = now
+	}
+}
+
+func longestQueueWait(s *Store, _ *AlertEngine, now time.Time) (float64, string) {
+	var longest time.Duration
+	name := ""
+	for _, u := range s.PendingUsers() {
+		if wait := now.Sub(s.QueueTime(u.ID)); wait > longest {
+			longest, name = wait, u.Name
+		}
+	}
+	if name == "" {
+		return 0, "queue is empty"
+	}
+	return longest.Minutes(), fmt.Sprintf("%s has waited %d min", name, int(longest.Minutes()))
+}
+
+func allPCsBusyFor(_ *Store, e *AlertEngine, now time.Time) (float64, string) {
+	if e.allBusySince.IsZero() {
+		return 0, "a PC is free"
+	}
+	busy := now.Sub(e.allBusySince)
+	return busy.Minutes(), fmt.Sprintf("every PC has been occupied for %d min", int(busy.Minutes()))
+}
+
+func devicesInMaintenance(s *Store, _ *AlertEngine, _ time.Time) (float64, string) {
+	n := 0
+	for _, device := range s.Devices {
+		if device.Status == StatusMaintenance {
+			n++
+		}
+	}
+	return float64(n), fmt.Sprintf("%d devices in maintenance", n)
+}
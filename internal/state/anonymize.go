@@ -0,0 +1,88 @@
+package state
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// anonymousIDPrefix starts every pseudonym ID returns.
+const anonymousIDPrefix = "anon-"
+
+// Anonymizer replaces user IDs in exported data with a salted hash. The same
+// salt always gives the same hash, so exports made on different days can be
+// joined per user until the salt is rotated.
+type Anonymizer struct {
+	salt []byte
+}
+
+// LoadAnonymizer reads the salt at path, creating one the first time.
+func LoadAnonymizer(path string) (*Anonymizer, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return RotateAnonymizerSalt(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read export salt: %w", err)
+	}
+	salt, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("export salt %s is damaged; rotate it to make a new one", path)
+	}
+	return &Anonymizer{salt: salt}, nil
+}
+
+// RotateAnonymizerSalt writes a new salt to path, which breaks the link
+// between exports made before and after.
+func RotateAnonymizerSalt(path string) (*Anonymizer, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate export salt: %w", err)
+	}
+	if err := WriteFileAtomic(path, []byte(hex.EncodeToString(salt)+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	return &Anonymizer{salt: salt}, nil
+}
+
+// ID returns the stable pseudonym for a user ID. IDs are normalized first so
+// "a123" and " A123" stay the same person.
+func (a *Anonymizer) ID(id string) string {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	if id == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, a.salt)
+	mac.Write([]byte(id))
+	return anonymousIDPrefix + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// AnonymizeName shortens a name to the first name and last initial, e.g.
+// "Ada Lovelace" becomes "Ada L.".
+func AnonymizeName(name string) string {
+	fields := strings.Fields(name)
+	switch len(fields) {
+	case 0:
+		return ""
+	case 1:
+		return fields[0]
+	}
+	initial, _ := utf8.DecodeRuneInString(fields[len(fields)-1])
+	return fields[0] + " " + strings.ToUpper(string(initial)) + "."
+}
+
+// Entries returns copies of entries with user IDs and names anonymized.
+func (a *Anonymizer) Entries(entries []LogEntry) []LogEntry {
+	out := make([]LogEntry, len(entries))
+	for i, entry := range entries {
+		entry.UserID = a.ID(entry.UserID)
+		entry.UserName = AnonymizeName(entry.UserName)
+		out[i] = entry
+	}
+	return out
+}
@@ -0,0 +1,249 @@
+package state
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// logDateFromFileName returns the date of a daily log file name, or "" when
+// name is not one.
+func logDateFromFileName(name string) string {
+	if !strings.HasPrefix(name, "lounge-") || !strings.HasSuffix(name, ".json") {
+		return ""
+	}
+	date := strings.TrimSuffix(strings.TrimPrefix(name, "lounge-"), ".json")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return ""
+	}
+	return date
+}
+
+func (s *Store) archiveDir() string { return filepath.Join(s.LogDir, "archive") }
+
+func (s *Store) archivePathForMonth(month string) string {
+	return filepath.Join(s.archiveDir(), fmt.Sprintf("lounge-%s.zip", month))
+}
+
+// readArchivedLog returns the raw contents of date's log from its monthly
+// archive. found is false when no archive holds that day.
+func (s *Store) readArchivedLog(date string) (data []byte, found bool, err error) {
+	if len(date) < len("2006-01") {
+		return nil, false, nil
+	}
+	p := s.archivePathForMonth(date[:len("2006-01")])
+	reader, err := zip.OpenReader(p)
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("open archive: %s: %w", p, err)
+	}
+	defer reader.Close()
+	for _, f := range reader.File {
+		if f.Name != logFileName(date) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, false, fmt.Errorf("open archived log: %s: %w", f.Name, err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, false, fmt.Errorf("read archived log: %s: %w", f.Name, err)
+		}
+		return data, true, nil
+	}
+	return nil, false, nil
+}
+
+// listArchivedLogDates returns every day stored in log/archive.
+func (s *Store) listArchivedLogDates() []string {
+	files, err := os.ReadDir(s.archiveDir())
+	if err != nil {
+		return nil
+	}
+	dates := []string{}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".zip") {
+			continue
+		}
+		reader, err := zip.OpenReader(filepath.Join(s.archiveDir(), f.Name()))
+		if err != nil {
+			continue
+		}
+		for _, entry := range reader.File {
+			if date := logDateFromFileName(entry.Name); date != "" {
+				dates = append(dates, date)
+			}
+		}
+		reader.Close()
+	}
+	return dates
+}
+
+// ArchiveOldLogs moves daily log files older than maxAgeDays into one zip per
+// month. Each archive is written to a temp file, verified against the source
+// files and renamed into place before any original is deleted. progress is
+// called after each month with the number of months done.
+func (s *Store) ArchiveOldLogs(maxAgeDays int, progress func(done, total int)) (int, error) {
+	if s.ReadOnly {
+		return 0, ErrReadOnly
+	}
+	if maxAgeDays <= 0 {
+		return 0, nil
+	}
+	files, err := os.ReadDir(s.LogDir)
+	if err != nil {
+		return 0, fmt.Errorf("read log dir: %w", err)
+	}
+	cutoff := time.Now().AddDate(0, 0, -maxAgeDays).Format("2006-01-02")
+	byMonth := map[string][]string{}
+	for _, f := range files {
+		date := logDateFromFileName(f.Name())
+		if date == "" || date >= cutoff {
+			continue
+		}
+		month := date[:len("2006-01")]
+		byMonth[month] = append(byMonth[month], f.Name())
+	}
+	months := make([]string, 0, len(byMonth))
+	for month := range byMonth {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	archived := 0
+	for i, month := range months {
+		if err := s.archiveMonth(month, byMonth[month]); err != nil {
+			return archived, err
+		}
+		archived += len(byMonth[month])
+		if progress != nil {
+			progress(i+1, len(months))
+		}
+	}
+	return archived, nil
+}
+
+func (s *Store) archiveMonth(month string, names []string) error {
+	if err := os.MkdirAll(s.archiveDir(), 0o755); err != nil {
+		return fmt.Errorf("create archive dir: %w", err)
+	}
+	contents := map[string][]byte{}
+	finalPath := s.archivePathForMonth(month)
+	if existing, err := zip.OpenReader(finalPath); err == nil {
+		for _, f := range existing.File {
+			rc, err := f.Open()
+			if err != nil {
+				existing.Close()
+				return fmt.Errorf("read archive: %s: %w", finalPath, err)
+			}
+			data, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				existing.Close()
+				return fmt.Errorf("read archive: %s: %w", finalPath, err)
+			}
+			contents[f.Name] = data
+		}
+		existing.Close()
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(s.LogDir, name))
+		if err != nil {
+			return fmt.Errorf("read log: %s: %w", name, err)
+		}
+		contents[name] = data
+	}
+
+	tmpPath := finalPath + ".tmp"
+	if err := writeZip(tmpPath, contents); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := verifyZip(tmpPath, contents); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("replace archive: %s: %w", finalPath, err)
+	}
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(s.LogDir, name)); err != nil {
+			return fmt.Errorf("remove archived log: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func writeZip(path string, contents map[string][]byte) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create archive: %s: %w", path, err)
+	}
+	names := make([]string, 0, len(contents))
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	zw := zip.NewWriter(out)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			out.Close()
+			return fmt.Errorf("add %s to archive: %w", name, err)
+		}
+		if _, err := w.Write(contents[name]); err != nil {
+			out.Close()
+			return fmt.Errorf("add %s to archive: %w", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return fmt.Errorf("finish archive: %s: %w", path, err)
+	}
+	return out.Close()
+}
+
+// verifyZip re-reads the archive (which checks each entry's CRC) and compares
+// every entry with the bytes it was built from.
+func verifyZip(path string, contents map[string][]byte) error {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("verify archive: %s: %w", path, err)
+	}
+	defer reader.Close()
+	seen := 0
+	for _, f := range reader.File {
+		want, ok := contents[f.Name]
+		if !ok {
+			return fmt.Errorf("verify archive: %s: unexpected entry %s", path, f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("verify archive: %s: %w", path, err)
+		}
+		got, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("verify archive: %s: %w", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			return fmt.Errorf("verify archive: %s: %s does not match its source", path, f.Name)
+		}
+		seen++
+	}
+	if seen != len(contents) {
+		return fmt.Errorf("verify archive: %s: %d of %d entries present", path, seen, len(contents))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AuditUsageDays is how far back the inventory audit totals device usage.
+const AuditUsageDays = 30
+
+// saveInventory writes the devices back to log/devices.json, keeping the
+// type icons. Without the file the defaults are written out in full.
+func (s *Store) saveInventory() error {
+	if err := s.EnsureLogDir(); err != nil {
+		return err
+	}
+	inv := deviceInventory{TypeIcons: s.TypeIcons}
+	for _, device := range s.Devices {
+		inv.Devices = append(inv.Devices, DeviceSpec{ID: device.ID, Type: device.Type, Label: device.Label, Icon: device.Icon,
+			AssetTag: device.AssetTag, SerialNumber: device.SerialNumber})
+	}
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(s.inventoryFile(), data, 0o644)
+}
+
+// SetDeviceAsset records deviceID's asset tag and serial number. They are
+// informational; nothing in check-in looks at them.
+func (s *Store) SetDeviceAsset(deviceID int, assetTag, serialNumber string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	device := s.DeviceByID(deviceID)
+	if device == nil {
+		return newError(ErrDeviceNotFound, "device ID %d does not exist", deviceID)
+	}
+	device.AssetTag = strings.TrimSpace(assetTag)
+	device.SerialNumber = strings.TrimSpace(serialNumber)
+	err := s.saveInventory()
+	s.changed()
+	return err
+}
+
+// SetDeviceLabel names deviceID for staff, e.g. "PS5"; empty goes back to
+// its type and number.
+func (s *Store) SetDeviceLabel(deviceID int, label string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	device := s.DeviceByID(deviceID)
+	if device == nil {
+		return newError(ErrDeviceNotFound, "device ID %d does not exist", deviceID)
+	}
+	device.Label = strings.TrimSpace(label)
+	err := s.saveInventory()
+	s.changed()
+	return err
+}
+
+// DeviceUsage totals the time each device was in use over the days days up
+// to and including now's, counting open sessions up to now. Players sharing
+// a console count once.
+func (s *Store) DeviceUsage(days int, now time.Time) (map[int]time.Duration, error) {
+	start := now.AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+	entries, err := s.ReadLogEntriesInRange(start, now.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	type span struct{ from, to time.Time }
+	spans := make(map[int][]span)
+	for _, entry := range entries {
+		if entry.Kind != "" || entry.PCID == 0 {
+			continue
+		}
+		if d, ok := entry.SessionDuration(now); ok {
+			spans[entry.PCID] = append(spans[entry.PCID], span{entry.CheckInTime, entry.CheckInTime.Add(d)})
+		}
+	}
+	usage := make(map[int]time.Duration, len(spans))
+	for id, list := range spans {
+		sort.Slice(list, func(i, j int) bool { return list[i].from.Before(list[j].from) })
+		var total time.Duration
+		current := list[0]
+		for _, next := range list[1:] {
+			if next.from.After(current.to) {
+				total += current.to.Sub(current.from)
+				current = next
+			} else if next.to.After(current.to) {
+				current.to = next.to
+			}
+		}
+		usage[id] = total + current.to.Sub(current.from)
+	}
+	return usage, nil
+}
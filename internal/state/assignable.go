@@ -0,0 +1,104 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrDeviceMaintenance is returned for a device under maintenance.
+	ErrDeviceMaintenance = errors.New("device under maintenance")
+	// ErrDeviceReserved warns that a device is reserved right now.
+	ErrDeviceReserved = errors.New("device reserved")
+	// ErrNotWaitedFor warns that a queued user is waiting for another device
+	// or device type.
+	ErrNotWaitedFor = errors.New("not the device waited for")
+)
+
+// Assignable reports whether userID may be seated on deviceID now, by the
+// Device validators: nil, a *ValidationWarning staff may waive, or an error
+// refusing it. Its kind, matched with errors.Is, is the reason:
+// ErrDeviceNotFound, ErrDeviceBusy or ErrDeviceMaintenance for refusals;
+// ErrDeviceCleaning, ErrDeviceReserved or ErrNotWaitedFor for warnings.
+// Every way of seating someone goes through it, so a new device state needs
+// one validator to be honoured everywhere.
+func (s *Store) Assignable(userID string, deviceID int, opts RegisterOptions, now time.Time) error {
+	ctx := CheckInContext{Store: s, Name: userID, UserID: userID, Options: opts, Now: now}
+	if u := s.UserByID(userID); u != nil {
+		ctx.Name = u.Name
+	}
+	if ctx.Device = s.DeviceByID(deviceID); ctx.Device == nil {
+		return newError(ErrDeviceNotFound, "device ID %d does not exist", deviceID)
+	}
+	return s.runValidators(ctx, true)
+}
+
+func validateReserved(ctx CheckInContext) error {
+	if ctx.Device == nil {
+		return nil
+	}
+	occurrence, ok := ctx.Store.ReservationAt(ctx.Device.ID, ctx.Now)
+	if !ok {
+		return nil
+	}
+	return &ValidationWarning{Validator: "reserved", Title: "Reserved",
+		Question: fmt.Sprintf("Seat %s anyway?", ctx.Name),
+		Err: newError(ErrDeviceReserved, "%s is reserved for %s until %s", ctx.Device.Name(),
+			occurrence.Reservation.Label, occurrence.End.Format("15:04"))}
+}
+
+// validateWaitedFor asks before seating a queued user on something other
+// than what they are waiting for. New check-ins are not waiting for anything.
+func validateWaitedFor(ctx CheckInContext) error {
+	u := ctx.Store.UserByID(ctx.UserID)
+	if ctx.Device == nil || u == nil || u.PCID != 0 || u.WaitingFor.Matches(*ctx.Device) {
+		return nil
+	}
+	wanted := "any " + u.WaitingFor.Type
+	if u.WaitingFor.DeviceID != 0 {
+		wanted = fmt.Sprintf("device %d", u.WaitingFor.DeviceID)
+		if device := ctx.Store.DeviceByID(u.WaitingFor.DeviceID); device != nil {
+			wanted = device.Name()
+		}
+	}
+	return &ValidationWarning{Validator: "waiting-for", Title: "Waiting for Another Device",
+		Question: fmt.Sprintf("Seat them on %s anyway?", ctx.Device.Name()),
+		Err:      newError(ErrNotWaitedFor, "%s is waiting for %s", ctx.Name, wanted)}
+}
+
+// AssignQueuedConfirming is AssignQueued that asks confirm about each
+// warning from Assignable in turn, as RegisterConfirming does, then passes
+// the outcome to done.
+func (s *Store) AssignQueuedConfirming(userID string, deviceID int, opts RegisterOptions, confirm Confirmer, done func(error)) {
+	confirmWarnings(func(opts RegisterOptions) error {
+		return s.AssignQueuedWith(userID, deviceID, opts)
+	}, opts, confirm, done)
+}
+
+// SwitchDeviceConfirming is SwitchDevice that asks confirm about each
+// warning from Assignable in turn, then passes the outcome to done.
+func (s *Store) SwitchDeviceConfirming(userID string, deviceID int, opts RegisterOptions, confirm Confirmer, done func(error)) {
+	confirmWarnings(func(opts RegisterOptions) error {
+		return s.SwitchDeviceWith(userID, deviceID, opts)
+	}, opts, confirm, done)
+}
+
+// confirmWarnings runs try, and while it fails with a warning confirm lets
+// through, runs it again with that warning waived.
+func confirmWarnings(try func(RegisterOptions) error, opts RegisterOptions, confirm Confirmer, done func(error)) {
+	err := try(opts)
+	var warning *ValidationWarning
+	if confirm == nil || !errors.As(err, &warning) {
+		done(err)
+		return
+	}
+	confirm(warning, func(ok bool) {
+		if !ok {
+			done(warning)
+			return
+		}
+		opts.Waived = append(append([]string{}, opts.Waived...), warning.Validator)
+		confirmWarnings(try, opts, confirm, done)
+	})
+}
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const assignableMembers = "Student Name,Student Number\nAda Lovelace,1001\n"
+
+// newAssignableStore has a device in each state Assignable knows about:
+//
+//	1 free PC, 2 occupied PC, 3 PC under maintenance, 4 PC being cleaned,
+//	5 reserved PC, 6 free console, 7 free PC to start a switch from.
+func newAssignableStore(t *testing.T) *Store {
+	t.Helper()
+	s := newTestStore(t, assignableMembers,
+		Device{ID: 1, Type: "PC", Status: "free"},
+		Device{ID: 2, Type: "PC", Status: "occupied", UserID: "9999"},
+		Device{ID: 3, Type: "PC", Status: StatusMaintenance},
+		Device{ID: 4, Type: "PC", Status: "free"},
+		Device{ID: 5, Type: "PC", Status: "free"},
+		Device{ID: 6, Type: "Console", Status: "free"},
+		Device{ID: 7, Type: "PC", Status: "free"},
+	)
+	s.cleaningUntil = map[int]time.Time{4: time.Now().Add(time.Hour)}
+	if conflicts, err := s.SaveReservation(Reservation{Label: "Chess club", Devices: []int{5},
+		Date: TodaysLogDate(), Start: "00:00", End: "23:59"}, false); err != nil || len(conflicts) > 0 {
+		t.Fatalf("reserving device 5: %v %v", err, conflicts)
+	}
+	return s
+}
+
+// seatings are the ways of seating Ada on a device. Each prepares the store
+// and returns the call under test.
+var seatings = []struct {
+	name    string
+	prepare func(t *testing.T, s *Store) func(deviceID int, opts RegisterOptions) error
+}{
+	{"register", func(t *testing.T, s *Store) func(int, RegisterOptions) error {
+		return func(deviceID int, opts RegisterOptions) error {
+			return s.RegisterWith("Ada Lovelace", "1001", deviceID, opts)
+		}
+	}},
+	{"assign queued", func(t *testing.T, s *Store) func(int, RegisterOptions) error {
+		if err := s.RegisterWith("Ada Lovelace", "1001", 0, RegisterOptions{WaitingFor: &WaitingFor{Type: "PC"}}); err != nil {
+			t.Fatal(err)
+		}
+		return func(deviceID int, opts RegisterOptions) error {
+			return s.AssignQueuedWith("1001", deviceID, opts)
+		}
+	}},
+	{"switch", func(t *testing.T, s *Store) func(int, RegisterOptions) error {
+		if err := s.Register("Ada Lovelace", "1001", 7, ""); err != nil {
+			t.Fatal(err)
+		}
+		return func(deviceID int, opts RegisterOptions) error {
+			return s.SwitchDeviceWith("1001", deviceID, opts)
+		}
+	}},
+}
+
+func TestSeatingGoesThroughAssignable(t *testing.T) {
+	tests := []struct {
+		name   string
+		device int
+		want   error
+		// warning is the validator staff may waive, "" for a refusal.
+		warning string
+		// queuedOnly is set when only a queued user is vetted by the rule.
+		queuedOnly bool
+	}{
+		{name: "free", device: 1},
+		{name: "missing", device: 99, want: ErrDeviceNotFound},
+		{name: "occupied", device: 2, want: ErrDeviceBusy},
+		{name: "maintenance", device: 3, want: ErrDeviceMaintenance},
+		{name: "cleaning", device: 4, want: ErrDeviceCleaning, warning: "cleaning"},
+		{name: "reserved", device: 5, want: ErrDeviceReserved, warning: "reserved"},
+		{name: "not waited for", device: 6, want: ErrNotWaitedFor, warning: "waiting-for", queuedOnly: true},
+	}
+	for _, seating := range seatings {
+		for _, tt := range tests {
+			t.Run(seating.name+"/"+tt.name, func(t *testing.T) {
+				s := newAssignableStore(t)
+				seat := seating.prepare(t, s)
+				want := tt.want
+				if tt.queuedOnly && seating.name != "assign queued" {
+					want = nil
+				}
+
+				err := seat(tt.device, RegisterOptions{})
+				if want == nil {
+					if err != nil {
+						t.Fatalf("got %v, want it seated", err)
+					}
+					if u := s.UserByID("1001"); u == nil || u.PCID != tt.device {
+						t.Fatalf("user is on %+v, want device %d", u, tt.device)
+					}
+					return
+				}
+				if !errors.Is(err, want) {
+					t.Fatalf("got %v, want %v", err, want)
+				}
+				var warning *ValidationWarning
+				if isWarning := errors.As(err, &warning); isWarning != (tt.warning != "") {
+					t.Fatalf("got warning %v for %v, want %q", isWarning, err, tt.warning)
+				}
+				if tt.warning == "" {
+					return
+				}
+				if warning.Validator != tt.warning {
+					t.Fatalf("got warning from %q, want %q", warning.Validator, tt.warning)
+				}
+				if err := seat(tt.device, RegisterOptions{Waived: []string{tt.warning}}); err != nil {
+					t.Fatalf("waiving %s: %v", tt.warning, err)
+				}
+				if u := s.UserByID("1001"); u == nil || u.PCID != tt.device {
+					t.Fatalf("user is on %+v after waiving, want device %d", u, tt.device)
+				}
+			})
+		}
+	}
+}
+
+func TestSwitchDeviceConfirming(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  bool
+		wantErr bool
+		wantPC  int
+	}{
+		{"confirmed", true, false, 5},
+		{"declined", false, true, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newAssignableStore(t)
+			if err := s.Register("Ada Lovelace", "1001", 7, ""); err != nil {
+				t.Fatal(err)
+			}
+			asked := 0
+			confirm := func(warning *ValidationWarning, answer func(bool)) {
+				asked++
+				answer(tt.answer)
+			}
+			var got error
+			s.SwitchDeviceConfirming("1001", 5, RegisterOptions{}, confirm, func(err error) { got = err })
+			if asked != 1 || (got != nil) != tt.wantErr {
+				t.Fatalf("asked %d times, got %v", asked, got)
+			}
+			if pc := s.UserByID("1001").PCID; pc != tt.wantPC {
+				t.Fatalf("user is on device %d, want %d", pc, tt.wantPC)
+			}
+			if reserved := s.DeviceByID(5).Status; (reserved == "occupied") != (tt.wantPC == 5) {
+				t.Fatalf("device 5 is %s", reserved)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package state
+
+import "time"
+
+// MarkAway flags userID as briefly away from their device, e.g. at the
+// bathroom, so staff do not check them out meanwhile. The session keeps
+// running and the device stays theirs; the flag lapses after AwayPeriod.
+func (s *Store) MarkAway(userID string, now time.Time) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	u := s.UserByID(userID)
+	if u == nil {
+		return newError(ErrUserNotFound, "user ID %s not found", userID)
+	}
+	if u.PCID == 0 {
+		return newError(ErrUserNotFound, "user ID %s (%s) is in the queue, not on a device", userID, u.Name)
+	}
+	u.AwaySince = now
+	s.saveJournaled(JournalEntry{Time: now, Action: JournalAway, UserID: u.ID, Name: u.Name, Device: u.PCID, Session: u.SessionID})
+	s.changed()
+	return nil
+}
+
+// ClearAway takes userID's away flag down, when staff see them back.
+func (s *Store) ClearAway(userID string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	u := s.UserByID(userID)
+	if u == nil || u.AwaySince.IsZero() {
+		return nil
+	}
+	u.AwaySince = time.Time{}
+	s.saveJournaled(JournalEntry{Time: time.Now(), Action: JournalBack, UserID: u.ID, Name: u.Name, Device: u.PCID, Session: u.SessionID})
+	s.changed()
+	return nil
+}
+
+// AwayUntil returns when u's away flag lapses, or false when they are not
+// away at now.
+func (s *Store) AwayUntil(u User, now time.Time) (time.Time, bool) {
+	if u.AwaySince.IsZero() || s.AwayPeriod <= 0 {
+		return time.Time{}, false
+	}
+	until := u.AwaySince.Add(s.AwayPeriod)
+	return until, until.After(now)
+}
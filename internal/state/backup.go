@@ -0,0 +1,130 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	backupDirPrefix = "lounge-backup-"
+	backupDirLayout = "2006-01-02-150405"
+)
+
+// BackupSnapshot is the data files as they stood at one moment, held in
+// memory so a slow backup destination never holds a lock.
+type BackupSnapshot struct {
+	Time  time.Time
+	Files map[string][]byte // by file name
+}
+
+// SnapshotForBackup reads active_users.json, devices.json, the member file
+// and today's and yesterday's logs, plus extra files by path, into memory.
+// Call it from the UI goroutine; it waits for the queued writes so the
+// snapshot has every change so far. Files that do not exist are skipped.
+func (s *Store) SnapshotForBackup(now time.Time, extra ...string) (BackupSnapshot, error) {
+	s.FlushWrites()
+	snap := BackupSnapshot{Time: now, Files: make(map[string][]byte)}
+	add := func(p string) error {
+		data, err := os.ReadFile(p)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read %s for backup: %w", p, err)
+		}
+		snap.Files[filepath.Base(p)] = data
+		return nil
+	}
+	for _, p := range append([]string{s.userDataFile(), s.inventoryFile(), s.MemberFile}, extra...) {
+		if err := add(p); err != nil {
+			return snap, err
+		}
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	for _, day := range []time.Time{now, now.AddDate(0, 0, -1)} {
+		if err := add(s.LogFilePathForDate(day.Format("2006-01-02"))); err != nil {
+			return snap, err
+		}
+	}
+	return snap, nil
+}
+
+// WriteBackup writes snap to a timestamped folder under dest and returns its
+// path. The folder gets its name only once every file is in it, so an
+// interrupted backup never looks complete.
+func WriteBackup(dest string, snap BackupSnapshot) (string, error) {
+	dir := filepath.Join(dest, backupDirPrefix+snap.Time.Format(backupDirLayout))
+	partial := dir + ".partial"
+	if err := os.MkdirAll(partial, 0o755); err != nil {
+		return "", fmt.Errorf("create backup folder: %w", err)
+	}
+	for name, data := range snap.Files {
+		if err := os.WriteFile(filepath.Join(partial, name), data, 0o644); err != nil {
+			os.RemoveAll(partial)
+			return "", fmt.Errorf("write backup of %s: %w", name, err)
+		}
+	}
+	if err := os.Rename(partial, dir); err != nil {
+		os.RemoveAll(partial)
+		return "", fmt.Errorf("finish backup folder: %w", err)
+	}
+	return dir, nil
+}
+
+// backupTime returns when the backup folder name was taken, or false when
+// name is not a backup folder.
+func backupTime(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, backupDirPrefix) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(backupDirLayout, strings.TrimPrefix(name, backupDirPrefix), time.Local)
+	return t, err == nil
+}
+
+// PruneBackups removes the backup folders under dest older than keepDays
+// days and returns how many it removed; 0 keeps every backup.
+func PruneBackups(dest string, keepDays int, now time.Time) (int, error) {
+	if keepDays <= 0 {
+		return 0, nil
+	}
+	entries, err := os.ReadDir(dest)
+	if err != nil {
+		return 0, fmt.Errorf("list backups: %w", err)
+	}
+	cutoff := now.AddDate(0, 0, -keepDays)
+	removed := 0
+	for _, entry := range entries {
+		t, ok := backupTime(entry.Name())
+		if !entry.IsDir() || !ok || !t.Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dest, entry.Name())); err != nil {
+			return removed, fmt.Errorf("remove old backup: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// LatestBackup returns when the newest complete backup under dest was
+// taken, or the zero time when there is none.
+func LatestBackup(dest string) (time.Time, error) {
+	entries, err := os.ReadDir(dest)
+	if os.IsNotExist(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("list backups: %w", err)
+	}
+	var latest time.Time
+	for _, entry := range entries {
+		if t, ok := backupTime(entry.Name()); entry.IsDir() && ok && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// DailyUsage is today's device time per user and device type, from today's
+// log. Open sessions count up to the time it was taken.
+type DailyUsage map[string]map[string]time.Duration
+
+// DailyUsageToday reads today's log once, for checking caps against many
+// users, e.g. a page of member search results.
+func (s *Store) DailyUsageToday(now time.Time) DailyUsage {
+	usage := DailyUsage{}
+	if len(s.DailyCaps) == 0 {
+		return usage
+	}
+	entries, err := s.ReadDailyLogEntriesLocked()
+	if err != nil {
+		return usage
+	}
+	for _, entry := range entries {
+		device := s.DeviceByID(entry.PCID)
+		if entry.Kind != "" || entry.UserID == "" || device == nil {
+			continue
+		}
+		d, ok := entry.SessionDuration(now)
+		if !ok {
+			continue
+		}
+		if usage[entry.UserID] == nil {
+			usage[entry.UserID] = make(map[string]time.Duration)
+		}
+		usage[entry.UserID][device.Type] += d
+	}
+	return usage
+}
+
+// CapRemaining is how much of today's cap on deviceType userID has left,
+// never below zero; capped is false when deviceType has no cap.
+func (s *Store) CapRemaining(usage DailyUsage, userID, deviceType string) (left time.Duration, capped bool) {
+	limit := s.DailyCaps[deviceType]
+	if limit <= 0 {
+		return 0, false
+	}
+	return max(limit-usage[userID][deviceType], 0), true
+}
+
+// FormatMinutes shows a duration to the minute, e.g. "2h 05m" or "40m".
+func FormatMinutes(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if h := int(d.Hours()); h > 0 {
+		return fmt.Sprintf("%dh %02dm", h, int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm", int(d.Minutes()))
+}
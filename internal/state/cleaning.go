@@ -0,0 +1,61 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDeviceCleaning warns that a device freed a moment ago has not been
+// wiped down yet.
+var ErrDeviceCleaning = errors.New("device being cleaned")
+
+// startCleaning starts deviceID's cleaning cooldown if it is now free. The
+// cooldown lives only in memory: after a restart every free device is ready.
+func (s *Store) startCleaning(deviceID int, now time.Time) {
+	device := s.DeviceByID(deviceID)
+	if s.CleaningCooldown <= 0 || device == nil || device.Status != "free" {
+		return
+	}
+	if s.cleaningUntil == nil {
+		s.cleaningUntil = make(map[int]time.Time)
+	}
+	s.cleaningUntil[deviceID] = now.Add(s.CleaningCooldown)
+}
+
+// CleaningUntil returns when deviceID's cleaning cooldown ends, or false when
+// it is not being cleaned.
+func (s *Store) CleaningUntil(deviceID int, now time.Time) (time.Time, bool) {
+	until, ok := s.cleaningUntil[deviceID]
+	if !ok || !until.After(now) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// ClearCleaning ends deviceID's cleaning cooldown early, once staff have
+// wiped it down.
+func (s *Store) ClearCleaning(deviceID int) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	if _, ok := s.cleaningUntil[deviceID]; !ok {
+		return nil
+	}
+	delete(s.cleaningUntil, deviceID)
+	s.changed()
+	return nil
+}
+
+func validateCleaned(ctx CheckInContext) error {
+	if ctx.Device == nil {
+		return nil
+	}
+	until, ok := ctx.Store.CleaningUntil(ctx.Device.ID, ctx.Now)
+	if !ok {
+		return nil
+	}
+	return &ValidationWarning{Validator: "cleaning", Title: "Being Cleaned",
+		Question: fmt.Sprintf("Seat %s anyway?", ctx.Name),
+		Err:      newError(ErrDeviceCleaning, "%s %d is being cleaned until %s", ctx.Device.Type, ctx.Device.ID, until.Format("15:04"))}
+}
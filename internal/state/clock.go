@@ -0,0 +1,93 @@
+package state
+
+import (
+	"time"
+)
+
+// clockJumpTolerance is how far the wall clock may go back between checks
+// before it counts as a jump. NTP slews stay well below it; a manual clock
+// change or a day-off BIOS clock does not.
+const clockJumpTolerance = 2 * time.Minute
+
+// ClockReport describes what CheckClock found.
+type ClockReport struct {
+	// Jump is how far the wall clock went backwards since the previous
+	// check, as a negative duration; zero when it did not, and on the first
+	// check.
+	Jump time.Duration
+	// Flagged names the open sessions whose check-in is now in the future.
+	Flagged []string
+}
+
+// CheckClock compares the wall clock with its reading at the previous call
+// and flags open sessions that started "after" now, which only happens when
+// the clock went backwards past them. Only wall times are compared: the
+// monotonic clock stops while the computer sleeps, so it cannot tell a
+// forward jump from a suspend. DST changes are not jumps either: times are
+// absolute instants, so only the local rendering shifts. The log is written
+// only when a session is newly flagged.
+func (s *Store) CheckClock(now time.Time) ClockReport {
+	now = now.Round(0)
+	var report ClockReport
+	if !s.lastClockCheck.IsZero() {
+		if elapsed := now.Sub(s.lastClockCheck); elapsed < -clockJumpTolerance {
+			report.Jump = elapsed
+		}
+	}
+	s.lastClockCheck = now
+
+	flagged := make(map[string]bool)
+	future := make(map[string]bool)
+	for _, u := range s.ActiveUsers {
+		if !u.CheckInTime.Round(0).After(now) {
+			continue
+		}
+		report.Flagged = append(report.Flagged, u.Name)
+		if key := u.ID + "/" + u.SessionID; s.clockFlagged[key] {
+			flagged[key] = true
+		} else {
+			future[u.ID] = true
+			flagged[key] = true
+		}
+	}
+	s.clockFlagged = flagged
+	if len(future) == 0 {
+		return report
+	}
+	s.updateDailyLog(func(entries []LogEntry) {
+		for i := range entries {
+			if entries[i].CheckOutTime.IsZero() && future[entries[i].UserID] {
+				entries[i].ClockSkew = true
+			}
+		}
+	})
+	return report
+}
+
+// sessionUsage works out a finished session's duration from the wall clock
+// times, as they are logged. ok is false when the clock went back past the
+// check-in, so no trustworthy duration exists.
+func sessionUsage(checkIn, checkOut time.Time) (d time.Duration, ok bool) {
+	d = checkOut.Round(0).Sub(checkIn.Round(0))
+	return d, d >= 0
+}
+
+// SessionDuration is how long the session lasted, or has lasted by now while
+// it is open. ok is false for flagged sessions without a recorded duration,
+// which must not be counted rather than counted as negative.
+func (e LogEntry) SessionDuration(now time.Time) (time.Duration, bool) {
+	if e.UsageTime != "" {
+		if d, err := time.ParseDuration(e.UsageTime); err == nil {
+			return d, true
+		}
+	}
+	end := e.CheckOutTime
+	if end.IsZero() {
+		end = now
+	}
+	d := end.Sub(e.CheckInTime)
+	if e.ClockSkew || d < 0 {
+		return 0, false
+	}
+	return d, true
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckClock(t *testing.T) {
+	start := time.Date(2026, 3, 29, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		next     time.Time
+		wantJump time.Duration
+		flagged  int
+	}{
+		{"ticking", start.Add(30 * time.Second), 0, 0},
+		{"woken from sleep", start.Add(8 * time.Hour), 0, 0},
+		{"small correction", start.Add(-time.Minute), 0, 0},
+		{"set back past a check-in", start.Add(-time.Hour), -time.Hour, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "")
+			s.ActiveUsers = []User{{ID: "LOUNGE-1", Name: "Ada Lovelace", PCID: 1, CheckInTime: start.Add(-10 * time.Minute), SessionID: "a"}}
+			s.CheckClock(start)
+			report := s.CheckClock(tt.next)
+			if report.Jump != tt.wantJump || len(report.Flagged) != tt.flagged {
+				t.Fatalf("got %+v, want jump %s and %d flagged", report, tt.wantJump, tt.flagged)
+			}
+			if tt.flagged > 0 && !s.clockFlagged["LOUNGE-1/a"] {
+				t.Fatal("flagged session not remembered, so every tick rewrites the log")
+			}
+		})
+	}
+}
+
+func TestSessionUsage(t *testing.T) {
+	checkIn := time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		checkOut time.Time
+		want     time.Duration
+		ok       bool
+	}{
+		{"normal", checkIn.Add(90 * time.Minute), 90 * time.Minute, true},
+		{"across a DST change", checkIn.In(time.FixedZone("CET", 3600)).Add(3 * time.Hour), 3 * time.Hour, true},
+		{"clock set back past the check-in", checkIn.Add(-time.Hour), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := sessionUsage(checkIn, tt.checkOut)
+			if ok != tt.ok || (ok && d != tt.want) {
+				t.Fatalf("got %s %v, want %s %v", d, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrLoungeClosed warns that a check-in falls on a closure day.
+var ErrLoungeClosed = errors.New("lounge closed")
+
+// Closure is a day or run of days the lounge is closed, such as a university
+// holiday or an exam quiet period. Start and End are inclusive YYYY-MM-DD
+// dates.
+type Closure struct {
+	Label string `json:"label"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ParseClosure parses "Reading week: 2025-02-17..2025-02-21", or a single
+// day such as "Canada Day: 2025-07-01".
+func ParseClosure(line string) (Closure, error) {
+	label, span, ok := strings.Cut(line, ":")
+	if !ok {
+		return Closure{}, fmt.Errorf("closure %q: expected \"Label: YYYY-MM-DD\" or \"Label: YYYY-MM-DD..YYYY-MM-DD\"", line)
+	}
+	start, end, isRange := strings.Cut(span, "..")
+	if !isRange {
+		end = start
+	}
+	closure := Closure{Label: strings.TrimSpace(label), Start: strings.TrimSpace(start), End: strings.TrimSpace(end)}
+	if closure.Label == "" {
+		return Closure{}, fmt.Errorf("closure %q: label is required", line)
+	}
+	startDate, err := time.Parse("2006-01-02", closure.Start)
+	if err != nil {
+		return Closure{}, fmt.Errorf("closure %s: bad start date %q", closure.Label, closure.Start)
+	}
+	endDate, err := time.Parse("2006-01-02", closure.End)
+	if err != nil {
+		return Closure{}, fmt.Errorf("closure %s: bad end date %q", closure.Label, closure.End)
+	}
+	if endDate.Before(startDate) {
+		return Closure{}, fmt.Errorf("closure %s: ends before it starts", closure.Label)
+	}
+	return closure, nil
+}
+
+func (c Closure) String() string {
+	if c.Start == c.End {
+		return fmt.Sprintf("%s: %s", c.Label, c.Start)
+	}
+	return fmt.Sprintf("%s: %s..%s", c.Label, c.Start, c.End)
+}
+
+// Contains reports whether the YYYY-MM-DD date falls inside the closure.
+func (c Closure) Contains(date string) bool { return date >= c.Start && date <= c.End }
+
+// ClosureOn returns the closure covering the YYYY-MM-DD date, if any.
+func (s *Store) ClosureOn(date string) (Closure, bool) {
+	for _, closure := range s.Closures {
+		if closure.Contains(date) {
+			return closure, true
+		}
+	}
+	return Closure{}, false
+}
+
+// OpenDayAverages are a range's figures per day the lounge was open, so
+// closures do not count as days nobody came.
+type OpenDayAverages struct {
+	OpenDays   int
+	ClosedDays int
+	// VisitsPerDay and UsagePerDay average the sessions started on open
+	// days; sessions staff ran during a closure are left out.
+	VisitsPerDay float64
+	UsagePerDay  time.Duration
+	// PCUtilization is the share of PCs in use across the occupancy samples
+	// taken on open days, from 0 to 1; -1 when there are none.
+	PCUtilization float64
+}
+
+// OpenDayAverages works out the averages for the days from start to end,
+// stopping at today, over entries read for that range.
+func (s *Store) OpenDayAverages(start, end string, entries []LogEntry, now time.Time) OpenDayAverages {
+	var out OpenDayAverages
+	today := now.Format("2006-01-02")
+	if end > today {
+		end = today
+	}
+	first, err := time.ParseInLocation("2006-01-02", start, time.Local)
+	if err != nil {
+		out.PCUtilization = -1
+		return out
+	}
+	pcs := 0
+	for _, device := range s.Devices {
+		if device.Type == "PC" {
+			pcs++
+		}
+	}
+	var samples, occupied int
+	for day := first; day.Format("2006-01-02") <= end; day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if _, closed := s.ClosureOn(date); closed {
+			out.ClosedDays++
+			continue
+		}
+		out.OpenDays++
+		daySamples, err := s.ReadOccupancySamples(date)
+		if err != nil {
+			continue
+		}
+		for _, sample := range daySamples {
+			samples++
+			occupied += sample.OccupiedPCs
+		}
+	}
+	out.PCUtilization = -1
+	if samples > 0 && pcs > 0 {
+		out.PCUtilization = float64(occupied) / float64(samples*pcs)
+	}
+	if out.OpenDays == 0 {
+		return out
+	}
+	var visits int
+	var usage time.Duration
+	for _, entry := range entries {
+		if !entry.IsSession() {
+			continue
+		}
+		if _, closed := s.ClosureOn(entry.CheckInTime.Local().Format("2006-01-02")); closed {
+			continue
+		}
+		visits++
+		if d, ok := entry.SessionDuration(now); ok {
+			usage += d
+		}
+	}
+	out.VisitsPerDay = float64(visits) / float64(out.OpenDays)
+	out.UsagePerDay = usage / time.Duration(out.OpenDays)
+	return out
+}
+
+// validateOpen asks staff to confirm check-ins on a closure day. A running
+// event is already staff overriding the calendar, so it is not asked again.
+func validateOpen(ctx CheckInContext) error {
+	closure, closed := ctx.Store.ClosureOn(ctx.Now.Format("2006-01-02"))
+	if !closed || ctx.Store.Event != "" {
+		return nil
+	}
+	return &ValidationWarning{Validator: "closed", Title: "Lounge Closed", Question: "Check them in anyway?",
+		Err: newError(ErrLoungeClosed, "the lounge is closed today (%s)", closure.Label)}
+}
@@ -0,0 +1,149 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Repair is a fix staff can pick for a Discrepancy.
+type Repair string
+
+const (
+	RepairFreeDevice   Repair = "Free the device"
+	RepairOccupyDevice Repair = "Re-occupy the device"
+	RepairDropUser     Repair = "Drop the user"
+)
+
+// DroppedByRepairNote closes the log entry of a user dropped by a repair.
+const DroppedByRepairNote = "dropped by consistency repair"
+
+// Discrepancy is a device and active user that disagree, usually after a
+// crash between saving one and the other.
+type Discrepancy struct {
+	DeviceID int
+	UserID   string
+	Detail   string
+	Repairs  []Repair
+}
+
+func (s *Store) repairLogFile() string { return filepath.Join(s.LogDir, "repairs.log") }
+
+// CheckConsistency finds devices marked occupied that no active user is on,
+// and active users on a device that is free or does not exist.
+func (s *Store) CheckConsistency() []Discrepancy {
+	var found []Discrepancy
+	for _, device := range s.Devices {
+		if device.Status != "occupied" {
+			continue
+		}
+		switch {
+		case device.Type == "PC" && device.UserID == "":
+			found = append(found, Discrepancy{DeviceID: device.ID,
+				Detail:  fmt.Sprintf("PC %d is occupied by nobody", device.ID),
+				Repairs: []Repair{RepairFreeDevice}})
+		case device.Type == "PC":
+			if u := s.UserByID(device.UserID); u == nil || u.PCID != device.ID {
+				found = append(found, Discrepancy{DeviceID: device.ID, UserID: device.UserID,
+					Detail:  fmt.Sprintf("PC %d is occupied by %s, who is not checked in on it", device.ID, device.UserID),
+					Repairs: []Repair{RepairFreeDevice}})
+			}
+		case len(s.ActiveUserIDsOnDevice(device.ID)) == 0:
+			found = append(found, Discrepancy{DeviceID: device.ID,
+				Detail:  fmt.Sprintf("%s %d is occupied with no players checked in", device.Type, device.ID),
+				Repairs: []Repair{RepairFreeDevice}})
+		}
+	}
+	for _, u := range s.ActiveUsers {
+		if u.PCID == 0 {
+			continue
+		}
+		device := s.DeviceByID(u.PCID)
+		switch {
+		case device == nil:
+			found = append(found, Discrepancy{DeviceID: u.PCID, UserID: u.ID,
+				Detail:  fmt.Sprintf("%s (%s) is checked in on device %d, which does not exist", u.Name, u.ID, u.PCID),
+				Repairs: []Repair{RepairDropUser}})
+		case device.Status == "free":
+			found = append(found, Discrepancy{DeviceID: u.PCID, UserID: u.ID,
+				Detail:  fmt.Sprintf("%s (%s) is checked in on %s %d, which is shown as free", u.Name, u.ID, device.Type, device.ID),
+				Repairs: []Repair{RepairOccupyDevice, RepairDropUser}})
+		case device.Status == StatusMaintenance:
+			found = append(found, Discrepancy{DeviceID: u.PCID, UserID: u.ID,
+				Detail:  fmt.Sprintf("%s (%s) is checked in on %s %d, which is under maintenance", u.Name, u.ID, device.Type, device.ID),
+				Repairs: []Repair{RepairDropUser}})
+		case device.Type == "PC" && device.UserID != u.ID:
+			found = append(found, Discrepancy{DeviceID: u.PCID, UserID: u.ID,
+				Detail:  fmt.Sprintf("%s (%s) is checked in on PC %d, which shows %s", u.Name, u.ID, device.ID, device.UserID),
+				Repairs: []Repair{RepairDropUser}})
+		}
+	}
+	return found
+}
+
+// RepairDiscrepancy applies repair to d and records the choice in
+// log/repairs.log. A dropped user's session is closed with
+// DroppedByRepairNote.
+func (s *Store) RepairDiscrepancy(d Discrepancy, repair Repair) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	switch repair {
+	case RepairFreeDevice:
+		device := s.DeviceByID(d.DeviceID)
+		if device == nil {
+			return newError(ErrDeviceNotFound, "device ID %d does not exist", d.DeviceID)
+		}
+		device.UserID = ""
+		s.releaseDevice(device.ID)
+	case RepairOccupyDevice:
+		device := s.DeviceByID(d.DeviceID)
+		if device == nil {
+			return newError(ErrDeviceNotFound, "device ID %d does not exist", d.DeviceID)
+		}
+		if device.Status != "free" {
+			return newError(ErrDeviceBusy, "device %d is no longer free", d.DeviceID)
+		}
+		s.occupyDevice(device, d.UserID)
+	case RepairDropUser:
+		u := s.UserByID(d.UserID)
+		if u == nil {
+			return newError(ErrUserNotFound, "user ID %s not found", d.UserID)
+		}
+		dropped := *u
+		s.journal(JournalCheckOut, dropped, dropped.PCID)
+		s.removeActiveUser(dropped.ID)
+		// A device under maintenance stays that way; only its user goes.
+		if device := s.DeviceByID(dropped.PCID); device != nil && device.Status == StatusMaintenance {
+			if device.UserID == dropped.ID {
+				device.UserID = ""
+			}
+		} else if device != nil && (device.Type != "PC" || device.UserID == dropped.ID) {
+			s.releaseDevice(device.ID)
+		}
+		s.RecordLogEventAsync(false, dropped, dropped.PCID, DroppedByRepairNote)
+	default:
+		return fmt.Errorf("unknown repair %q", repair)
+	}
+	s.Save()
+	s.appendRepairLog(fmt.Sprintf("%s: %s", d.Detail, repair))
+	s.changed()
+	return nil
+}
+
+func (s *Store) appendRepairLog(line string) {
+	if err := s.EnsureLogDir(); err != nil {
+		s.writeFailed("writing repair log", err)
+		return
+	}
+	f, err := os.OpenFile(s.repairLogFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		s.writeFailed("writing repair log", err)
+		return
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%s %s\n", time.Now().Format(time.RFC3339), line); err != nil {
+		s.writeFailed("writing repair log", err)
+	}
+}
@@ -0,0 +1,55 @@
+package state
+
+import "testing"
+
+func TestRepairDropUserKeepsMaintenance(t *testing.T) {
+	tests := []struct {
+		name   string
+		device Device
+	}{
+		{"console", Device{ID: 6, Type: "Console", Status: StatusMaintenance}},
+		{"PC", Device{ID: 3, Type: "PC", Status: StatusMaintenance, UserID: "1001"}},
+		{"PC left on", Device{ID: 3, Type: "PC", Status: StatusMaintenance}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, assignableMembers, tt.device)
+			s.ActiveUsers = []User{{ID: "1001", Name: "Ada Lovelace", PCID: tt.device.ID}}
+			found := s.CheckConsistency()
+			if len(found) != 1 || found[0].UserID != "1001" {
+				t.Fatalf("got discrepancies %+v", found)
+			}
+			if err := s.RepairDiscrepancy(found[0], RepairDropUser); err != nil {
+				t.Fatal(err)
+			}
+			if s.UserByID("1001") != nil {
+				t.Fatal("user is still checked in")
+			}
+			device := s.DeviceByID(tt.device.ID)
+			if device.Status != StatusMaintenance || device.UserID != "" {
+				t.Fatalf("device is %s with user %q, want maintenance with none", device.Status, device.UserID)
+			}
+			if found := s.CheckConsistency(); len(found) != 0 {
+				t.Fatalf("still found %+v", found)
+			}
+		})
+	}
+}
+
+func TestRepairDropUserLeavesOthersPC(t *testing.T) {
+	s := newTestStore(t, assignableMembers, Device{ID: 6, Type: "Console", Status: "occupied"}, Device{ID: 1, Type: "PC", Status: "occupied", UserID: "9999"})
+	s.ActiveUsers = []User{{ID: "1001", Name: "Ada Lovelace", PCID: 1}}
+	found := s.CheckConsistency()
+	var drop Discrepancy
+	for _, d := range found {
+		if d.UserID == "1001" {
+			drop = d
+		}
+	}
+	if err := s.RepairDiscrepancy(drop, RepairDropUser); err != nil {
+		t.Fatal(err)
+	}
+	if device := s.DeviceByID(1); device.Status != "occupied" || device.UserID != "9999" {
+		t.Fatalf("PC 1 is %s with %q, want it left to 9999", device.Status, device.UserID)
+	}
+}
@@ -0,0 +1,121 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// ForecastPoint is the expected number of free PCs at Time.
+type ForecastPoint struct {
+	Time time.Time
+	Free int
+}
+
+// ForecastFreePCs estimates free PCs at each step after now, up to horizon.
+// It is deliberately simple: every session ends when it reaches
+// SessionLimit counted from when the user was seated (sessions already over
+// it end now), and queued users take PCs in queue order as they free up,
+// then stay for the full limit. PCs in maintenance stay out of service, and
+// so do PCs whose session is exempt from the limit: there is no telling when
+// those end. PCs reserved at a step are not counted free at it. It returns
+// nil when no limit is set.
+func (s *Store) ForecastFreePCs(now time.Time, step, horizon time.Duration) []ForecastPoint {
+	if s.SessionLimit <= 0 || step <= 0 {
+		return nil
+	}
+	free := 0
+	var ends []time.Time
+	for _, device := range s.Devices {
+		if device.Type != "PC" {
+			continue
+		}
+		switch device.Status {
+		case "free":
+			free++
+		case "occupied":
+			end := now
+			if u := s.UserByID(device.UserID); u != nil {
+				if s.SessionLimitExempt(*u) {
+					continue
+				}
+				end = u.SeatedTime().Add(s.SessionLimit)
+			}
+			ends = append(ends, end)
+		}
+	}
+	queued := len(s.PendingUsers())
+	seat := func(at time.Time) {
+		queued--
+		ends = append(ends, at.Add(s.SessionLimit))
+	}
+	for free > 0 && queued > 0 {
+		free--
+		seat(now)
+	}
+
+	var points []ForecastPoint
+	for t := now.Add(step); !t.After(now.Add(horizon)); t = t.Add(step) {
+		for {
+			sort.Slice(ends, func(i, j int) bool { return ends[i].Before(ends[j]) })
+			if len(ends) == 0 || ends[0].After(t) {
+				break
+			}
+			end := ends[0]
+			ends = ends[1:]
+			if end.Before(now) {
+				end = now
+			}
+			if queued > 0 {
+				seat(end)
+			} else {
+				free++
+			}
+		}
+		points = append(points, ForecastPoint{Time: t, Free: max(free-s.reservedPCs(t), 0)})
+	}
+	return points
+}
+
+// reservedPCs counts the PCs in service that a reservation holds at t.
+func (s *Store) reservedPCs(t time.Time) int {
+	n := 0
+	for _, device := range s.Devices {
+		if device.Type != "PC" || device.Status == StatusMaintenance {
+			continue
+		}
+		if _, ok := s.ReservationAt(device.ID, t); ok {
+			n++
+		}
+	}
+	return n
+}
+
+// EndingSoon is a session within SessionWarning of SessionLimit. Left is
+// negative once the limit has passed.
+type EndingSoon struct {
+	User User
+	Left time.Duration
+}
+
+// SessionEndingSoon reports how long u has left when that is within
+// SessionWarning, counting the limit from when u was seated. Queued users,
+// exempt sessions and unset limits never end soon.
+func (s *Store) SessionEndingSoon(u User, now time.Time) (time.Duration, bool) {
+	if s.SessionLimit <= 0 || s.SessionWarning <= 0 || u.PCID == 0 || s.SessionLimitExempt(u) {
+		return 0, false
+	}
+	left := u.SeatedTime().Add(s.SessionLimit).Sub(now)
+	return left, left <= s.SessionWarning
+}
+
+// SessionsEndingSoon lists the sessions ending soon, least time left first.
+func (s *Store) SessionsEndingSoon(now time.Time) []EndingSoon {
+	var out []EndingSoon
+	for _, u := range s.ActiveUsers {
+		if left, ok := s.SessionEndingSoon(u, now); ok {
+			out = append(out, EndingSoon{User: u, Left: left})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Left < out[j].Left })
+	return out
+}
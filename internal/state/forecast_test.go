@@ -0,0 +1,86 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForecastFreePCs(t *testing.T) {
+	now := time.Now()
+	pcs := func() []Device {
+		return []Device{{ID: 1, Type: "PC", Status: "free"}, {ID: 2, Type: "PC", Status: "free"}, {ID: 3, Type: "PC", Status: "free"}}
+	}
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, s *Store)
+		want    int
+	}{
+		{"all free", func(t *testing.T, s *Store) {}, 3},
+		{"seated from a long queue wait", func(t *testing.T, s *Store) {
+			// Queued 90 minutes of a 2 hour limit, seated just now: the
+			// PC is not free within the hour.
+			if err := s.Register("Ada Lovelace", "LOUNGE-1", 0, ""); err != nil {
+				t.Fatal(err)
+			}
+			s.UserByID("LOUNGE-1").CheckInTime = now.Add(-90 * time.Minute)
+			if err := s.AssignQueued("LOUNGE-1", 1); err != nil {
+				t.Fatal(err)
+			}
+		}, 2},
+		{"reserved in the window", func(t *testing.T, s *Store) {
+			start := now.Add(30 * time.Minute)
+			end := now.Add(2 * time.Hour)
+			if start.Day() != now.Day() || end.Day() != now.Day() {
+				t.Skip("the reservation would cross midnight")
+			}
+			if conflicts, err := s.SaveReservation(Reservation{Label: "Chess club", Devices: []int{2, 3},
+				Date: now.Format("2006-01-02"), Start: start.Format("15:04"), End: end.Format("15:04")}, false); err != nil || len(conflicts) > 0 {
+				t.Fatal(err, conflicts)
+			}
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "", pcs()...)
+			s.SessionLimit = 2 * time.Hour
+			tt.prepare(t, s)
+			points := s.ForecastFreePCs(now, 10*time.Minute, time.Hour)
+			if len(points) != 6 {
+				t.Fatalf("got %d points, want 6", len(points))
+			}
+			if got := points[len(points)-1].Free; got != tt.want {
+				t.Fatalf("free in an hour: got %d, want %d (%+v)", got, tt.want, points)
+			}
+		})
+	}
+}
+
+func TestSessionEndingSoon(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		checkIn  time.Duration // before now
+		assigned time.Duration // before now; 0 for a check-in straight onto the PC
+		want     bool
+	}{
+		{"fresh", 10 * time.Minute, 0, false},
+		{"near the limit", 110 * time.Minute, 0, true},
+		{"over the limit", 3 * time.Hour, 0, true},
+		{"long queue wait, seated just now", 110 * time.Minute, time.Minute, false},
+		{"seated near the limit", 3 * time.Hour, 115 * time.Minute, true},
+	}
+	s := newTestStore(t, "")
+	s.SessionLimit = 2 * time.Hour
+	s.SessionWarning = 15 * time.Minute
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := User{ID: "LOUNGE-1", PCID: 1, CheckInTime: now.Add(-tt.checkIn)}
+			if tt.assigned != 0 {
+				u.AssignedTime = now.Add(-tt.assigned)
+			}
+			if _, got := s.SessionEndingSoon(u, now); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrGuestLimit warns that a guest has used all their visits this term.
+var ErrGuestLimit = errors.New("guest visit limit reached")
+
+// IsGuestID reports whether id was made up by the desk for a visitor
+// without a student ID.
+func IsGuestID(id string) bool { return strings.HasPrefix(id, GeneratedIDPrefix) }
+
+// GuestVisits counts a guest's visits, as days with a session, in Term.
+type GuestVisits struct {
+	Term  Term
+	Used  int
+	Limit int
+	// Today is set when one of the Used days is today.
+	Today bool
+}
+
+// Visit is the number of today's visit: the one under way, or the one a
+// check-in now would make.
+func (g GuestVisits) Visit() int {
+	if g.Today {
+		return g.Used
+	}
+	return g.Used + 1
+}
+
+// guestHistory caches the guests' visit days before today in a term, which
+// only a history import changes.
+type guestHistory struct {
+	key  string // term start and today's date
+	days map[string]int
+}
+
+func (s *Store) guestConversionsFile() string {
+	return filepath.Join(s.LogDir, "guest_conversions.json")
+}
+
+func (s *Store) loadGuestConversions() {
+	s.guestConversions = map[string]string{}
+	data, err := os.ReadFile(s.guestConversionsFile())
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &s.guestConversions); err != nil {
+		s.guestConversions = map[string]string{}
+	}
+}
+
+// TermOn returns the configured term containing the YYYY-MM-DD date.
+func (s *Store) TermOn(date string) (Term, bool) {
+	for _, term := range s.Terms {
+		if term.Contains(date) {
+			return term, true
+		}
+	}
+	return Term{}, false
+}
+
+// GuestVisitsFor counts guestID's visits in the term containing now. ok is
+// false when the ID is not a guest's, the limit is off, no term covers
+// today or the guest has been converted to a member.
+func (s *Store) GuestVisitsFor(guestID string, now time.Time) (visits GuestVisits, ok bool) {
+	today := now.Format("2006-01-02")
+	term, inTerm := s.TermOn(today)
+	if !IsGuestID(guestID) || s.GuestVisitLimit <= 0 || !inTerm || s.guestConversions[guestID] != "" {
+		return GuestVisits{}, false
+	}
+	visits = GuestVisits{Term: term, Limit: s.GuestVisitLimit, Used: s.guestDaysBefore(term, today)[guestID]}
+	if entries, err := s.ReadDailyLogEntriesLocked(); err == nil {
+		for _, entry := range entries {
+			if entry.Kind == "" && entry.UserID == guestID {
+				visits.Today = true
+				visits.Used++
+				break
+			}
+		}
+	}
+	return visits, true
+}
+
+// guestDaysBefore counts, per guest, the days in term before today with a
+// session.
+func (s *Store) guestDaysBefore(term Term, today string) map[string]int {
+	key := term.Start + " " + today
+	if s.guestHistory.key == key {
+		return s.guestHistory.days
+	}
+	days := make(map[string]int)
+	for _, date := range s.ListAvailableLogDates() {
+		if date >= today || !term.Contains(date) {
+			continue
+		}
+		entries, err := s.ReadLogEntries(date)
+		if err != nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, entry := range entries {
+			if entry.Kind == "" && IsGuestID(entry.UserID) && !seen[entry.UserID] {
+				seen[entry.UserID] = true
+				days[entry.UserID]++
+			}
+		}
+	}
+	s.guestHistory = guestHistory{key: key, days: days}
+	return days
+}
+
+// ConvertGuest registers guestID as member, merging in the guest row's notes
+// and flag, and stops counting the guest's visits from then on, including
+// the ones already made.
+func (s *Store) ConvertGuest(guestID string, member Member) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	member.Name, member.ID = NormalizeName(member.Name), strings.TrimSpace(member.ID)
+	if member.Name == "" || member.ID == "" {
+		return fmt.Errorf("name and ID are required")
+	}
+	if IsGuestID(member.ID) {
+		return newError(ErrInvalidID, "%s is a guest ID; enter their student ID", member.ID)
+	}
+	if guest := s.MemberByID(guestID); guest != nil {
+		member = MergeMembers([]Member{member, *guest}, 0)
+	}
+	var err error
+	if existing := s.MemberByID(member.ID); existing != nil {
+		member = MergeMembers([]Member{*existing, member}, 0)
+		err = s.SaveMemberDetails(member)
+	} else {
+		err = s.AppendMember(member)
+	}
+	if err != nil {
+		return err
+	}
+	s.guestConversions[guestID] = member.ID
+	data, _ := json.MarshalIndent(s.guestConversions, "", "  ")
+	if err := WriteFileAtomic(s.guestConversionsFile(), data, 0o644); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// validateGuestLimit asks staff before a guest's visit past GuestVisitLimit
+// this term. Further sessions on a day already counted pass.
+func validateGuestLimit(ctx CheckInContext) error {
+	visits, ok := ctx.Store.GuestVisitsFor(ctx.UserID, ctx.Now)
+	if !ok || visits.Today || visits.Used < visits.Limit {
+		return nil
+	}
+	return &ValidationWarning{Validator: "guest-limit", Title: "Guest Limit",
+		Question: "Check them in anyway?",
+		Err: newError(ErrGuestLimit, "%s (%s) has used all %d guest visits for %s and should register as a member",
+			ctx.Name, ctx.UserID, visits.Limit, visits.Term.Name)}
+}
@@ -0,0 +1,235 @@
+package state
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importColumns are the columns of the old spreadsheet export, in the order
+// assumed when the file has no header row.
+var importColumns = []string{"date", "name", "id", "device", "time-in", "time-out"}
+
+var (
+	importDateLayouts = []string{"2006-01-02", "1/2/2006", "01/02/2006", "2006/01/02"}
+	importTimeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04:05 PM", "3:04 pm", "3:04pm"}
+)
+
+// ImportRowError is a row ImportHistoricalSessions could not use.
+type ImportRowError struct {
+	Line    int
+	Message string
+}
+
+// ImportReport summarizes an import, or what a dry run would do.
+type ImportReport struct {
+	// Imported counts new entries; Duplicates counts rows already logged.
+	Imported   int
+	Duplicates int
+	// Created and Modified list the log dates whose files are (or would
+	// be) created or rewritten.
+	Created  []string
+	Modified []string
+	Errors   []ImportRowError
+}
+
+// ImportHistoricalSessions reads sessions from a CSV with date, name, id,
+// device, time-in and time-out columns and merges them into the daily logs,
+// marked Imported. A row identical to a logged session is skipped, so the
+// same file can be imported twice. With dryRun set nothing is written.
+func (s *Store) ImportHistoricalSessions(r io.Reader, dryRun bool) (ImportReport, error) {
+	var report ImportReport
+	if s.ReadOnly && !dryRun {
+		return report, ErrReadOnly
+	}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return report, fmt.Errorf("read import file: %w", err)
+	}
+
+	columns, start := importColumnIndexes(rows)
+	byDate := make(map[string][]LogEntry)
+	for i, row := range rows[start:] {
+		line := start + i + 1
+		if len(strings.Join(row, "")) == 0 {
+			continue
+		}
+		entry, date, err := parseImportRow(row, columns)
+		if err != nil {
+			report.Errors = append(report.Errors, ImportRowError{Line: line, Message: err.Error()})
+			continue
+		}
+		byDate[date] = append(byDate[date], entry)
+	}
+
+	dates := make([]string, 0, len(byDate))
+	for date := range byDate {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	for _, date := range dates {
+		existing, err := s.ReadLogEntries(date)
+		if err != nil {
+			return report, err
+		}
+		added := 0
+		for _, entry := range byDate[date] {
+			if importedDuplicate(existing, entry) {
+				report.Duplicates++
+				continue
+			}
+			existing = append(existing, entry)
+			added++
+		}
+		if added == 0 {
+			continue
+		}
+		report.Imported += added
+		if _, err := os.Stat(s.LogFilePathForDate(date)); os.IsNotExist(err) && len(existing) == added {
+			report.Created = append(report.Created, date)
+		} else {
+			report.Modified = append(report.Modified, date)
+		}
+		if dryRun {
+			continue
+		}
+		sort.SliceStable(existing, func(i, j int) bool { return existing[i].CheckInTime.Before(existing[j].CheckInTime) })
+		if err := s.EnsureLogDir(); err != nil {
+			return report, err
+		}
+		if err := s.writeLogEntries(date, existing); err != nil {
+			return report, err
+		}
+		s.guestHistory = guestHistory{}
+		s.streakHistory = streakHistory{}
+		if date == TodaysLogDate() {
+			s.logChanged(existing)
+		}
+	}
+	return report, nil
+}
+
+// importColumnIndexes finds each column from a header row, or falls back to
+// importColumns order. start is the first data row.
+func importColumnIndexes(rows [][]string) (columns map[string]int, start int) {
+	columns = make(map[string]int, len(importColumns))
+	for i, name := range importColumns {
+		columns[name] = i
+	}
+	if len(rows) == 0 {
+		return columns, 0
+	}
+	found := make(map[string]int)
+	for i, cell := range rows[0] {
+		key := strings.ToLower(strings.TrimSpace(cell))
+		key = strings.NewReplacer(" ", "-", "_", "-").Replace(key)
+		for _, name := range importColumns {
+			if key == name {
+				found[name] = i
+			}
+		}
+	}
+	if len(found) == len(importColumns) {
+		return found, 1
+	}
+	return columns, 0
+}
+
+func parseImportRow(row []string, columns map[string]int) (LogEntry, string, error) {
+	cell := func(name string) string {
+		if i := columns[name]; i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	name, id := cell("name"), cell("id")
+	if name == "" || id == "" {
+		return LogEntry{}, "", fmt.Errorf("name and id are required")
+	}
+	day, err := parseImportDate(cell("date"))
+	if err != nil {
+		return LogEntry{}, "", err
+	}
+	checkIn, err := parseImportTime(day, cell("time-in"))
+	if err != nil {
+		return LogEntry{}, "", fmt.Errorf("time-in: %w", err)
+	}
+	checkOut, err := parseImportTime(day, cell("time-out"))
+	if err != nil {
+		return LogEntry{}, "", fmt.Errorf("time-out: %w", err)
+	}
+	if !checkOut.After(checkIn) {
+		return LogEntry{}, "", fmt.Errorf("time-out %s is not after time-in %s", cell("time-out"), cell("time-in"))
+	}
+	device, err := parseImportDevice(cell("device"))
+	if err != nil {
+		return LogEntry{}, "", err
+	}
+	entry := LogEntry{
+		UserName:     name,
+		UserID:       id,
+		PCID:         device,
+		CheckInTime:  checkIn.UTC(),
+		CheckOutTime: checkOut.UTC(),
+		UsageTime:    FormatDuration(checkOut.Sub(checkIn)),
+		Imported:     true,
+	}
+	return entry, day.Format("2006-01-02"), nil
+}
+
+func parseImportDate(text string) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		if day, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return day, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", text)
+}
+
+func parseImportTime(day time.Time, text string) (time.Time, error) {
+	for _, layout := range importTimeLayouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", text)
+}
+
+// parseImportDevice accepts "7", "PC 7" or "Console 17"; an empty cell or
+// "queue" is a session that never got a device.
+func parseImportDevice(text string) (int, error) {
+	digits := strings.TrimLeft(text, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz #")
+	if digits == "" {
+		if text == "" || strings.EqualFold(text, "queue") {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("unrecognized device %q", text)
+	}
+	device, err := strconv.Atoi(digits)
+	if err != nil || device < 0 {
+		return 0, fmt.Errorf("unrecognized device %q", text)
+	}
+	return device, nil
+}
+
+// importedDuplicate reports whether entries already holds the same session.
+func importedDuplicate(entries []LogEntry, entry LogEntry) bool {
+	for _, e := range entries {
+		if e.IsSession() && e.UserID == entry.UserID && e.PCID == entry.PCID &&
+			e.CheckInTime.Equal(entry.CheckInTime) && e.CheckOutTime.Equal(entry.CheckOutTime) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package state
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// DeviceSpec describes one device in log/devices.json.
+type DeviceSpec struct {
+	ID   int    `json:"id"`
+	Type string `json:"type"`
+	// Label is what staff call the device, e.g. "PS5"; empty means its type
+	// and number.
+	Label string `json:"label,omitempty"`
+	// Icon is the image base name, resolved as <Icon>_free.png and
+	// <Icon>_busy.png. Empty means the type's icon, then the defaults.
+	Icon         string `json:"icon,omitempty"`
+	AssetTag     string `json:"asset_tag,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+}
+
+// deviceInventory is the optional log/devices.json file. Without it the
+// lounge has 16 PCs and two consoles.
+type deviceInventory struct {
+	TypeIcons map[string]string `json:"type_icons,omitempty"`
+	Devices   []DeviceSpec      `json:"devices,omitempty"`
+}
+
+func (s *Store) inventoryFile() string { return filepath.Join(s.LogDir, "devices.json") }
+
+func defaultDeviceSpecs() []DeviceSpec {
+	specs := []DeviceSpec{}
+	for i := 1; i <= 16; i++ {
+		specs = append(specs, DeviceSpec{ID: i, Type: "PC"})
+	}
+	specs = append(specs, DeviceSpec{ID: 17, Type: "Console"}, DeviceSpec{ID: 18, Type: "Console"})
+	return specs
+}
+
+// loadInventory reads the device inventory, falling back to the default
+// devices when the file is missing or unreadable.
+func (s *Store) loadInventory() []DeviceSpec {
+	s.TypeIcons = map[string]string{}
+	data, err := os.ReadFile(s.inventoryFile())
+	if err != nil {
+		return defaultDeviceSpecs()
+	}
+	var inv deviceInventory
+	if err := json.Unmarshal(data, &inv); err != nil {
+		slog.Error("reading device inventory", "err", err)
+		return defaultDeviceSpecs()
+	}
+	if inv.TypeIcons != nil {
+		s.TypeIcons = inv.TypeIcons
+	}
+	if len(inv.Devices) == 0 {
+		return defaultDeviceSpecs()
+	}
+	seen := make(map[int]bool, len(inv.Devices))
+	specs := make([]DeviceSpec, 0, len(inv.Devices))
+	for _, spec := range inv.Devices {
+		if spec.ID <= 0 || seen[spec.ID] {
+			slog.Warn("skipping device inventory entry with invalid or duplicate ID", "device", spec.ID)
+			continue
+		}
+		if spec.Type != "Console" {
+			spec.Type = "PC"
+		}
+		seen[spec.ID] = true
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// IconBase returns the image base name configured for device, by device
+// first and then by type, or "" for the built-in images.
+func (s *Store) IconBase(device Device) string {
+	if device.Icon != "" {
+		return device.Icon
+	}
+	return s.TypeIcons[device.Type]
+}
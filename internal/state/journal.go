@@ -0,0 +1,299 @@
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Journal actions. Every mutation appends its action before active_users.json
+// is rewritten, and each successful rewrite appends a commit, so actions after
+// the last commit are ones a crash kept from reaching the JSON files.
+const (
+	JournalCheckIn  = "checkin"
+	JournalCheckOut = "checkout"
+	JournalRemove   = "remove"
+	JournalAssign   = "assign"
+	JournalSwitch   = "switch"
+	JournalEdit     = "edit"
+	JournalAway     = "away"
+	JournalBack     = "back"
+	JournalTest     = "test"
+	journalCommit   = "commit"
+)
+
+// JournalEntry is one line of log/journal-YYYY-MM-DD.log.
+type JournalEntry struct {
+	Time    time.Time `json:"t"`
+	Action  string    `json:"a"`
+	UserID  string    `json:"u,omitempty"`
+	Name    string    `json:"n,omitempty"`
+	Device  int       `json:"d,omitempty"`
+	Purpose string    `json:"p,omitempty"`
+	Course  string    `json:"c,omitempty"`
+	Event   string    `json:"e,omitempty"`
+	Source  string    `json:"s,omitempty"`
+	Session string    `json:"sid,omitempty"`
+	// Test is the user's ExcludeFromStats.
+	Test bool `json:"x,omitempty"`
+	// NoLimit is the user's LimitExempt.
+	NoLimit bool `json:"nl,omitempty"`
+	// PreviousID is the ID a JournalEdit renamed the user from.
+	PreviousID string `json:"prev,omitempty"`
+	// CheckIn is the user's check-in time, so a replayed check-in keeps it.
+	CheckIn time.Time `json:"in"`
+}
+
+func (e JournalEntry) String() string {
+	ts := e.Time.Format("15:04:05")
+	switch e.Action {
+	case JournalCheckIn:
+		if e.Device == 0 {
+			return fmt.Sprintf("%s queued %s (%s)", ts, e.Name, e.UserID)
+		}
+		return fmt.Sprintf("%s checked in %s (%s) on device %d", ts, e.Name, e.UserID, e.Device)
+	case JournalCheckOut:
+		return fmt.Sprintf("%s checked out %s (%s)", ts, e.Name, e.UserID)
+	case JournalRemove:
+		return fmt.Sprintf("%s removed %s (%s) from the queue", ts, e.Name, e.UserID)
+	case JournalAssign:
+		return fmt.Sprintf("%s seated %s (%s) on device %d", ts, e.Name, e.UserID, e.Device)
+	case JournalSwitch:
+		return fmt.Sprintf("%s moved %s (%s) to device %d", ts, e.Name, e.UserID, e.Device)
+	case JournalEdit:
+		return fmt.Sprintf("%s corrected queued %s to %s (%s)", ts, e.PreviousID, e.Name, e.UserID)
+	case JournalAway:
+		return fmt.Sprintf("%s marked %s (%s) away", ts, e.Name, e.UserID)
+	case JournalBack:
+		return fmt.Sprintf("%s marked %s (%s) back", ts, e.Name, e.UserID)
+	case JournalTest:
+		if e.Test {
+			return fmt.Sprintf("%s excluded %s (%s) from stats", ts, e.Name, e.UserID)
+		}
+		return fmt.Sprintf("%s counted %s (%s) in stats again", ts, e.Name, e.UserID)
+	}
+	return fmt.Sprintf("%s %s %s", ts, e.Action, e.UserID)
+}
+
+func (s *Store) journalPathForDate(date string) string {
+	return filepath.Join(s.LogDir, fmt.Sprintf("journal-%s.log", date))
+}
+
+func (s *Store) journalFiles() []string {
+	matches, _ := filepath.Glob(filepath.Join(s.LogDir, "journal-*.log"))
+	sort.Strings(matches)
+	return matches
+}
+
+// saveJournaled journals entry and then saves the active users, for the
+// mutations that only change a user's fields.
+func (s *Store) saveJournaled(entry JournalEntry) {
+	s.appendJournal(entry)
+	s.Save()
+}
+
+// appendJournal queues entry for the journal. The writer keeps the order,
+// so an action's journal line still lands before the state it leads to.
+func (s *Store) appendJournal(entry JournalEntry) {
+	s.queueWrite("writing journal", func() error { return s.writeJournal(entry) }, nil)
+}
+
+func (s *Store) writeJournal(entry JournalEntry) error {
+	if err := s.EnsureLogDir(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode journal entry: %w", err)
+	}
+	f, err := os.OpenFile(s.journalPathForDate(entry.Time.Format("2006-01-02")), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (s *Store) journal(action string, u User, deviceID int) {
+	s.appendJournal(JournalEntry{
+		Time:    time.Now(),
+		Action:  action,
+		UserID:  u.ID,
+		Name:    u.Name,
+		Device:  deviceID,
+		Purpose: u.Purpose,
+		Course:  u.Course,
+		Event:   u.Event,
+		Source:  u.Source,
+		Session: u.SessionID,
+		Test:    u.ExcludeFromStats,
+		NoLimit: u.LimitExempt,
+		CheckIn: u.CheckInTime,
+	})
+}
+
+// UnappliedJournal returns the journaled actions after the last commit, which
+// active_users.json does not reflect. A torn final line is ignored.
+func (s *Store) UnappliedJournal() ([]JournalEntry, error) {
+	var pending []JournalEntry
+	for _, path := range s.journalFiles() {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open journal: %s: %w", path, err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var entry JournalEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				continue
+			}
+			if entry.Action == journalCommit {
+				pending = pending[:0]
+				continue
+			}
+			pending = append(pending, entry)
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read journal: %s: %w", path, err)
+		}
+	}
+	return pending, nil
+}
+
+// ReplayJournal applies entries from UnappliedJournal to the in-memory state
+// and saves it. Entries that no longer make sense (the user is already gone,
+// the device is taken) are skipped. Restored check-ins whose log entry never
+// landed are logged again, so their checkout has a session to close.
+func (s *Store) ReplayJournal(entries []JournalEntry) {
+	if s.ReadOnly {
+		return
+	}
+	var restored []string
+	for _, e := range entries {
+		switch e.Action {
+		case JournalCheckIn:
+			if s.UserByID(e.UserID) != nil {
+				continue
+			}
+			checkIn := e.CheckIn
+			if checkIn.IsZero() {
+				checkIn = e.Time
+			}
+			if e.Device != 0 {
+				device := s.DeviceByID(e.Device)
+				if device == nil || (device.Type == "PC" && device.Status != "free") {
+					continue
+				}
+				s.occupyDevice(device, e.UserID)
+			}
+			s.ActiveUsers = append(s.ActiveUsers, User{ID: e.UserID, Name: e.Name, CheckInTime: checkIn, PCID: e.Device, Purpose: e.Purpose, Course: e.Course, Event: e.Event, Source: e.Source, SessionID: e.Session, ExcludeFromStats: e.Test, LimitExempt: e.NoLimit})
+			if e.Device == 0 {
+				s.ensureQueueEntry(e.UserID, checkIn)
+			}
+			restored = append(restored, e.UserID)
+		case JournalCheckOut, JournalRemove:
+			u := s.UserByID(e.UserID)
+			if u == nil {
+				continue
+			}
+			deviceID := u.PCID
+			s.removeActiveUser(e.UserID)
+			s.releaseDevice(deviceID)
+			s.removeQueueEntry(e.UserID)
+		case JournalAssign, JournalSwitch:
+			u := s.UserByID(e.UserID)
+			target := s.DeviceByID(e.Device)
+			if u == nil || target == nil || u.PCID == e.Device || (target.Type == "PC" && target.Status != "free") {
+				continue
+			}
+			previous := u.PCID
+			if e.Action == JournalAssign {
+				u.AssignedTime = e.Time
+			}
+			u.PCID = e.Device
+			s.releaseDevice(previous)
+			s.occupyDevice(target, e.UserID)
+			s.removeQueueEntry(e.UserID)
+		case JournalEdit:
+			u := s.UserByID(e.PreviousID)
+			if u == nil || u.PCID != 0 || (e.UserID != e.PreviousID && s.UserByID(e.UserID) != nil) {
+				continue
+			}
+			u.ID = e.UserID
+			u.Name = e.Name
+			s.renameQueueEntry(e.PreviousID, e.UserID)
+		case JournalAway, JournalBack:
+			u := s.UserByID(e.UserID)
+			if u == nil || u.PCID == 0 {
+				continue
+			}
+			u.AwaySince = time.Time{}
+			if e.Action == JournalAway {
+				u.AwaySince = e.Time
+			}
+		case JournalTest:
+			if u := s.UserByID(e.UserID); u != nil {
+				u.ExcludeFromStats = e.Test
+			}
+		}
+	}
+	for _, id := range restored {
+		if u := s.UserByID(id); u != nil {
+			s.ensureLoggedCheckIn(*u)
+		}
+	}
+	s.Save()
+	s.changed()
+}
+
+// ensureLoggedCheckIn queues a check-in entry for u unless today's log
+// already has u's session open.
+func (s *Store) ensureLoggedCheckIn(u User) {
+	s.queueWrite("writing daily log", func() error {
+		entries, err := s.ReadDailyLogEntriesLocked()
+		if err != nil {
+			return err
+		}
+		if openSessionIndex(entries, u) >= 0 {
+			return nil
+		}
+		return s.recordLogEvent(true, u, u.PCID, "", QueueRemoval{})
+	}, nil)
+}
+
+// DiscardJournal marks the pending journal entries as handled without
+// applying them.
+func (s *Store) DiscardJournal() {
+	if s.ReadOnly {
+		return
+	}
+	s.appendJournal(JournalEntry{Time: time.Now(), Action: journalCommit})
+}
+
+// ClearJournal removes the journal files after a clean shutdown.
+func (s *Store) ClearJournal() {
+	if s.ReadOnly {
+		return
+	}
+	s.WaitForWrites()
+	for _, path := range s.journalFiles() {
+		if err := os.Remove(path); err != nil {
+			slog.Error("removing journal", "err", err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package state
+
+import (
+	"strings"
+	"time"
+)
+
+// Labels for UsageBySessionLimit.
+const (
+	LimitApplied = "limited"
+	LimitExempt  = "no limit"
+)
+
+// SessionLimitExempt reports whether SessionLimit does not apply to u: the
+// session was exempted at check-in, the member has NoSessionLimit, or the
+// purpose is one of LimitExemptPurposes.
+func (s *Store) SessionLimitExempt(u User) bool {
+	if u.LimitExempt {
+		return true
+	}
+	if member := s.MemberByID(u.ID); member != nil && member.NoSessionLimit {
+		return true
+	}
+	return s.limitExemptPurpose(u.Purpose)
+}
+
+func (s *Store) limitExemptPurpose(purpose string) bool {
+	purpose = strings.TrimSpace(purpose)
+	if purpose == "" {
+		return false
+	}
+	for _, exempt := range s.LimitExemptPurposes {
+		if strings.EqualFold(strings.TrimSpace(exempt), purpose) {
+			return true
+		}
+	}
+	return false
+}
+
+// UsageBySessionLimit totals entries under LimitApplied and LimitExempt,
+// longest usage first, so exempt sessions can be told apart in stats.
+func UsageBySessionLimit(entries []LogEntry, now time.Time) []PurposeUsage {
+	return usageBy(entries, now, func(entry LogEntry) string {
+		if entry.LimitExempt {
+			return LimitExempt
+		}
+		return LimitApplied
+	})
+}
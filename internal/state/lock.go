@@ -0,0 +1,159 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockFileName is the instance lock in the log directory.
+const LockFileName = ".lounge.lock"
+
+// LockStaleAfter is how long a lock held from another computer survives
+// without its holder touching it. Such a PID cannot be checked from here, so
+// the holder refreshes the file's time instead; see Touch.
+const LockStaleAfter = 2 * time.Minute
+
+// InstanceLock is log/.lounge.lock, holding the PID and host name of the
+// running app. Two copies reading and saving active_users.json would
+// silently overwrite each other's check-ins, so only the lock holder may
+// write to the store.
+type InstanceLock struct {
+	path string
+}
+
+// LockedError reports that another running instance holds the lock.
+type LockedError struct {
+	PID  int
+	Host string // empty when it is this computer
+	Path string
+}
+
+func (e *LockedError) Error() string {
+	if e.Host != "" {
+		return fmt.Sprintf("the lounge app is already running on %s (PID %d, lock file %s)", e.Host, e.PID, e.Path)
+	}
+	return fmt.Sprintf("another copy of the lounge app is already running (PID %d, lock file %s)", e.PID, e.Path)
+}
+
+func localHost() string {
+	host, _ := os.Hostname()
+	return host
+}
+
+// AcquireInstanceLock takes the lock in dir. A lock left by a process that is
+// no longer running is stale and taken over; force takes over any lock, for
+// when the holder is known to be hung or the PID was reused.
+func AcquireInstanceLock(dir string, force bool) (*InstanceLock, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, LockFileName)
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n%s\n", os.Getpid(), localHost())
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("write lock file: %s: %w", path, err)
+			}
+			return &InstanceLock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("create lock file: %s: %w", path, err)
+		}
+		if locked := heldElsewhere(path); !force && locked != nil {
+			return nil, locked
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove stale lock file: %s: %w", path, err)
+		}
+	}
+	return nil, fmt.Errorf("lock file %s keeps reappearing; is another copy starting?", path)
+}
+
+// CheckInstanceLock returns a *LockedError while another live instance holds
+// the lock in dir, and nil once it is free or stale.
+func CheckInstanceLock(dir string) error {
+	if locked := heldElsewhere(filepath.Join(dir, LockFileName)); locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// heldElsewhere reports who holds the lock at path, or nil when nobody else
+// does. A holder on this computer must still be running; one on another
+// computer must have touched the file within LockStaleAfter.
+func heldElsewhere(path string) *LockedError {
+	pid, host := readLock(path)
+	if pid <= 0 {
+		return nil
+	}
+	if host == "" || host == localHost() {
+		if pid == os.Getpid() || !processRunning(pid) {
+			return nil
+		}
+		return &LockedError{PID: pid, Path: path}
+	}
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > LockStaleAfter {
+		return nil
+	}
+	return &LockedError{PID: pid, Host: host, Path: path}
+}
+
+// readLock returns the PID and host in the lock file. The PID is 0 when the
+// file is unreadable, which makes the lock stale; files written before the
+// host was recorded have no host.
+func readLock(path string) (pid int, host string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	pid, err = strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil || pid <= 0 {
+		return 0, ""
+	}
+	if len(lines) > 1 {
+		host = strings.TrimSpace(lines[1])
+	}
+	return pid, host
+}
+
+func (l *InstanceLock) ours() bool {
+	pid, host := readLock(l.path)
+	return pid == os.Getpid() && (host == "" || host == localHost())
+}
+
+// Touch marks the lock as still held, so instances on other computers
+// sharing the folder do not treat it as stale. Call it well within
+// LockStaleAfter.
+func (l *InstanceLock) Touch() {
+	if l == nil || !l.ours() {
+		return
+	}
+	now := time.Now()
+	if err := os.Chtimes(l.path, now, now); err != nil {
+		slog.Error("refreshing instance lock", "err", err)
+	}
+}
+
+// Release removes the lock file if it is still ours; a forced takeover by
+// another instance leaves it alone.
+func (l *InstanceLock) Release() {
+	if l == nil {
+		return
+	}
+	if l.ours() {
+		os.Remove(l.path)
+	}
+}
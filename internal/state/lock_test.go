@@ -0,0 +1,119 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// deadPID is the PID of a process that has already exited.
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+func writeLock(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, LockFileName)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInstanceLockRecoversStaleLocks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content func(t *testing.T) string
+		// age backdates the lock file.
+		age time.Duration
+	}{
+		{"dead process", func(t *testing.T) string { return fmt.Sprintf("%d\n%s\n", deadPID(t), localHost()) }, 0},
+		{"dead process without a host", func(t *testing.T) string { return fmt.Sprintf("%d\n", deadPID(t)) }, 0},
+		{"unreadable", func(t *testing.T) string { return "not a pid\n" }, 0},
+		{"other computer gone quiet", func(t *testing.T) string { return "4242\nfront-desk\n" }, LockStaleAfter + time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeLock(t, dir, tt.content(t))
+			if tt.age > 0 {
+				old := time.Now().Add(-tt.age)
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := CheckInstanceLock(dir); err != nil {
+				t.Fatalf("stale lock reported as held: %v", err)
+			}
+			lock, err := AcquireInstanceLock(dir, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pid, host := readLock(path); pid != os.Getpid() || host != localHost() {
+				t.Fatalf("lock holds PID %d on %q after the takeover", pid, host)
+			}
+			lock.Release()
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("lock file left after release: %v", err)
+			}
+		})
+	}
+}
+
+func TestInstanceLockRefusesLiveHolders(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantHost string
+	}{
+		// The test runner that started this test is still running.
+		{"process on this computer", fmt.Sprintf("%d\n%s\n", os.Getppid(), localHost()), ""},
+		{"other computer", "4242\nfront-desk\n", "front-desk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeLock(t, dir, tt.content)
+			_, err := AcquireInstanceLock(dir, false)
+			var locked *LockedError
+			if !errors.As(err, &locked) || locked.Host != tt.wantHost || locked.Path != path {
+				t.Fatalf("got %v, want the lock held on %q", err, tt.wantHost)
+			}
+			if err := CheckInstanceLock(dir); !errors.As(err, &locked) {
+				t.Fatalf("check: got %v, want it held", err)
+			}
+
+			lock, err := AcquireInstanceLock(dir, true)
+			if err != nil {
+				t.Fatalf("forced takeover: %v", err)
+			}
+			if pid, _ := readLock(path); pid != os.Getpid() {
+				t.Fatalf("lock holds PID %d after a forced takeover", pid)
+			}
+			lock.Release()
+		})
+	}
+}
+
+func TestReleaseLeavesATakenOverLock(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := AcquireInstanceLock(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another desk forced its way in.
+	path := writeLock(t, dir, "4242\nfront-desk\n")
+	lock.Release()
+	if pid, host := readLock(path); pid != 4242 || host != "front-desk" {
+		t.Fatalf("release removed the other desk's lock: PID %d on %q", pid, host)
+	}
+}
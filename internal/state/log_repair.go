@@ -0,0 +1,215 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// DefaultDuplicateWindow is how close two check-ins of the same user on the
+// same device must be to count as one check-in logged twice.
+const DefaultDuplicateWindow = 60 * time.Second
+
+// LogDuplicate is a pair of sessions in one day's log that look like the
+// same visit logged twice, First logged before Second.
+type LogDuplicate struct {
+	Date   string
+	First  LogEntry
+	Second LogEntry
+	// Reason says why the pair was picked, for staff deciding what to keep.
+	Reason string
+}
+
+// FindLogDuplicates returns the pairs in entries, date's log, with the same
+// user and device that either checked in within window of each other or
+// overlap in time. An entry still open overlaps everything after it.
+func FindLogDuplicates(date string, entries []LogEntry, window time.Duration) []LogDuplicate {
+	var out []LogDuplicate
+	for i, a := range entries {
+		if !a.IsSession() || a.UserID == "" {
+			continue
+		}
+		for _, b := range entries[i+1:] {
+			if !b.IsSession() || b.UserID != a.UserID || b.PCID != a.PCID {
+				continue
+			}
+			gap := b.CheckInTime.Sub(a.CheckInTime).Abs()
+			var reason string
+			switch {
+			case gap <= window:
+				reason = fmt.Sprintf("checked in %s apart", FormatDuration(gap))
+			case sessionsOverlap(a, b):
+				reason = "sessions overlap"
+			default:
+				continue
+			}
+			out = append(out, LogDuplicate{Date: date, First: a, Second: b, Reason: reason})
+		}
+	}
+	return out
+}
+
+// sessionsOverlap reports whether a and b were open at the same moment.
+func sessionsOverlap(a, b LogEntry) bool {
+	if b.CheckInTime.Before(a.CheckInTime) {
+		a, b = b, a
+	}
+	return a.CheckOutTime.IsZero() || b.CheckInTime.Before(a.CheckOutTime)
+}
+
+// ScanLogDuplicates runs FindLogDuplicates over every log from from to to,
+// both "2006-01-02" and inclusive, oldest first.
+func (s *Store) ScanLogDuplicates(from, to string, window time.Duration) ([]LogDuplicate, error) {
+	if from > to {
+		from, to = to, from
+	}
+	dates := s.ListAvailableLogDates()
+	sort.Strings(dates)
+	var out []LogDuplicate
+	for _, date := range dates {
+		if date < from || date > to {
+			continue
+		}
+		s.logMu.Lock()
+		entries, err := s.ReadLogEntries(date)
+		s.logMu.Unlock()
+		if err != nil {
+			return out, err
+		}
+		out = append(out, FindLogDuplicates(date, entries, window)...)
+	}
+	return out, nil
+}
+
+// MergeLogEntries combines a and b, one visit logged twice, into one
+// session: the earlier check-in, the later checkout, and the first of each
+// other field that is set. A visit still open stays open only if both are;
+// otherwise the checkout that was logged ends it.
+func MergeLogEntries(a, b LogEntry) LogEntry {
+	if b.CheckInTime.Before(a.CheckInTime) {
+		a, b = b, a
+	}
+	merged := a
+	if b.CheckOutTime.After(merged.CheckOutTime) {
+		merged.CheckOutTime = b.CheckOutTime
+	}
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&merged.UserName, b.UserName}, {&merged.Purpose, b.Purpose}, {&merged.Course, b.Course}, {&merged.VisitorType, b.VisitorType}, {&merged.Event, b.Event},
+		{&merged.Source, b.Source}, {&merged.SessionID, b.SessionID}, {&merged.Note, b.Note},
+		{&merged.RemovalReason, b.RemovalReason},
+	} {
+		if *field.dst == "" {
+			*field.dst = field.src
+		}
+	}
+	merged.UsageTime = ""
+	if !merged.CheckOutTime.IsZero() {
+		merged.UsageTime = FormatDuration(merged.CheckOutTime.Sub(merged.CheckInTime))
+	}
+	if len(merged.DeviceHistory) == 0 {
+		merged.DeviceHistory = b.DeviceHistory
+	}
+	merged.ClockSkew = a.ClockSkew || b.ClockSkew
+	merged.Imported = a.Imported && b.Imported
+	merged.ExcludeFromStats = a.ExcludeFromStats && b.ExcludeFromStats
+	merged.LimitExempt = a.LimitExempt || b.LimitExempt
+	merged.NotAVisit = a.NotAVisit && b.NotAVisit
+	if len(a.Extra)+len(b.Extra) > 0 {
+		merged.Extra = Extra{}
+		for key, value := range b.Extra {
+			merged.Extra[key] = value
+		}
+		for key, value := range a.Extra {
+			merged.Extra[key] = value
+		}
+	}
+	return merged
+}
+
+// sameLogEntry reports whether a and b are the same line of a log, so a
+// repair finds the entries staff saw even if others were added since.
+func sameLogEntry(a, b LogEntry) bool {
+	return a.IsSession() == b.IsSession() && a.UserID == b.UserID && a.PCID == b.PCID && a.SessionID == b.SessionID &&
+		a.CheckInTime.Equal(b.CheckInTime) && a.CheckOutTime.Equal(b.CheckOutTime)
+}
+
+// MergeLogDuplicate replaces dup's two entries with MergeLogEntries of them,
+// where the first one was.
+func (s *Store) MergeLogDuplicate(dup LogDuplicate) error {
+	return s.repairLog(dup.Date, func(entries []LogEntry) ([]LogEntry, error) {
+		first, second := indexOfLogEntry(entries, dup.First, -1), indexOfLogEntry(entries, dup.Second, -1)
+		if first >= 0 {
+			second = indexOfLogEntry(entries, dup.Second, first)
+		}
+		if first < 0 || second < 0 {
+			return nil, newError(ErrUserNotFound, "the log for %s no longer has both entries for %s (%s)", dup.Date, dup.First.UserName, dup.First.UserID)
+		}
+		entries[first] = MergeLogEntries(entries[first], entries[second])
+		return append(entries[:second], entries[second+1:]...), nil
+	})
+}
+
+// DeleteLogEntry removes entry, one half of a duplicate, from date's log.
+func (s *Store) DeleteLogEntry(date string, entry LogEntry) error {
+	return s.repairLog(date, func(entries []LogEntry) ([]LogEntry, error) {
+		i := indexOfLogEntry(entries, entry, -1)
+		if i < 0 {
+			return nil, newError(ErrUserNotFound, "the log for %s no longer has that entry for %s (%s)", date, entry.UserName, entry.UserID)
+		}
+		return append(entries[:i], entries[i+1:]...), nil
+	})
+}
+
+// indexOfLogEntry returns the index of entry in entries, not counting skip,
+// or -1.
+func indexOfLogEntry(entries []LogEntry, entry LogEntry, skip int) int {
+	for i := range entries {
+		if i != skip && sameLogEntry(entries[i], entry) {
+			return i
+		}
+	}
+	return -1
+}
+
+// repairLog applies repair to date's log under the log lock. The file as it
+// was before the first repair is kept as <log>.repair.bak. Archived months
+// cannot be changed.
+func (s *Store) repairLog(date string, repair func(entries []LogEntry) ([]LogEntry, error)) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	p := s.LogFilePathForDate(date)
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("the log for %s is archived and cannot be changed", date)
+	}
+	if err != nil {
+		return fmt.Errorf("read log: %s: %w", p, err)
+	}
+	entries, err := s.ReadLogEntries(date)
+	if err != nil {
+		return err
+	}
+	if entries, err = repair(entries); err != nil {
+		return err
+	}
+	backup := p + ".repair.bak"
+	if _, err := os.Stat(backup); os.IsNotExist(err) {
+		if err := WriteFileAtomic(backup, data, 0o644); err != nil {
+			return fmt.Errorf("back up %s before repairing it: %w", date, err)
+		}
+	}
+	if err := s.writeLogEntries(date, entries); err != nil {
+		return err
+	}
+	if date == TodaysLogDate() {
+		s.logChanged(entries)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const fixtureDate = "2026-03-09"
+
+// newRepairStore is a store whose log folder holds the crafted log in
+// testdata, which has two visits logged twice among sessions that are not.
+func newRepairStore(t *testing.T) (*Store, []byte) {
+	t.Helper()
+	fixture, err := os.ReadFile(filepath.Join("testdata", logFileName(fixtureDate)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestStore(t, "")
+	if err := s.EnsureLogDir(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.LogFilePathForDate(fixtureDate), fixture, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return s, fixture
+}
+
+func TestScanLogDuplicates(t *testing.T) {
+	s, _ := newRepairStore(t)
+	tests := []struct {
+		name   string
+		window time.Duration
+		want   [][2]string // session IDs of each pair
+	}{
+		{"default window", DefaultDuplicateWindow, [][2]string{{"a1", "a2"}, {"b1", "b2"}}},
+		// a2 checked in 20s after a1 but also overlaps it.
+		{"no window", 0, [][2]string{{"a1", "a2"}, {"b1", "b2"}}},
+		{"wide window", 3 * time.Hour, [][2]string{{"a1", "a2"}, {"b1", "b2"}, {"c1", "c2"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dups, err := s.ScanLogDuplicates(fixtureDate, fixtureDate, tt.window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][2]string
+			for _, dup := range dups {
+				if dup.Date != fixtureDate || dup.Reason == "" {
+					t.Errorf("pair %+v lacks its date or reason", dup)
+				}
+				got = append(got, [2]string{dup.First.SessionID, dup.Second.SessionID})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got pairs %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("pair %d is %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFindLogDuplicatesReasons(t *testing.T) {
+	at := func(clock string) time.Time {
+		t, _ := time.Parse(time.TimeOnly, clock)
+		return t
+	}
+	ada := func(in, out string) LogEntry {
+		entry := LogEntry{UserID: "1001", PCID: 1, CheckInTime: at(in)}
+		if out != "" {
+			entry.CheckOutTime = at(out)
+		}
+		return entry
+	}
+	tests := []struct {
+		name    string
+		a, b    LogEntry
+		reason  string
+		noMatch bool
+	}{
+		{"double click", ada("10:00:00", "11:00:00"), ada("10:00:05", "10:00:06"), "checked in 5s apart", false},
+		{"overlap", ada("10:00:00", "11:00:00"), ada("10:30:00", "12:00:00"), "sessions overlap", false},
+		{"still open", ada("10:00:00", ""), ada("15:00:00", "16:00:00"), "sessions overlap", false},
+		{"back to back", ada("10:00:00", "11:00:00"), ada("11:00:00", "12:00:00"), "", true},
+		{"other device", ada("10:00:00", "11:00:00"), LogEntry{UserID: "1001", PCID: 2, CheckInTime: at("10:00:01")}, "", true},
+		{"other user", ada("10:00:00", "11:00:00"), LogEntry{UserID: "1002", PCID: 1, CheckInTime: at("10:00:01")}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dups := FindLogDuplicates(fixtureDate, []LogEntry{tt.a, tt.b}, DefaultDuplicateWindow)
+			if tt.noMatch {
+				if len(dups) != 0 {
+					t.Fatalf("got %+v, want no duplicates", dups)
+				}
+				return
+			}
+			if len(dups) != 1 || dups[0].Reason != tt.reason {
+				t.Fatalf("got %+v, want one pair because %q", dups, tt.reason)
+			}
+		})
+	}
+}
+
+func TestMergeLogDuplicate(t *testing.T) {
+	s, fixture := newRepairStore(t)
+	dups, err := s.ScanLogDuplicates(fixtureDate, fixtureDate, DefaultDuplicateWindow)
+	if err != nil || len(dups) != 2 {
+		t.Fatalf("scan: %v, %d pairs", err, len(dups))
+	}
+	// Passed second first, as the later of the two.
+	if err := s.MergeLogDuplicate(LogDuplicate{Date: fixtureDate, First: dups[0].Second, Second: dups[0].First}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.ReadLogEntries(fixtureDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 8 {
+		t.Fatalf("log has %d entries after the merge, want 8", len(entries))
+	}
+	merged := entries[0]
+	if merged.SessionID != "a1" || !merged.CheckInTime.Equal(dups[0].First.CheckInTime) ||
+		!merged.CheckOutTime.Equal(dups[0].First.CheckOutTime) {
+		t.Errorf("merged entry %+v, want a1's check-in and checkout", merged)
+	}
+	if merged.Purpose != "Study" || string(merged.Extra["seat"]) != `"window"` {
+		t.Errorf("merged entry lost a2's purpose or seat: %+v", merged)
+	}
+	if merged.UsageTime != "1h00m00s" {
+		t.Errorf("merged usage %q, want 1h00m00s", merged.UsageTime)
+	}
+
+	backup, err := os.ReadFile(s.LogFilePathForDate(fixtureDate) + ".repair.bak")
+	if err != nil || !bytes.Equal(backup, fixture) {
+		t.Fatalf("backup is not the original log: %v", err)
+	}
+
+	// Merging again finds the pair gone, and a second repair keeps the
+	// first backup.
+	if err := s.MergeLogDuplicate(dups[0]); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("merging a merged pair: got %v, want ErrUserNotFound", err)
+	}
+	if err := s.DeleteLogEntry(fixtureDate, dups[1].Second); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := s.ReadLogEntries(fixtureDate); len(entries) != 7 {
+		t.Errorf("log has %d entries after the delete, want 7", len(entries))
+	}
+	if backup, _ := os.ReadFile(s.LogFilePathForDate(fixtureDate) + ".repair.bak"); !bytes.Equal(backup, fixture) {
+		t.Error("the second repair replaced the backup")
+	}
+	if dups, _ := s.ScanLogDuplicates(fixtureDate, fixtureDate, DefaultDuplicateWindow); len(dups) != 0 {
+		t.Errorf("still %d duplicates after the repairs", len(dups))
+	}
+}
+
+func TestDeleteLogEntryKeepsTheOther(t *testing.T) {
+	s, _ := newRepairStore(t)
+	dups, _ := s.ScanLogDuplicates(fixtureDate, fixtureDate, DefaultDuplicateWindow)
+	if err := s.DeleteLogEntry(fixtureDate, dups[0].First); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := s.ReadLogEntries(fixtureDate)
+	if entries[0].SessionID != "a2" {
+		t.Errorf("first entry is %s after deleting a1, want a2", entries[0].SessionID)
+	}
+	s.ReadOnly = true
+	if err := s.DeleteLogEntry(fixtureDate, dups[1].First); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("read-only delete: got %v, want ErrReadOnly", err)
+	}
+}
@@ -0,0 +1,294 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+func TodaysLogDate() string { return time.Now().Format("2006-01-02") }
+
+func logFileName(date string) string { return fmt.Sprintf("lounge-%s.json", date) }
+
+func (s *Store) LogFilePathForDate(date string) string {
+	if date == "" {
+		date = TodaysLogDate()
+	}
+	return filepath.Join(s.LogDir, logFileName(date))
+}
+
+func (s *Store) ReadDailyLogEntries() ([]LogEntry, error) {
+	return s.ReadLogEntries(TodaysLogDate())
+}
+
+// ReadLogEntries returns date's log, reading it from the monthly archive when
+// the daily file has been archived.
+func (s *Store) ReadLogEntries(date string) ([]LogEntry, error) {
+	p := s.LogFilePathForDate(date)
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return s.readArchivedLogEntries(date)
+	}
+	logFile, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("open log: %s: %w", p, err)
+	}
+	defer logFile.Close()
+	fileData, err := io.ReadAll(logFile)
+	if err != nil {
+		return nil, fmt.Errorf("read log: %s: %w", p, err)
+	}
+	var entries []LogEntry
+	if len(fileData) > 0 {
+		// A newer build's log is still shown; writeLogEntries refuses to
+		// rewrite it.
+		if err := DecodeVersioned(p, fileData, "entries", LogVersion, &entries); err != nil && !errors.Is(err, ErrNewerFile) {
+			return nil, fmt.Errorf("unmarshal log: %s: %w", p, err)
+		}
+	}
+	return entries, nil
+}
+
+func (s *Store) readArchivedLogEntries(date string) ([]LogEntry, error) {
+	data, found, err := s.readArchivedLog(date)
+	if err != nil {
+		return nil, err
+	}
+	if !found || len(data) == 0 {
+		return []LogEntry{}, nil
+	}
+	var entries []LogEntry
+	if err := DecodeVersioned(date, data, "entries", LogVersion, &entries); err != nil && !errors.Is(err, ErrNewerFile) {
+		return nil, fmt.Errorf("unmarshal archived log: %s: %w", date, err)
+	}
+	return entries, nil
+}
+
+func (s *Store) writeDailyLogEntries(entries []LogEntry) error {
+	return s.writeLogEntries(TodaysLogDate(), entries)
+}
+
+func (s *Store) writeLogEntries(date string, entries []LogEntry) error {
+	p := s.LogFilePathForDate(date)
+	if err := GuardRewrite(p, LogVersion); err != nil {
+		return err
+	}
+	data, err := EncodeVersioned("entries", LogVersion, entries)
+	if err != nil {
+		return fmt.Errorf("marshal log: %w", err)
+	}
+	return WriteFileAtomic(p, data, 0o644)
+}
+
+// logChanged passes today's entries, as just written, to the checkout cache
+// and OnLogChange.
+func (s *Store) logChanged(entries []LogEntry) {
+	s.checkouts.set(TodaysLogDate(), entries)
+	if s.OnLogChange != nil {
+		s.OnLogChange(entries)
+	}
+}
+
+// updateDailyLog queues a write that applies update to today's entries
+// under the log lock and writes them back. update runs on the background
+// writer, so it must only use copies of the store's state.
+func (s *Store) updateDailyLog(update func(entries []LogEntry)) {
+	if s.ReadOnly {
+		return
+	}
+	s.queueWrite("writing daily log", func() error {
+		s.logMu.Lock()
+		defer s.logMu.Unlock()
+		entries, err := s.ReadDailyLogEntries()
+		if err != nil {
+			return err
+		}
+		update(entries)
+		if err := s.writeDailyLogEntries(entries); err != nil {
+			return err
+		}
+		s.logChanged(entries)
+		return nil
+	}, nil)
+}
+
+// ReadDailyLogEntriesLocked reads today's log while holding the log lock, for
+// callers outside the store that need a consistent snapshot.
+func (s *Store) ReadDailyLogEntriesLocked() ([]LogEntry, error) {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	return s.ReadDailyLogEntries()
+}
+
+// openSessionIndex returns the index of u's open session in entries, or -1.
+// Sessions are matched on SessionID; entries logged before session IDs fall
+// back to the user and check-in time.
+func openSessionIndex(entries []LogEntry, u User) int {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.UserID != u.ID || !e.CheckOutTime.IsZero() {
+			continue
+		}
+		if e.SessionID != "" || u.SessionID != "" {
+			if e.SessionID == u.SessionID {
+				return i
+			}
+			continue
+		}
+		if e.CheckInTime.Equal(u.CheckInTime) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *Store) recordLogEvent(isCheckIn bool, u User, deviceID int, note string, removal QueueRemoval) error {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	if err := s.EnsureLogDir(); err != nil {
+		return err
+	}
+	entries, err := s.ReadDailyLogEntries()
+	if err != nil {
+		return err
+	}
+	if isCheckIn {
+		entries = append(entries, LogEntry{UserName: u.Name, UserID: u.ID, PCID: deviceID, CheckInTime: u.CheckInTime.UTC(),
+			Purpose: u.Purpose, Course: u.Course, VisitorType: u.VisitorType, Event: u.Event, Source: u.Source, SessionID: u.SessionID, ExcludeFromStats: u.ExcludeFromStats,
+			LimitExempt: u.LimitExempt})
+	} else if i := openSessionIndex(entries, u); i >= 0 {
+		checkOut := time.Now()
+		entries[i].CheckOutTime = checkOut.UTC()
+		entries[i].Note = note
+		entries[i].RemovalReason = removal.Reason
+		entries[i].NotAVisit = removal.NotAVisit
+		if d, ok := sessionUsage(u.CheckInTime, checkOut); ok {
+			entries[i].UsageTime = FormatDuration(d)
+		} else {
+			entries[i].ClockSkew = true
+		}
+	} else {
+		slog.Warn("no matching check-in for checkout", "user", u.ID, "device", deviceID)
+	}
+	if err := s.writeDailyLogEntries(entries); err != nil {
+		return err
+	}
+	s.logChanged(entries)
+	return nil
+}
+
+// RecordLogEventAsync queues a check-in or checkout for the background
+// writer; WaitForWrites waits for it to land on disk. note is kept on a
+// closed entry.
+func (s *Store) RecordLogEventAsync(isCheckIn bool, u User, deviceID int, note string) {
+	s.recordLogEventAsync(isCheckIn, u, deviceID, note, QueueRemoval{})
+}
+
+// recordLogEventAsync is RecordLogEventAsync that also keeps removal on a
+// closed entry.
+func (s *Store) recordLogEventAsync(isCheckIn bool, u User, deviceID int, note string, removal QueueRemoval) {
+	s.queueWrite("writing daily log", func() error {
+		return s.recordLogEvent(isCheckIn, u, deviceID, note, removal)
+	}, nil)
+}
+
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	sec := int(d.Seconds()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%dh%02dm%02ds", h, m, sec)
+	}
+	if m > 0 {
+		return fmt.Sprintf("%dm%02ds", m, sec)
+	}
+	return fmt.Sprintf("%ds", sec)
+}
+
+// ListAvailableLogDates returns every day with a log, newest first, always
+// including today.
+func (s *Store) ListAvailableLogDates() []string {
+	files, err := os.ReadDir(s.LogDir)
+	if err != nil {
+		return []string{TodaysLogDate()}
+	}
+	dates := make(map[string]bool)
+	dates[TodaysLogDate()] = true
+	for _, f := range files {
+		if date := logDateFromFileName(f.Name()); date != "" {
+			dates[date] = true
+		}
+	}
+	for _, date := range s.listArchivedLogDates() {
+		dates[date] = true
+	}
+	out := make([]string, 0, len(dates))
+	for d := range dates {
+		out = append(out, d)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(out)))
+	return out
+}
+
+// lastCheckouts is each user's latest checkout in one day's log. It is read
+// from disk once a day and then follows the log through logChanged, which
+// may run on the background writer.
+type lastCheckouts struct {
+	mu   sync.Mutex
+	date string
+	byID map[string]time.Time
+}
+
+func (c *lastCheckouts) set(date string, entries []LogEntry) {
+	byID := make(map[string]time.Time)
+	for _, entry := range entries {
+		if entry.UserID != "" && entry.CheckOutTime.After(byID[entry.UserID]) {
+			byID[entry.UserID] = entry.CheckOutTime
+		}
+	}
+	c.mu.Lock()
+	c.date, c.byID = date, byID
+	c.mu.Unlock()
+}
+
+func (c *lastCheckouts) get(date, userID string) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.date != date {
+		return time.Time{}, false
+	}
+	return c.byID[userID], true
+}
+
+// CooldownUntil returns when userID may check in again after their last
+// session today, or the zero time when Cooldown is off or already over.
+// It is called on every keystroke of an ID, so the log is only read the
+// first time each day.
+func (s *Store) CooldownUntil(userID string, now time.Time) time.Time {
+	if s.Cooldown <= 0 || userID == "" {
+		return time.Time{}
+	}
+	today := TodaysLogDate()
+	last, ok := s.checkouts.get(today, userID)
+	if !ok {
+		entries, err := s.ReadDailyLogEntriesLocked()
+		if err != nil {
+			return time.Time{}
+		}
+		s.checkouts.set(today, entries)
+		last, _ = s.checkouts.get(today, userID)
+	}
+	if last.IsZero() {
+		return time.Time{}
+	}
+	if eligible := last.Add(s.Cooldown); eligible.After(now) {
+		return eligible
+	}
+	return time.Time{}
+}
@@ -0,0 +1,109 @@
+package state
+
+import (
+	"log/slog"
+	"time"
+)
+
+// KindHeadcount marks a log entry that records a change to the lounge
+// headcount rather than a device session.
+const KindHeadcount = "headcount"
+
+// IsSession reports whether e is a device or queue session, as opposed to a
+// headcount or rotation entry.
+func (e LogEntry) IsSession() bool { return e.Kind == "" }
+
+// AdjustLoungeCount adds delta to the number of visitors in the lounge
+// without a device, never going below zero, and logs the change. It returns
+// the new count.
+func (s *Store) AdjustLoungeCount(delta int) int {
+	if s.ReadOnly {
+		return s.LoungeCount
+	}
+	next := max(s.LoungeCount+delta, 0)
+	if next == s.LoungeCount {
+		return next
+	}
+	change := next - s.LoungeCount
+	s.LoungeCount = next
+	entry := LogEntry{Kind: KindHeadcount, CheckInTime: time.Now().UTC(), Headcount: next, Change: change}
+	if err := s.appendLogEntry(entry); err != nil {
+		slog.Error("logging lounge headcount", "err", err)
+	}
+	s.changed()
+	return next
+}
+
+// appendLogEntry adds a non-session entry to today's log.
+func (s *Store) appendLogEntry(entry LogEntry) error {
+	if err := s.EnsureLogDir(); err != nil {
+		slog.Error("creating log directory", "err", err)
+	}
+	s.logMu.Lock()
+	entries, err := s.ReadDailyLogEntries()
+	if err == nil {
+		entries = append(entries, entry)
+		err = s.writeDailyLogEntries(entries)
+	}
+	s.logMu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.logChanged(entries)
+	return nil
+}
+
+// Headcount is who is in the room, for the status bar and the evacuation
+// list alike. Queued users wait in the room, so they count.
+type Headcount struct {
+	OnDevices, Queued, Lounge int
+}
+
+// Total is everyone in the room.
+func (h Headcount) Total() int { return h.OnDevices + h.Queued + h.Lounge }
+
+// Headcount counts the people in the room now.
+func (s *Store) Headcount() Headcount {
+	queued := len(s.PendingUsers())
+	return Headcount{OnDevices: len(s.ActiveUsers) - queued, Queued: queued, Lounge: s.LoungeCount}
+}
+
+// ResetLoungeCount empties the lounge headcount, when everyone is checked
+// out at closing.
+func (s *Store) ResetLoungeCount() {
+	s.AdjustLoungeCount(-s.LoungeCount)
+}
+
+// ReloadLoungeCount reads the headcount back from today's log, as a restart
+// would. Call it at a new day, whose log has no visitors yet, so
+// yesterday's are not carried over.
+func (s *Store) ReloadLoungeCount() {
+	s.loadLoungeCount()
+	s.changed()
+}
+
+// loadLoungeCount restores the headcount from the last headcount entry in
+// today's log, so a restart mid-day keeps it and a new day starts at zero.
+func (s *Store) loadLoungeCount() {
+	s.LoungeCount = 0
+	entries, err := s.ReadDailyLogEntriesLocked()
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.Kind == KindHeadcount {
+			s.LoungeCount = entry.Headcount
+		}
+	}
+}
+
+// LoungeVisits counts the arrivals recorded by headcount entries.
+func LoungeVisits(entries []LogEntry) int {
+	visits := 0
+	for _, entry := range entries {
+		if entry.Kind == KindHeadcount && entry.Change > 0 {
+			visits += entry.Change
+		}
+	}
+	return visits
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// StatusMaintenance is the status of a device taken out of service, e.g. for
+// cleaning. Nobody can be seated on it until staff clear it.
+const StatusMaintenance = "maintenance"
+
+func (s *Store) maintenanceFile() string { return filepath.Join(s.LogDir, "maintenance.json") }
+
+// loadMaintenance restores the devices left in maintenance at the last
+// shutdown. A device that somehow came back occupied stays occupied.
+func (s *Store) loadMaintenance() {
+	data, err := os.ReadFile(s.maintenanceFile())
+	if err != nil {
+		return
+	}
+	var ids []int
+	if json.Unmarshal(data, &ids) != nil {
+		return
+	}
+	for _, id := range ids {
+		if device := s.DeviceByID(id); device != nil && device.Status == "free" {
+			device.Status = StatusMaintenance
+		}
+	}
+}
+
+func (s *Store) saveMaintenance() error {
+	if err := s.EnsureLogDir(); err != nil {
+		return err
+	}
+	ids := []int{}
+	for _, device := range s.Devices {
+		if device.Status == StatusMaintenance {
+			ids = append(ids, device.ID)
+		}
+	}
+	sort.Ints(ids)
+	data, _ := json.MarshalIndent(ids, "", "  ")
+	return WriteFileAtomic(s.maintenanceFile(), data, 0o644)
+}
+
+// SetMaintenance takes deviceID out of service, or returns it to service when
+// on is false. Only a free device can be taken out; its players must be
+// checked out first.
+func (s *Store) SetMaintenance(deviceID int, on bool) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	device := s.DeviceByID(deviceID)
+	if device == nil {
+		return newError(ErrDeviceNotFound, "device ID %d does not exist", deviceID)
+	}
+	switch {
+	case on && device.Status == StatusMaintenance, !on && device.Status != StatusMaintenance:
+		return nil
+	case on && device.Status != "free":
+		return newError(ErrDeviceBusy, "device %d is in use; check its players out first", deviceID)
+	case on:
+		device.Status = StatusMaintenance
+	default:
+		device.Status = "free"
+	}
+	err := s.saveMaintenance()
+	s.changed()
+	return err
+}
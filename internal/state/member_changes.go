@@ -0,0 +1,122 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// MemberChange is one member added or edited since MemberFile was last
+// written. Changes wait in MemberChanges, and in the member journal next to
+// MemberFile, until FlushUnsavedMembers writes them all in one rewrite.
+type MemberChange struct {
+	Added  bool      `json:"added,omitempty"`
+	Member Member    `json:"member"`
+	At     time.Time `json:"at"`
+}
+
+// memberJournalFile holds the MemberChanges not yet in MemberFile, one JSON
+// line each, so a crash before the flush loses none of them.
+func (s *Store) memberJournalFile() string { return s.MemberFile + ".journal" }
+
+// memberLastGoodFile is MemberFile as it was before the last rewrite.
+// memberRewritingFile is left by older builds, which rewrote the file in
+// place, when such a rewrite was cut short.
+func (s *Store) memberLastGoodFile() string  { return s.MemberFile + ".last-good" }
+func (s *Store) memberRewritingFile() string { return s.MemberFile + ".rewriting" }
+
+// recordMemberChange adds change to MemberChanges and queues its journal
+// line.
+func (s *Store) recordMemberChange(change MemberChange) {
+	s.MemberChanges = append(s.MemberChanges, change)
+	line, err := json.Marshal(change)
+	if err != nil {
+		s.writeFailed("encoding member change", err)
+		return
+	}
+	s.queueWrite("journaling member change", func() error {
+		f, err := os.OpenFile(s.memberJournalFile(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		return f.Sync()
+	}, nil)
+}
+
+// readMemberJournal returns the changes a run that ended without flushing
+// left behind. A torn final line is ignored.
+func (s *Store) readMemberJournal() ([]MemberChange, error) {
+	data, err := os.ReadFile(s.memberJournalFile())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var changes []MemberChange
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var change MemberChange
+		if err := json.Unmarshal([]byte(line), &change); err != nil {
+			break
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// recoverMemberFile puts the last good copy of MemberFile back when a
+// rewrite was cut short, which may have left it empty or half written. It
+// returns a warning for staff, or "" when there was nothing to recover.
+func (s *Store) recoverMemberFile() string {
+	if _, err := os.Stat(s.memberRewritingFile()); err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(s.memberLastGoodFile())
+	if err != nil {
+		return fmt.Sprintf("%s was being rewritten when the app stopped and no good copy was found: %v", s.MemberFile, err)
+	}
+	if err := WriteFileAtomic(s.MemberFile, data, 0o644); err != nil {
+		return fmt.Sprintf("%s was being rewritten when the app stopped and could not be restored: %v", s.MemberFile, err)
+	}
+	os.Remove(s.memberRewritingFile())
+	return fmt.Sprintf("%s was being rewritten when the app stopped; restored the last good copy", s.MemberFile)
+}
+
+// applyMemberChange puts change into rows, replacing the row with the
+// member's ID or adding one.
+func (c memberColumnLayout) applyMemberChange(rows [][]string, change MemberChange) [][]string {
+	for i, row := range rows {
+		if i == 0 && c.hasHeader {
+			continue
+		}
+		if c.id < len(row) && strings.TrimSpace(row[c.id]) == change.Member.ID {
+			rows[i] = c.row(change.Member, row)
+			return rows
+		}
+	}
+	return append(rows, c.row(change.Member, nil))
+}
+
+// applyToMembers puts change into Members, for changes replayed from the
+// journal.
+func (s *Store) applyToMembers(change MemberChange) {
+	if existing := s.MemberByID(change.Member.ID); existing != nil {
+		*existing = change.Member
+		return
+	}
+	s.Members = append(s.Members, change.Member)
+}
@@ -0,0 +1,123 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DuplicateMembers is a set of member rows whose IDs only differ in case or
+// whitespace, in file order.
+type DuplicateMembers struct {
+	Key     string
+	Members []Member
+}
+
+// NormalizeMemberID folds the differences hand-edited files pick up: case and
+// stray whitespace anywhere in the ID.
+func NormalizeMemberID(id string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(id), ""))
+}
+
+// FindDuplicateMembers groups members sharing a normalized ID. The groups
+// are worked out when the members load or change, not on each call.
+func (s *Store) FindDuplicateMembers() []DuplicateMembers {
+	if len(s.memberKeys) != len(s.Members) {
+		s.indexMembers()
+	}
+	return s.duplicateMembers
+}
+
+func groupDuplicateMembers(members []Member) []DuplicateMembers {
+	index := make(map[string]int)
+	var groups []DuplicateMembers
+	for _, member := range members {
+		key := NormalizeMemberID(member.ID)
+		if i, ok := index[key]; ok {
+			groups[i].Members = append(groups[i].Members, member)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, DuplicateMembers{Key: key, Members: []Member{member}})
+	}
+	out := groups[:0]
+	for _, group := range groups {
+		if len(group.Members) > 1 {
+			out = append(out, group)
+		}
+	}
+	return out
+}
+
+// MergeMembers combines a duplicate group into members[canonical], keeping
+// every distinct note, the flag and staff mark if any row had them and the
+// first email on file. Receipts stay on only if the kept row opted in.
+func MergeMembers(members []Member, canonical int) Member {
+	merged := members[canonical]
+	var notes []string
+	seen := make(map[string]bool)
+	for _, member := range members {
+		note := strings.TrimSpace(member.Notes)
+		if note != "" && !seen[note] {
+			seen[note] = true
+			notes = append(notes, note)
+		}
+		merged.Flagged = merged.Flagged || member.Flagged
+		merged.ExcludeFromStats = merged.ExcludeFromStats || member.ExcludeFromStats
+		merged.NoSessionLimit = merged.NoSessionLimit || member.NoSessionLimit
+		if merged.Email == "" {
+			merged.Email = member.Email
+		}
+	}
+	merged.Notes = strings.Join(notes, "\n")
+	return merged
+}
+
+// ResolveDuplicateMembers keeps one row per duplicate group: the row of the
+// first member in the group is rewritten as keep[key] and the others are
+// dropped. The original file is copied next to it first; the backup path is
+// returned.
+func (s *Store) ResolveDuplicateMembers(keep map[string]Member) (string, error) {
+	if s.ReadOnly {
+		return "", ErrReadOnly
+	}
+	if err := s.FlushUnsavedMembers(); err != nil {
+		return "", fmt.Errorf("%d member change(s) are not saved to %s yet; close any program holding it and try again: %w", len(s.MemberChanges), s.MemberFile, err)
+	}
+	data, err := os.ReadFile(s.MemberFile)
+	if err != nil {
+		return "", fmt.Errorf("read member file: %w", err)
+	}
+	backup := s.MemberFile + ".bak-" + time.Now().Format("20060102-150405")
+	if err := WriteFileAtomic(backup, data, 0o644); err != nil {
+		return "", fmt.Errorf("back up member file: %w", err)
+	}
+	err = s.rewriteMemberFile(func(rows [][]string) [][]string {
+		written := make(map[string]bool)
+		out := rows[:0]
+		for i, row := range rows {
+			if (i == 0 && s.memberColumns.hasHeader) || s.memberColumns.id >= len(row) {
+				out = append(out, row)
+				continue
+			}
+			key := NormalizeMemberID(row[s.memberColumns.id])
+			member, ok := keep[key]
+			if !ok {
+				out = append(out, row)
+				continue
+			}
+			if written[key] {
+				continue
+			}
+			written[key] = true
+			out = append(out, s.memberColumns.row(member, row))
+		}
+		return out
+	})
+	if err != nil {
+		return backup, err
+	}
+	s.LoadMembers()
+	return backup, nil
+}
@@ -0,0 +1,89 @@
+package state
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicateMembers(t *testing.T) {
+	tests := []struct {
+		name    string
+		members string
+		want    map[string]int // normalized ID: rows
+	}{
+		{"none", "Ada Lovelace,1001\nAlan Turing,1002\n", map[string]int{}},
+		{"exact", "Ada Lovelace,1001\nAda Lovelace,1001\nAlan Turing,1002\n", map[string]int{"1001": 2}},
+		{"case", "Ada Lovelace,ab1001\nAda King,AB1001\nAda L.,Ab1001\n", map[string]int{"AB1001": 3}},
+		{"whitespace", "Ada Lovelace,AB 1001\nAda Lovelace, AB1001 \nAlan Turing,1002\nAlan M. Turing,10 02\n",
+			map[string]int{"AB1001": 2, "1002": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, "Student Name,Student Number\n"+tt.members)
+			groups := s.FindDuplicateMembers()
+			got := make(map[string]int)
+			for _, group := range groups {
+				got[group.Key] = len(group.Members)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got groups %v, want %v", got, tt.want)
+			}
+			for key, n := range tt.want {
+				if got[key] != n {
+					t.Errorf("group %s has %d rows, want %d", key, got[key], n)
+				}
+			}
+			warned := false
+			for _, warning := range s.MemberWarnings {
+				warned = warned || strings.Contains(warning, "more than one row")
+			}
+			if warned != (len(tt.want) > 0) {
+				t.Errorf("load warnings %q", s.MemberWarnings)
+			}
+		})
+	}
+}
+
+func TestDuplicateMembersFollowChanges(t *testing.T) {
+	s := newSessionStore(t)
+	if dups := s.FindDuplicateMembers(); len(dups) != 0 {
+		t.Fatalf("got %v before any change", dups)
+	}
+	// A check-in with an ID that differs only in spacing adds a second row.
+	if err := s.RegisterWith("Ada Lovelace", " 1001", 0, RegisterOptions{Waived: []string{"unknown-member"}}); err != nil {
+		t.Fatal(err)
+	}
+	if dups := s.FindDuplicateMembers(); len(dups) != 1 || len(dups[0].Members) != 2 {
+		t.Fatalf("after the check-in got %v, want 1001 twice", dups)
+	}
+}
+
+func TestResolveDuplicateMembers(t *testing.T) {
+	original := "Student Name,Student Number,Notes\nAda Lovelace,ab1001,first\nAlan Turing,1002,\nAda King,AB 1001,second\n"
+	s := newTestStore(t, original)
+	groups := s.FindDuplicateMembers()
+	if len(groups) != 1 {
+		t.Fatalf("got %v, want one group", groups)
+	}
+	merged := MergeMembers(groups[0].Members, 1)
+	if merged.Name != "Ada King" || merged.Notes != "first\nsecond" {
+		t.Fatalf("merged member %+v", merged)
+	}
+	backup, err := s.ResolveDuplicateMembers(map[string]Member{groups[0].Key: merged})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := readFile(t, backup); data != strings.TrimSpace(original) {
+		t.Errorf("backup is %q, want the original file", data)
+	}
+	if dups := s.FindDuplicateMembers(); len(dups) != 0 {
+		t.Errorf("still %v after resolving", dups)
+	}
+	if len(s.Members) != 2 {
+		t.Errorf("%d members after resolving, want 2", len(s.Members))
+	}
+	data := readFile(t, s.MemberFile)
+	if strings.Count(data, "1001") != 1 || !strings.Contains(data, "Ada King") {
+		t.Errorf("member file after resolving:\n%s", data)
+	}
+}
@@ -0,0 +1,72 @@
+package state
+
+import "strings"
+
+// memberSearchKey is what member searches match against: the lowercased name,
+// the name as first typed and the ID, separated so a query cannot match
+// across them.
+func memberSearchKey(m Member) string {
+	return strings.ToLower(strings.TrimSpace(m.Name)) + "\x00" + strings.ToLower(strings.Join(strings.Fields(m.RawName), " ")) +
+		"\x00" + strings.ToLower(strings.TrimSpace(m.ID))
+}
+
+// indexMembers rebuilds the search keys and the duplicate groups after
+// Members changed.
+func (s *Store) indexMembers() {
+	s.memberKeys = s.memberKeys[:0]
+	for _, m := range s.Members {
+		s.memberKeys = append(s.memberKeys, memberSearchKey(m))
+	}
+	s.duplicateMembers = groupDuplicateMembers(s.Members)
+	s.membersVersion++
+}
+
+// MemberSearch finds members as the user types. It remembers the previous
+// query's matches, so a query that extends it only rescans those.
+type MemberSearch struct {
+	store   *Store
+	version int
+	query   string
+	matches []int
+}
+
+func (s *Store) NewMemberSearch() *MemberSearch {
+	return &MemberSearch{store: s}
+}
+
+// Find returns up to limit members whose name or ID contains query, ignoring
+// case, and the total number of matches.
+func (m *MemberSearch) Find(query string, limit int) (members []Member, total int) {
+	s := m.store
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		m.query, m.matches = "", nil
+		return nil, 0
+	}
+	if len(s.memberKeys) != len(s.Members) {
+		s.indexMembers()
+	}
+	var next []int
+	if m.version == s.membersVersion && m.query != "" && strings.HasPrefix(query, m.query) {
+		next = make([]int, 0, len(m.matches))
+		for _, i := range m.matches {
+			if strings.Contains(s.memberKeys[i], query) {
+				next = append(next, i)
+			}
+		}
+	} else {
+		for i, key := range s.memberKeys {
+			if strings.Contains(key, query) {
+				next = append(next, i)
+			}
+		}
+	}
+	m.version, m.query, m.matches = s.membersVersion, query, next
+
+	shown := min(len(next), limit)
+	members = make([]Member, shown)
+	for j := range shown {
+		members[j] = s.Members[next[j]]
+	}
+	return members, len(next)
+}
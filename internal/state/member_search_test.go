@@ -0,0 +1,77 @@
+package state
+
+import (
+	"fmt"
+	"testing"
+)
+
+// membersForSearch is n members with names and IDs like a real member file.
+func membersForSearch(n int) []Member {
+	first := []string{"Ada", "Grace", "Alan", "Katherine", "Linus", "Margaret", "Dennis", "Barbara"}
+	last := []string{"Lovelace", "Hopper", "Turing", "Johnson", "Torvalds", "Hamilton", "Ritchie", "Liskov"}
+	members := make([]Member, n)
+	for i := range members {
+		name := fmt.Sprintf("%s %s %d", first[i%len(first)], last[i/len(first)%len(last)], i)
+		members[i] = Member{Name: name, RawName: name, ID: fmt.Sprint(2000000 + i)}
+	}
+	return members
+}
+
+func TestMemberSearch(t *testing.T) {
+	s := NewStore(t.TempDir(), "")
+	s.Members = membersForSearch(200)
+	search := s.NewMemberSearch()
+
+	steps := []struct {
+		query string
+		total int
+	}{
+		{"ada", 25},
+		{"ada lovelace", 4},
+		{"ADA LOVELACE 12", 1},
+		{"ada", 25},
+		{"2000013", 1},
+		{"nobody", 0},
+		{"nobody here", 0},
+		{"", 0},
+	}
+	for _, step := range steps {
+		members, total := search.Find(step.query, 3)
+		if total != step.total || len(members) != min(total, 3) {
+			t.Errorf("Find(%q) gave %d of %d, want %d", step.query, len(members), total, step.total)
+		}
+	}
+
+	// A member added after the last search is found by the next one.
+	s.Members = append(s.Members, Member{Name: "Ada Zed", ID: "9"})
+	s.indexMembers()
+	if _, total := search.Find("ada z", 3); total != 1 {
+		t.Errorf("got %d matches for a new member, want 1", total)
+	}
+}
+
+// BenchmarkMemberSearch types a name into a search over 10,000 members,
+// one keystroke per call. Each should stay well inside a 16ms frame.
+func BenchmarkMemberSearch(b *testing.B) {
+	s := NewStore(b.TempDir(), "")
+	s.Members = membersForSearch(10000)
+	s.indexMembers()
+	const typed = "margaret hopper"
+
+	b.Run("first keystroke", func(b *testing.B) {
+		search := s.NewMemberSearch()
+		for range b.N {
+			search.Find("", 50)
+			search.Find("m", 50)
+		}
+	})
+	b.Run("typing", func(b *testing.B) {
+		search := s.NewMemberSearch()
+		for range b.N {
+			for i := 1; i <= len(typed); i++ {
+				search.Find(typed[:i], 50)
+			}
+		}
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(typed)), "ns/keystroke")
+	})
+}
@@ -0,0 +1,466 @@
+package state
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// memberColumnLayout records where each field lives in membership.csv so
+// rewrites keep the file's own column order.
+type memberColumnLayout struct {
+	hasHeader bool
+	name      int
+	id        int
+	notes     int
+	flag      int
+	raw       int
+	email     int
+	receipts  int
+	staff     int
+	noLimit   int
+	course    int
+}
+
+func defaultMemberColumns() memberColumnLayout {
+	return memberColumnLayout{name: 2, id: 3, notes: 4, flag: 5, raw: 6, email: 7, receipts: 8, staff: 9, noLimit: 10, course: 11}
+}
+
+// row renders member into a CSV row, keeping any other columns from base.
+func (c memberColumnLayout) row(member Member, base []string) []string {
+	width := max(len(base), c.name+1, c.id+1, c.notes+1, c.flag+1, c.raw+1, c.email+1, c.receipts+1, c.staff+1, c.noLimit+1, c.course+1)
+	row := make([]string, width)
+	copy(row, base)
+	row[c.name] = member.Name
+	row[c.raw] = member.RawName
+	row[c.id] = member.ID
+	row[c.notes] = encodeMemberNotes(member.Notes)
+	row[c.flag] = ""
+	if member.Flagged {
+		row[c.flag] = "yes"
+	}
+	row[c.email] = member.Email
+	row[c.receipts] = ""
+	if member.EmailReceipts {
+		row[c.receipts] = "yes"
+	}
+	row[c.staff] = ""
+	if member.ExcludeFromStats {
+		row[c.staff] = "yes"
+	}
+	row[c.noLimit] = ""
+	if member.NoSessionLimit {
+		row[c.noLimit] = "yes"
+	}
+	row[c.course] = member.LastCourse
+	return row
+}
+
+// withHeader names the columns the app adds in a headed file.
+func (c memberColumnLayout) withHeader(rows [][]string) [][]string {
+	if !c.hasHeader || len(rows) == 0 {
+		return rows
+	}
+	header := rows[0]
+	for len(header) <= max(c.notes, c.flag, c.raw, c.email, c.receipts, c.staff, c.noLimit, c.course) {
+		header = append(header, "")
+	}
+	if strings.TrimSpace(header[c.notes]) == "" {
+		header[c.notes] = "Notes"
+	}
+	if strings.TrimSpace(header[c.flag]) == "" {
+		header[c.flag] = "Flag"
+	}
+	if strings.TrimSpace(header[c.raw]) == "" {
+		header[c.raw] = "Raw Name"
+	}
+	if strings.TrimSpace(header[c.email]) == "" {
+		header[c.email] = "Email"
+	}
+	if strings.TrimSpace(header[c.receipts]) == "" {
+		header[c.receipts] = "Email Receipts"
+	}
+	if strings.TrimSpace(header[c.staff]) == "" {
+		header[c.staff] = "Exclude From Stats"
+	}
+	if strings.TrimSpace(header[c.noLimit]) == "" {
+		header[c.noLimit] = "No Session Limit"
+	}
+	if strings.TrimSpace(header[c.course]) == "" {
+		header[c.course] = "Last Course"
+	}
+	rows[0] = header
+	return rows
+}
+
+// encodeMemberNotes keeps multi-line notes on one CSV line.
+func encodeMemberNotes(notes string) string {
+	notes = strings.ReplaceAll(notes, "\\", "\\\\")
+	notes = strings.ReplaceAll(notes, "\r\n", "\n")
+	return strings.ReplaceAll(notes, "\n", "\\n")
+}
+
+func decodeMemberNotes(encoded string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range encoded {
+		switch {
+		case escaped && r == 'n':
+			b.WriteRune('\n')
+			escaped = false
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// LoadedMembers is MemberFile as parsed by ReadMembers, not yet in the
+// store.
+type LoadedMembers struct {
+	members  []Member
+	columns  memberColumnLayout
+	warnings []string
+	// changes are the journaled changes a run left unflushed.
+	changes []MemberChange
+}
+
+// LoadMembers reads MemberFile, detecting its column layout from the header.
+func (s *Store) LoadMembers() { s.ApplyMembers(s.ReadMembers()) }
+
+// ReadMembers parses MemberFile without touching the rest of the store, so
+// it may run off the UI goroutine; ApplyMembers puts the result in place.
+func (s *Store) ReadMembers() LoadedMembers {
+	l := LoadedMembers{columns: defaultMemberColumns()}
+	if !s.ReadOnly {
+		if warning := s.recoverMemberFile(); warning != "" {
+			l.warnings = append(l.warnings, warning)
+		}
+		changes, err := s.readMemberJournal()
+		if err != nil {
+			l.warnings = append(l.warnings, fmt.Sprintf("read unsaved member changes: %v", err))
+		}
+		l.changes = changes
+	}
+	memberHandle, err := os.Open(s.MemberFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			l.warnings = append(l.warnings, err.Error())
+		}
+		return l
+	}
+	defer memberHandle.Close()
+
+	memberReader := csv.NewReader(memberHandle)
+	memberReader.FieldsPerRecord = -1
+	rows, err := memberReader.ReadAll()
+	if err != nil || len(rows) == 0 {
+		if err != nil {
+			l.warnings = append(l.warnings, fmt.Sprintf("parse %s: %v", s.MemberFile, err))
+		}
+		return l
+	}
+
+	nameIdx, idIdx, notesIdx, flagIdx, rawIdx, emailIdx, receiptsIdx, staffIdx, noLimitIdx, courseIdx := -1, -1, -1, -1, -1, -1, -1, -1, -1, -1
+	header := rows[0]
+	for i := range header {
+		key := strings.ToLower(strings.TrimSpace(header[i]))
+		if key == "student name" || key == "name" {
+			nameIdx = i
+		}
+		if key == "student number" || key == "id" || key == "student id" {
+			idIdx = i
+		}
+		if key == "notes" {
+			notesIdx = i
+		}
+		if key == "flag" {
+			flagIdx = i
+		}
+		if key == "raw name" {
+			rawIdx = i
+		}
+		if key == "email" || key == "e-mail" || key == "email address" {
+			emailIdx = i
+		}
+		if key == "email receipts" {
+			receiptsIdx = i
+		}
+		if key == "exclude from stats" {
+			staffIdx = i
+		}
+		if key == "no session limit" {
+			noLimitIdx = i
+		}
+		if key == "last course" {
+			courseIdx = i
+		}
+	}
+
+	start := 0
+	if nameIdx != -1 && idIdx != -1 {
+		start = 1
+		l.columns.hasHeader = true
+		l.columns.name, l.columns.id = nameIdx, idIdx
+		l.columns.placeMissing(len(header), notesIdx, flagIdx, rawIdx, emailIdx, receiptsIdx, staffIdx, noLimitIdx, courseIdx)
+	}
+	// A headerless file has no names to find the columns by, so it always
+	// uses the default layout, the same one rows are written in. That is
+	// the app's own format, not a fault; rows that do not fit it are
+	// reported as skipped below.
+	c := l.columns
+
+	var skipped []string
+	for i, row := range rows[start:] {
+		if c.name >= len(row) || c.id >= len(row) {
+			skipped = append(skipped, strconv.Itoa(start+i+1))
+			continue
+		}
+		name := strings.TrimSpace(row[c.name])
+		id := strings.TrimSpace(row[c.id])
+		if name == "" || id == "" {
+			skipped = append(skipped, strconv.Itoa(start+i+1))
+			continue
+		}
+		member := Member{
+			Name:          name,
+			ID:            id,
+			StudentNumber: id,
+		}
+		if c.notes < len(row) {
+			member.Notes = decodeMemberNotes(row[c.notes])
+		}
+		if c.flag < len(row) {
+			member.Flagged = memberFlag(row[c.flag])
+		}
+		if c.raw < len(row) {
+			member.RawName = strings.TrimSpace(row[c.raw])
+		}
+		if c.email < len(row) {
+			member.Email = strings.TrimSpace(row[c.email])
+		}
+		if c.receipts < len(row) {
+			member.EmailReceipts = memberFlag(row[c.receipts])
+		}
+		if c.staff < len(row) {
+			member.ExcludeFromStats = memberFlag(row[c.staff])
+		}
+		if c.noLimit < len(row) {
+			member.NoSessionLimit = memberFlag(row[c.noLimit])
+		}
+		if c.course < len(row) {
+			member.LastCourse = strings.TrimSpace(row[c.course])
+		}
+		l.members = append(l.members, member)
+	}
+	if len(skipped) > 0 {
+		if len(skipped) > 10 {
+			skipped = append(skipped[:10], "...")
+		}
+		l.warnings = append(l.warnings, fmt.Sprintf("skipped rows without a name or ID: %s", strings.Join(skipped, ", ")))
+	}
+	return l
+}
+
+// memberFlag reads a yes/no column.
+func memberFlag(cell string) bool {
+	cell = strings.ToLower(strings.TrimSpace(cell))
+	return cell == "yes" || cell == "true" || cell == "1"
+}
+
+// placeMissing puts the app's columns a headed file lacks after its last
+// column, in order; withHeader names them on the next rewrite. Indexes of
+// -1 are the missing ones.
+func (c *memberColumnLayout) placeMissing(width int, notes, flag, raw, email, receipts, staff, noLimit, course int) {
+	width = max(width, c.name+1, c.id+1)
+	place := func(idx int) int {
+		if idx != -1 {
+			return idx
+		}
+		width++
+		return width - 1
+	}
+	c.notes, c.flag, c.raw = place(notes), place(flag), place(raw)
+	c.email, c.receipts, c.staff = place(email), place(receipts), place(staff)
+	c.noLimit, c.course = place(noLimit), place(course)
+}
+
+// ApplyMembers replaces the members with l, replays the changes the last
+// run left unsaved and adds any unknown IDs checked in while they were
+// loading.
+func (s *Store) ApplyMembers(l LoadedMembers) {
+	s.Members, s.memberColumns, s.MemberWarnings = l.members, l.columns, l.warnings
+	s.MembersLoaded = true
+	if len(s.MemberChanges) == 0 && len(l.changes) > 0 {
+		for _, change := range l.changes {
+			s.applyToMembers(change)
+		}
+		s.MemberChanges = l.changes
+		s.MemberWarnings = append(s.MemberWarnings, fmt.Sprintf("%d member change(s) from the last run were not saved; they are written with the next flush", len(l.changes)))
+	}
+	s.indexMembers()
+	if dups := s.FindDuplicateMembers(); len(dups) > 0 {
+		s.MemberWarnings = append(s.MemberWarnings, fmt.Sprintf("%d member ID(s) appear on more than one row", len(dups)))
+	}
+	added := s.membersToAdd
+	s.membersToAdd = nil
+	for _, member := range added {
+		if s.MemberByID(member.ID) != nil {
+			continue
+		}
+		if err := s.AppendMember(member); err != nil {
+			s.writeFailed("saving member", err)
+		}
+	}
+}
+
+func (s *Store) NextMemberID() string { return strconv.Itoa(len(s.Members) + 1) }
+
+// AppendMember adds member, with the name normalized. Like every member
+// change it is journaled now and written to MemberFile by the next
+// FlushUnsavedMembers.
+func (s *Store) AppendMember(member Member) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	s.addUnsavedMember(member)
+	return nil
+}
+
+// addUnsavedMember adds member, with the name normalized, to Members and
+// MemberChanges and returns it as added.
+func (s *Store) addUnsavedMember(member Member) Member {
+	if normalized := NormalizeName(member.Name); normalized != member.Name {
+		if member.RawName == "" {
+			member.RawName = member.Name
+		}
+		member.Name = normalized
+	}
+	s.Members = append(s.Members, member)
+	s.indexMembers()
+	s.recordMemberChange(MemberChange{Added: true, Member: member, At: time.Now()})
+	return member
+}
+
+// FlushUnsavedMembers writes MemberChanges to MemberFile in one rewrite,
+// once the queued writes have landed, and empties the member journal. When
+// the write fails (Excel holds a lock on membership.csv on Windows) the
+// changes stay for the next flush.
+func (s *Store) FlushUnsavedMembers() error {
+	s.FlushWrites()
+	if len(s.MemberChanges) == 0 {
+		return nil
+	}
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	changes := s.MemberChanges
+	err := s.rewriteMemberFile(func(rows [][]string) [][]string {
+		rows = s.memberColumns.withHeader(rows)
+		for _, change := range changes {
+			rows = s.memberColumns.applyMemberChange(rows, change)
+		}
+		return rows
+	})
+	if err != nil {
+		return err
+	}
+	s.MemberChanges = nil
+	if err := os.Remove(s.memberJournalFile()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("clear member journal: %w", err)
+	}
+	return nil
+}
+
+// SaveMemberDetails updates the in-memory member with member.ID and
+// journals the change for the next FlushUnsavedMembers.
+func (s *Store) SaveMemberDetails(member Member) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	existing := s.MemberByID(member.ID)
+	if existing == nil {
+		return newError(ErrUserNotFound, "member %s not found", member.ID)
+	}
+	*existing = member
+	s.indexMembers()
+	s.recordMemberChange(MemberChange{Member: member, At: time.Now()})
+	return nil
+}
+
+// SetLastCourse makes course the one preselected at memberID's next
+// check-in. Like other member edits it is journaled now and written to
+// MemberFile by the next FlushUnsavedMembers. Unknown members are skipped.
+func (s *Store) SetLastCourse(memberID, course string) error {
+	member := s.MemberByID(memberID)
+	if member == nil || member.LastCourse == course {
+		return nil
+	}
+	updated := *member
+	updated.LastCourse = course
+	return s.SaveMemberDetails(updated)
+}
+
+// writeMemberFile replaces the member file; tests swap it to fail a write.
+var writeMemberFile = WriteFileAtomic
+
+// rewriteMemberFile reads the member file, lets update change the rows and
+// replaces the file with the result in one rename, so a failed write leaves
+// it as it was. The file as read is kept as the last good copy. A rewrite an
+// earlier build cut short is recovered first; see recoverMemberFile.
+func (s *Store) rewriteMemberFile(update func(rows [][]string) [][]string) error {
+	if _, err := os.Stat(s.memberRewritingFile()); err == nil {
+		s.recoverMemberFile()
+		if _, err := os.Stat(s.memberRewritingFile()); err == nil {
+			return fmt.Errorf("%s may be half written and could not be restored from %s; fix it before saving members",
+				s.MemberFile, filepath.Base(s.memberLastGoodFile()))
+		}
+	}
+	data, err := os.ReadFile(s.MemberFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read member file: %w", err)
+	}
+	memberReader := csv.NewReader(bytes.NewReader(data))
+	memberReader.FieldsPerRecord = -1
+	rows, readErr := memberReader.ReadAll()
+	if readErr != nil && readErr != io.EOF {
+		return fmt.Errorf("read member file: %w", readErr)
+	}
+	rows = update(rows)
+
+	var b bytes.Buffer
+	memberWriter := csv.NewWriter(&b)
+	if err := memberWriter.WriteAll(rows); err != nil {
+		return fmt.Errorf("write member rows: %w", err)
+	}
+	if len(data) > 0 {
+		if err := WriteFileAtomic(s.memberLastGoodFile(), data, 0o644); err != nil {
+			return fmt.Errorf("keep last good member file: %w", err)
+		}
+	}
+	if err := writeMemberFile(s.MemberFile, b.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write member file: %w", err)
+	}
+	return nil
+}
+
+// MemberByID finds nobody until the members have loaded; see MembersLoaded.
+func (s *Store) MemberByID(id string) *Member {
+	for i := range s.Members {
+		if s.Members[i].ID == id {
+			return &s.Members[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMemberDetailsSurviveRestart(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"headerless", "x,y,Ada Lovelace,1001\nx,y,Alan Turing,1002\n"},
+		{"headed", "Student Name,Student Number\nAda Lovelace,1001\nAlan Turing,1002\n"},
+		{"headed with app columns", "Notes,Student Name,Student Number,Email\n,Ada Lovelace,1001,\n,Alan Turing,1002,\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t, tt.file)
+			member := *s.MemberByID("1001")
+			member.Notes = "needs the tall desk\nand a footrest"
+			member.Flagged = true
+			member.Email = "ada@example.edu"
+			member.EmailReceipts = true
+			member.ExcludeFromStats = true
+			member.NoSessionLimit = true
+			if err := s.SaveMemberDetails(member); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetLastCourse("1001", "CS101"); err != nil {
+				t.Fatal(err)
+			}
+			member.LastCourse = "CS101"
+			if err := s.FlushUnsavedMembers(); err != nil {
+				t.Fatal(err)
+			}
+
+			again := reopen(t, s)
+			got := again.MemberByID("1001")
+			if got == nil {
+				t.Fatalf("member lost; file:\n%s", readFile(t, s.MemberFile))
+			}
+			if got.Notes != member.Notes || !got.Flagged || got.Email != member.Email || !got.EmailReceipts ||
+				!got.ExcludeFromStats || !got.NoSessionLimit || got.LastCourse != member.LastCourse {
+				t.Errorf("after restart got %+v, want %+v; file:\n%s", *got, member, readFile(t, s.MemberFile))
+			}
+			if other := again.MemberByID("1002"); other == nil || other.Flagged || other.Notes != "" {
+				t.Errorf("other member changed: %+v", other)
+			}
+		})
+	}
+}
+
+// lockMemberFile stands in for Excel holding membership.csv open: the file
+// is replaced by a folder, which no write can open. The returned func puts
+// the file back as it was.
+func lockMemberFile(t *testing.T, s *Store) (unlock func()) {
+	t.Helper()
+	s.FlushWrites()
+	data, err := os.ReadFile(s.MemberFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(s.MemberFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(s.MemberFile, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if err := os.Remove(s.MemberFile); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(s.MemberFile, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUnsavedMembersAreRetried(t *testing.T) {
+	s := newTestStore(t, "Student Name,Student Number\nAda Lovelace,1001\n")
+	unlock := lockMemberFile(t, s)
+	for _, member := range []Member{{Name: "Alan Turing", ID: "1002"}, {Name: "Grace Hopper", ID: "1003"}} {
+		if err := s.AppendMember(member); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.FlushUnsavedMembers(); err == nil {
+		t.Fatal("flushing to a locked file succeeded")
+	}
+	if len(s.MemberChanges) != 2 || s.MemberByID("1003") == nil {
+		t.Fatalf("after the failed write: %d unsaved, member 1003 %+v", len(s.MemberChanges), s.MemberByID("1003"))
+	}
+
+	// A restart before the retry still has the unsaved members.
+	unlock()
+	if again := reopen(t, s); again.MemberByID("1002") == nil || again.MemberByID("1003") == nil || len(again.MemberChanges) != 2 {
+		t.Fatalf("after a restart: %d unsaved, members %+v", len(again.MemberChanges), again.Members)
+	}
+
+	if err := s.FlushUnsavedMembers(); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.MemberChanges) != 0 {
+		t.Fatalf("%d changes left after the retry", len(s.MemberChanges))
+	}
+	if _, err := os.Stat(s.memberJournalFile()); !os.IsNotExist(err) {
+		t.Fatalf("member journal left after the retry: %v", err)
+	}
+	want := "Student Name,Student Number"
+	if got := readFile(t, s.MemberFile); !strings.HasPrefix(got, want) || strings.Count(got, "Alan Turing,1002") != 1 || strings.Count(got, "Grace Hopper,1003") != 1 {
+		t.Fatalf("member file after the retry:\n%s", got)
+	}
+	if again := reopen(t, s); len(again.Members) != 3 || len(again.MemberChanges) != 0 {
+		t.Fatalf("after the retry a restart finds %d members and %d unsaved", len(again.Members), len(again.MemberChanges))
+	}
+}
+
+func TestFailedRewriteKeepsTheGoodCopy(t *testing.T) {
+	const original = "Student Name,Student Number\nAda Lovelace,1001"
+	s := newTestStore(t, original+"\n")
+	if err := s.AppendMember(Member{Name: "Alan Turing", ID: "1002"}); err != nil {
+		t.Fatal(err)
+	}
+	failures := 1
+	writeMemberFile = func(path string, data []byte, perm os.FileMode) error {
+		if failures > 0 {
+			failures--
+			return errors.New("disk full")
+		}
+		return WriteFileAtomic(path, data, perm)
+	}
+	t.Cleanup(func() { writeMemberFile = WriteFileAtomic })
+
+	if err := s.FlushUnsavedMembers(); err == nil {
+		t.Fatal("the failed rewrite reported success")
+	}
+	if got := readFile(t, s.MemberFile); got != original {
+		t.Fatalf("member file after the failed rewrite:\n%s", got)
+	}
+	if err := s.FlushUnsavedMembers(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, s.MemberFile); !strings.Contains(got, "Ada Lovelace,1001") || !strings.Contains(got, "Alan Turing,1002") {
+		t.Fatalf("member file after the retry:\n%s", got)
+	}
+	if got := readFile(t, s.memberLastGoodFile()); got != original {
+		t.Fatalf("last good copy after the retry:\n%s", got)
+	}
+}
+
+func TestRewriteRecoversAHalfWrittenFile(t *testing.T) {
+	const original = "Student Name,Student Number\nAda Lovelace,1001\nGrace Hopper,1003"
+	s := newTestStore(t, original+"\n")
+	// An older build was cut short rewriting the file in place.
+	if err := os.WriteFile(s.memberLastGoodFile(), []byte(original+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.MemberFile, []byte("Student Name,Student Nu"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.memberRewritingFile(), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AppendMember(Member{Name: "Alan Turing", ID: "1002"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.FlushUnsavedMembers(); err != nil {
+		t.Fatal(err)
+	}
+	got := readFile(t, s.MemberFile)
+	for _, row := range []string{"Ada Lovelace,1001", "Grace Hopper,1003", "Alan Turing,1002"} {
+		if !strings.Contains(got, row) {
+			t.Fatalf("member file lost %q:\n%s", row, got)
+		}
+	}
+	if got := readFile(t, s.memberLastGoodFile()); got != original {
+		t.Fatalf("the half-written file became the last good copy:\n%s", got)
+	}
+	if _, err := os.Stat(s.memberRewritingFile()); !os.IsNotExist(err) {
+		t.Fatalf("rewrite marker left: %v", err)
+	}
+}
+
+func TestHeaderlessMembersUseDefaultColumns(t *testing.T) {
+	// A wide headerless row must not push the app's columns past it.
+	s := newTestStore(t, "a,b,Ada Lovelace,1001,,,,,,,,extra,more\n")
+	if s.memberColumns != defaultMemberColumns() {
+		t.Fatalf("columns = %+v, want the defaults", s.memberColumns)
+	}
+}
+
+func TestHeaderlessMemberFileIsValid(t *testing.T) {
+	s := newTestStore(t, ",,Ada Lovelace,1001\n,,Grace Hopper,1002,,,,,,\n")
+	if len(s.MemberWarnings) != 0 || len(s.Members) != 2 {
+		t.Fatalf("got %d members and warnings %q, want 2 and none", len(s.Members), s.MemberWarnings)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(data))
+}
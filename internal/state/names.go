@@ -0,0 +1,58 @@
+package state
+
+import (
+	"strings"
+	"unicode"
+)
+
+// nameParticles stay lower case inside a name: "Ludwig van Beethoven",
+// "María de la Cruz".
+var nameParticles = map[string]bool{
+	"van": true, "von": true, "der": true, "den": true, "ter": true, "ten": true,
+	"de": true, "la": true, "le": true, "du": true, "des": true,
+	"da": true, "di": true, "del": true, "della": true, "dos": true, "das": true,
+}
+
+// NormalizeName tidies a name as typed at the desk: runs of whitespace become
+// one space and each word is title cased, so "  aNA  li" and "ANA LI" both
+// become "Ana Li". Words already written with a capital first and lower case
+// after ("McDonald", "DeShawn") are kept, as are all-caps initialisms ("JJ")
+// unless the whole name is in capitals. Particles such as "van" and "de la"
+// are lower case after the first word. Hyphenated parts are cased on their
+// own, and a one-letter prefix before an apostrophe ("O'Brien") counts as a
+// part.
+func NormalizeName(raw string) string {
+	words := strings.Fields(raw)
+	shouting := !strings.ContainsFunc(raw, unicode.IsLower)
+	for i, word := range words {
+		if i > 0 && nameParticles[strings.ToLower(word)] {
+			words[i] = strings.ToLower(word)
+			continue
+		}
+		parts := strings.Split(word, "-")
+		for j, part := range parts {
+			parts[j] = titleNamePart(part, shouting)
+		}
+		words[i] = strings.Join(parts, "-")
+	}
+	return strings.Join(words, " ")
+}
+
+func titleNamePart(part string, shouting bool) string {
+	runes := []rune(part)
+	if len(runes) == 0 {
+		return part
+	}
+	if len(runes) > 2 && (runes[1] == '\'' || runes[1] == '’') {
+		return string(unicode.ToUpper(runes[0])) + string(runes[1]) + titleNamePart(string(runes[2:]), shouting)
+	}
+	hasLower := strings.ContainsFunc(part, unicode.IsLower)
+	hasUpper := strings.ContainsFunc(part, unicode.IsUpper)
+	switch {
+	case unicode.IsUpper(runes[0]) && hasLower:
+		return part
+	case hasUpper && !hasLower && !shouting && len(runes) > 1:
+		return part
+	}
+	return string(unicode.ToTitle(runes[0])) + strings.ToLower(string(runes[1:]))
+}
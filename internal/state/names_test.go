@@ -0,0 +1,52 @@
+package state
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{"  aNA  li", "Ana Li"},
+		{"ANA LI", "Ana Li"},
+		{"ana li", "Ana Li"},
+		{"Cher", "Cher"},
+		{"  prince ", "Prince"},
+		{"josé álvarez", "José Álvarez"},
+		{"ÉMILE ZOLA", "Émile Zola"},
+		{"søren kierkegaard", "Søren Kierkegaard"},
+		{"łukasz żółć", "Łukasz Żółć"},
+		{"mary-jane watson", "Mary-Jane Watson"},
+		{"anne-marie SMITH-JONES", "Anne-Marie SMITH-JONES"},
+		{"ludwig VAN beethoven", "Ludwig van Beethoven"},
+		{"maría de la cruz", "María de la Cruz"},
+		{"van morrison", "Van Morrison"},
+		{"conor o'brien", "Conor O'Brien"},
+		{"ronald mcdonald", "Ronald Mcdonald"},
+		{"Ronald McDonald", "Ronald McDonald"},
+		{"JJ abrams", "JJ Abrams"},
+		{"DeShawn jones", "DeShawn Jones"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.raw); got != tt.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestAppendMemberKeepsTheRawName(t *testing.T) {
+	s := newTestStore(t, "Student Name,Student Number\n")
+	if err := s.AppendMember(Member{Name: "  émile   zola", ID: "1001"}); err != nil {
+		t.Fatal(err)
+	}
+	member := s.MemberByID("1001")
+	if member.Name != "Émile Zola" || member.RawName != "  émile   zola" {
+		t.Fatalf("got name %q, raw %q", member.Name, member.RawName)
+	}
+	search := s.NewMemberSearch()
+	for _, query := range []string{"émile zola", "ÉMILE ZOLA", "zola", "1001"} {
+		if _, total := search.Find(query, 5); total != 1 {
+			t.Errorf("%q found %d members, want 1", query, total)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package state
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const OccupancySampleInterval = 5 * time.Minute
+
+var occupancyHeader = []string{"timestamp", "occupied_pcs", "console_players", "queue_length", "lounge_visitors"}
+
+// occupancyMinColumns is the width of files written before lounge_visitors.
+const occupancyMinColumns = 4
+
+// OccupancySample is a point-in-time count of device use, recorded every
+// OccupancySampleInterval.
+type OccupancySample struct {
+	Time           time.Time
+	OccupiedPCs    int
+	ConsolePlayers int
+	QueueLength    int
+	LoungeVisitors int
+}
+
+func (s *Store) occupancyFilePathForDate(date string) string {
+	if date == "" {
+		date = TodaysLogDate()
+	}
+	return filepath.Join(s.LogDir, fmt.Sprintf("occupancy-%s.csv", date))
+}
+
+// CurrentOccupancy snapshots the in-memory state.
+func (s *Store) CurrentOccupancy(now time.Time) OccupancySample {
+	sample := OccupancySample{Time: now.Truncate(OccupancySampleInterval), LoungeVisitors: s.LoungeCount}
+	for _, device := range s.Devices {
+		if device.Type == "PC" && device.Status == "occupied" {
+			sample.OccupiedPCs++
+		}
+	}
+	for _, u := range s.ActiveUsers {
+		if u.PCID == 0 {
+			sample.QueueLength++
+			continue
+		}
+		if d := s.DeviceByID(u.PCID); d != nil && d.Type == "Console" {
+			sample.ConsolePlayers++
+		}
+	}
+	return sample
+}
+
+func (s *Store) ReadOccupancySamples(date string) ([]OccupancySample, error) {
+	p := s.occupancyFilePathForDate(date)
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return []OccupancySample{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open occupancy: %s: %w", p, err)
+	}
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read occupancy: %s: %w", p, err)
+	}
+	samples := make([]OccupancySample, 0, len(rows))
+	for _, row := range rows {
+		if len(row) < occupancyMinColumns || row[0] == occupancyHeader[0] {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, row[0])
+		if err != nil {
+			continue
+		}
+		pcs, _ := strconv.Atoi(row[1])
+		consoles, _ := strconv.Atoi(row[2])
+		queue, _ := strconv.Atoi(row[3])
+		sample := OccupancySample{Time: ts, OccupiedPCs: pcs, ConsolePlayers: consoles, QueueLength: queue}
+		if len(row) > occupancyMinColumns {
+			sample.LoungeVisitors, _ = strconv.Atoi(row[4])
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// AppendOccupancySample writes sample to its day's file unless that 5-minute
+// bucket is already recorded, so quick restarts do not duplicate rows.
+func (s *Store) AppendOccupancySample(sample OccupancySample) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	if err := s.EnsureLogDir(); err != nil {
+		return err
+	}
+	date := sample.Time.Format("2006-01-02")
+	existing, err := s.ReadOccupancySamples(date)
+	if err != nil {
+		return err
+	}
+	for _, prev := range existing {
+		if prev.Time.Equal(sample.Time) {
+			return nil
+		}
+	}
+	p := s.occupancyFilePathForDate(date)
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open occupancy: %s: %w", p, err)
+	}
+	defer f.Close()
+	writer := csv.NewWriter(f)
+	if len(existing) == 0 {
+		if info, err := f.Stat(); err == nil && info.Size() == 0 {
+			_ = writer.Write(occupancyHeader)
+		}
+	}
+	_ = writer.Write([]string{
+		sample.Time.Format(time.RFC3339),
+		strconv.Itoa(sample.OccupiedPCs),
+		strconv.Itoa(sample.ConsolePlayers),
+		strconv.Itoa(sample.QueueLength),
+		strconv.Itoa(sample.LoungeVisitors),
+	})
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,232 @@
+package state
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// preregColumns are the sign-up list's columns, in the order assumed when
+// the file has no header row. Seat is optional.
+var preregColumns = []string{"name", "id", "seat"}
+
+// Preregistration is the sign-up list for one event.
+type Preregistration struct {
+	Event   string        `json:"event"`
+	Entries []PreregEntry `json:"entries"`
+}
+
+// PreregEntry is one person signed up. Seat, when set, is the device ID they
+// are seated on when they arrive.
+type PreregEntry struct {
+	Name string `json:"name"`
+	ID   string `json:"id,omitempty"`
+	Seat int    `json:"seat,omitempty"`
+	// Member marks an entry linked to a member, whose ID it carries.
+	Member  bool      `json:"member,omitempty"`
+	Arrived time.Time `json:"arrived,omitempty"`
+}
+
+// Counts returns how many have arrived and how many are on the list.
+func (p Preregistration) Counts() (arrived, expected int) {
+	for _, entry := range p.Entries {
+		if !entry.Arrived.IsZero() {
+			arrived++
+		}
+	}
+	return arrived, len(p.Entries)
+}
+
+// NoShows lists the entries that have not arrived.
+func (p Preregistration) NoShows() []PreregEntry {
+	var out []PreregEntry
+	for _, entry := range p.Entries {
+		if entry.Arrived.IsZero() {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// ParsePreregistration reads a sign-up CSV with name, id and optional seat
+// columns. An ID may be blank for someone who does not have one yet.
+func ParsePreregistration(r io.Reader) ([]PreregEntry, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("read pre-registration file: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range preregColumns {
+		columns[name] = i
+	}
+	start := 0
+	if len(rows) > 0 {
+		found := map[string]int{}
+		for i, cell := range rows[0] {
+			key := strings.ToLower(strings.TrimSpace(cell))
+			for _, name := range preregColumns {
+				if key == name {
+					found[name] = i
+				}
+			}
+		}
+		if _, ok := found["name"]; ok {
+			columns, start = found, 1
+		}
+	}
+	var entries []PreregEntry
+	var rowErrors []ImportRowError
+	for i, row := range rows[start:] {
+		cell := func(name string) string {
+			if c, ok := columns[name]; ok && c < len(row) {
+				return strings.TrimSpace(row[c])
+			}
+			return ""
+		}
+		if len(strings.Join(row, "")) == 0 {
+			continue
+		}
+		line := start + i + 1
+		entry := PreregEntry{Name: NormalizeName(cell("name")), ID: cell("id")}
+		if entry.Name == "" {
+			rowErrors = append(rowErrors, ImportRowError{Line: line, Message: "no name"})
+			continue
+		}
+		if seat := cell("seat"); seat != "" {
+			if entry.Seat, err = strconv.Atoi(seat); err != nil || entry.Seat <= 0 {
+				rowErrors = append(rowErrors, ImportRowError{Line: line, Message: fmt.Sprintf("bad seat %q", seat)})
+				continue
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rowErrors, nil
+}
+
+func (s *Store) preregistrationFile() string { return filepath.Join(s.LogDir, "preregistration.json") }
+
+func (s *Store) loadPreregistration() {
+	s.prereg = nil
+	data, err := os.ReadFile(s.preregistrationFile())
+	if err != nil {
+		return
+	}
+	var prereg Preregistration
+	if err := DecodeVersioned(s.preregistrationFile(), data, "preregistration", PreregistrationVersion, &prereg); err == nil {
+		s.prereg = &prereg
+	}
+}
+
+func (s *Store) savePreregistration() {
+	if s.prereg == nil {
+		s.queueWrite("removing pre-registration", func() error {
+			if err := os.Remove(s.preregistrationFile()); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return nil
+		}, nil)
+		return
+	}
+	data, err := EncodeVersioned("preregistration", PreregistrationVersion, s.prereg)
+	if err != nil {
+		s.writeFailed("encoding pre-registration", err)
+		return
+	}
+	s.queueWrite("writing pre-registration", func() error {
+		if err := s.EnsureLogDir(); err != nil {
+			return err
+		}
+		if err := GuardRewrite(s.preregistrationFile(), PreregistrationVersion); err != nil {
+			return err
+		}
+		return WriteFileAtomic(s.preregistrationFile(), data, 0o644)
+	}, nil)
+}
+
+// Preregistration returns the loaded sign-up list, if any.
+func (s *Store) Preregistration() (Preregistration, bool) {
+	if s.prereg == nil {
+		return Preregistration{}, false
+	}
+	return *s.prereg, true
+}
+
+// SetPreregistration replaces the sign-up list with entries for event. Each
+// entry whose ID is a member's, or whose name is exactly one member's when
+// it has no ID, is linked to that member so their history stays in one
+// place. People already checked in count as arrived.
+func (s *Store) SetPreregistration(event string, entries []PreregEntry) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	for i := range entries {
+		entry := &entries[i]
+		if entry.ID == "" {
+			entry.ID = s.uniqueMemberIDByName(entry.Name)
+		}
+		entry.Member = entry.ID != "" && s.MemberByID(entry.ID) != nil
+		if entry.ID != "" && s.UserByID(entry.ID) != nil {
+			entry.Arrived = time.Now()
+		}
+	}
+	s.prereg = &Preregistration{Event: strings.TrimSpace(event), Entries: entries}
+	s.savePreregistration()
+	s.changed()
+	return nil
+}
+
+func (s *Store) uniqueMemberIDByName(name string) string {
+	id := ""
+	for _, member := range s.Members {
+		if strings.EqualFold(member.Name, name) {
+			if id != "" {
+				return ""
+			}
+			id = member.ID
+		}
+	}
+	return id
+}
+
+// SetPreregID gives entry i, which had none, the ID it checks in with.
+func (s *Store) SetPreregID(i int, id string) {
+	if s.prereg == nil || i < 0 || i >= len(s.prereg.Entries) {
+		return
+	}
+	s.prereg.Entries[i].ID = id
+	s.savePreregistration()
+}
+
+// ClearPreregistration drops the sign-up list.
+func (s *Store) ClearPreregistration() error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	s.prereg = nil
+	s.savePreregistration()
+	s.changed()
+	return nil
+}
+
+// markPreregArrived notes userID's arrival if they are on the list, by
+// whichever way they checked in.
+func (s *Store) markPreregArrived(userID string, now time.Time) {
+	if s.prereg == nil {
+		return
+	}
+	for i := range s.prereg.Entries {
+		if s.prereg.Entries[i].ID == userID && s.prereg.Entries[i].Arrived.IsZero() {
+			s.prereg.Entries[i].Arrived = now
+			s.savePreregistration()
+			return
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package state
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning reports whether pid is a live process. EPERM means it exists
+// but belongs to another user.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package state
+
+import "syscall"
+
+// stillActive is the exit code Windows reports for a process that has not
+// exited.
+const stillActive = 259
+
+// processRunning reports whether pid is a live process.
+func processRunning(pid int) bool {
+	h, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(h)
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
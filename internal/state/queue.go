@@ -0,0 +1,367 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// QueueEntry records when a user joined the queue; the persisted list also
+// fixes their order.
+type QueueEntry struct {
+	UserID     string    `json:"user_id"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	// RefreshedAt restarts the queue timeout when staff confirm the user is
+	// still waiting, without moving them in the queue.
+	RefreshedAt time.Time `json:"refreshed_at,omitempty"`
+}
+
+// QueueRemoval is why staff took someone out of the queue without seating
+// them.
+type QueueRemoval struct {
+	Reason string
+	// NotAVisit leaves the session out of visit counts, e.g. for a no-show
+	// or a duplicate entry.
+	NotAVisit bool
+}
+
+// QueueExpiryGrace is how long a user stays in the queue, marked stale, once
+// QueueTimeout has passed.
+const QueueExpiryGrace = 5 * time.Minute
+
+// ExpiredFromQueueNote is the note on the log entry of a user the queue
+// timeout removed.
+const ExpiredFromQueueNote = "expired from queue"
+
+func (s *Store) queueFile() string { return filepath.Join(s.LogDir, "queue.json") }
+
+func (s *Store) loadQueue() {
+	data, err := os.ReadFile(s.queueFile())
+	if err != nil {
+		s.queue = []QueueEntry{}
+		return
+	}
+	var entries []QueueEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		s.queue = []QueueEntry{}
+		return
+	}
+	s.queue = entries
+	s.cleanQueue()
+}
+
+func (s *Store) saveQueue() {
+	if s.ReadOnly {
+		return
+	}
+	data, _ := json.MarshalIndent(s.queue, "", "  ")
+	s.queueWrite("writing queue file", func() error {
+		if err := s.EnsureLogDir(); err != nil {
+			return err
+		}
+		return WriteFileAtomic(s.queueFile(), data, 0o644)
+	}, nil)
+}
+
+func (s *Store) cleanQueue() {
+	valid := make(map[string]bool)
+	for _, u := range s.ActiveUsers {
+		if u.PCID == 0 {
+			valid[u.ID] = true
+		}
+	}
+	filtered := make([]QueueEntry, 0, len(s.queue))
+	changed := false
+	for _, entry := range s.queue {
+		if valid[entry.UserID] {
+			filtered = append(filtered, entry)
+			delete(valid, entry.UserID)
+		} else {
+			changed = true
+		}
+	}
+	s.queue = filtered
+	for id := range valid {
+		s.ensureQueueEntry(id, time.Time{})
+	}
+	if changed {
+		s.saveQueue()
+	}
+}
+
+func (s *Store) ensureQueueEntry(userID string, ts time.Time) {
+	if userID == "" {
+		return
+	}
+	for _, entry := range s.queue {
+		if entry.UserID == userID {
+			return
+		}
+	}
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	s.queue = append(s.queue, QueueEntry{UserID: userID, EnqueuedAt: ts})
+	s.saveQueue()
+}
+
+// renameQueueEntry moves oldID's queue entry to newID in place.
+func (s *Store) renameQueueEntry(oldID, newID string) {
+	for i := range s.queue {
+		if s.queue[i].UserID == oldID {
+			s.queue[i].UserID = newID
+			s.saveQueue()
+			return
+		}
+	}
+}
+
+func (s *Store) removeQueueEntry(userID string) {
+	if userID == "" {
+		return
+	}
+	idx := -1
+	for i, entry := range s.queue {
+		if entry.UserID == userID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return
+	}
+	s.queue = append(s.queue[:idx], s.queue[idx+1:]...)
+	s.saveQueue()
+}
+
+func (s *Store) orderedQueuedUsers(users []User) []User {
+	if len(users) == 0 {
+		return []User{}
+	}
+	idMap := make(map[string]User, len(users))
+	for _, u := range users {
+		idMap[u.ID] = u
+	}
+	ordered := make([]User, 0, len(users))
+	for _, entry := range s.queue {
+		if u, ok := idMap[entry.UserID]; ok {
+			ordered = append(ordered, u)
+			delete(idMap, entry.UserID)
+		}
+	}
+	for _, u := range idMap {
+		ordered = append(ordered, u)
+		s.ensureQueueEntry(u.ID, u.CheckInTime)
+	}
+	return ordered
+}
+
+// QueueTime returns when userID joined the queue, or now if unknown.
+func (s *Store) QueueTime(userID string) time.Time {
+	for i := range s.queue {
+		if s.queue[i].UserID == userID {
+			return s.queue[i].EnqueuedAt
+		}
+	}
+	return time.Now()
+}
+
+// queueTimerStart is when userID's queue timeout started counting.
+func (s *Store) queueTimerStart(userID string) time.Time {
+	for _, entry := range s.queue {
+		if entry.UserID == userID {
+			if entry.RefreshedAt.After(entry.EnqueuedAt) {
+				return entry.RefreshedAt
+			}
+			return entry.EnqueuedAt
+		}
+	}
+	return time.Now()
+}
+
+// QueueStale reports whether userID has waited past QueueTimeout and will be
+// removed once QueueExpiryGrace is over.
+func (s *Store) QueueStale(userID string, now time.Time) bool {
+	return s.QueueTimeout > 0 && now.Sub(s.queueTimerStart(userID)) >= s.QueueTimeout
+}
+
+// RefreshQueued restarts userID's queue timeout, keeping their place.
+func (s *Store) RefreshQueued(userID string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	u := s.UserByID(userID)
+	if u == nil {
+		return newError(ErrUserNotFound, "user ID %s not found", userID)
+	}
+	if u.PCID != 0 {
+		return newError(ErrUserNotQueued, "user %s is assigned to device %d", userID, u.PCID)
+	}
+	s.ensureQueueEntry(userID, u.CheckInTime)
+	for i := range s.queue {
+		if s.queue[i].UserID == userID {
+			s.queue[i].RefreshedAt = time.Now()
+		}
+	}
+	s.saveQueue()
+	s.changed()
+	return nil
+}
+
+// MoveQueued moves userID to position index (0 is next) in the queue, for
+// when staff agree to let someone ahead. The order is saved with the queue,
+// so it holds across restarts and everything reading PendingUsers follows it.
+func (s *Store) MoveQueued(userID string, index int) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	u := s.UserByID(userID)
+	if u == nil {
+		return newError(ErrUserNotFound, "user ID %s not found", userID)
+	}
+	if u.PCID != 0 {
+		return newError(ErrUserNotQueued, "%s has already been seated on device %d", u.Name, u.PCID)
+	}
+	s.ensureQueueEntry(userID, u.CheckInTime)
+	from := -1
+	for i := range s.queue {
+		if s.queue[i].UserID == userID {
+			from = i
+			break
+		}
+	}
+	index = max(0, min(index, len(s.queue)-1))
+	if from == index {
+		return nil
+	}
+	entry := s.queue[from]
+	s.queue = append(s.queue[:from], s.queue[from+1:]...)
+	s.queue = append(s.queue[:index], append([]QueueEntry{entry}, s.queue[index:]...)...)
+	s.saveQueue()
+	s.changed()
+	return nil
+}
+
+// EditQueued corrects a queued user's name and ID, keeping their check-in time
+// and place in the queue, and rewrites their open log entry to match.
+func (s *Store) EditQueued(userID, name, newID string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	u := s.UserByID(userID)
+	if u == nil {
+		return newError(ErrUserNotFound, "user ID %s not found", userID)
+	}
+	if u.PCID != 0 {
+		return newError(ErrUserNotQueued, "%s has already been seated on device %d", u.Name, u.PCID)
+	}
+	if newID != userID {
+		if existing := s.UserByID(newID); existing != nil {
+			return newError(ErrUserAlreadyActive, "user ID %s (%s) is already checked in", newID, existing.Name)
+		}
+	}
+	before := *u
+	u.ID = newID
+	u.Name = name
+	journaled := *u
+	s.appendJournal(JournalEntry{
+		Time:       time.Now(),
+		Action:     JournalEdit,
+		UserID:     newID,
+		Name:       name,
+		PreviousID: userID,
+		Session:    u.SessionID,
+		CheckIn:    u.CheckInTime,
+	})
+	s.renameQueueEntry(userID, newID)
+	s.Save()
+
+	s.updateDailyLog(func(entries []LogEntry) {
+		if i := openSessionIndex(entries, before); i >= 0 {
+			entries[i].UserID = journaled.ID
+			entries[i].UserName = journaled.Name
+		}
+	})
+	s.changed()
+	return nil
+}
+
+// ExpireQueued removes the queued users whose timeout and grace period are
+// both over, noting ExpiredFromQueueNote on their log entries, and returns
+// them.
+func (s *Store) ExpireQueued(now time.Time) []User {
+	if s.ReadOnly {
+		return nil
+	}
+	if s.QueueTimeout <= 0 {
+		return nil
+	}
+	var expired []User
+	for _, u := range s.PendingUsers() {
+		if now.Sub(s.queueTimerStart(u.ID)) < s.QueueTimeout+QueueExpiryGrace {
+			continue
+		}
+		if err := s.removeQueued(u.ID, ExpiredFromQueueNote, QueueRemoval{}); err == nil {
+			expired = append(expired, u)
+		}
+	}
+	return expired
+}
+
+func (s *Store) QueueIsFull(queued int) bool {
+	return s.MaxQueueLength > 0 && queued >= s.MaxQueueLength
+}
+
+// nameKey lowercases a name and reduces it to letters and digits separated by
+// single spaces, with the words sorted so "Li Ana" matches "Ana Li".
+func nameKey(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(fields)
+	return strings.Join(fields, " ")
+}
+
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// SimilarQueuedUser returns a queued user whose name closely matches name, so
+// staff can be warned before queueing the same walk-in twice.
+func (s *Store) SimilarQueuedUser(name string) *User {
+	key := nameKey(name)
+	if key == "" {
+		return nil
+	}
+	for _, u := range s.PendingUsers() {
+		other := nameKey(u.Name)
+		if other == key {
+			return s.UserByID(u.ID)
+		}
+		if len([]rune(key)) >= 5 && editDistance(key, other) <= 2 {
+			return s.UserByID(u.ID)
+		}
+	}
+	return nil
+}
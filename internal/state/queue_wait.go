@@ -0,0 +1,83 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// QueueWait is how long a queued user waited for a device: AssignedTime
+// minus CheckInTime. ok is false for sessions never queued or never seated,
+// and for ones the clock jumped across.
+func (e LogEntry) QueueWait() (time.Duration, bool) {
+	if e.AssignedTime.IsZero() || e.ClockSkew {
+		return 0, false
+	}
+	d := e.AssignedTime.Sub(e.CheckInTime)
+	if d < 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// WaitStats sums up the waits of the users seated from the queue. Users
+// taken out of the queue without a device are not counted.
+type WaitStats struct {
+	Seated int
+	Median time.Duration
+	P90    time.Duration
+}
+
+// QueueWaits works out WaitStats over entries, which should already have
+// test sessions left out.
+func QueueWaits(entries []LogEntry) WaitStats {
+	var waits []time.Duration
+	for _, entry := range entries {
+		if !entry.IsSession() {
+			continue
+		}
+		if d, ok := entry.QueueWait(); ok {
+			waits = append(waits, d)
+		}
+	}
+	stats := WaitStats{Seated: len(waits)}
+	if len(waits) == 0 {
+		return stats
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	stats.Median = percentile(waits, 50)
+	stats.P90 = percentile(waits, 90)
+	return stats
+}
+
+// PeriodWaits is WaitStats for the users seated in one period, e.g. a day
+// or a week, starting at Start.
+type PeriodWaits struct {
+	Start time.Time
+	WaitStats
+}
+
+// QueueWaitsBy works out WaitStats for each period entries were checked in
+// during, oldest first. periodStart maps a check-in time to the start of its
+// period, e.g. local midnight for days. Periods where no one was seated from
+// the queue are left out.
+func QueueWaitsBy(entries []LogEntry, periodStart func(time.Time) time.Time) []PeriodWaits {
+	groups := map[time.Time][]LogEntry{}
+	for _, entry := range entries {
+		if _, ok := entry.QueueWait(); ok && entry.IsSession() {
+			start := periodStart(entry.CheckInTime)
+			groups[start] = append(groups[start], entry)
+		}
+	}
+	periods := make([]PeriodWaits, 0, len(groups))
+	for start, group := range groups {
+		periods = append(periods, PeriodWaits{Start: start, WaitStats: QueueWaits(group)})
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods
+}
+
+// percentile is the nearest-rank p-th percentile of sorted.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+// seated is a session queued at checkIn and seated wait later.
+func seated(checkIn time.Time, wait time.Duration) LogEntry {
+	return LogEntry{CheckInTime: checkIn, AssignedTime: checkIn.Add(wait)}
+}
+
+func TestQueueWaits(t *testing.T) {
+	day := time.Date(2026, 3, 9, 10, 0, 0, 0, time.Local)
+	var entries []LogEntry
+	for i := 1; i <= 10; i++ {
+		entries = append(entries, seated(day, time.Duration(i)*time.Minute))
+	}
+	entries = append(entries,
+		LogEntry{CheckInTime: day}, // never queued
+		LogEntry{CheckInTime: day, ClockSkew: true, AssignedTime: day.Add(time.Hour)},
+		LogEntry{Kind: KindHeadcount, CheckInTime: day, AssignedTime: day.Add(time.Hour)},
+	)
+	got := QueueWaits(entries)
+	want := WaitStats{Seated: 10, Median: 5 * time.Minute, P90: 9 * time.Minute}
+	if got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if got := QueueWaits(nil); got != (WaitStats{}) {
+		t.Fatalf("got %+v with no entries", got)
+	}
+}
+
+func TestQueueWaitsBy(t *testing.T) {
+	monday := time.Date(2026, 3, 9, 10, 0, 0, 0, time.Local)
+	entries := []LogEntry{
+		seated(monday.AddDate(0, 0, 7), 20*time.Minute),
+		seated(monday, 2*time.Minute),
+		seated(monday.Add(time.Hour), 4*time.Minute),
+		seated(monday.AddDate(0, 0, 1), 10*time.Minute),
+		{CheckInTime: monday.AddDate(0, 0, 2)},
+	}
+	day := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	}
+	week := func(t time.Time) time.Time {
+		t = day(t)
+		return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	}
+
+	days := QueueWaitsBy(entries, day)
+	wantDays := []PeriodWaits{
+		{day(monday), WaitStats{Seated: 2, Median: 2 * time.Minute, P90: 4 * time.Minute}},
+		{day(monday.AddDate(0, 0, 1)), WaitStats{Seated: 1, Median: 10 * time.Minute, P90: 10 * time.Minute}},
+		{day(monday.AddDate(0, 0, 7)), WaitStats{Seated: 1, Median: 20 * time.Minute, P90: 20 * time.Minute}},
+	}
+	if len(days) != len(wantDays) {
+		t.Fatalf("got %d days, want %d: %+v", len(days), len(wantDays), days)
+	}
+	for i := range days {
+		if !days[i].Start.Equal(wantDays[i].Start) || days[i].WaitStats != wantDays[i].WaitStats {
+			t.Errorf("day %d: got %+v, want %+v", i, days[i], wantDays[i])
+		}
+	}
+
+	weeks := QueueWaitsBy(entries, week)
+	if len(weeks) != 2 || weeks[0].Seated != 3 || weeks[0].Median != 4*time.Minute || weeks[1].Seated != 1 {
+		t.Fatalf("got weeks %+v", weeks)
+	}
+	if !weeks[0].Start.Equal(day(monday)) {
+		t.Fatalf("first week starts %v, want %v", weeks[0].Start, day(monday))
+	}
+}
@@ -0,0 +1,57 @@
+package state
+
+import "time"
+
+// QuickStats is today at a glance, for the status bar.
+type QuickStats struct {
+	CheckIns int
+	// AverageSession is over today's completed sessions; 0 when none.
+	AverageSession time.Duration
+	Queued         int
+	LongestWait    time.Duration
+	// Waits are the waits of the users seated from the queue today.
+	Waits WaitStats
+	// BusiestHour is the hour of day with the most check-ins so far, or -1
+	// before the first one.
+	BusiestHour         int
+	BusiestHourCheckIns int
+}
+
+// QuickStats works out QuickStats from today's entries as already in memory
+// and the queue, without touching the disk. Test sessions are left out, as
+// in Stats.
+func (s *Store) QuickStats(entries []LogEntry, now time.Time) QuickStats {
+	stats := QuickStats{BusiestHour: -1}
+	var total time.Duration
+	completed := 0
+	var perHour [24]int
+	for _, entry := range StatsEntries(entries, false) {
+		if !entry.IsSession() {
+			continue
+		}
+		stats.CheckIns++
+		hour := entry.CheckInTime.Local().Hour()
+		perHour[hour]++
+		if perHour[hour] > stats.BusiestHourCheckIns {
+			stats.BusiestHour, stats.BusiestHourCheckIns = hour, perHour[hour]
+		}
+		if entry.CheckOutTime.IsZero() {
+			continue
+		}
+		if d, ok := entry.SessionDuration(entry.CheckOutTime); ok {
+			total += d
+			completed++
+		}
+	}
+	stats.Waits = QueueWaits(StatsEntries(entries, false))
+	if completed > 0 {
+		stats.AverageSession = total / time.Duration(completed)
+	}
+	for _, u := range s.PendingUsers() {
+		stats.Queued++
+		if wait := now.Sub(s.QueueTime(u.ID)); wait > stats.LongestWait {
+			stats.LongestWait = wait
+		}
+	}
+	return stats
+}
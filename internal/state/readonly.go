@@ -0,0 +1,20 @@
+package state
+
+import (
+	"os"
+	"time"
+)
+
+// DataModTime is the latest modification time of the files another desk
+// writes while this one is read-only. A change means Load would see new
+// state.
+func (s *Store) DataModTime() time.Time {
+	var latest time.Time
+	paths := []string{s.userDataFile(), s.queueFile(), s.maintenanceFile(), s.LogFilePathForDate(""), s.MemberFile}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
@@ -0,0 +1,40 @@
+package state
+
+import "time"
+
+const (
+	// recentCheckoutLimit is how many checkouts RecentCheckouts remembers.
+	recentCheckoutLimit = 5
+	// RecentCheckoutWindow is how long a checkout stays in the list.
+	RecentCheckoutWindow = 15 * time.Minute
+)
+
+// RecentCheckout is a user checked out from a device this session, kept so
+// staff can check them straight back in after a mistaken checkout.
+type RecentCheckout struct {
+	User     User
+	DeviceID int
+	Time     time.Time
+}
+
+func (s *Store) rememberCheckout(u User, deviceID int, now time.Time) {
+	recent := []RecentCheckout{{User: u, DeviceID: deviceID, Time: now}}
+	for _, r := range s.recentCheckouts {
+		if r.User.ID != u.ID && len(recent) < recentCheckoutLimit {
+			recent = append(recent, r)
+		}
+	}
+	s.recentCheckouts = recent
+}
+
+// RecentCheckouts returns the users checked out within RecentCheckoutWindow
+// who have not checked in again, newest first. It is kept in memory only.
+func (s *Store) RecentCheckouts(now time.Time) []RecentCheckout {
+	var recent []RecentCheckout
+	for _, r := range s.recentCheckouts {
+		if now.Sub(r.Time) <= RecentCheckoutWindow && s.UserByID(r.User.ID) == nil {
+			recent = append(recent, r)
+		}
+	}
+	return recent
+}
@@ -0,0 +1,330 @@
+package state
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RepeatWeekly marks a Reservation as a series that repeats on Weekdays.
+const RepeatWeekly = "weekly"
+
+// maxSeriesDays caps how far ahead a series is expanded when checking it for
+// conflicts.
+const maxSeriesDays = 366
+
+// Reservation books Devices from Start to End ("15:04") for Label. A one-off
+// is on Date. A series has Repeat set to RepeatWeekly and happens on each of
+// Weekdays from Date until Until, both inclusive, except the dates in Skip,
+// which were cancelled or changed one at a time.
+type Reservation struct {
+	ID      string `json:"id"`
+	Label   string `json:"label"`
+	Devices []int  `json:"devices"`
+	Date    string `json:"date"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+
+	Repeat   string         `json:"repeat,omitempty"`
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`
+	Until    string         `json:"until,omitempty"`
+	Skip     []string       `json:"skip,omitempty"`
+	// SeriesID, on a one-off, is the series it was split from when staff
+	// changed a single occurrence.
+	SeriesID string `json:"series_id,omitempty"`
+}
+
+// IsSeries reports whether r repeats.
+func (r Reservation) IsSeries() bool { return r.Repeat == RepeatWeekly }
+
+// OccursOn reports whether r happens on date, "2006-01-02".
+func (r Reservation) OccursOn(date string) bool {
+	if !r.IsSeries() {
+		return r.Date == date
+	}
+	if date < r.Date || (r.Until != "" && date > r.Until) || slices.Contains(r.Skip, date) {
+		return false
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	return err == nil && slices.Contains(r.Weekdays, day.Weekday())
+}
+
+// Occurrence is one day's instance of a reservation.
+type Occurrence struct {
+	Reservation Reservation
+	Date        string
+	Start       time.Time
+	End         time.Time
+}
+
+// occurrence is r on date, which must be one of its dates.
+func (r Reservation) occurrence(date string) Occurrence {
+	at := func(clock string) time.Time {
+		t, _ := time.ParseInLocation("2006-01-02 15:04", date+" "+clock, time.Local)
+		return t
+	}
+	return Occurrence{Reservation: r, Date: date, Start: at(r.Start), End: at(r.End)}
+}
+
+// Dates lists r's dates, oldest first, up to maxSeriesDays of a series.
+func (r Reservation) Dates() []string {
+	if !r.IsSeries() {
+		return []string{r.Date}
+	}
+	day, err := time.ParseInLocation("2006-01-02", r.Date, time.Local)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for i := 0; i < maxSeriesDays; i++ {
+		date := day.AddDate(0, 0, i).Format("2006-01-02")
+		if r.Until != "" && date > r.Until {
+			break
+		}
+		if r.OccursOn(date) {
+			out = append(out, date)
+		}
+	}
+	return out
+}
+
+// Validate checks r before it is saved.
+func (r Reservation) Validate() error {
+	if strings.TrimSpace(r.Label) == "" {
+		return fmt.Errorf("a reservation needs a label")
+	}
+	if len(r.Devices) == 0 {
+		return fmt.Errorf("reservation %s: pick at least one device", r.Label)
+	}
+	if _, err := time.Parse("2006-01-02", r.Date); err != nil {
+		return fmt.Errorf("reservation %s: bad date %q", r.Label, r.Date)
+	}
+	start, err := time.Parse("15:04", r.Start)
+	if err != nil {
+		return fmt.Errorf("reservation %s: bad start time %q", r.Label, r.Start)
+	}
+	end, err := time.Parse("15:04", r.End)
+	if err != nil {
+		return fmt.Errorf("reservation %s: bad end time %q", r.Label, r.End)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("reservation %s: ends before it starts", r.Label)
+	}
+	if r.IsSeries() {
+		if len(r.Weekdays) == 0 {
+			return fmt.Errorf("reservation %s: pick the weekdays it repeats on", r.Label)
+		}
+		if _, err := time.Parse("2006-01-02", r.Until); err != nil {
+			return fmt.Errorf("reservation %s: bad end date %q", r.Label, r.Until)
+		}
+		if r.Until < r.Date {
+			return fmt.Errorf("reservation %s: repeats until before it starts", r.Label)
+		}
+	}
+	return nil
+}
+
+// ReservationConflict is one occurrence of a new reservation that overlaps
+// an existing one on a shared device.
+type ReservationConflict struct {
+	Date     string
+	Devices  []int
+	Existing Reservation
+}
+
+func (c ReservationConflict) String() string {
+	devices := make([]string, len(c.Devices))
+	for i, id := range c.Devices {
+		devices[i] = fmt.Sprintf("%d", id)
+	}
+	return fmt.Sprintf("%s: device %s already reserved for %s (%s-%s)", c.Date, strings.Join(devices, ", "),
+		c.Existing.Label, c.Existing.Start, c.Existing.End)
+}
+
+func (s *Store) reservationsFile() string { return filepath.Join(s.LogDir, "reservations.json") }
+
+func (s *Store) loadReservations() {
+	s.reservations = nil
+	data, err := os.ReadFile(s.reservationsFile())
+	if err != nil {
+		return
+	}
+	_ = DecodeVersioned(s.reservationsFile(), data, "reservations", ReservationsVersion, &s.reservations)
+}
+
+func (s *Store) saveReservations() {
+	data, err := EncodeVersioned("reservations", ReservationsVersion, s.reservations)
+	if err != nil {
+		s.writeFailed("encoding reservations", err)
+		return
+	}
+	s.queueWrite("writing reservations", func() error {
+		if err := s.EnsureLogDir(); err != nil {
+			return err
+		}
+		if err := GuardRewrite(s.reservationsFile(), ReservationsVersion); err != nil {
+			return err
+		}
+		return WriteFileAtomic(s.reservationsFile(), data, 0o644)
+	}, nil)
+}
+
+// ReservationsOn expands the reservations, series included, that happen on
+// date, earliest first.
+func (s *Store) ReservationsOn(date string) []Occurrence {
+	var out []Occurrence
+	for _, r := range s.reservations {
+		if r.OccursOn(date) {
+			out = append(out, r.occurrence(date))
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// ReservationAt returns the reservation holding deviceID at now.
+func (s *Store) ReservationAt(deviceID int, now time.Time) (Occurrence, bool) {
+	for _, o := range s.ReservationsOn(now.Format("2006-01-02")) {
+		if slices.Contains(o.Reservation.Devices, deviceID) && !now.Before(o.Start) && now.Before(o.End) {
+			return o, true
+		}
+	}
+	return Occurrence{}, false
+}
+
+// ReservationConflicts checks every occurrence of r against the other
+// reservations and returns one conflict per clash.
+func (s *Store) ReservationConflicts(r Reservation) []ReservationConflict {
+	var out []ReservationConflict
+	for _, date := range r.Dates() {
+		mine := r.occurrence(date)
+		for _, other := range s.ReservationsOn(date) {
+			if other.Reservation.ID == r.ID || !mine.Start.Before(other.End) || !other.Start.Before(mine.End) {
+				continue
+			}
+			var shared []int
+			for _, id := range r.Devices {
+				if slices.Contains(other.Reservation.Devices, id) {
+					shared = append(shared, id)
+				}
+			}
+			if len(shared) > 0 {
+				out = append(out, ReservationConflict{Date: date, Devices: shared, Existing: other.Reservation})
+			}
+		}
+	}
+	return out
+}
+
+func newReservationID() string {
+	var b [6]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// SaveReservation adds r, or replaces the reservation with its ID. When an
+// occurrence clashes with another reservation nothing is saved and the
+// clashes are returned, unless skipConflicts, which leaves the clashing
+// dates out of a series instead. A one-off that clashes is never saved.
+func (s *Store) SaveReservation(r Reservation, skipConflicts bool) ([]ReservationConflict, error) {
+	if s.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	r.Label = strings.TrimSpace(r.Label)
+	if !r.IsSeries() {
+		r.Weekdays, r.Until, r.Skip = nil, "", nil
+	}
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	if conflicts := s.ReservationConflicts(r); len(conflicts) > 0 {
+		if !skipConflicts || !r.IsSeries() {
+			return conflicts, nil
+		}
+		for _, c := range conflicts {
+			if !slices.Contains(r.Skip, c.Date) {
+				r.Skip = append(r.Skip, c.Date)
+			}
+		}
+	}
+	if r.ID == "" {
+		r.ID = newReservationID()
+		s.reservations = append(s.reservations, r)
+	} else if i := s.reservationIndex(r.ID); i >= 0 {
+		s.reservations[i] = r
+	} else {
+		return nil, fmt.Errorf("reservation %s no longer exists", r.Label)
+	}
+	s.saveReservations()
+	s.changed()
+	return nil, nil
+}
+
+// SaveOccurrence changes the occurrence of series seriesID on date to r,
+// leaving the rest of the series as it was.
+func (s *Store) SaveOccurrence(seriesID, date string, r Reservation) ([]ReservationConflict, error) {
+	if s.ReadOnly {
+		return nil, ErrReadOnly
+	}
+	i := s.reservationIndex(seriesID)
+	if i < 0 {
+		return nil, fmt.Errorf("that reservation no longer exists")
+	}
+	series := s.reservations[i]
+	r.ID, r.Repeat, r.SeriesID = "", "", seriesID
+	// Check r without the occurrence it replaces.
+	s.reservations[i].Skip = append(slices.Clone(series.Skip), date)
+	conflicts, err := s.SaveReservation(r, false)
+	if err != nil || len(conflicts) > 0 {
+		s.reservations[i] = series
+		return conflicts, err
+	}
+	return nil, nil
+}
+
+// CancelReservation removes the reservation with id, a whole series included
+// along with the occurrences split from it.
+func (s *Store) CancelReservation(id string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	if s.reservationIndex(id) < 0 {
+		return nil
+	}
+	s.reservations = slices.DeleteFunc(s.reservations, func(r Reservation) bool {
+		return r.ID == id || r.SeriesID == id
+	})
+	s.saveReservations()
+	s.changed()
+	return nil
+}
+
+// CancelOccurrence cancels the series id on date only.
+func (s *Store) CancelOccurrence(id, date string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	i := s.reservationIndex(id)
+	if i < 0 || !s.reservations[i].IsSeries() {
+		return s.CancelReservation(id)
+	}
+	s.reservations[i].Skip = append(s.reservations[i].Skip, date)
+	s.saveReservations()
+	s.changed()
+	return nil
+}
+
+func (s *Store) reservationIndex(id string) int {
+	for i, r := range s.reservations {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
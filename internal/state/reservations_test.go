@@ -0,0 +1,34 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCancelSeriesRemovesChangedOccurrences(t *testing.T) {
+	s := newTestStore(t, "", Device{ID: 1, Type: "PC", Status: "free"}, Device{ID: 2, Type: "PC", Status: "free"})
+	series := Reservation{Label: "Chess club", Devices: []int{1}, Date: "2026-01-05", Start: "16:00", End: "18:00",
+		Repeat: RepeatWeekly, Weekdays: []time.Weekday{time.Monday}, Until: "2026-03-30"}
+	if conflicts, err := s.SaveReservation(series, false); err != nil || len(conflicts) > 0 {
+		t.Fatal(err, conflicts)
+	}
+	seriesID := s.reservations[0].ID
+	moved := Reservation{Label: "Chess club", Devices: []int{2}, Date: "2026-01-12", Start: "17:00", End: "19:00"}
+	if conflicts, err := s.SaveOccurrence(seriesID, "2026-01-12", moved); err != nil || len(conflicts) > 0 {
+		t.Fatal(err, conflicts)
+	}
+	other := Reservation{Label: "Exam prep", Devices: []int{2}, Date: "2026-01-13", Start: "10:00", End: "12:00"}
+	if conflicts, err := s.SaveReservation(other, false); err != nil || len(conflicts) > 0 {
+		t.Fatal(err, conflicts)
+	}
+
+	if err := s.CancelReservation(seriesID); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.reservations) != 1 || s.reservations[0].Label != "Exam prep" {
+		t.Fatalf("left %+v, want only the unrelated reservation", s.reservations)
+	}
+	if got := s.ReservationsOn("2026-01-12"); len(got) != 0 {
+		t.Fatalf("the changed occurrence is still on the 12th: %+v", got)
+	}
+}
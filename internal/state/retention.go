@@ -0,0 +1,431 @@
+package state
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// What a purge does to the logs past the retention window. Journals, repair
+// log lines and backup copies of logs past it are deleted either way; they
+// only matter on the day they are written. Occupancy samples and daily summaries hold counts,
+// no IDs, and are kept.
+const (
+	RetentionAnonymize = "anonymize"
+	RetentionDelete    = "delete"
+)
+
+// PurgeFile is one file a purge changes, with how many entries in it hold
+// personal data. Name is relative to the log folder; a day inside a monthly
+// archive is "archive/lounge-2006-01.zip:lounge-2006-01-02.json".
+type PurgeFile struct {
+	Name    string
+	Entries int
+}
+
+// PurgePlan is what Purge will do, for staff to check first.
+type PurgePlan struct {
+	Mode string
+	// Before is the first day kept as it is.
+	Before  string
+	Files   []PurgeFile
+	Entries int
+}
+
+func (s *Store) purgeLogFile() string { return filepath.Join(s.LogDir, "purges.log") }
+
+// retentionCutoff is the first day within months of now.
+func retentionCutoff(months int, now time.Time) string {
+	return now.AddDate(0, -months, 0).Format("2006-01-02")
+}
+
+// personalEntries counts the entries in entries that still name someone.
+func personalEntries(entries []LogEntry) int {
+	n := 0
+	for _, entry := range entries {
+		if !entryAnonymized(entry) {
+			n++
+		}
+	}
+	return n
+}
+
+func entryAnonymized(entry LogEntry) bool {
+	if entry.UserID == "" {
+		return entry.UserName == ""
+	}
+	return strings.HasPrefix(entry.UserID, anonymousIDPrefix) && (entry.UserName == "" || entry.UserName == entry.UserID)
+}
+
+// anonymizeEntries replaces each user ID and name with the ID's hash,
+// keeping the times, devices and everything else for long-term stats.
+func anonymizeEntries(a *Anonymizer, entries []LogEntry) {
+	for i := range entries {
+		if entryAnonymized(entries[i]) {
+			continue
+		}
+		if !strings.HasPrefix(entries[i].UserID, anonymousIDPrefix) {
+			entries[i].UserID = a.ID(entries[i].UserID)
+		}
+		entries[i].UserName = entries[i].UserID
+	}
+}
+
+// PlanPurge lists what a purge in mode of the data older than months would
+// change, without changing anything.
+func (s *Store) PlanPurge(months int, mode string, now time.Time) (PurgePlan, error) {
+	plan := PurgePlan{Mode: mode, Before: retentionCutoff(months, now)}
+	if months <= 0 {
+		return plan, fmt.Errorf("set how many months to keep personal data first")
+	}
+	if mode != RetentionAnonymize && mode != RetentionDelete {
+		return plan, fmt.Errorf("unknown retention mode %q", mode)
+	}
+	add := func(name string, entries int) {
+		if entries > 0 || mode == RetentionDelete {
+			plan.Files = append(plan.Files, PurgeFile{Name: name, Entries: entries})
+			plan.Entries += entries
+		}
+	}
+	files, err := os.ReadDir(s.LogDir)
+	if err != nil {
+		return plan, fmt.Errorf("read log dir: %w", err)
+	}
+	for _, f := range files {
+		if date := logDateFromFileName(f.Name()); date != "" && date < plan.Before {
+			entries, err := s.ReadLogEntries(date)
+			if err != nil {
+				return plan, err
+			}
+			add(f.Name(), personalEntries(entries))
+		}
+		if date := journalDateFromFileName(f.Name()); date != "" && date < plan.Before {
+			lines, err := countLines(filepath.Join(s.LogDir, f.Name()))
+			if err != nil {
+				return plan, err
+			}
+			plan.Files = append(plan.Files, PurgeFile{Name: f.Name(), Entries: lines})
+			plan.Entries += lines
+		}
+		if date := logBackupDate(f.Name()); date != "" && date < plan.Before {
+			entries := s.backupEntries(filepath.Join(s.LogDir, f.Name()))
+			plan.Files = append(plan.Files, PurgeFile{Name: f.Name(), Entries: entries})
+			plan.Entries += entries
+		}
+	}
+	err = s.eachArchivedLog(plan.Before, func(zipName, name string, data []byte) error {
+		var entries []LogEntry
+		if err := DecodeVersioned(name, data, "entries", LogVersion, &entries); err != nil && !errors.Is(err, ErrNewerFile) {
+			return fmt.Errorf("unmarshal archived log: %s: %w", name, err)
+		}
+		add(filepath.Join("archive", zipName)+":"+name, personalEntries(entries))
+		return nil
+	})
+	if err != nil {
+		return plan, err
+	}
+	if lines, err := s.oldRepairLines(plan.Before); err != nil {
+		return plan, err
+	} else if lines > 0 {
+		plan.Files = append(plan.Files, PurgeFile{Name: filepath.Base(s.repairLogFile()), Entries: lines})
+		plan.Entries += lines
+	}
+	return plan, nil
+}
+
+// Purge carries out plan: the logs before plan.Before are anonymized with a
+// or deleted, older journals and log backups are deleted and older repair
+// log lines dropped. Each run is recorded in purges.log.
+func (s *Store) Purge(plan PurgePlan, a *Anonymizer) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	if plan.Mode == RetentionAnonymize && a == nil {
+		return fmt.Errorf("anonymizing needs the export salt")
+	}
+	files, err := os.ReadDir(s.LogDir)
+	if err != nil {
+		return fmt.Errorf("read log dir: %w", err)
+	}
+	for _, f := range files {
+		path := filepath.Join(s.LogDir, f.Name())
+		if date := journalDateFromFileName(f.Name()); date != "" && date < plan.Before {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("remove journal: %w", err)
+			}
+			continue
+		}
+		if date := logBackupDate(f.Name()); date != "" && date < plan.Before {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("remove log backup: %w", err)
+			}
+			continue
+		}
+		date := logDateFromFileName(f.Name())
+		if date == "" || date >= plan.Before {
+			continue
+		}
+		if plan.Mode == RetentionDelete {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("remove log: %w", err)
+			}
+			continue
+		}
+		entries, err := s.ReadLogEntries(date)
+		if err != nil {
+			return err
+		}
+		if personalEntries(entries) == 0 {
+			continue
+		}
+		anonymizeEntries(a, entries)
+		// A newer build's log is left alone, as it is everywhere.
+		if err := s.writeLogEntries(date, entries); err != nil && !errors.Is(err, ErrNewerFile) {
+			return err
+		}
+	}
+	if err := s.purgeArchives(plan, a); err != nil {
+		return err
+	}
+	if err := s.purgeRepairLog(plan.Before); err != nil {
+		return err
+	}
+	return s.recordPurge(plan)
+}
+
+// purgeArchives rewrites each monthly archive holding days before
+// plan.Before, checking it the way archiving does before replacing it.
+func (s *Store) purgeArchives(plan PurgePlan, a *Anonymizer) error {
+	changed := map[string]map[string][]byte{}
+	dirty := map[string]bool{}
+	err := s.eachArchivedLog(plan.Before, func(zipName, name string, data []byte) error {
+		if changed[zipName] == nil {
+			contents, err := readZip(filepath.Join(s.archiveDir(), zipName))
+			if err != nil {
+				return err
+			}
+			changed[zipName] = contents
+		}
+		if plan.Mode == RetentionDelete {
+			delete(changed[zipName], name)
+			dirty[zipName] = true
+			return nil
+		}
+		var entries []LogEntry
+		if err := DecodeVersioned(name, data, "entries", LogVersion, &entries); err != nil {
+			// A newer build's log is left alone, as it is everywhere.
+			if errors.Is(err, ErrNewerFile) {
+				return nil
+			}
+			return fmt.Errorf("unmarshal archived log: %s: %w", name, err)
+		}
+		if personalEntries(entries) == 0 {
+			return nil
+		}
+		anonymizeEntries(a, entries)
+		encoded, err := EncodeVersioned("entries", LogVersion, entries)
+		if err != nil {
+			return fmt.Errorf("marshal log: %w", err)
+		}
+		changed[zipName][name] = encoded
+		dirty[zipName] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for zipName, contents := range changed {
+		if !dirty[zipName] {
+			continue
+		}
+		finalPath := filepath.Join(s.archiveDir(), zipName)
+		if len(contents) == 0 {
+			if err := os.Remove(finalPath); err != nil {
+				return fmt.Errorf("remove archive: %w", err)
+			}
+			continue
+		}
+		tmpPath := finalPath + ".tmp"
+		if err := writeZip(tmpPath, contents); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+		if err := verifyZip(tmpPath, contents); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+		if err := os.Rename(tmpPath, finalPath); err != nil {
+			os.Remove(tmpPath)
+			return fmt.Errorf("replace archive: %s: %w", finalPath, err)
+		}
+	}
+	return nil
+}
+
+// eachArchivedLog calls fn with every archived day's log before before.
+func (s *Store) eachArchivedLog(before string, fn func(zipName, name string, data []byte) error) error {
+	files, err := os.ReadDir(s.archiveDir())
+	if err != nil {
+		return nil
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".zip") {
+			continue
+		}
+		contents, err := readZip(filepath.Join(s.archiveDir(), f.Name()))
+		if err != nil {
+			return err
+		}
+		names := make([]string, 0, len(contents))
+		for name := range contents {
+			if date := logDateFromFileName(name); date != "" && date < before {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := fn(f.Name(), name, contents[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readZip(path string) (map[string][]byte, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %s: %w", path, err)
+	}
+	defer reader.Close()
+	contents := map[string][]byte{}
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %s: %w", path, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read archive: %s: %w", path, err)
+		}
+		contents[f.Name] = data
+	}
+	return contents, nil
+}
+
+// journalDateFromFileName returns the date of a journal file name, or "".
+func journalDateFromFileName(name string) string {
+	if !strings.HasPrefix(name, "journal-") || !strings.HasSuffix(name, ".log") {
+		return ""
+	}
+	date := strings.TrimSuffix(strings.TrimPrefix(name, "journal-"), ".log")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return ""
+	}
+	return date
+}
+
+// logBackupDate returns the date of a copy left beside a day's log or
+// journal, such as "lounge-2006-01-02.json.repair.bak" from a repair or
+// "lounge-2006-01-02.json.v1.bak" from a schema upgrade, or "".
+func logBackupDate(name string) string {
+	for _, suffix := range []string{".json.", ".log."} {
+		i := strings.Index(name, suffix)
+		if i < 0 {
+			continue
+		}
+		base := name[:i+len(suffix)-1]
+		if date := logDateFromFileName(base); date != "" {
+			return date
+		}
+		if date := journalDateFromFileName(base); date != "" {
+			return date
+		}
+	}
+	return ""
+}
+
+// backupEntries counts the entries naming someone in a log backup, or its
+// lines for a journal backup. A backup it cannot read counts as none; it is
+// deleted all the same.
+func (s *Store) backupEntries(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	if strings.Contains(filepath.Base(path), ".log.") {
+		return bytes.Count(data, []byte("\n"))
+	}
+	var entries []LogEntry
+	if err := DecodeVersioned(path, data, "entries", LogVersion, &entries); err != nil && !errors.Is(err, ErrNewerFile) {
+		return 0
+	}
+	return personalEntries(entries)
+}
+
+func countLines(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return bytes.Count(data, []byte("\n")), nil
+}
+
+// oldRepairLines counts the repair log lines from before before. Each line
+// starts with its RFC 3339 time, so the date is its first ten characters.
+func (s *Store) oldRepairLines(before string) (int, error) {
+	f, err := os.Open(s.repairLogFile())
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); len(line) >= 10 && line[:10] < before {
+			n++
+		}
+	}
+	return n, scanner.Err()
+}
+
+func (s *Store) purgeRepairLog(before string) error {
+	data, err := os.ReadFile(s.repairLogFile())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var kept []string
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line != "" && (len(line) < 10 || line[:10] >= before) {
+			kept = append(kept, line)
+		}
+	}
+	return WriteFileAtomic(s.repairLogFile(), []byte(strings.Join(kept, "")), 0o644)
+}
+
+// recordPurge notes the run in purges.log, which holds no personal data.
+func (s *Store) recordPurge(plan PurgePlan) error {
+	f, err := os.OpenFile(s.purgeLogFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("record purge: %w", err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s %s data before %s: %d entries in %d files\n",
+		time.Now().Format(time.RFC3339), plan.Mode, plan.Before, plan.Entries, len(plan.Files))
+	return err
+}
@@ -0,0 +1,58 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPurgeRemovesOldLogBackups(t *testing.T) {
+	for _, mode := range []string{RetentionAnonymize, RetentionDelete} {
+		t.Run(mode, func(t *testing.T) {
+			s := newTestStore(t, "")
+			if err := s.EnsureLogDir(); err != nil {
+				t.Fatal(err)
+			}
+			entries := []LogEntry{{UserName: "Ada Lovelace", UserID: "1001", PCID: 1, CheckInTime: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)}}
+			data, err := EncodeVersioned("entries", LogVersion, entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := map[string]bool{ // name: kept
+				"lounge-2024-01-02.json.repair.bak": false,
+				"lounge-2024-01-02.json.v1.bak":     false,
+				"journal-2024-01-02.log.v1.bak":     false,
+				"lounge-2026-01-02.json.repair.bak": true,
+				"notes.json.bak":                    true,
+			}
+			for name := range files {
+				if err := os.WriteFile(filepath.Join(s.LogDir, name), data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+			plan, err := s.PlanPurge(12, mode, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, kept := range files {
+				planned := slices.ContainsFunc(plan.Files, func(f PurgeFile) bool { return f.Name == name })
+				if planned == kept {
+					t.Errorf("%s: planned %v, want %v", name, planned, !kept)
+				}
+			}
+			if err := s.Purge(plan, &Anonymizer{salt: []byte("salt")}); err != nil {
+				t.Fatal(err)
+			}
+			for name, kept := range files {
+				_, err := os.Stat(filepath.Join(s.LogDir, name))
+				if exists := err == nil; exists != kept {
+					t.Errorf("%s: exists %v after the purge, want %v", name, exists, kept)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package state
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// KindRotation marks a log entry recording that staff rotated the players on
+// a console.
+const KindRotation = "rotation"
+
+// RotationDue returns when the players on console deviceID should be rotated.
+// The timer starts when the earliest current player sat down, not when they
+// joined the queue, or at the last rotation if that was later, so it stops
+// while the console is empty and starts fresh with the next player. ok is false when the timer is off or nobody is on it.
+func (s *Store) RotationDue(deviceID int) (due time.Time, ok bool) {
+	if s.ConsoleRotation <= 0 {
+		return time.Time{}, false
+	}
+	device := s.DeviceByID(deviceID)
+	if device == nil || device.Type != "Console" {
+		return time.Time{}, false
+	}
+	var start time.Time
+	for _, u := range s.UsersOnDevice(deviceID) {
+		if seated := u.SeatedTime(); start.IsZero() || seated.Before(start) {
+			start = seated
+		}
+	}
+	if start.IsZero() {
+		return time.Time{}, false
+	}
+	if rotated := s.lastRotation[deviceID]; rotated.After(start) {
+		start = rotated
+	}
+	return start.Add(s.ConsoleRotation), true
+}
+
+// MarkRotated restarts deviceID's rotation timer and logs the rotation, noting
+// how late it was so compliance can be checked later.
+func (s *Store) MarkRotated(deviceID int) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	due, ok := s.RotationDue(deviceID)
+	if !ok {
+		return newError(ErrDeviceNotFound, "console %d has no rotation timer running", deviceID)
+	}
+	now := time.Now()
+	if s.lastRotation == nil {
+		s.lastRotation = make(map[int]time.Time)
+	}
+	s.lastRotation[deviceID] = now
+
+	var names []string
+	for _, u := range s.UsersOnDevice(deviceID) {
+		names = append(names, u.Name)
+	}
+	note := "on time"
+	if late := now.Sub(due); late > 0 {
+		note = FormatDuration(late.Truncate(time.Minute)) + " late"
+	}
+	entry := LogEntry{Kind: KindRotation, CheckInTime: now.UTC(), PCID: deviceID, UserName: strings.Join(names, ", "), Note: note}
+	if err := s.appendLogEntry(entry); err != nil {
+		slog.Error("logging console rotation", "err", err)
+	}
+	s.changed()
+	return nil
+}
+
+// loadRotations restores today's rotation times so a restart does not reset
+// running timers.
+func (s *Store) loadRotations() {
+	s.lastRotation = make(map[int]time.Time)
+	entries, err := s.ReadDailyLogEntriesLocked()
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.Kind == KindRotation {
+			s.lastRotation[entry.PCID] = entry.CheckInTime
+		}
+	}
+}
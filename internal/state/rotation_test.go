@@ -0,0 +1,25 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRotationStartsWhenSeated(t *testing.T) {
+	s := newTestStore(t, "", Device{ID: 1, Type: "Console", Status: "free"})
+	s.ConsoleRotation = 30 * time.Minute
+	if err := s.RegisterWith("Ada Lovelace", "LOUNGE-1", 0, RegisterOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	s.UserByID("LOUNGE-1").CheckInTime = time.Now().Add(-time.Hour)
+	if err := s.AssignQueued("LOUNGE-1", 1); err != nil {
+		t.Fatal(err)
+	}
+	due, ok := s.RotationDue(1)
+	if !ok {
+		t.Fatal("no rotation timer running")
+	}
+	if want := s.UserByID("LOUNGE-1").AssignedTime.Add(s.ConsoleRotation); !due.Equal(want) {
+		t.Fatalf("rotation due %s, want %s, half an hour after being seated", due, want)
+	}
+}
@@ -0,0 +1,257 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Format versions this build writes. Files from before versioning are a bare
+// JSON array and count as version 0; a file with a higher version than these
+// came from a newer build and is read but never rewritten.
+const (
+	ActiveUsersVersion     = 1
+	LogVersion             = 1
+	LayoutVersion          = 1
+	ReservationsVersion    = 1
+	PreregistrationVersion = 1
+	ShiftNotesVersion      = 1
+)
+
+// ErrNewerFile is the kind of a *NewerFileError; match it with errors.Is.
+var ErrNewerFile = errors.New("file written by a newer version")
+
+// NewerFileError refuses to rewrite a file a newer build wrote, which would
+// drop the fields this build does not know.
+type NewerFileError struct {
+	Path      string
+	Version   int
+	Supported int
+}
+
+func (e *NewerFileError) Error() string {
+	return fmt.Sprintf("%s was written by a newer version of the lounge app (format %d; this version knows up to %d). "+
+		"Update the app before making changes, so nothing in it is lost", filepath.Base(e.Path), e.Version, e.Supported)
+}
+
+func (e *NewerFileError) Unwrap() error { return ErrNewerFile }
+
+// Extra holds the fields of a record this build does not know, so a newer
+// build's additions survive being loaded and saved again here.
+type Extra map[string]json.RawMessage
+
+// versionedFile is the layout of every versioned file: the version, then the
+// records under one key, e.g. {"version": 1, "users": [...]}.
+type versionedFile struct {
+	Version int `json:"version"`
+}
+
+// FileVersion returns the format version of a file's contents; a bare array,
+// as written before versioning, is version 0.
+func FileVersion(data []byte) int {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] == '[' {
+		return 0
+	}
+	var head versionedFile
+	if json.Unmarshal(data, &head) != nil {
+		return 0
+	}
+	return head.Version
+}
+
+// DecodeVersioned reads the records under key in data, or data itself when it
+// is a bare array, into items, a pointer to a slice. When the file is newer
+// than supported the records are still read and a *NewerFileError returned,
+// so callers can show the data without writing it back.
+func DecodeVersioned(path string, data []byte, key string, supported int, items any) error {
+	version := FileVersion(data)
+	if version == 0 {
+		return json.Unmarshal(data, items)
+	}
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	if records, ok := file[key]; ok {
+		if err := json.Unmarshal(records, items); err != nil {
+			return err
+		}
+	}
+	if version > supported {
+		return &NewerFileError{Path: path, Version: version, Supported: supported}
+	}
+	return nil
+}
+
+// EncodeVersioned writes items under key with the version, indented like the
+// other data files.
+func EncodeVersioned(key string, version int, items any) ([]byte, error) {
+	records, err := json.MarshalIndent(items, "  ", "  ")
+	if err != nil {
+		return nil, err
+	}
+	keyJSON, _ := json.Marshal(key)
+	return []byte(fmt.Sprintf("{\n  \"version\": %d,\n  %s: %s\n}\n", version, keyJSON, records)), nil
+}
+
+// CheckFileVersion returns a *NewerFileError when the file at path is newer
+// than supported; a missing file is fine.
+func CheckFileVersion(path string, supported int) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	if version := FileVersion(data); version > supported {
+		return &NewerFileError{Path: path, Version: version, Supported: supported}
+	}
+	return nil
+}
+
+// GuardRewrite runs before path is written in format supported. It refuses a
+// file from a newer build, and copies a file in an older format to
+// path.v<N>.bak first, so an upgrade can always be undone by hand. An existing
+// backup is kept, as it is the oldest copy.
+func GuardRewrite(path string, supported int) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) || (err == nil && len(bytes.TrimSpace(data)) == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	version := FileVersion(data)
+	switch {
+	case version > supported:
+		return &NewerFileError{Path: path, Version: version, Supported: supported}
+	case version == supported:
+		return nil
+	}
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if _, err := os.Stat(backup); err == nil {
+		return nil
+	}
+	if err := WriteFileAtomic(backup, data, 0o644); err != nil {
+		return fmt.Errorf("back up %s before upgrading it: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// UnmarshalWithExtra decodes data into v, a pointer to a struct type without
+// its own UnmarshalJSON, and returns the fields none of v's fields name.
+func UnmarshalWithExtra(data []byte, v any) (Extra, error) {
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range jsonFieldNames(reflect.TypeOf(v).Elem()) {
+		for key := range fields {
+			if strings.EqualFold(key, name) {
+				delete(fields, key)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return Extra(fields), nil
+}
+
+// MarshalWithExtra encodes v, a struct without its own MarshalJSON, followed
+// by the fields in extra, sorted by name.
+func MarshalWithExtra(v any, extra Extra) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for i, key := range keys {
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+		keyJSON, _ := json.Marshal(key)
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.Write(extra[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonFieldNames returns the names t's fields go by in JSON.
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// NewerFiles returns a *NewerFileError for each of active_users.json and
+// today's log that a newer build wrote, so the desk can stay read-only
+// instead of failing on every save.
+func (s *Store) NewerFiles() []error {
+	var errs []error
+	for _, check := range []struct {
+		path      string
+		supported int
+	}{{s.userDataFile(), ActiveUsersVersion}, {s.LogFilePathForDate(""), LogVersion}} {
+		if err := CheckFileVersion(check.path, check.supported); errors.Is(err, ErrNewerFile) {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// upgradeDataFiles rewrites active_users.json and today's log in the current
+// format when an older build wrote them; GuardRewrite keeps the originals.
+// Older logs are upgraded the same way if they are ever rewritten.
+func (s *Store) upgradeDataFiles() {
+	if data, err := os.ReadFile(s.userDataFile()); err == nil && len(bytes.TrimSpace(data)) > 0 &&
+		FileVersion(data) < ActiveUsersVersion {
+		if err := s.writeActiveUsers(s.ActiveUsers); err != nil {
+			slog.Error("upgrading user data file", "err", err)
+		}
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	p := s.LogFilePathForDate("")
+	data, err := os.ReadFile(p)
+	if err != nil || len(bytes.TrimSpace(data)) == 0 || FileVersion(data) >= LogVersion {
+		return
+	}
+	entries, err := s.ReadLogEntries(TodaysLogDate())
+	if err == nil {
+		err = s.writeLogEntries(TodaysLogDate(), entries)
+	}
+	if err != nil {
+		slog.Error("upgrading today's log", "err", err)
+	}
+}
@@ -0,0 +1,157 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeDataFile writes a data file as some other build left it.
+func writeDataFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// recordsIn returns the records under key in the versioned file at path,
+// each as its raw fields.
+func recordsIn(t *testing.T, path, key string) []map[string]json.RawMessage {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]json.RawMessage
+	if err := DecodeVersioned(path, data, key, 99, &records); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestFieldsFromTheFutureSurviveASave(t *testing.T) {
+	dir := t.TempDir()
+	checkIn := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)
+	writeDataFile(t, filepath.Join(dir, "active_users.json"), fmt.Sprintf(`{"version": 1, "users": [
+		{"id": "1001", "name": "Ada Lovelace", "checkin_time": %[1]q, "pc_id": 1, "session_id": "a", "seat_heater": "on"},
+		{"id": "1002", "name": "Alan Turing", "checkin_time": %[1]q, "pc_id": 2, "session_id": "b", "badge": {"colour": "teal"}}
+	]}`, checkIn))
+	s := NewStore(dir, "")
+	writeDataFile(t, s.LogFilePathForDate(""), fmt.Sprintf(`{"version": 1, "entries": [
+		{"user_name": "Ada Lovelace", "user_id": "1001", "pc_id": 1, "check_in_time": %[1]q, "session_id": "a", "weather": "rain"},
+		{"user_name": "Alan Turing", "user_id": "1002", "pc_id": 2, "check_in_time": %[1]q, "session_id": "b", "weather": "sun"}
+	]}`, checkIn))
+	s.LoadState()
+	t.Cleanup(s.FlushWrites)
+
+	if err := s.Checkout("1001"); err != nil {
+		t.Fatal(err)
+	}
+	s.FlushWrites()
+
+	users := recordsIn(t, s.userDataFile(), "users")
+	if len(users) != 1 || string(users[0]["id"]) != `"1002"` {
+		t.Fatalf("active users after checkout: %v", users)
+	}
+	var badge struct{ Colour string }
+	if json.Unmarshal(users[0]["badge"], &badge); badge.Colour != "teal" {
+		t.Errorf("Alan's badge is %s after the save, want it kept", users[0]["badge"])
+	}
+	entries := recordsIn(t, s.LogFilePathForDate(""), "entries")
+	if len(entries) != 2 {
+		t.Fatalf("log has %d entries, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		if entry["weather"] == nil {
+			t.Errorf("entry %s lost its weather", entry["user_id"])
+		}
+	}
+	if entries[0]["check_out_time"] == nil {
+		t.Error("Ada's entry was not closed")
+	}
+}
+
+func TestNewerFilesAreNotRewritten(t *testing.T) {
+	dir := t.TempDir()
+	newer := fmt.Sprintf(`{"version": 99, "users": [{"id": "1001", "name": "Ada Lovelace", "checkin_time": %q, "pc_id": 1, "seat_heater": "on"}]}`,
+		time.Now().UTC().Format(time.RFC3339Nano))
+	path := filepath.Join(dir, "active_users.json")
+	writeDataFile(t, path, newer)
+
+	s := NewStore(dir, "")
+	var writeErr error
+	s.OnWriteError = func(err error) { writeErr = err }
+	s.LoadState()
+	if u := s.UserByID("1001"); u == nil || u.PCID != 1 {
+		t.Fatalf("the newer file's user is not shown: %+v", u)
+	}
+	if errs := s.NewerFiles(); len(errs) != 1 || !errors.Is(errs[0], ErrNewerFile) {
+		t.Fatalf("NewerFiles() = %v, want active_users.json", errs)
+	}
+
+	s.Save()
+	s.FlushWrites()
+	var newerErr *NewerFileError
+	if !errors.As(writeErr, &newerErr) || newerErr.Version != 99 || newerErr.Supported != ActiveUsersVersion {
+		t.Errorf("save failed with %v, want a NewerFileError", writeErr)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Errorf("the newer file was rewritten:\n%s", data)
+	}
+}
+
+func TestOldFilesAreUpgradedWithABackup(t *testing.T) {
+	dir := t.TempDir()
+	old := fmt.Sprintf(`[{"id": "1001", "name": "Ada Lovelace", "checkin_time": %q, "pc_id": 1}]`,
+		time.Now().UTC().Format(time.RFC3339Nano))
+	path := filepath.Join(dir, "active_users.json")
+	writeDataFile(t, path, old)
+
+	s := NewStore(dir, "")
+	s.LoadState()
+	s.FlushWrites()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := FileVersion(data); v != ActiveUsersVersion {
+		t.Errorf("upgraded file is version %d, want %d", v, ActiveUsersVersion)
+	}
+	if users := recordsIn(t, path, "users"); len(users) != 1 {
+		t.Errorf("upgraded file has %d users, want 1", len(users))
+	}
+	if backup, err := os.ReadFile(path + ".v0.bak"); err != nil || string(backup) != old {
+		t.Errorf("backup is %q (%v), want the original", backup, err)
+	}
+}
+
+func TestExtraRoundTrip(t *testing.T) {
+	in := `{"id":"1001","name":"Ada Lovelace","checkin_time":"2026-03-09T10:00:00Z","pc_id":1,"mood":"happy","seat":{"row":3}}`
+	var u User
+	if err := json.Unmarshal([]byte(in), &u); err != nil {
+		t.Fatal(err)
+	}
+	if len(u.Extra) != 2 || string(u.Extra["mood"]) != `"happy"` {
+		t.Fatalf("extra fields = %v", u.Extra)
+	}
+	u.PCID = 2
+	out, err := json.Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"pc_id":2`, `"mood":"happy"`, `"seat":{"row":3}`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("%s lacks %s", out, want)
+		}
+	}
+	var again map[string]any
+	if err := json.Unmarshal(out, &again); err != nil {
+		t.Fatalf("round trip is not JSON: %v\n%s", err, out)
+	}
+}
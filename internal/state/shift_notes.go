@@ -0,0 +1,180 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ShiftNote is one entry in the staff shift log, e.g. "printer jammed
+// again". A note stays open until staff resolve it, and open notes are
+// carried into each new day's log.
+type ShiftNote struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	// By is who wrote the note, as the initials they typed.
+	By   string `json:"by"`
+	Text string `json:"text"`
+	// Resolved is when the note was marked resolved, and by whom; zero
+	// while it is open.
+	Resolved   time.Time `json:"resolved,omitempty"`
+	ResolvedBy string    `json:"resolved_by,omitempty"`
+}
+
+// Open reports whether the note still needs attention.
+func (n ShiftNote) Open() bool { return n.Resolved.IsZero() }
+
+// shiftLog is the notes of one day: those written that day and the open ones
+// carried in from the day before.
+type shiftLog struct {
+	date  string
+	notes []ShiftNote
+}
+
+const shiftNotesPrefix = "shiftnotes-"
+
+func (s *Store) shiftNotesFile(date string) string {
+	return filepath.Join(s.LogDir, shiftNotesPrefix+date+".json")
+}
+
+// loadShiftNotes reads today's shift log once per day. Until today's file is
+// written, it starts from the open notes of the latest earlier one.
+func (s *Store) loadShiftNotes(today string) error {
+	if s.shiftLog.date == today {
+		return nil
+	}
+	notes, err := s.readShiftNotes(today)
+	if errors.Is(err, os.ErrNotExist) {
+		notes, err = s.carriedShiftNotes(today)
+	}
+	if err != nil {
+		return err
+	}
+	s.shiftLog = shiftLog{date: today, notes: notes}
+	return nil
+}
+
+func (s *Store) readShiftNotes(date string) ([]ShiftNote, error) {
+	path := s.shiftNotesFile(date)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var notes []ShiftNote
+	if err := DecodeVersioned(path, data, "notes", ShiftNotesVersion, &notes); err != nil && !errors.Is(err, ErrNewerFile) {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	return notes, nil
+}
+
+// carriedShiftNotes is the open notes of the latest shift log before today.
+func (s *Store) carriedShiftNotes(today string) ([]ShiftNote, error) {
+	entries, err := os.ReadDir(s.LogDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	latest := ""
+	for _, entry := range entries {
+		date, ok := strings.CutPrefix(entry.Name(), shiftNotesPrefix)
+		if date, ok = strings.CutSuffix(date, ".json"); ok && date < today && date > latest {
+			latest = date
+		}
+	}
+	if latest == "" {
+		return nil, nil
+	}
+	notes, err := s.readShiftNotes(latest)
+	if err != nil {
+		return nil, err
+	}
+	var open []ShiftNote
+	for _, note := range notes {
+		if note.Open() {
+			open = append(open, note)
+		}
+	}
+	return open, nil
+}
+
+func (s *Store) saveShiftNotes() {
+	date, path := s.shiftLog.date, s.shiftNotesFile(s.shiftLog.date)
+	data, err := EncodeVersioned("notes", ShiftNotesVersion, s.shiftLog.notes)
+	if err != nil {
+		s.writeFailed("encoding shift notes", err)
+		return
+	}
+	s.queueWrite("writing shift notes for "+date, func() error {
+		if err := s.EnsureLogDir(); err != nil {
+			return err
+		}
+		if err := GuardRewrite(path, ShiftNotesVersion); err != nil {
+			return err
+		}
+		return WriteFileAtomic(path, data, 0o644)
+	}, nil)
+}
+
+// ShiftNotes returns today's shift log, open notes carried from earlier days
+// included, newest first.
+func (s *Store) ShiftNotes() ([]ShiftNote, error) {
+	if err := s.loadShiftNotes(TodaysLogDate()); err != nil {
+		return nil, err
+	}
+	notes := append([]ShiftNote(nil), s.shiftLog.notes...)
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].Time.After(notes[j].Time) })
+	return notes, nil
+}
+
+// AddShiftNote appends text to today's shift log, signed with by.
+func (s *Store) AddShiftNote(by, text string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	by, text = strings.TrimSpace(by), strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("the note is empty")
+	}
+	if by == "" {
+		return fmt.Errorf("enter your initials to sign the note")
+	}
+	if err := s.loadShiftNotes(TodaysLogDate()); err != nil {
+		return err
+	}
+	now := time.Now()
+	s.shiftLog.notes = append(s.shiftLog.notes, ShiftNote{ID: strconv.FormatInt(now.UnixNano(), 36), Time: now, By: by, Text: text})
+	s.saveShiftNotes()
+	s.changed()
+	return nil
+}
+
+// ResolveShiftNote marks the note with id resolved by by, so it is not
+// carried into tomorrow's log.
+func (s *Store) ResolveShiftNote(id, by string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	by = strings.TrimSpace(by)
+	if by == "" {
+		return fmt.Errorf("enter your initials to resolve the note")
+	}
+	if err := s.loadShiftNotes(TodaysLogDate()); err != nil {
+		return err
+	}
+	for i, note := range s.shiftLog.notes {
+		if note.ID != id {
+			continue
+		}
+		if note.Open() {
+			s.shiftLog.notes[i].Resolved, s.shiftLog.notes[i].ResolvedBy = time.Now(), by
+			s.saveShiftNotes()
+			s.changed()
+		}
+		return nil
+	}
+	return fmt.Errorf("that note is no longer in today's shift log")
+}
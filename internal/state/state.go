@@ -0,0 +1,886 @@
+// Package state holds the lounge's devices, active users, queue and members,
+// the check-in rules that mutate them, and their persistence. It has no GUI
+// dependencies so the rules can be exercised without a display.
+package state
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type User struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	RawName     string    `json:"raw_name,omitempty"` // as typed, when NormalizeName changed it
+	CheckInTime time.Time `json:"checkin_time"`
+	PCID        int       `json:"pc_id"`
+	Purpose     string    `json:"purpose,omitempty"`
+	Course      string    `json:"course,omitempty"`
+	// VisitorType is one of the Visitor* types, decided at check-in.
+	VisitorType string `json:"visitor_type,omitempty"`
+	Event       string `json:"event,omitempty"`
+	Source      string `json:"source,omitempty"`
+	// SessionID ties the user to their log entry; check-in times do not
+	// survive every JSON round trip exactly.
+	SessionID string `json:"session_id,omitempty"`
+	// ExcludeFromStats marks a staff or test session; see LogEntry.
+	ExcludeFromStats bool `json:"exclude_from_stats,omitempty"`
+	// LimitExempt marks a session SessionLimit does not apply to; see
+	// SessionLimitExempt.
+	LimitExempt bool `json:"limit_exempt,omitempty"`
+	// WaitingFor, on a queued user, is the device or type they will only be
+	// seated on; nil means any device.
+	WaitingFor *WaitingFor `json:"waiting_for,omitempty"`
+	// Email and Phone are copied from the member at check-in so staff can
+	// reach the user in an emergency. They stay in active_users.json, which
+	// never leaves this computer; log entries do not carry them.
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+	// AwaySince is when the user marked themselves away from their device
+	// at the kiosk; see MarkAway. Zero when they are not.
+	AwaySince time.Time `json:"away_since,omitempty"`
+	// AssignedTime is when a queued user was seated; zero for check-ins
+	// straight onto a device and while queued. See SeatedTime.
+	AssignedTime time.Time `json:"assigned_time,omitempty"`
+	// Extra keeps fields written by a newer build.
+	Extra Extra `json:"-"`
+}
+
+// SeatedTime is when u sat down at a device: AssignedTime for a user seated
+// from the queue, CheckInTime otherwise. Time spent queued is not time on a
+// device.
+func (u User) SeatedTime() time.Time {
+	if !u.AssignedTime.IsZero() {
+		return u.AssignedTime
+	}
+	return u.CheckInTime
+}
+
+type plainUser User
+
+func (u User) MarshalJSON() ([]byte, error) { return MarshalWithExtra(plainUser(u), u.Extra) }
+
+func (u *User) UnmarshalJSON(data []byte) error {
+	extra, err := UnmarshalWithExtra(data, (*plainUser)(u))
+	u.Extra = extra
+	return err
+}
+
+type Device struct {
+	ID     int
+	Type   string
+	Status string
+	UserID string
+	Icon   string
+	// Label is the inventory's name for the device; see Name.
+	Label string
+	// AssetTag and SerialNumber are the university's asset records, kept
+	// for the inventory audit only.
+	AssetTag     string
+	SerialNumber string
+}
+
+// Name is what dialogs call the device: its label, or its type and number
+// like "PC 7".
+func (d Device) Name() string {
+	if d.Label != "" {
+		return d.Label
+	}
+	return fmt.Sprintf("%s %d", d.Type, d.ID)
+}
+
+type Member struct {
+	Name          string
+	RawName       string // as first entered, when NormalizeName changed it
+	ID            string
+	Email         string
+	StudentNumber string
+	PhoneNumber   string
+	Notes         string // staff-only; never include in exports meant for members
+	Flagged       bool   // serious note, shown as a red banner
+	EmailReceipts bool   // opted in to a receipt email at each checkout
+	// ExcludeFromStats marks a staff account whose sessions are tests.
+	ExcludeFromStats bool
+	// NoSessionLimit exempts the member's sessions from SessionLimit, e.g.
+	// stream production staff or the varsity team.
+	NoSessionLimit bool
+	// LastCourse is the course of the member's last check-in, preselected
+	// at their next one.
+	LastCourse string
+}
+
+type LogEntry struct {
+	UserName     string    `json:"user_name"`
+	UserID       string    `json:"user_id"`
+	PCID         int       `json:"pc_id"`
+	CheckInTime  time.Time `json:"check_in_time"`
+	CheckOutTime time.Time `json:"check_out_time,omitempty"`
+	UsageTime    string    `json:"usage_time,omitempty"`
+	Purpose      string    `json:"purpose,omitempty"`
+	// Course is the course code the session counts towards for credit
+	// hours; empty for sessions that count towards none.
+	Course string `json:"course,omitempty"`
+	// VisitorType is one of the Visitor* types, decided at check-in; empty
+	// in logs written before it was recorded (see ClassifyVisitors).
+	VisitorType string `json:"visitor_type,omitempty"`
+	// Event is the club event running when the session began; empty for
+	// open hours.
+	Event string `json:"event,omitempty"`
+	// Source is the check-in path that created the session, one of the
+	// Source* constants; empty in logs written before it was recorded.
+	Source    string `json:"source,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	// DeviceHistory lists the moves staff made to other devices during the
+	// session, oldest first; PCID is the last device. The session stays one
+	// entry, so its usage is the continuous total.
+	DeviceHistory []DeviceSwitch `json:"device_history,omitempty"`
+	// AssignedTime is when a queued user was seated; zero for check-ins
+	// straight onto a device and for users never seated. CheckInTime stays
+	// the queue check-in, so the wait is the difference; see QueueWait.
+	AssignedTime time.Time `json:"assigned_time,omitempty"`
+	// Kind is empty for sessions, KindHeadcount for lounge headcount changes
+	// and KindRotation for console rotations. Both use CheckInTime as the
+	// time of the change; headcount entries leave the user fields empty.
+	Kind      string `json:"kind,omitempty"`
+	Headcount int    `json:"headcount,omitempty"`
+	Change    int    `json:"change,omitempty"`
+	// Note explains how a session ended when staff did not close it, e.g.
+	// ExpiredFromQueueNote.
+	Note string `json:"note,omitempty"`
+	// ClockSkew marks a session the wall clock jumped across; its check-in
+	// and checkout times cannot be trusted to subtract.
+	ClockSkew bool `json:"clock_skew,omitempty"`
+	// Imported marks a session brought in from the pre-app spreadsheet by
+	// ImportHistoricalSessions.
+	Imported bool `json:"imported,omitempty"`
+	// ExcludeFromStats marks a staff or test session. It stays in the log
+	// and exports; StatsEntries leaves it out of the totals.
+	ExcludeFromStats bool `json:"exclude_from_stats,omitempty"`
+	// LimitExempt marks a session SessionLimit did not apply to.
+	LimitExempt bool `json:"limit_exempt,omitempty"`
+	// RemovalReason is why staff took the user out of the queue, e.g.
+	// "No-show"; NotAVisit leaves such a session out of visit counts.
+	RemovalReason string `json:"removal_reason,omitempty"`
+	NotAVisit     bool   `json:"not_a_visit,omitempty"`
+	// Extra keeps fields written by a newer build.
+	Extra Extra `json:"-"`
+}
+
+type plainLogEntry LogEntry
+
+// DeviceSwitch is one move of a session from device From to device To.
+type DeviceSwitch struct {
+	From int       `json:"from"`
+	To   int       `json:"to"`
+	At   time.Time `json:"at"`
+}
+
+// DevicePath describes the devices a session used in order, like "3 → 9";
+// "" for a session never seated.
+func (e LogEntry) DevicePath() string {
+	if e.PCID == 0 {
+		return ""
+	}
+	if len(e.DeviceHistory) == 0 {
+		return strconv.Itoa(e.PCID)
+	}
+	path := []string{strconv.Itoa(e.DeviceHistory[0].From)}
+	for _, move := range e.DeviceHistory {
+		path = append(path, strconv.Itoa(move.To))
+	}
+	return strings.Join(path, " → ")
+}
+
+func (e LogEntry) MarshalJSON() ([]byte, error) { return MarshalWithExtra(plainLogEntry(e), e.Extra) }
+
+func (e *LogEntry) UnmarshalJSON(data []byte) error {
+	extra, err := UnmarshalWithExtra(data, (*plainLogEntry)(e))
+	e.Extra = extra
+	return err
+}
+
+// Kinds of rule violation returned by Store operations; match them with
+// errors.Is.
+var (
+	ErrUserAlreadyActive = errors.New("user already active")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUserNotQueued     = errors.New("user not queued")
+	ErrDeviceNotFound    = errors.New("device not found")
+	ErrDeviceBusy        = errors.New("device busy")
+	// ErrQueueFull is returned when the queue is at MaxQueueLength. Staff can
+	// override it; self-service paths must not.
+	ErrQueueFull = errors.New("queue is full")
+	// ErrCooldown is returned when a user checks in again too soon after
+	// their last session while others are queued. Staff can override it.
+	ErrCooldown = errors.New("cooldown not over")
+	// ErrDailyCap is returned when a user has used up today's time on a
+	// device type. Staff can override it unless DailyCapsBlock is set.
+	ErrDailyCap = errors.New("daily cap reached")
+	// ErrReadOnly is returned by every change while the store is read-only.
+	ErrReadOnly = errors.New("read-only: another desk is writing to this data folder")
+)
+
+// Error is a rule violation with a human-readable message. It unwraps to one
+// of the Err* kinds.
+type Error struct {
+	Kind error
+	Msg  string
+}
+
+func (e *Error) Error() string { return e.Msg }
+func (e *Error) Unwrap() error { return e.Kind }
+
+func newError(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Store owns the lounge state. It is not safe for concurrent use except for
+// the log writes it starts itself; callers drive it from the UI goroutine.
+type Store struct {
+	LogDir     string
+	MemberFile string
+
+	Devices     []Device
+	ActiveUsers []User
+	Members     []Member
+	// TypeIcons maps a device type to its icon base name.
+	TypeIcons map[string]string
+	// MemberChanges are the members added or edited since MemberFile was
+	// last written; see FlushUnsavedMembers. They are also kept in the
+	// member journal in case the app stops before the flush.
+	MemberChanges []MemberChange
+	// MemberWarnings lists problems LoadMembers worked around, such as a
+	// missing header or skipped rows.
+	MemberWarnings []string
+	// MembersLoaded is false until LoadMembers or ApplyMembers has run, so
+	// a caller loading them in the background can tell an empty list from
+	// one still on its way.
+	MembersLoaded bool
+	// membersToAdd are unknown IDs checked in before the members loaded;
+	// ApplyMembers appends those still missing.
+	membersToAdd []Member
+
+	// MaxQueueLength caps the queue; 0 means unlimited.
+	MaxQueueLength int
+	// Cooldown is the minimum gap between a user's sessions while the queue
+	// is non-empty; 0 disables it.
+	Cooldown time.Duration
+	// QueueTimeout removes queued users who have waited this long (plus
+	// QueueExpiryGrace); 0 disables it.
+	QueueTimeout time.Duration
+	// ConsoleRotation is how long players may stay on a console before
+	// staff rotate them; 0 disables the rotation timer.
+	ConsoleRotation time.Duration
+	// CleaningCooldown is how long a device freed by a checkout is marked
+	// for wiping down before the next user; 0 disables it.
+	CleaningCooldown time.Duration
+	// AwayPeriod is how long a user's away flag lasts; 0 disables marking
+	// away.
+	AwayPeriod time.Duration
+	// SessionLimit is how long a session is expected to last, for the
+	// availability forecast; 0 disables the forecast.
+	SessionLimit time.Duration
+	// SessionWarning is how long before SessionLimit a session counts as
+	// ending soon; 0 turns the warning off.
+	SessionWarning time.Duration
+	// LimitExemptPurposes are the purposes, matched ignoring case, whose
+	// sessions SessionLimit does not apply to, e.g. "Club practice".
+	LimitExemptPurposes []string
+	// DailyCaps limits each user's time per device type per day, e.g.
+	// "Console" to 3h; a missing or zero cap is unlimited. DailyCapsBlock
+	// refuses check-ins over a cap instead of asking staff to confirm.
+	DailyCaps      map[string]time.Duration
+	DailyCapsBlock bool
+	// StudentIDPattern, when set, is what IDs must match apart from the
+	// desk's own GeneratedIDPrefix ones.
+	StudentIDPattern *regexp.Regexp
+	// Validators vet every check-in in order; see ConfigureValidators.
+	Validators []CheckInValidator
+	// GuestVisitLimit is how many days a guest (a GeneratedIDPrefix ID) may
+	// visit per term before registering; 0 means unlimited. Terms are the
+	// configured terms; outside them guests are not counted.
+	GuestVisitLimit int
+	Terms           []Term
+	// Closures are the days the lounge is closed; check-ins on them need
+	// staff to confirm, and the open-day averages skip them.
+	Closures []Closure
+	// Event names the club event the lounge is reserved for. New sessions
+	// are tagged with it; empty means open hours.
+	Event string
+	// LoungeCount is how many visitors are in the lounge without a device.
+	LoungeCount int
+
+	// ReadOnly stops the store writing anything, for watching a data folder
+	// another desk holds the lock on. Changes return ErrReadOnly.
+	ReadOnly bool
+
+	// OnChange is called after devices or active users change.
+	OnChange func()
+	// OnCheckout is called after Checkout ends a session, with the user as
+	// they were and the device they left.
+	OnCheckout func(u User, deviceID int, at time.Time)
+	// OnLogChange receives today's entries whenever the daily log is
+	// rewritten. It may be called from a background goroutine.
+	OnLogChange func(entries []LogEntry)
+	// OnWriteError is called with every data file write that failed. It may
+	// be called from a background goroutine.
+	OnWriteError func(err error)
+	// Do runs f on the goroutine that drives the store, for the background
+	// writer to report back. When nil, results wait for FlushWrites.
+	Do func(f func())
+
+	queue         []QueueEntry
+	memberColumns memberColumnLayout
+	// guestConversions maps converted guest IDs to their member IDs.
+	guestConversions map[string]string
+	guestHistory     guestHistory
+	streakHistory    streakHistory
+	// memberKeys holds memberSearchKey for each of Members, in order.
+	memberKeys     []string
+	membersVersion int
+	// duplicateMembers is FindDuplicateMembers as of the last indexMembers.
+	duplicateMembers []DuplicateMembers
+	// checkouts is each user's last checkout today, for CooldownUntil.
+	checkouts lastCheckouts
+	logMu     sync.Mutex
+
+	// The background writer; see queueWrite. writeMu guards the rest.
+	writeMu      sync.Mutex
+	writeQueue   []writeOp
+	writing      bool
+	writeResults []writeResult
+	writes       sync.WaitGroup
+
+	lastClockCheck time.Time
+	// clockFlagged is the open sessions, by user and session ID, that
+	// CheckClock has already flagged in the log.
+	clockFlagged map[string]bool
+	// lastRotation is when staff last rotated each console, from today's log.
+	lastRotation map[int]time.Time
+	// cleaningUntil is when each device's cleaning cooldown ends.
+	cleaningUntil map[int]time.Time
+	// recentCheckouts backs RecentCheckouts, newest first.
+	recentCheckouts []RecentCheckout
+	// reservations are the bookings, series stored once; see ReservationsOn.
+	reservations []Reservation
+	// prereg is the event sign-up list, nil when none is loaded.
+	prereg *Preregistration
+	// shiftLog is today's shift notes, loaded on first use; see ShiftNotes.
+	shiftLog shiftLog
+}
+
+func NewStore(logDir, memberFile string) *Store {
+	return &Store{
+		LogDir:        logDir,
+		MemberFile:    memberFile,
+		memberColumns: defaultMemberColumns(),
+		Validators:    append([]CheckInValidator{}, BuiltinValidators...),
+	}
+}
+
+func (s *Store) userDataFile() string { return filepath.Join(s.LogDir, "active_users.json") }
+
+func (s *Store) EnsureLogDir() error { return os.MkdirAll(s.LogDir, 0o755) }
+
+// changed tells OnChange about a mutation and, at debug level, logs which
+// mutator made it.
+func (s *Store) changed() {
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		by := "unknown"
+		if pc, _, _, ok := runtime.Caller(1); ok {
+			by = runtime.FuncForPC(pc).Name()
+			by = by[strings.LastIndex(by, ".")+1:]
+		}
+		slog.Debug("state changed", "by", by, "active", len(s.ActiveUsers), "queued", len(s.PendingUsers()))
+	}
+	if s.OnChange != nil {
+		s.OnChange()
+	}
+}
+
+// Load builds the device list from the inventory and restores active users, members and the
+// queue from disk.
+func (s *Store) Load() {
+	s.LoadState()
+	s.LoadMembers()
+}
+
+// LoadState is Load without the members, which are the slow part on a large
+// member file; load those with ReadMembers and ApplyMembers.
+func (s *Store) LoadState() {
+	s.EnsureLogDir()
+	s.Devices = []Device{}
+	for _, spec := range s.loadInventory() {
+		s.Devices = append(s.Devices, Device{ID: spec.ID, Type: spec.Type, Status: "free", Icon: spec.Icon, Label: spec.Label,
+			AssetTag: spec.AssetTag, SerialNumber: spec.SerialNumber})
+	}
+
+	s.ActiveUsers = []User{}
+	if _, err := os.Stat(s.userDataFile()); !os.IsNotExist(err) {
+		if data, readErr := os.ReadFile(s.userDataFile()); readErr == nil {
+			// A newer build's file is still shown; Save refuses to rewrite it.
+			decodeErr := DecodeVersioned(s.userDataFile(), data, "users", ActiveUsersVersion, &s.ActiveUsers)
+			if decodeErr == nil || errors.Is(decodeErr, ErrNewerFile) {
+				for i := range s.ActiveUsers {
+					userRecord := &s.ActiveUsers[i]
+					for j := range s.Devices {
+						if s.Devices[j].ID == userRecord.PCID {
+							s.Devices[j].Status = "occupied"
+							if s.Devices[j].Type == "PC" {
+								s.Devices[j].UserID = userRecord.ID
+							}
+							break
+						}
+					}
+				}
+			} else {
+				s.ActiveUsers = []User{}
+			}
+		}
+	}
+	s.loadMaintenance()
+	s.loadGuestConversions()
+	s.loadQueue()
+	s.loadLoungeCount()
+	s.loadRotations()
+	s.loadReservations()
+	s.loadPreregistration()
+	if !s.ReadOnly {
+		s.upgradeDataFiles()
+	}
+}
+
+// Save queues a write of the active users as they are now, with check-in
+// times in UTC, which then marks the journal up to here as applied.
+func (s *Store) Save() {
+	if s.ReadOnly {
+		return
+	}
+	users := append([]User(nil), s.ActiveUsers...)
+	s.queueWrite("writing user data file", func() error {
+		if err := s.EnsureLogDir(); err != nil {
+			return err
+		}
+		if err := s.writeActiveUsers(users); err != nil {
+			return err
+		}
+		return s.writeJournal(JournalEntry{Time: time.Now(), Action: journalCommit})
+	}, nil)
+}
+
+// writeFailed logs a data file write that failed and passes it to
+// OnWriteError, so no lost write is only in the application log.
+func (s *Store) writeFailed(msg string, err error) {
+	slog.Error(msg, "err", err)
+	if s.OnWriteError != nil {
+		s.OnWriteError(fmt.Errorf("%s: %w", msg, err))
+	}
+}
+
+// writeActiveUsers writes active as active_users.json in the current format,
+// unless a newer build wrote it.
+func (s *Store) writeActiveUsers(active []User) error {
+	if err := GuardRewrite(s.userDataFile(), ActiveUsersVersion); err != nil {
+		return err
+	}
+	users := make([]User, len(active))
+	for i, u := range active {
+		u.CheckInTime = u.CheckInTime.UTC()
+		users[i] = u
+	}
+	data, err := EncodeVersioned("users", ActiveUsersVersion, users)
+	if err != nil {
+		return fmt.Errorf("encode user data: %w", err)
+	}
+	return WriteFileAtomic(s.userDataFile(), data, 0o644)
+}
+
+func (s *Store) occupyDevice(device *Device, userID string) {
+	device.Status = "occupied"
+	delete(s.cleaningUntil, device.ID)
+	if device.Type == "PC" {
+		device.UserID = userID
+	}
+}
+
+// releaseDevice frees deviceID once nobody is left on it; consoles stay
+// occupied while other players remain.
+func (s *Store) releaseDevice(deviceID int) {
+	device := s.DeviceByID(deviceID)
+	if device == nil {
+		return
+	}
+	if device.Type == "PC" {
+		device.Status = "free"
+		device.UserID = ""
+		return
+	}
+	if len(s.ActiveUserIDsOnDevice(device.ID)) == 0 {
+		device.Status = "free"
+	} else {
+		device.Status = "occupied"
+	}
+}
+
+func (s *Store) removeActiveUser(userID string) {
+	for i := range s.ActiveUsers {
+		if s.ActiveUsers[i].ID == userID {
+			s.ActiveUsers = append(s.ActiveUsers[:i], s.ActiveUsers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Store) UserByID(id string) *User {
+	for i := range s.ActiveUsers {
+		if s.ActiveUsers[i].ID == id {
+			return &s.ActiveUsers[i]
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeviceByID(id int) *Device {
+	for i := range s.Devices {
+		if s.Devices[i].ID == id {
+			return &s.Devices[i]
+		}
+	}
+	return nil
+}
+
+// Contact returns how to reach u in an emergency: the details copied at
+// check-in, or the member's as loaded now when u checked in before the
+// members had loaded. Both are empty when none are on file.
+func (s *Store) Contact(u User) (email, phone string) {
+	if u.Email != "" || u.Phone != "" {
+		return u.Email, u.Phone
+	}
+	if member := s.MemberByID(u.ID); member != nil {
+		return member.Email, member.PhoneNumber
+	}
+	return "", ""
+}
+
+// UsersOnDevice returns the active users seated on deviceID.
+func (s *Store) UsersOnDevice(deviceID int) []User {
+	users := []User{}
+	for _, user := range s.ActiveUsers {
+		if user.PCID == deviceID {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+func (s *Store) ActiveUserIDsOnDevice(deviceID int) []string {
+	ids := []string{}
+	for _, u := range s.ActiveUsers {
+		if u.PCID == deviceID {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids
+}
+
+// Check-in sources recorded on each session.
+const (
+	SourceDesk    = "desk"   // staff Check In dialog
+	SourceMap     = "map"    // clicking a free device
+	SourceQueue   = "queue"  // Queue Check-In form
+	SourcePrereg  = "prereg" // Pre-registered panel
+	SourceKiosk   = "kiosk"
+	SourceAPI     = "api"
+	SourceUnknown = "unknown" // sessions logged before sources were recorded
+)
+
+// RegisterOptions are the optional parts of a check-in.
+type RegisterOptions struct {
+	Purpose string
+	// Course is the course code the session earns credit hours for.
+	Course string
+	// Source is the check-in path, one of the Source* constants.
+	Source string
+	// Waived lists the validators whose warnings staff have confirmed
+	// waiving, by ID.
+	Waived []string
+	// WalkIn marks someone who is not taking part in the running event.
+	WalkIn bool
+	// ExcludeFromStats marks a staff or test session. Staff accounts'
+	// sessions are marked whether or not it is set.
+	ExcludeFromStats bool
+	// WaitingFor, when queueing, is the device or type the user waits for.
+	WaitingFor *WaitingFor
+}
+
+// newSessionID returns a random ID for a new session.
+func newSessionID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Register checks a user in on deviceID, or queues them when deviceID is 0.
+// purpose may be empty.
+func (s *Store) Register(name, userID string, deviceID int, purpose string) error {
+	return s.RegisterWith(name, userID, deviceID, RegisterOptions{Purpose: purpose})
+}
+
+// RegisterWith is Register with options, for callers that record their
+// source or have confirmed waiving a rule. The Validators run first; see
+// RegisterConfirming for asking staff about warnings.
+func (s *Store) RegisterWith(name, userID string, deviceID int, opts RegisterOptions) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	rawName := name
+	name = NormalizeName(name)
+	if rawName == name {
+		rawName = ""
+	}
+	ctx := CheckInContext{Store: s, Name: name, UserID: userID, Options: opts, Now: time.Now()}
+	if deviceID != 0 {
+		if ctx.Device = s.DeviceByID(deviceID); ctx.Device == nil {
+			return newError(ErrDeviceNotFound, "device ID %d does not exist", deviceID)
+		}
+	}
+	if err := s.validateCheckIn(ctx); err != nil {
+		return err
+	}
+
+	if device := ctx.Device; device != nil {
+		if device.Type == "PC" {
+			device.Status = "occupied"
+			device.UserID = userID
+		} else {
+			device.Status = "occupied"
+		}
+	}
+
+	newUser := User{ID: userID, Name: name, RawName: rawName, CheckInTime: time.Now(), PCID: deviceID, Purpose: opts.Purpose, Course: opts.Course, Event: s.Event, Source: opts.Source, SessionID: newSessionID()}
+	if member := s.MemberByID(userID); opts.ExcludeFromStats || (member != nil && member.ExcludeFromStats) {
+		newUser.ExcludeFromStats = true
+	}
+	if member := s.MemberByID(userID); member != nil {
+		newUser.Email, newUser.Phone = member.Email, member.PhoneNumber
+	}
+	newUser.LimitExempt = s.SessionLimitExempt(newUser)
+	newUser.VisitorType = s.VisitorType(userID)
+	if deviceID == 0 {
+		newUser.WaitingFor = opts.WaitingFor
+	}
+	s.journal(JournalCheckIn, newUser, deviceID)
+	s.ActiveUsers = append(s.ActiveUsers, newUser)
+	if deviceID == 0 {
+		s.ensureQueueEntry(userID, newUser.CheckInTime)
+	}
+
+	switch {
+	case !s.MembersLoaded:
+		s.membersToAdd = append(s.membersToAdd, Member{Name: name, RawName: rawName, ID: userID})
+	case s.MemberByID(userID) == nil:
+		s.addUnsavedMember(Member{Name: name, RawName: rawName, ID: userID})
+	}
+	s.markPreregArrived(userID, newUser.CheckInTime)
+	s.Save()
+	s.RecordLogEventAsync(true, newUser, deviceID, "")
+	s.changed()
+	return nil
+}
+
+// Checkout ends userID's session and frees their device.
+func (s *Store) Checkout(userID string) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	u := s.UserByID(userID)
+	if u == nil {
+		return newError(ErrUserNotFound, "user ID %s not found", userID)
+	}
+	idx := -1
+	for i, v := range s.ActiveUsers {
+		if v.ID == userID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return fmt.Errorf("user %s consistency error", userID)
+	}
+	checkedOut := *u
+	devID := u.PCID
+	now := time.Now()
+	s.journal(JournalCheckOut, checkedOut, devID)
+
+	s.ActiveUsers = append(s.ActiveUsers[:idx], s.ActiveUsers[idx+1:]...)
+	s.releaseDevice(devID)
+	s.startCleaning(devID, now)
+	s.rememberCheckout(checkedOut, devID, now)
+
+	s.Save()
+	s.RecordLogEventAsync(false, checkedOut, devID, "")
+	s.changed()
+	if s.OnCheckout != nil {
+		s.OnCheckout(checkedOut, devID, now)
+	}
+	return nil
+}
+
+// RemoveQueued drops a user from the queue without seating them.
+func (s *Store) RemoveQueued(userID string) error {
+	return s.removeQueued(userID, "", QueueRemoval{})
+}
+
+// RemoveQueuedFor is RemoveQueued recording why on the closed log entry.
+func (s *Store) RemoveQueuedFor(userID string, removal QueueRemoval) error {
+	return s.removeQueued(userID, "", removal)
+}
+
+// removeQueued is RemoveQueuedFor with a note for the closed log entry.
+func (s *Store) removeQueued(userID, note string, removal QueueRemoval) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	u := s.UserByID(userID)
+	if u == nil {
+		return newError(ErrUserNotFound, "user ID %s not found", userID)
+	}
+	if u.PCID != 0 {
+		return newError(ErrUserNotQueued, "user %s is assigned to device %d", userID, u.PCID)
+	}
+	idx := -1
+	for i := range s.ActiveUsers {
+		if s.ActiveUsers[i].ID == userID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return fmt.Errorf("user %s consistency error", userID)
+	}
+	removed := *u
+	s.journal(JournalRemove, removed, 0)
+	s.ActiveUsers = append(s.ActiveUsers[:idx], s.ActiveUsers[idx+1:]...)
+	s.removeQueueEntry(userID)
+	s.Save()
+	s.recordLogEventAsync(false, removed, 0, note, removal)
+	s.changed()
+	return nil
+}
+
+// AssignQueued seats a queued user on deviceID and fills in the device on
+// their open log entry. Warnings from Assignable refuse it; see
+// AssignQueuedConfirming.
+func (s *Store) AssignQueued(userID string, deviceID int) error {
+	return s.AssignQueuedWith(userID, deviceID, RegisterOptions{})
+}
+
+// AssignQueuedWith is AssignQueued with the Assignable warnings in
+// opts.Waived confirmed.
+func (s *Store) AssignQueuedWith(userID string, deviceID int, opts RegisterOptions) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	u := s.UserByID(userID)
+	if u == nil {
+		return newError(ErrUserNotFound, "user ID %s not found", userID)
+	}
+	if u.PCID != 0 {
+		return newError(ErrUserAlreadyActive, "user %s already on device %d", userID, u.PCID)
+	}
+	if err := s.Assignable(userID, deviceID, opts, time.Now()); err != nil {
+		return err
+	}
+	d := s.DeviceByID(deviceID)
+	s.journal(JournalAssign, *u, deviceID)
+	s.occupyDevice(d, userID)
+	assigned := time.Now().UTC()
+	u.PCID = deviceID
+	u.AssignedTime = assigned
+	u.WaitingFor = nil
+	s.Save()
+
+	session := *u
+	s.updateDailyLog(func(entries []LogEntry) {
+		if i := openSessionIndex(entries, session); i >= 0 {
+			entries[i].PCID = deviceID
+			entries[i].AssignedTime = assigned
+		}
+	})
+
+	s.removeQueueEntry(userID)
+	s.changed()
+	return nil
+}
+
+// SwitchDevice moves an active user to a free device, keeping their session.
+func (s *Store) SwitchDevice(userID string, targetDeviceID int) error {
+	return s.SwitchDeviceWith(userID, targetDeviceID, RegisterOptions{})
+}
+
+// SwitchDeviceWith is SwitchDevice with the Assignable warnings in
+// opts.Waived let through, as AssignQueuedWith does.
+func (s *Store) SwitchDeviceWith(userID string, targetDeviceID int, opts RegisterOptions) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	user := s.UserByID(userID)
+	if user == nil {
+		return newError(ErrUserNotFound, "user %s not found", userID)
+	}
+	if user.PCID == targetDeviceID {
+		return newError(ErrUserAlreadyActive, "user %s is already on device %d", userID, targetDeviceID)
+	}
+	if err := s.Assignable(userID, targetDeviceID, opts, time.Now()); err != nil {
+		return err
+	}
+	target := s.DeviceByID(targetDeviceID)
+	if target.Status != "free" {
+		return newError(ErrDeviceBusy, "device %d is not available", targetDeviceID)
+	}
+
+	s.journal(JournalSwitch, *user, targetDeviceID)
+	originalDeviceID := user.PCID
+	user.PCID = targetDeviceID
+	s.releaseDevice(originalDeviceID)
+	s.occupyDevice(target, user.ID)
+
+	s.Save()
+
+	session := *user
+	move := DeviceSwitch{From: originalDeviceID, To: targetDeviceID, At: time.Now().UTC()}
+	s.updateDailyLog(func(entries []LogEntry) {
+		if i := openSessionIndex(entries, session); i >= 0 {
+			entries[i].PCID = targetDeviceID
+			if move.From != 0 {
+				entries[i].DeviceHistory = append(entries[i].DeviceHistory, move)
+			}
+		}
+	})
+
+	s.changed()
+	return nil
+}
+
+// PendingUsers returns the queued users in queue order.
+func (s *Store) PendingUsers() []User {
+	out := []User{}
+	for _, u := range s.ActiveUsers {
+		if u.PCID == 0 {
+			out = append(out, u)
+		}
+	}
+	return s.orderedQueuedUsers(out)
+}
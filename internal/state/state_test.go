@@ -0,0 +1,506 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logEntriesToday waits for the queued log writes and reads today's log.
+func logEntriesToday(t *testing.T, s *Store) []LogEntry {
+	t.Helper()
+	s.FlushWrites()
+	entries, err := s.ReadDailyLogEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func newSessionStore(t *testing.T) *Store {
+	t.Helper()
+	return newTestStore(t, "Student Name,Student Number\nAda Lovelace,1001\nAlan Turing,1002\nGrace Hopper,1003\n",
+		Device{ID: 1, Type: "PC", Status: "free"},
+		Device{ID: 2, Type: "PC", Status: "free"},
+		Device{ID: 17, Type: "Console", Status: "free"},
+	)
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name   string
+		device int
+		want   error
+	}{
+		{"on a free PC", 1, nil},
+		{"on a console", 17, nil},
+		{"into the queue", 0, nil},
+		{"on a missing device", 99, ErrDeviceNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSessionStore(t)
+			err := s.Register("ada lovelace", "1001", tt.device, "Gaming")
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				if len(s.ActiveUsers) != 0 {
+					t.Fatalf("refused check-in left %+v active", s.ActiveUsers)
+				}
+				return
+			}
+			u := s.UserByID("1001")
+			if u == nil || u.PCID != tt.device || u.Name != "Ada Lovelace" || u.Purpose != "Gaming" || u.SessionID == "" {
+				t.Fatalf("got user %+v", u)
+			}
+			if tt.device == 0 {
+				if pending := s.PendingUsers(); len(pending) != 1 || pending[0].ID != "1001" {
+					t.Fatalf("queue is %+v", pending)
+				}
+			} else if d := s.DeviceByID(tt.device); d.Status != "occupied" {
+				t.Fatalf("device is %s", d.Status)
+			}
+			entries := logEntriesToday(t, s)
+			if len(entries) != 1 || entries[0].UserID != "1001" || entries[0].PCID != tt.device || !entries[0].CheckOutTime.IsZero() {
+				t.Fatalf("log is %+v", entries)
+			}
+		})
+	}
+}
+
+func TestRegisterTwiceIsRefused(t *testing.T) {
+	s := newSessionStore(t)
+	if err := s.Register("Ada Lovelace", "1001", 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("Ada Lovelace", "1001", 2, ""); !errors.Is(err, ErrUserAlreadyActive) {
+		t.Fatalf("got %v, want ErrUserAlreadyActive", err)
+	}
+	if err := s.Register("Alan Turing", "1002", 1, ""); !errors.Is(err, ErrDeviceBusy) {
+		t.Fatalf("got %v, want ErrDeviceBusy", err)
+	}
+}
+
+func TestCheckout(t *testing.T) {
+	s := newSessionStore(t)
+	for _, id := range []string{"1001", "1002"} {
+		if err := s.Register("", id, 17, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Register("Grace Hopper", "1003", 1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Checkout("1003"); err != nil {
+		t.Fatal(err)
+	}
+	if d := s.DeviceByID(1); d.Status != "free" || d.UserID != "" {
+		t.Fatalf("PC after checkout: %+v", d)
+	}
+	if err := s.Checkout("1001"); err != nil {
+		t.Fatal(err)
+	}
+	if d := s.DeviceByID(17); d.Status != "occupied" {
+		t.Fatalf("console with a player left is %s", d.Status)
+	}
+	if err := s.Checkout("1002"); err != nil {
+		t.Fatal(err)
+	}
+	if d := s.DeviceByID(17); d.Status != "free" {
+		t.Fatalf("empty console is %s", d.Status)
+	}
+	if err := s.Checkout("1002"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("second checkout: got %v, want ErrUserNotFound", err)
+	}
+	if len(s.ActiveUsers) != 0 {
+		t.Fatalf("still active: %+v", s.ActiveUsers)
+	}
+	for _, entry := range logEntriesToday(t, s) {
+		if entry.CheckOutTime.IsZero() || entry.UsageTime == "" {
+			t.Errorf("session left open in the log: %+v", entry)
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	s := newSessionStore(t)
+	for _, id := range []string{"1001", "1002", "1003"} {
+		if err := s.Register("", id, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	order := func() []string {
+		var ids []string
+		for _, u := range s.PendingUsers() {
+			ids = append(ids, u.ID)
+		}
+		return ids
+	}
+	if got := order(); len(got) != 3 || got[0] != "1001" || got[2] != "1003" {
+		t.Fatalf("queue order %v, want check-in order", got)
+	}
+	if err := s.MoveQueued("1003", 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := order(); got[0] != "1003" {
+		t.Fatalf("after moving 1003 up the queue is %v", got)
+	}
+
+	if err := s.AssignQueued("1003", 1); err != nil {
+		t.Fatal(err)
+	}
+	if u := s.UserByID("1003"); u.PCID != 1 || u.AssignedTime.IsZero() {
+		t.Fatalf("assigned user: %+v", u)
+	}
+	if err := s.AssignQueued("1003", 2); !errors.Is(err, ErrUserAlreadyActive) {
+		t.Fatalf("assigning a seated user: got %v", err)
+	}
+	if err := s.RemoveQueued("1003"); !errors.Is(err, ErrUserNotQueued) {
+		t.Fatalf("removing a seated user from the queue: got %v", err)
+	}
+
+	if err := s.RemoveQueued("1001"); err != nil {
+		t.Fatal(err)
+	}
+	if got := order(); len(got) != 1 || got[0] != "1002" {
+		t.Fatalf("queue after removal %v, want [1002]", got)
+	}
+	entries := logEntriesToday(t, s)
+	for _, entry := range entries {
+		switch entry.UserID {
+		case "1001":
+			if entry.CheckOutTime.IsZero() {
+				t.Errorf("removed user's session is still open: %+v", entry)
+			}
+		case "1003":
+			if entry.PCID != 1 || entry.AssignedTime.IsZero() {
+				t.Errorf("assignment not logged: %+v", entry)
+			}
+		}
+	}
+}
+
+func TestSessionsFoundAfterRestart(t *testing.T) {
+	s := newSessionStore(t)
+	if err := s.Register("Ada Lovelace", "1001", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("Alan Turing", "1002", 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	s.FlushWrites()
+
+	again := NewStore(s.LogDir, s.MemberFile)
+	again.LoadMembers()
+	again.LoadState()
+	t.Cleanup(again.FlushWrites)
+	if len(again.ActiveUsers) != 2 {
+		t.Fatalf("after the restart %d users are active", len(again.ActiveUsers))
+	}
+	// JSON keeps neither the monotonic clock nor, on some platforms, the
+	// last digits; the session ID must be enough to find the log entry.
+	for i := range again.ActiveUsers {
+		again.ActiveUsers[i].CheckInTime = again.ActiveUsers[i].CheckInTime.Round(time.Millisecond).Add(time.Microsecond)
+	}
+	pc := 0
+	for _, d := range again.Devices {
+		if d.Type == "PC" && d.Status == "free" {
+			pc = d.ID
+			break
+		}
+	}
+	if err := again.AssignQueued("1001", pc); err != nil {
+		t.Fatal(err)
+	}
+	if err := again.Checkout("1002"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logEntriesToday(t, again)
+	if len(entries) != 2 {
+		t.Fatalf("log has %d entries, want the 2 sessions: %+v", len(entries), entries)
+	}
+	for _, entry := range entries {
+		switch entry.UserID {
+		case "1001":
+			if entry.PCID != pc || entry.AssignedTime.IsZero() || !entry.CheckOutTime.IsZero() {
+				t.Errorf("assignment did not find its session: %+v", entry)
+			}
+		case "1002":
+			if entry.PCID != 1 || entry.CheckOutTime.IsZero() {
+				t.Errorf("checkout did not find its session: %+v", entry)
+			}
+		}
+		if entry.SessionID == "" {
+			t.Errorf("entry without a session ID: %+v", entry)
+		}
+	}
+}
+
+func TestReplayedCheckInsAreLogged(t *testing.T) {
+	s := newSessionStore(t)
+	// The app died after journaling both check-ins but before saving;
+	// only Alan's log entry had been written.
+	ada := User{ID: "1001", Name: "Ada Lovelace", CheckInTime: time.Now(), PCID: 1, SessionID: newSessionID()}
+	alan := User{ID: "1002", Name: "Alan Turing", CheckInTime: time.Now(), PCID: 2, SessionID: newSessionID()}
+	s.journal(JournalCheckIn, ada, ada.PCID)
+	s.journal(JournalCheckIn, alan, alan.PCID)
+	s.RecordLogEventAsync(true, alan, alan.PCID, "")
+	s.FlushWrites()
+
+	again := NewStore(s.LogDir, s.MemberFile)
+	again.LoadMembers()
+	again.LoadState()
+	t.Cleanup(again.FlushWrites)
+	pending, err := again.UnappliedJournal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again.ReplayJournal(pending)
+	if len(again.ActiveUsers) != 2 {
+		t.Fatalf("after the replay %d users are active", len(again.ActiveUsers))
+	}
+	for _, id := range []string{"1001", "1002"} {
+		if err := again.Checkout(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries := logEntriesToday(t, again)
+	if len(entries) != 2 {
+		t.Fatalf("log has %d entries, want the 2 sessions: %+v", len(entries), entries)
+	}
+	for _, entry := range entries {
+		if entry.CheckOutTime.IsZero() {
+			t.Errorf("checkout did not find its session: %+v", entry)
+		}
+	}
+}
+
+func TestRefusalsLeaveStateAlone(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(s *Store) error
+		want error
+	}{
+		{"assign an unknown user", func(s *Store) error { return s.AssignQueued("9999", 1) }, ErrUserNotFound},
+		{"assign to a missing device", func(s *Store) error { return s.AssignQueued("1002", 99) }, ErrDeviceNotFound},
+		{"assign to a busy PC", func(s *Store) error { return s.AssignQueued("1002", 1) }, ErrDeviceBusy},
+		{"remove an unknown user", func(s *Store) error { return s.RemoveQueued("9999") }, ErrUserNotFound},
+		{"remove a seated user", func(s *Store) error { return s.RemoveQueued("1001") }, ErrUserNotQueued},
+		{"check out an unknown user", func(s *Store) error { return s.Checkout("9999") }, ErrUserNotFound},
+		{"register a seated user", func(s *Store) error { return s.Register("Ada Lovelace", "1001", 2, "") }, ErrUserAlreadyActive},
+		{"register a queued user", func(s *Store) error { return s.Register("Alan Turing", "1002", 0, "") }, ErrUserAlreadyActive},
+		{"register on a busy PC", func(s *Store) error { return s.Register("Grace Hopper", "1003", 1, "") }, ErrDeviceBusy},
+		{"read-only register", func(s *Store) error { s.ReadOnly = true; return s.Register("Grace Hopper", "1003", 2, "") }, ErrReadOnly},
+		{"read-only checkout", func(s *Store) error { s.ReadOnly = true; return s.Checkout("1001") }, ErrReadOnly},
+		{"read-only assign", func(s *Store) error { s.ReadOnly = true; return s.AssignQueued("1002", 2) }, ErrReadOnly},
+		{"read-only remove", func(s *Store) error { s.ReadOnly = true; return s.RemoveQueued("1002") }, ErrReadOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSessionStore(t)
+			if err := s.Register("Ada Lovelace", "1001", 1, ""); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Register("Alan Turing", "1002", 0, ""); err != nil {
+				t.Fatal(err)
+			}
+			users, devices := slices.Clone(s.ActiveUsers), slices.Clone(s.Devices)
+			logged := len(logEntriesToday(t, s))
+
+			err := tt.op(s)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			var ruleErr *Error
+			if tt.want != ErrReadOnly && !errors.As(err, &ruleErr) {
+				t.Fatalf("got %T, want a *Error with a message for staff", err)
+			}
+			if !slices.EqualFunc(users, s.ActiveUsers, func(a, b User) bool { return a.ID == b.ID && a.PCID == b.PCID }) {
+				t.Fatalf("active users changed to %+v", s.ActiveUsers)
+			}
+			if !slices.Equal(devices, s.Devices) {
+				t.Fatalf("devices changed to %+v", s.Devices)
+			}
+			if got := len(logEntriesToday(t, s)); got != logged {
+				t.Fatalf("log has %d entries, want %d", got, logged)
+			}
+		})
+	}
+}
+
+func TestCheckoutFromTheQueue(t *testing.T) {
+	s := newSessionStore(t)
+	if err := s.Register("Ada Lovelace", "1001", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Checkout("1001"); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.ActiveUsers) != 0 || len(s.PendingUsers()) != 0 {
+		t.Fatalf("still active %+v, queued %+v", s.ActiveUsers, s.PendingUsers())
+	}
+	for _, d := range s.Devices {
+		if d.Status != "free" {
+			t.Fatalf("device %d is %s", d.ID, d.Status)
+		}
+	}
+	if entries := logEntriesToday(t, s); len(entries) != 1 || entries[0].CheckOutTime.IsZero() {
+		t.Fatalf("log is %+v", entries)
+	}
+}
+
+func TestRegisterAddsUnknownMembers(t *testing.T) {
+	s := newSessionStore(t)
+	if err := s.Register("Katherine Johnson", "2001", 2, ""); !errors.Is(err, ErrUnknownMember) {
+		t.Fatalf("got %v, want ErrUnknownMember until staff confirm", err)
+	}
+	if err := s.RegisterWith("  katherine   johnson ", "2001", 2, RegisterOptions{Waived: []string{"unknown-member"}}); err != nil {
+		t.Fatal(err)
+	}
+	member := s.MemberByID("2001")
+	if member == nil || member.Name != "Katherine Johnson" || len(s.MemberChanges) != 1 {
+		t.Fatalf("member %+v, %d unsaved changes", member, len(s.MemberChanges))
+	}
+}
+
+func TestHeadcount(t *testing.T) {
+	s := newSessionStore(t)
+	if err := s.Register("", "1001", 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register("", "1002", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	s.AdjustLoungeCount(3)
+	got := s.Headcount()
+	if got != (Headcount{OnDevices: 1, Queued: 1, Lounge: 3}) || got.Total() != 5 {
+		t.Errorf("headcount %+v (total %d), want 1 on devices, 1 queued, 3 lounge", got, got.Total())
+	}
+}
+
+func TestReloadLoungeCountAtNewDay(t *testing.T) {
+	s := newSessionStore(t)
+	s.AdjustLoungeCount(3)
+	s.ReloadLoungeCount()
+	if s.LoungeCount != 3 {
+		t.Fatalf("reloading today's count gave %d, want 3", s.LoungeCount)
+	}
+	// A new day starts on a log with no headcount entries.
+	if err := os.Remove(s.LogFilePathForDate("")); err != nil {
+		t.Fatal(err)
+	}
+	s.ReloadLoungeCount()
+	if s.LoungeCount != 0 {
+		t.Errorf("the new day starts with %d lounge visitors, want 0", s.LoungeCount)
+	}
+}
+
+func TestAwayAndTestMarksAreJournaled(t *testing.T) {
+	s := newSessionStore(t)
+	if err := s.Register("Ada Lovelace", "1001", 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	away := time.Now().Add(-time.Minute).Round(0)
+	if err := s.MarkAway("1001", away); err != nil {
+		t.Fatal(err)
+	}
+	u := *s.UserByID("1001")
+	entry := LogEntry{UserID: u.ID, CheckInTime: u.CheckInTime, SessionID: u.SessionID}
+	s.FlushWrites()
+	if err := s.SetExcludeFromStats(TodaysLogDate(), entry, true); err != nil {
+		t.Fatal(err)
+	}
+	s.FlushWrites()
+
+	var actions []string
+	for _, path := range s.journalFiles() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var e JournalEntry
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatal(err)
+			}
+			if e.Action != journalCommit {
+				actions = append(actions, e.Action)
+			}
+		}
+	}
+	if want := []string{JournalCheckIn, JournalAway, JournalTest}; !slices.Equal(actions, want) {
+		t.Errorf("journal has %v, want %v", actions, want)
+	}
+
+	// Replaying the marks onto the user as they were at check-in restores
+	// them.
+	again := newSessionStore(t)
+	again.ReplayJournal([]JournalEntry{
+		{Time: u.CheckInTime, Action: JournalCheckIn, UserID: "1001", Name: "Ada Lovelace", Device: 1, Session: u.SessionID},
+		{Time: away, Action: JournalAway, UserID: "1001", Device: 1},
+		{Time: away, Action: JournalTest, UserID: "1001", Test: true},
+	})
+	if got := again.UserByID("1001"); got == nil || !got.AwaySince.Equal(away) || !got.ExcludeFromStats {
+		t.Errorf("replayed user %+v, want away since %s and excluded", got, away)
+	}
+	again.ReplayJournal([]JournalEntry{{Time: time.Now(), Action: JournalBack, UserID: "1001"}})
+	if got := again.UserByID("1001"); !got.AwaySince.IsZero() {
+		t.Errorf("replaying back left the user away since %s", got.AwaySince)
+	}
+}
+
+func TestConfigureValidatorsKeepsRequired(t *testing.T) {
+	s := newSessionStore(t)
+	s.ConfigureValidators([]string{"unknown-member", "duplicate-id", "cooldown"})
+	on := make(map[string]bool)
+	for _, v := range s.Validators {
+		on[v.ID] = true
+	}
+	if !on["unknown-member"] || !on["duplicate-id"] || on["cooldown"] {
+		t.Errorf("validators on after switching some off: %v", on)
+	}
+	err := s.Register("Nobody Known", "5555", 1, "")
+	var warning *ValidationWarning
+	if !errors.As(err, &warning) || warning.Validator != "unknown-member" {
+		t.Fatalf("checking in an unknown ID: got %v, want the unknown-member warning", err)
+	}
+}
+
+func TestCooldownUntil(t *testing.T) {
+	s := newSessionStore(t)
+	s.Cooldown = 30 * time.Minute
+	if err := s.Register("", "1001", 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Checkout("1001"); err != nil {
+		t.Fatal(err)
+	}
+	s.FlushWrites()
+	now := time.Now()
+	until := s.CooldownUntil("1001", now)
+	if until.Before(now.Add(29*time.Minute)) || until.After(now.Add(30*time.Minute)) {
+		t.Fatalf("cooldown until %s, want about 30 minutes from now", until)
+	}
+	if other := s.CooldownUntil("1002", now); !other.IsZero() {
+		t.Errorf("1002 never checked in but cools down until %s", other)
+	}
+
+	// Later keystrokes use the cache, not the disk.
+	if err := os.Remove(s.LogFilePathForDate("")); err != nil {
+		t.Fatal(err)
+	}
+	if again := s.CooldownUntil("1001", now); !again.Equal(until) {
+		t.Errorf("second lookup gave %s, want %s", again, until)
+	}
+	if over := s.CooldownUntil("1001", now.Add(time.Hour)); !over.IsZero() {
+		t.Errorf("cooldown still running an hour later: %s", over)
+	}
+}
@@ -0,0 +1,244 @@
+package state
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// StatsExportVersion is StatsExport's schema_version. Fields are only ever
+// added within a version; renaming or removing one bumps it.
+const StatsExportVersion = 1
+
+// StatsExport is the Stats figures for a range of days as published for a
+// website: the JSON written by the stats command and served at /stats.
+// Hours are decimal hours; dates are "2006-01-02".
+type StatsExport struct {
+	SchemaVersion int       `json:"schema_version"`
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	Generated     time.Time `json:"generated"`
+	// TestSessions is whether staff and test sessions are counted.
+	TestSessions bool    `json:"test_sessions_included"`
+	Visits       int     `json:"total_visits"`
+	UniqueUsers  int     `json:"unique_users"`
+	Hours        float64 `json:"total_hours"`
+	// ByPurpose and ByVisitorType split the visits and hours as the Stats
+	// tab does, most hours first.
+	ByPurpose     []StatsUsage `json:"by_purpose"`
+	ByVisitorType []StatsUsage `json:"by_visitor_type"`
+	// Devices are the hours each device was in use, by device ID. A session
+	// moved between devices counts on each for its time there.
+	Devices []StatsDevice `json:"devices"`
+	// Weekdays average the open days of each weekday in the range, Monday
+	// first; closures are not counted as days.
+	Weekdays []StatsWeekday `json:"weekdays"`
+	// Occupancy averages the occupancy samples of the open days by hour of
+	// day, for the hours with samples.
+	Occupancy []StatsOccupancy `json:"occupancy"`
+}
+
+// StatsUsage is the visits and hours of one purpose or visitor type.
+type StatsUsage struct {
+	Name   string  `json:"name"`
+	Visits int     `json:"visits"`
+	Hours  float64 `json:"hours"`
+}
+
+// StatsDevice is one device's use.
+type StatsDevice struct {
+	ID     int     `json:"id"`
+	Type   string  `json:"type"`
+	Visits int     `json:"visits"`
+	Hours  float64 `json:"hours"`
+}
+
+// StatsWeekday is the average day for one weekday.
+type StatsWeekday struct {
+	Weekday      string  `json:"weekday"`
+	OpenDays     int     `json:"open_days"`
+	VisitsPerDay float64 `json:"visits_per_day"`
+	HoursPerDay  float64 `json:"hours_per_day"`
+}
+
+// StatsOccupancy is the average occupancy in one hour of the day, 0 to 23.
+type StatsOccupancy struct {
+	Hour           int     `json:"hour"`
+	Samples        int     `json:"samples"`
+	OccupiedPCs    float64 `json:"occupied_pcs"`
+	ConsolePlayers float64 `json:"console_players"`
+	QueueLength    float64 `json:"queue_length"`
+}
+
+// ExportStats works out the StatsExport for the days from start to end. It
+// reads one day's log at a time, archived days included, so a long range
+// never holds more than a day of entries.
+func (s *Store) ExportStats(start, end string, includeTest bool, now time.Time) (StatsExport, error) {
+	out := StatsExport{SchemaVersion: StatsExportVersion, From: start, To: end, Generated: now, TestSessions: includeTest}
+	purposes := map[string]*StatsUsage{}
+	visitorTypes := map[string]*StatsUsage{}
+	devices := map[int]*StatsDevice{}
+	users := map[string]bool{}
+	var weekdays [7]struct {
+		days   int
+		visits int
+		usage  time.Duration
+	}
+	var hours [24]StatsOccupancy
+	var total time.Duration
+
+	today := now.Format("2006-01-02")
+	first, err := time.ParseInLocation("2006-01-02", start, time.Local)
+	if err != nil {
+		return out, err
+	}
+	for day := first; day.Format("2006-01-02") <= end; day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		_, closed := s.ClosureOn(date)
+		if !closed && date <= today {
+			weekdays[day.Weekday()].days++
+		}
+		entries, err := s.ReadLogEntries(date)
+		if err != nil {
+			return out, err
+		}
+		s.ClassifyVisitors(entries)
+		entries = StatsEntries(entries, includeTest)
+		addUsage(purposes, UsageByPurpose(entries, now))
+		addUsage(visitorTypes, UsageByVisitorType(entries, now))
+		for _, entry := range entries {
+			if !entry.IsSession() {
+				continue
+			}
+			users[entry.UserID] = true
+			d, _ := entry.SessionDuration(now)
+			out.Visits++
+			total += d
+			if !closed {
+				weekdays[day.Weekday()].visits++
+				weekdays[day.Weekday()].usage += d
+			}
+			for id, used := range deviceUsage(entry, d) {
+				device := devices[id]
+				if device == nil {
+					device = &StatsDevice{ID: id}
+					if known := s.DeviceByID(id); known != nil {
+						device.Type = known.Type
+					}
+					devices[id] = device
+				}
+				device.Visits++
+				device.Hours += used.Hours()
+			}
+		}
+		if closed {
+			continue
+		}
+		samples, err := s.ReadOccupancySamples(date)
+		if err != nil {
+			continue
+		}
+		for _, sample := range samples {
+			h := &hours[sample.Time.Hour()]
+			h.Samples++
+			h.OccupiedPCs += float64(sample.OccupiedPCs)
+			h.ConsolePlayers += float64(sample.ConsolePlayers)
+			h.QueueLength += float64(sample.QueueLength)
+		}
+	}
+
+	out.UniqueUsers = len(users)
+	out.Hours = total.Hours()
+	out.ByPurpose = sortedUsage(purposes)
+	out.ByVisitorType = sortedUsage(visitorTypes)
+	out.Devices = []StatsDevice{}
+	for _, device := range devices {
+		out.Devices = append(out.Devices, *device)
+	}
+	sort.Slice(out.Devices, func(i, j int) bool { return out.Devices[i].ID < out.Devices[j].ID })
+	for i := 0; i < 7; i++ {
+		weekday := time.Weekday((i + 1) % 7)
+		w := weekdays[weekday]
+		avg := StatsWeekday{Weekday: weekday.String(), OpenDays: w.days}
+		if w.days > 0 {
+			avg.VisitsPerDay = float64(w.visits) / float64(w.days)
+			avg.HoursPerDay = w.usage.Hours() / float64(w.days)
+		}
+		out.Weekdays = append(out.Weekdays, avg)
+	}
+	out.Occupancy = []StatsOccupancy{}
+	for hour, h := range hours {
+		if h.Samples == 0 {
+			continue
+		}
+		n := float64(h.Samples)
+		out.Occupancy = append(out.Occupancy, StatsOccupancy{Hour: hour, Samples: h.Samples,
+			OccupiedPCs: h.OccupiedPCs / n, ConsolePlayers: h.ConsolePlayers / n, QueueLength: h.QueueLength / n})
+	}
+	return out, nil
+}
+
+func addUsage(into map[string]*StatsUsage, usages []PurposeUsage) {
+	for _, usage := range usages {
+		u := into[usage.Purpose]
+		if u == nil {
+			u = &StatsUsage{Name: usage.Purpose}
+			into[usage.Purpose] = u
+		}
+		u.Visits += usage.Visits
+		u.Hours += usage.Usage.Hours()
+	}
+}
+
+func sortedUsage(usages map[string]*StatsUsage) []StatsUsage {
+	out := make([]StatsUsage, 0, len(usages))
+	for _, u := range usages {
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Hours != out[j].Hours {
+			return out[i].Hours > out[j].Hours
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// deviceUsage splits a session's duration d between the devices it used,
+// by the times of its device moves. The last device gets what is left, so
+// the parts add up to d.
+func deviceUsage(entry LogEntry, d time.Duration) map[int]time.Duration {
+	if entry.PCID == 0 {
+		return nil
+	}
+	out := map[int]time.Duration{}
+	left := d
+	from := entry.CheckInTime
+	for _, move := range entry.DeviceHistory {
+		part := move.At.Sub(from)
+		if part < 0 {
+			part = 0
+		}
+		if part > left {
+			part = left
+		}
+		out[move.From] += part
+		left -= part
+		from = move.At
+	}
+	out[entry.PCID] += left
+	return out
+}
+
+// StatsSnapshot copies what ExportStats reads from the store (the closures,
+// devices and members) into a read-only store on the same data folder, so
+// an export can read the logs off the UI goroutine while s carries on.
+func (s *Store) StatsSnapshot() *Store {
+	snap := NewStore(s.LogDir, s.MemberFile)
+	snap.ReadOnly = true
+	snap.Closures = slices.Clone(s.Closures)
+	snap.Devices = slices.Clone(s.Devices)
+	snap.Members = slices.Clone(s.Members)
+	snap.MembersLoaded = s.MembersLoaded
+	return snap
+}
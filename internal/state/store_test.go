@@ -0,0 +1,35 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestStore returns a store on a fresh temporary data folder with
+// devices, its members loaded from members (CSV text; "" for none).
+func newTestStore(t *testing.T, members string, devices ...Device) *Store {
+	t.Helper()
+	dir := t.TempDir()
+	memberFile := filepath.Join(dir, "membership.csv")
+	if members != "" {
+		if err := os.WriteFile(memberFile, []byte(members), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewStore(filepath.Join(dir, "log"), memberFile)
+	s.Devices = devices
+	s.ActiveUsers = []User{}
+	s.LoadMembers()
+	t.Cleanup(s.FlushWrites)
+	return s
+}
+
+// reopen loads s's member file into a new store, as a restart would.
+func reopen(t *testing.T, s *Store) *Store {
+	t.Helper()
+	s.FlushWrites()
+	again := NewStore(s.LogDir, s.MemberFile)
+	again.LoadMembers()
+	return again
+}
@@ -0,0 +1,202 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Streak is one person's attendance over a range of days. A streak counts
+// consecutive open days with at least one visit; an open day is one outside
+// the closures on which anyone visited, so holidays and empty weekends never
+// break a streak. Today only adds to a streak: it is not over yet.
+type Streak struct {
+	UserID string
+	Name   string
+	// Current is the streak running into the last open day, or into today.
+	Current int
+	Longest int
+	// Days is the open days visited and Visits the sessions over them.
+	Days      int
+	Visits    int
+	LastVisit string
+
+	lastOpen int
+}
+
+// streakHistory caches everyone's streaks over the days before today, which
+// only a history import or a change of closures alters.
+type streakHistory struct {
+	key     string // today's date and the closures
+	streaks map[string]Streak
+}
+
+// streaksBefore works out the streaks over the open days from start up to,
+// not including, today.
+func (s *Store) streaksBefore(start, end, today string) (map[string]Streak, error) {
+	out := map[string]Streak{}
+	dates := s.ListAvailableLogDates()
+	open := 0
+	for i := len(dates) - 1; i >= 0; i-- {
+		date := dates[i]
+		if date < start || date > end || date >= today {
+			continue
+		}
+		if _, closed := s.ClosureOn(date); closed {
+			continue
+		}
+		entries, err := s.ReadLogEntries(date)
+		if err != nil {
+			return nil, err
+		}
+		visits := streakVisits(entries)
+		if len(visits) == 0 {
+			continue
+		}
+		open++
+		for id, visit := range visits {
+			streak, ok := out[id]
+			if !ok {
+				streak = Streak{UserID: id}
+			}
+			if streak.lastOpen == open-1 {
+				streak.Current++
+			} else {
+				streak.Current = 1
+			}
+			streak.lastOpen = open
+			streak.Longest = max(streak.Longest, streak.Current)
+			streak.Days++
+			streak.Visits += visit.n
+			streak.Name = visit.name
+			streak.LastVisit = date
+			out[id] = streak
+		}
+	}
+	for id, streak := range out {
+		if streak.lastOpen != open {
+			streak.Current = 0
+			out[id] = streak
+		}
+	}
+	return out, nil
+}
+
+type streakVisit struct {
+	name string
+	n    int
+}
+
+// streakVisits counts each person's sessions in a day's entries, leaving out
+// staff and test sessions as Stats does.
+func streakVisits(entries []LogEntry) map[string]streakVisit {
+	visits := map[string]streakVisit{}
+	for _, entry := range StatsEntries(entries, false) {
+		if !entry.IsSession() || entry.UserID == "" {
+			continue
+		}
+		visit := visits[entry.UserID]
+		visit.name = entry.UserName
+		visit.n++
+		visits[entry.UserID] = visit
+	}
+	return visits
+}
+
+// addToday adds today's visits to streaks, which must not be cached.
+func (s *Store) addToday(streaks map[string]Streak, today string) error {
+	if _, closed := s.ClosureOn(today); closed {
+		return nil
+	}
+	entries, err := s.ReadDailyLogEntriesLocked()
+	if err != nil {
+		return err
+	}
+	for id, visit := range streakVisits(entries) {
+		streak, ok := streaks[id]
+		if !ok {
+			streak = Streak{UserID: id}
+		}
+		streak.Current++
+		streak.Longest = max(streak.Longest, streak.Current)
+		streak.Days++
+		streak.Visits += visit.n
+		streak.Name = visit.name
+		streak.LastVisit = today
+		streaks[id] = streak
+	}
+	return nil
+}
+
+// historyStreak returns userID's streak over every log kept before today.
+func (s *Store) historyStreak(userID, today string) (Streak, error) {
+	key := today + " " + fmt.Sprint(s.Closures)
+	if s.streakHistory.key != key {
+		streaks, err := s.streaksBefore("", today, today)
+		if err != nil {
+			return Streak{UserID: userID}, err
+		}
+		s.streakHistory = streakHistory{key: key, streaks: streaks}
+	}
+	if streak, ok := s.streakHistory.streaks[userID]; ok {
+		return streak, nil
+	}
+	return Streak{UserID: userID}, nil
+}
+
+// StreakFor returns userID's streak over every log kept, today included.
+func (s *Store) StreakFor(userID, today string) (Streak, error) {
+	streak, err := s.historyStreak(userID, today)
+	if err != nil {
+		return streak, err
+	}
+	streaks := map[string]Streak{userID: streak}
+	if err := s.addToday(streaks, today); err != nil {
+		return streak, err
+	}
+	return streaks[userID], nil
+}
+
+// ActiveStreak is the current streak of userID, who is checked in and so
+// has visited today. It reads no logs once the history is cached, for the
+// map to call on every refresh; 0 when the history cannot be read.
+func (s *Store) ActiveStreak(userID, today string) int {
+	if _, closed := s.ClosureOn(today); closed {
+		return 0
+	}
+	streak, err := s.historyStreak(userID, today)
+	if err != nil {
+		return 0
+	}
+	return streak.Current + 1
+}
+
+// StreakLeaderboard ranks everyone who visited during term by their longest
+// streak in it, then by days visited. Names are the members' where known.
+func (s *Store) StreakLeaderboard(term Term, today string) ([]Streak, error) {
+	streaks, err := s.streaksBefore(term.Start, term.End, today)
+	if err != nil {
+		return nil, err
+	}
+	if term.Contains(today) {
+		if err := s.addToday(streaks, today); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]Streak, 0, len(streaks))
+	for _, streak := range streaks {
+		if member := s.MemberByID(streak.UserID); member != nil {
+			streak.Name = member.Name
+		}
+		out = append(out, streak)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Longest != out[j].Longest {
+			return out[i].Longest > out[j].Longest
+		}
+		if out[i].Days != out[j].Days {
+			return out[i].Days > out[j].Days
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
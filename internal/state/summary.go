@@ -0,0 +1,218 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DailySummary is the end-of-day digest written to log/summary-YYYY-MM-DD.json
+// when the app closes.
+type DailySummary struct {
+	Date              string    `json:"date"`
+	GeneratedAt       time.Time `json:"generated_at"`
+	CheckIns          int       `json:"check_ins"`
+	CompletedSessions int       `json:"completed_sessions"`
+	OpenSessions      int       `json:"open_sessions"`
+	TotalUsage        string    `json:"total_usage"`
+	LoungeVisitors    int       `json:"lounge_visitors,omitempty"`
+	// TestSessions are the staff and test sessions left out of the counts.
+	TestSessions int `json:"test_sessions,omitempty"`
+	// Visitors counts the check-ins by visitor type.
+	Visitors map[string]int `json:"visitors,omitempty"`
+	// ShiftNotes is the day's shift log, newest first, open notes carried
+	// from earlier days included.
+	ShiftNotes []ShiftNote `json:"shift_notes,omitempty"`
+}
+
+func (s *Store) summaryFilePathForDate(date string) string {
+	if date == "" {
+		date = TodaysLogDate()
+	}
+	return filepath.Join(s.LogDir, fmt.Sprintf("summary-%s.json", date))
+}
+
+// BuildDailySummary summarizes date's entries. Run ClassifyVisitors over
+// them first so older sessions have a visitor type.
+func BuildDailySummary(date string, entries []LogEntry) DailySummary {
+	summary := DailySummary{Date: date, GeneratedAt: time.Now(), Visitors: make(map[string]int)}
+	var total time.Duration
+	summary.LoungeVisitors = LoungeVisits(entries)
+	summary.TestSessions = TestSessions(entries)
+	for _, entry := range StatsEntries(entries, false) {
+		if !entry.IsSession() {
+			continue
+		}
+		summary.CheckIns++
+		if entry.VisitorType != "" {
+			summary.Visitors[entry.VisitorType]++
+		}
+		if entry.CheckOutTime.IsZero() {
+			summary.OpenSessions++
+			continue
+		}
+		summary.CompletedSessions++
+		if d, ok := entry.SessionDuration(summary.GeneratedAt); ok {
+			total += d
+		}
+	}
+	summary.TotalUsage = FormatDuration(total)
+	return summary
+}
+
+// WriteDailySummary rewrites today's summary file from the daily log.
+func (s *Store) WriteDailySummary() error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	entries, err := s.ReadDailyLogEntriesLocked()
+	if err != nil {
+		return err
+	}
+	s.ClassifyVisitors(entries)
+	summary := BuildDailySummary(TodaysLogDate(), entries)
+	if notes, err := s.ShiftNotes(); err != nil {
+		slog.Error("reading shift notes for the daily summary", "err", err)
+	} else {
+		summary.ShiftNotes = notes
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal summary: %w", err)
+	}
+	if err := s.EnsureLogDir(); err != nil {
+		return err
+	}
+	return WriteFileAtomic(s.summaryFilePathForDate(TodaysLogDate()), data, 0o644)
+}
+
+// UnspecifiedPurpose labels check-ins made without a purpose.
+const UnspecifiedPurpose = "unspecified"
+
+// PurposeUsage is the visit count and session time for one purpose (or, from
+// UsageByEvent and UsageBySource, one event or check-in source).
+type PurposeUsage struct {
+	Purpose string
+	Visits  int
+	Usage   time.Duration
+}
+
+// UsageByPurpose totals entries per purpose, longest usage first. Open
+// sessions count up to now.
+func UsageByPurpose(entries []LogEntry, now time.Time) []PurposeUsage {
+	return usageBy(entries, now, func(entry LogEntry) string {
+		if entry.Purpose == "" {
+			return UnspecifiedPurpose
+		}
+		return entry.Purpose
+	})
+}
+
+// OpenHours labels sessions that began outside any club event.
+const OpenHours = "open hours"
+
+// UsageByEvent totals entries per club event, with untagged sessions under
+// OpenHours, longest usage first.
+func UsageByEvent(entries []LogEntry, now time.Time) []PurposeUsage {
+	return usageBy(entries, now, func(entry LogEntry) string {
+		if entry.Event == "" {
+			return OpenHours
+		}
+		return entry.Event
+	})
+}
+
+// UsageBySource totals entries per check-in source, longest usage first.
+// Entries from before sources were recorded count as SourceUnknown.
+func UsageBySource(entries []LogEntry, now time.Time) []PurposeUsage {
+	return usageBy(entries, now, func(entry LogEntry) string {
+		if entry.Source == "" {
+			return SourceUnknown
+		}
+		return entry.Source
+	})
+}
+
+func usageBy(entries []LogEntry, now time.Time, key func(LogEntry) string) []PurposeUsage {
+	byKey := make(map[string]*PurposeUsage)
+	var order []string
+	for _, entry := range entries {
+		if !entry.IsSession() {
+			continue
+		}
+		k := key(entry)
+		usage, ok := byKey[k]
+		if !ok {
+			usage = &PurposeUsage{Purpose: k}
+			byKey[k] = usage
+			order = append(order, k)
+		}
+		usage.Visits++
+		if d, ok := entry.SessionDuration(now); ok {
+			usage.Usage += d
+		}
+	}
+	out := make([]PurposeUsage, 0, len(order))
+	for _, k := range order {
+		out = append(out, *byKey[k])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Usage > out[j].Usage })
+	return out
+}
+
+// CourseCredit is one student's sessions and time tagged with a course.
+type CourseCredit struct {
+	UserID   string
+	UserName string
+	Sessions int
+	Usage    time.Duration
+}
+
+// CourseCredits totals the entries tagged with course per student, by name.
+// Sessions without that course are left out. Open sessions count up to now.
+func CourseCredits(entries []LogEntry, course string, now time.Time) []CourseCredit {
+	byID := make(map[string]*CourseCredit)
+	for _, entry := range entries {
+		if !entry.IsSession() || entry.Course != course {
+			continue
+		}
+		credit, ok := byID[entry.UserID]
+		if !ok {
+			credit = &CourseCredit{UserID: entry.UserID}
+			byID[entry.UserID] = credit
+		}
+		credit.UserName = entry.UserName
+		credit.Sessions++
+		if d, ok := entry.SessionDuration(now); ok {
+			credit.Usage += d
+		}
+	}
+	out := make([]CourseCredit, 0, len(byID))
+	for _, credit := range byID {
+		out = append(out, *credit)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].UserName != out[j].UserName {
+			return out[i].UserName < out[j].UserName
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	return out
+}
+
+// LoggedCourses returns the course codes entries are tagged with, sorted.
+func LoggedCourses(entries []LogEntry) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, entry := range entries {
+		if entry.Course != "" && !seen[entry.Course] {
+			seen[entry.Course] = true
+			out = append(out, entry.Course)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
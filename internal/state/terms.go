@@ -0,0 +1,67 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Term is a named reporting period, such as an academic term. Start and End
+// are inclusive YYYY-MM-DD dates. Terms only select which daily logs a report
+// reads; they never change the logs themselves.
+type Term struct {
+	Name  string `json:"name"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ParseTerm parses "Fall 2024: 2024-09-01..2024-12-20".
+func ParseTerm(line string) (Term, error) {
+	name, span, ok := strings.Cut(line, ":")
+	if !ok {
+		return Term{}, fmt.Errorf("term %q: expected \"Name: YYYY-MM-DD..YYYY-MM-DD\"", line)
+	}
+	start, end, ok := strings.Cut(span, "..")
+	if !ok {
+		return Term{}, fmt.Errorf("term %q: expected a range like 2024-09-01..2024-12-20", line)
+	}
+	term := Term{Name: strings.TrimSpace(name), Start: strings.TrimSpace(start), End: strings.TrimSpace(end)}
+	if term.Name == "" {
+		return Term{}, fmt.Errorf("term %q: name is required", line)
+	}
+	startDate, err := time.Parse("2006-01-02", term.Start)
+	if err != nil {
+		return Term{}, fmt.Errorf("term %s: bad start date %q", term.Name, term.Start)
+	}
+	endDate, err := time.Parse("2006-01-02", term.End)
+	if err != nil {
+		return Term{}, fmt.Errorf("term %s: bad end date %q", term.Name, term.End)
+	}
+	if endDate.Before(startDate) {
+		return Term{}, fmt.Errorf("term %s: ends before it starts", term.Name)
+	}
+	return term, nil
+}
+
+func (t Term) String() string { return fmt.Sprintf("%s: %s..%s", t.Name, t.Start, t.End) }
+
+// Contains reports whether the YYYY-MM-DD date falls inside the term.
+func (t Term) Contains(date string) bool { return date >= t.Start && date <= t.End }
+
+// ReadLogEntriesInRange returns every entry logged on the days from start to
+// end inclusive. Entries are filed under the day their session started, so a
+// session that runs past midnight counts toward that first day.
+func (s *Store) ReadLogEntriesInRange(start, end string) ([]LogEntry, error) {
+	var out []LogEntry
+	for _, date := range s.ListAvailableLogDates() {
+		if date < start || date > end {
+			continue
+		}
+		entries, err := s.ReadLogEntries(date)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, entries...)
+	}
+	return out, nil
+}
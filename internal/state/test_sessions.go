@@ -0,0 +1,88 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// StatsEntries is entries as the Stats tab, reports and summaries count
+// them: without queue removals that were NotAVisit, and without the sessions
+// marked ExcludeFromStats unless includeTest.
+func StatsEntries(entries []LogEntry, includeTest bool) []LogEntry {
+	out := make([]LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.NotAVisit && (includeTest || !entry.ExcludeFromStats) {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// TestSessions counts the sessions in entries marked ExcludeFromStats.
+func TestSessions(entries []LogEntry) int {
+	n := 0
+	for _, entry := range entries {
+		if entry.IsSession() && entry.ExcludeFromStats {
+			n++
+		}
+	}
+	return n
+}
+
+// sameSession reports whether a and b are the same session, by SessionID or,
+// for entries logged before session IDs, by user and check-in time.
+func sameSession(a, b LogEntry) bool {
+	if a.SessionID != "" || b.SessionID != "" {
+		return a.SessionID == b.SessionID
+	}
+	return a.UserID == b.UserID && a.CheckInTime.Equal(b.CheckInTime)
+}
+
+// SetExcludeFromStats marks or unmarks entry, a session in date's log, as a
+// staff or test session after the fact. A session still open keeps the mark
+// through checkout. Archived months cannot be changed.
+func (s *Store) SetExcludeFromStats(date string, entry LogEntry, exclude bool) error {
+	if s.ReadOnly {
+		return ErrReadOnly
+	}
+	if !entry.IsSession() {
+		return fmt.Errorf("only sessions can be excluded from stats")
+	}
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+	if _, err := os.Stat(s.LogFilePathForDate(date)); os.IsNotExist(err) {
+		return fmt.Errorf("the log for %s is archived and cannot be changed", date)
+	}
+	entries, err := s.ReadLogEntries(date)
+	if err != nil {
+		return err
+	}
+	found := false
+	for i := range entries {
+		if entries[i].IsSession() && sameSession(entries[i], entry) {
+			entries[i].ExcludeFromStats = exclude
+			found = true
+		}
+	}
+	if !found {
+		return newError(ErrUserNotFound, "no session for %s (%s) in the log for %s", entry.UserName, entry.UserID, date)
+	}
+	if err := s.writeLogEntries(date, entries); err != nil {
+		return err
+	}
+	if entry.CheckOutTime.IsZero() {
+		for i := range s.ActiveUsers {
+			u := &s.ActiveUsers[i]
+			if sameSession(LogEntry{UserID: u.ID, CheckInTime: u.CheckInTime, SessionID: u.SessionID}, entry) {
+				u.ExcludeFromStats = exclude
+				s.saveJournaled(JournalEntry{Time: time.Now(), Action: JournalTest, UserID: u.ID, Name: u.Name, Device: u.PCID,
+					Session: u.SessionID, Test: exclude})
+			}
+		}
+	}
+	if date == TodaysLogDate() {
+		s.logChanged(entries)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GeneratedIDPrefix starts the IDs the desk makes up for visitors without
+// one; the student ID pattern does not apply to them.
+const GeneratedIDPrefix = "LOUNGE-"
+
+var (
+	// ErrInvalidID is returned when an ID does not match StudentIDPattern.
+	ErrInvalidID = errors.New("invalid ID")
+	// ErrMemberFlagged warns that the member has a serious staff note.
+	ErrMemberFlagged = errors.New("member flagged")
+	// ErrEventWalkIn warns that a walk-in is being seated during an event.
+	ErrEventWalkIn = errors.New("walk-in during event")
+	// ErrPossibleDuplicate warns that someone with a similar name is queued.
+	ErrPossibleDuplicate = errors.New("possible duplicate")
+	// ErrUnknownMember warns that an ID is not in the member file, so
+	// checking in with it would create a new member, perhaps from a typo.
+	ErrUnknownMember = errors.New("unknown member")
+)
+
+// CheckInContext is what a validator sees of a check-in about to happen.
+// Nothing has changed yet.
+type CheckInContext struct {
+	Store   *Store
+	Name    string // normalized
+	UserID  string
+	Device  *Device // nil when queueing
+	Options RegisterOptions
+	Now     time.Time
+}
+
+// CheckInValidator is one rule in the check-in pipeline. Check returns nil to
+// pass, a *ValidationWarning for a rule staff may waive, or any other error
+// to refuse the check-in.
+type CheckInValidator struct {
+	ID   string
+	Name string
+	// Required rules keep the state sound and cannot be switched off.
+	Required bool
+	// Device rules are about the device being seated on; they also vet
+	// seating a queued user, through Assignable.
+	Device bool
+	Check  func(ctx CheckInContext) error
+}
+
+// ValidationWarning is a rule staff may waive. RegisterWith returns it until
+// RegisterOptions.Waived names its validator.
+type ValidationWarning struct {
+	Validator string
+	Title     string
+	// Question asks staff whether to go ahead, e.g. "Check them in anyway?".
+	Question string
+	Err      error
+}
+
+func (w *ValidationWarning) Error() string { return w.Err.Error() }
+func (w *ValidationWarning) Unwrap() error { return w.Err }
+
+// BuiltinValidators run in this order unless switched off with
+// ConfigureValidators.
+var BuiltinValidators = []CheckInValidator{
+	{ID: "duplicate-id", Name: "User already checked in", Required: true, Check: validateNotActive},
+	{ID: "device", Name: "Device exists and is free", Required: true, Device: true, Check: validateDevice},
+	{ID: "cleaning", Name: "Confirm seating on a device being cleaned", Device: true, Check: validateCleaned},
+	{ID: "reserved", Name: "Confirm seating on a reserved device", Device: true, Check: validateReserved},
+	{ID: "waiting-for", Name: "Confirm seating someone on a device they are not waiting for", Device: true, Check: validateWaitedFor},
+	{ID: "closed", Name: "Confirm check-ins on closure days", Check: validateOpen},
+	{ID: "student-id", Name: "ID matches the student ID pattern", Check: validateStudentID},
+	{ID: "unknown-member", Name: "Confirm creating a member for an unknown ID", Required: true, Check: validateKnownMember},
+	{ID: "event-walk-in", Name: "Confirm walk-ins during an event", Check: validateEventWalkIn},
+	{ID: "similar-queued", Name: "Confirm names similar to someone queued", Check: validateSimilarQueued},
+	{ID: "flagged-member", Name: "Confirm members with a serious note", Check: validateNotFlagged},
+	{ID: "cooldown", Name: "Cooldown between sessions", Check: validateCooldown},
+	{ID: "queue-cap", Name: "Self-service check-ins stop at the maximum queue length", Required: true, Check: validateSelfServiceQueue},
+	{ID: "queue-full", Name: "Maximum queue length for staff check-ins", Check: validateQueueNotFull},
+	{ID: "daily-cap", Name: "Daily PC and console caps", Check: validateDailyCap},
+	{ID: "guest-limit", Name: "Guest visits per term", Check: validateGuestLimit},
+}
+
+// ConfigureValidators runs the built-in validators except those whose IDs
+// are in disabled; required ones always run.
+func (s *Store) ConfigureValidators(disabled []string) {
+	off := make(map[string]bool, len(disabled))
+	for _, id := range disabled {
+		off[id] = true
+	}
+	s.Validators = nil
+	for _, v := range BuiltinValidators {
+		if v.Required || !off[v.ID] {
+			s.Validators = append(s.Validators, v)
+		}
+	}
+}
+
+// validateCheckIn runs the pipeline and returns the first refusal or
+// unwaived warning.
+func (s *Store) validateCheckIn(ctx CheckInContext) error {
+	return s.runValidators(ctx, false)
+}
+
+// runValidators runs the pipeline, or only its Device rules when
+// deviceOnly is set.
+func (s *Store) runValidators(ctx CheckInContext, deviceOnly bool) error {
+	for _, v := range s.Validators {
+		if deviceOnly && !v.Device {
+			continue
+		}
+		err := v.Check(ctx)
+		var warning *ValidationWarning
+		if errors.As(err, &warning) && ctx.Options.waived(warning.Validator) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o RegisterOptions) waived(id string) bool {
+	for _, w := range o.Waived {
+		if w == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Confirmer asks staff whether to waive warning and passes their choice to
+// answer, straight away or later, e.g. from a dialog. Tests can answer at
+// once.
+type Confirmer func(warning *ValidationWarning, answer func(ok bool))
+
+// RegisterConfirming is RegisterWith that asks confirm about each warning in
+// turn and tries again with it waived, then passes the outcome to done. With
+// a nil confirm, warnings refuse the check-in, as self-service needs.
+func (s *Store) RegisterConfirming(name, userID string, deviceID int, opts RegisterOptions, confirm Confirmer, done func(error)) {
+	err := s.RegisterWith(name, userID, deviceID, opts)
+	var warning *ValidationWarning
+	if confirm == nil || !errors.As(err, &warning) {
+		done(err)
+		return
+	}
+	confirm(warning, func(ok bool) {
+		if !ok {
+			done(warning)
+			return
+		}
+		opts.Waived = append(append([]string{}, opts.Waived...), warning.Validator)
+		s.RegisterConfirming(name, userID, deviceID, opts, confirm, done)
+	})
+}
+
+func validateNotActive(ctx CheckInContext) error {
+	existing := ctx.Store.UserByID(ctx.UserID)
+	switch {
+	case existing == nil:
+		return nil
+	case existing.PCID == 0:
+		return newError(ErrUserAlreadyActive, "user ID %s (%s) is already in the queue", ctx.UserID, existing.Name)
+	}
+	return newError(ErrUserAlreadyActive, "user ID %s (%s) already checked in on Device %d", ctx.UserID, existing.Name, existing.PCID)
+}
+
+func validateDevice(ctx CheckInContext) error {
+	device := ctx.Device
+	switch {
+	case device == nil:
+		return nil
+	case device.Status == StatusMaintenance:
+		return newError(ErrDeviceMaintenance, "device %d is under maintenance", device.ID)
+	case device.Type == "PC" && device.Status != "free":
+		return newError(ErrDeviceBusy, "device %d is busy (occupied by UserID: %s)", device.ID, device.UserID)
+	}
+	return nil
+}
+
+func validateStudentID(ctx CheckInContext) error {
+	pattern := ctx.Store.StudentIDPattern
+	if pattern == nil || strings.HasPrefix(ctx.UserID, GeneratedIDPrefix) || pattern.MatchString(ctx.UserID) {
+		return nil
+	}
+	return newError(ErrInvalidID, "%s is not a valid student ID", ctx.UserID)
+}
+
+// validateKnownMember asks before an ID the member file does not know
+// becomes a new member. The desk's own GeneratedIDPrefix IDs are new on
+// purpose and pass.
+func validateKnownMember(ctx CheckInContext) error {
+	s := ctx.Store
+	if !s.MembersLoaded || IsGuestID(ctx.UserID) || s.MemberByID(ctx.UserID) != nil {
+		return nil
+	}
+	return &ValidationWarning{Validator: "unknown-member", Title: "Unknown ID",
+		Question: "Create a new member, fix the ID, or check in as a guest?",
+		Err:      newError(ErrUnknownMember, "ID %s is not a member", ctx.UserID)}
+}
+
+func validateEventWalkIn(ctx CheckInContext) error {
+	if !ctx.Options.WalkIn || ctx.Store.Event == "" {
+		return nil
+	}
+	return &ValidationWarning{Validator: "event-walk-in", Title: "Event Running",
+		Question: fmt.Sprintf("Seat %s anyway?", ctx.Name),
+		Err:      newError(ErrEventWalkIn, "the lounge is reserved for %s", ctx.Store.Event)}
+}
+
+func validateSimilarQueued(ctx CheckInContext) error {
+	if ctx.Device != nil {
+		return nil
+	}
+	existing := ctx.Store.SimilarQueuedUser(ctx.Name)
+	if existing == nil || existing.ID == ctx.UserID {
+		return nil
+	}
+	return &ValidationWarning{Validator: "similar-queued", Title: "Possible Duplicate",
+		Question: fmt.Sprintf("Queue %s (%s) as well?", ctx.Name, ctx.UserID),
+		Err:      newError(ErrPossibleDuplicate, "%s (%s) is already queued", existing.Name, existing.ID)}
+}
+
+func validateNotFlagged(ctx CheckInContext) error {
+	member := ctx.Store.MemberByID(ctx.UserID)
+	if member == nil || !member.Flagged {
+		return nil
+	}
+	return &ValidationWarning{Validator: "flagged-member", Title: "Flagged Member",
+		Question: "Check them in anyway?",
+		Err:      newError(ErrMemberFlagged, "%s (%s) is flagged: %s", member.Name, member.ID, member.Notes)}
+}
+
+func validateCooldown(ctx CheckInContext) error {
+	if len(ctx.Store.PendingUsers()) == 0 {
+		return nil
+	}
+	eligible := ctx.Store.CooldownUntil(ctx.UserID, ctx.Now)
+	if eligible.IsZero() {
+		return nil
+	}
+	return &ValidationWarning{Validator: "cooldown", Title: "Cooldown", Question: "Check them in anyway?",
+		Err: newError(ErrCooldown, "%s (%s) had a session that ended recently and can check in again at %s",
+			ctx.Name, ctx.UserID, eligible.Format("15:04"))}
+}
+
+// validateSelfServiceQueue refuses kiosk and API check-ins to a full queue;
+// only staff can queue someone past MaxQueueLength.
+func validateSelfServiceQueue(ctx CheckInContext) error {
+	if ctx.Device != nil || (ctx.Options.Source != SourceKiosk && ctx.Options.Source != SourceAPI) {
+		return nil
+	}
+	queued := len(ctx.Store.PendingUsers())
+	if !ctx.Store.QueueIsFull(queued) {
+		return nil
+	}
+	return newError(ErrQueueFull, "the queue is full (%d/%d)", queued, ctx.Store.MaxQueueLength)
+}
+
+func validateQueueNotFull(ctx CheckInContext) error {
+	queued := len(ctx.Store.PendingUsers())
+	if ctx.Device != nil || !ctx.Store.QueueIsFull(queued) {
+		return nil
+	}
+	return &ValidationWarning{Validator: "queue-full", Title: "Queue Full",
+		Question: fmt.Sprintf("Add %s anyway?", ctx.Name),
+		Err:      newError(ErrQueueFull, "the queue is full (%d/%d)", queued, ctx.Store.MaxQueueLength)}
+}
+
+// validateDailyCap refuses or warns, per DailyCapsBlock, when the user has
+// no time left today on the device's type.
+func validateDailyCap(ctx CheckInContext) error {
+	s := ctx.Store
+	if ctx.Device == nil {
+		return nil
+	}
+	left, capped := s.CapRemaining(s.DailyUsageToday(ctx.Now), ctx.UserID, ctx.Device.Type)
+	if !capped || left > 0 {
+		return nil
+	}
+	err := newError(ErrDailyCap, "%s (%s) has used all of today's %s of %s time (none left)",
+		ctx.Name, ctx.UserID, FormatMinutes(s.DailyCaps[ctx.Device.Type]), ctx.Device.Type)
+	if s.DailyCapsBlock {
+		return err
+	}
+	return &ValidationWarning{Validator: "daily-cap", Title: "Daily Limit", Question: "Check them in anyway?", Err: err}
+}
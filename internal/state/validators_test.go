@@ -0,0 +1,137 @@
+package state
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestRegisterConfirming(t *testing.T) {
+	tests := []struct {
+		name string
+		// answers are staff's replies to the warnings, in order.
+		answers   []bool
+		nilAsk    bool
+		wantAsked []string
+		wantIn    bool
+	}{
+		{name: "both confirmed", answers: []bool{true, true}, wantAsked: []string{"unknown-member", "flagged-member"}, wantIn: true},
+		{name: "first declined", answers: []bool{false}, wantAsked: []string{"unknown-member"}},
+		{name: "second declined", answers: []bool{true, false}, wantAsked: []string{"unknown-member", "flagged-member"}},
+		{name: "self-service", nilAsk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSessionStore(t)
+			// A flagged rule that fires for the unknown ID too, so two
+			// warnings come up in pipeline order.
+			s.Validators = append(s.Validators, CheckInValidator{ID: "flagged-member", Check: func(ctx CheckInContext) error {
+				return &ValidationWarning{Validator: "flagged-member", Err: newError(ErrMemberFlagged, "flagged")}
+			}})
+			var asked []string
+			confirm := func(warning *ValidationWarning, answer func(bool)) {
+				asked = append(asked, warning.Validator)
+				answer(tt.answers[len(asked)-1])
+			}
+			if tt.nilAsk {
+				confirm = nil
+			}
+			var got error
+			done := false
+			s.RegisterConfirming("Katherine Johnson", "2001", 1, RegisterOptions{}, confirm, func(err error) { got, done = err, true })
+
+			if !done {
+				t.Fatal("done was never called")
+			}
+			if !slices.Equal(asked, tt.wantAsked) {
+				t.Fatalf("asked about %v, want %v", asked, tt.wantAsked)
+			}
+			if in := s.UserByID("2001") != nil; in != tt.wantIn || (got == nil) != tt.wantIn {
+				t.Fatalf("checked in %v with %v, want %v", in, got, tt.wantIn)
+			}
+			if !tt.wantIn {
+				var warning *ValidationWarning
+				if !errors.As(got, &warning) {
+					t.Fatalf("got %v, want the declined warning", got)
+				}
+				if d := s.DeviceByID(1); d.Status != "free" || s.MemberByID("2001") != nil {
+					t.Fatalf("declined check-in changed the store: device %+v", d)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatorsCanBeSwitchedOff(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator string
+		prepare   func(t *testing.T, s *Store)
+		want      error
+	}{
+		{"student ID", "student-id", func(t *testing.T, s *Store) { s.StudentIDPattern = regexp.MustCompile(`^\d{3}$`) }, ErrInvalidID},
+		{"flagged member", "flagged-member", func(t *testing.T, s *Store) { s.MemberByID("1001").Flagged = true }, ErrMemberFlagged},
+		{"queue full", "queue-full", func(t *testing.T, s *Store) {
+			s.MaxQueueLength = 1
+			if err := s.Register("Alan Turing", "1002", 0, ""); err != nil {
+				t.Fatal(err)
+			}
+		}, ErrQueueFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSessionStore(t)
+			tt.prepare(t, s)
+			register := func() error { return s.Register("Ada Lovelace", "1001", 0, "") }
+			if err := register(); !errors.Is(err, tt.want) {
+				t.Fatalf("with the rule on: got %v, want %v", err, tt.want)
+			}
+			s.ConfigureValidators([]string{tt.validator})
+			if err := register(); err != nil {
+				t.Fatalf("with %s off: %v", tt.validator, err)
+			}
+		})
+	}
+}
+
+func TestSelfServiceCannotPassAFullQueue(t *testing.T) {
+	for _, source := range []string{SourceKiosk, SourceAPI} {
+		t.Run(source, func(t *testing.T) {
+			s := newSessionStore(t)
+			s.MaxQueueLength = 1
+			if err := s.Register("Alan Turing", "1002", 0, ""); err != nil {
+				t.Fatal(err)
+			}
+			s.ConfigureValidators([]string{"queue-full"})
+			opts := RegisterOptions{Source: source, Waived: []string{"queue-full"}}
+			err := s.RegisterWith("Ada Lovelace", "1001", 0, opts)
+			var warning *ValidationWarning
+			if !errors.Is(err, ErrQueueFull) || errors.As(err, &warning) {
+				t.Fatalf("got %v, want a refusal with %v", err, ErrQueueFull)
+			}
+			if s.UserByID("1001") != nil {
+				t.Fatal("the refused user was queued")
+			}
+		})
+	}
+}
+
+func TestCustomValidatorRunsBeforeAnyChange(t *testing.T) {
+	s := newSessionStore(t)
+	errClosed := errors.New("closed for exams")
+	var seen CheckInContext
+	s.Validators = append([]CheckInValidator{{ID: "exams", Check: func(ctx CheckInContext) error {
+		seen = ctx
+		return errClosed
+	}}}, s.Validators...)
+	if err := s.Register("ada  lovelace", "1001", 2, ""); !errors.Is(err, errClosed) {
+		t.Fatalf("got %v, want the custom refusal", err)
+	}
+	if seen.Name != "Ada Lovelace" || seen.UserID != "1001" || seen.Device == nil || seen.Device.ID != 2 {
+		t.Fatalf("validator saw %+v", seen)
+	}
+	if len(s.ActiveUsers) != 0 || s.DeviceByID(2).Status != "free" {
+		t.Fatalf("refused check-in changed the store: %+v", s.ActiveUsers)
+	}
+}
@@ -0,0 +1,89 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Visitor types, for the member/guest split in the funding report.
+const (
+	VisitorMember = "member"
+	VisitorGuest  = "guest"
+	VisitorStaff  = "staff"
+)
+
+// VisitorTypes lists the visitor types in the order they are reported.
+var VisitorTypes = []string{VisitorMember, VisitorGuest, VisitorStaff}
+
+// ClassifyVisitor gives the visitor type for userID, where member is the
+// member with that ID or nil. A staff account (a member marked staff) is
+// staff; a GeneratedIDPrefix ID is a guest; any other ID is a member, since
+// check-in adds unknown IDs to the member file.
+func ClassifyVisitor(userID string, member *Member) string {
+	switch {
+	case member != nil && member.ExcludeFromStats:
+		return VisitorStaff
+	case IsGuestID(userID):
+		return VisitorGuest
+	}
+	return VisitorMember
+}
+
+// VisitorType is ClassifyVisitor for userID as a member now. Until the
+// members have loaded, staff count as members.
+func (s *Store) VisitorType(userID string) string {
+	return ClassifyVisitor(userID, s.MemberByID(userID))
+}
+
+// ClassifyVisitors fills in VisitorType on the sessions in entries logged
+// before it was recorded, by the rules used at check-in. Call it from the UI
+// goroutine, which owns the members.
+func (s *Store) ClassifyVisitors(entries []LogEntry) {
+	for i := range entries {
+		if entries[i].IsSession() && entries[i].VisitorType == "" {
+			entries[i].VisitorType = s.VisitorType(entries[i].UserID)
+		}
+	}
+}
+
+// UsageByVisitorType totals entries per visitor type, longest usage first.
+// Run ClassifyVisitors over entries first.
+func UsageByVisitorType(entries []LogEntry, now time.Time) []PurposeUsage {
+	return usageBy(entries, now, func(entry LogEntry) string { return entry.VisitorType })
+}
+
+// ActiveVisitorCounts counts the active users, queued or seated, by
+// visitor type.
+func (s *Store) ActiveVisitorCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, u := range s.ActiveUsers {
+		visitor := u.VisitorType
+		if visitor == "" {
+			visitor = s.VisitorType(u.ID)
+		}
+		counts[visitor]++
+	}
+	return counts
+}
+
+// FormatVisitorCounts describes counts like "10 members · 2 guests",
+// leaving out types with none; "0" when all are zero.
+func FormatVisitorCounts(counts map[string]int) string {
+	var parts []string
+	for _, visitor := range VisitorTypes {
+		n := counts[visitor]
+		if n == 0 {
+			continue
+		}
+		label := visitor
+		if n != 1 && visitor != VisitorStaff {
+			label += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, label))
+	}
+	if len(parts) == 0 {
+		return "0"
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,67 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassifyVisitor(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID string
+		member *Member
+		want   string
+	}{
+		{"member", "1001", &Member{Name: "Ada Lovelace", ID: "1001"}, VisitorMember},
+		{"staff", "2001", &Member{Name: "Desk Staff", ID: "2001", ExcludeFromStats: true}, VisitorStaff},
+		{"guest", GeneratedIDPrefix + "7Q2K", nil, VisitorGuest},
+		{"guest converted to a member", GeneratedIDPrefix + "7Q2K", &Member{Name: "Grace Hopper", ID: GeneratedIDPrefix + "7Q2K"}, VisitorGuest},
+		{"staff on a generated ID", GeneratedIDPrefix + "9X", &Member{ID: GeneratedIDPrefix + "9X", ExcludeFromStats: true}, VisitorStaff},
+		{"unknown", "5555", nil, VisitorMember},
+		{"prefix is case sensitive", "lounge-7Q2K", nil, VisitorMember},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyVisitor(tt.userID, tt.member); got != tt.want {
+				t.Errorf("ClassifyVisitor(%q) = %q, want %q", tt.userID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyVisitorsFillsOldEntries(t *testing.T) {
+	s := newTestStore(t, "Student Name,Student Number,Exclude From Stats\nAda Lovelace,1001,\nDesk Staff,2001,yes\n")
+	now := time.Now()
+	entries := []LogEntry{
+		{UserID: "1001", CheckInTime: now},
+		{UserID: "2001", CheckInTime: now},
+		{UserID: GeneratedIDPrefix + "7Q2K", CheckInTime: now},
+		{UserID: "5555", CheckInTime: now},
+		// Recorded at check-in, so kept even though 2001 is staff now.
+		{UserID: "2001", CheckInTime: now, VisitorType: VisitorMember},
+		{Kind: "lounge", CheckInTime: now},
+	}
+	s.ClassifyVisitors(entries)
+	want := []string{VisitorMember, VisitorStaff, VisitorGuest, VisitorMember, VisitorMember, ""}
+	for i, entry := range entries {
+		if entry.VisitorType != want[i] {
+			t.Errorf("entry %d (%s) classified %q, want %q", i, entry.UserID, entry.VisitorType, want[i])
+		}
+	}
+}
+
+func TestFormatVisitorCounts(t *testing.T) {
+	tests := []struct {
+		counts map[string]int
+		want   string
+	}{
+		{nil, "0"},
+		{map[string]int{VisitorMember: 10, VisitorGuest: 2}, "10 members · 2 guests"},
+		{map[string]int{VisitorMember: 1, VisitorGuest: 1, VisitorStaff: 3}, "1 member · 1 guest · 3 staff"},
+	}
+	for _, tt := range tests {
+		if got := FormatVisitorCounts(tt.counts); got != tt.want {
+			t.Errorf("FormatVisitorCounts(%v) = %q, want %q", tt.counts, got, tt.want)
+		}
+	}
+}
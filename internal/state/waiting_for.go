@@ -0,0 +1,56 @@
+package state
+
+// WaitingFor is what a queued user will only be seated on: one device, or
+// any device of a type, e.g. the racing rig or any console.
+type WaitingFor struct {
+	DeviceID int    `json:"device_id,omitempty"`
+	Type     string `json:"type,omitempty"`
+}
+
+// Matches reports whether d is what w waits for; a nil w matches any device.
+func (w *WaitingFor) Matches(d Device) bool {
+	switch {
+	case w == nil:
+		return true
+	case w.DeviceID != 0:
+		return d.ID == w.DeviceID
+	}
+	return d.Type == w.Type
+}
+
+// WaitingForDevice counts the queued users waiting for deviceID in
+// particular, by ID or type.
+func (s *Store) WaitingForDevice(deviceID int) int {
+	device := s.DeviceByID(deviceID)
+	if device == nil {
+		return 0
+	}
+	n := 0
+	for _, u := range s.PendingUsers() {
+		if u.WaitingFor != nil && u.WaitingFor.Matches(*device) {
+			n++
+		}
+	}
+	return n
+}
+
+// NextQueuedFor returns who should be seated on deviceID next: the first in
+// the queue waiting for it in particular, or else the first waiting for any
+// device. Users waiting for something else are never chosen; nil means
+// nobody suits it.
+func (s *Store) NextQueuedFor(deviceID int) *User {
+	device := s.DeviceByID(deviceID)
+	if device == nil {
+		return nil
+	}
+	var next *User
+	for _, u := range s.PendingUsers() {
+		if u.WaitingFor != nil && u.WaitingFor.Matches(*device) {
+			return s.UserByID(u.ID)
+		}
+		if u.WaitingFor == nil && next == nil {
+			next = s.UserByID(u.ID)
+		}
+	}
+	return next
+}
@@ -0,0 +1,111 @@
+package state
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Writes that fail are tried WriteAttempts times in all, waiting
+// WriteRetryDelay after the first failure and twice as long after each
+// one after that.
+const (
+	WriteAttempts   = 3
+	WriteRetryDelay = 250 * time.Millisecond
+)
+
+// writeOp is one data file write queued for the background writer. done,
+// if set, hears how it ended on the goroutine that drives the store.
+type writeOp struct {
+	name  string
+	write func() error
+	done  func(err error)
+}
+
+type writeResult struct {
+	op  writeOp
+	err error
+}
+
+// queueWrite hands write to the background writer, which runs the queued
+// writes one at a time in the order they were queued, so the UI never waits
+// on the disk. name describes the write in errors, e.g. "writing queue file".
+// A write that still fails after WriteAttempts goes to writeFailed.
+func (s *Store) queueWrite(name string, write func() error, done func(err error)) {
+	s.writes.Add(1)
+	s.writeMu.Lock()
+	s.writeQueue = append(s.writeQueue, writeOp{name: name, write: write, done: done})
+	start := !s.writing
+	s.writing = true
+	s.writeMu.Unlock()
+	if start {
+		go s.runWrites()
+	}
+}
+
+func (s *Store) runWrites() {
+	for {
+		s.writeMu.Lock()
+		if len(s.writeQueue) == 0 {
+			s.writing = false
+			s.writeMu.Unlock()
+			return
+		}
+		op := s.writeQueue[0]
+		s.writeQueue = s.writeQueue[1:]
+		s.writeMu.Unlock()
+
+		err := attemptWrite(op)
+		if err != nil {
+			s.writeFailed(op.name, err)
+		}
+		if op.done != nil {
+			s.writeMu.Lock()
+			s.writeResults = append(s.writeResults, writeResult{op: op, err: err})
+			s.writeMu.Unlock()
+			if s.Do != nil {
+				s.Do(s.applyWriteResults)
+			}
+		}
+		s.writes.Done()
+	}
+}
+
+// attemptWrite runs op until it succeeds or has failed WriteAttempts times.
+// A file from a newer build is not retried; it will not change.
+func attemptWrite(op writeOp) error {
+	delay := WriteRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := op.write()
+		if err == nil || attempt == WriteAttempts || errors.Is(err, ErrNewerFile) {
+			return err
+		}
+		slog.Warn("write failed, retrying", "write", op.name, "attempt", attempt, "err", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// applyWriteResults tells the finished writes' done callbacks how they
+// went. It runs on the goroutine that drives the store.
+func (s *Store) applyWriteResults() {
+	s.writeMu.Lock()
+	results := s.writeResults
+	s.writeResults = nil
+	s.writeMu.Unlock()
+	for _, result := range results {
+		result.op.done(result.err)
+	}
+}
+
+// WaitForWrites blocks until every queued write has finished. It may be
+// called from any goroutine.
+func (s *Store) WaitForWrites() { s.writes.Wait() }
+
+// FlushWrites waits for every queued write and applies what they left to
+// the in-memory state. Call it from the goroutine that drives the store,
+// e.g. before the app exits.
+func (s *Store) FlushWrites() {
+	s.WaitForWrites()
+	s.applyWriteResults()
+}
@@ -0,0 +1,47 @@
+package state
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newBenchStore is a store with a busy evening's worth of active users.
+func newBenchStore(b *testing.B) *Store {
+	b.Helper()
+	s := NewStore(b.TempDir(), "")
+	for i := range 40 {
+		id := strconv.Itoa(1000 + i)
+		s.ActiveUsers = append(s.ActiveUsers, User{Name: "Member " + id, ID: id, PCID: i + 1, CheckInTime: time.Now()})
+	}
+	return s
+}
+
+// BenchmarkSave compares what a check-in waited for before the writes went
+// to the background writer with what it waits for now. The queued time
+// does not grow with the disk's.
+func BenchmarkSave(b *testing.B) {
+	b.Run("sync", func(b *testing.B) {
+		s := newBenchStore(b)
+		if err := s.EnsureLogDir(); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for range b.N {
+			if err := s.writeActiveUsers(s.ActiveUsers); err != nil {
+				b.Fatal(err)
+			}
+			if err := s.writeJournal(JournalEntry{Time: time.Now(), Action: journalCommit}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("queued", func(b *testing.B) {
+		s := newBenchStore(b)
+		for range b.N {
+			s.Save()
+		}
+		b.StopTimer()
+		s.FlushWrites()
+	})
+}
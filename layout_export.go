@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"image/png"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/software"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+var layoutExportResolutions = []string{"1280x800", "1920x1080", "2560x1600", "3840x2160"}
+
+// newLayoutSnapshotWidget copies the on-screen station arrangement into a
+// detached widget that never shows occupant names. When blank is set every
+// device is drawn as free, giving a plain numbering map.
+func newLayoutSnapshotWidget(source *DeviceStatusLayoutWidget, blank bool) *DeviceStatusLayoutWidget {
+	snapshot := &DeviceStatusLayoutWidget{
+		devicePositions: make(map[int]fyne.Position, len(source.devicePositions)),
+		pcIconSize:      source.pcIconSize,
+		consoleIconSize: source.consoleIconSize,
+		slotMargin:      source.slotMargin,
+		layoutLocked:    true,
+		hideOccupants:   true,
+		blankMap:        blank,
+	}
+	for id, pos := range source.devicePositions {
+		snapshot.devicePositions[id] = pos
+	}
+	snapshot.ensurePositions()
+	snapshot.ExtendBaseWidget(snapshot)
+	return snapshot
+}
+
+func parseResolution(text string) (fyne.Size, error) {
+	var w, h int
+	if _, err := fmt.Sscanf(strings.TrimSpace(text), "%dx%d", &w, &h); err != nil || w <= 0 || h <= 0 {
+		return fyne.Size{}, fmt.Errorf("invalid resolution %q", text)
+	}
+	return fyne.NewSize(float32(w), float32(h)), nil
+}
+
+func renderLayoutImage(size fyne.Size, blank bool) *canvas.Image {
+	snapshot := newLayoutSnapshotWidget(deviceLayoutWidget, blank)
+	background := canvas.NewRectangle(latteBase)
+	c := software.NewCanvas()
+	c.SetPadded(false)
+	c.SetContent(container.NewStack(background, snapshot))
+	c.Resize(size)
+	return canvas.NewImageFromImage(c.Capture())
+}
+
+func showExportLayoutDialog() {
+	if deviceLayoutWidget == nil {
+		return
+	}
+	resolution := widget.NewSelect(layoutExportResolutions, nil)
+	resolution.SetSelected("1920x1080")
+	withStatus := widget.NewCheck("Current status snapshot (unchecked: blank map)", nil)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Resolution", resolution),
+		widget.NewFormItem("", withStatus),
+	}
+	dlg := dialog.NewForm("Export Layout Image", "Export", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		size, err := parseResolution(resolution.Selected)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		img := renderLayoutImage(size, !withStatus.Checked)
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+			if err := png.Encode(writer, img.Image); err != nil {
+				dialog.ShowError(fmt.Errorf("write layout image: %w", err), mainWindow)
+			}
+		}, mainWindow)
+		save.SetFileName("lounge-layout.png")
+		save.SetFilter(storage.NewExtensionFileFilter([]string{".png"}))
+		save.Show()
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(420, dlg.MinSize().Height))
+	dlg.Show()
+}
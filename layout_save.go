@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"lounge/internal/state"
+)
+
+// layoutSaveDelay is how long the layout must sit still before it is written.
+const layoutSaveDelay = 2 * time.Second
+
+// layoutEntry is one device's normalized position in deviceLayoutFile.
+type layoutEntry struct {
+	DeviceID int
+	X, Y     float32
+	// Extra keeps fields written by a newer build.
+	Extra state.Extra `json:"-"`
+}
+
+type plainLayoutEntry layoutEntry
+
+func (e layoutEntry) MarshalJSON() ([]byte, error) {
+	return state.MarshalWithExtra(plainLayoutEntry(e), e.Extra)
+}
+
+func (e *layoutEntry) UnmarshalJSON(data []byte) error {
+	extra, err := state.UnmarshalWithExtra(data, (*plainLayoutEntry)(e))
+	e.Extra = extra
+	return err
+}
+
+// layoutSaver owns the device positions shared by every map widget (the room
+// tab rebuilds its widget on each refresh, and a fresh widget must not reload
+// a file that is still waiting to be written) and debounces writing them.
+// It is only touched from the UI goroutine.
+type layoutSaver struct {
+	positions map[int]fyne.Position
+	// extra is each device's fields from a newer build, written back as read.
+	extra map[int]state.Extra
+	dirty bool
+	timer *time.Timer
+	// editStart is the layout when edit mode was entered, for discarding.
+	editStart map[int]fyne.Position
+}
+
+var deviceLayout = &layoutSaver{}
+
+// markDirty schedules a write layoutSaveDelay after the last change.
+func (s *layoutSaver) markDirty() {
+	s.dirty = true
+	if s.timer != nil {
+		s.timer.Reset(layoutSaveDelay)
+		return
+	}
+	s.timer = time.AfterFunc(layoutSaveDelay, func() {
+		fyne.Do(func() {
+			if err := s.flush(); err != nil {
+				writeFailed("saving device layout", err)
+			}
+		})
+	})
+}
+
+// flush writes the layout now if it has unsaved changes.
+func (s *layoutSaver) flush() error {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	if !s.dirty || s.positions == nil {
+		return nil
+	}
+	entries := make([]layoutEntry, 0, len(s.positions))
+	for deviceID, pos := range s.positions {
+		entries = append(entries, layoutEntry{DeviceID: deviceID, X: pos.X, Y: pos.Y, Extra: s.extra[deviceID]})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].DeviceID < entries[j].DeviceID })
+	if err := state.GuardRewrite(deviceLayoutFile, state.LayoutVersion); err != nil {
+		return err
+	}
+	data, err := state.EncodeVersioned("devices", state.LayoutVersion, entries)
+	if err != nil {
+		return fmt.Errorf("marshal device layout: %w", err)
+	}
+	if err := state.WriteFileAtomic(deviceLayoutFile, data, 0o644); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+func (s *layoutSaver) beginEdit() {
+	s.editStart = maps.Clone(s.positions)
+}
+
+// editedSinceBegin reports whether any device moved since beginEdit.
+func (s *layoutSaver) editedSinceBegin() bool {
+	return s.editStart != nil && !maps.Equal(s.editStart, s.positions)
+}
+
+// endEdit keeps or discards the changes made since beginEdit and saves.
+func (s *layoutSaver) endEdit(keep bool) error {
+	if !keep && s.editStart != nil {
+		clear(s.positions)
+		maps.Copy(s.positions, s.editStart)
+		s.dirty = true
+	}
+	s.editStart = nil
+	return s.flush()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+
+	"lounge/internal/state"
+)
+
+func TestLayoutKeepsFieldsFromTheFuture(t *testing.T) {
+	savedFile, savedStore, savedLayout := deviceLayoutFile, store, deviceLayout
+	t.Cleanup(func() { deviceLayoutFile, store, deviceLayout = savedFile, savedStore, savedLayout })
+	dir := t.TempDir()
+	deviceLayoutFile = filepath.Join(dir, "device_layout.json")
+	store = state.NewStore(dir, "")
+	store.Devices = []state.Device{{ID: 1, Type: "PC", Status: "free"}, {ID: 2, Type: "PC", Status: "free"}}
+	deviceLayout = &layoutSaver{}
+	if err := os.WriteFile(deviceLayoutFile, []byte(`{"version": 1, "devices": [
+		{"DeviceID": 1, "X": 0.1, "Y": 0.2, "Rotation": 90},
+		{"DeviceID": 2, "X": 0.3, "Y": 0.4}
+	]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	layoutWidget := &DeviceStatusLayoutWidget{}
+	layoutWidget.loadDeviceLayout()
+	deviceLayout.positions = layoutWidget.devicePositions
+	deviceLayout.positions[2] = fyne.NewPos(0.5, 0.6)
+	deviceLayout.dirty = true
+	if err := deviceLayout.flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(deviceLayoutFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []map[string]any
+	if err := state.DecodeVersioned(deviceLayoutFile, data, "devices", state.LayoutVersion, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("layout has %d devices, want 2:\n%s", len(entries), data)
+	}
+	if entries[0]["Rotation"] != float64(90) {
+		t.Errorf("device 1 lost its rotation: %v", entries[0])
+	}
+	if entries[1]["X"] != 0.5 {
+		t.Errorf("device 2 is at %v, want the move saved", entries[1])
+	}
+}
+
+func TestRapidDragsSaveOnceWithTheFinalLayout(t *testing.T) {
+	test.NewTempApp(t)
+	savedFile, savedStore, savedLayout := deviceLayoutFile, store, deviceLayout
+	t.Cleanup(func() { deviceLayoutFile, store, deviceLayout = savedFile, savedStore, savedLayout })
+	dir := t.TempDir()
+	deviceLayoutFile = filepath.Join(dir, "device_layout.json")
+	store = state.NewStore(dir, "")
+	store.Devices = []state.Device{{ID: 1, Type: "PC", Status: "free"}, {ID: 2, Type: "PC", Status: "free"}}
+	deviceLayout = &layoutSaver{}
+	t.Cleanup(func() { deviceLayout.flush() })
+
+	layoutWidget := NewDeviceStatusLayoutWidget()
+	layoutWidget.containerSize = fyne.NewSize(1000, 500)
+	drop := func(deviceID int, x, y float32) {
+		layoutWidget.isDragging = true
+		layoutWidget.draggingDeviceID = deviceID
+		layoutWidget.transientDragPos = fyne.NewPos(x, y)
+		layoutWidget.DragEnd()
+	}
+	for i := range 20 {
+		drop(1+i%2, float32(100+10*i), 250)
+	}
+	drop(1, 500, 100)
+	drop(2, 250, 400)
+
+	if _, err := os.Stat(deviceLayoutFile); !os.IsNotExist(err) {
+		t.Fatalf("a drag wrote the layout before the debounce: %v", err)
+	}
+	if !deviceLayout.dirty {
+		t.Fatal("the drags left the layout clean")
+	}
+	// The debounce timer, or closing the app, writes it once.
+	if err := deviceLayout.flush(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(deviceLayoutFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []layoutEntry
+	if err := state.DecodeVersioned(deviceLayoutFile, data, "devices", state.LayoutVersion, &entries); err != nil {
+		t.Fatalf("layout file is not coherent: %v\n%s", err, data)
+	}
+	want := map[int]fyne.Position{1: fyne.NewPos(0.5, 0.2), 2: fyne.NewPos(0.25, 0.8)}
+	for _, entry := range entries {
+		if pos, ok := want[entry.DeviceID]; ok && (entry.X != pos.X || entry.Y != pos.Y) {
+			t.Errorf("device %d saved at (%v, %v), want the last drop %v", entry.DeviceID, entry.X, entry.Y, pos)
+		}
+		delete(want, entry.DeviceID)
+	}
+	if len(want) > 0 {
+		t.Errorf("devices missing from the layout: %v\n%s", want, data)
+	}
+	if deviceLayout.dirty {
+		t.Error("still dirty after the save")
+	}
+}
@@ -0,0 +1,1173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"maps"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+type DeviceStatusLayoutWidget struct {
+	widget.BaseWidget
+	containerSize    fyne.Size
+	devicePositions  map[int]fyne.Position // normalized 0-1 coords
+	draggingDeviceID int
+	dragOffset       fyne.Position
+	isDragging       bool
+	transientDragPos fyne.Position
+	layoutLocked     bool
+	swapDragActive   bool
+	focused          bool
+	shiftDown        bool
+	tapModifier      fyne.KeyModifier // modifiers held at the last primary click
+	banding          bool             // rubber-band selection in edit mode
+	bandStart        fyne.Position
+	bandEnd          fyne.Position
+	hideOccupants    bool // omit occupant names (printed maps)
+	blankMap         bool // draw every device as free
+
+	pcIconSize      float32
+	consoleIconSize float32
+	slotMargin      float32
+	compact         bool // denser arrangement for small screens
+}
+
+func NewDeviceStatusLayoutWidget() *DeviceStatusLayoutWidget {
+	layoutWidget := &DeviceStatusLayoutWidget{
+		devicePositions: deviceLayout.positions,
+		pcIconSize:      64,
+		consoleIconSize: 64,
+		slotMargin:      24,
+	}
+	if layoutWidget.devicePositions == nil {
+		layoutWidget.loadDeviceLayout()
+		deviceLayout.positions = layoutWidget.devicePositions
+	}
+	layoutWidget.ExtendBaseWidget(layoutWidget)
+	return layoutWidget
+}
+
+// defaultPositions returns the room-layout default normalized positions (0–1)
+// for all 18 devices, matching the physical arrangement in the lounge.
+func (layoutWidget *DeviceStatusLayoutWidget) defaultPositions() map[int]fyne.Position {
+	cols := []float32{0.11, 0.28, 0.46, 0.63}
+	topRows := []float32{0.28, 0.42}
+	btmRows := []float32{0.64, 0.79}
+	conX := float32(0.87)
+	return map[int]fyne.Position{
+		// top group (PCs 9-16)
+		16: {X: cols[0], Y: topRows[0]},
+		15: {X: cols[1], Y: topRows[0]},
+		14: {X: cols[2], Y: topRows[0]},
+		13: {X: cols[3], Y: topRows[0]},
+		9:  {X: cols[0], Y: topRows[1]},
+		10: {X: cols[1], Y: topRows[1]},
+		11: {X: cols[2], Y: topRows[1]},
+		12: {X: cols[3], Y: topRows[1]},
+		// bottom group (PCs 1-8)
+		8: {X: cols[0], Y: btmRows[0]},
+		7: {X: cols[1], Y: btmRows[0]},
+		6: {X: cols[2], Y: btmRows[0]},
+		5: {X: cols[3], Y: btmRows[0]},
+		1: {X: cols[0], Y: btmRows[1]},
+		2: {X: cols[1], Y: btmRows[1]},
+		3: {X: cols[2], Y: btmRows[1]},
+		4: {X: cols[3], Y: btmRows[1]},
+		// consoles (right side)
+		17: {X: conX, Y: 0.28}, // XBOX
+		18: {X: conX, Y: 0.68}, // PS4
+	}
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) ensurePositions() {
+	defaults := layoutWidget.defaultPositions()
+	for _, device := range store.Devices {
+		if _, ok := layoutWidget.devicePositions[device.ID]; !ok {
+			if def, ok2 := defaults[device.ID]; ok2 {
+				layoutWidget.devicePositions[device.ID] = def
+			} else {
+				layoutWidget.devicePositions[device.ID] = fyne.NewPos(0.05, 0.05)
+			}
+		}
+	}
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) loadDeviceLayout() {
+	_ = store.EnsureLogDir()
+	data, err := os.ReadFile(deviceLayoutFile)
+	if err != nil || len(data) == 0 {
+		layoutWidget.devicePositions = make(map[int]fyne.Position)
+		layoutWidget.ensurePositions()
+		deviceLayout.markDirty()
+		return
+	}
+	var entries []layoutEntry
+	// A newer build's layout is still used; flush refuses to rewrite it.
+	if err := state.DecodeVersioned(deviceLayoutFile, data, "devices", state.LayoutVersion, &entries); err != nil &&
+		!errors.Is(err, state.ErrNewerFile) {
+		layoutWidget.devicePositions = make(map[int]fyne.Position)
+		layoutWidget.ensurePositions()
+		deviceLayout.markDirty()
+		return
+	}
+	layoutWidget.devicePositions = make(map[int]fyne.Position)
+	deviceLayout.extra = make(map[int]state.Extra)
+	allZero := true
+	for _, entry := range entries {
+		layoutWidget.devicePositions[entry.DeviceID] = fyne.NewPos(entry.X, entry.Y)
+		if entry.Extra != nil {
+			deviceLayout.extra[entry.DeviceID] = entry.Extra
+		}
+		if entry.X != 0 || entry.Y != 0 {
+			allZero = false
+		}
+	}
+	// If all positions are zero it's probably an old slot-format file; reset to defaults.
+	if allZero {
+		layoutWidget.devicePositions = make(map[int]fyne.Position)
+	}
+	if state.FileVersion(data) < state.LayoutVersion && !readOnly {
+		deviceLayout.markDirty()
+	}
+	layoutWidget.ensurePositions()
+}
+
+// computeIconSizes derives pcIconSize / consoleIconSize from the current container.
+func (layoutWidget *DeviceStatusLayoutWidget) computeIconSizes() {
+	if layoutWidget.containerSize.IsZero() {
+		return
+	}
+	// Assume ~4 columns across ~75% of width and ~5 rows tall.
+	colW := layoutWidget.containerSize.Width * 0.18
+	rowH := layoutWidget.containerSize.Height * 0.16
+	iconBase := float32(math.Min(float64(colW), float64(rowH))) * 0.55
+	scale := mapScale()
+	if layoutWidget.compact {
+		// Pack tighter: icons take more of their cell and may get smaller.
+		iconBase = float32(math.Min(float64(colW), float64(rowH))) * 0.65
+		layoutWidget.pcIconSize = clampFloat(iconBase, 28*scale, 72*scale)
+		layoutWidget.consoleIconSize = clampFloat(iconBase*1.05, 32*scale, 80*scale)
+		return
+	}
+	layoutWidget.pcIconSize = clampFloat(iconBase, 40*scale, 96*scale)
+	layoutWidget.consoleIconSize = clampFloat(iconBase*1.05, 48*scale, 104*scale)
+}
+
+// setCompact switches the map between its normal and compact arrangement.
+func (layoutWidget *DeviceStatusLayoutWidget) setCompact(compact bool) {
+	if layoutWidget.compact == compact {
+		return
+	}
+	layoutWidget.compact = compact
+	layoutWidget.slotMargin = 24
+	if compact {
+		layoutWidget.slotMargin = 12
+	}
+	layoutWidget.computeIconSizes()
+	layoutWidget.Refresh()
+}
+
+// normalizePos converts an absolute widget-relative position to 0-1 coords.
+func (layoutWidget *DeviceStatusLayoutWidget) normalizePos(pos fyne.Position) fyne.Position {
+	w := layoutWidget.containerSize.Width
+	h := layoutWidget.containerSize.Height
+	if w <= 0 {
+		w = 1
+	}
+	if h <= 0 {
+		h = 1
+	}
+	x := clampFloat(pos.X/w, 0, 1)
+	y := clampFloat(pos.Y/h, 0, 1)
+	return fyne.NewPos(x, y)
+}
+
+// absolutePos converts normalized 0-1 coords to absolute widget-relative position.
+func (layoutWidget *DeviceStatusLayoutWidget) absolutePos(norm fyne.Position) fyne.Position {
+	return fyne.NewPos(norm.X*layoutWidget.containerSize.Width, norm.Y*layoutWidget.containerSize.Height)
+}
+
+func clampFloat(value, minValue, maxValue float32) float32 {
+	if value < minValue {
+		return minValue
+	}
+	if value > maxValue {
+		return maxValue
+	}
+	return value
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) UpdateDevices() {
+	layoutWidget.ensurePositions()
+	layoutWidget.announceFocus()
+	layoutWidget.Refresh()
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) SetLayoutLocked(locked bool) {
+	layoutWidget.layoutLocked = locked
+	layoutWidget.isDragging = false
+	layoutWidget.draggingDeviceID = 0
+	layoutWidget.Refresh()
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) ResetLayout() {
+	clear(layoutWidget.devicePositions)
+	maps.Copy(layoutWidget.devicePositions, layoutWidget.defaultPositions())
+	layoutWidget.ensurePositions()
+	deviceLayout.markDirty()
+	layoutWidget.Refresh()
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) Tapped(tapEvent *fyne.PointEvent) {
+	if !isMultiSelect(layoutWidget.tapModifier) && layoutWidget.tappedAwayBadge(tapEvent.Position) {
+		return
+	}
+	for _, device := range store.Devices {
+		center := layoutWidget.positionForDevice(device.ID)
+		size := layoutWidget.iconSizeForDevice(device.ID)
+		topLeft := fyne.NewPos(center.X-size/2, center.Y-size/2)
+
+		if tapEvent.Position.X < topLeft.X || tapEvent.Position.X > topLeft.X+size ||
+			tapEvent.Position.Y < topLeft.Y || tapEvent.Position.Y > topLeft.Y+size {
+			continue
+		}
+		if isMultiSelect(layoutWidget.tapModifier) {
+			layoutWidget.setFocusDevice(device.ID)
+			toggleDeviceSelection(device.ID)
+			return
+		}
+		if deviceDialogRecentlyOpened(device.ID) {
+			return
+		}
+		layoutWidget.setFocusDevice(device.ID)
+		layoutWidget.activateDevice(device)
+		return
+	}
+	if !isMultiSelect(layoutWidget.tapModifier) {
+		clearDeviceSelection()
+	}
+}
+
+// activateDevice runs a device's primary action: seat the user in assignment
+// mode, otherwise check in on a free device or check out an occupied one.
+func (layoutWidget *DeviceStatusLayoutWidget) activateDevice(device state.Device) {
+	if readOnly {
+		showReadOnlyNotice()
+		return
+	}
+	if assignmentUserID != "" {
+		targetUserID := assignmentUserID
+		assignmentUserID = ""
+		if assignmentNoticeLabel != nil {
+			assignmentNoticeLabel.SetText("")
+		}
+		assignQueuedConfirming(targetUserID, device, nil)
+		return
+	}
+
+	if device.Status == state.StatusMaintenance {
+		dialog.ShowInformation("Under Maintenance",
+			device.Name()+" is under maintenance. Clear it from the right-click menu before seating anyone.", mainWindow)
+		return
+	}
+
+	if device.Type == "Console" {
+		if device.Status == "occupied" {
+			showConsoleCheckoutDialog(device)
+		} else {
+			showCheckInDialogShared(device.ID, true)
+		}
+		return
+	}
+
+	if device.Status == "occupied" {
+		user := store.UserByID(device.UserID)
+		userName := "Unknown User"
+		if user != nil {
+			userName = user.Name
+		}
+		dlg := dialog.NewConfirm(
+			"Confirm Checkout",
+			fmt.Sprintf("Checkout %s from PC %d?", userName, device.ID)+awayWarning(device.UserID),
+			func(confirm bool) {
+				if confirm {
+					requireStaffPIN("Check Out", func() {
+						if err := store.Checkout(device.UserID); err != nil {
+							dialog.ShowError(err, mainWindow)
+						}
+					})
+				}
+			},
+			mainWindow,
+		)
+		trackDeviceDialog(device.ID, dlg)
+		dlg.Show()
+		return
+	}
+
+	showCheckInDialogShared(device.ID, true)
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) MouseDown(mouseEvent *desktop.MouseEvent) {
+	if mouseEvent.Button != desktop.MouseButtonSecondary {
+		layoutWidget.tapModifier = mouseEvent.Modifier
+		return
+	}
+	for _, device := range store.Devices {
+		center := layoutWidget.positionForDevice(device.ID)
+		size := layoutWidget.iconSizeForDevice(device.ID)
+		topLeft := fyne.NewPos(center.X-size/2, center.Y-size/2)
+		if mouseEvent.Position.X >= topLeft.X && mouseEvent.Position.X <= topLeft.X+size &&
+			mouseEvent.Position.Y >= topLeft.Y && mouseEvent.Position.Y <= topLeft.Y+size {
+			if readOnly {
+				showReadOnlyNotice()
+				return
+			}
+			showDeviceContextMenu(device, mouseEvent.AbsolutePosition)
+			return
+		}
+	}
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) MouseUp(_ *desktop.MouseEvent) {}
+
+func (layoutWidget *DeviceStatusLayoutWidget) Dragged(dragEvent *fyne.DragEvent) {
+	if layoutWidget.banding {
+		layoutWidget.bandEnd = dragEvent.Position
+		layoutWidget.Refresh()
+		return
+	}
+	if !layoutWidget.isDragging {
+		for _, device := range store.Devices {
+			center := layoutWidget.positionForDevice(device.ID)
+			size := layoutWidget.iconSizeForDevice(device.ID)
+			topLeft := fyne.NewPos(center.X-size/2, center.Y-size/2)
+			if dragEvent.Position.X >= topLeft.X && dragEvent.Position.X <= topLeft.X+size &&
+				dragEvent.Position.Y >= topLeft.Y && dragEvent.Position.Y <= topLeft.Y+size {
+				if layoutWidget.layoutLocked && (device.Type != "PC" || device.Status != "occupied") {
+					continue
+				}
+				layoutWidget.isDragging = true
+				layoutWidget.draggingDeviceID = device.ID
+				layoutWidget.dragOffset = fyne.NewPos(dragEvent.Position.X-center.X, dragEvent.Position.Y-center.Y)
+				layoutWidget.transientDragPos = center
+				layoutWidget.swapDragActive = layoutWidget.layoutLocked && device.Type == "PC" && device.Status == "occupied"
+				if layoutWidget.swapDragActive {
+					updateDragOverlay(dragEvent.AbsolutePosition, true)
+				}
+				break
+			}
+		}
+		// In edit mode a drag from empty floor selects a group of devices.
+		if !layoutWidget.isDragging && !layoutWidget.layoutLocked {
+			layoutWidget.banding = true
+			layoutWidget.bandStart = dragEvent.Position.Subtract(dragEvent.Dragged)
+			layoutWidget.bandEnd = dragEvent.Position
+			layoutWidget.Refresh()
+			return
+		}
+	}
+	if layoutWidget.isDragging && layoutWidget.draggingDeviceID != 0 {
+		newX := dragEvent.Position.X - layoutWidget.dragOffset.X
+		newY := dragEvent.Position.Y - layoutWidget.dragOffset.Y
+		minX := layoutWidget.slotMargin + layoutWidget.pcIconSize/2
+		maxX := layoutWidget.containerSize.Width - layoutWidget.slotMargin - layoutWidget.pcIconSize/2
+		minY := layoutWidget.slotMargin + layoutWidget.pcIconSize/2
+		maxY := layoutWidget.containerSize.Height - layoutWidget.slotMargin - layoutWidget.pcIconSize/2
+		if newX < minX {
+			newX = minX
+		}
+		if newX > maxX {
+			newX = maxX
+		}
+		if newY < minY {
+			newY = minY
+		}
+		if newY > maxY {
+			newY = maxY
+		}
+		layoutWidget.transientDragPos = fyne.NewPos(newX, newY)
+		if layoutWidget.layoutLocked && layoutWidget.swapDragActive {
+			updateDragOverlay(dragEvent.AbsolutePosition, true)
+		} else {
+			layoutWidget.Refresh()
+		}
+	}
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) DragEnd() {
+	if layoutWidget.banding {
+		layoutWidget.banding = false
+		layoutWidget.selectInBand(layoutWidget.bandStart, layoutWidget.bandEnd, isMultiSelect(layoutWidget.tapModifier))
+		return
+	}
+	if !layoutWidget.isDragging || layoutWidget.draggingDeviceID == 0 {
+		if layoutWidget.swapDragActive {
+			updateDragOverlay(fyne.NewPos(0, 0), false)
+		}
+		layoutWidget.isDragging = false
+		layoutWidget.draggingDeviceID = 0
+		layoutWidget.swapDragActive = false
+		return
+	}
+	if layoutWidget.layoutLocked {
+		layoutWidget.handleLockedDrop()
+		if layoutWidget.swapDragActive {
+			updateDragOverlay(fyne.NewPos(0, 0), false)
+		}
+		layoutWidget.isDragging = false
+		layoutWidget.draggingDeviceID = 0
+		layoutWidget.swapDragActive = false
+		layoutWidget.Refresh()
+		return
+	}
+	// Free-form layout: store the dropped position directly (normalized).
+	layoutWidget.devicePositions[layoutWidget.draggingDeviceID] = layoutWidget.normalizePos(layoutWidget.transientDragPos)
+	deviceLayout.markDirty()
+	layoutWidget.isDragging = false
+	layoutWidget.draggingDeviceID = 0
+	if layoutWidget.swapDragActive {
+		updateDragOverlay(fyne.NewPos(0, 0), false)
+	}
+	layoutWidget.Refresh()
+	layoutWidget.swapDragActive = false
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) handleLockedDrop() {
+	sourceDevice := store.DeviceByID(layoutWidget.draggingDeviceID)
+	if sourceDevice == nil || sourceDevice.Type != "PC" || sourceDevice.Status != "occupied" || sourceDevice.UserID == "" {
+		return
+	}
+	targetDevice := layoutWidget.deviceAtPosition(layoutWidget.transientDragPos)
+	if targetDevice == nil || targetDevice.Type != "PC" || targetDevice.Status != "free" {
+		return
+	}
+	switchDeviceConfirming(sourceDevice.UserID, targetDevice.ID)
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) positionForDevice(deviceID int) fyne.Position {
+	if layoutWidget.isDragging && !layoutWidget.layoutLocked && layoutWidget.draggingDeviceID == deviceID {
+		return layoutWidget.transientDragPos
+	}
+	if norm, ok := layoutWidget.devicePositions[deviceID]; ok {
+		return layoutWidget.absolutePos(norm)
+	}
+	return fyne.NewPos(layoutWidget.slotMargin+layoutWidget.pcIconSize, layoutWidget.slotMargin+layoutWidget.pcIconSize)
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) iconSizeForDevice(deviceID int) float32 {
+	if device := store.DeviceByID(deviceID); device != nil && device.Type == "Console" {
+		return layoutWidget.consoleIconSize
+	}
+	return layoutWidget.pcIconSize
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) deviceAtPosition(pos fyne.Position) *state.Device {
+	for i := range store.Devices {
+		device := &store.Devices[i]
+		center := layoutWidget.positionForDevice(device.ID)
+		size := layoutWidget.iconSizeForDevice(device.ID)
+		topLeft := fyne.NewPos(center.X-size/2, center.Y-size/2)
+		if pos.X >= topLeft.X && pos.X <= topLeft.X+size &&
+			pos.Y >= topLeft.Y && pos.Y <= topLeft.Y+size {
+			return device
+		}
+	}
+	return nil
+}
+
+func updateDragOverlay(pos fyne.Position, active bool) {
+	if mainWindow == nil {
+		return
+	}
+	canvasObj := mainWindow.Canvas()
+	if active {
+		if dragIndicator == nil {
+			img := canvas.NewImageFromResource(iconCache.Get("move.png", glyph{Letter: "↔", Color: lattePrimary}))
+			img.SetMinSize(fyne.NewSize(84, 84))
+			img.Resize(fyne.NewSize(84, 84))
+			dragIndicator = img
+		}
+		dragIndicator.Move(fyne.NewPos(pos.X+20, pos.Y+20))
+		if !dragIndicatorVisible {
+			canvasObj.Overlays().Add(dragIndicator)
+			dragIndicatorVisible = true
+		}
+		dragIndicator.Show()
+		dragIndicator.Refresh()
+	} else if dragIndicatorVisible {
+		canvasObj.Overlays().Remove(dragIndicator)
+		dragIndicatorVisible = false
+	}
+}
+
+func updateQueuedUserDrag(userID string, pos fyne.Position) {
+	if pendingDragActive && pendingDragUserID != userID {
+		return
+	}
+	pendingDragUserID = userID
+	pendingDragActive = true
+	pendingDragLastPos = pos
+	updateDragOverlay(pos, true)
+}
+
+func endQueuedUserDrag() {
+	if !pendingDragActive {
+		return
+	}
+	updateDragOverlay(fyne.NewPos(0, 0), false)
+	if target := pendingIconIndexAt(pendingDragLastPos); target >= 0 {
+		moveQueuedUser(pendingDragUserID, target)
+	} else {
+		attemptAssignQueuedUserAtPos(pendingDragLastPos)
+	}
+	pendingDragActive = false
+	pendingDragUserID = ""
+}
+
+// pendingIconIndexAt returns the queue position of the pending icon under
+// absPos, or -1 when absPos is not over one.
+func pendingIconIndexAt(absPos fyne.Position) int {
+	driver := fyne.CurrentApp().Driver()
+	for i, icon := range pendingIconWidgets {
+		pos := driver.AbsolutePositionForObject(icon)
+		size := icon.Size()
+		if absPos.X >= pos.X && absPos.X <= pos.X+size.Width &&
+			absPos.Y >= pos.Y && absPos.Y <= pos.Y+size.Height {
+			return i
+		}
+	}
+	return -1
+}
+
+// moveQueuedUser puts userID at queue position index, where a dragged
+// pending icon was dropped onto another.
+func moveQueuedUser(userID string, index int) {
+	if readOnly {
+		showReadOnlyNotice()
+		return
+	}
+	if err := store.MoveQueued(userID, index); err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	refreshPendingIcons()
+}
+
+func cancelQueuedUserDrag() {
+	if !pendingDragActive {
+		return
+	}
+	updateDragOverlay(fyne.NewPos(0, 0), false)
+	pendingDragActive = false
+	pendingDragUserID = ""
+}
+
+// assignQueuedConfirming seats a queued user on device, asking staff about
+// each warning from store.Assignable, such as a device being cleaned or not
+// the one the user is waiting for, then calls done if they were seated.
+// Refusals are shown in a dialog.
+func assignQueuedConfirming(userID string, device state.Device, done func()) {
+	store.AssignQueuedConfirming(userID, device.ID, state.RegisterOptions{}, confirmWarningDialog, seatingDone(done))
+}
+
+// switchDeviceConfirming moves an active user to deviceID, asking staff
+// about each warning from store.Assignable as assignQueuedConfirming does.
+func switchDeviceConfirming(userID string, deviceID int) {
+	store.SwitchDeviceConfirming(userID, deviceID, state.RegisterOptions{}, confirmWarningDialog, seatingDone(nil))
+}
+
+func confirmWarningDialog(warning *state.ValidationWarning, answer func(bool)) {
+	dialog.ShowConfirm(warning.Title, warningMessage(warning), answer, mainWindow)
+}
+
+// seatingDone shows a refusal in a dialog, ignores a warning staff declined,
+// and otherwise calls done.
+func seatingDone(done func()) func(error) {
+	return func(err error) {
+		var declined *state.ValidationWarning
+		switch {
+		case errors.As(err, &declined):
+		case err != nil:
+			dialog.ShowError(err, mainWindow)
+		case done != nil:
+			done()
+		}
+	}
+}
+
+func attemptAssignQueuedUserAtPos(absPos fyne.Position) {
+	if pendingDragUserID == "" || deviceLayoutWidget == nil {
+		return
+	}
+	driver := fyne.CurrentApp().Driver()
+	if driver == nil {
+		return
+	}
+	widgetPos := driver.AbsolutePositionForObject(deviceLayoutWidget)
+	size := deviceLayoutWidget.Size()
+	if absPos.X < widgetPos.X || absPos.Y < widgetPos.Y ||
+		absPos.X > widgetPos.X+size.Width || absPos.Y > widgetPos.Y+size.Height {
+		return
+	}
+	rel := fyne.NewPos(absPos.X-widgetPos.X, absPos.Y-widgetPos.Y)
+	target := deviceLayoutWidget.deviceAtPosition(rel)
+	if target == nil {
+		cancelQueuedUserDrag()
+		return
+	}
+	assignQueuedConfirming(pendingDragUserID, *target, func() {
+		if pendingIconsBox != nil {
+			refreshPendingIcons()
+		}
+	})
+	cancelQueuedUserDrag()
+}
+
+type deviceVisual struct {
+	selection *canvas.Rectangle
+	icon      *canvas.Image
+	primary   *canvas.Text
+	secondary *canvas.Text
+	marker    *canvas.Circle
+	// markerSquare and markerCore draw the maintenance and reserved
+	// shapes; marker is hidden for a square.
+	markerSquare *canvas.Rectangle
+	markerCore   *canvas.Circle
+	away         *canvas.Image
+	// endingSoon marks a session near the session limit.
+	endingSoon *canvas.Circle
+	// streak is the occupant's attendance streak, after their name.
+	streak *canvas.Text
+}
+
+// objects are the canvas objects of v, in drawing order.
+func (v *deviceVisual) objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{v.selection, v.icon, v.primary, v.secondary, v.marker, v.markerSquare, v.markerCore, v.away, v.endingSoon, v.streak}
+}
+
+// statusShape is a colour-independent status indicator drawn by the renderer in
+// the corner of each device icon, so each status can be told apart without
+// relying on the red/green artwork in src/. The map legend shows the same
+// shapes.
+type statusShape int
+
+const (
+	statusShapeRing     statusShape = iota // free: hollow ring ○
+	statusShapeDot                         // occupied: filled dot ●
+	statusShapeSquare                      // maintenance: hollow square □
+	statusShapeBullseye                    // reserved: ring around a dot ◉
+)
+
+func statusShapeForDevice(device state.Device) statusShape {
+	switch mapStatusOf(device) {
+	case mapStatusOccupied:
+		return statusShapeDot
+	case mapStatusMaintenance:
+		return statusShapeSquare
+	case mapStatusReserved:
+		return statusShapeBullseye
+	}
+	return statusShapeRing
+}
+
+type deviceStatusRenderer struct {
+	widget    *DeviceStatusLayoutWidget
+	objects   []fyne.CanvasObject
+	visuals   map[int]*deviceVisual
+	focusRing *canvas.Rectangle
+	band      *canvas.Rectangle
+}
+
+func (renderer *deviceStatusRenderer) Layout(size fyne.Size) {
+	if renderer.widget.containerSize != size {
+		renderer.widget.containerSize = size
+		renderer.widget.computeIconSizes()
+	}
+	renderer.Refresh()
+}
+
+// MinSize shrinks when the desk may go compact, so the window can be made
+// small enough to get there.
+func (renderer *deviceStatusRenderer) MinSize() fyne.Size {
+	if compactModeSetting() == compactNever {
+		return fyne.NewSize(840, 520)
+	}
+	return fyne.NewSize(420, 320)
+}
+
+func firstLast(name string) string {
+	parts := strings.Fields(strings.TrimSpace(name))
+	if len(parts) >= 2 {
+		return parts[0] + " " + parts[1]
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return ""
+}
+
+func firstLastNonEmpty(name string) string {
+	if trimmed := strings.TrimSpace(name); trimmed != "" {
+		if out := firstLast(trimmed); out != "" {
+			return out
+		}
+		return trimmed
+	}
+	return "Unnamed"
+}
+
+func (renderer *deviceStatusRenderer) Refresh() {
+	for _, device := range store.Devices {
+		visual, ok := renderer.visuals[device.ID]
+		if !ok {
+			visual = renderer.newVisualForDevice(device)
+			renderer.visuals[device.ID] = visual
+			renderer.objects = append(renderer.objects, visual.objects()...)
+		}
+		renderer.updateVisual(device, visual)
+	}
+	renderer.updateFocusRing()
+	renderer.updateBand()
+}
+
+// updateBand draws the rubber band while a group selection is being dragged.
+func (renderer *deviceStatusRenderer) updateBand() {
+	layoutWidget := renderer.widget
+	if !layoutWidget.banding {
+		renderer.band.Hide()
+		return
+	}
+	a, b := layoutWidget.bandStart, layoutWidget.bandEnd
+	renderer.band.Move(fyne.NewPos(min(a.X, b.X), min(a.Y, b.Y)))
+	renderer.band.Resize(fyne.NewSize(max(a.X, b.X)-min(a.X, b.X), max(a.Y, b.Y)-min(a.Y, b.Y)))
+	renderer.band.Show()
+	canvas.Refresh(renderer.band)
+}
+
+func (renderer *deviceStatusRenderer) Objects() []fyne.CanvasObject { return renderer.objects }
+func (renderer *deviceStatusRenderer) Destroy()                     {}
+
+func (renderer *deviceStatusRenderer) newVisualForDevice(device state.Device) *deviceVisual {
+	icon := canvas.NewImageFromResource(deviceIcon(device, false))
+	icon.FillMode = canvas.ImageFillContain
+	primary := canvas.NewText("", primaryTextColor())
+	primary.Alignment = fyne.TextAlignCenter
+	secondary := canvas.NewText("", secondaryTextColor())
+	secondary.Alignment = fyne.TextAlignCenter
+	secondary.TextSize = 10 * mapScale()
+	marker := canvas.NewCircle(latteBase)
+	marker.StrokeColor = latteText
+	marker.StrokeWidth = 2
+	markerSquare := canvas.NewRectangle(latteBase)
+	markerSquare.StrokeColor = latteText
+	markerSquare.StrokeWidth = 2
+	markerSquare.Hide()
+	markerCore := canvas.NewCircle(latteText)
+	markerCore.Hide()
+	selection := canvas.NewRectangle(color.Transparent)
+	selection.StrokeColor = theme.PrimaryColor()
+	selection.StrokeWidth = 2
+	selection.CornerRadius = 6
+	selection.Hide()
+	return &deviceVisual{selection: selection, icon: icon, primary: primary, secondary: secondary, marker: marker, markerSquare: markerSquare,
+		markerCore: markerCore, away: newAwayBadge(), endingSoon: newEndingSoonBadge(), streak: newStreakBadge()}
+}
+
+func (renderer *deviceStatusRenderer) updateVisual(device state.Device, visual *deviceVisual) {
+	if renderer.widget.blankMap {
+		device.Status = "free"
+		device.UserID = ""
+	}
+	center := renderer.widget.positionForDevice(device.ID)
+	size := renderer.widget.iconSizeForDevice(device.ID)
+	if res := deviceIcon(device, device.Status == "occupied"); visual.icon.Resource != res {
+		visual.icon.File = ""
+		visual.icon.Resource = res
+	}
+	visual.icon.Translucency = 0
+	visual.icon.SetMinSize(fyne.NewSize(size, size))
+	visual.icon.Resize(fyne.NewSize(size, size))
+	visual.icon.Move(fyne.NewPos(center.X-size/2, center.Y-size/2))
+	if device.Status == state.StatusMaintenance {
+		visual.icon.Translucency = 0.6
+	}
+	visual.icon.Refresh()
+	renderer.updateMarker(device, visual, center, size)
+	renderer.updateAwayBadge(device, visual.away, center, size)
+	renderer.updateEndingSoonBadge(device, visual.endingSoon, center, size)
+	cleaning, isCleaning := cleaningLabel(device.ID, time.Now())
+	isCleaning = isCleaning && !renderer.widget.blankMap
+	reserved, _ := reservationLabel(device.ID, time.Now())
+	if (deviceSelection[device.ID] || isCleaning) && !renderer.widget.blankMap {
+		selected := size + 8
+		visual.selection.FillColor = color.NRGBA{R: 30, G: 102, B: 245, A: 40}
+		if !deviceSelection[device.ID] {
+			visual.selection.FillColor = cleaningTint
+		}
+		visual.selection.Resize(fyne.NewSize(selected, selected))
+		visual.selection.Move(fyne.NewPos(center.X-selected/2, center.Y-selected/2))
+		visual.selection.Show()
+		visual.selection.Refresh()
+	} else {
+		visual.selection.Hide()
+	}
+
+	nameText := ""
+	if !renderer.widget.hideOccupants {
+		nameText = occupantNames(device)
+	}
+	scale := mapScale()
+	visual.primary.Color = primaryTextColor()
+	visual.secondary.TextSize = 10 * scale
+	secondaryY := center.Y + size/2 + 12*scale
+
+	if nameText != "" {
+		visual.primary.Text = nameText
+		visual.primary.TextStyle = fyne.TextStyle{}
+		visual.primary.TextSize = 12 * scale
+		visual.primary.Refresh()
+		visual.primary.Move(fyne.NewPos(center.X-visual.primary.MinSize().Width/2, center.Y+size/2-4))
+		visual.primary.Show()
+
+		visual.secondary.Text = fmt.Sprintf("%d", device.ID)
+		visual.secondary.Color = secondaryTextColor()
+		if rotation, overdue, ok := rotationLabel(device.ID, time.Now()); ok {
+			visual.secondary.Text += " · " + rotation
+			if overdue {
+				visual.secondary.Color = latteRed
+				// Flash the icon once a second until staff rotate.
+				if time.Now().Second()%2 == 0 {
+					visual.icon.Translucency = 0.5
+				}
+			}
+		}
+		visual.secondary.Refresh()
+		visual.secondary.Move(fyne.NewPos(center.X-visual.secondary.MinSize().Width/2, secondaryY))
+		visual.secondary.Show()
+	} else {
+		visual.primary.Text = strconv.Itoa(device.ID)
+		visual.primary.TextStyle = fyne.TextStyle{Bold: true}
+		visual.primary.TextSize = 12 * scale
+		visual.primary.Refresh()
+		visual.primary.Move(fyne.NewPos(center.X-visual.primary.MinSize().Width/2, center.Y+size/2-4))
+		visual.primary.Show()
+
+		switch {
+		case device.Status == state.StatusMaintenance:
+			visual.secondary.Text = "maintenance"
+			visual.secondary.Color = readableColor(latteSubtext1)
+			visual.secondary.Refresh()
+			visual.secondary.Move(fyne.NewPos(center.X-visual.secondary.MinSize().Width/2, secondaryY))
+			visual.secondary.Show()
+		case isCleaning:
+			visual.secondary.Text = cleaning
+			visual.secondary.Color = cleaningColor
+			visual.secondary.Refresh()
+			visual.secondary.Move(fyne.NewPos(center.X-visual.secondary.MinSize().Width/2, secondaryY))
+			visual.secondary.Show()
+		case reserved != "":
+			visual.secondary.Text = reserved
+			visual.secondary.Color = readableColor(latteAccent)
+			visual.secondary.Refresh()
+			visual.secondary.Move(fyne.NewPos(center.X-visual.secondary.MinSize().Width/2, secondaryY))
+			visual.secondary.Show()
+		default:
+			visual.secondary.Hide()
+		}
+	}
+	renderer.updateStreakBadge(device, visual.streak, visual.primary)
+	if dimmedByFilter(device) && !renderer.widget.blankMap {
+		visual.icon.Translucency = 0.8
+		visual.icon.Refresh()
+		visual.marker.FillColor = dimmedColor(visual.marker.FillColor)
+		visual.marker.StrokeColor = dimmedColor(visual.marker.StrokeColor)
+		visual.marker.Refresh()
+		visual.markerSquare.FillColor = dimmedColor(visual.markerSquare.FillColor)
+		visual.markerSquare.StrokeColor = dimmedColor(visual.markerSquare.StrokeColor)
+		visual.markerSquare.Refresh()
+		visual.markerCore.FillColor = dimmedColor(visual.markerCore.FillColor)
+		visual.markerCore.Refresh()
+		for _, text := range []*canvas.Text{visual.primary, visual.secondary} {
+			text.Color = dimmedColor(text.Color)
+			text.Refresh()
+		}
+	}
+}
+
+// updateMarker places the status shape on the icon's top-right corner.
+func (renderer *deviceStatusRenderer) updateMarker(device state.Device, visual *deviceVisual, center fyne.Position, size float32) {
+	radius := clampFloat(size*0.11, 5, 11)
+	shape := statusShapeRing
+	if !renderer.widget.blankMap {
+		shape = statusShapeForDevice(device)
+	}
+	marker, square, core := visual.marker, visual.markerSquare, visual.markerCore
+	pos := fyne.NewPos(center.X+size/2-radius*1.5, center.Y-size/2-radius/2)
+	marker.FillColor, marker.StrokeColor = latteBase, latteText
+	if shape == statusShapeDot {
+		marker.FillColor, marker.StrokeColor = latteText, latteBase
+	}
+	marker.Resize(fyne.NewSize(radius*2, radius*2))
+	marker.Move(pos)
+	marker.Hidden = shape == statusShapeSquare
+	marker.Refresh()
+
+	square.FillColor, square.StrokeColor = latteBase, latteText
+	square.Resize(fyne.NewSize(radius*2, radius*2))
+	square.Move(pos)
+	square.Hidden = shape != statusShapeSquare
+	square.Refresh()
+
+	core.FillColor = latteText
+	core.Resize(fyne.NewSize(radius, radius))
+	core.Move(pos.AddXY(radius/2, radius/2))
+	core.Hidden = shape != statusShapeBullseye
+	core.Refresh()
+}
+
+// occupantNames is the primary label for a device: the PC's user, or every
+// player on a console.
+func occupantNames(device state.Device) string {
+	if device.Type == "PC" {
+		if device.Status == "occupied" {
+			if user := store.UserByID(device.UserID); user != nil {
+				return firstLast(user.Name)
+			}
+		}
+		return ""
+	}
+	deviceUsers := store.UsersOnDevice(device.ID)
+	names := make([]string, 0, len(deviceUsers))
+	for _, deviceUser := range deviceUsers {
+		names = append(names, firstLast(deviceUser.Name))
+	}
+	return strings.Join(names, ", ")
+}
+
+func (layoutWidget *DeviceStatusLayoutWidget) CreateRenderer() fyne.WidgetRenderer {
+	layoutWidget.computeIconSizes()
+	renderer := &deviceStatusRenderer{
+		widget:  layoutWidget,
+		visuals: make(map[int]*deviceVisual),
+	}
+	for _, device := range store.Devices {
+		visual := renderer.newVisualForDevice(device)
+		renderer.visuals[device.ID] = visual
+		renderer.objects = append(renderer.objects, visual.objects()...)
+	}
+	renderer.focusRing = canvas.NewRectangle(color.Transparent)
+	renderer.focusRing.StrokeWidth = 3
+	renderer.focusRing.CornerRadius = 6
+	renderer.focusRing.Hide()
+	renderer.band = canvas.NewRectangle(color.NRGBA{R: 30, G: 102, B: 245, A: 30})
+	renderer.band.StrokeColor = theme.PrimaryColor()
+	renderer.band.StrokeWidth = 1
+	renderer.band.Hide()
+	renderer.objects = append(renderer.objects, renderer.focusRing, renderer.band)
+	return renderer
+}
+
+type leftRatioLayout struct {
+	ratio float32
+	minW  float32
+	maxW  float32
+}
+
+func (l *leftRatioLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	if len(objects) == 0 {
+		return
+	}
+	child := objects[0]
+	w := size.Width * l.ratio
+	if l.minW > 0 && w < l.minW {
+		w = l.minW
+	}
+	if l.maxW > 0 && w > l.maxW {
+		w = l.maxW
+	}
+	child.Resize(fyne.NewSize(w, child.MinSize().Height))
+	child.Move(fyne.NewPos(0, 0))
+}
+
+func (l *leftRatioLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	if len(objects) == 0 {
+		return fyne.NewSize(0, 0)
+	}
+	min := objects[0].MinSize()
+	if l.minW > 0 && min.Width < l.minW {
+		return fyne.NewSize(l.minW, min.Height)
+	}
+	return min
+}
+
+type verticalWrapLayout struct {
+	padding float32
+}
+
+func (l *verticalWrapLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	if len(objects) == 0 {
+		return
+	}
+	padding := l.padding
+	if padding <= 0 {
+		padding = 8
+	}
+	maxHeight := size.Height
+	if maxHeight <= 0 {
+		maxHeight = 320
+	}
+	x := float32(0)
+	y := float32(0)
+	colWidth := float32(0)
+	for _, obj := range objects {
+		if obj == nil || !obj.Visible() {
+			continue
+		}
+		item := obj.MinSize()
+		if y+item.Height > maxHeight && y > 0 {
+			x += colWidth + padding
+			y = 0
+			colWidth = 0
+		}
+		obj.Resize(item)
+		obj.Move(fyne.NewPos(x, y))
+		y += item.Height + padding
+		if item.Width > colWidth {
+			colWidth = item.Width
+		}
+	}
+}
+
+func (l *verticalWrapLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	if len(objects) == 0 {
+		return fyne.NewSize(0, 0)
+	}
+	padding := l.padding
+	if padding <= 0 {
+		padding = 8
+	}
+	var width, height float32
+	for i, obj := range objects {
+		if obj == nil || !obj.Visible() {
+			continue
+		}
+		item := obj.MinSize()
+		if item.Width > width {
+			width = item.Width
+		}
+		if i > 0 {
+			height += padding
+		}
+		height += item.Height
+	}
+	return fyne.NewSize(width, height)
+}
+
+type twoPaneLayout struct {
+	leftRatio float32
+	leftMin   float32
+	leftMax   float32
+}
+
+func (l *twoPaneLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	if len(objects) < 2 {
+		return
+	}
+	leftWidth := size.Width * l.leftRatio
+	if l.leftMin > 0 && leftWidth < l.leftMin {
+		leftWidth = l.leftMin
+	}
+	if l.leftMax > 0 && leftWidth > l.leftMax {
+		leftWidth = l.leftMax
+	}
+	if leftWidth > size.Width {
+		leftWidth = size.Width
+	}
+	left := objects[0]
+	right := objects[1]
+	left.Resize(fyne.NewSize(leftWidth, size.Height))
+	left.Move(fyne.NewPos(0, 0))
+	rightWidth := size.Width - leftWidth
+	if rightWidth < 0 {
+		rightWidth = 0
+	}
+	right.Resize(fyne.NewSize(rightWidth, size.Height))
+	right.Move(fyne.NewPos(leftWidth, 0))
+}
+
+func (l *twoPaneLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	if len(objects) < 2 {
+		return fyne.NewSize(0, 0)
+	}
+	leftMin := objects[0].MinSize()
+	rightMin := objects[1].MinSize()
+	requiredLeft := leftMin.Width
+	if l.leftMin > 0 && requiredLeft < l.leftMin {
+		requiredLeft = l.leftMin
+	}
+	width := requiredLeft + rightMin.Width
+	height := leftMin.Height
+	if rightMin.Height > height {
+		height = rightMin.Height
+	}
+	return fyne.NewSize(width, height)
+}
+
+func decorateCard(content fyne.CanvasObject) fyne.CanvasObject {
+	background := canvas.NewRectangle(color.NRGBA{R: 245, G: 246, B: 252, A: 255})
+	background.SetMinSize(content.MinSize().Add(fyne.NewSize(32, 32)))
+	card := container.NewPadded(content)
+	return container.NewMax(background, card)
+}
+
+func updateLayoutLockButton(btn *widget.Button) {
+	if btn == nil {
+		return
+	}
+	if layoutLocked {
+		btn.SetText("Unlock Layout")
+		btn.SetIcon(theme.VisibilityIcon())
+	} else {
+		btn.SetText("Lock Layout")
+		btn.SetIcon(theme.VisibilityOffIcon())
+	}
+	btn.Refresh()
+}
+
+func buildDeviceRoomContent() fyne.CanvasObject {
+	layoutWidget := NewDeviceStatusLayoutWidget()
+	layoutWidget.SetLayoutLocked(layoutLocked)
+	layoutWidget.UpdateDevices()
+	deviceLayoutWidget = layoutWidget
+
+	queueView := buildPendingQueueView()
+
+	// The queue shows whether or not the check-in panel does.
+	leftPane := container.NewVBox(
+		newCheckInPanelSlot(),
+		newPreregPanel(),
+		queueView,
+		newEndingSoonView(),
+	)
+	leftScroll := container.NewVScroll(container.NewPadded(leftPane))
+	deviceFocusLabel = widget.NewLabel("")
+	compactBar := newCompactBar()
+	mapPane := container.NewBorder(container.NewVBox(newClosureBanner(), compactBar, newMapLegend()), container.NewVBox(newSelectionBar(), newForecastStrip(), deviceFocusLabel), nil, nil, layoutWidget)
+	return newRoomContent(layoutWidget, mapPane, newCompactDrawer(leftScroll), compactBar)
+}
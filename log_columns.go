@@ -0,0 +1,489 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+const (
+	logColumnStatus  = "status"
+	logColumnPurpose = "purpose"
+	logColumnNote    = "note"
+	logColumnSource  = "source"
+
+	logColumnMinWidth = float32(40)
+)
+
+// logColumn is one LogEntry field the log view can show. Width is the
+// default; staff resize columns by dragging the header.
+type logColumn struct {
+	ID    string
+	Title string
+	Width float32
+	Value func(entry state.LogEntry) string
+}
+
+// logColumns lists every field in the order the column picker first offers
+// them.
+var logColumns = []logColumn{
+	{ID: logColumnStatus, Title: "Status", Width: 70, Value: func(e state.LogEntry) string { text, _ := logEntryBadge(e); return text }},
+	{ID: "name", Title: "Name", Width: 180, Value: func(e state.LogEntry) string { return e.UserName }},
+	{ID: "pc", Title: "PC", Width: 60, Value: func(e state.LogEntry) string { return e.DevicePath() }},
+	{ID: "check_in", Title: "In", Width: 160, Value: func(e state.LogEntry) string { return formatDateTime(e.CheckInTime) }},
+	{ID: "check_out", Title: "Out", Width: 160, Value: func(e state.LogEntry) string {
+		if e.CheckOutTime.IsZero() {
+			return "--"
+		}
+		return formatDateTime(e.CheckOutTime)
+	}},
+	{ID: "session", Title: "Session", Width: 150, Value: logEntrySession},
+	{ID: "user_id", Title: "User ID", Width: 110, Value: func(e state.LogEntry) string { return e.UserID }},
+	{ID: logColumnPurpose, Title: "Purpose", Width: 110, Value: func(e state.LogEntry) string { return e.Purpose }},
+	{ID: "course", Title: "Course", Width: 90, Value: func(e state.LogEntry) string { return e.Course }},
+	{ID: "visitor_type", Title: "Visitor", Width: 80, Value: func(e state.LogEntry) string {
+		if e.VisitorType == "" && e.IsSession() {
+			return store.VisitorType(e.UserID)
+		}
+		return e.VisitorType
+	}},
+	{ID: logColumnSource, Title: "Source", Width: 100, Value: func(e state.LogEntry) string {
+		if e.Source == "" && e.Kind == "" {
+			return state.SourceUnknown
+		}
+		return e.Source
+	}},
+	{ID: "event", Title: "Event", Width: 120, Value: func(e state.LogEntry) string { return e.Event }},
+	{ID: logColumnNote, Title: "Note", Width: 160, Value: logEntryNote},
+	{ID: "headcount", Title: "In Lounge", Width: 90, Value: func(e state.LogEntry) string {
+		if e.Kind != state.KindHeadcount {
+			return ""
+		}
+		return fmt.Sprintf("%d (%+d)", e.Headcount, e.Change)
+	}},
+	{ID: "session_id", Title: "Session ID", Width: 140, Value: func(e state.LogEntry) string { return e.SessionID }},
+	// The title documents the flattened form for CSV exports.
+	{ID: "device_history", Title: "Device Moves (from>to@time; ...)", Width: 200, Value: deviceMovesText},
+	{ID: "test", Title: "Test Session", Width: 100, Value: func(e state.LogEntry) string {
+		if e.ExcludeFromStats {
+			return "yes"
+		}
+		return ""
+	}},
+	{ID: "limit_exempt", Title: "No Session Limit", Width: 120, Value: func(e state.LogEntry) string {
+		if e.LimitExempt {
+			return "yes"
+		}
+		return ""
+	}},
+}
+
+// defaultLogColumnIDs are the columns the log always showed; settings
+// without LogColumns keep them.
+var defaultLogColumnIDs = []string{logColumnStatus, "name", "pc", "check_in", "check_out", "session"}
+
+var logHeader *fyne.Container
+
+func logColumnByID(id string) (logColumn, bool) {
+	for _, column := range logColumns {
+		if column.ID == id {
+			return column, true
+		}
+	}
+	return logColumn{}, false
+}
+
+// visibleLogColumns is the configured columns in order. Settings from before
+// the picker fall back to the defaults, plus Source if it was switched on.
+func visibleLogColumns() []logColumn {
+	ids := appSettings.LogColumns
+	if len(ids) == 0 {
+		ids = defaultLogColumnIDs
+		if appSettings.LogShowSource {
+			ids = append(append([]string{}, ids...), logColumnSource)
+		}
+	}
+	columns := make([]logColumn, 0, len(ids))
+	for _, id := range ids {
+		if column, ok := logColumnByID(id); ok {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+func logColumnVisible(id string) bool {
+	for _, column := range visibleLogColumns() {
+		if column.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func logColumnWidth(column logColumn) float32 {
+	if w, ok := appSettings.LogColumnWidths[column.ID]; ok && w >= logColumnMinWidth {
+		return w
+	}
+	return column.Width
+}
+
+// logEntryBadge is the status text and colour shown in the Status column.
+func logEntryBadge(entry state.LogEntry) (string, color.Color) {
+	switch {
+	case entry.Kind == state.KindRotation:
+		return "ROTATED", latteSecondary
+	case entry.Kind == state.KindHeadcount:
+		return "LOUNGE", latteSubtext1
+	case entry.ClockSkew:
+		return "CLOCK", latteRed
+	case entry.CheckOutTime.IsZero():
+		return "ACTIVE", color.NRGBA{R: 4, G: 165, B: 229, A: 255}
+	}
+	return "DONE", color.NRGBA{R: 64, G: 160, B: 43, A: 255}
+}
+
+func logEntrySession(entry state.LogEntry) string {
+	if entry.Kind != "" {
+		return ""
+	}
+	session := "unknown"
+	if d, ok := entry.SessionDuration(time.Now()); ok {
+		session = state.FormatDuration(d)
+	}
+	if entry.ClockSkew {
+		session += " (clock changed)"
+	}
+	return session
+}
+
+func logEntryNote(entry state.LogEntry) string {
+	var notes []string
+	if entry.Note != "" && entry.Kind == "" {
+		notes = append(notes, entry.Note)
+	}
+	if entry.RemovalReason != "" {
+		notes = append(notes, "removed from queue: "+entry.RemovalReason)
+	}
+	if entry.NotAVisit {
+		notes = append(notes, "not a visit")
+	}
+	if entry.Imported {
+		notes = append(notes, "imported")
+	}
+	return strings.Join(notes, ", ")
+}
+
+// logColumnsLayout places cells side by side at the visible columns' widths;
+// the last cell takes whatever room is left. A cell at span or later also
+// takes the rest of the row, for entries that are not sessions.
+type logColumnsLayout struct {
+	span int
+}
+
+func (l *logColumnsLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	columns := visibleLogColumns()
+	pad := theme.Padding()
+	x := float32(0)
+	for i, o := range objects {
+		if !o.Visible() {
+			continue
+		}
+		w := size.Width - x
+		if i < len(columns) && i < len(objects)-1 && (l.span < 0 || i < l.span) {
+			w = logColumnWidth(columns[i])
+		}
+		o.Move(fyne.NewPos(x, 0))
+		o.Resize(fyne.NewSize(max(w-pad, 0), size.Height))
+		x += w
+	}
+}
+
+func (l *logColumnsLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	var h float32
+	for _, o := range objects {
+		h = max(h, o.MinSize().Height)
+	}
+	return fyne.NewSize(0, h)
+}
+
+// newLogHeader shows the column titles above the log, each with a handle to
+// drag its width.
+func newLogHeader() fyne.CanvasObject {
+	logHeader = container.New(&logColumnsLayout{span: -1})
+	refreshLogHeader()
+	return logHeader
+}
+
+func refreshLogHeader() {
+	if logHeader == nil {
+		return
+	}
+	columns := visibleLogColumns()
+	cells := make([]fyne.CanvasObject, 0, len(columns))
+	for i, column := range columns {
+		title := widget.NewLabelWithStyle(column.Title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		title.Truncation = fyne.TextTruncateEllipsis
+		if i == len(columns)-1 {
+			cells = append(cells, title)
+			continue
+		}
+		cells = append(cells, container.NewBorder(nil, nil, nil, newLogColumnResizer(column), title))
+	}
+	logHeader.Objects = cells
+	logHeader.Refresh()
+}
+
+// logColumnResizer is the handle at a header cell's right edge.
+type logColumnResizer struct {
+	widget.BaseWidget
+	column logColumn
+	width  float32
+}
+
+func newLogColumnResizer(column logColumn) *logColumnResizer {
+	r := &logColumnResizer{column: column}
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+func (r *logColumnResizer) CreateRenderer() fyne.WidgetRenderer {
+	line := canvas.NewRectangle(theme.Color(theme.ColorNameSeparator))
+	return widget.NewSimpleRenderer(container.New(layout.NewCustomPaddedLayout(4, 4, 3, 3), line))
+}
+
+func (r *logColumnResizer) MinSize() fyne.Size { return fyne.NewSize(8, 0) }
+
+func (r *logColumnResizer) Cursor() desktop.Cursor { return desktop.HResizeCursor }
+
+func (r *logColumnResizer) Dragged(ev *fyne.DragEvent) {
+	if r.width == 0 {
+		r.width = logColumnWidth(r.column)
+	}
+	r.width = max(r.width+ev.Dragged.DX, logColumnMinWidth)
+	if appSettings.LogColumnWidths == nil {
+		appSettings.LogColumnWidths = make(map[string]float32)
+	}
+	appSettings.LogColumnWidths[r.column.ID] = float32(math.Round(float64(r.width)))
+	logHeader.Refresh()
+	if logList != nil {
+		logList.Refresh()
+	}
+}
+
+func (r *logColumnResizer) DragEnd() {
+	r.width = 0
+	if err := saveSettings(); err != nil {
+		writeFailed("saving settings", err)
+	}
+}
+
+// applyLogColumns saves a new column choice and redraws the log.
+func applyLogColumns(ids []string) {
+	appSettings.LogColumns = ids
+	appSettings.LogShowSource = false
+	if err := saveSettings(); err != nil {
+		writeFailed("saving settings", err)
+	}
+	refreshLogHeader()
+	if logList != nil {
+		logList.Refresh()
+	}
+}
+
+// showLogColumnPicker lists every field with a checkbox; rows are dragged
+// by their handle to reorder the columns.
+func showLogColumnPicker(anchor fyne.CanvasObject) {
+	var order []string
+	shown := make(map[string]bool)
+	for _, column := range visibleLogColumns() {
+		order = append(order, column.ID)
+		shown[column.ID] = true
+	}
+	for _, column := range logColumns {
+		if !shown[column.ID] {
+			order = append(order, column.ID)
+		}
+	}
+	save := func() {
+		var ids []string
+		for _, id := range order {
+			if shown[id] {
+				ids = append(ids, id)
+			}
+		}
+		applyLogColumns(ids)
+	}
+
+	rows := container.NewVBox()
+	var rebuild func()
+	rebuild = func() {
+		rows.Objects = nil
+		for i, id := range order {
+			column, _ := logColumnByID(id)
+			check := widget.NewCheck(column.Title, func(on bool) {
+				if !on && countShown(shown) == 1 {
+					rebuild() // keep at least one column
+					return
+				}
+				shown[id] = on
+				save()
+			})
+			check.Checked = shown[id]
+			rows.Add(newLogColumnPickerRow(check, func(moveBy int) {
+				to := min(max(i+moveBy, 0), len(order)-1)
+				if to == i {
+					return
+				}
+				moved := order[i]
+				order = append(order[:i], order[i+1:]...)
+				order = append(order[:to], append([]string{moved}, order[to:]...)...)
+				save()
+				rebuild()
+			}))
+		}
+		rows.Refresh()
+	}
+	rebuild()
+
+	var popup *widget.PopUp
+	reset := widget.NewButton("Reset to default", func() {
+		popup.Hide()
+		appSettings.LogColumnWidths = nil
+		applyLogColumns(nil)
+	})
+	title := widget.NewLabelWithStyle("Log columns", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	hint := widget.NewLabel("Drag a row by its handle to reorder.")
+	hint.Importance = widget.LowImportance
+	popup = widget.NewPopUp(container.NewVBox(title, rows, hint, reset), mainWindow.Canvas())
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(anchor)
+	popup.ShowAtPosition(pos.Add(fyne.NewPos(0, anchor.Size().Height)))
+}
+
+func countShown(shown map[string]bool) int {
+	n := 0
+	for _, on := range shown {
+		if on {
+			n++
+		}
+	}
+	return n
+}
+
+// logColumnPickerRow is a picker row; dragging its handle up or down moves
+// the column by whole rows.
+type logColumnPickerRow struct {
+	widget.BaseWidget
+	check  *widget.Check
+	onMove func(moveBy int)
+	dragY  float32
+}
+
+func newLogColumnPickerRow(check *widget.Check, onMove func(int)) *logColumnPickerRow {
+	r := &logColumnPickerRow{check: check, onMove: onMove}
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+func (r *logColumnPickerRow) CreateRenderer() fyne.WidgetRenderer {
+	handle := widget.NewIcon(theme.MenuIcon())
+	return widget.NewSimpleRenderer(container.NewBorder(nil, nil, handle, nil, r.check))
+}
+
+func (r *logColumnPickerRow) Cursor() desktop.Cursor { return desktop.VResizeCursor }
+
+func (r *logColumnPickerRow) Dragged(ev *fyne.DragEvent) { r.dragY += ev.Dragged.DY }
+
+func (r *logColumnPickerRow) DragEnd() {
+	moveBy := int(math.Round(float64(r.dragY / r.Size().Height)))
+	r.dragY = 0
+	if moveBy != 0 {
+		r.onMove(moveBy)
+	}
+}
+
+// logExportRows is the CSV for entries: the visible columns, or every field.
+func logExportRows(entries []state.LogEntry, allFields bool) [][]string {
+	columns := visibleLogColumns()
+	if allFields {
+		columns = logColumns
+	}
+	// The times are local; the header says which zone they are in, as of
+	// the day exported.
+	zone := time.Now()
+	if len(entries) > 0 {
+		zone = entries[0].CheckInTime
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Title
+		if column.ID == "check_in" || column.ID == "check_out" {
+			header[i] += " (" + zoneLabel(zone) + ")"
+		}
+	}
+	rows := [][]string{header}
+	for _, entry := range entries {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = column.Value(entry)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// showExportLogDialog saves the log as shown, after the date, filters and
+// sort, as CSV.
+func showExportLogDialog() {
+	entries := append([]state.LogEntry{}, displayedLogEntries...)
+	fields := widget.NewRadioGroup([]string{"Visible columns only", "All fields"}, nil)
+	fields.SetSelected("Visible columns only")
+	anonymize := newAnonymizeCheck(nil)
+	content := container.NewVBox(widget.NewLabel(fmt.Sprintf("%d log entries", len(entries))), fields, anonymize)
+	dialog.ShowCustomConfirm("Export Log", "Export", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		anon, err := exportAnonymizerFor(anonymize.Checked)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if anon != nil {
+			entries = anon.Entries(entries)
+		}
+		rows := logExportRows(entries, fields.Selected == "All fields")
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			defer writer.Close()
+			w := csv.NewWriter(writer)
+			if err := w.WriteAll(rows); err != nil {
+				dialog.ShowError(fmt.Errorf("write log: %w", err), mainWindow)
+			}
+		}, mainWindow)
+		save.SetFileName(fmt.Sprintf("lounge-log-%s.csv", selectedLogDate))
+		save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+		save.Show()
+	}, mainWindow)
+}
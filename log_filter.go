@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// Quick filters for the log view. The choice lives in memory only, so the
+// log opens on logFilterAll after a restart.
+const (
+	logFilterAll       = "All"
+	logFilterPCs       = "PCs"
+	logFilterConsoles  = "Consoles"
+	logFilterQueueOnly = "Queue-only"
+	logFilterOpen      = "Open sessions"
+	logFilterClosed    = "Closed sessions"
+)
+
+var logFilterOptions = []string{logFilterAll, logFilterPCs, logFilterConsoles, logFilterQueueOnly, logFilterOpen, logFilterClosed}
+
+var (
+	currentLogFilter = logFilterAll
+	logFilterButtons []*widget.Button
+	logTotalsLabel   *widget.Label
+)
+
+// logFilterMatches reports whether entry passes the quick filter. Headcount
+// entries only show under All, since they are not sessions.
+func logFilterMatches(entry state.LogEntry, filter string) bool {
+	if filter == logFilterAll {
+		return true
+	}
+	if !entry.IsSession() {
+		return false
+	}
+	switch filter {
+	case logFilterPCs:
+		return entry.PCID != 0 && deviceTypeName(entry.PCID) != "Console"
+	case logFilterConsoles:
+		return entry.PCID != 0 && deviceTypeName(entry.PCID) == "Console"
+	case logFilterQueueOnly:
+		return entry.PCID == 0
+	case logFilterOpen:
+		return entry.CheckOutTime.IsZero()
+	case logFilterClosed:
+		return !entry.CheckOutTime.IsZero()
+	}
+	return true
+}
+
+// filterLogEntries keeps the entries that pass every log filter.
+func filterLogEntries(entries []state.LogEntry) []state.LogEntry {
+	filtered := make([]state.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if logFilterMatches(entry, currentLogFilter) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func setLogFilter(filter string) {
+	currentLogFilter = filter
+	for i, button := range logFilterButtons {
+		button.Importance = widget.LowImportance
+		if logFilterOptions[i] == filter {
+			button.Importance = widget.HighImportance
+		}
+		button.Refresh()
+	}
+	refreshDisplayedLogEntries()
+	if logList != nil {
+		logList.Refresh()
+	}
+}
+
+func newLogFilterChips() fyne.CanvasObject {
+	logFilterButtons = logFilterButtons[:0]
+	chips := container.NewHBox()
+	for _, option := range logFilterOptions {
+		button := widget.NewButton(option, func() { setLogFilter(option) })
+		logFilterButtons = append(logFilterButtons, button)
+		chips.Add(button)
+	}
+	setLogFilter(currentLogFilter)
+	return chips
+}
+
+// updateLogTotals shows the row count and summed usage of the displayed
+// entries under the log.
+func updateLogTotals() {
+	if logTotalsLabel == nil {
+		return
+	}
+	var total time.Duration
+	for _, entry := range displayedLogEntries {
+		total += logEntrySessionDuration(entry)
+	}
+	total = total.Truncate(time.Minute)
+	noun := "entries"
+	if len(displayedLogEntries) == 1 {
+		noun = "entry"
+	}
+	logTotalsLabel.SetText(fmt.Sprintf("%d %s · %dh%02dm total", len(displayedLogEntries), noun, int(total.Hours()), int(total.Minutes())%60))
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// logRepairFields are the log columns shown for each side of a duplicate.
+var logRepairFields = []string{"name", "user_id", "pc", "check_in", "check_out", "session", "session_id", "source", "note"}
+
+// showLogRepairDialog scans a range of logs for visits logged twice and lets
+// staff merge each pair or delete one side.
+func showLogRepairDialog() {
+	dates := store.ListAvailableLogDates()
+	fromSelect := widget.NewSelect(dates, nil)
+	toSelect := widget.NewSelect(dates, nil)
+	fromSelect.SetSelected(state.TodaysLogDate())
+	toSelect.SetSelected(state.TodaysLogDate())
+	windowEntry := widget.NewEntry()
+	windowEntry.SetText(fmt.Sprintf("%d", int(state.DefaultDuplicateWindow.Seconds())))
+	summary := widget.NewLabel("Pick the days to scan.")
+	rows := container.NewVBox()
+
+	var scan func()
+	repair := func(title, question string, action func() error) {
+		if readOnly {
+			showReadOnlyNotice()
+			return
+		}
+		requireStaffPIN("Repair Log", func() {
+			dialog.ShowConfirm(title, question+" The log is backed up before its first repair.", func(ok bool) {
+				if !ok {
+					return
+				}
+				if err := action(); err != nil {
+					dialog.ShowError(err, mainWindow)
+				}
+				scan()
+			}, mainWindow)
+		})
+	}
+	scan = func() {
+		seconds, err := parseNonNegativeInt("Within (seconds)", windowEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		dups, err := store.ScanLogDuplicates(fromSelect.Selected, toSelect.Selected, time.Duration(seconds)*time.Second)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		summary.SetText(fmt.Sprintf("%d possible duplicate(s).", len(dups)))
+		rows.Objects = nil
+		for _, dup := range dups {
+			rows.Add(newLogDuplicateCard(dup, repair))
+		}
+		rows.Refresh()
+	}
+	scanButton := widget.NewButtonWithIcon("Scan", theme.SearchIcon(), scan)
+	form := widget.NewForm(
+		widget.NewFormItem("From", fromSelect),
+		widget.NewFormItem("To", toSelect),
+		widget.NewFormItem("Within (seconds)", windowEntry),
+	)
+	intro := widget.NewLabel("Finds sessions of the same user on the same device that checked in within the given seconds " +
+		"of each other or overlap. Each log is backed up before its first repair.")
+	intro.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(640, 380))
+	content := container.NewBorder(container.NewVBox(intro, form, container.NewHBox(scanButton, summary)), nil, nil, nil, scroll)
+	dialog.ShowCustom("Repair Log", "Close", content, mainWindow)
+	scan()
+}
+
+// newLogDuplicateCard shows the two entries of dup side by side with the
+// ways to resolve it. Each asks for the staff PIN and a confirmation
+// before it changes the log.
+func newLogDuplicateCard(dup state.LogDuplicate, repair func(title, question string, action func() error)) fyne.CanvasObject {
+	title := widget.NewLabelWithStyle(fmt.Sprintf("%s — %s", dup.Date, dup.Reason), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	merge := widget.NewButton("Merge", func() {
+		repair("Merge Entries", fmt.Sprintf("Merge the two %s entries for %s into one session?", dup.Date, dup.First.UserName),
+			func() error { return store.MergeLogDuplicate(dup) })
+	})
+	merge.Importance = widget.HighImportance
+	deleteEntry := func(entry state.LogEntry) func() {
+		return func() {
+			repair("Delete Entry", fmt.Sprintf("Delete the %s entry for %s checked in at %s?", dup.Date, entry.UserName, formatClock(entry.CheckInTime)),
+				func() error { return store.DeleteLogEntry(dup.Date, entry) })
+		}
+	}
+	deleteFirst := widget.NewButtonWithIcon("Delete left", theme.DeleteIcon(), deleteEntry(dup.First))
+	deleteSecond := widget.NewButtonWithIcon("Delete right", theme.DeleteIcon(), deleteEntry(dup.Second))
+	disableWhenReadOnly(merge, deleteFirst, deleteSecond)
+	sides := container.NewGridWithColumns(2, logRepairEntryForm(dup.First), logRepairEntryForm(dup.Second))
+	buttons := container.NewHBox(merge, deleteFirst, deleteSecond)
+	return container.NewVBox(title, sides, buttons, widget.NewSeparator())
+}
+
+func logRepairEntryForm(entry state.LogEntry) fyne.CanvasObject {
+	grid := container.NewGridWithColumns(2)
+	for _, id := range logRepairFields {
+		column, ok := logColumnByID(id)
+		if !ok {
+			continue
+		}
+		value := column.Value(entry)
+		if value == "" {
+			value = "-"
+		}
+		grid.Add(widget.NewLabel(column.Title))
+		grid.Add(widget.NewLabelWithStyle(value, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	}
+	return grid
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+const (
+	logSortMostRecent     = "Most Recent"
+	logSortOldest         = "Oldest"
+	logSortLongestSession = "Longest Session"
+)
+
+func formatAgo(ts time.Time) string {
+	if ts.IsZero() {
+		return "just now"
+	}
+	if ts.After(time.Now()) {
+		return "just now"
+	}
+	return fmt.Sprintf("%s ago", state.FormatDuration(time.Since(ts)))
+}
+
+func updateCurrentLogEntriesCache() {
+	if selectedLogDate == "" {
+		selectedLogDate = state.TodaysLogDate()
+	}
+	entries, err := store.ReadLogEntries(selectedLogDate)
+	if err != nil {
+		slog.Error("updating log cache", "err", err)
+		currentLogEntries = []state.LogEntry{}
+	} else {
+		currentLogEntries = entries
+		rememberTodaysLog(selectedLogDate, entries)
+	}
+	refreshDisplayedLogEntries()
+	refreshLogDateOptions()
+}
+
+func refreshDisplayedLogEntries() {
+	displayedLogEntries = filterLogEntries(currentLogEntries)
+	sortLogEntries(displayedLogEntries, currentLogSort)
+	updateLogTotals()
+}
+
+func setLogSort(sortOption string) {
+	if sortOption == "" {
+		sortOption = logSortMostRecent
+	}
+	currentLogSort = sortOption
+	refreshDisplayedLogEntries()
+	if logSortSelect != nil && logSortSelect.Selected != sortOption {
+		logSortSelect.Selected = sortOption
+		logSortSelect.Refresh()
+	}
+	if logList != nil {
+		logList.Refresh()
+	}
+}
+
+func sortLogEntries(entries []state.LogEntry, sortOption string) {
+	switch sortOption {
+	case logSortOldest:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return logEntryActivityTime(entries[i]).Before(logEntryActivityTime(entries[j]))
+		})
+	case logSortLongestSession:
+		sort.SliceStable(entries, func(i, j int) bool {
+			di := logEntrySessionDuration(entries[i])
+			dj := logEntrySessionDuration(entries[j])
+			if di == dj {
+				return logEntryActivityTime(entries[i]).After(logEntryActivityTime(entries[j]))
+			}
+			return di > dj
+		})
+	default:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return logEntryActivityTime(entries[i]).After(logEntryActivityTime(entries[j]))
+		})
+	}
+}
+
+func logEntryActivityTime(entry state.LogEntry) time.Time {
+	if !entry.CheckOutTime.IsZero() {
+		return entry.CheckOutTime
+	}
+	return entry.CheckInTime
+}
+
+func logEntrySessionDuration(entry state.LogEntry) time.Duration {
+	if !entry.IsSession() {
+		return 0
+	}
+	d, _ := entry.SessionDuration(time.Now())
+	return d
+}
+
+func refreshLogDateOptions() {
+	if logDateSelect == nil {
+		return
+	}
+	options := store.ListAvailableLogDates()
+	logDateSelect.Options = options
+	logDateSelect.Refresh()
+}
+
+func setLogDate(date string) {
+	if date == "" {
+		date = state.TodaysLogDate()
+	}
+	selectedLogDate = date
+	if logDateSelect != nil && logDateSelect.Selected != date {
+		logDateSelect.Selected = date
+		logDateSelect.Refresh()
+	}
+	updateCurrentLogEntriesCache()
+	if logList != nil {
+		logList.Refresh()
+	}
+}
+
+// buildLogView starts out empty; loadInBackground fills in the entries and
+// the dates.
+func buildLogView() fyne.CanvasObject {
+	logList = widget.NewList(
+		func() int { return len(displayedLogEntries) },
+		func() fyne.CanvasObject { return newLogEntryCard() },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < 0 || i >= len(displayedLogEntries) {
+				return
+			}
+			card := o.(*logEntryCard)
+			card.SetEntry(displayedLogEntries[i])
+		},
+	)
+	logRefreshPending = false
+	header := widget.NewLabelWithStyle("Live Activity", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	if selectedLogDate == "" {
+		selectedLogDate = state.TodaysLogDate()
+	}
+	logDateSelect = widget.NewSelect([]string{selectedLogDate}, func(val string) {
+		setLogDate(val)
+	})
+	logDateSelect.PlaceHolder = "Select date"
+	logDateSelect.Selected = selectedLogDate
+	sortOptions := []string{logSortMostRecent, logSortOldest, logSortLongestSession}
+	logSortSelect = widget.NewSelect(sortOptions, func(val string) {
+		setLogSort(val)
+	})
+	logSortSelect.PlaceHolder = "Sort logs"
+	logSortSelect.Selected = currentLogSort
+	var columnsButton *widget.Button
+	columnsButton = widget.NewButtonWithIcon("Columns", theme.ListIcon(), func() { showLogColumnPicker(columnsButton) })
+	exportButton := widget.NewButtonWithIcon("Export", theme.DownloadIcon(), showExportLogDialog)
+	toolbar := container.NewHBox(header, layout.NewSpacer(), columnsButton, exportButton, logDateSelect, logSortSelect)
+	logTotalsLabel = widget.NewLabel("")
+	top := container.NewVBox(toolbar, newLogFilterChips(), newLogHeader())
+	return container.NewBorder(top, logTotalsLabel, nil, nil, logList)
+}
+
+// logEntryCard is one log row: a cell per visible column, and the purpose
+// tag at the end unless Purpose is a column of its own.
+type logEntryCard struct {
+	widget.BaseWidget
+	cells   *fyne.Container
+	layout  *logColumnsLayout
+	columns string // visible column IDs the cells were built for
+	badge   *canvas.Text
+	purpose *canvas.Text
+	entry   state.LogEntry
+}
+
+func newLogEntryCard() *logEntryCard {
+	c := &logEntryCard{
+		layout:  &logColumnsLayout{span: -1},
+		badge:   canvas.NewText("", theme.PrimaryColor()),
+		purpose: canvas.NewText("", latteSubtext1),
+	}
+	c.cells = container.New(c.layout)
+	c.ExtendBaseWidget(c)
+	c.buildCells()
+	return c
+}
+
+func (c *logEntryCard) CreateRenderer() fyne.WidgetRenderer {
+	c.badge.TextStyle.Bold = true
+	c.badge.Alignment = fyne.TextAlignCenter
+	c.badge.TextSize = 10
+	c.purpose.TextStyle.Bold = true
+	c.purpose.TextSize = 10
+	body := container.NewBorder(nil, nil, nil, container.NewCenter(c.purpose), c.cells)
+	return widget.NewSimpleRenderer(body)
+}
+
+// buildCells makes one cell per visible column; the Status column holds the
+// coloured badge.
+func (c *logEntryCard) buildCells() {
+	var ids []string
+	c.cells.Objects = nil
+	for _, column := range visibleLogColumns() {
+		ids = append(ids, column.ID)
+		if column.ID == logColumnStatus {
+			c.cells.Objects = append(c.cells.Objects, container.NewCenter(c.badge))
+			continue
+		}
+		label := widget.NewLabel("")
+		label.Truncation = fyne.TextTruncateEllipsis
+		c.cells.Objects = append(c.cells.Objects, label)
+	}
+	c.columns = strings.Join(ids, ",")
+}
+
+func (c *logEntryCard) SetEntry(entry state.LogEntry) {
+	columns := visibleLogColumns()
+	ids := make([]string, len(columns))
+	for i, column := range columns {
+		ids[i] = column.ID
+	}
+	if c.columns != strings.Join(ids, ",") {
+		c.buildCells()
+	}
+	c.entry = entry
+	c.badge.Text, c.badge.Color = logEntryBadge(entry)
+	c.badge.Refresh()
+
+	// Rotations and headcount changes are not sessions; their description
+	// takes the row after the badge.
+	line := ""
+	switch entry.Kind {
+	case state.KindRotation:
+		line = rotationEntryLine(entry)
+	case state.KindHeadcount:
+		line = headcountEntryLine(entry)
+	}
+	c.layout.span = -1
+	var last *widget.Label
+	for i, column := range columns {
+		cell := c.cells.Objects[i]
+		label, ok := cell.(*widget.Label)
+		if !ok {
+			continue
+		}
+		switch {
+		case line != "" && c.layout.span < 0:
+			c.layout.span = i
+			label.SetText(line)
+			label.Show()
+		case line != "":
+			label.Hide()
+		default:
+			label.SetText(column.Value(entry))
+			label.Show()
+			last = label
+		}
+	}
+	// Without a Note column the note trails the row, as it always did.
+	if note := logEntryNote(entry); last != nil && note != "" && !logColumnVisible(logColumnNote) {
+		last.SetText(last.Text + "    (" + note + ")")
+	}
+	c.cells.Refresh()
+
+	c.purpose.Text = ""
+	if line == "" && !logColumnVisible(logColumnPurpose) {
+		c.purpose.Text = strings.ToUpper(entry.Purpose)
+		c.purpose.Color = purposeTagColor(entry.Purpose)
+	}
+	c.purpose.Refresh()
+}
@@ -1,2545 +0,0 @@
-package main
-
-import (
-	"encoding/csv"
-	"encoding/json"
-	"fmt"
-	"image/color"
-	"io"
-	"math"
-	"os"
-	"path/filepath"
-	"sort"
-	"strconv"
-	"strings"
-	"sync"
-	"time"
-
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/app"
-	"fyne.io/fyne/v2/canvas"
-	"fyne.io/fyne/v2/container"
-	"fyne.io/fyne/v2/dialog"
-	"fyne.io/fyne/v2/driver/desktop"
-	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/theme"
-	"fyne.io/fyne/v2/widget"
-)
-
-const (
-	userDataFile     = "log/active_users.json"
-	deviceLayoutFile = "log/device_layout.json"
-	memberFile       = "membership.csv"
-	logDir           = "log"
-	imgBaseDir       = "src"
-	queueFile        = "log/queue.json"
-)
-
-const (
-	logSortMostRecent     = "Most Recent"
-	logSortOldest         = "Oldest"
-	logSortLongestSession = "Longest Session"
-)
-
-type User struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	CheckInTime time.Time `json:"checkin_time"`
-	PCID        int       `json:"pc_id"`
-}
-
-type Device struct {
-	ID     int
-	Type   string
-	Status string
-	UserID string
-}
-
-type Member struct {
-	Name          string
-	ID            string
-	Email         string
-	StudentNumber string
-	PhoneNumber   string
-}
-
-type LogEntry struct {
-	UserName     string    `json:"user_name"`
-	UserID       string    `json:"user_id"`
-	PCID         int       `json:"pc_id"`
-	CheckInTime  time.Time `json:"check_in_time"`
-	CheckOutTime time.Time `json:"check_out_time,omitempty"`
-	UsageTime    string    `json:"usage_time,omitempty"`
-}
-
-var (
-	allDevices          []Device
-	activeUsers         []User
-	members             []Member
-	mainWindow          fyne.Window
-	logList             *widget.List
-	logDateSelect       *widget.Select
-	logSortSelect       *widget.Select
-	refreshTrigger      = make(chan bool, 1)
-	logRefreshPending   = false
-	logFileMutex        sync.Mutex
-	currentLogEntries   []LogEntry
-	displayedLogEntries []LogEntry
-	currentLogSort      = logSortMostRecent
-
-	assignmentUserID         string
-	assignmentNoticeLabel    *widget.Label
-	checkInInlineForm        *fyne.Container
-	checkInNameEntry         *widget.Entry
-	checkInIDEntry           *widget.Entry
-	checkInSearchEntry       *widget.Entry
-	checkInResultsList       *widget.List
-	filteredMembersForInline []Member
-	pendingIconsBox          *fyne.Container
-	raccoonIconResource      fyne.Resource
-	deviceLayoutWidget       *DeviceStatusLayoutWidget
-	layoutLocked             = true
-	dragIndicator            *canvas.Image
-	dragIndicatorVisible     bool
-	logTabActive             bool
-	selectedLogDate          string
-	pendingDragUserID        string
-	pendingDragActive        bool
-	pendingDragLastPos       fyne.Position
-	queuedEntries            []queueEntry
-	pendingIconWidgets       []*PendingUserIcon
-)
-
-type queueEntry struct {
-	UserID     string    `json:"user_id"`
-	EnqueuedAt time.Time `json:"enqueued_at"`
-}
-
-type PendingUserIcon struct {
-	widget.BaseWidget
-	user     User
-	resource fyne.Resource
-	onAssign func(User)
-	label    string
-	subLabel string
-}
-
-func newPendingUserIcon(u User, res fyne.Resource, onAssign func(User)) *PendingUserIcon {
-	w := &PendingUserIcon{user: u, resource: res, onAssign: onAssign}
-	w.ExtendBaseWidget(w)
-	return w
-}
-
-func (w *PendingUserIcon) SetLabel(text string) {
-	w.label = text
-	w.Refresh()
-}
-
-func (w *PendingUserIcon) SetLabels(primary, secondary string) {
-	w.label = primary
-	w.subLabel = secondary
-	w.Refresh()
-}
-
-func truncateLabel(text string, maxChars int) string {
-	text = strings.TrimSpace(text)
-	if len([]rune(text)) <= maxChars {
-		return text
-	}
-	runes := []rune(text)
-	return string(runes[:maxChars-1]) + "…"
-}
-
-type pendingUserIconRenderer struct {
-	widget  *PendingUserIcon
-	image   *canvas.Image
-	label   *canvas.Text
-	sub     *canvas.Text
-	objects []fyne.CanvasObject
-}
-
-func (w *PendingUserIcon) CreateRenderer() fyne.WidgetRenderer {
-	img := canvas.NewImageFromResource(w.resource)
-	img.FillMode = canvas.ImageFillContain
-	const s float32 = 72
-	img.SetMinSize(fyne.NewSize(s, s))
-	img.Resize(fyne.NewSize(s, s))
-	label := canvas.NewText(w.label, theme.ForegroundColor())
-	label.Alignment = fyne.TextAlignCenter
-	label.TextSize = 12
-	sub := canvas.NewText(w.subLabel, color.NRGBA{R: 130, G: 136, B: 150, A: 255})
-	sub.Alignment = fyne.TextAlignCenter
-	sub.TextSize = 11
-	labels := container.NewVBox(
-		container.NewCenter(label),
-		layout.NewSpacer(),
-		container.NewCenter(sub),
-	)
-	card := container.NewVBox(
-		container.NewCenter(img),
-		layout.NewSpacer(),
-		labels,
-		layout.NewSpacer(),
-	)
-	return &pendingUserIconRenderer{widget: w, image: img, label: label, sub: sub, objects: []fyne.CanvasObject{card}}
-}
-
-func (r *pendingUserIconRenderer) Layout(size fyne.Size) { r.objects[0].Resize(size) }
-func (r *pendingUserIconRenderer) MinSize() fyne.Size    { return fyne.NewSize(90, 116) }
-func (r *pendingUserIconRenderer) Refresh() {
-	r.image.Resource = r.widget.resource
-	r.image.Refresh()
-	r.label.Text = r.widget.label
-	r.label.Refresh()
-	r.sub.Text = r.widget.subLabel
-	r.sub.Refresh()
-}
-func (r *pendingUserIconRenderer) Objects() []fyne.CanvasObject { return r.objects }
-func (r *pendingUserIconRenderer) Destroy()                     {}
-
-func (w *PendingUserIcon) Tapped(_ *fyne.PointEvent) {
-	if mainWindow == nil {
-		return
-	}
-	info := widget.NewLabel("Choose what to do with this queued user.")
-	var dlg dialog.Dialog
-	assignBtn := widget.NewButton("Assign", func() {
-		if w.onAssign != nil {
-			w.onAssign(w.user)
-		}
-		dlg.Hide()
-	})
-	removeBtn := widget.NewButton("Remove", func() {
-		if err := removeQueuedUser(w.user.ID); err != nil {
-			dialog.ShowError(err, mainWindow)
-		}
-		dlg.Hide()
-	})
-	closeBtn := widget.NewButton("Close", func() { dlg.Hide() })
-	content := container.NewVBox(
-		widget.NewLabel(fmt.Sprintf("Queued: %s (%s)", w.user.Name, w.user.ID)),
-		info,
-		container.NewHBox(layout.NewSpacer(), assignBtn, removeBtn, closeBtn),
-	)
-	box := container.NewPadded(content)
-	dlg = dialog.NewCustomWithoutButtons("Queued User", box, mainWindow)
-	dlg.Show()
-}
-
-func (w *PendingUserIcon) Dragged(ev *fyne.DragEvent) {
-	updateQueuedUserDrag(w.user.ID, ev.AbsolutePosition)
-}
-
-func (w *PendingUserIcon) DragEnd() {
-	endQueuedUserDrag()
-}
-
-func ensureRaccoonIcon() fyne.Resource {
-	if raccoonIconResource != nil {
-		return raccoonIconResource
-	}
-	path := filepath.Join(imgBaseDir, "racoon.png")
-	if _, err := os.Stat(path); err == nil {
-		res, _ := fyne.LoadResourceFromPath(path)
-		raccoonIconResource = res
-	} else {
-		raccoonIconResource = theme.ComputerIcon()
-	}
-	return raccoonIconResource
-}
-
-func loadQueuedEntries() {
-	path := queueFile
-	data, err := os.ReadFile(path)
-	if err != nil {
-		queuedEntries = []queueEntry{}
-		return
-	}
-	var entries []queueEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		queuedEntries = []queueEntry{}
-		return
-	}
-	queuedEntries = entries
-	cleanQueuedEntries()
-}
-
-func saveQueuedEntries() {
-	if err := ensureLogDir(); err != nil {
-		return
-	}
-	data, _ := json.MarshalIndent(queuedEntries, "", "  ")
-	_ = os.WriteFile(queueFile, data, 0o644)
-}
-
-func cleanQueuedEntries() {
-	valid := make(map[string]bool)
-	for _, u := range activeUsers {
-		if u.PCID == 0 {
-			valid[u.ID] = true
-		}
-	}
-	filtered := make([]queueEntry, 0, len(queuedEntries))
-	changed := false
-	for _, entry := range queuedEntries {
-		if valid[entry.UserID] {
-			filtered = append(filtered, entry)
-			delete(valid, entry.UserID)
-		} else {
-			changed = true
-		}
-	}
-	queuedEntries = filtered
-	for id := range valid {
-		ensureQueuedEntry(id, time.Time{})
-	}
-	if changed {
-		saveQueuedEntries()
-	}
-}
-
-func ensureQueuedEntry(userID string, ts time.Time) {
-	if userID == "" {
-		return
-	}
-	for _, entry := range queuedEntries {
-		if entry.UserID == userID {
-			return
-		}
-	}
-	if ts.IsZero() {
-		ts = time.Now()
-	}
-	queuedEntries = append(queuedEntries, queueEntry{UserID: userID, EnqueuedAt: ts})
-	saveQueuedEntries()
-}
-
-func removeQueuedEntry(userID string) {
-	if userID == "" {
-		return
-	}
-	idx := -1
-	for i, entry := range queuedEntries {
-		if entry.UserID == userID {
-			idx = i
-			break
-		}
-	}
-	if idx == -1 {
-		return
-	}
-	queuedEntries = append(queuedEntries[:idx], queuedEntries[idx+1:]...)
-	saveQueuedEntries()
-}
-
-func orderedQueuedUsers(users []User) []User {
-	if len(users) == 0 {
-		return []User{}
-	}
-	idMap := make(map[string]User, len(users))
-	for _, u := range users {
-		idMap[u.ID] = u
-	}
-	ordered := make([]User, 0, len(users))
-	for _, entry := range queuedEntries {
-		if u, ok := idMap[entry.UserID]; ok {
-			ordered = append(ordered, u)
-			delete(idMap, entry.UserID)
-		}
-	}
-	for _, u := range idMap {
-		ordered = append(ordered, u)
-		ensureQueuedEntry(u.ID, u.CheckInTime)
-	}
-	return ordered
-}
-
-func queueEntryForUser(userID string) *queueEntry {
-	for i := range queuedEntries {
-		if queuedEntries[i].UserID == userID {
-			return &queuedEntries[i]
-		}
-	}
-	return nil
-}
-
-func queueTimeForUser(userID string) time.Time {
-	if entry := queueEntryForUser(userID); entry != nil {
-		return entry.EnqueuedAt
-	}
-	return time.Now()
-}
-
-func queueTimeLabel(userID string) string {
-	return formatAgo(queueTimeForUser(userID))
-}
-
-func refreshPendingIcons() {
-	if pendingIconsBox == nil {
-		return
-	}
-	pendingIconsBox.Objects = pendingIconsBox.Objects[:0]
-	pendingIconWidgets = pendingIconWidgets[:0]
-	iconRes := ensureRaccoonIcon()
-	queuedUsers := getPendingUsers()
-	for idx, u := range queuedUsers {
-		user := u
-		label := fmt.Sprintf("%02d. %s", idx+1, firstLastNonEmpty(user.Name))
-		label = truncateLabel(label, 20)
-		subLabel := queueTimeLabel(user.ID)
-		icon := newPendingUserIcon(user, iconRes, func(sel User) {
-			assignmentUserID = sel.ID
-			if assignmentNoticeLabel != nil {
-				assignmentNoticeLabel.SetText(fmt.Sprintf("Assignment mode: click a free device for %s (%s).", sel.Name, sel.ID))
-			}
-		})
-		icon.SetLabels(label, subLabel)
-		pendingIconsBox.Add(icon)
-		pendingIconWidgets = append(pendingIconWidgets, icon)
-	}
-	pendingIconsBox.Refresh()
-}
-
-func updatePendingIconTimes() {
-	if len(pendingIconWidgets) == 0 {
-		return
-	}
-	queuedUsers := getPendingUsers()
-	for idx, icon := range pendingIconWidgets {
-		if idx >= len(queuedUsers) {
-			break
-		}
-		user := queuedUsers[idx]
-		label := fmt.Sprintf("%02d. %s", idx+1, firstLastNonEmpty(user.Name))
-		label = truncateLabel(label, 20)
-		icon.SetLabels(label, queueTimeLabel(user.ID))
-	}
-	if pendingIconsBox != nil {
-		pendingIconsBox.Refresh()
-	}
-}
-
-type DeviceStatusLayoutWidget struct {
-	widget.BaseWidget
-	containerSize    fyne.Size
-	devicePositions  map[int]fyne.Position // normalized 0-1 coords
-	draggingDeviceID int
-	dragOffset       fyne.Position
-	isDragging       bool
-	transientDragPos fyne.Position
-	layoutLocked     bool
-	swapDragActive   bool
-
-	pcIconSize      float32
-	consoleIconSize float32
-	slotMargin      float32
-}
-
-func NewDeviceStatusLayoutWidget() *DeviceStatusLayoutWidget {
-	layoutWidget := &DeviceStatusLayoutWidget{
-		devicePositions: make(map[int]fyne.Position),
-		pcIconSize:      64,
-		consoleIconSize: 64,
-		slotMargin:      24,
-	}
-	layoutWidget.loadDeviceLayout()
-	layoutWidget.ExtendBaseWidget(layoutWidget)
-	return layoutWidget
-}
-
-// defaultPositions returns the room-layout default normalized positions (0–1)
-// for all 18 devices, matching the physical arrangement in the lounge.
-func (layoutWidget *DeviceStatusLayoutWidget) defaultPositions() map[int]fyne.Position {
-	cols := []float32{0.11, 0.28, 0.46, 0.63}
-	topRows := []float32{0.28, 0.42}
-	btmRows := []float32{0.64, 0.79}
-	conX := float32(0.87)
-	return map[int]fyne.Position{
-		// top group (PCs 9-16)
-		16: {X: cols[0], Y: topRows[0]},
-		15: {X: cols[1], Y: topRows[0]},
-		14: {X: cols[2], Y: topRows[0]},
-		13: {X: cols[3], Y: topRows[0]},
-		9:  {X: cols[0], Y: topRows[1]},
-		10: {X: cols[1], Y: topRows[1]},
-		11: {X: cols[2], Y: topRows[1]},
-		12: {X: cols[3], Y: topRows[1]},
-		// bottom group (PCs 1-8)
-		8: {X: cols[0], Y: btmRows[0]},
-		7: {X: cols[1], Y: btmRows[0]},
-		6: {X: cols[2], Y: btmRows[0]},
-		5: {X: cols[3], Y: btmRows[0]},
-		1: {X: cols[0], Y: btmRows[1]},
-		2: {X: cols[1], Y: btmRows[1]},
-		3: {X: cols[2], Y: btmRows[1]},
-		4: {X: cols[3], Y: btmRows[1]},
-		// consoles (right side)
-		17: {X: conX, Y: 0.28}, // XBOX
-		18: {X: conX, Y: 0.68}, // PS4
-	}
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) ensurePositions() {
-	defaults := layoutWidget.defaultPositions()
-	for _, device := range allDevices {
-		if _, ok := layoutWidget.devicePositions[device.ID]; !ok {
-			if def, ok2 := defaults[device.ID]; ok2 {
-				layoutWidget.devicePositions[device.ID] = def
-			} else {
-				layoutWidget.devicePositions[device.ID] = fyne.NewPos(0.05, 0.05)
-			}
-		}
-	}
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) loadDeviceLayout() {
-	_ = ensureLogDir()
-	data, err := os.ReadFile(deviceLayoutFile)
-	if err != nil || len(data) == 0 {
-		layoutWidget.devicePositions = make(map[int]fyne.Position)
-		layoutWidget.ensurePositions()
-		layoutWidget.saveDeviceLayout()
-		return
-	}
-	type layoutEntry struct {
-		DeviceID int
-		X, Y     float32
-	}
-	var entries []layoutEntry
-	if json.Unmarshal(data, &entries) != nil {
-		layoutWidget.devicePositions = make(map[int]fyne.Position)
-		layoutWidget.ensurePositions()
-		layoutWidget.saveDeviceLayout()
-		return
-	}
-	layoutWidget.devicePositions = make(map[int]fyne.Position)
-	allZero := true
-	for _, entry := range entries {
-		layoutWidget.devicePositions[entry.DeviceID] = fyne.NewPos(entry.X, entry.Y)
-		if entry.X != 0 || entry.Y != 0 {
-			allZero = false
-		}
-	}
-	// If all positions are zero it's probably an old slot-format file; reset to defaults.
-	if allZero {
-		layoutWidget.devicePositions = make(map[int]fyne.Position)
-	}
-	layoutWidget.ensurePositions()
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) saveDeviceLayout() {
-	type layoutEntry struct {
-		DeviceID int
-		X, Y     float32
-	}
-	entries := make([]layoutEntry, 0, len(layoutWidget.devicePositions))
-	for deviceID, pos := range layoutWidget.devicePositions {
-		entries = append(entries, layoutEntry{DeviceID: deviceID, X: pos.X, Y: pos.Y})
-	}
-	data, _ := json.MarshalIndent(entries, "", "  ")
-	_ = os.WriteFile(deviceLayoutFile, data, 0o644)
-}
-
-// computeIconSizes derives pcIconSize / consoleIconSize from the current container.
-func (layoutWidget *DeviceStatusLayoutWidget) computeIconSizes() {
-	if layoutWidget.containerSize.IsZero() {
-		return
-	}
-	// Assume ~4 columns across ~75% of width and ~5 rows tall.
-	colW := layoutWidget.containerSize.Width * 0.18
-	rowH := layoutWidget.containerSize.Height * 0.16
-	iconBase := float32(math.Min(float64(colW), float64(rowH))) * 0.55
-	layoutWidget.pcIconSize = clampFloat(iconBase, 40, 96)
-	layoutWidget.consoleIconSize = clampFloat(iconBase*1.05, 48, 104)
-}
-
-// normalizePos converts an absolute widget-relative position to 0-1 coords.
-func (layoutWidget *DeviceStatusLayoutWidget) normalizePos(pos fyne.Position) fyne.Position {
-	w := layoutWidget.containerSize.Width
-	h := layoutWidget.containerSize.Height
-	if w <= 0 {
-		w = 1
-	}
-	if h <= 0 {
-		h = 1
-	}
-	x := clampFloat(pos.X/w, 0, 1)
-	y := clampFloat(pos.Y/h, 0, 1)
-	return fyne.NewPos(x, y)
-}
-
-// absolutePos converts normalized 0-1 coords to absolute widget-relative position.
-func (layoutWidget *DeviceStatusLayoutWidget) absolutePos(norm fyne.Position) fyne.Position {
-	return fyne.NewPos(norm.X*layoutWidget.containerSize.Width, norm.Y*layoutWidget.containerSize.Height)
-}
-
-func clampFloat(value, minValue, maxValue float32) float32 {
-	if value < minValue {
-		return minValue
-	}
-	if value > maxValue {
-		return maxValue
-	}
-	return value
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) UpdateDevices() {
-	layoutWidget.ensurePositions()
-	layoutWidget.Refresh()
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) SetLayoutLocked(locked bool) {
-	layoutWidget.layoutLocked = locked
-	layoutWidget.isDragging = false
-	layoutWidget.draggingDeviceID = 0
-	layoutWidget.Refresh()
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) ResetLayout() {
-	layoutWidget.devicePositions = layoutWidget.defaultPositions()
-	layoutWidget.saveDeviceLayout()
-	layoutWidget.Refresh()
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) Tapped(tapEvent *fyne.PointEvent) {
-	for _, device := range allDevices {
-		center := layoutWidget.positionForDevice(device.ID)
-		size := layoutWidget.iconSizeForDevice(device.ID)
-		topLeft := fyne.NewPos(center.X-size/2, center.Y-size/2)
-
-		if tapEvent.Position.X < topLeft.X || tapEvent.Position.X > topLeft.X+size ||
-			tapEvent.Position.Y < topLeft.Y || tapEvent.Position.Y > topLeft.Y+size {
-			continue
-		}
-
-		if assignmentUserID != "" {
-			targetUserID := assignmentUserID
-			assignmentUserID = ""
-			if assignmentNoticeLabel != nil {
-				assignmentNoticeLabel.SetText("")
-			}
-			if err := assignQueuedUserToDevice(targetUserID, device.ID); err != nil {
-				dialog.ShowError(err, mainWindow)
-			}
-			return
-		}
-
-		if device.Type == "Console" {
-			if device.Status == "occupied" {
-				showConsoleCheckoutDialog(device)
-			} else {
-				showCheckInDialogShared(device.ID, true)
-			}
-			return
-		}
-
-		if device.Status == "occupied" {
-			user := getUserByID(device.UserID)
-			userName := "Unknown User"
-			if user != nil {
-				userName = user.Name
-			}
-			dialog.ShowConfirm(
-				"Confirm Checkout",
-				fmt.Sprintf("Checkout %s from PC %d?", userName, device.ID),
-				func(confirm bool) {
-					if confirm {
-						if err := checkoutUser(device.UserID); err != nil {
-							dialog.ShowError(err, mainWindow)
-						}
-					}
-				},
-				mainWindow,
-			)
-			return
-		}
-
-		showCheckInDialogShared(device.ID, true)
-		return
-	}
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) MouseDown(mouseEvent *desktop.MouseEvent) {
-	if mouseEvent.Button != desktop.MouseButtonSecondary {
-		return
-	}
-	for _, device := range allDevices {
-		if device.Type != "Console" {
-			continue
-		}
-		center := layoutWidget.positionForDevice(device.ID)
-		size := layoutWidget.iconSizeForDevice(device.ID)
-		topLeft := fyne.NewPos(center.X-size/2, center.Y-size/2)
-		if mouseEvent.Position.X >= topLeft.X && mouseEvent.Position.X <= topLeft.X+size &&
-			mouseEvent.Position.Y >= topLeft.Y && mouseEvent.Position.Y <= topLeft.Y+size {
-			if device.Status == "occupied" {
-				showConsoleCheckoutDialog(device)
-			}
-			return
-		}
-	}
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) MouseUp(_ *desktop.MouseEvent) {}
-
-func (layoutWidget *DeviceStatusLayoutWidget) Dragged(dragEvent *fyne.DragEvent) {
-	if !layoutWidget.isDragging {
-		for _, device := range allDevices {
-			center := layoutWidget.positionForDevice(device.ID)
-			size := layoutWidget.iconSizeForDevice(device.ID)
-			topLeft := fyne.NewPos(center.X-size/2, center.Y-size/2)
-			if dragEvent.Position.X >= topLeft.X && dragEvent.Position.X <= topLeft.X+size &&
-				dragEvent.Position.Y >= topLeft.Y && dragEvent.Position.Y <= topLeft.Y+size {
-				if layoutWidget.layoutLocked && (device.Type != "PC" || device.Status != "occupied") {
-					continue
-				}
-				layoutWidget.isDragging = true
-				layoutWidget.draggingDeviceID = device.ID
-				layoutWidget.dragOffset = fyne.NewPos(dragEvent.Position.X-center.X, dragEvent.Position.Y-center.Y)
-				layoutWidget.transientDragPos = center
-				layoutWidget.swapDragActive = layoutWidget.layoutLocked && device.Type == "PC" && device.Status == "occupied"
-				if layoutWidget.swapDragActive {
-					updateDragOverlay(dragEvent.AbsolutePosition, true)
-				}
-				break
-			}
-		}
-	}
-	if layoutWidget.isDragging && layoutWidget.draggingDeviceID != 0 {
-		newX := dragEvent.Position.X - layoutWidget.dragOffset.X
-		newY := dragEvent.Position.Y - layoutWidget.dragOffset.Y
-		minX := layoutWidget.slotMargin + layoutWidget.pcIconSize/2
-		maxX := layoutWidget.containerSize.Width - layoutWidget.slotMargin - layoutWidget.pcIconSize/2
-		minY := layoutWidget.slotMargin + layoutWidget.pcIconSize/2
-		maxY := layoutWidget.containerSize.Height - layoutWidget.slotMargin - layoutWidget.pcIconSize/2
-		if newX < minX {
-			newX = minX
-		}
-		if newX > maxX {
-			newX = maxX
-		}
-		if newY < minY {
-			newY = minY
-		}
-		if newY > maxY {
-			newY = maxY
-		}
-		layoutWidget.transientDragPos = fyne.NewPos(newX, newY)
-		if layoutWidget.layoutLocked && layoutWidget.swapDragActive {
-			updateDragOverlay(dragEvent.AbsolutePosition, true)
-		} else {
-			layoutWidget.Refresh()
-		}
-	}
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) DragEnd() {
-	if !layoutWidget.isDragging || layoutWidget.draggingDeviceID == 0 {
-		if layoutWidget.swapDragActive {
-			updateDragOverlay(fyne.NewPos(0, 0), false)
-		}
-		layoutWidget.isDragging = false
-		layoutWidget.draggingDeviceID = 0
-		layoutWidget.swapDragActive = false
-		return
-	}
-	if layoutWidget.layoutLocked {
-		layoutWidget.handleLockedDrop()
-		if layoutWidget.swapDragActive {
-			updateDragOverlay(fyne.NewPos(0, 0), false)
-		}
-		layoutWidget.isDragging = false
-		layoutWidget.draggingDeviceID = 0
-		layoutWidget.swapDragActive = false
-		layoutWidget.Refresh()
-		return
-	}
-	// Free-form layout: store the dropped position directly (normalized).
-	layoutWidget.devicePositions[layoutWidget.draggingDeviceID] = layoutWidget.normalizePos(layoutWidget.transientDragPos)
-	layoutWidget.saveDeviceLayout()
-	layoutWidget.isDragging = false
-	layoutWidget.draggingDeviceID = 0
-	if layoutWidget.swapDragActive {
-		updateDragOverlay(fyne.NewPos(0, 0), false)
-	}
-	layoutWidget.Refresh()
-	layoutWidget.swapDragActive = false
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) handleLockedDrop() {
-	sourceDevice := getDeviceByID(layoutWidget.draggingDeviceID)
-	if sourceDevice == nil || sourceDevice.Type != "PC" || sourceDevice.Status != "occupied" || sourceDevice.UserID == "" {
-		return
-	}
-	targetDevice := layoutWidget.deviceAtPosition(layoutWidget.transientDragPos)
-	if targetDevice == nil || targetDevice.Type != "PC" || targetDevice.Status != "free" {
-		return
-	}
-	if err := switchUserDevice(sourceDevice.UserID, targetDevice.ID); err != nil {
-		dialog.ShowError(err, mainWindow)
-	}
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) positionForDevice(deviceID int) fyne.Position {
-	if layoutWidget.isDragging && !layoutWidget.layoutLocked && layoutWidget.draggingDeviceID == deviceID {
-		return layoutWidget.transientDragPos
-	}
-	if norm, ok := layoutWidget.devicePositions[deviceID]; ok {
-		return layoutWidget.absolutePos(norm)
-	}
-	return fyne.NewPos(layoutWidget.slotMargin+layoutWidget.pcIconSize, layoutWidget.slotMargin+layoutWidget.pcIconSize)
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) iconSizeForDevice(deviceID int) float32 {
-	if deviceID == 17 || deviceID == 18 {
-		return layoutWidget.consoleIconSize
-	}
-	return layoutWidget.pcIconSize
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) deviceAtPosition(pos fyne.Position) *Device {
-	for i := range allDevices {
-		device := &allDevices[i]
-		center := layoutWidget.positionForDevice(device.ID)
-		size := layoutWidget.iconSizeForDevice(device.ID)
-		topLeft := fyne.NewPos(center.X-size/2, center.Y-size/2)
-		if pos.X >= topLeft.X && pos.X <= topLeft.X+size &&
-			pos.Y >= topLeft.Y && pos.Y <= topLeft.Y+size {
-			return device
-		}
-	}
-	return nil
-}
-
-func updateDragOverlay(pos fyne.Position, active bool) {
-	if mainWindow == nil {
-		return
-	}
-	canvasObj := mainWindow.Canvas()
-	if active {
-		if dragIndicator == nil {
-			imgPath := filepath.Join(imgBaseDir, "move.png")
-			img := canvas.NewImageFromFile(imgPath)
-			img.SetMinSize(fyne.NewSize(84, 84))
-			img.Resize(fyne.NewSize(84, 84))
-			dragIndicator = img
-		}
-		dragIndicator.Move(fyne.NewPos(pos.X+20, pos.Y+20))
-		if !dragIndicatorVisible {
-			canvasObj.Overlays().Add(dragIndicator)
-			dragIndicatorVisible = true
-		}
-		dragIndicator.Show()
-		dragIndicator.Refresh()
-	} else if dragIndicatorVisible {
-		canvasObj.Overlays().Remove(dragIndicator)
-		dragIndicatorVisible = false
-	}
-}
-
-func updateQueuedUserDrag(userID string, pos fyne.Position) {
-	if pendingDragActive && pendingDragUserID != userID {
-		return
-	}
-	pendingDragUserID = userID
-	pendingDragActive = true
-	pendingDragLastPos = pos
-	updateDragOverlay(pos, true)
-}
-
-func endQueuedUserDrag() {
-	if !pendingDragActive {
-		return
-	}
-	updateDragOverlay(fyne.NewPos(0, 0), false)
-	attemptAssignQueuedUserAtPos(pendingDragLastPos)
-	pendingDragActive = false
-	pendingDragUserID = ""
-}
-
-func cancelQueuedUserDrag() {
-	if !pendingDragActive {
-		return
-	}
-	updateDragOverlay(fyne.NewPos(0, 0), false)
-	pendingDragActive = false
-	pendingDragUserID = ""
-}
-
-func attemptAssignQueuedUserAtPos(absPos fyne.Position) {
-	if pendingDragUserID == "" || deviceLayoutWidget == nil {
-		return
-	}
-	driver := fyne.CurrentApp().Driver()
-	if driver == nil {
-		return
-	}
-	widgetPos := driver.AbsolutePositionForObject(deviceLayoutWidget)
-	size := deviceLayoutWidget.Size()
-	if absPos.X < widgetPos.X || absPos.Y < widgetPos.Y ||
-		absPos.X > widgetPos.X+size.Width || absPos.Y > widgetPos.Y+size.Height {
-		return
-	}
-	rel := fyne.NewPos(absPos.X-widgetPos.X, absPos.Y-widgetPos.Y)
-	target := deviceLayoutWidget.deviceAtPosition(rel)
-	if target == nil || target.Type != "PC" || target.Status != "free" {
-		cancelQueuedUserDrag()
-		return
-	}
-	if err := assignQueuedUserToDevice(pendingDragUserID, target.ID); err != nil {
-		dialog.ShowError(err, mainWindow)
-		cancelQueuedUserDrag()
-		return
-	}
-	if pendingIconsBox != nil {
-		refreshPendingIcons()
-	}
-	cancelQueuedUserDrag()
-}
-
-type deviceVisual struct {
-	icon      *canvas.Image
-	primary   *canvas.Text
-	secondary *canvas.Text
-}
-
-type deviceStatusRenderer struct {
-	widget  *DeviceStatusLayoutWidget
-	objects []fyne.CanvasObject
-	visuals map[int]*deviceVisual
-}
-
-func (renderer *deviceStatusRenderer) Layout(size fyne.Size) {
-	if renderer.widget.containerSize != size {
-		renderer.widget.containerSize = size
-		renderer.widget.computeIconSizes()
-	}
-	renderer.Refresh()
-}
-
-func (renderer *deviceStatusRenderer) MinSize() fyne.Size { return fyne.NewSize(840, 520) }
-
-func firstLast(name string) string {
-	parts := strings.Fields(strings.TrimSpace(name))
-	if len(parts) >= 2 {
-		return parts[0] + " " + parts[1]
-	}
-	if len(parts) == 1 {
-		return parts[0]
-	}
-	return ""
-}
-
-func firstLastNonEmpty(name string) string {
-	if trimmed := strings.TrimSpace(name); trimmed != "" {
-		if out := firstLast(trimmed); out != "" {
-			return out
-		}
-		return trimmed
-	}
-	return "Unnamed"
-}
-
-func usersOnDevice(deviceID int) []User {
-	users := []User{}
-	for _, user := range activeUsers {
-		if user.PCID == deviceID {
-			users = append(users, user)
-		}
-	}
-	return users
-}
-
-func (renderer *deviceStatusRenderer) Refresh() {
-	for _, device := range allDevices {
-		visual, ok := renderer.visuals[device.ID]
-		if !ok {
-			visual = renderer.newVisualForDevice(device)
-			renderer.visuals[device.ID] = visual
-			renderer.objects = append(renderer.objects, visual.icon, visual.primary, visual.secondary)
-		}
-		renderer.updateVisual(device, visual)
-	}
-}
-
-func (renderer *deviceStatusRenderer) Objects() []fyne.CanvasObject { return renderer.objects }
-func (renderer *deviceStatusRenderer) Destroy()                     {}
-
-func (renderer *deviceStatusRenderer) newVisualForDevice(device Device) *deviceVisual {
-	icon := canvas.NewImageFromFile(filepath.Join(imgBaseDir, "free.png"))
-	icon.FillMode = canvas.ImageFillContain
-	primary := canvas.NewText("", theme.ForegroundColor())
-	primary.Alignment = fyne.TextAlignCenter
-	secondary := canvas.NewText("", color.NRGBA{A: 255, R: 150, G: 150, B: 160})
-	secondary.Alignment = fyne.TextAlignCenter
-	secondary.TextSize = 10
-	return &deviceVisual{icon: icon, primary: primary, secondary: secondary}
-}
-
-func (renderer *deviceStatusRenderer) updateVisual(device Device, visual *deviceVisual) {
-	center := renderer.widget.positionForDevice(device.ID)
-	size := renderer.widget.iconSizeForDevice(device.ID)
-	imageName := renderer.imageNameForDevice(device)
-	imagePath := filepath.Join(imgBaseDir, imageName)
-	if visual.icon.File != imagePath {
-		visual.icon.File = imagePath
-	}
-	visual.icon.SetMinSize(fyne.NewSize(size, size))
-	visual.icon.Resize(fyne.NewSize(size, size))
-	visual.icon.Move(fyne.NewPos(center.X-size/2, center.Y-size/2))
-	visual.icon.Refresh()
-
-	nameText := ""
-	if device.Type == "PC" {
-		if device.Status == "occupied" {
-			if user := getUserByID(device.UserID); user != nil {
-				nameText = firstLast(user.Name)
-			}
-		}
-	} else {
-		deviceUsers := usersOnDevice(device.ID)
-		if len(deviceUsers) > 0 {
-			names := make([]string, 0, len(deviceUsers))
-			for _, deviceUser := range deviceUsers {
-				names = append(names, firstLast(deviceUser.Name))
-			}
-			nameText = strings.Join(names, ", ")
-		}
-	}
-
-	if nameText != "" {
-		visual.primary.Text = nameText
-		visual.primary.TextStyle = fyne.TextStyle{}
-		visual.primary.TextSize = 12
-		visual.primary.Refresh()
-		visual.primary.Move(fyne.NewPos(center.X-visual.primary.MinSize().Width/2, center.Y+size/2-4))
-		visual.primary.Show()
-
-		visual.secondary.Text = fmt.Sprintf("%d", device.ID)
-		visual.secondary.Refresh()
-		visual.secondary.Move(fyne.NewPos(center.X-visual.secondary.MinSize().Width/2, center.Y+size/2+12))
-		visual.secondary.Show()
-	} else {
-		visual.primary.Text = strconv.Itoa(device.ID)
-		visual.primary.TextStyle = fyne.TextStyle{Bold: true}
-		visual.primary.TextSize = 12
-		visual.primary.Refresh()
-		visual.primary.Move(fyne.NewPos(center.X-visual.primary.MinSize().Width/2, center.Y+size/2-4))
-		visual.primary.Show()
-
-		visual.secondary.Hide()
-	}
-}
-
-func (renderer *deviceStatusRenderer) imageNameForDevice(device Device) string {
-	if device.Status == "free" {
-		if device.Type == "PC" {
-			return "free.png"
-		}
-		return "console.png"
-	}
-	if device.Type == "PC" {
-		return "busy.png"
-	}
-	return "console_busy.png"
-}
-
-func (layoutWidget *DeviceStatusLayoutWidget) CreateRenderer() fyne.WidgetRenderer {
-	layoutWidget.computeIconSizes()
-	renderer := &deviceStatusRenderer{
-		widget:  layoutWidget,
-		visuals: make(map[int]*deviceVisual),
-	}
-	for _, device := range allDevices {
-		visual := renderer.newVisualForDevice(device)
-		renderer.visuals[device.ID] = visual
-		renderer.objects = append(renderer.objects, visual.icon, visual.primary, visual.secondary)
-	}
-	return renderer
-}
-
-
-func ensureLogDir() error { return os.MkdirAll(logDir, 0o755) }
-
-func todaysLogDate() string { return time.Now().Format("2006-01-02") }
-
-func getLogFilePath() string { return getLogFilePathForDate(todaysLogDate()) }
-
-func getLogFilePathForDate(date string) string {
-	if date == "" {
-		date = todaysLogDate()
-	}
-	return filepath.Join(logDir, fmt.Sprintf("lounge-%s.json", date))
-}
-
-func readDailyLogEntries() ([]LogEntry, error) {
-	return readLogEntriesForDate(todaysLogDate())
-}
-
-func readLogEntriesForDate(date string) ([]LogEntry, error) {
-	p := getLogFilePathForDate(date)
-	if _, err := os.Stat(p); os.IsNotExist(err) {
-		return []LogEntry{}, nil
-	}
-	logFile, err := os.Open(p)
-	if err != nil {
-		return nil, fmt.Errorf("open log: %s: %w", p, err)
-	}
-	defer logFile.Close()
-	fileData, err := io.ReadAll(logFile)
-	if err != nil {
-		return nil, fmt.Errorf("read log: %s: %w", p, err)
-	}
-	var entries []LogEntry
-	if len(fileData) > 0 {
-		if err := json.Unmarshal(fileData, &entries); err != nil {
-			return nil, fmt.Errorf("unmarshal log: %s: %w", p, err)
-		}
-	}
-	return entries, nil
-}
-
-func writeDailyLogEntries(entries []LogEntry) error {
-	data, err := json.MarshalIndent(entries, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal log: %w", err)
-	}
-	return os.WriteFile(getLogFilePath(), data, 0o644)
-}
-
-func recordLogEvent(isCheckIn bool, u User, deviceID int, original *time.Time) {
-	logFileMutex.Lock()
-	defer logFileMutex.Unlock()
-	if err := ensureLogDir(); err != nil {
-		fmt.Println("Error creating log directory:", err)
-		return
-	}
-	entries, err := readDailyLogEntries()
-	if err != nil {
-		fmt.Println("Error reading daily log:", err)
-		return
-	}
-	if isCheckIn {
-		entries = append(entries, LogEntry{UserName: u.Name, UserID: u.ID, PCID: deviceID, CheckInTime: u.CheckInTime})
-	} else {
-		found := false
-		for i := len(entries) - 1; i >= 0; i-- {
-			e := entries[i]
-			if e.UserID == u.ID && e.PCID == deviceID && e.CheckOutTime.IsZero() {
-				if original == nil || e.CheckInTime.Equal(*original) {
-					entries[i].CheckOutTime = time.Now()
-					entries[i].UsageTime = formatDuration(entries[i].CheckOutTime.Sub(entries[i].CheckInTime))
-					found = true
-					break
-				}
-			}
-		}
-		if !found {
-			fmt.Printf("No matching check-in for user %s (ID: %s) Device %d.\n", u.Name, u.ID, deviceID)
-		}
-	}
-	if err := writeDailyLogEntries(entries); err != nil {
-		fmt.Println("Error writing daily log:", err)
-	}
-	fyne.Do(func() {
-		currentLogEntries = entries
-		refreshDisplayedLogEntries()
-		if logList != nil {
-			logList.Refresh()
-			logRefreshPending = false
-		} else {
-			logRefreshPending = true
-		}
-	})
-}
-
-func formatDuration(d time.Duration) string {
-	d = d.Round(time.Second)
-	h := int(d.Hours())
-	m := int(d.Minutes()) % 60
-	s := int(d.Seconds()) % 60
-	if h > 0 {
-		return fmt.Sprintf("%dh%02dm%02ds", h, m, s)
-	}
-	if m > 0 {
-		return fmt.Sprintf("%dm%02ds", m, s)
-	}
-	return fmt.Sprintf("%ds", s)
-}
-
-func formatAgo(ts time.Time) string {
-	if ts.IsZero() {
-		return "just now"
-	}
-	if ts.After(time.Now()) {
-		return "just now"
-	}
-	return fmt.Sprintf("%s ago", formatDuration(time.Since(ts)))
-}
-
-func updateCurrentLogEntriesCache() {
-	if selectedLogDate == "" {
-		selectedLogDate = todaysLogDate()
-	}
-	entries, err := readLogEntriesForDate(selectedLogDate)
-	if err != nil {
-		fmt.Println("Error updating log cache:", err)
-		currentLogEntries = []LogEntry{}
-	} else {
-		currentLogEntries = entries
-	}
-	refreshDisplayedLogEntries()
-	refreshLogDateOptions()
-}
-
-func refreshDisplayedLogEntries() {
-	if len(currentLogEntries) == 0 {
-		displayedLogEntries = []LogEntry{}
-		return
-	}
-	displayedLogEntries = make([]LogEntry, len(currentLogEntries))
-	copy(displayedLogEntries, currentLogEntries)
-	sortLogEntries(displayedLogEntries, currentLogSort)
-}
-
-func setLogSort(sortOption string) {
-	if sortOption == "" {
-		sortOption = logSortMostRecent
-	}
-	currentLogSort = sortOption
-	refreshDisplayedLogEntries()
-	if logSortSelect != nil && logSortSelect.Selected != sortOption {
-		logSortSelect.Selected = sortOption
-		logSortSelect.Refresh()
-	}
-	if logList != nil {
-		logList.Refresh()
-	}
-}
-
-func sortLogEntries(entries []LogEntry, sortOption string) {
-	switch sortOption {
-	case logSortOldest:
-		sort.SliceStable(entries, func(i, j int) bool {
-			return logEntryActivityTime(entries[i]).Before(logEntryActivityTime(entries[j]))
-		})
-	case logSortLongestSession:
-		sort.SliceStable(entries, func(i, j int) bool {
-			di := logEntrySessionDuration(entries[i])
-			dj := logEntrySessionDuration(entries[j])
-			if di == dj {
-				return logEntryActivityTime(entries[i]).After(logEntryActivityTime(entries[j]))
-			}
-			return di > dj
-		})
-	default:
-		sort.SliceStable(entries, func(i, j int) bool {
-			return logEntryActivityTime(entries[i]).After(logEntryActivityTime(entries[j]))
-		})
-	}
-}
-
-func logEntryActivityTime(entry LogEntry) time.Time {
-	if !entry.CheckOutTime.IsZero() {
-		return entry.CheckOutTime
-	}
-	return entry.CheckInTime
-}
-
-func logEntrySessionDuration(entry LogEntry) time.Duration {
-	if !entry.CheckOutTime.IsZero() {
-		return entry.CheckOutTime.Sub(entry.CheckInTime)
-	}
-	return time.Since(entry.CheckInTime)
-}
-
-func listAvailableLogDates() []string {
-	files, err := os.ReadDir(logDir)
-	if err != nil {
-		return []string{todaysLogDate()}
-	}
-	dates := make(map[string]bool)
-	dates[todaysLogDate()] = true
-	for _, f := range files {
-		name := f.Name()
-		if strings.HasPrefix(name, "lounge-") && strings.HasSuffix(name, ".json") && len(name) >= len("lounge-2006-01-02.json") {
-			date := strings.TrimSuffix(strings.TrimPrefix(name, "lounge-"), ".json")
-			dates[date] = true
-		}
-	}
-	out := make([]string, 0, len(dates))
-	for d := range dates {
-		out = append(out, d)
-	}
-	sort.Sort(sort.Reverse(sort.StringSlice(out)))
-	return out
-}
-
-func refreshLogDateOptions() {
-	if logDateSelect == nil {
-		return
-	}
-	options := listAvailableLogDates()
-	logDateSelect.Options = options
-	logDateSelect.Refresh()
-}
-
-func setLogDate(date string) {
-	if date == "" {
-		date = todaysLogDate()
-	}
-	selectedLogDate = date
-	if logDateSelect != nil && logDateSelect.Selected != date {
-		logDateSelect.Selected = date
-		logDateSelect.Refresh()
-	}
-	updateCurrentLogEntriesCache()
-	if logList != nil {
-		logList.Refresh()
-	}
-}
-
-func buildLogView() fyne.CanvasObject {
-	updateCurrentLogEntriesCache()
-	logList = widget.NewList(
-		func() int { return len(displayedLogEntries) },
-		func() fyne.CanvasObject { return newLogEntryCard() },
-		func(i widget.ListItemID, o fyne.CanvasObject) {
-			if i < 0 || i >= len(displayedLogEntries) {
-				return
-			}
-			card := o.(*logEntryCard)
-			card.SetEntry(displayedLogEntries[i])
-		},
-	)
-	logRefreshPending = false
-	header := widget.NewLabelWithStyle("Live Activity", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-	dates := listAvailableLogDates()
-	if selectedLogDate == "" {
-		selectedLogDate = todaysLogDate()
-	}
-	logDateSelect = widget.NewSelect(dates, func(val string) {
-		setLogDate(val)
-	})
-	logDateSelect.PlaceHolder = "Select date"
-	logDateSelect.Selected = selectedLogDate
-	sortOptions := []string{logSortMostRecent, logSortOldest, logSortLongestSession}
-	logSortSelect = widget.NewSelect(sortOptions, func(val string) {
-		setLogSort(val)
-	})
-	logSortSelect.PlaceHolder = "Sort logs"
-	logSortSelect.Selected = currentLogSort
-	toolbar := container.NewHBox(header, layout.NewSpacer(), logDateSelect, logSortSelect)
-	return container.NewBorder(toolbar, nil, nil, nil, logList)
-}
-
-type logEntryCard struct {
-	widget.BaseWidget
-	line  *widget.Label
-	badge *canvas.Text
-}
-
-func newLogEntryCard() *logEntryCard {
-	c := &logEntryCard{
-		line:  widget.NewLabel(""),
-		badge: canvas.NewText("", theme.PrimaryColor()),
-	}
-	c.ExtendBaseWidget(c)
-	return c
-}
-
-func (c *logEntryCard) CreateRenderer() fyne.WidgetRenderer {
-	c.line.Wrapping = fyne.TextTruncate
-	c.line.Alignment = fyne.TextAlignLeading
-	c.badge.TextStyle.Bold = true
-	c.badge.Alignment = fyne.TextAlignCenter
-	c.badge.TextSize = 10
-	right := container.NewCenter(c.badge)
-	body := container.NewBorder(nil, nil, right, nil, c.line)
-	return widget.NewSimpleRenderer(body)
-}
-
-func (c *logEntryCard) SetEntry(entry LogEntry) {
-	checkIn := entry.CheckInTime.Format("Jan 02 15:04")
-	outText := "--"
-	if entry.CheckOutTime.IsZero() {
-		outText = "--"
-		c.badge.Text = "ACTIVE"
-		c.badge.Color = color.NRGBA{R: 4, G: 165, B: 229, A: 255}
-	} else {
-		outText = entry.CheckOutTime.Format("Jan 02 15:04")
-		c.badge.Text = "DONE"
-		c.badge.Color = color.NRGBA{R: 64, G: 160, B: 43, A: 255}
-	}
-	session := entry.UsageTime
-	if entry.CheckOutTime.IsZero() {
-		session = formatDuration(time.Since(entry.CheckInTime))
-	} else if session == "" {
-		session = formatDuration(entry.CheckOutTime.Sub(entry.CheckInTime))
-	}
-	line := fmt.Sprintf("%s · PC %d    In: %s    Out: %s    Session: %s", entry.UserName, entry.PCID, checkIn, outText, session)
-	c.line.SetText(line)
-	c.line.Refresh()
-	c.badge.Refresh()
-}
-
-type leftRatioLayout struct {
-	ratio float32
-	minW  float32
-	maxW  float32
-}
-
-func (l *leftRatioLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
-	if len(objects) == 0 {
-		return
-	}
-	child := objects[0]
-	w := size.Width * l.ratio
-	if l.minW > 0 && w < l.minW {
-		w = l.minW
-	}
-	if l.maxW > 0 && w > l.maxW {
-		w = l.maxW
-	}
-	child.Resize(fyne.NewSize(w, child.MinSize().Height))
-	child.Move(fyne.NewPos(0, 0))
-}
-
-func (l *leftRatioLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
-	if len(objects) == 0 {
-		return fyne.NewSize(0, 0)
-	}
-	min := objects[0].MinSize()
-	if l.minW > 0 && min.Width < l.minW {
-		return fyne.NewSize(l.minW, min.Height)
-	}
-	return min
-}
-
-type verticalWrapLayout struct {
-	padding float32
-}
-
-func (l *verticalWrapLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
-	if len(objects) == 0 {
-		return
-	}
-	padding := l.padding
-	if padding <= 0 {
-		padding = 8
-	}
-	maxHeight := size.Height
-	if maxHeight <= 0 {
-		maxHeight = 320
-	}
-	x := float32(0)
-	y := float32(0)
-	colWidth := float32(0)
-	for _, obj := range objects {
-		if obj == nil || !obj.Visible() {
-			continue
-		}
-		item := obj.MinSize()
-		if y+item.Height > maxHeight && y > 0 {
-			x += colWidth + padding
-			y = 0
-			colWidth = 0
-		}
-		obj.Resize(item)
-		obj.Move(fyne.NewPos(x, y))
-		y += item.Height + padding
-		if item.Width > colWidth {
-			colWidth = item.Width
-		}
-	}
-}
-
-func (l *verticalWrapLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
-	if len(objects) == 0 {
-		return fyne.NewSize(0, 0)
-	}
-	padding := l.padding
-	if padding <= 0 {
-		padding = 8
-	}
-	var width, height float32
-	for i, obj := range objects {
-		if obj == nil || !obj.Visible() {
-			continue
-		}
-		item := obj.MinSize()
-		if item.Width > width {
-			width = item.Width
-		}
-		if i > 0 {
-			height += padding
-		}
-		height += item.Height
-	}
-	return fyne.NewSize(width, height)
-}
-
-type twoPaneLayout struct {
-	leftRatio float32
-	leftMin   float32
-	leftMax   float32
-}
-
-func (l *twoPaneLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
-	if len(objects) < 2 {
-		return
-	}
-	leftWidth := size.Width * l.leftRatio
-	if l.leftMin > 0 && leftWidth < l.leftMin {
-		leftWidth = l.leftMin
-	}
-	if l.leftMax > 0 && leftWidth > l.leftMax {
-		leftWidth = l.leftMax
-	}
-	if leftWidth > size.Width {
-		leftWidth = size.Width
-	}
-	left := objects[0]
-	right := objects[1]
-	left.Resize(fyne.NewSize(leftWidth, size.Height))
-	left.Move(fyne.NewPos(0, 0))
-	rightWidth := size.Width - leftWidth
-	if rightWidth < 0 {
-		rightWidth = 0
-	}
-	right.Resize(fyne.NewSize(rightWidth, size.Height))
-	right.Move(fyne.NewPos(leftWidth, 0))
-}
-
-func (l *twoPaneLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
-	if len(objects) < 2 {
-		return fyne.NewSize(0, 0)
-	}
-	leftMin := objects[0].MinSize()
-	rightMin := objects[1].MinSize()
-	requiredLeft := leftMin.Width
-	if l.leftMin > 0 && requiredLeft < l.leftMin {
-		requiredLeft = l.leftMin
-	}
-	width := requiredLeft + rightMin.Width
-	height := leftMin.Height
-	if rightMin.Height > height {
-		height = rightMin.Height
-	}
-	return fyne.NewSize(width, height)
-}
-
-func decorateCard(content fyne.CanvasObject) fyne.CanvasObject {
-	background := canvas.NewRectangle(color.NRGBA{R: 245, G: 246, B: 252, A: 255})
-	background.SetMinSize(content.MinSize().Add(fyne.NewSize(32, 32)))
-	card := container.NewPadded(content)
-	return container.NewMax(background, card)
-}
-
-func updateLayoutLockButton(btn *widget.Button) {
-	if btn == nil {
-		return
-	}
-	if layoutLocked {
-		btn.SetText("Unlock Layout")
-		btn.SetIcon(theme.VisibilityIcon())
-	} else {
-		btn.SetText("Lock Layout")
-		btn.SetIcon(theme.VisibilityOffIcon())
-	}
-	btn.Refresh()
-}
-
-func buildInlineCheckInForm() *fyne.Container {
-	checkInNameEntry = widget.NewEntry()
-	checkInNameEntry.SetPlaceHolder("Full Name")
-
-	checkInIDEntry = widget.NewEntry()
-	checkInIDEntry.SetPlaceHolder("User ID")
-
-	checkInSearchEntry = widget.NewEntry()
-	checkInSearchEntry.SetPlaceHolder("Search Member (Name or ID)")
-
-	filteredMembersForInline = nil
-
-	checkInResultsList = widget.NewList(
-		func() int { return len(filteredMembersForInline) },
-		func() fyne.CanvasObject { return widget.NewLabel("") },
-		func(i widget.ListItemID, o fyne.CanvasObject) {
-			if i >= 0 && i < len(filteredMembersForInline) {
-				m := filteredMembersForInline[i]
-				o.(*widget.Label).SetText(fmt.Sprintf("%s (%s)", m.Name, m.ID))
-			}
-		},
-	)
-	resultsScroll := container.NewScroll(checkInResultsList)
-	resultsScroll.SetMinSize(fyne.NewSize(0, 120))
-	resultsScroll.Hide()
-
-	checkInResultsList.OnSelected = func(i widget.ListItemID) {
-		if i < 0 || i >= len(filteredMembersForInline) {
-			return
-		}
-		m := filteredMembersForInline[i]
-		checkInNameEntry.SetText(m.Name)
-		checkInIDEntry.SetText(m.ID)
-		checkInSearchEntry.SetText("")
-		filteredMembersForInline = nil
-		checkInResultsList.UnselectAll()
-		checkInResultsList.Refresh()
-		resultsScroll.Hide()
-		if mainWindow != nil {
-			mainWindow.Canvas().Focus(checkInIDEntry)
-		}
-	}
-
-	checkInSearchEntry.OnChanged = func(q string) {
-		q = strings.ToLower(strings.TrimSpace(q))
-		if len(members) == 0 {
-			loadMembers()
-		}
-		if q == "" {
-			filteredMembersForInline = nil
-			checkInResultsList.Refresh()
-			resultsScroll.Hide()
-			return
-		}
-		matches := make([]Member, 0, 20)
-		for _, m := range members {
-			n := strings.ToLower(strings.TrimSpace(m.Name))
-			id := strings.ToLower(strings.TrimSpace(m.ID))
-			if strings.Contains(n, q) || strings.Contains(id, q) {
-				matches = append(matches, m)
-			}
-		}
-		filteredMembersForInline = matches
-		checkInResultsList.Refresh()
-		if len(matches) > 0 {
-			resultsScroll.Show()
-		} else {
-			resultsScroll.Hide()
-		}
-	}
-
-	noIDButton := widget.NewButton("No ID?", func() {
-		checkInIDEntry.SetText("LOUNGE-" + getNextMemberID())
-	})
-	addButton := widget.NewButton("Add to Queue", func() {
-		name := strings.TrimSpace(checkInNameEntry.Text)
-		id := strings.TrimSpace(checkInIDEntry.Text)
-		if name == "" || id == "" {
-			dialog.ShowError(fmt.Errorf("name and ID are required"), mainWindow)
-			return
-		}
-		if err := registerUser(name, id, 0); err != nil {
-			dialog.ShowError(err, mainWindow)
-			return
-		}
-		checkInNameEntry.SetText("")
-		checkInIDEntry.SetText("")
-		if pendingIconsBox != nil {
-			refreshPendingIcons()
-		}
-	})
-	hideButton := widget.NewButton("Hide", func() {})
-
-	idRow := container.NewBorder(nil, nil, nil, noIDButton, checkInIDEntry)
-
-	form := widget.NewForm(
-		widget.NewFormItem("Name", checkInNameEntry),
-		widget.NewFormItem("ID", idRow),
-	)
-
-	header := widget.NewLabelWithStyle("Queue Check-In", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-	bar := container.NewBorder(nil, nil, nil, hideButton, header)
-	body := container.NewVBox(
-		checkInSearchEntry,
-		resultsScroll,
-		form,
-		container.NewHBox(layout.NewSpacer(), addButton),
-	)
-	hideButton.OnTapped = func() {
-		if body.Hidden {
-			body.Show()
-			hideButton.SetText("Hide")
-			hideButton.Refresh()
-		} else {
-			body.Hide()
-			hideButton.SetText("Show")
-			hideButton.Refresh()
-		}
-	}
-	card := container.NewVBox(bar, widget.NewSeparator(), body)
-
-	wrapper := container.New(&leftRatioLayout{ratio: 0.25, minW: 360, maxW: 560}, container.NewPadded(card))
-	return wrapper
-}
-
-func buildPendingQueueView() fyne.CanvasObject {
-	assignmentNoticeLabel = widget.NewLabel("")
-	pendingIconsBox = container.New(&verticalWrapLayout{padding: 10})
-	refreshPendingIcons()
-
-	header := widget.NewLabelWithStyle("Queued Check-Ins", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-	centered := container.NewHBox(layout.NewSpacer(), pendingIconsBox, layout.NewSpacer())
-	return container.NewVBox(header, assignmentNoticeLabel, centered)
-}
-
-func loadMembers() {
-	memberHandle, err := os.Open(memberFile)
-	if err != nil {
-		members = nil
-		return
-	}
-	defer memberHandle.Close()
-
-	memberReader := csv.NewReader(memberHandle)
-	memberReader.FieldsPerRecord = -1
-	rows, err := memberReader.ReadAll()
-	if err != nil || len(rows) == 0 {
-		members = nil
-		return
-	}
-
-	nameIdx, idIdx := -1, -1
-	header := rows[0]
-	for i := range header {
-		key := strings.ToLower(strings.TrimSpace(header[i]))
-		if key == "student name" || key == "name" {
-			nameIdx = i
-		}
-		if key == "student number" || key == "id" || key == "student id" {
-			idIdx = i
-		}
-	}
-
-	start := 0
-	if nameIdx != -1 && idIdx != -1 {
-		start = 1
-	} else {
-		nameIdx, idIdx = 2, 3
-	}
-
-	members = members[:0]
-	for _, row := range rows[start:] {
-		if nameIdx >= len(row) || idIdx >= len(row) {
-			continue
-		}
-		name := strings.TrimSpace(row[nameIdx])
-		id := strings.TrimSpace(row[idIdx])
-		if name == "" || id == "" {
-			continue
-		}
-		members = append(members, Member{
-			Name:          name,
-			ID:            id,
-			StudentNumber: id,
-		})
-	}
-}
-
-func getNextMemberID() string { return strconv.Itoa(len(members) + 1) }
-
-func appendMember(member Member) {
-	memberHandle, err := os.OpenFile(memberFile, os.O_RDWR|os.O_CREATE, 0o644)
-	if err != nil {
-		fmt.Println("Error opening member file:", err)
-		return
-	}
-	defer memberHandle.Close()
-
-	memberReader := csv.NewReader(memberHandle)
-	rows, readErr := memberReader.ReadAll()
-	if readErr != nil && readErr != io.EOF {
-		fmt.Println("Error reading CSV:", readErr)
-		return
-	}
-
-	memberHandle.Seek(0, 0)
-	memberHandle.Truncate(0)
-
-	memberWriter := csv.NewWriter(memberHandle)
-	for _, row := range rows {
-		if err := memberWriter.Write(row); err != nil {
-			fmt.Println("Error writing row:", err)
-			return
-		}
-	}
-	newRow := []string{"", "", member.Name, member.ID}
-	if err := memberWriter.Write(newRow); err != nil {
-		fmt.Println("Error writing new member:", err)
-		return
-	}
-	memberWriter.Flush()
-	if err := memberWriter.Error(); err != nil {
-		fmt.Println("Error flushing writer:", err)
-	}
-	members = append(members, member)
-}
-
-func memberByID(id string) *Member {
-	for i := range members {
-		if members[i].ID == id {
-			return &members[i]
-		}
-	}
-	return nil
-}
-
-func initData() {
-	ensureLogDir()
-	allDevices = []Device{}
-	for i := 1; i <= 16; i++ {
-		allDevices = append(allDevices, Device{ID: i, Type: "PC", Status: "free", UserID: ""})
-	}
-	allDevices = append(allDevices, Device{ID: 17, Type: "Console", Status: "free", UserID: ""})
-	allDevices = append(allDevices, Device{ID: 18, Type: "Console", Status: "free", UserID: ""})
-
-	activeUsers = []User{}
-	if _, err := os.Stat(userDataFile); !os.IsNotExist(err) {
-		if userDataHandle, openErr := os.Open(userDataFile); openErr == nil {
-			defer userDataHandle.Close()
-			if json.NewDecoder(userDataHandle).Decode(&activeUsers) == nil {
-				for i := range activeUsers {
-					userRecord := &activeUsers[i]
-					for j := range allDevices {
-						if allDevices[j].ID == userRecord.PCID {
-							allDevices[j].Status = "occupied"
-							if allDevices[j].Type == "PC" {
-								allDevices[j].UserID = userRecord.ID
-							}
-							break
-						}
-					}
-				}
-			} else {
-				activeUsers = []User{}
-			}
-		}
-	}
-	loadMembers()
-	loadQueuedEntries()
-}
-
-func saveData() {
-	ensureLogDir()
-	userDataHandle, err := os.Create(userDataFile)
-	if err != nil {
-		fmt.Println("Error creating user data file:", err)
-		return
-	}
-	defer userDataHandle.Close()
-	if err := json.NewEncoder(userDataHandle).Encode(activeUsers); err != nil {
-		fmt.Println("Error encoding user data:", err)
-	}
-}
-
-func getUserByID(id string) *User {
-	for i := range activeUsers {
-		if activeUsers[i].ID == id {
-			return &activeUsers[i]
-		}
-	}
-	return nil
-}
-
-func getDeviceByID(id int) *Device {
-	for i := range allDevices {
-		if allDevices[i].ID == id {
-			return &allDevices[i]
-		}
-	}
-	return nil
-}
-
-func activeUserIDsOnDevice(deviceID int) []string {
-	ids := []string{}
-	for _, u := range activeUsers {
-		if u.PCID == deviceID {
-			ids = append(ids, u.ID)
-		}
-	}
-	return ids
-}
-
-func registerUser(name, userID string, deviceID int) error {
-	if getUserByID(userID) != nil {
-		existing := getUserByID(userID)
-		if existing.PCID == 0 {
-			return fmt.Errorf("user ID %s (%s) is already in the queue", userID, existing.Name)
-		}
-		return fmt.Errorf("user ID %s (%s) already checked in on Device %d", userID, existing.Name, existing.PCID)
-	}
-
-	if deviceID != 0 {
-		device := getDeviceByID(deviceID)
-		if device == nil {
-			return fmt.Errorf("device ID %d does not exist", deviceID)
-		}
-		if device.Type == "PC" {
-			if device.Status != "free" {
-				return fmt.Errorf("device %d is busy (occupied by UserID: %s)", deviceID, device.UserID)
-			}
-			device.Status = "occupied"
-			device.UserID = userID
-		} else {
-			device.Status = "occupied"
-		}
-	}
-
-	newUser := User{ID: userID, Name: name, CheckInTime: time.Now(), PCID: deviceID}
-	activeUsers = append(activeUsers, newUser)
-	if deviceID == 0 {
-		ensureQueuedEntry(userID, newUser.CheckInTime)
-	}
-
-	if memberByID(userID) == nil {
-		appendMember(Member{Name: name, ID: userID})
-	}
-	saveData()
-	go recordLogEvent(true, newUser, deviceID, nil)
-	refreshTrigger <- true
-	return nil
-}
-
-func checkoutUser(userID string) error {
-	u := getUserByID(userID)
-	if u == nil {
-		return fmt.Errorf("user ID %s not found", userID)
-	}
-	idx := -1
-	for i, v := range activeUsers {
-		if v.ID == userID {
-			idx = i
-			break
-		}
-	}
-	if idx == -1 {
-		return fmt.Errorf("user %s consistency error", userID)
-	}
-	originalCheckIn := u.CheckInTime
-	devID := u.PCID
-	dev := getDeviceByID(devID)
-
-	activeUsers = append(activeUsers[:idx], activeUsers[idx+1:]...)
-
-	if dev != nil {
-		if dev.Type == "PC" {
-			dev.Status = "free"
-			dev.UserID = ""
-		} else {
-			if len(activeUserIDsOnDevice(dev.ID)) == 0 {
-				dev.Status = "free"
-			} else {
-				dev.Status = "occupied"
-			}
-		}
-	}
-
-	saveData()
-	go recordLogEvent(false, *u, devID, &originalCheckIn)
-	refreshTrigger <- true
-	return nil
-}
-
-func removeQueuedUser(userID string) error {
-	u := getUserByID(userID)
-	if u == nil {
-		return fmt.Errorf("user ID %s not found", userID)
-	}
-	if u.PCID != 0 {
-		return fmt.Errorf("user %s is assigned to device %d", userID, u.PCID)
-	}
-	idx := -1
-	for i := range activeUsers {
-		if activeUsers[i].ID == userID {
-			idx = i
-			break
-		}
-	}
-	if idx == -1 {
-		return fmt.Errorf("user %s consistency error", userID)
-	}
-	original := u.CheckInTime
-	activeUsers = append(activeUsers[:idx], activeUsers[idx+1:]...)
-	removeQueuedEntry(userID)
-	saveData()
-	go recordLogEvent(false, *u, 0, &original)
-	refreshTrigger <- true
-	return nil
-}
-
-func assignQueuedUserToDevice(userID string, deviceID int) error {
-	u := getUserByID(userID)
-	if u == nil {
-		return fmt.Errorf("user ID %s not found", userID)
-	}
-	if u.PCID != 0 {
-		return fmt.Errorf("user %s already on device %d", userID, u.PCID)
-	}
-	d := getDeviceByID(deviceID)
-	if d == nil {
-		return fmt.Errorf("device ID %d does not exist", deviceID)
-	}
-	if d.Type == "PC" && d.Status != "free" {
-		return fmt.Errorf("device %d is busy", deviceID)
-	}
-	d.Status = "occupied"
-	if d.Type == "PC" {
-		d.UserID = userID
-	}
-	original := u.CheckInTime
-	u.PCID = deviceID
-	saveData()
-
-	logFileMutex.Lock()
-	entries, err := readDailyLogEntries()
-	if err == nil {
-		for i := len(entries) - 1; i >= 0; i-- {
-			if entries[i].UserID == userID && entries[i].CheckOutTime.IsZero() &&
-				entries[i].PCID == 0 && entries[i].CheckInTime.Equal(original) {
-				entries[i].PCID = deviceID
-				break
-			}
-		}
-		_ = writeDailyLogEntries(entries)
-		currentLogEntries = entries
-		refreshDisplayedLogEntries()
-	}
-	logFileMutex.Unlock()
-
-	removeQueuedEntry(userID)
-	refreshTrigger <- true
-	return nil
-}
-
-func switchUserDevice(userID string, targetDeviceID int) error {
-	user := getUserByID(userID)
-	if user == nil {
-		return fmt.Errorf("user %s not found", userID)
-	}
-	if user.PCID == targetDeviceID {
-		return fmt.Errorf("user %s is already on device %d", userID, targetDeviceID)
-	}
-	target := getDeviceByID(targetDeviceID)
-	if target == nil {
-		return fmt.Errorf("device %d does not exist", targetDeviceID)
-	}
-	if target.Status != "free" {
-		return fmt.Errorf("device %d is not available", targetDeviceID)
-	}
-
-	originalDeviceID := user.PCID
-	original := getDeviceByID(originalDeviceID)
-
-	user.PCID = targetDeviceID
-
-	if original != nil {
-		if original.Type == "PC" {
-			original.Status = "free"
-			original.UserID = ""
-		} else {
-			if len(activeUserIDsOnDevice(original.ID)) == 0 {
-				original.Status = "free"
-			}
-		}
-	}
-
-	if target.Type == "PC" {
-		target.Status = "occupied"
-		target.UserID = user.ID
-	} else {
-		target.Status = "occupied"
-	}
-
-	saveData()
-
-	logFileMutex.Lock()
-	if entries, err := readDailyLogEntries(); err == nil {
-		for i := len(entries) - 1; i >= 0; i-- {
-			if entries[i].UserID == userID && entries[i].CheckOutTime.IsZero() {
-				entries[i].PCID = targetDeviceID
-				break
-			}
-		}
-		_ = writeDailyLogEntries(entries)
-		currentLogEntries = entries
-		refreshDisplayedLogEntries()
-	}
-	logFileMutex.Unlock()
-
-	refreshTrigger <- true
-	return nil
-}
-
-func getPendingUsers() []User {
-	out := []User{}
-	for _, u := range activeUsers {
-		if u.PCID == 0 {
-			out = append(out, u)
-		}
-	}
-	return orderedQueuedUsers(out)
-}
-
-func showConsoleCheckoutDialog(d Device) {
-	userIDs := activeUserIDsOnDevice(d.ID)
-	if len(userIDs) == 0 {
-		return
-	}
-	display := make([]string, 0, len(userIDs))
-	for _, id := range userIDs {
-		u := getUserByID(id)
-		name := "Unknown User"
-		if u != nil {
-			name = u.Name
-		}
-		display = append(display, fmt.Sprintf("%s (ID: %s)", name, id))
-	}
-	selector := widget.NewSelectEntry(display)
-	items := []*widget.FormItem{{Text: "User on " + d.Type, Widget: selector}}
-	dlg := dialog.NewForm("Checkout From "+d.Type, "Check Out", "Cancel", items, func(ok bool) {
-		if !ok {
-			return
-		}
-		choice := strings.TrimSpace(selector.Text)
-		if choice == "" {
-			return
-		}
-		var targetID string
-		for i, s := range display {
-			if s == choice {
-				targetID = userIDs[i]
-				break
-			}
-		}
-		if targetID == "" {
-			return
-		}
-		if err := checkoutUser(targetID); err != nil {
-			dialog.ShowError(err, mainWindow)
-		}
-	}, mainWindow)
-	dlg.Resize(fyne.NewSize(420, dlg.MinSize().Height))
-	dlg.Show()
-}
-
-func buildDeviceRoomContent() fyne.CanvasObject {
-	layoutWidget := NewDeviceStatusLayoutWidget()
-	layoutWidget.SetLayoutLocked(layoutLocked)
-	layoutWidget.UpdateDevices()
-	deviceLayoutWidget = layoutWidget
-
-	checkInInlineForm = buildInlineCheckInForm()
-	queueView := buildPendingQueueView()
-
-	leftPane := container.NewVBox(
-		checkInInlineForm,
-		widget.NewSeparator(),
-		queueView,
-	)
-	leftScroll := container.NewVScroll(container.NewPadded(leftPane))
-	return container.New(&twoPaneLayout{leftRatio: 0.30, leftMin: 340, leftMax: 520}, leftScroll, layoutWidget)
-}
-
-func showCheckInDialogShared(deviceID int, fixed bool) {
-	const (
-		dialogWidth             float32 = 460
-		dialogBaseHeight        float32 = 260
-		dialogResultsListHeight float32 = 110
-	)
-
-	search := widget.NewEntry()
-	search.SetPlaceHolder("Search Existing Member (Name/ID)...")
-	nameEntry := widget.NewEntry()
-	idEntry := widget.NewEntry()
-	deviceEntry := widget.NewEntry()
-
-	nameEntry.SetPlaceHolder("Full Name")
-	idEntry.SetPlaceHolder("ID")
-
-	noID := widget.NewButton("No ID?", func() {
-		idEntry.SetText("LOUNGE-" + getNextMemberID())
-	})
-	noID.Resize(fyne.NewSize(55, 25))
-
-	if fixed {
-		deviceEntry.SetText(strconv.Itoa(deviceID))
-		deviceEntry.Disable()
-	} else {
-		deviceEntry.SetPlaceHolder("Enter Device ID")
-	}
-
-	var filtered []Member
-	var results *widget.List
-	var dlg dialog.Dialog
-
-	results = widget.NewList(
-		func() int { return len(filtered) },
-		func() fyne.CanvasObject { return widget.NewLabel("") },
-		func(i widget.ListItemID, o fyne.CanvasObject) {
-			if i >= 0 && i < len(filtered) {
-				o.(*widget.Label).SetText(fmt.Sprintf("%s (%s)", filtered[i].Name, filtered[i].ID))
-			}
-		})
-
-	scroll := container.NewScroll(results)
-	scroll.SetMinSize(fyne.NewSize(dialogWidth-40, dialogResultsListHeight-10))
-	scroll.Hide()
-
-	results.OnSelected = func(i widget.ListItemID) {
-		if i >= 0 && i < len(filtered) {
-			m := filtered[i]
-			nameEntry.SetText(m.Name)
-			idEntry.SetText(m.ID)
-			search.SetText("")
-			scroll.Hide()
-			results.UnselectAll()
-			filtered = []Member{}
-			results.Refresh()
-			if dlg != nil {
-				dlg.Resize(fyne.NewSize(dialogWidth, dialogBaseHeight))
-			}
-		}
-	}
-
-	search.OnChanged = func(s string) {
-		q := strings.ToLower(strings.TrimSpace(s))
-		if q == "" {
-			filtered = []Member{}
-		} else {
-			out := []Member{}
-			for _, m := range members {
-				if strings.Contains(strings.ToLower(m.Name), q) || strings.Contains(strings.ToLower(m.ID), q) {
-					out = append(out, m)
-				}
-			}
-			filtered = out
-		}
-		results.Refresh()
-
-		if dlg != nil {
-			if len(filtered) > 0 {
-				scroll.Show()
-				dlg.Resize(fyne.NewSize(dialogWidth, dialogBaseHeight+dialogResultsListHeight))
-			} else {
-				scroll.Hide()
-				dlg.Resize(fyne.NewSize(dialogWidth, dialogBaseHeight))
-			}
-		}
-	}
-
-	userIDRow := container.NewBorder(nil, nil, nil, noID, idEntry)
-
-	form := widget.NewForm(
-		widget.NewFormItem("Name:", nameEntry),
-		widget.NewFormItem("User ID:", userIDRow),
-		widget.NewFormItem("Device ID:", deviceEntry),
-	)
-
-	onConfirm := func() {
-		uid := strings.TrimSpace(idEntry.Text)
-		name := strings.TrimSpace(nameEntry.Text)
-
-		if name == "" || uid == "" {
-			dialog.ShowError(fmt.Errorf("name and ID are required"), mainWindow)
-			return
-		}
-
-		targetDeviceID := 0
-		var err error
-		if fixed {
-			targetDeviceID = deviceID
-		} else {
-			deviceText := strings.TrimSpace(deviceEntry.Text)
-			if deviceText == "" {
-				dialog.ShowError(fmt.Errorf("device ID is required"), mainWindow)
-				return
-			}
-			targetDeviceID, err = strconv.Atoi(deviceText)
-			if err != nil {
-				dialog.ShowError(fmt.Errorf("invalid Device ID: must be a number"), mainWindow)
-				return
-			}
-		}
-
-		if err := registerUser(name, uid, targetDeviceID); err != nil {
-			dialog.ShowError(err, mainWindow)
-			return
-		}
-		if dlg != nil {
-			dlg.Hide()
-		}
-	}
-
-	content := container.NewVBox(search, scroll, form)
-
-	dlg = dialog.NewCustomConfirm("Check In User", "Check In", "Cancel", content, func(ok bool) {
-		if ok {
-			onConfirm()
-		}
-	}, mainWindow)
-	dlg.Resize(fyne.NewSize(dialogWidth, dialogBaseHeight))
-	dlg.Show()
-}
-
-func showCheckInDialog() { showCheckInDialogShared(0, false) }
-
-func showCheckOutDialog() {
-	if len(activeUsers) == 0 {
-		dialog.ShowInformation("Check Out", "No active users to check out.", mainWindow)
-		return
-	}
-
-	display := make([]string, len(activeUsers))
-	ids := make([]string, len(activeUsers))
-
-	for i, u := range activeUsers {
-		displayName := u.Name
-		if len(displayName) > 25 {
-			displayName = displayName[:22] + "..."
-		}
-		display[i] = fmt.Sprintf("%s (ID: %s, PC: %d)", displayName, u.ID, u.PCID)
-		ids[i] = u.ID
-	}
-
-	selector := widget.NewSelectEntry(display)
-	selector.SetPlaceHolder("Select User to Check Out")
-	items := []*widget.FormItem{{Text: "User:", Widget: selector}}
-
-	dlg := dialog.NewForm("Check Out User", "Check Out", "Cancel", items, func(ok bool) {
-		if !ok {
-			return
-		}
-		choice := strings.TrimSpace(selector.Text)
-		if choice == "" {
-			dialog.ShowError(fmt.Errorf("no user selected"), mainWindow)
-			return
-		}
-		var target string
-		for i, s := range display {
-			if s == choice {
-				target = ids[i]
-				break
-			}
-		}
-		if target == "" {
-			dialog.ShowError(fmt.Errorf("invalid user selection"), mainWindow)
-			return
-		}
-		if err := checkoutUser(target); err != nil {
-			dialog.ShowError(err, mainWindow)
-		}
-	}, mainWindow)
-
-	dlg.Resize(fyne.NewSize(450, dlg.MinSize().Height))
-	dlg.Show()
-}
-
-func showSwitchStationDialog() {
-	if len(activeUsers) == 0 {
-		dialog.ShowInformation("Switch Station", "No active users to move.", mainWindow)
-		return
-	}
-	freeDevices := []Device{}
-	for _, d := range allDevices {
-		if d.Status == "free" {
-			freeDevices = append(freeDevices, d)
-		}
-	}
-	if len(freeDevices) == 0 {
-		dialog.ShowInformation("Switch Station", "No available stations.", mainWindow)
-		return
-	}
-
-	userLabels := make([]string, len(activeUsers))
-	userIDs := make([]string, len(activeUsers))
-	for i, u := range activeUsers {
-		name := u.Name
-		if len(name) > 25 {
-			name = name[:22] + "..."
-		}
-		userLabels[i] = fmt.Sprintf("%s (Current: %d)", name, u.PCID)
-		userIDs[i] = u.ID
-	}
-	deviceLabels := make([]string, len(freeDevices))
-	deviceIDs := make([]int, len(freeDevices))
-	for i, d := range freeDevices {
-		deviceLabels[i] = fmt.Sprintf("%s %d", d.Type, d.ID)
-		deviceIDs[i] = d.ID
-	}
-
-	userSelector := widget.NewSelectEntry(userLabels)
-	userSelector.SetPlaceHolder("Select user")
-	deviceSelector := widget.NewSelectEntry(deviceLabels)
-	deviceSelector.SetPlaceHolder("Select available station")
-
-	items := []*widget.FormItem{
-		{Text: "User", Widget: userSelector},
-		{Text: "Target Station", Widget: deviceSelector},
-	}
-
-	dlg := dialog.NewForm("Switch Station", "Switch", "Cancel", items, func(ok bool) {
-		if !ok {
-			return
-		}
-		userChoice := strings.TrimSpace(userSelector.Text)
-		deviceChoice := strings.TrimSpace(deviceSelector.Text)
-		if userChoice == "" || deviceChoice == "" {
-			dialog.ShowError(fmt.Errorf("select a user and a station"), mainWindow)
-			return
-		}
-		var userID string
-		for i, label := range userLabels {
-			if label == userChoice {
-				userID = userIDs[i]
-				break
-			}
-		}
-		if userID == "" {
-			dialog.ShowError(fmt.Errorf("invalid user selection"), mainWindow)
-			return
-		}
-		var deviceID int
-		for i, label := range deviceLabels {
-			if label == deviceChoice {
-				deviceID = deviceIDs[i]
-				break
-			}
-		}
-		if deviceID == 0 {
-			dialog.ShowError(fmt.Errorf("invalid station selection"), mainWindow)
-			return
-		}
-		if err := switchUserDevice(userID, deviceID); err != nil {
-			dialog.ShowError(err, mainWindow)
-			return
-		}
-	}, mainWindow)
-	dlg.Resize(fyne.NewSize(460, dlg.MinSize().Height))
-	dlg.Show()
-}
-
-func main() {
-	initData()
-	_ = os.MkdirAll(imgBaseDir, 0o755)
-
-	appInstance := app.New()
-	appInstance.Settings().SetTheme(NewCatppuccinLatteTheme())
-	mainWindow = appInstance.NewWindow("Lounge Management System")
-	mainWindow.Resize(fyne.NewSize(1080, 720))
-
-	deviceStatus := buildDeviceRoomContent()
-	logView := buildLogView()
-
-	checkInButton := widget.NewButtonWithIcon("Check In", theme.ContentAddIcon(), showCheckInDialog)
-	checkOutButton := widget.NewButtonWithIcon("Check Out", theme.ContentRemoveIcon(), showCheckOutDialog)
-	switchButton := widget.NewButtonWithIcon("Switch Station", theme.ViewRefreshIcon(), showSwitchStationDialog)
-	var lockButton *widget.Button
-	lockButton = widget.NewButton("", func() {
-		layoutLocked = !layoutLocked
-		if deviceLayoutWidget != nil {
-			deviceLayoutWidget.SetLayoutLocked(layoutLocked)
-		}
-		updateLayoutLockButton(lockButton)
-	})
-	resetButton := widget.NewButtonWithIcon("Reset Layout", theme.ViewRestoreIcon(), func() {
-		if deviceLayoutWidget != nil {
-			dialog.ShowConfirm("Reset Layout", "Reset all stations to the default layout?", func(ok bool) {
-				if ok {
-					deviceLayoutWidget.ResetLayout()
-				}
-			}, mainWindow)
-		}
-	})
-	updateLayoutLockButton(lockButton)
-	toolbar := container.NewHBox(checkInButton, checkOutButton, switchButton, lockButton, resetButton, layout.NewSpacer())
-
-	totalDevicesLabel := widget.NewLabel("")
-	activeUsersLabel := widget.NewLabel("")
-
-	updateStatus := func() {
-		totalDevicesLabel.SetText(fmt.Sprintf("Total Devices: %d", len(allDevices)))
-		activeUsersLabel.SetText(fmt.Sprintf("Active Users: %d", len(activeUsers)))
-	}
-	updateStatus()
-
-	statusBar := container.NewHBox(totalDevicesLabel, widget.NewLabel(" | "), activeUsersLabel)
-
-	tabs := container.NewAppTabs(
-		container.NewTabItem("Device Status", deviceStatus),
-		container.NewTabItem("Log", logView),
-	)
-	tabs.SetTabLocation(container.TabLocationTop)
-	tabs.OnSelected = func(it *container.TabItem) {
-		logTabActive = it.Text == "Log"
-		if logTabActive {
-			updateCurrentLogEntriesCache()
-			if logList != nil {
-				refreshDisplayedLogEntries()
-				logList.Refresh()
-				logRefreshPending = false
-			}
-		}
-	}
-
-	top := container.NewVBox(toolbar, widget.NewSeparator())
-	bottom := container.NewVBox(widget.NewSeparator(), statusBar)
-	root := container.NewBorder(top, bottom, nil, nil, tabs)
-	mainWindow.SetContent(root)
-
-	go func() {
-		logTicker := time.NewTicker(5 * time.Minute)
-		liveLogTicker := time.NewTicker(1 * time.Second)
-		lastDate := time.Now().Format("2006-01-02")
-		defer logTicker.Stop()
-		defer liveLogTicker.Stop()
-
-		for {
-			select {
-			case <-logTicker.C:
-				fyne.Do(func() {
-					current := time.Now().Format("2006-01-02")
-					if current != lastDate {
-						lastDate = current
-						updateCurrentLogEntriesCache()
-						if logList != nil {
-							refreshDisplayedLogEntries()
-							logList.Refresh()
-						}
-						logRefreshPending = true
-					}
-				})
-			case <-liveLogTicker.C:
-				fyne.Do(func() {
-					if logTabActive && logList != nil {
-						refreshDisplayedLogEntries()
-						logList.Refresh()
-					}
-					updatePendingIconTimes()
-				})
-			case <-refreshTrigger:
-				fyne.Do(func() {
-					updateStatus()
-					tabs.Items[0].Content = buildDeviceRoomContent()
-					tabs.Refresh()
-					if logList != nil {
-						refreshDisplayedLogEntries()
-						logList.Refresh()
-					}
-					if mainWindow != nil && mainWindow.Content() != nil {
-						mainWindow.Content().Refresh()
-					}
-				})
-			}
-		}
-	}()
-
-	mainWindow.SetMaster()
-	mainWindow.ShowAndRun()
-}
-
-func fileExists(p string) bool {
-	_, err := os.Stat(p)
-	return err == nil
-}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// statsIncludeLounge adds lounge visitors without a device to Stats and the
+// report export.
+var statsIncludeLounge = true
+
+// newLoungeCounter is the toolbar headcount for visitors who are in the room
+// but not on a device or in the queue.
+func newLoungeCounter() fyne.CanvasObject {
+	count := widget.NewLabel(loungeCountText())
+	minus := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		store.AdjustLoungeCount(-1)
+		count.SetText(loungeCountText())
+	})
+	plus := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
+		store.AdjustLoungeCount(1)
+		count.SetText(loungeCountText())
+	})
+	writerOnly(minus, plus)
+	return container.NewHBox(minus, count, plus)
+}
+
+func loungeCountText() string { return fmt.Sprintf("Lounge: %d", store.LoungeCount) }
+
+// roomHeadcountText is the fire-marshal count for the status bar, e.g.
+// "In room: 23 (16 on devices, 2 queued, 5 lounge)", the same count the
+// evacuation list gives.
+func roomHeadcountText() string {
+	count := store.Headcount()
+	return fmt.Sprintf("In room: %d (%d on devices, %d queued, %d lounge)", count.Total(), count.OnDevices, count.Queued, count.Lounge)
+}
+
+// headcountEntryLine describes a headcount entry for the log view.
+func headcountEntryLine(entry state.LogEntry) string {
+	verb := "arrived"
+	change := entry.Change
+	if change < 0 {
+		verb = "left"
+		change = -change
+	}
+	return fmt.Sprintf("Lounge visitor %s (%d)    At: %s    In lounge: %d",
+		verb, change, formatDateTime(entry.CheckInTime), entry.Headcount)
+}
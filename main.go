@@ -0,0 +1,521 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/applog"
+	"lounge/internal/schedule"
+	"lounge/internal/state"
+)
+
+const imgBaseDir = "src"
+
+// The data files, relative to the working directory until useDataRoot moves
+// them.
+var (
+	deviceLayoutFile = "log/device_layout.json"
+	memberFile       = "membership.csv"
+	logDir           = "log"
+	settingsFile     = "log/settings.json"
+	exportSaltFile   = "log/export_salt"
+)
+
+// useDataRoot points every data file at root instead of the working
+// directory. Call it before anything is loaded.
+func useDataRoot(root string) {
+	logDir = filepath.Join(root, "log")
+	memberFile = filepath.Join(root, "membership.csv")
+	deviceLayoutFile = filepath.Join(logDir, "device_layout.json")
+	settingsFile = filepath.Join(logDir, "settings.json")
+	exportSaltFile = filepath.Join(logDir, "export_salt")
+}
+
+var (
+	store               *state.Store
+	mainWindow          fyne.Window
+	logList             *widget.List
+	logDateSelect       *widget.Select
+	logSortSelect       *widget.Select
+	refreshTrigger      = make(chan bool, 1)
+	logRefreshPending   = false
+	currentLogEntries   []state.LogEntry
+	displayedLogEntries []state.LogEntry
+	currentLogSort      = logSortMostRecent
+
+	assignmentUserID         string
+	assignmentNoticeLabel    *widget.Label
+	checkInNameEntry         *widget.Entry
+	checkInIDEntry           *widget.Entry
+	checkInSearchEntry       *widget.Entry
+	checkInResultsList       *widget.List
+	checkInPurposeSelect     *widget.Select
+	filteredMembersForInline []state.Member
+	pendingIconsBox          *fyne.Container
+	deviceLayoutWidget       *DeviceStatusLayoutWidget
+	layoutLocked             = true
+	dragIndicator            *canvas.Image
+	dragIndicatorVisible     bool
+	logTabActive             bool
+	selectedLogDate          string
+	pendingDragUserID        string
+	pendingDragActive        bool
+	pendingDragLastPos       fyne.Position
+	pendingIconWidgets       []*PendingUserIcon
+	queueCapacityLabel       *canvas.Text
+)
+
+// reportRegisterError shows err from store.Register and reports whether the
+// check-in went through.
+func reportRegisterError(err error) bool {
+	if err == nil {
+		return true
+	}
+	dialog.ShowError(err, mainWindow)
+	return false
+}
+
+// shutdown waits for in-flight log writes, then saves window state, unsaved
+// members and the daily summary and takes a backup before closing the
+// window.
+func shutdown() {
+	if err := deviceLayout.flush(); err != nil {
+		writeFailed("saving device layout", err)
+	}
+	go func() {
+		store.WaitForWrites()
+		fyne.Do(func() {
+			if err := store.FlushUnsavedMembers(); err != nil {
+				writeFailed("saving members", err)
+			}
+			size := mainWindow.Canvas().Size()
+			appSettings.WindowWidth = size.Width
+			appSettings.WindowHeight = size.Height
+			if err := saveSettings(); err != nil {
+				writeFailed("saving settings", err)
+			}
+			if err := store.WriteDailySummary(); err != nil {
+				writeFailed("writing daily summary", err)
+			}
+			store.ClearJournal()
+			runBackup("closing", func(error) { mainWindow.Close() })
+		})
+	}()
+}
+
+// offerJournalReplay asks whether to replay journaled actions that never
+// reached active_users.json, which happens when the app dies mid-write.
+func offerJournalReplay() {
+	pending, err := store.UnappliedJournal()
+	if err != nil {
+		slog.Error("reading journal", "err", err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+	lines := make([]string, 0, len(pending))
+	for i, entry := range pending {
+		if i == 10 {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(pending)-i))
+			break
+		}
+		lines = append(lines, entry.String())
+	}
+	message := fmt.Sprintf("%d action(s) from the last session were not saved before the app stopped:\n\n%s\n\nReplay them now?",
+		len(pending), strings.Join(lines, "\n"))
+	dialog.ShowConfirm("Recover Unsaved Actions", message, func(ok bool) {
+		if ok {
+			store.ReplayJournal(pending)
+			return
+		}
+		store.DiscardJournal()
+	}, mainWindow)
+}
+
+// liveTickInterval is how often checkClock and refreshLiveDurations run.
+const liveTickInterval = 30 * time.Second
+
+// clockFlaggedShown is the flagged sessions checkClock last warned about, so
+// the live tick does not repeat the warning while the clock catches up.
+var clockFlaggedShown string
+
+// checkClock warns staff when the system clock moved back, past open
+// sessions or not. Those sessions are flagged in the log rather than given
+// negative durations; a wrong clock also files entries under the wrong day's log.
+func checkClock() {
+	report := store.CheckClock(time.Now())
+	flagged := strings.Join(report.Flagged, ", ")
+	if report.Jump == 0 && flagged == clockFlaggedShown {
+		return
+	}
+	clockFlaggedShown = flagged
+	if report.Jump == 0 && flagged == "" {
+		return
+	}
+	var parts []string
+	if report.Jump != 0 {
+		parts = append(parts, fmt.Sprintf("The system clock moved backward by %s.", state.FormatDuration(report.Jump.Abs())))
+	}
+	if len(report.Flagged) > 0 {
+		parts = append(parts, fmt.Sprintf("These sessions began after the current time and are flagged in the log: %s.", flagged))
+	}
+	parts = append(parts, fmt.Sprintf("It is now %s. If that is wrong, fix the system clock; entries are written to the log for the day the clock shows.",
+		time.Now().Format("Mon "+dateLayout()+" "+clockLayout(false)+" MST")))
+	dialog.ShowInformation("Clock Changed", strings.Join(parts, "\n\n"), mainWindow)
+}
+
+// initStore loads the lounge state and hooks its change notifications up to
+// the UI refresh loop.
+func initStore() {
+	store = state.NewStore(logDir, memberFile)
+	store.OnChange = func() { refreshTrigger <- true }
+	store.OnCheckout = queueCheckoutReceipt
+	store.OnWriteError = noteWriteError
+	store.Do = fyne.Do
+	store.OnLogChange = func(entries []state.LogEntry) {
+		sheetMirror.enqueue(entries)
+		fyne.Do(func() {
+			currentLogEntries = entries
+			todaysLogEntries = entries
+			refreshQuickStats()
+			refreshDisplayedLogEntries()
+			if logList != nil {
+				logList.Refresh()
+				logRefreshPending = false
+			} else {
+				logRefreshPending = true
+			}
+		})
+	}
+	loadSettings()
+	applySettings()
+	store.ReadOnly = readOnly
+	if !readOnly && !trainingMode {
+		moveSMTPPasswordOutOfSettings()
+	}
+	store.LoadState()
+	checkNewerDataFiles()
+}
+
+func main() {
+	force := flag.Bool("force", false, "start even if another copy seems to be running")
+	training := flag.Bool("training", false, "practice on a copy of the data in a sandbox, discarded on exit; the real files are never touched")
+	dataDir := flag.String("data-dir", os.Getenv(dataDirEnv), "folder holding log/ and membership.csv (default the working directory; env "+dataDirEnv+")")
+	config := flag.String("config", os.Getenv(configEnv), "settings file (default log/settings.json in the data folder; env "+configEnv+")")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: lounge [flags] [command]\n\nFlags:\n")
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), commandUsage)
+	}
+	flag.Parse()
+	usePaths(*dataDir, *config)
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
+	if *training {
+		if err := startTraining(); err != nil {
+			slog.Error("preparing the training sandbox", "err", err)
+			os.Exit(1)
+		}
+	}
+	if err := applog.Setup(logDir); err != nil {
+		slog.Error("opening application log", "err", err)
+	}
+	slog.Info("data files", "log", absPath(logDir), "members", absPath(memberFile),
+		"settings", absPath(settingsFile), "layout", absPath(deviceLayoutFile))
+
+	appInstance := app.New()
+	appInstance.Settings().SetTheme(NewCatppuccinLatteTheme())
+	start := func() { startLounge(appInstance) }
+	if checkDataDir(start) && acquireInstanceLock(*force, start) {
+		start()
+	}
+	appInstance.Run()
+
+	if store != nil {
+		// Nothing queued may be lost, whichever way the app was closed.
+		store.FlushWrites()
+		if err := store.FlushUnsavedMembers(); err != nil {
+			slog.Error("saving members", "err", err)
+		}
+		if err := deviceLayout.flush(); err != nil {
+			slog.Error("saving device layout", "err", err)
+		}
+	}
+	instanceLock.Release()
+	if trainingMode {
+		discardTrainingSandbox()
+	}
+	if memoryOnly {
+		discardMemoryOnlyDir()
+	}
+}
+
+// startLounge loads the state and opens the main window. It runs once the
+// instance lock is held.
+func startLounge(appInstance fyne.App) {
+	initStore()
+	_ = os.MkdirAll(imgBaseDir, 0o755)
+
+	mainWindow = appInstance.NewWindow("Lounge Management System")
+	if appSettings.WindowWidth > 0 && appSettings.WindowHeight > 0 {
+		mainWindow.Resize(fyne.NewSize(appSettings.WindowWidth, appSettings.WindowHeight))
+	} else {
+		mainWindow.Resize(fyne.NewSize(1080, 720))
+	}
+	mainWindow.SetCloseIntercept(showCloseDialog)
+
+	deviceStatus := buildDeviceRoomContent()
+	logView := buildLogView()
+	statsView := buildStatsView()
+
+	recentButton := newRecentCheckoutsButton()
+	var lockButton *widget.Button
+	lockButton = widget.NewButton("", func() {
+		setLocked := func(locked bool) {
+			layoutLocked = locked
+			if deviceLayoutWidget != nil {
+				deviceLayoutWidget.SetLayoutLocked(layoutLocked)
+			}
+			updateLayoutLockButton(lockButton)
+		}
+		if layoutLocked {
+			deviceLayout.beginEdit()
+			setLocked(false)
+			return
+		}
+		if !deviceLayout.editedSinceBegin() {
+			if err := deviceLayout.endEdit(true); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+			setLocked(true)
+			return
+		}
+		dialog.ShowCustomConfirm("Save Layout", "Save", "Discard", widget.NewLabel("Save the new station layout?"), func(keep bool) {
+			if err := deviceLayout.endEdit(keep); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+			setLocked(true)
+		}, mainWindow)
+	})
+	updateLayoutLockButton(lockButton)
+	writerOnly(lockButton)
+	toolbar := container.NewHBox(recentButton, lockButton, newToolbarButtons(), layout.NewSpacer(), newLoungeCounter(), newSelfCheckoutEntry(), newToolbarOverflow(), newStaffLockButton())
+
+	totalDevicesLabel := newStatusLabel()
+	activeUsersLabel := newStatusLabel()
+	roomLabel := newStatusLabel()
+	unsavedMembersButton := widget.NewButtonWithIcon("", theme.WarningIcon(), func() {
+		if err := flushMembers(); err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+	})
+	unsavedMembersButton.Importance = widget.DangerImportance
+	duplicateMembersButton := widget.NewButtonWithIcon("", theme.WarningIcon(), func() { requireStaffPIN("Merge Members", showDuplicateMembersDialog) })
+	duplicateMembersButton.Importance = widget.WarningImportance
+
+	updateStatus := func() {
+		refreshRecentCheckoutsButton()
+		totalDevicesLabel.SetText(fmt.Sprintf("Total Devices: %d", len(store.Devices)))
+		activeUsersLabel.SetText("Active: " + state.FormatVisitorCounts(store.ActiveVisitorCounts()))
+		roomLabel.SetText(roomHeadcountText())
+		refreshQuickStats()
+		scheduleMemberFlush()
+		// Changes wait for the scheduled flush; the button is for when it
+		// failed.
+		if len(store.MemberChanges) > 0 && memberFlushFailed {
+			unsavedMembersButton.SetText(fmt.Sprintf("%d unsaved member change(s) - retry", len(store.MemberChanges)))
+			unsavedMembersButton.Show()
+		} else {
+			unsavedMembersButton.Hide()
+		}
+		if dups := len(store.FindDuplicateMembers()); dups > 0 {
+			duplicateMembersButton.SetText(fmt.Sprintf("%d duplicate member ID(s)", dups))
+			duplicateMembersButton.Show()
+		} else {
+			duplicateMembersButton.Hide()
+		}
+	}
+	updateStatus()
+
+	statusBar := container.NewHBox(totalDevicesLabel, widget.NewLabel(" | "), activeUsersLabel, widget.NewLabel(" | "), roomLabel, layout.NewSpacer(), container.NewCenter(newEventBanner()), layout.NewSpacer(), newStartupProgress(), newDimButton(), newAlertsButton(), duplicateMembersButton, unsavedMembersButton, newWriteErrorsButton())
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Device Status", deviceStatus),
+		container.NewTabItem("Log", logView),
+		container.NewTabItem("Stats", statsView),
+	)
+	tabs.SetTabLocation(container.TabLocationTop)
+	tabs.OnSelected = func(it *container.TabItem) {
+		logTabActive = it.Text == "Log"
+		setDeviceTabActive(it.Text == "Device Status")
+		if logTabActive {
+			updateCurrentLogEntriesCache()
+			if logList != nil {
+				refreshDisplayedLogEntries()
+				logList.Refresh()
+				logRefreshPending = false
+			}
+		}
+		if it.Text == "Stats" {
+			refreshOccupancyChart()
+			refreshPurposeStats()
+		}
+	}
+
+	top := container.NewVBox(toolbar, widget.NewSeparator(), newTrainingBanner(), newMemoryOnlyBanner(), newReadOnlyBanner())
+	bottom := container.NewVBox(widget.NewSeparator(), statusBar)
+	root := container.NewBorder(top, bottom, nil, nil, newCheckInSlideOver(tabs))
+	mainWindow.SetContent(container.NewStack(root, newTrainingWatermark(), newDimScrim()))
+	applyDimming()
+	refreshWindowTitle()
+	loadInBackground()
+	checkClock()
+	if len(newerDataFiles) > 0 {
+		showNewerDataFilesDialog()
+	}
+	if readOnly {
+		readOnlySeen = store.DataModTime()
+	} else {
+		startWriterServices()
+	}
+
+	clock := &schedule.Scheduler{
+		Tick: liveTickInterval,
+		OnTick: func(time.Time) {
+			fyne.Do(func() {
+				checkClock()
+				updateStatus()
+				refreshLiveDurations()
+			})
+		},
+		OnNewDay: func(time.Time) { fyne.Do(startNewDay) },
+	}
+	go clock.Run(nil)
+
+	go func() {
+		countdownTicker := time.NewTicker(1 * time.Second)
+		queueExpiryTicker := time.NewTicker(queueExpiryInterval)
+		alertTicker := time.NewTicker(alertInterval)
+		readOnlyTicker := time.NewTicker(readOnlyPollInterval)
+		defer countdownTicker.Stop()
+		defer queueExpiryTicker.Stop()
+		defer alertTicker.Stop()
+		defer readOnlyTicker.Stop()
+
+		for {
+			select {
+			case <-countdownTicker.C:
+				fyne.Do(func() {
+					checkConsoleRotations()
+					refreshCleaningCountdowns()
+					refreshAwayBadges()
+					refreshStaffLockButton()
+					sendQuietDigest()
+				})
+			case <-queueExpiryTicker.C:
+				fyne.Do(func() {
+					instanceLock.Touch()
+					checkQueueExpiry()
+					refreshForecastStrip()
+					refreshRecentCheckoutsButton()
+					applyDimming()
+				})
+			case <-alertTicker.C:
+				fyne.Do(checkAlerts)
+			case <-readOnlyTicker.C:
+				fyne.Do(checkReadOnlyUpdates)
+			case <-refreshTrigger:
+				fyne.Do(func() {
+					updateStatus()
+					refreshWindowTitle()
+					publishDisplayState()
+					restoreDeviceMapFocus(func() {
+						tabs.Items[0].Content = buildDeviceRoomContent()
+						tabs.Refresh()
+					})
+					if logList != nil {
+						refreshDisplayedLogEntries()
+						logList.Refresh()
+					}
+					if mainWindow != nil && mainWindow.Content() != nil {
+						mainWindow.Content().Refresh()
+					}
+				})
+			}
+		}
+	}()
+
+	applyEvacuationShortcut()
+	mainWindow.SetMaster()
+	mainWindow.Show()
+}
+
+// startNewDay moves the desk onto the new date's log at midnight.
+func startNewDay() {
+	checkClock()
+	todaysLogEntries = nil
+	store.ReloadLoungeCount()
+	refreshTrigger <- true
+	runLogArchival()
+	runBackup("new day", nil)
+	updateCurrentLogEntriesCache()
+	if logList != nil {
+		refreshDisplayedLogEntries()
+		logList.Refresh()
+	}
+	logRefreshPending = true
+}
+
+// refreshLiveDurations redraws the times that grow while they are on
+// screen: session lengths in the log, queue waits and the map's labels.
+func refreshLiveDurations() {
+	if logTabActive && logList != nil {
+		refreshDisplayedLogEntries()
+		logList.Refresh()
+	}
+	if deviceTabActive {
+		updatePendingIconTimes()
+		refreshEndingSoon()
+		if deviceLayoutWidget != nil {
+			deviceLayoutWidget.Refresh()
+		}
+	}
+}
+
+// startWriterServices starts everything that writes to the data folder or
+// speaks for the lounge, which only the desk holding the lock may do.
+func startWriterServices() {
+	startOccupancySampler()
+	runLogArchival()
+	offerJournalReplay()
+	checkConsistency(true)
+	if err := configureSheetsMirror(); err != nil {
+		slog.Error("configuring Google Sheets sync", "err", err)
+	}
+	if err := configureDisplayServer(); err != nil {
+		slog.Error("starting wall display server", "err", err)
+	}
+	configureAlerts()
+	configureReceipts()
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
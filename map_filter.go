@@ -0,0 +1,102 @@
+package main
+
+import (
+	"image/color"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// mapStatus is what the legend calls a device's state.
+type mapStatus string
+
+const (
+	mapStatusFree        mapStatus = "Free"
+	mapStatusOccupied    mapStatus = "Occupied"
+	mapStatusMaintenance mapStatus = "Maintenance"
+	// mapStatusReserved is a free device that is booked now, or that
+	// someone in the queue is waiting for in particular.
+	mapStatusReserved mapStatus = "Reserved"
+)
+
+// mapLegend lists the statuses in the legend, with how the map shows each.
+var mapLegend = []struct {
+	Status mapStatus
+	Label  string
+	Image  string
+}{
+	{Status: mapStatusFree, Label: "○ Free", Image: "free.png"},
+	{Status: mapStatusOccupied, Label: "● Occupied", Image: "busy.png"},
+	{Status: mapStatusMaintenance, Label: "□ Maintenance (faded)", Image: "free.png"},
+	{Status: mapStatusReserved, Label: "◉ Reserved (booked or waited for)", Image: "free.png"},
+}
+
+// mapFilter holds the legend chips switched on. With none on, nothing is
+// dimmed. It lasts until the app closes.
+var mapFilter = make(map[mapStatus]bool)
+
+func mapStatusOf(device state.Device) mapStatus {
+	switch {
+	case device.Status == state.StatusMaintenance:
+		return mapStatusMaintenance
+	case device.Status == "occupied":
+		return mapStatusOccupied
+	case store.WaitingForDevice(device.ID) > 0:
+		return mapStatusReserved
+	}
+	if _, reserved := store.ReservationAt(device.ID, time.Now()); reserved {
+		return mapStatusReserved
+	}
+	return mapStatusFree
+}
+
+// dimmedByFilter reports whether the legend filter fades device out. Devices
+// are dimmed rather than hidden so the room keeps its shape.
+func dimmedByFilter(device state.Device) bool {
+	for _, on := range mapFilter {
+		if on {
+			return !mapFilter[mapStatusOf(device)]
+		}
+	}
+	return false
+}
+
+// dimmedColor is c faded the way a filtered-out device is.
+func dimmedColor(c color.Color) color.Color {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	nrgba.A /= 4
+	return nrgba
+}
+
+// newMapLegend is the row of chips above the map. Each shows the icon and
+// marker for its status; tapping it dims the devices in other states.
+func newMapLegend() fyne.CanvasObject {
+	row := container.NewHBox()
+	for _, entry := range mapLegend {
+		status := entry.Status
+		icon := iconCache.Get(entry.Image, deviceGlyph("PC", entry.Status == mapStatusOccupied))
+		var chip *widget.Button
+		chip = widget.NewButtonWithIcon(entry.Label, icon, func() {
+			mapFilter[status] = !mapFilter[status]
+			updateMapChip(chip, status)
+			if deviceLayoutWidget != nil {
+				deviceLayoutWidget.Refresh()
+			}
+		})
+		updateMapChip(chip, status)
+		row.Add(chip)
+	}
+	return row
+}
+
+func updateMapChip(chip *widget.Button, status mapStatus) {
+	chip.Importance = widget.LowImportance
+	if mapFilter[status] {
+		chip.Importance = widget.HighImportance
+	}
+	chip.Refresh()
+}
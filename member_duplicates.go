@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// duplicateMemberOption labels one row of a duplicate group for the picker.
+func duplicateMemberOption(i int, member state.Member) string {
+	label := fmt.Sprintf("%d. %s (%s)", i+1, member.Name, member.ID)
+	if member.Flagged {
+		label += " - flagged"
+	}
+	if note := strings.TrimSpace(member.Notes); note != "" {
+		label += " - " + truncateLabel(strings.ReplaceAll(note, "\n", " "), 40)
+	}
+	return label
+}
+
+// showDuplicateMembersDialog lets staff pick the row to keep for each member
+// ID that appears more than once, then rewrites membership.csv after backing
+// it up.
+func showDuplicateMembersDialog() {
+	groups := store.FindDuplicateMembers()
+	if len(groups) == 0 {
+		dialog.ShowInformation("Duplicate Members", "No member ID appears more than once.", mainWindow)
+		return
+	}
+	type choice struct {
+		group state.DuplicateMembers
+		radio *widget.RadioGroup
+		merge *widget.Check
+	}
+	choices := make([]choice, 0, len(groups))
+	rows := container.NewVBox()
+	for _, group := range groups {
+		options := make([]string, len(group.Members))
+		for i, member := range group.Members {
+			options[i] = duplicateMemberOption(i, member)
+		}
+		radio := widget.NewRadioGroup(options, nil)
+		radio.Required = true
+		radio.SetSelected(options[0])
+		merge := widget.NewCheck("Merge notes and flags from the other rows", nil)
+		merge.SetChecked(true)
+		choices = append(choices, choice{group: group, radio: radio, merge: merge})
+		title := widget.NewLabelWithStyle(fmt.Sprintf("ID %s - %d rows", group.Key, len(group.Members)), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		rows.Add(container.NewVBox(title, radio, merge, widget.NewSeparator()))
+	}
+	scroll := container.NewVScroll(rows)
+	scroll.SetMinSize(fyne.NewSize(520, 360))
+	intro := widget.NewLabel("Pick the row to keep for each ID. The other rows are removed from membership.csv; a backup of the file is kept.")
+	intro.Wrapping = fyne.TextWrapWord
+	content := container.NewBorder(intro, nil, nil, nil, scroll)
+	dialog.ShowCustomConfirm("Duplicate Members", "Clean up", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		keep := make(map[string]state.Member, len(choices))
+		for _, c := range choices {
+			canonical := 0
+			for i, option := range c.radio.Options {
+				if option == c.radio.Selected {
+					canonical = i
+				}
+			}
+			member := c.group.Members[canonical]
+			if c.merge.Checked {
+				member = state.MergeMembers(c.group.Members, canonical)
+			}
+			keep[c.group.Key] = member
+		}
+		backup, err := store.ResolveDuplicateMembers(keep)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		refreshTrigger <- true
+		dialog.ShowInformation("Duplicate Members", fmt.Sprintf("membership.csv cleaned up. The original was saved as %s.", backup), mainWindow)
+	}, mainWindow)
+}
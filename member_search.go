@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"lounge/internal/state"
+)
+
+const (
+	// memberSearchDelay lets a burst of keystrokes settle before searching.
+	memberSearchDelay = 150 * time.Millisecond
+	// memberSearchLimit caps the rows shown; staff keep typing to narrow.
+	memberSearchLimit = 50
+)
+
+// debouncer runs the latest function passed to call once delay has passed
+// without another call. It is used from the UI goroutine and runs fn there.
+type debouncer struct {
+	delay time.Duration
+	timer *time.Timer
+	fn    func()
+}
+
+func (d *debouncer) call(fn func()) {
+	d.fn = fn
+	if d.timer != nil {
+		d.timer.Reset(d.delay)
+		return
+	}
+	d.timer = time.AfterFunc(d.delay, func() {
+		fyne.Do(func() { d.fn() })
+	})
+}
+
+// memberSearchFooter is the last row of a capped result list, or "" when
+// every match is shown.
+func memberSearchFooter(shown, total int) string {
+	if total <= shown {
+		return ""
+	}
+	return fmt.Sprintf("… and %d more, keep typing", total-shown)
+}
+
+// allowanceText is the time a member has left today on each capped device
+// type, for their search result row, e.g. " - Console 40m left"; "" when no
+// cap is set.
+func allowanceText(usage state.DailyUsage, memberID string) string {
+	var parts []string
+	for _, deviceType := range []string{"PC", "Console"} {
+		if left, capped := store.CapRemaining(usage, memberID, deviceType); capped {
+			parts = append(parts, fmt.Sprintf("%s %s left", deviceType, state.FormatMinutes(left)))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " - " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+var (
+	noteBannerColor    = color.NRGBA{R: 252, G: 243, B: 207, A: 255}
+	flaggedBannerColor = color.NRGBA{R: 250, G: 215, B: 222, A: 255}
+)
+
+// memberFlushDelay is how long member changes wait in the journal before
+// membership.csv is rewritten, so a rush of new members costs one rewrite.
+const memberFlushDelay = 15 * time.Second
+
+var (
+	memberFlushTimer *time.Timer
+	// memberFlushFailed is set while the last flush failed, e.g. because
+	// Excel holds membership.csv; the status bar then offers a retry.
+	memberFlushFailed bool
+)
+
+// scheduleMemberFlush flushes the member changes memberFlushDelay after the
+// first one still unsaved. It runs with every status update.
+func scheduleMemberFlush() {
+	if readOnly || memberFlushTimer != nil || len(store.MemberChanges) == 0 {
+		return
+	}
+	memberFlushTimer = time.AfterFunc(memberFlushDelay, func() {
+		fyne.Do(func() {
+			if err := flushMembers(); err != nil {
+				slog.Error("saving members", "err", err)
+			}
+		})
+	})
+}
+
+// flushMembers writes the member changes to membership.csv now.
+func flushMembers() error {
+	if memberFlushTimer != nil {
+		memberFlushTimer.Stop()
+		memberFlushTimer = nil
+	}
+	err := store.FlushUnsavedMembers()
+	if err != nil && !memberFlushFailed {
+		noteWriteError(fmt.Errorf("saving members: %w", err))
+	}
+	memberFlushFailed = err != nil
+	refreshTrigger <- true
+	return err
+}
+
+// unsavedMembersText is the Members dialog's note on changes not yet in
+// membership.csv.
+func unsavedMembersText() string {
+	if n := len(store.MemberChanges); n > 0 {
+		return fmt.Sprintf("%d unsaved change(s); written automatically within %d seconds.", n, int(memberFlushDelay.Seconds()))
+	}
+	return "All changes saved to membership.csv."
+}
+
+// memberNoteBanner shows a member's staff notes and any remaining cooldown in
+// the check-in forms: yellow for ordinary notes, red when the member is
+// flagged.
+type memberNoteBanner struct {
+	background *canvas.Rectangle
+	text       *widget.Label
+	container  *fyne.Container
+}
+
+func newMemberNoteBanner() *memberNoteBanner {
+	b := &memberNoteBanner{
+		background: canvas.NewRectangle(noteBannerColor),
+		text:       widget.NewLabel(""),
+	}
+	b.background.CornerRadius = 4
+	b.text.Wrapping = fyne.TextWrapWord
+	b.container = container.NewStack(b.background, container.NewPadded(b.text))
+	b.container.Hide()
+	return b
+}
+
+// SetMember shows member's notes, or hides the banner when there are none.
+func (b *memberNoteBanner) SetMember(member *state.Member) {
+	b.show(member)
+}
+
+// SetMemberID shows the notes, cooldown and guest visits for id, if any.
+func (b *memberNoteBanner) SetMemberID(id string) {
+	id = strings.TrimSpace(id)
+	b.show(store.MemberByID(id), cooldownNotice(id), guestVisitNotice(id))
+}
+
+func (b *memberNoteBanner) show(member *state.Member, notices ...string) {
+	var lines []string
+	if member != nil && strings.TrimSpace(member.Notes) != "" {
+		lines = append(lines, "Note: "+member.Notes)
+	}
+	for _, notice := range notices {
+		if notice != "" {
+			lines = append(lines, notice)
+		}
+	}
+	if len(lines) == 0 {
+		b.container.Hide()
+		return
+	}
+	b.background.FillColor = noteBannerColor
+	b.text.TextStyle = fyne.TextStyle{}
+	if member != nil && member.Flagged {
+		b.background.FillColor = flaggedBannerColor
+		b.text.TextStyle = fyne.TextStyle{Bold: true}
+	}
+	b.background.Refresh()
+	b.text.SetText(strings.Join(lines, "\n"))
+	b.container.Show()
+}
+
+// guestVisitNotice counts a guest's visit this term, e.g. "Guest visit 2 of 3
+// (Fall 2026).", or returns "" for members and when the limit is off.
+func guestVisitNotice(id string) string {
+	visits, ok := store.GuestVisitsFor(id, time.Now())
+	if !ok {
+		return ""
+	}
+	if visit := visits.Visit(); visit <= visits.Limit {
+		return fmt.Sprintf("Guest visit %d of %d (%s).", visit, visits.Limit, visits.Term.Name)
+	}
+	return fmt.Sprintf("Guest visits used up (%d of %d, %s); register them as a member.", visits.Used, visits.Limit, visits.Term.Name)
+}
+
+// cooldownNotice describes how long id must wait before checking in again,
+// or returns "" when no cooldown applies.
+func cooldownNotice(id string) string {
+	now := time.Now()
+	eligible := store.CooldownUntil(id, now)
+	if eligible.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("Cooldown: eligible again at %s (%s left) while others are queued.",
+		formatClock(eligible), state.FormatDuration(eligible.Sub(now).Round(time.Minute)))
+}
+
+func showMembersDialog() {
+	if !store.MembersLoaded {
+		whenMembersReady(showMembersDialog)
+		return
+	}
+	var filtered []state.Member
+	noLimitOnly := false
+	filter := func(q string) {
+		q = strings.ToLower(strings.TrimSpace(q))
+		filtered = filtered[:0]
+		for _, m := range store.Members {
+			if noLimitOnly && !m.NoSessionLimit {
+				continue
+			}
+			if q == "" || strings.Contains(strings.ToLower(m.Name), q) || strings.Contains(strings.ToLower(m.ID), q) {
+				filtered = append(filtered, m)
+			}
+		}
+	}
+	filter("")
+
+	unsavedLabel := widget.NewLabel(unsavedMembersText())
+	flushButton := widget.NewButton("Flush", func() {
+		if err := flushMembers(); err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		unsavedLabel.SetText(unsavedMembersText())
+	})
+	disableWhenReadOnly(flushButton)
+
+	search := widget.NewEntry()
+	search.SetPlaceHolder("Search Member (Name or ID)")
+	list := widget.NewList(
+		func() int { return len(filtered) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < 0 || i >= len(filtered) {
+				return
+			}
+			m := filtered[i]
+			text := fmt.Sprintf("%s (%s)", m.Name, m.ID)
+			if m.Flagged {
+				text += "  [flagged]"
+			} else if m.Notes != "" {
+				text += "  [note]"
+			}
+			if m.NoSessionLimit {
+				text += "  [no limit]"
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	search.OnChanged = func(q string) {
+		filter(q)
+		list.UnselectAll()
+		list.Refresh()
+	}
+	// noLimitCheck lists only the members exempt from the session limit,
+	// for auditing who has it.
+	noLimitCheck := widget.NewCheck("No session limit only", func(on bool) {
+		noLimitOnly = on
+		search.OnChanged(search.Text)
+	})
+	list.OnSelected = func(i widget.ListItemID) {
+		if i < 0 || i >= len(filtered) {
+			return
+		}
+		showEditMemberDialog(filtered[i], func() {
+			filter(search.Text)
+			list.Refresh()
+			unsavedLabel.SetText(unsavedMembersText())
+		})
+		list.UnselectAll()
+	}
+
+	scroll := container.NewVScroll(list)
+	scroll.SetMinSize(fyne.NewSize(460, 320))
+	footer := container.NewBorder(nil, nil, nil, flushButton, unsavedLabel)
+	top := container.NewBorder(nil, nil, nil, noLimitCheck, search)
+	content := container.NewBorder(top, footer, nil, nil, scroll)
+	dlg := dialog.NewCustom("Members", "Close", content, mainWindow)
+	dlg.Resize(fyne.NewSize(520, 440))
+	dlg.Show()
+}
+
+func showEditMemberDialog(member state.Member, onSaved func()) {
+	notes := widget.NewMultiLineEntry()
+	notes.SetText(member.Notes)
+	notes.SetPlaceHolder("e.g. needs the height-adjustable desk")
+	notes.SetMinRowsVisible(4)
+	flagged := widget.NewCheck("Flag (show the note in red)", nil)
+	flagged.SetChecked(member.Flagged)
+	email := widget.NewEntry()
+	email.SetText(member.Email)
+	email.SetPlaceHolder("For session receipts")
+	receipts := widget.NewCheck("Email a receipt at each checkout", nil)
+	receipts.SetChecked(member.EmailReceipts)
+	staff := widget.NewCheck("Staff account (sessions are not in stats)", nil)
+	staff.SetChecked(member.ExcludeFromStats)
+	noLimit := widget.NewCheck("No session limit (no ending-soon warning)", nil)
+	noLimit.SetChecked(member.NoSessionLimit)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", widget.NewLabel(member.Name)),
+		widget.NewFormItem("ID", widget.NewLabel(member.ID)),
+		widget.NewFormItem("Streak", widget.NewLabel(memberStreakText(member.ID))),
+		widget.NewFormItem("Notes", notes),
+		widget.NewFormItem("", flagged),
+		widget.NewFormItem("Email", email),
+		widget.NewFormItem("", receipts),
+		widget.NewFormItem("", staff),
+		widget.NewFormItem("", noLimit),
+	}
+	var dlg dialog.Dialog
+	if state.IsGuestID(member.ID) {
+		convert := widget.NewButton("Register as Member...", func() {
+			dlg.Hide()
+			showConvertGuestDialog(member.Name, member.ID, onSaved)
+		})
+		disableWhenReadOnly(convert)
+		items = append(items, widget.NewFormItem("Guest", convert))
+	}
+	dlg = dialog.NewForm("Edit Member", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		member.Notes = strings.TrimSpace(notes.Text)
+		member.Flagged = flagged.Checked
+		member.Email = strings.TrimSpace(email.Text)
+		member.EmailReceipts = receipts.Checked
+		member.ExcludeFromStats = staff.Checked
+		member.NoSessionLimit = noLimit.Checked
+		if member.EmailReceipts && member.Email == "" {
+			dialog.ShowError(fmt.Errorf("enter an email address to send %s receipts", member.Name), mainWindow)
+			return
+		}
+		if err := store.SaveMemberDetails(member); err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		if onSaved != nil {
+			onSaved()
+		}
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(460, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+// showConvertGuestDialog is the member-add form for a guest who has to
+// register: it takes their student ID, merges the guest row into the new
+// member and clears their guest visits.
+func showConvertGuestDialog(name, guestID string, onSaved func()) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(name)
+	idEntry := widget.NewEntry()
+	idEntry.SetPlaceHolder("Student ID")
+	items := []*widget.FormItem{
+		widget.NewFormItem("Guest ID", widget.NewLabel(guestID)),
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Student ID", idEntry),
+	}
+	dlg := dialog.NewForm("Register Guest as Member", "Register", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		member := state.Member{Name: nameEntry.Text, ID: idEntry.Text, StudentNumber: strings.TrimSpace(idEntry.Text)}
+		if err := store.ConvertGuest(guestID, member); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if onSaved != nil {
+			onSaved()
+		}
+		dialog.ShowInformation("Registered", fmt.Sprintf("%s is now member %s. Check them in with that ID.", nameEntry.Text, strings.TrimSpace(idEntry.Text)), mainWindow)
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(420, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+// showUnknownMemberDialog asks what to do about an ID no member has: create
+// a member for it, go back and fix a mistyped ID, or check in as a guest.
+func showUnknownMemberDialog(message string, answer func(bool), asGuest func()) {
+	label := widget.NewLabel(message)
+	label.Wrapping = fyne.TextWrapWord
+	var dlg *dialog.CustomDialog
+	fix := widget.NewButton("Fix the ID", func() {
+		dlg.Hide()
+		answer(false)
+	})
+	fix.Importance = widget.HighImportance
+	guest := widget.NewButton("Check In as Guest", func() {
+		dlg.Hide()
+		asGuest()
+	})
+	create := widget.NewButton("Create New Member", func() {
+		dlg.Hide()
+		answer(true)
+	})
+	dlg = dialog.NewCustomWithoutButtons("Unknown ID", label, mainWindow)
+	dlg.SetButtons([]fyne.CanvasObject{fix, guest, create})
+	dlg.Resize(fyne.NewSize(420, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+// showGuestLimitDialog asks about a guest past their visits, offering to
+// register them as a member instead of checking them in as a guest.
+func showGuestLimitDialog(name, guestID, message string, answer func(bool)) {
+	label := widget.NewLabel(message)
+	label.Wrapping = fyne.TextWrapWord
+	var dlg *dialog.CustomDialog
+	cancel := widget.NewButton("Cancel", func() {
+		dlg.Hide()
+		answer(false)
+	})
+	register := widget.NewButton("Register as Member...", func() {
+		dlg.Hide()
+		answer(false)
+		showConvertGuestDialog(name, guestID, nil)
+	})
+	register.Importance = widget.HighImportance
+	anyway := widget.NewButton("Check In Anyway", func() {
+		dlg.Hide()
+		answer(true)
+	})
+	dlg = dialog.NewCustomWithoutButtons("Guest Limit", label, mainWindow)
+	dlg.SetButtons([]fyne.CanvasObject{cancel, anyway, register})
+	dlg.Resize(fyne.NewSize(420, dlg.MinSize().Height))
+	dlg.Show()
+}
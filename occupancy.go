@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"lounge/internal/state"
+)
+
+func recordOccupancySample() {
+	var sample state.OccupancySample
+	fyne.DoAndWait(func() { sample = store.CurrentOccupancy(time.Now()) })
+	if err := store.AppendOccupancySample(sample); err != nil {
+		writeFailed("writing occupancy sample", err)
+		return
+	}
+	fyne.Do(refreshOccupancyChart)
+}
+
+// startOccupancySampler records a sample now and then on every 5-minute
+// boundary while the app is running, independent of check-in activity.
+func startOccupancySampler() {
+	go func() {
+		recordOccupancySample()
+		for {
+			now := time.Now()
+			next := now.Truncate(state.OccupancySampleInterval).Add(state.OccupancySampleInterval)
+			time.Sleep(next.Sub(now))
+			recordOccupancySample()
+		}
+	}()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// pinHashRounds slows down guessing a PIN from settings.json; PINs are short.
+const pinHashRounds = 100000
+
+var (
+	padlockClosedIcon = theme.NewThemedResource(fyne.NewStaticResource("padlock_closed.svg", []byte(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><path d="M18 8h-1V6A5 5 0 0 0 7 6v2H6a2 2 0 0 0-2 2v10a2 2 0 0 0 2 2h12a2 2 0 0 0 2-2V10a2 2 0 0 0-2-2zM9 6a3 3 0 0 1 6 0v2H9zm3 11a2 2 0 1 1 0-4 2 2 0 0 1 0 4z"/></svg>`)))
+	padlockOpenIcon = theme.NewThemedResource(fyne.NewStaticResource("padlock_open.svg", []byte(
+		`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><path d="M18 8H9V6a3 3 0 0 1 5.8-1.1l1.9-.7A5 5 0 0 0 7 6v2H6a2 2 0 0 0-2 2v10a2 2 0 0 0 2 2h12a2 2 0 0 0 2-2V10a2 2 0 0 0-2-2zm-6 9a2 2 0 1 1 0-4 2 2 0 0 1 0 4z"/></svg>`)))
+)
+
+// staffUnlockedUntil is when a correct PIN stops covering destructive
+// actions. It lives in memory only, so a restart locks the desk again.
+var staffUnlockedUntil time.Time
+
+var staffLockButton *widget.Button
+
+func staffLockEnabled() bool {
+	return appSettings.LockDestructiveActions && appSettings.StaffPINHash != ""
+}
+
+func staffUnlocked() bool {
+	return !staffLockEnabled() || time.Now().Before(staffUnlockedUntil)
+}
+
+// hashPIN returns "rounds$salt$hash" for storing in settings.
+func hashPIN(pin string) string {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	return fmt.Sprintf("%d$%s$%s", pinHashRounds, hex.EncodeToString(salt), hex.EncodeToString(stretchPIN(pin, salt, pinHashRounds)))
+}
+
+func stretchPIN(pin string, salt []byte, rounds int) []byte {
+	sum := sha256.Sum256(append(append([]byte{}, salt...), pin...))
+	for range rounds - 1 {
+		sum = sha256.Sum256(append(sum[:], salt...))
+	}
+	return sum[:]
+}
+
+func checkPIN(pin, stored string) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 3 {
+		return false
+	}
+	rounds, err := strconv.Atoi(parts[0])
+	if err != nil || rounds < 1 {
+		return false
+	}
+	salt, err1 := hex.DecodeString(parts[1])
+	want, err2 := hex.DecodeString(parts[2])
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(stretchPIN(pin, salt, rounds), want) == 1
+}
+
+// requireStaffPIN runs onOK straight away while the desk is unlocked, and
+// otherwise only after the staff PIN is entered. A correct PIN keeps the desk
+// unlocked for PINCacheMinutes.
+func requireStaffPIN(action string, onOK func()) {
+	if staffUnlocked() {
+		onOK()
+		return
+	}
+	pinEntry := widget.NewPasswordEntry()
+	pinEntry.SetPlaceHolder("Staff PIN")
+	items := []*widget.FormItem{widget.NewFormItem("PIN", pinEntry)}
+	var dlg *dialog.FormDialog
+	submit := func(ok bool) {
+		if !ok {
+			return
+		}
+		if !checkPIN(pinEntry.Text, appSettings.StaffPINHash) {
+			dialog.ShowError(fmt.Errorf("wrong PIN"), mainWindow)
+			return
+		}
+		staffUnlockedUntil = time.Now().Add(time.Duration(appSettings.PINCacheMinutes) * time.Minute)
+		refreshStaffLockButton()
+		onOK()
+	}
+	dlg = dialog.NewForm(action+" - Staff Only", "Unlock", "Cancel", items, submit, mainWindow)
+	pinEntry.OnSubmitted = func(string) { dlg.Submit() }
+	dlg.Resize(fyne.NewSize(360, dlg.MinSize().Height))
+	dlg.Show()
+	mainWindow.Canvas().Focus(pinEntry)
+}
+
+// newStaffLockButton is the toolbar padlock. It shows whether destructive
+// actions need the PIN; tapping it locks an unlocked desk straight away, or
+// asks for the PIN to unlock it.
+func newStaffLockButton() *widget.Button {
+	staffLockButton = widget.NewButtonWithIcon("", padlockClosedIcon, func() {
+		if time.Now().Before(staffUnlockedUntil) {
+			staffUnlockedUntil = time.Time{}
+			refreshStaffLockButton()
+			return
+		}
+		requireStaffPIN("Unlock", func() {})
+	})
+	refreshStaffLockButton()
+	return staffLockButton
+}
+
+// refreshStaffLockButton also relocks the padlock once the cached PIN runs
+// out; it runs on the one-second tick.
+func refreshStaffLockButton() {
+	if staffLockButton == nil {
+		return
+	}
+	if !staffLockEnabled() {
+		staffLockButton.Hide()
+		return
+	}
+	staffLockButton.Show()
+	icon := padlockClosedIcon
+	if staffUnlocked() {
+		icon = padlockOpenIcon
+	}
+	if staffLockButton.Icon != icon {
+		staffLockButton.SetIcon(icon)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// showPreregistrationDialog loads an event's sign-up list, exports its
+// no-shows or clears it.
+func showPreregistrationDialog() {
+	prereg, loaded := store.Preregistration()
+	eventEntry := widget.NewEntry()
+	eventEntry.SetPlaceHolder("Smash Weekly")
+	eventEntry.SetText(store.Event)
+	status := widget.NewLabel("No list loaded. The CSV needs name and id columns, and may have a seat column with a device ID.")
+	status.Wrapping = fyne.TextWrapWord
+	if loaded {
+		eventEntry.SetText(prereg.Event)
+		arrived, expected := prereg.Counts()
+		status.SetText(fmt.Sprintf("%s: %d of %d arrived.", prereg.Event, arrived, expected))
+	}
+	var dlg dialog.Dialog
+	load := widget.NewButton("Load CSV...", func() {
+		event := strings.TrimSpace(eventEntry.Text)
+		if event == "" {
+			dialog.ShowError(fmt.Errorf("event name is required"), mainWindow)
+			return
+		}
+		dlg.Hide()
+		loadPreregistrationCSV(event)
+	})
+	writerOnly(load)
+	noShows := widget.NewButton("Export No-Shows...", exportPreregNoShows)
+	clearButton := widget.NewButton("Clear List", func() {
+		if err := store.ClearPreregistration(); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		dlg.Hide()
+	})
+	writerOnly(clearButton)
+	if !loaded {
+		noShows.Disable()
+		clearButton.Disable()
+	}
+	form := widget.NewForm(widget.NewFormItem("Event", eventEntry))
+	content := container.NewVBox(status, form, container.NewHBox(load, noShows, clearButton))
+	dlg = dialog.NewCustom("Pre-Registration", "Close", content, mainWindow)
+	dlg.Resize(fyne.NewSize(460, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+func loadPreregistrationCSV(event string) {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		entries, rowErrors, err := state.ParsePreregistration(reader)
+		reader.Close()
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if len(entries) == 0 {
+			dialog.ShowError(fmt.Errorf("no one is on that list"), mainWindow)
+			return
+		}
+		if err := store.SetPreregistration(event, entries); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if len(rowErrors) > 0 {
+			lines := make([]string, 0, len(rowErrors))
+			for _, rowErr := range rowErrors {
+				lines = append(lines, fmt.Sprintf("Line %d: %s", rowErr.Line, rowErr.Message))
+			}
+			dialog.ShowInformation("Pre-Registration", fmt.Sprintf("Loaded %d people. These rows were skipped:\n%s",
+				len(entries), strings.Join(lines, "\n")), mainWindow)
+		}
+	}, mainWindow)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	open.Show()
+}
+
+// exportPreregNoShows saves who on the list has not arrived as CSV.
+func exportPreregNoShows() {
+	prereg, ok := store.Preregistration()
+	if !ok {
+		return
+	}
+	rows := [][]string{{"name", "id", "seat"}}
+	for _, entry := range prereg.NoShows() {
+		seat := ""
+		if entry.Seat != 0 {
+			seat = strconv.Itoa(entry.Seat)
+		}
+		rows = append(rows, []string{entry.Name, entry.ID, seat})
+	}
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+		w := csv.NewWriter(writer)
+		if err := w.WriteAll(rows); err != nil {
+			dialog.ShowError(fmt.Errorf("write no-shows: %w", err), mainWindow)
+		}
+	}, mainWindow)
+	save.SetFileName(fmt.Sprintf("lounge-no-shows-%s.csv", strings.Join(strings.Fields(prereg.Event), "-")))
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	save.Show()
+}
+
+// offerPreregNoShows offers the no-shows when event, which just ended, had
+// a sign-up list.
+func offerPreregNoShows(event string) {
+	prereg, ok := store.Preregistration()
+	if !ok || prereg.Event != event || len(prereg.NoShows()) == 0 {
+		return
+	}
+	arrived, expected := prereg.Counts()
+	dialog.ShowConfirm("Pre-Registration", fmt.Sprintf("%d of %d pre-registered people arrived. Export the no-shows?", arrived, expected),
+		func(ok bool) {
+			if ok {
+				exportPreregNoShows()
+			}
+		}, mainWindow)
+}
+
+// newPreregPanel is the Pre-registered list on the Device Status tab: one
+// button per person, which checks them in with a tap. Arrivals are greyed
+// out. It is empty while no list is loaded.
+func newPreregPanel() fyne.CanvasObject {
+	prereg, ok := store.Preregistration()
+	if !ok {
+		return container.NewVBox()
+	}
+	arrived, expected := prereg.Counts()
+	header := widget.NewLabelWithStyle(fmt.Sprintf("Pre-registered: %s (%d/%d arrived)", prereg.Event, arrived, expected),
+		fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	list := container.NewVBox()
+	for i, entry := range prereg.Entries {
+		text := entry.Name
+		if entry.Seat != 0 {
+			text += " · " + deviceNameByID(entry.Seat)
+		}
+		button := widget.NewButton(text, func() { checkInPrereg(i) })
+		button.Alignment = widget.ButtonAlignLeading
+		if !entry.Arrived.IsZero() {
+			button.SetText("✓ " + text)
+			button.Disable()
+		} else {
+			writerOnly(button)
+		}
+		list.Add(button)
+	}
+	return container.NewVBox(header, list, widget.NewSeparator())
+}
+
+// checkInPrereg checks entry i in: to their seat if they have one and it is
+// free, otherwise into the queue. Someone without an ID gets a guest ID.
+func checkInPrereg(i int) {
+	if !store.MembersLoaded {
+		whenMembersReady(func() { checkInPrereg(i) })
+		return
+	}
+	prereg, ok := store.Preregistration()
+	if !ok || i >= len(prereg.Entries) {
+		return
+	}
+	entry := prereg.Entries[i]
+	if entry.ID == "" {
+		entry.ID = state.GeneratedIDPrefix + store.NextMemberID()
+		store.SetPreregID(i, entry.ID)
+	}
+	deviceID := 0
+	if device := store.DeviceByID(entry.Seat); device != nil && device.Status == "free" {
+		deviceID = device.ID
+	}
+	opts := state.RegisterOptions{Source: state.SourcePrereg}
+	registerUserWithChecks(entry.Name, entry.ID, deviceID, opts, false, nil)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"image/color"
+	"strings"
+
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+var defaultPurposeOptions = []string{"Gaming", "Studying", "Club event"}
+
+var purposeTagColors = []color.Color{lattePrimary, latteGreen, latteSecondary, latteAccent, latteRed}
+
+func purposeOptions() []string {
+	if len(appSettings.PurposeOptions) > 0 {
+		return appSettings.PurposeOptions
+	}
+	return defaultPurposeOptions
+}
+
+// newPurposeSelect builds the optional Purpose picker, preselecting the last
+// purpose staff used if it is still an option.
+func newPurposeSelect() *widget.Select {
+	sel := widget.NewSelect(purposeOptions(), nil)
+	sel.PlaceHolder = "(optional)"
+	for _, option := range sel.Options {
+		if option == appSettings.LastPurpose {
+			sel.SetSelected(option)
+			break
+		}
+	}
+	return sel
+}
+
+// rememberPurpose makes purpose the default for the next check-in.
+func rememberPurpose(purpose string) {
+	if purpose == "" || purpose == appSettings.LastPurpose {
+		return
+	}
+	appSettings.LastPurpose = purpose
+	if err := saveSettings(); err != nil {
+		writeFailed("saving settings", err)
+	}
+}
+
+// purposeTagColor picks a stable color for purpose from its position in the
+// configured options, so the same purpose keeps its color across the log.
+func purposeTagColor(purpose string) color.Color {
+	for i, option := range purposeOptions() {
+		if option == purpose {
+			return purposeTagColors[i%len(purposeTagColors)]
+		}
+	}
+	return latteSubtext1
+}
+
+func parsePurposeOptions(text string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, option := range strings.Split(text, ",") {
+		option = strings.TrimSpace(option)
+		if option == "" || strings.EqualFold(option, state.UnspecifiedPurpose) || seen[option] {
+			continue
+		}
+		seen[option] = true
+		out = append(out, option)
+	}
+	return out
+}
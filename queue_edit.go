@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// showEditQueuedDialog fixes a typo in a queued user's name or ID without
+// costing them their place in the queue.
+func showEditQueuedDialog(u state.User) {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(u.Name)
+	idEntry := widget.NewEntry()
+	idEntry.SetText(u.ID)
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("ID", idEntry),
+	}
+	dlg := dialog.NewForm("Edit Queued User", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		name := strings.TrimSpace(nameEntry.Text)
+		uid := strings.TrimSpace(idEntry.Text)
+		if name == "" || uid == "" {
+			dialog.ShowError(fmt.Errorf("name and ID are required"), mainWindow)
+			return
+		}
+		if name == u.Name && uid == u.ID {
+			return
+		}
+		requireStaffPIN("Edit Queued User", func() {
+			if err := store.EditQueued(u.ID, name, uid); err != nil {
+				if errors.Is(err, state.ErrUserNotQueued) || errors.Is(err, state.ErrUserNotFound) {
+					// Seated or removed while the form was open; show where they are now.
+					refreshTrigger <- true
+				}
+				dialog.ShowError(err, mainWindow)
+			}
+		})
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(360, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+// defaultRemovalReasons are offered when Settings.RemovalReasons is empty. A
+// trailing nonVisitMark marks a reason that is not a visit.
+var defaultRemovalReasons = []string{"Left", "No-show*", "Duplicate*", "Other"}
+
+const nonVisitMark = "*"
+
+// removalReasons returns the configured reasons for taking someone out of
+// the queue, with the ones that are not visits marked.
+func removalReasons() []state.QueueRemoval {
+	names := appSettings.RemovalReasons
+	if len(names) == 0 {
+		names = defaultRemovalReasons
+	}
+	reasons := make([]state.QueueRemoval, 0, len(names))
+	for _, name := range names {
+		reason := strings.TrimSpace(strings.TrimSuffix(name, nonVisitMark))
+		reasons = append(reasons, state.QueueRemoval{Reason: reason, NotAVisit: strings.HasSuffix(name, nonVisitMark)})
+	}
+	return reasons
+}
+
+// showRemoveQueuedDialog asks why u is leaving the queue, with optional
+// details, and records it on their log entry.
+func showRemoveQueuedDialog(u state.User) {
+	reasons := removalReasons()
+	options := make([]string, len(reasons))
+	for i, reason := range reasons {
+		options[i] = reason.Reason
+		if reason.NotAVisit {
+			options[i] += " (not a visit)"
+		}
+	}
+	reasonSelect := widget.NewSelect(options, nil)
+	reasonSelect.PlaceHolder = "(no reason)"
+	detailsEntry := widget.NewEntry()
+	detailsEntry.SetPlaceHolder("Optional")
+	items := []*widget.FormItem{
+		widget.NewFormItem("Reason", reasonSelect),
+		widget.NewFormItem("Details", detailsEntry),
+	}
+	dlg := dialog.NewForm("Remove "+u.Name, "Remove", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		var removal state.QueueRemoval
+		if i := reasonSelect.SelectedIndex(); i >= 0 {
+			removal = reasons[i]
+		}
+		if details := strings.TrimSpace(detailsEntry.Text); details != "" {
+			removal.Reason = strings.TrimPrefix(removal.Reason+": "+details, ": ")
+		}
+		if err := store.RemoveQueuedFor(u.ID, removal); err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(360, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+func parseRemovalReasons(text string) []string {
+	var out []string
+	for _, reason := range strings.Split(text, ",") {
+		if reason = strings.TrimSpace(reason); strings.TrimSuffix(reason, nonVisitMark) != "" {
+			out = append(out, reason)
+		}
+	}
+	return out
+}
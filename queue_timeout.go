@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/dialog"
+
+	"lounge/internal/state"
+)
+
+// queueExpiryInterval is how often the queue is checked for users who have
+// waited past the timeout and its grace period.
+const queueExpiryInterval = 30 * time.Second
+
+// checkQueueExpiry removes queued users whose timeout ran out and tells the
+// desk with a system notification and a dialog, since the queue may be out of
+// sight when it happens.
+func checkQueueExpiry() {
+	expired := store.ExpireQueued(time.Now())
+	if len(expired) == 0 {
+		return
+	}
+	names := make([]string, 0, len(expired))
+	for _, u := range expired {
+		names = append(names, u.Name)
+	}
+	message := fmt.Sprintf("Removed from the queue after %s: %s", state.FormatDuration(store.QueueTimeout), strings.Join(names, ", "))
+	notify("queue-timeout", "Queue timeout", message)
+	dialog.ShowInformation("Queue Timeout", message+".", mainWindow)
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+type PendingUserIcon struct {
+	widget.BaseWidget
+	user     state.User
+	resource fyne.Resource
+	onAssign func(state.User)
+	label    string
+	subLabel string
+	// position is the user's place in the queue, shown in a badge; 1 is next.
+	position int
+	// target badges what the user waits for, e.g. "PC 7"; empty for any.
+	target string
+	// stale greys the icon once the user has outlived the queue timeout.
+	stale bool
+}
+
+func newPendingUserIcon(u state.User, res fyne.Resource, onAssign func(state.User)) *PendingUserIcon {
+	w := &PendingUserIcon{user: u, resource: res, onAssign: onAssign}
+	w.ExtendBaseWidget(w)
+	return w
+}
+
+func (w *PendingUserIcon) SetLabel(text string) {
+	w.label = text
+	w.Refresh()
+}
+
+func (w *PendingUserIcon) SetLabels(primary, secondary string) {
+	w.label = primary
+	w.subLabel = secondary
+	w.Refresh()
+}
+
+func (w *PendingUserIcon) SetPosition(position int) {
+	if w.position == position {
+		return
+	}
+	w.position = position
+	w.Refresh()
+}
+
+func (w *PendingUserIcon) SetTarget(target string) {
+	if w.target == target {
+		return
+	}
+	w.target = target
+	w.Refresh()
+}
+
+func (w *PendingUserIcon) SetStale(stale bool) {
+	if w.stale == stale {
+		return
+	}
+	w.stale = stale
+	w.Refresh()
+}
+
+func truncateLabel(text string, maxChars int) string {
+	text = strings.TrimSpace(text)
+	if len([]rune(text)) <= maxChars {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxChars-1]) + "…"
+}
+
+type pendingUserIconRenderer struct {
+	widget  *PendingUserIcon
+	image   *canvas.Image
+	label   *canvas.Text
+	sub     *canvas.Text
+	badge   *canvas.Circle
+	number  *canvas.Text
+	tag     *canvas.Rectangle
+	target  *canvas.Text
+	objects []fyne.CanvasObject
+}
+
+const pendingBadgeSize float32 = 22
+
+func (w *PendingUserIcon) CreateRenderer() fyne.WidgetRenderer {
+	img := canvas.NewImageFromResource(w.resource)
+	img.FillMode = canvas.ImageFillContain
+	const s float32 = 72
+	img.SetMinSize(fyne.NewSize(s, s))
+	img.Resize(fyne.NewSize(s, s))
+	scale := mapScale()
+	label := canvas.NewText(w.label, primaryTextColor())
+	label.Alignment = fyne.TextAlignCenter
+	label.TextSize = 12 * scale
+	sub := canvas.NewText(w.subLabel, secondaryTextColor())
+	sub.Alignment = fyne.TextAlignCenter
+	sub.TextSize = 11 * scale
+	labels := container.NewVBox(
+		container.NewCenter(label),
+		layout.NewSpacer(),
+		container.NewCenter(sub),
+	)
+	card := container.NewVBox(
+		container.NewCenter(img),
+		layout.NewSpacer(),
+		labels,
+		layout.NewSpacer(),
+	)
+	badge := canvas.NewCircle(lattePrimary)
+	number := canvas.NewText("", color.White)
+	number.Alignment = fyne.TextAlignCenter
+	number.TextSize = 11 * scale
+	number.TextStyle = fyne.TextStyle{Bold: true}
+	tag := canvas.NewRectangle(latteAccent)
+	tag.CornerRadius = 4
+	target := canvas.NewText("", color.White)
+	target.TextSize = 10 * scale
+	target.TextStyle = fyne.TextStyle{Bold: true}
+	r := &pendingUserIconRenderer{widget: w, image: img, label: label, sub: sub, badge: badge, number: number, tag: tag, target: target,
+		objects: []fyne.CanvasObject{card, badge, number, tag, target}}
+	r.Refresh()
+	return r
+}
+
+func (r *pendingUserIconRenderer) Layout(size fyne.Size) {
+	r.objects[0].Resize(size)
+	r.badge.Resize(fyne.NewSquareSize(pendingBadgeSize))
+	r.badge.Move(fyne.NewPos(2, 2))
+	r.number.Resize(fyne.NewSquareSize(pendingBadgeSize))
+	r.number.Move(fyne.NewPos(2, 2))
+	text := r.target.MinSize()
+	tagSize := fyne.NewSize(text.Width+8, text.Height+2)
+	r.tag.Resize(tagSize)
+	r.tag.Move(fyne.NewPos(size.Width-tagSize.Width-2, 2))
+	r.target.Move(fyne.NewPos(size.Width-tagSize.Width+2, 3))
+	r.target.Resize(text)
+}
+
+func (r *pendingUserIconRenderer) MinSize() fyne.Size {
+	scale := mapScale()
+	return fyne.NewSize(90*scale, 116*scale)
+}
+
+func (r *pendingUserIconRenderer) Refresh() {
+	r.image.Resource = r.widget.resource
+	r.image.Translucency = 0
+	r.label.Color = primaryTextColor()
+	r.sub.Color = secondaryTextColor()
+	if r.widget.stale {
+		r.image.Translucency = 0.6
+		r.label.Color = readableColor(latteSubtext1)
+	}
+	r.image.Refresh()
+	r.label.Text = r.widget.label
+	r.label.Refresh()
+	r.sub.Text = r.widget.subLabel
+	r.sub.Refresh()
+	r.badge.Hidden = r.widget.position <= 0
+	r.number.Hidden = r.badge.Hidden
+	r.number.Text = strconv.Itoa(r.widget.position)
+	r.number.Refresh()
+	r.badge.Refresh()
+	r.tag.Hidden = r.widget.target == ""
+	r.target.Hidden = r.tag.Hidden
+	r.target.Text = r.widget.target
+	r.target.Refresh()
+	r.Layout(r.widget.Size())
+}
+
+func (r *pendingUserIconRenderer) Objects() []fyne.CanvasObject { return r.objects }
+func (r *pendingUserIconRenderer) Destroy()                     {}
+
+func (w *PendingUserIcon) Tapped(_ *fyne.PointEvent) {
+	if mainWindow == nil {
+		return
+	}
+	info := widget.NewLabel("Choose what to do with this queued user.")
+	var dlg dialog.Dialog
+	assignBtn := widget.NewButton("Assign", func() {
+		if w.onAssign != nil {
+			w.onAssign(w.user)
+		}
+		dlg.Hide()
+	})
+	removeBtn := widget.NewButton("Remove", func() {
+		dlg.Hide()
+		requireStaffPIN("Remove from Queue", func() { showRemoveQueuedDialog(w.user) })
+	})
+	editBtn := widget.NewButton("Edit", func() {
+		dlg.Hide()
+		showEditQueuedDialog(w.user)
+	})
+	stillHereBtn := widget.NewButton("Still here", func() {
+		if err := store.RefreshQueued(w.user.ID); err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		dlg.Hide()
+	})
+	disableWhenReadOnly(assignBtn, removeBtn, editBtn, stillHereBtn)
+	if store.QueueTimeout <= 0 {
+		stillHereBtn.Hide()
+	} else if store.QueueStale(w.user.ID, time.Now()) {
+		info.SetText("This user has waited past the queue timeout and will be removed soon unless they are still here.")
+	}
+	closeBtn := widget.NewButton("Close", func() { dlg.Hide() })
+	content := container.NewVBox(
+		widget.NewLabel(fmt.Sprintf("Queued: %s (%s)", w.user.Name, w.user.ID)),
+		info,
+		container.NewHBox(layout.NewSpacer(), assignBtn, editBtn, stillHereBtn, removeBtn, closeBtn),
+	)
+	box := container.NewPadded(content)
+	dlg = dialog.NewCustomWithoutButtons("Queued User", box, mainWindow)
+	dlg.Show()
+}
+
+func (w *PendingUserIcon) Dragged(ev *fyne.DragEvent) {
+	updateQueuedUserDrag(w.user.ID, ev.AbsolutePosition)
+}
+
+func (w *PendingUserIcon) DragEnd() {
+	endQueuedUserDrag()
+}
+
+// queueIcon is the picture shown for each person in the queue.
+func queueIcon() fyne.Resource {
+	return iconCache.Get("racoon.png", glyph{Letter: "Q", Color: latteAccent})
+}
+
+func queueTimeLabel(userID string) string {
+	return formatAgo(store.QueueTime(userID))
+}
+
+func refreshPendingIcons() {
+	if pendingIconsBox == nil {
+		return
+	}
+	pendingIconsBox.Objects = pendingIconsBox.Objects[:0]
+	pendingIconWidgets = pendingIconWidgets[:0]
+	iconRes := queueIcon()
+	queuedUsers := store.PendingUsers()
+	for idx, u := range queuedUsers {
+		user := u
+		label := truncateLabel(firstLastNonEmpty(user.Name), 20)
+		subLabel := queueTimeLabel(user.ID)
+		icon := newPendingUserIcon(user, iconRes, func(sel state.User) {
+			assignmentUserID = sel.ID
+			if assignmentNoticeLabel != nil {
+				assignmentNoticeLabel.SetText(fmt.Sprintf("Assignment mode: click a free device for %s (%s).", sel.Name, sel.ID))
+			}
+		})
+		icon.SetLabels(label, subLabel)
+		icon.SetPosition(idx + 1)
+		icon.SetTarget(waitingForBadge(user.WaitingFor))
+		icon.SetStale(store.QueueStale(user.ID, time.Now()))
+		pendingIconsBox.Add(icon)
+		pendingIconWidgets = append(pendingIconWidgets, icon)
+	}
+	pendingIconsBox.Refresh()
+	updateQueueCapacityLabel(len(queuedUsers))
+}
+
+func updateQueueCapacityLabel(queued int) {
+	if queueCapacityLabel == nil {
+		return
+	}
+	switch {
+	case store.QueueIsFull(queued):
+		queueCapacityLabel.Text = fmt.Sprintf("Queue full (%d/%d)", queued, appSettings.MaxQueueLength)
+		queueCapacityLabel.Color = latteRed
+	case appSettings.MaxQueueLength > 0:
+		queueCapacityLabel.Text = fmt.Sprintf("%d/%d", queued, appSettings.MaxQueueLength)
+		queueCapacityLabel.Color = latteSubtext1
+	default:
+		queueCapacityLabel.Text = ""
+	}
+	queueCapacityLabel.Refresh()
+}
+
+// registerUserWithChecks checks a user in (or queues them when deviceID is 0)
+// from a staff form. Each warning from the check-in validators, such as a
+// walk-in during an event or a full queue, is put to staff in a dialog and
+// the check-in retried with it waived. An ID that is not a member's may be
+// fixed, made a new member or swapped for a guest ID. onDone runs once the
+// attempt is over and reports whether the user was registered.
+func registerUserWithChecks(name, userID string, deviceID int, opts state.RegisterOptions, walkIn bool, onDone func(registered bool)) {
+	// The flagged-member check and the member file both need the members.
+	if !store.MembersLoaded {
+		whenMembersReady(func() { registerUserWithChecks(name, userID, deviceID, opts, walkIn, onDone) })
+		return
+	}
+	opts.WalkIn = walkIn
+	// guestID is set when staff chose to check in as a guest instead.
+	var guestID string
+	confirm := func(warning *state.ValidationWarning, answer func(bool)) {
+		message := warningMessage(warning)
+		switch warning.Validator {
+		case "guest-limit":
+			showGuestLimitDialog(name, userID, message, answer)
+		case "unknown-member":
+			showUnknownMemberDialog(message, answer, func() {
+				guestID = state.GeneratedIDPrefix + store.NextMemberID()
+				answer(false)
+			})
+		default:
+			dialog.ShowConfirm(warning.Title, message, answer, mainWindow)
+		}
+	}
+	store.RegisterConfirming(name, userID, deviceID, opts, confirm, func(err error) {
+		if guestID != "" {
+			registerUserWithChecks(name, guestID, deviceID, opts, walkIn, onDone)
+			return
+		}
+		var declined *state.ValidationWarning
+		registered := !errors.As(err, &declined) && reportRegisterError(err)
+		if onDone != nil {
+			onDone(registered)
+		}
+	})
+}
+
+// warningMessage puts a validator's warning and its question as one
+// sentence each, for a confirm dialog.
+func warningMessage(warning *state.ValidationWarning) string {
+	message := warning.Error()
+	return strings.ToUpper(message[:1]) + message[1:] + ". " + warning.Question
+}
+
+func updatePendingIconTimes() {
+	if len(pendingIconWidgets) == 0 {
+		return
+	}
+	queuedUsers := store.PendingUsers()
+	now := time.Now()
+	for idx, icon := range pendingIconWidgets {
+		if idx >= len(queuedUsers) {
+			break
+		}
+		user := queuedUsers[idx]
+		icon.SetLabels(truncateLabel(firstLastNonEmpty(user.Name), 20), queueTimeLabel(user.ID))
+		icon.SetPosition(idx + 1)
+		icon.SetStale(store.QueueStale(user.ID, now))
+	}
+	if pendingIconsBox != nil {
+		pendingIconsBox.Refresh()
+	}
+}
+
+func buildPendingQueueView() fyne.CanvasObject {
+	assignmentNoticeLabel = widget.NewLabel("")
+	queueCapacityLabel = canvas.NewText("", latteRed)
+	queueCapacityLabel.TextStyle = fyne.TextStyle{Bold: true}
+	queueCapacityLabel.TextSize = 14
+	pendingIconsBox = container.New(&verticalWrapLayout{padding: 10})
+	refreshPendingIcons()
+
+	header := widget.NewLabelWithStyle("Queued Check-Ins", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	headerRow := container.NewHBox(header, layout.NewSpacer(), container.NewCenter(queueCapacityLabel))
+	centered := container.NewHBox(layout.NewSpacer(), pendingIconsBox, layout.NewSpacer())
+	return container.NewVBox(headerRow, assignmentNoticeLabel, centered)
+}
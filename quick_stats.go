@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+var (
+	// todaysLogEntries is today's log as last read or written, so quick stats
+	// need no file read even while the log view shows another day.
+	todaysLogEntries []state.LogEntry
+	quickStatsPopup  *widget.PopUp
+	quickStatsGrid   *fyne.Container
+)
+
+// statusLabel is a status bar label that opens the quick stats when tapped.
+type statusLabel struct {
+	widget.Label
+}
+
+func newStatusLabel() *statusLabel {
+	l := &statusLabel{}
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+func (l *statusLabel) Tapped(*fyne.PointEvent) { showQuickStats(l) }
+
+func (l *statusLabel) Cursor() desktop.Cursor { return desktop.PointerCursor }
+
+// rememberTodaysLog keeps entries for quick stats when date is today.
+func rememberTodaysLog(date string, entries []state.LogEntry) {
+	if date == state.TodaysLogDate() {
+		todaysLogEntries = entries
+	}
+}
+
+// showQuickStats opens the quick stats above the tapped status bar label.
+func showQuickStats(from fyne.CanvasObject) {
+	if quickStatsPopup != nil {
+		quickStatsPopup.Hide()
+	}
+	quickStatsGrid = container.NewGridWithColumns(2)
+	fillQuickStats()
+	title := widget.NewLabelWithStyle("Today so far", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	quickStatsPopup = widget.NewPopUp(container.NewPadded(container.NewVBox(title, quickStatsGrid)), mainWindow.Canvas())
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(from)
+	quickStatsPopup.ShowAtPosition(pos.SubtractXY(0, quickStatsPopup.MinSize().Height))
+}
+
+// refreshQuickStats recomputes the quick stats if they are open; it runs on
+// every state and log change, so the numbers follow check-ins.
+func refreshQuickStats() {
+	if quickStatsPopup != nil && quickStatsPopup.Visible() {
+		fillQuickStats()
+	}
+}
+
+func fillQuickStats() {
+	stats := store.QuickStats(todaysLogEntries, time.Now())
+	average, wait, median, busiest := "-", "-", "-", "-"
+	if stats.AverageSession > 0 {
+		average = state.FormatMinutes(stats.AverageSession)
+	}
+	if stats.Queued > 0 {
+		wait = state.FormatMinutes(stats.LongestWait)
+	}
+	if stats.Waits.Seated > 0 {
+		median = fmt.Sprintf("%s (%d seated)", state.FormatMinutes(stats.Waits.Median), stats.Waits.Seated)
+	}
+	if stats.BusiestHour >= 0 {
+		from := time.Date(2000, 1, 1, stats.BusiestHour, 0, 0, 0, time.Local)
+		busiest = fmt.Sprintf("%s–%s (%d check-ins)", formatClock(from), formatClock(from.Add(time.Hour)), stats.BusiestHourCheckIns)
+	}
+	rows := [][2]string{
+		{"Check-ins", fmt.Sprintf("%d", stats.CheckIns)},
+		{"Average session", average},
+		{"In queue", fmt.Sprintf("%d", stats.Queued)},
+		{"Longest wait", wait},
+		{"Median wait to seat", median},
+		{"Busiest hour", busiest},
+	}
+	quickStatsGrid.Objects = nil
+	for _, row := range rows {
+		quickStatsGrid.Add(widget.NewLabel(row[0]))
+		quickStatsGrid.Add(widget.NewLabelWithStyle(row[1], fyne.TextAlignTrailing, fyne.TextStyle{Bold: true}))
+	}
+	quickStatsGrid.Refresh()
+}
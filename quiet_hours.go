@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+// notifyPriority decides what happens to a notification in quiet hours.
+type notifyPriority int
+
+const (
+	// notifyLow is dropped in quiet hours; it is stale by the time they end.
+	notifyLow notifyPriority = iota
+	// notifyNormal is held and sent in a digest when quiet hours end.
+	notifyNormal
+	// notifyEmergency always goes out, e.g. anything about an evacuation.
+	notifyEmergency
+)
+
+// notifyFeature is something that sends desktop notifications.
+type notifyFeature struct {
+	ID       string
+	Name     string
+	Priority notifyPriority
+}
+
+var notifyFeatures = []notifyFeature{
+	{ID: "alerts", Name: "Staff alerts", Priority: notifyNormal},
+	{ID: "queue-timeout", Name: "Queue timeouts", Priority: notifyNormal},
+	{ID: "lounge-full", Name: "Lounge full", Priority: notifyLow},
+	{ID: "console-rotation", Name: "Console rotations", Priority: notifyLow},
+}
+
+// quietDigest holds the notifications kept back during quiet hours, as
+// "15:04 Title: message" lines.
+var quietDigest []string
+
+func notifyFeatureByID(id string) (notifyFeature, bool) {
+	for _, feature := range notifyFeatures {
+		if feature.ID == id {
+			return feature, true
+		}
+	}
+	return notifyFeature{}, false
+}
+
+// quietHours reports whether now falls in the quiet hours.
+func quietHours(now time.Time) bool {
+	return inSchedule(appSettings.QuietFrom, appSettings.QuietUntil, now)
+}
+
+// quietFor reports whether feature's notifications are held back at now.
+func quietFor(id string, now time.Time) bool {
+	feature, ok := notifyFeatureByID(id)
+	return ok && feature.Priority != notifyEmergency && !slices.Contains(appSettings.QuietHoursExempt, id) && quietHours(now)
+}
+
+// notify sends a desktop notification for the feature with this ID, or in
+// quiet hours holds it for the digest or drops it, by the feature's priority.
+func notify(id, title, message string) {
+	now := time.Now()
+	if !quietFor(id, now) {
+		fyne.CurrentApp().SendNotification(fyne.NewNotification(title, message))
+		return
+	}
+	if feature, _ := notifyFeatureByID(id); feature.Priority == notifyLow {
+		slog.Info("notification dropped in quiet hours", "title", title, "message", message)
+		return
+	}
+	quietDigest = append(quietDigest, fmt.Sprintf("%s %s: %s", formatClock(now), title, message))
+}
+
+// sendQuietDigest runs on the ticker and sends what quiet hours held back as
+// one notification once they are over.
+func sendQuietDigest() {
+	if len(quietDigest) == 0 || quietHours(time.Now()) {
+		return
+	}
+	title := fmt.Sprintf("During quiet hours (%d)", len(quietDigest))
+	fyne.CurrentApp().SendNotification(fyne.NewNotification(title, strings.Join(quietDigest, "\n")))
+	quietDigest = nil
+}
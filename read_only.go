@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// readOnlyPollInterval is how often a read-only desk looks for changes made
+// by the desk holding the lock.
+const readOnlyPollInterval = 3 * time.Second
+
+// readOnly is set when staff open the app next to another desk that holds
+// the lock on the data folder. Every view works, but nothing is written; it
+// doubles as a supervisor's monitoring mode.
+var readOnly bool
+
+// newerDataFiles are the data files a newer build of the app wrote. The desk
+// stays read-only while there are any, as saving would drop what this build
+// does not know.
+var newerDataFiles []error
+
+var (
+	readOnlySeen    time.Time
+	readOnlyBanner  *fyne.Container
+	readOnlyMessage *widget.Label
+	readOnlySwitch  *widget.Button
+	// writerControls are disabled while read-only.
+	writerControls []fyne.Disableable
+)
+
+// writerOnly registers long-lived controls that change the lounge state, so
+// they are disabled while the desk is read-only and enabled on switching.
+func writerOnly(controls ...fyne.Disableable) {
+	writerControls = append(writerControls, controls...)
+	disableWhenReadOnly(controls...)
+}
+
+// disableWhenReadOnly is for controls rebuilt on every refresh or dialog.
+func disableWhenReadOnly(controls ...fyne.Disableable) {
+	if !readOnly {
+		return
+	}
+	for _, control := range controls {
+		control.Disable()
+	}
+}
+
+// newReadOnlyBanner explains read-only mode above the tabs. Fyne buttons have
+// no tooltips, so this is where staff learn why the controls are disabled.
+func newReadOnlyBanner() fyne.CanvasObject {
+	readOnlyMessage = widget.NewLabel("")
+	readOnlyMessage.Wrapping = fyne.TextWrapWord
+	readOnlySwitch = widget.NewButtonWithIcon("Switch to Writable", theme.ConfirmIcon(), switchToWritable)
+	readOnlySwitch.Importance = widget.HighImportance
+	readOnlyBanner = container.NewBorder(nil, nil, widget.NewIcon(theme.VisibilityIcon()), readOnlySwitch, readOnlyMessage)
+	refreshReadOnlyBanner()
+	return readOnlyBanner
+}
+
+// refreshReadOnlyBanner runs on the poll tick and offers to switch once the
+// other desk has let go of the lock.
+func refreshReadOnlyBanner() {
+	if readOnlyBanner == nil {
+		return
+	}
+	if !readOnly {
+		readOnlyBanner.Hide()
+		return
+	}
+	readOnlyBanner.Show()
+	if len(newerDataFiles) > 0 {
+		readOnlyMessage.SetText("Read-only: a newer version of the app wrote the data files. " +
+			"Update the app on this computer to make changes from here.")
+		readOnlySwitch.Hide()
+		return
+	}
+	var locked *state.LockedError
+	if errors.As(state.CheckInstanceLock(logDir), &locked) {
+		holder := fmt.Sprintf("PID %d", locked.PID)
+		if locked.Host != "" {
+			holder = locked.Host
+		}
+		readOnlyMessage.SetText(fmt.Sprintf("Read-only: the desk on %s is writing to this data folder. "+
+			"You can watch the lounge, but check-ins, checkouts and other changes are off.", holder))
+		readOnlySwitch.Hide()
+		return
+	}
+	readOnlyMessage.SetText("Read-only: the other desk has closed. Switch to writable to make changes from here.")
+	readOnlySwitch.Show()
+}
+
+// showReadOnlyNotice answers a tap on a disabled action, e.g. on the map.
+func showReadOnlyNotice() {
+	if len(newerDataFiles) > 0 {
+		showNewerDataFilesDialog()
+		return
+	}
+	dialog.ShowInformation("Read-Only", "This desk is read-only because another desk is writing to the data folder. "+
+		"Make changes there, or switch to writable once it closes.", mainWindow)
+}
+
+// checkReadOnlyUpdates reloads the state when the writing desk saved
+// something since the last poll.
+func checkReadOnlyUpdates() {
+	if !readOnly {
+		return
+	}
+	refreshReadOnlyBanner()
+	modified := store.DataModTime()
+	if !modified.After(readOnlySeen) {
+		return
+	}
+	readOnlySeen = modified
+	store.Load()
+	updateCurrentLogEntriesCache()
+	refreshTrigger <- true
+}
+
+// checkNewerDataFiles makes the desk read-only when a newer build wrote any
+// data file, so nothing it added is stripped by a save from here.
+func checkNewerDataFiles() {
+	newerDataFiles = store.NewerFiles()
+	if err := state.CheckFileVersion(deviceLayoutFile, state.LayoutVersion); errors.Is(err, state.ErrNewerFile) {
+		newerDataFiles = append(newerDataFiles, err)
+	}
+	if len(newerDataFiles) > 0 {
+		readOnly = true
+		store.ReadOnly = true
+	}
+}
+
+func showNewerDataFilesDialog() {
+	parts := []string{"This desk is read-only: a newer version of the lounge app has been used on this data folder, " +
+		"and saving from this version would lose what it added."}
+	for _, err := range newerDataFiles {
+		parts = append(parts, err.Error()+".")
+	}
+	parts = append(parts, "Install the latest version on this computer, then start the app again.")
+	dialog.ShowInformation("Update Needed", strings.Join(parts, "\n\n"), mainWindow)
+}
+
+// switchToWritable takes the lock once it is free and turns this desk into
+// the writer, as if it had started normally.
+func switchToWritable() {
+	lock, err := state.AcquireInstanceLock(logDir, false)
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		refreshReadOnlyBanner()
+		return
+	}
+	instanceLock = lock
+	readOnly = false
+	store.ReadOnly = false
+	store.Load()
+	updateCurrentLogEntriesCache()
+	for _, control := range writerControls {
+		control.Enable()
+	}
+	refreshReadOnlyBanner()
+	startWriterServices()
+	refreshTrigger <- true
+}
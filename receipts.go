@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/smtpmail"
+	"lounge/internal/state"
+)
+
+const (
+	receiptMaxAttempts = 5
+	receiptRetryDelay  = 30 * time.Second
+	// receiptFailuresKept bounds the failures Diagnostics lists.
+	receiptFailuresKept = 20
+)
+
+// receipt is one checkout email, rendered on the UI goroutine so the worker
+// needs nothing from the store.
+type receipt struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// receiptFailure is a receipt the worker gave up on.
+type receiptFailure struct {
+	Time time.Time
+	To   string
+	Err  error
+}
+
+// receiptMailer emails members who opted in a record of each session, for
+// co-op hour tracking. Checkout only appends to the queue; the worker sends
+// and retries, so a slow or unreachable server never holds up the desk.
+type receiptMailer struct {
+	mu       sync.Mutex
+	config   *smtpmail.Config
+	queue    []receipt
+	failures []receiptFailure
+	wake     chan struct{}
+	started  sync.Once
+}
+
+var receiptSender = &receiptMailer{wake: make(chan struct{}, 1)}
+
+func smtpConfigFromSettings() smtpmail.Config {
+	return smtpmail.Config{
+		Host:     appSettings.SMTPHost,
+		Port:     appSettings.SMTPPort,
+		From:     appSettings.SMTPFrom,
+		Username: appSettings.SMTPUsername,
+		Password: appSettings.SMTPPassword,
+	}
+}
+
+// configureReceipts (re)reads the SMTP settings. With no server set no
+// receipts are sent.
+func configureReceipts() {
+	var config *smtpmail.Config
+	if appSettings.SMTPHost != "" {
+		c := smtpConfigFromSettings()
+		config = &c
+	}
+	receiptSender.mu.Lock()
+	receiptSender.config = config
+	receiptSender.mu.Unlock()
+	if config != nil {
+		receiptSender.started.Do(func() { go receiptSender.run() })
+	}
+}
+
+func (m *receiptMailer) enabled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.config != nil
+}
+
+// enqueue adds r to the queue. It never blocks.
+func (m *receiptMailer) enqueue(r receipt) {
+	m.mu.Lock()
+	if m.config == nil {
+		m.mu.Unlock()
+		return
+	}
+	m.queue = append(m.queue, r)
+	m.mu.Unlock()
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *receiptMailer) run() {
+	for range m.wake {
+		for {
+			m.mu.Lock()
+			if len(m.queue) == 0 || m.config == nil {
+				m.mu.Unlock()
+				break
+			}
+			r := m.queue[0]
+			m.queue = m.queue[1:]
+			m.mu.Unlock()
+			if err := m.sendWithRetry(r); err != nil {
+				slog.Error("sending receipt email", "to", r.To, "err", err)
+				m.recordFailure(receiptFailure{Time: time.Now(), To: r.To, Err: err})
+			}
+		}
+	}
+}
+
+// sendWithRetry sends r with the settings current at each attempt, so a fix
+// to the settings applies to receipts already queued.
+func (m *receiptMailer) sendWithRetry(r receipt) error {
+	delay := receiptRetryDelay
+	var err error
+	for attempt := 1; attempt <= receiptMaxAttempts; attempt++ {
+		m.mu.Lock()
+		config := m.config
+		m.mu.Unlock()
+		if config == nil {
+			return fmt.Errorf("email receipts were switched off")
+		}
+		err = smtpmail.Send(*config, r.To, r.Subject, r.Body)
+		if err == nil || !smtpmail.Retryable(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return err
+}
+
+func (m *receiptMailer) recordFailure(f receiptFailure) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures = append(m.failures, f)
+	if len(m.failures) > receiptFailuresKept {
+		m.failures = m.failures[len(m.failures)-receiptFailuresKept:]
+	}
+}
+
+func (m *receiptMailer) recentFailures() []receiptFailure {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]receiptFailure(nil), m.failures...)
+}
+
+// queueCheckoutReceipt is store.OnCheckout: it queues a receipt for a member
+// who opted in and has an email on file.
+func queueCheckoutReceipt(u state.User, deviceID int, at time.Time) {
+	if !receiptSender.enabled() {
+		return
+	}
+	member := store.MemberByID(u.ID)
+	if member == nil || !member.EmailReceipts || member.Email == "" {
+		return
+	}
+	receiptSender.enqueue(checkoutReceipt(*member, u, deviceID, at))
+}
+
+func checkoutReceipt(member state.Member, u state.User, deviceID int, at time.Time) receipt {
+	device := fmt.Sprintf("Device %d", deviceID)
+	if d := store.DeviceByID(deviceID); d != nil {
+		device = d.Name()
+	}
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\n", member.Name)
+	body.WriteString("Here is your receipt for your session at the lounge.\n\n")
+	fmt.Fprintf(&body, "Name:        %s\n", member.Name)
+	fmt.Fprintf(&body, "Date:        %s\n", at.Local().Format("Mon "+dateLayout()))
+	fmt.Fprintf(&body, "Device:      %s\n", device)
+	fmt.Fprintf(&body, "Checked in:  %s\n", formatClock(u.CheckInTime))
+	fmt.Fprintf(&body, "Checked out: %s\n", formatClock(at))
+	fmt.Fprintf(&body, "Duration:    %s\n", state.FormatDuration(at.Sub(u.CheckInTime)))
+	body.WriteString("\nYou get this email because you asked the desk for session receipts. Ask staff to stop them at any time.\n")
+	return receipt{
+		To:      member.Email,
+		Subject: fmt.Sprintf("Lounge session receipt, %s", at.Local().Format(dateLayout())),
+		Body:    body.String(),
+	}
+}
+
+// receiptChecks lists receipts that could not be sent, for Diagnostics.
+func receiptChecks() []diagnosticCheck {
+	if !receiptSender.enabled() {
+		return nil
+	}
+	failures := receiptSender.recentFailures()
+	if len(failures) == 0 {
+		return []diagnosticCheck{{Name: "Receipt emails", Detail: "no failed sends", OK: true}}
+	}
+	checks := make([]diagnosticCheck, 0, len(failures))
+	for _, f := range failures {
+		checks = append(checks, diagnosticCheck{Name: "Receipt emails",
+			Detail: fmt.Sprintf("%s to %s: %v", formatDateTime(f.Time), f.To, f.Err),
+			Hint:   "Check the SMTP settings and use Send test email; check the member's email address."})
+	}
+	return checks
+}
+
+// showTestEmailDialog sends a test message to the from address with config,
+// the values in the settings form, so staff can check them before saving.
+func showTestEmailDialog(config smtpmail.Config) {
+	if err := config.Validate(); err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	progress := dialog.NewCustomWithoutButtons("Test Email", widget.NewLabel("Sending a test email to "+config.From+"..."), mainWindow)
+	progress.Show()
+	go func() {
+		err := smtpmail.Send(config, config.From, "Lounge test email",
+			"This is a test from the lounge desk. Receipt emails will be sent through this server.\n")
+		fyne.Do(func() {
+			progress.Hide()
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			dialog.ShowInformation("Test Email", "Sent. Check the inbox of "+config.From+".", mainWindow)
+		})
+	}()
+}
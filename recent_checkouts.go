@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+var recentCheckoutsButton *widget.Button
+
+// newRecentCheckoutsButton sits next to Check In and lists who was just
+// checked out, for when staff checked out the wrong person. Picking one opens
+// an ordinary check-in, so the closed log entry stays as it is and the new
+// session gets its own check-in time.
+func newRecentCheckoutsButton() *widget.Button {
+	recentCheckoutsButton = widget.NewButtonWithIcon("", theme.HistoryIcon(), nil)
+	recentCheckoutsButton.OnTapped = func() {
+		recent := store.RecentCheckouts(time.Now())
+		items := make([]*fyne.MenuItem, 0, len(recent))
+		for _, r := range recent {
+			prefill := r.User
+			prefill.PCID = r.DeviceID
+			label := fmt.Sprintf("%s (%s) - %s, %s ago", r.User.Name, r.User.ID, recentDeviceText(r.DeviceID),
+				state.FormatDuration(time.Since(r.Time)))
+			items = append(items, fyne.NewMenuItem(label, func() { showCheckInDialogFor(0, false, &prefill) }))
+		}
+		if len(items) == 0 {
+			return
+		}
+		menu := fyne.NewMenu("Recently Checked Out", items...)
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(recentCheckoutsButton)
+		widget.ShowPopUpMenuAtPosition(menu, mainWindow.Canvas(), pos.Add(fyne.NewPos(0, recentCheckoutsButton.Size().Height)))
+	}
+	refreshRecentCheckoutsButton()
+	return recentCheckoutsButton
+}
+
+func recentDeviceText(deviceID int) string {
+	if device := store.DeviceByID(deviceID); device != nil {
+		return device.Name()
+	}
+	return "queue"
+}
+
+// refreshRecentCheckoutsButton enables the button while anyone is in the
+// list; it runs whenever the lounge changes.
+func refreshRecentCheckoutsButton() {
+	if recentCheckoutsButton == nil {
+		return
+	}
+	if readOnly || len(store.RecentCheckouts(time.Now())) == 0 {
+		recentCheckoutsButton.Disable()
+	} else {
+		recentCheckoutsButton.Enable()
+	}
+}
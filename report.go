@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+
+	"lounge/internal/state"
+)
+
+// statsRangeTitle names the selected Stats range for report headers.
+func statsRangeTitle() string {
+	if term, ok := selectedStatsTerm(); ok {
+		return fmt.Sprintf("%s (%s..%s)", term.Name, term.Start, term.End)
+	}
+	return state.TodaysLogDate()
+}
+
+// reportFileName builds the default file name for a kind of report, e.g.
+// lounge-report-Fall-2024.csv for "report".
+func reportFileName(kind string) string {
+	safe := func(s string) string {
+		return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+			return r == ' ' || r == '/' || r == '\\' || r == ':'
+		}), "-")
+	}
+	name := state.TodaysLogDate()
+	if term, ok := selectedStatsTerm(); ok && selectedStatsRange == statsRangeThisWeek {
+		name = "week-" + term.Start
+	} else if ok {
+		name = safe(term.Name)
+	}
+	return fmt.Sprintf("lounge-%s-%s.csv", safe(kind), name)
+}
+
+// reportTimeZone names the local zone and its current offset, e.g.
+// "America/Toronto (EDT, UTC-04:00)", so times in the report are unambiguous.
+func reportTimeZone(now time.Time) string {
+	abbrev := now.Format("MST")
+	offset := now.Format("-07:00")
+	if name := now.Location().String(); name != "Local" && name != abbrev {
+		return fmt.Sprintf("%s (%s, UTC%s)", name, abbrev, offset)
+	}
+	return fmt.Sprintf("%s (UTC%s)", abbrev, offset)
+}
+
+// reportRows is the report for entries in the selected Stats range;
+// excluded is how many test sessions statsRangeEntries left out, which the
+// header states.
+func reportRows(entries []state.LogEntry, excluded int) [][]string {
+	return rangeReportRows(statsRangeTitle(), openDayRows(entries), entries, excluded)
+}
+
+// rangeReportRows is the report for entries from any range, titled title;
+// openDays is nil for a single day.
+func rangeReportRows(title string, openDays [][2]string, entries []state.LogEntry, excluded int) [][]string {
+	testSessions := "included"
+	if !statsIncludeTest {
+		testSessions = fmt.Sprintf("excluded (%d)", excluded)
+	}
+	rows := [][]string{
+		{"Lounge usage report"},
+		{"Range", title},
+		{"Generated", formatLongDateTime(time.Now())},
+		{"Time zone", reportTimeZone(time.Now())},
+		{"Test sessions", testSessions},
+		{},
+		{"Purpose", "Visits", "Hours"},
+	}
+	var visits int
+	var total time.Duration
+	for _, usage := range state.UsageByPurpose(entries, time.Now()) {
+		rows = append(rows, []string{usage.Purpose, fmt.Sprintf("%d", usage.Visits), fmt.Sprintf("%.2f", usage.Usage.Hours())})
+		visits += usage.Visits
+		total += usage.Usage
+	}
+	rows = append(rows, []string{"Total", fmt.Sprintf("%d", visits), fmt.Sprintf("%.2f", total.Hours())})
+	rows = append(rows, []string{}, []string{"Event", "Visits", "Hours"})
+	for _, usage := range state.UsageByEvent(entries, time.Now()) {
+		rows = append(rows, []string{usage.Purpose, fmt.Sprintf("%d", usage.Visits), fmt.Sprintf("%.2f", usage.Usage.Hours())})
+	}
+	rows = append(rows, []string{}, []string{"Source", "Visits", "Hours"})
+	for _, usage := range state.UsageBySource(entries, time.Now()) {
+		rows = append(rows, []string{usage.Purpose, fmt.Sprintf("%d", usage.Visits), fmt.Sprintf("%.2f", usage.Usage.Hours())})
+	}
+	rows = append(rows, []string{}, []string{"Visitor type", "Visits", "Hours"})
+	for _, usage := range state.UsageByVisitorType(entries, time.Now()) {
+		rows = append(rows, []string{usage.Purpose, fmt.Sprintf("%d", usage.Visits), fmt.Sprintf("%.2f", usage.Usage.Hours())})
+	}
+	if statsIncludeLounge {
+		rows = append(rows, []string{}, []string{"Lounge visitors (no device)", fmt.Sprintf("%d", state.LoungeVisits(entries))})
+	}
+	if openDays != nil {
+		rows = append(rows, []string{}, []string{"Per open day"})
+		for _, row := range openDays {
+			rows = append(rows, []string{row[0], row[1]})
+		}
+	}
+	return rows
+}
+
+// showExportReportDialog saves the Stats range's per-purpose totals as CSV.
+func showExportReportDialog() {
+	entries, excluded, err := statsRangeEntries()
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	rows := reportRows(entries, excluded)
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+		w := csv.NewWriter(writer)
+		if err := w.WriteAll(rows); err != nil {
+			dialog.ShowError(fmt.Errorf("write report: %w", err), mainWindow)
+		}
+	}, mainWindow)
+	save.SetFileName(reportFileName("report"))
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	save.Show()
+}
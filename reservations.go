@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// weekdayOptions are the repeat weekdays offered, Monday first.
+var weekdayOptions = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+func weekdayOf(option string) time.Weekday {
+	return time.Weekday((slices.Index(weekdayOptions, option) + 1) % 7)
+}
+
+func weekdayOption(day time.Weekday) string { return weekdayOptions[(int(day)+6)%7] }
+
+// parseDeviceList reads device IDs like "1-6, 9".
+func parseDeviceList(text string) ([]int, error) {
+	var ids []int
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("bad device %q", part)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || last < first {
+				return nil, fmt.Errorf("bad device range %q", part)
+			}
+		}
+		for id := first; id <= last; id++ {
+			if store.DeviceByID(id) == nil {
+				return nil, fmt.Errorf("device %d does not exist", id)
+			}
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// formatDeviceList writes ids the way parseDeviceList reads them.
+func formatDeviceList(ids []int) string {
+	ids = slices.Sorted(slices.Values(ids))
+	var parts []string
+	for i := 0; i < len(ids); {
+		j := i
+		for j+1 < len(ids) && ids[j+1] == ids[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", ids[i], ids[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(ids[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+// reservationLabel is what the map shows under a free device reserved now:
+// the reservation's label and when it ends.
+func reservationLabel(deviceID int, now time.Time) (string, bool) {
+	occurrence, ok := store.ReservationAt(deviceID, now)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s until %s", occurrence.Reservation.Label, formatClock(occurrence.End)), true
+}
+
+// showReservationsDialog lists one day's reservations, series expanded, and
+// lets staff add, change or cancel them.
+func showReservationsDialog() {
+	day := time.Now()
+	list := container.NewVBox()
+	dayLabel := widget.NewLabelWithStyle("", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	var show func()
+	show = func() {
+		date := day.Format("2006-01-02")
+		dayLabel.SetText(day.Format("Monday " + dateLayout()))
+		list.RemoveAll()
+		occurrences := store.ReservationsOn(date)
+		if len(occurrences) == 0 {
+			list.Add(widget.NewLabel("No reservations."))
+		}
+		for _, occurrence := range occurrences {
+			list.Add(reservationRow(occurrence, show))
+		}
+	}
+	step := func(days int) func() {
+		return func() {
+			day = day.AddDate(0, 0, days)
+			show()
+		}
+	}
+	add := widget.NewButton("New Reservation...", func() {
+		showReservationForm(state.Reservation{Date: day.Format("2006-01-02")}, "", show)
+	})
+	writerOnly(add)
+	nav := container.NewBorder(nil, nil, widget.NewButton("<", step(-1)), widget.NewButton(">", step(1)), dayLabel)
+	show()
+	content := container.NewBorder(container.NewVBox(nav, add), nil, nil, nil, container.NewVScroll(list))
+	dlg := dialog.NewCustom("Reservations", "Close", content, mainWindow)
+	dlg.Resize(fyne.NewSize(560, 460))
+	dlg.Show()
+}
+
+// reservationRow shows one occurrence with its edit and cancel buttons. A
+// series occurrence can be changed or cancelled alone or with its series.
+func reservationRow(occurrence state.Occurrence, changed func()) fyne.CanvasObject {
+	r := occurrence.Reservation
+	text := fmt.Sprintf("%s-%s  %s  (devices %s)", r.Start, r.End, r.Label, formatDeviceList(r.Devices))
+	if r.IsSeries() {
+		text += "  · repeats weekly"
+	}
+	cancel := func(err error) {
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		changed()
+	}
+	buttons := container.NewHBox()
+	if r.IsSeries() {
+		buttons.Add(widget.NewButton("Edit This", func() { showReservationForm(r, occurrence.Date, changed) }))
+		buttons.Add(widget.NewButton("Edit Series", func() { showReservationForm(r, "", changed) }))
+		buttons.Add(widget.NewButton("Cancel This", func() { cancel(store.CancelOccurrence(r.ID, occurrence.Date)) }))
+		buttons.Add(widget.NewButton("Cancel Series", func() {
+			dialog.ShowConfirm("Cancel Series", fmt.Sprintf("Cancel every occurrence of %s?", r.Label), func(ok bool) {
+				if ok {
+					cancel(store.CancelReservation(r.ID))
+				}
+			}, mainWindow)
+		}))
+	} else {
+		buttons.Add(widget.NewButton("Edit", func() { showReservationForm(r, "", changed) }))
+		buttons.Add(widget.NewButton("Cancel", func() { cancel(store.CancelReservation(r.ID)) }))
+	}
+	for _, button := range buttons.Objects {
+		writerOnly(button.(*widget.Button))
+	}
+	label := widget.NewLabel(text)
+	label.Wrapping = fyne.TextWrapWord
+	return container.NewBorder(nil, nil, nil, buttons, label)
+}
+
+// showReservationForm adds r when it has no ID, or changes it. With
+// occurrenceDate set only that occurrence of the series r is changed.
+func showReservationForm(r state.Reservation, occurrenceDate string, changed func()) {
+	labelEntry := widget.NewEntry()
+	labelEntry.SetText(r.Label)
+	labelEntry.SetPlaceHolder("Esports practice")
+	devicesEntry := widget.NewEntry()
+	devicesEntry.SetText(formatDeviceList(r.Devices))
+	devicesEntry.SetPlaceHolder("1-6")
+	dateEntry := widget.NewEntry()
+	dateEntry.SetText(r.Date)
+	dateEntry.SetPlaceHolder("2006-01-02")
+	startEntry := widget.NewEntry()
+	startEntry.SetText(r.Start)
+	startEntry.SetPlaceHolder("16:00")
+	endEntry := widget.NewEntry()
+	endEntry.SetText(r.End)
+	endEntry.SetPlaceHolder("19:00")
+	weekdays := widget.NewCheckGroup(weekdayOptions, nil)
+	weekdays.Horizontal = true
+	for _, day := range r.Weekdays {
+		weekdays.Selected = append(weekdays.Selected, weekdayOption(day))
+	}
+	untilEntry := widget.NewEntry()
+	untilEntry.SetText(r.Until)
+	untilEntry.SetPlaceHolder("2006-01-02")
+	repeat := widget.NewCheck("Repeat weekly", func(on bool) {
+		if on {
+			weekdays.Enable()
+			untilEntry.Enable()
+		} else {
+			weekdays.Disable()
+			untilEntry.Disable()
+		}
+	})
+	repeat.SetChecked(r.IsSeries())
+	repeat.OnChanged(repeat.Checked)
+
+	title := "New Reservation"
+	if r.ID != "" {
+		title = "Edit Reservation"
+	}
+	items := []*widget.FormItem{
+		widget.NewFormItem("Label", labelEntry),
+		widget.NewFormItem("Devices", devicesEntry),
+		widget.NewFormItem("Date", dateEntry),
+		widget.NewFormItem("Start", startEntry),
+		widget.NewFormItem("End", endEntry),
+	}
+	if occurrenceDate != "" {
+		// One occurrence becomes a one-off on its own date.
+		title = "Edit Occurrence"
+		dateEntry.SetText(occurrenceDate)
+		dateEntry.Disable()
+	} else {
+		items = append(items,
+			widget.NewFormItem("", repeat),
+			widget.NewFormItem("On", weekdays),
+			widget.NewFormItem("Until", untilEntry))
+		if r.IsSeries() {
+			// Editing a series keeps its first date.
+			dateEntry.Disable()
+		}
+	}
+	dlg := dialog.NewForm(title, "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		devices, err := parseDeviceList(devicesEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		edited := r
+		edited.Label = labelEntry.Text
+		edited.Devices = devices
+		edited.Date = strings.TrimSpace(dateEntry.Text)
+		edited.Start = strings.TrimSpace(startEntry.Text)
+		edited.End = strings.TrimSpace(endEntry.Text)
+		edited.Repeat, edited.Weekdays, edited.Until = "", nil, ""
+		if repeat.Checked && occurrenceDate == "" {
+			edited.Repeat = state.RepeatWeekly
+			edited.Until = strings.TrimSpace(untilEntry.Text)
+			for _, option := range weekdays.Selected {
+				edited.Weekdays = append(edited.Weekdays, weekdayOf(option))
+			}
+		}
+		save := func(skipConflicts bool) ([]state.ReservationConflict, error) {
+			if occurrenceDate != "" {
+				return store.SaveOccurrence(r.ID, occurrenceDate, edited)
+			}
+			return store.SaveReservation(edited, skipConflicts)
+		}
+		conflicts, err := save(false)
+		switch {
+		case err != nil:
+			dialog.ShowError(err, mainWindow)
+		case len(conflicts) > 0:
+			showReservationConflicts(conflicts, edited.IsSeries() && occurrenceDate == "", func() {
+				if _, err := save(true); err != nil {
+					dialog.ShowError(err, mainWindow)
+					return
+				}
+				changed()
+			})
+		default:
+			changed()
+		}
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(520, dlg.MinSize().Height))
+	dlg.Show()
+}
+
+// showReservationConflicts lists each clashing occurrence. A series may be
+// saved without the clashing dates; anything else has to be changed.
+func showReservationConflicts(conflicts []state.ReservationConflict, canSkip bool, skip func()) {
+	lines := make([]string, len(conflicts))
+	for i, conflict := range conflicts {
+		lines[i] = conflict.String()
+	}
+	message := widget.NewLabel(strings.Join(lines, "\n"))
+	content := container.NewBorder(widget.NewLabel(fmt.Sprintf("%d occurrence(s) clash with existing reservations:", len(conflicts))),
+		nil, nil, nil, container.NewVScroll(message))
+	if !canSkip {
+		dlg := dialog.NewCustom("Reservation Conflicts", "Close", content, mainWindow)
+		dlg.Resize(fyne.NewSize(520, 320))
+		dlg.Show()
+		return
+	}
+	dlg := dialog.NewCustomConfirm("Reservation Conflicts", "Skip Those Dates", "Cancel", content, func(ok bool) {
+		if ok {
+			skip()
+		}
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(520, 320))
+	dlg.Show()
+}
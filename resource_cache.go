@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// glyph is what an image that is missing or damaged is drawn as instead: a
+// coloured circle with a letter, e.g. the device type's.
+type glyph struct {
+	Letter string
+	Color  color.NRGBA
+}
+
+// ResourceCache loads the images in dir once each, by file name, and is safe
+// to use from any goroutine. A file that is missing or does not decode is
+// replaced by its glyph, which is cached too, so the problem is logged once.
+type ResourceCache struct {
+	mu        sync.Mutex
+	dir       string
+	resources map[string]fyne.Resource
+}
+
+func NewResourceCache(dir string) *ResourceCache {
+	return &ResourceCache{dir: dir, resources: make(map[string]fyne.Resource)}
+}
+
+// iconCache holds every image the map, the queue and the dialogs show.
+var iconCache = NewResourceCache(imgBaseDir)
+
+// Get returns the image name, or fallback drawn as a glyph.
+func (c *ResourceCache) Get(name string, fallback glyph) fyne.Resource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if res, ok := c.resources[name]; ok {
+		return res
+	}
+	res, err := loadImageResource(filepath.Join(c.dir, name))
+	if err != nil {
+		slog.Warn("using a placeholder image", "image", name, "err", err)
+		res = glyphResource(name, fallback)
+	}
+	c.resources[name] = res
+	return res
+}
+
+// Invalidate forgets every image, so the next Get reads the files again.
+func (c *ResourceCache) Invalidate() {
+	c.mu.Lock()
+	c.resources = make(map[string]fyne.Resource)
+	c.mu.Unlock()
+}
+
+// loadImageResource reads path and checks that it decodes as an image.
+func loadImageResource(path string) (fyne.Resource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return fyne.NewStaticResource(filepath.Base(path), data), nil
+}
+
+// glyphSize is the width and height of a glyph image in pixels.
+const glyphSize = 64
+
+// glyphFont is the theme's bold font, parsed once. Fyne's SVG renderer
+// ignores <text>, so glyphs are drawn as PNGs with it instead.
+var glyphFont = sync.OnceValues(func() (*opentype.Font, error) {
+	return opentype.Parse(theme.DefaultTextBoldFont().Content())
+})
+
+// glyphResource draws g as a PNG, named after the image it stands in for.
+// Without a usable font it is the circle alone.
+func glyphResource(name string, g glyph) fyne.Resource {
+	img := image.NewNRGBA(image.Rect(0, 0, glyphSize, glyphSize))
+	const centre, radius = glyphSize / 2, glyphSize/2 - 2
+	for y := range glyphSize {
+		for x := range glyphSize {
+			dx, dy := x-centre, y-centre
+			if dx*dx+dy*dy <= radius*radius {
+				img.SetNRGBA(x, y, g.Color)
+			}
+		}
+	}
+	if err := drawGlyphLetter(img, g.Letter); err != nil {
+		slog.Warn("drawing a placeholder letter", "image", name, "err", err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		slog.Warn("encoding a placeholder image", "image", name, "err", err)
+	}
+	return fyne.NewStaticResource(name+".glyph.png", buf.Bytes())
+}
+
+// drawGlyphLetter writes letter in white, centred on img.
+func drawGlyphLetter(img *image.NRGBA, letter string) error {
+	f, err := glyphFont()
+	if err != nil {
+		return err
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: 34, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+	d := font.Drawer{Dst: img, Src: image.White, Face: face}
+	metrics := face.Metrics()
+	d.Dot = fixed.Point26_6{
+		X: (fixed.I(glyphSize) - d.MeasureString(letter)) / 2,
+		Y: (fixed.I(glyphSize) + metrics.CapHeight) / 2,
+	}
+	d.DrawString(letter)
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func decodeGlyph(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("glyph is not a PNG: %v", err)
+	}
+	return img
+}
+
+func TestGlyphResourceDrawsTheLetter(t *testing.T) {
+	fill := color.NRGBA{R: 0x40, G: 0xa0, B: 0x2b, A: 0xff}
+	img := decodeGlyph(t, glyphResource("pc.png", glyph{Letter: "P", Color: fill}).Content())
+	if got := img.Bounds().Size(); got != image.Pt(glyphSize, glyphSize) {
+		t.Fatalf("glyph is %v, want %dx%d", got, glyphSize, glyphSize)
+	}
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Error("corner outside the circle is not transparent")
+	}
+	if got := color.NRGBAModel.Convert(img.At(glyphSize/2, 4)); got != fill {
+		t.Errorf("circle edge is %v, want %v", got, fill)
+	}
+	white := 0
+	for y := range glyphSize {
+		for x := range glyphSize {
+			if r, g, b, _ := img.At(x, y).RGBA(); r == 0xffff && g == 0xffff && b == 0xffff {
+				white++
+			}
+		}
+	}
+	if white < 50 {
+		t.Fatalf("only %d white pixels; the letter was not drawn", white)
+	}
+
+	other := glyphResource("console.png", glyph{Letter: "C", Color: fill}).Content()
+	if bytes.Equal(other, glyphResource("pc.png", glyph{Letter: "P", Color: fill}).Content()) {
+		t.Error("different letters drew the same glyph")
+	}
+}
+
+func TestResourceCacheFallsBackToGlyphs(t *testing.T) {
+	dir := t.TempDir()
+	var real bytes.Buffer
+	if err := png.Encode(&real, image.NewNRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pc.png"), real.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "damaged.png"), []byte("not an image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewResourceCache(dir)
+	fallback := glyph{Letter: "Q", Color: color.NRGBA{A: 0xff}}
+	tests := []struct {
+		name      string
+		wantGlyph bool
+	}{
+		{"pc.png", false},
+		{"missing.png", true},
+		{"damaged.png", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := cache.Get(tt.name, fallback)
+			if isGlyph := strings.HasSuffix(res.Name(), ".glyph.png"); isGlyph != tt.wantGlyph {
+				t.Fatalf("got %s, want glyph %v", res.Name(), tt.wantGlyph)
+			}
+			if tt.wantGlyph {
+				decodeGlyph(t, res.Content())
+			}
+		})
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "missing.png"), real.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if res := cache.Get("missing.png", fallback); !strings.HasSuffix(res.Name(), ".glyph.png") {
+		t.Error("the cached glyph was not kept until Invalidate")
+	}
+	cache.Invalidate()
+	if res := cache.Get("missing.png", fallback); res.Name() != "missing.png" {
+		t.Errorf("after Invalidate got %s, want the file", res.Name())
+	}
+}
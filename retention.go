@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+const (
+	retentionAnonymizeOption = "Anonymize IDs and names"
+	retentionDeleteOption    = "Delete the logs"
+	// purgeConfirmWord is what staff type to let a purge go ahead.
+	purgeConfirmWord = "PURGE"
+)
+
+var retentionModeOptions = []string{retentionAnonymizeOption, retentionDeleteOption}
+
+func retentionModeOption(mode string) string {
+	if mode == state.RetentionDelete {
+		return retentionDeleteOption
+	}
+	return retentionAnonymizeOption
+}
+
+func retentionModeValue(option string) string {
+	if option == retentionDeleteOption {
+		return state.RetentionDelete
+	}
+	return ""
+}
+
+// retentionMode is the RetentionMode setting, where empty is anonymize.
+func retentionMode() string {
+	if appSettings.RetentionMode == state.RetentionDelete {
+		return state.RetentionDelete
+	}
+	return state.RetentionAnonymize
+}
+
+// showPurgeDialog previews what the retention setting would purge, file by
+// file, and carries it out once staff type the confirmation word.
+func showPurgeDialog() {
+	plan, err := store.PlanPurge(appSettings.RetentionMonths, retentionMode(), time.Now())
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	if len(plan.Files) == 0 {
+		dialog.ShowInformation("Purge Old Data", fmt.Sprintf("Nothing from before %s holds personal data.", plan.Before), mainWindow)
+		return
+	}
+	action := "anonymized"
+	if plan.Mode == state.RetentionDelete {
+		action = "deleted"
+	}
+	var lines []string
+	for _, file := range plan.Files {
+		lines = append(lines, fmt.Sprintf("%s: %d entries", file.Name, file.Entries))
+	}
+	preview := widget.NewLabel(strings.Join(lines, "\n"))
+	scroll := container.NewVScroll(preview)
+	scroll.SetMinSize(fyne.NewSize(0, 220))
+	summary := widget.NewLabel(fmt.Sprintf("Logs from before %s will be %s: %d entries in %d files. Journals, log backups and repair log lines from then are deleted. This cannot be undone.",
+		plan.Before, action, plan.Entries, len(plan.Files)))
+	summary.Wrapping = fyne.TextWrapWord
+	confirm := widget.NewEntry()
+	confirm.SetPlaceHolder(fmt.Sprintf("Type %s to confirm", purgeConfirmWord))
+	content := container.NewBorder(summary, confirm, nil, nil, scroll)
+
+	var dlg *dialog.ConfirmDialog
+	dlg = dialog.NewCustomConfirm("Purge Old Data", "Purge", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		if strings.TrimSpace(confirm.Text) != purgeConfirmWord {
+			dialog.ShowError(fmt.Errorf("type %s to purge", purgeConfirmWord), mainWindow)
+			return
+		}
+		requireStaffPIN("Purge Old Data", func() { runPurge(plan) })
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(560, 460))
+	dlg.Show()
+}
+
+func runPurge(plan state.PurgePlan) {
+	var anon *state.Anonymizer
+	if plan.Mode == state.RetentionAnonymize {
+		var err error
+		if anon, err = loadExportAnonymizer(); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+	}
+	progress := dialog.NewCustomWithoutButtons("Purge Old Data", widget.NewProgressBarInfinite(), mainWindow)
+	progress.Show()
+	go func() {
+		err := store.Purge(plan, anon)
+		fyne.Do(func() {
+			progress.Hide()
+			refreshLogDateOptions()
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			dialog.ShowInformation("Purge Old Data", fmt.Sprintf("Purged %d entries in %d files from before %s.", plan.Entries, len(plan.Files), plan.Before), mainWindow)
+		})
+	}()
+}
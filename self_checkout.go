@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// newSelfCheckoutEntry is the toolbar field members type or scan their ID
+// into to check themselves out.
+func newSelfCheckoutEntry() fyne.CanvasObject {
+	entry := widget.NewEntry()
+	entry.SetPlaceHolder("Leaving or away? Scan or type your ID")
+	writerOnly(entry)
+	entry.OnSubmitted = func(text string) {
+		entry.SetText("")
+		showSelfCheckout(strings.TrimSpace(text))
+	}
+	return container.NewGridWrap(fyne.NewSize(260, entry.MinSize().Height), entry)
+}
+
+// showSelfCheckout confirms a member's own checkout with one large button. It
+// ends the session through store.Checkout, exactly as staff checkouts do, or
+// takes a queued member out through store.RemoveQueued.
+func showSelfCheckout(userID string) {
+	if userID == "" {
+		return
+	}
+	found := store.UserByID(userID)
+	if found == nil {
+		dialog.ShowInformation("Check Out", fmt.Sprintf("ID %s isn't checked in right now, so there's nothing to do. See you next time!", userID), mainWindow)
+		return
+	}
+	// The dialog may stay open for a while and the desk keeps working, so
+	// it shows a copy and each button looks the member up again.
+	user := *found
+	name := canvas.NewText(firstLastNonEmpty(user.Name), theme.ForegroundColor())
+	name.TextSize = 24
+	name.TextStyle = fyne.TextStyle{Bold: true}
+	name.Alignment = fyne.TextAlignCenter
+	where := "in the queue"
+	action := "Leave Queue"
+	if user.PCID != 0 {
+		where = "on " + deviceNameByID(user.PCID)
+		action = "Check Out"
+	}
+	details := widget.NewLabelWithStyle(fmt.Sprintf("%s for %s", where, state.FormatDuration(time.Since(user.CheckInTime))),
+		fyne.TextAlignCenter, fyne.TextStyle{})
+
+	var dlg dialog.Dialog
+	checkout := widget.NewButtonWithIcon(action, theme.ConfirmIcon(), func() {
+		dlg.Hide()
+		current := store.UserByID(userID)
+		if current == nil {
+			showNoLongerCheckedIn(userID)
+			return
+		}
+		name := firstLastNonEmpty(current.Name)
+		if current.PCID == 0 {
+			if err := store.RemoveQueued(userID); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			dialog.ShowInformation("Leave Queue", fmt.Sprintf("Thanks, %s - you've left the queue.", name), mainWindow)
+			return
+		}
+		if err := store.Checkout(userID); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		dialog.ShowInformation("Check Out", fmt.Sprintf("Thanks, %s - you're checked out.", name), mainWindow)
+	})
+	checkout.Importance = widget.HighImportance
+	cancel := widget.NewButton("Not me", func() { dlg.Hide() })
+	actions := []fyne.CanvasObject{checkout}
+	if user.PCID != 0 && store.AwayPeriod > 0 {
+		away := widget.NewButtonWithIcon(fmt.Sprintf("Away %d min", int(store.AwayPeriod.Minutes())), theme.HistoryIcon(), func() {
+			dlg.Hide()
+			markSelfAway(userID)
+		})
+		actions = append(actions, away)
+	}
+	content := container.NewVBox(
+		name,
+		details,
+		container.NewCenter(container.NewGridWrap(fyne.NewSize(320, 72), actions...)),
+		container.NewCenter(cancel),
+	)
+	dlg = dialog.NewCustomWithoutButtons("Check Out", content, mainWindow)
+	dlg.Show()
+}
+
+// showNoLongerCheckedIn tells a member at the kiosk that their session ended
+// while the dialog was open, e.g. staff checked them out.
+func showNoLongerCheckedIn(userID string) {
+	dialog.ShowInformation("Check Out", fmt.Sprintf("ID %s is no longer checked in, so there's nothing to do.", userID), mainWindow)
+}
+
+func deviceTypeName(deviceID int) string {
+	if device := store.DeviceByID(deviceID); device != nil {
+		return device.Type
+	}
+	return "Device"
+}
+
+// deviceNameByID is the device's Name, e.g. "PS5" or "PC 7".
+func deviceNameByID(deviceID int) string {
+	if device := store.DeviceByID(deviceID); device != nil {
+		return device.Name()
+	}
+	return fmt.Sprintf("Device %d", deviceID)
+}
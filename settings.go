@@ -0,0 +1,878 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/applog"
+	"lounge/internal/smtpmail"
+	"lounge/internal/state"
+)
+
+// Settings holds the user-configurable options persisted to settingsFile.
+// Fields missing from a file keep their defaultSettings value, so an older
+// file picks up the defaults of options added since. Where a field's
+// comment says so, 0 switches the feature off; fields with a non-zero
+// default are saved even when 0, so that choice survives a restart.
+type Settings struct {
+	MaxQueueLength   int     `json:"max_queue_length,omitempty"`
+	CooldownMinutes  int     `json:"cooldown_minutes,omitempty"`
+	WindowWidth      float32 `json:"window_width,omitempty"`
+	WindowHeight     float32 `json:"window_height,omitempty"`
+	ArchiveAfterDays int     `json:"archive_after_days"`
+	// QueueTimeoutMinutes removes queued users who waited this long; 0 = off.
+	QueueTimeoutMinutes int `json:"queue_timeout_minutes"`
+	// ConsoleRotationMinutes is how long players keep a console before
+	// staff rotate them; 0 = off.
+	ConsoleRotationMinutes int `json:"console_rotation_minutes"`
+	// CleaningCooldownMinutes marks a device for wiping down this long after
+	// each checkout; 0 = off.
+	CleaningCooldownMinutes int `json:"cleaning_cooldown_minutes,omitempty"`
+	// AwayMinutes is how long a member's "away" mark from the kiosk holds
+	// their device; 0 = off.
+	AwayMinutes int `json:"away_minutes"`
+	// SessionLimitMinutes is how long a session is expected to last, for
+	// the availability forecast; 0 = no forecast.
+	SessionLimitMinutes int `json:"session_limit_minutes"`
+	// SessionWarningMinutes is how long before the session limit a device
+	// is badged as ending soon; 0 = no badge.
+	SessionWarningMinutes int `json:"session_warning_minutes"`
+	// SessionLimitExemptPurposes are the purposes the session limit does
+	// not apply to, e.g. "Club event"; members can be exempted one by one
+	// in the member dialog.
+	SessionLimitExemptPurposes []string `json:"session_limit_exempt_purposes,omitempty"`
+	// StreakBadgeDays badges a member on the map once their attendance
+	// streak reaches this many open days; 0 = no badge.
+	StreakBadgeDays int `json:"streak_badge_days,omitempty"`
+	// PCDailyCapMinutes and ConsoleDailyCapMinutes cap each person's time
+	// on that device type per day; 0 = unlimited. DailyCapBlock refuses
+	// check-ins over a cap instead of asking staff to confirm.
+	PCDailyCapMinutes      int  `json:"pc_daily_cap_minutes,omitempty"`
+	ConsoleDailyCapMinutes int  `json:"console_daily_cap_minutes,omitempty"`
+	DailyCapBlock          bool `json:"daily_cap_block,omitempty"`
+	// GuestVisitLimit is how many days a guest may visit per term before
+	// registering as a member; 0 = unlimited.
+	GuestVisitLimit int `json:"guest_visit_limit"`
+	// StudentIDPattern is a regular expression IDs must match, except the
+	// ones the desk generates; empty accepts any ID.
+	StudentIDPattern string `json:"student_id_pattern,omitempty"`
+	// DisabledValidators lists check-in rules switched off, by
+	// CheckInValidator ID.
+	DisabledValidators []string `json:"disabled_validators,omitempty"`
+
+	// Google Sheets mirroring is off unless both of these are set.
+	SheetsCredentialsFile string `json:"sheets_credentials_file,omitempty"`
+	SpreadsheetID         string `json:"spreadsheet_id,omitempty"`
+	SheetName             string `json:"sheet_name,omitempty"`
+
+	// ToolbarButtons are the IDs of the actions shown as toolbar buttons, in
+	// order; nil shows every action. The rest are in the overflow menu.
+	ToolbarButtons []string `json:"toolbar_buttons"`
+
+	// PurposeOptions overrides defaultPurposeOptions when non-empty.
+	PurposeOptions []string `json:"purpose_options,omitempty"`
+	// RemovalReasons overrides defaultRemovalReasons when non-empty; a
+	// trailing "*" marks a reason that is not a visit.
+	RemovalReasons []string `json:"removal_reasons,omitempty"`
+	LastPurpose    string   `json:"last_purpose,omitempty"`
+	// CourseCodes are the courses a check-in can earn credit hours for; the
+	// check-in forms offer a Course picker only when there are some.
+	CourseCodes []string `json:"course_codes,omitempty"`
+
+	Terms []state.Term `json:"terms,omitempty"`
+	// Closures are the holidays and quiet periods the lounge is closed.
+	Closures []state.Closure `json:"closures,omitempty"`
+
+	// EventName is the club event the lounge is reserved for; empty means
+	// open hours.
+	EventName string `json:"event_name,omitempty"`
+
+	// AnonymizeExports replaces user IDs and names in exports and the Sheets
+	// mirror; each export dialog can still change it for one export.
+	AnonymizeExports bool `json:"anonymize_exports,omitempty"`
+	// RetentionMonths is how long logs keep user IDs and names; the purge
+	// tool anonymizes or deletes older ones, as RetentionMode says ("" is
+	// anonymize). 0 turns the purge off.
+	RetentionMonths int    `json:"retention_months"`
+	RetentionMode   string `json:"retention_mode,omitempty"`
+
+	// EvacuationShortcut opens the evacuation list from anywhere, e.g.
+	// "Ctrl+Shift+E"; empty means toolbar only.
+	EvacuationShortcut string `json:"evacuation_shortcut"`
+
+	// DateStyle, ClockStyle and WeekStart pick how dates and times are
+	// shown; empty follows the system locale.
+	DateStyle  string `json:"date_style,omitempty"`
+	ClockStyle string `json:"clock_style,omitempty"`
+	WeekStart  string `json:"week_start,omitempty"`
+
+	// LockDestructiveActions asks for the staff PIN before checkouts,
+	// queue removals, edits and settings changes. StaffPINHash is the PIN as
+	// written by hashPIN; a correct PIN is remembered for PINCacheMinutes.
+	LockDestructiveActions bool   `json:"lock_destructive_actions,omitempty"`
+	StaffPINHash           string `json:"staff_pin_hash,omitempty"`
+	PINCacheMinutes        int    `json:"pin_cache_minutes"`
+
+	// Alerts for the staff lead: a queue wait, a streak of every PC busy
+	// (both in minutes) or a number of devices in maintenance; 0 turns a
+	// rule off. AlertWebhookURL also receives each alert when set.
+	AlertQueueWaitMinutes   int    `json:"alert_queue_wait_minutes"`
+	AlertAllBusyMinutes     int    `json:"alert_all_busy_minutes"`
+	AlertMaintenanceDevices int    `json:"alert_maintenance_devices"`
+	AlertWebhookURL         string `json:"alert_webhook_url,omitempty"`
+
+	// DisplayServerAddr is where wall displays fetch /status and /events,
+	// e.g. ":8080"; empty means no server.
+	DisplayServerAddr string `json:"display_server_addr,omitempty"`
+	// DimFrom and DimUntil bound the nightly dimming of the wall display,
+	// as "15:04"; either blank turns the schedule off. DimMainWindow dims
+	// the desk too.
+	DimFrom       string `json:"dim_from,omitempty"`
+	DimUntil      string `json:"dim_until,omitempty"`
+	DimMainWindow bool   `json:"dim_main_window,omitempty"`
+	// QuietFrom and QuietUntil bound the daily quiet hours, as "15:04",
+	// when notifications are held for a digest or dropped; either blank
+	// turns them off. QuietHoursExempt lists the notifyFeatures, by ID,
+	// that notify in quiet hours anyway.
+	QuietFrom        string   `json:"quiet_from,omitempty"`
+	QuietUntil       string   `json:"quiet_until,omitempty"`
+	QuietHoursExempt []string `json:"quiet_hours_exempt,omitempty"`
+
+	// LogLevel is how much goes to log/app.log: "debug" (every state
+	// change), "info", "warn" or "error". Empty means info.
+	LogLevel string `json:"log_level,omitempty"`
+
+	// SMTP server for members' session receipts; an empty SMTPHost turns
+	// receipts off. Port 465 uses implicit TLS, others STARTTLS when the
+	// server offers it.
+	SMTPHost     string `json:"smtp_host,omitempty"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPFrom     string `json:"smtp_from,omitempty"`
+	SMTPUsername string `json:"smtp_username,omitempty"`
+	// SMTPPassword is never written to the settings file; see
+	// readSMTPPassword.
+	SMTPPassword string `json:"-"`
+
+	// BackupDir, e.g. a folder on the shared drive, gets a copy of the data
+	// files at close, at midnight and on demand; empty means no backups.
+	// Backups older than BackupKeepDays are removed; 0 keeps them all.
+	BackupDir      string `json:"backup_dir,omitempty"`
+	BackupKeepDays int    `json:"backup_keep_days"`
+
+	// CompactMode is "Always" or "Never" to force the small-screen layout
+	// on or off; empty means it follows the window width.
+	CompactMode string `json:"compact_mode,omitempty"`
+	// HideCheckInPanel keeps the queue check-in form out of the way; the
+	// toolbar's Check-In Panel toggle sets it.
+	HideCheckInPanel bool `json:"hide_check_in_panel,omitempty"`
+	// UIScale sizes the map's and queue's text and icons, in percent from
+	// 100 to 175. DisplayScale is the same for the wall displays, which are
+	// read from further away.
+	UIScale      int `json:"ui_scale"`
+	DisplayScale int `json:"display_scale"`
+
+	// LogColumns are the log view's columns in order, by logColumn ID;
+	// empty means defaultLogColumnIDs. LogColumnWidths holds the widths
+	// staff dragged, by the same IDs.
+	LogColumns      []string           `json:"log_columns,omitempty"`
+	LogColumnWidths map[string]float32 `json:"log_column_widths,omitempty"`
+	// LogShowSource is the Source toggle from before the column picker; it
+	// adds the Source column until the picker saves LogColumns.
+	LogShowSource bool `json:"log_show_source,omitempty"`
+}
+
+var appSettings = defaultSettings()
+
+func defaultSettings() Settings {
+	return Settings{
+		ArchiveAfterDays:        60,
+		RetentionMonths:         12,
+		QueueTimeoutMinutes:     45,
+		ConsoleRotationMinutes:  30,
+		AwayMinutes:             10,
+		SessionLimitMinutes:     120,
+		SessionWarningMinutes:   15,
+		PINCacheMinutes:         5,
+		GuestVisitLimit:         3,
+		SMTPPort:                587,
+		BackupKeepDays:          30,
+		AlertQueueWaitMinutes:   30,
+		AlertAllBusyMinutes:     45,
+		AlertMaintenanceDevices: 3,
+		EvacuationShortcut:      defaultEvacuationShortcut,
+		UIScale:                 100,
+		DisplayScale:            100,
+	}
+}
+
+func loadSettings() {
+	appSettings = defaultSettings()
+	data, err := os.ReadFile(settingsFile)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	if err := json.Unmarshal(data, &appSettings); err != nil {
+		slog.Error("reading settings", "err", err)
+		appSettings = defaultSettings()
+	}
+	appSettings.SMTPPassword = readSMTPPassword()
+	if appSettings.SMTPPassword == "" {
+		appSettings.SMTPPassword = settingsSMTPPassword(data)
+	}
+}
+
+// applySettings pushes the settings the state store enforces into it.
+func applySettings() {
+	if trainingMode {
+		keepTrainingInside(&appSettings)
+	}
+	store.MaxQueueLength = appSettings.MaxQueueLength
+	store.Cooldown = time.Duration(appSettings.CooldownMinutes) * time.Minute
+	store.QueueTimeout = time.Duration(appSettings.QueueTimeoutMinutes) * time.Minute
+	store.ConsoleRotation = time.Duration(appSettings.ConsoleRotationMinutes) * time.Minute
+	store.CleaningCooldown = time.Duration(appSettings.CleaningCooldownMinutes) * time.Minute
+	store.AwayPeriod = time.Duration(appSettings.AwayMinutes) * time.Minute
+	store.SessionLimit = time.Duration(appSettings.SessionLimitMinutes) * time.Minute
+	store.SessionWarning = time.Duration(appSettings.SessionWarningMinutes) * time.Minute
+	store.LimitExemptPurposes = appSettings.SessionLimitExemptPurposes
+	store.Event = appSettings.EventName
+	store.DailyCaps = map[string]time.Duration{
+		"PC":      time.Duration(appSettings.PCDailyCapMinutes) * time.Minute,
+		"Console": time.Duration(appSettings.ConsoleDailyCapMinutes) * time.Minute,
+	}
+	store.DailyCapsBlock = appSettings.DailyCapBlock
+	store.StudentIDPattern = nil
+	if appSettings.StudentIDPattern != "" {
+		pattern, err := compileStudentIDPattern(appSettings.StudentIDPattern)
+		if err != nil {
+			slog.Error("compiling student ID pattern", "err", err)
+		}
+		store.StudentIDPattern = pattern
+	}
+	store.ConfigureValidators(appSettings.DisabledValidators)
+	store.GuestVisitLimit = appSettings.GuestVisitLimit
+	store.Terms = appSettings.Terms
+	store.Closures = appSettings.Closures
+	applog.SetLevel(applog.ParseLevel(appSettings.LogLevel))
+}
+
+// compileStudentIDPattern anchors pattern so it must match the whole ID.
+func compileStudentIDPattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("student ID pattern: %w", err)
+	}
+	return re, nil
+}
+
+func saveSettings() error {
+	if err := store.EnsureLogDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(appSettings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal settings: %w", err)
+	}
+	return state.WriteFileAtomic(settingsFile, data, 0o644)
+}
+
+func parseNonNegativeInt(field, text string) (int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	value, err := strconv.Atoi(text)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("%s must be a whole number of 0 or more", field)
+	}
+	return value, nil
+}
+
+// parseTerms reads one "Name: start..end" term per non-blank line.
+func parseTerms(text string) ([]state.Term, error) {
+	var terms []state.Term
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		term, err := state.ParseTerm(line)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+func showSettingsDialog() {
+	maxQueueEntry := widget.NewEntry()
+	maxQueueEntry.SetText(strconv.Itoa(appSettings.MaxQueueLength))
+	maxQueueEntry.SetPlaceHolder("0 = unlimited")
+
+	cooldownEntry := widget.NewEntry()
+	cooldownEntry.SetText(strconv.Itoa(appSettings.CooldownMinutes))
+	cooldownEntry.SetPlaceHolder("0 = off; only applies while others are queued")
+
+	queueTimeoutEntry := widget.NewEntry()
+	queueTimeoutEntry.SetText(strconv.Itoa(appSettings.QueueTimeoutMinutes))
+	queueTimeoutEntry.SetPlaceHolder("0 = never remove queued users")
+
+	rotationEntry := widget.NewEntry()
+	rotationEntry.SetText(strconv.Itoa(appSettings.ConsoleRotationMinutes))
+	rotationEntry.SetPlaceHolder("0 = no rotation timer")
+	cleaningEntry := widget.NewEntry()
+	cleaningEntry.SetText(strconv.Itoa(appSettings.CleaningCooldownMinutes))
+	cleaningEntry.SetPlaceHolder("0 = off")
+	awayEntry := widget.NewEntry()
+	awayEntry.SetText(strconv.Itoa(appSettings.AwayMinutes))
+	awayEntry.SetPlaceHolder("0 = off")
+	sessionLimitEntry := widget.NewEntry()
+	sessionLimitEntry.SetText(strconv.Itoa(appSettings.SessionLimitMinutes))
+	sessionLimitEntry.SetPlaceHolder("0 = no availability forecast")
+	sessionWarningEntry := widget.NewEntry()
+	sessionWarningEntry.SetText(strconv.Itoa(appSettings.SessionWarningMinutes))
+	sessionWarningEntry.SetPlaceHolder("0 = no ending-soon badge")
+	limitExemptEntry := widget.NewEntry()
+	limitExemptEntry.SetText(strings.Join(appSettings.SessionLimitExemptPurposes, ", "))
+	limitExemptEntry.SetPlaceHolder("Comma separated purposes, e.g. Club event")
+	streakBadgeEntry := widget.NewEntry()
+	streakBadgeEntry.SetText(strconv.Itoa(appSettings.StreakBadgeDays))
+	streakBadgeEntry.SetPlaceHolder("0 = no streak badge")
+
+	pcCapEntry := widget.NewEntry()
+	pcCapEntry.SetText(strconv.Itoa(appSettings.PCDailyCapMinutes))
+	pcCapEntry.SetPlaceHolder("0 = unlimited")
+	consoleCapEntry := widget.NewEntry()
+	consoleCapEntry.SetText(strconv.Itoa(appSettings.ConsoleDailyCapMinutes))
+	consoleCapEntry.SetPlaceHolder("0 = unlimited")
+	capBlockCheck := widget.NewCheck("Refuse check-ins over a cap (otherwise warn)", nil)
+	capBlockCheck.SetChecked(appSettings.DailyCapBlock)
+	guestLimitEntry := widget.NewEntry()
+	guestLimitEntry.SetText(strconv.Itoa(appSettings.GuestVisitLimit))
+	guestLimitEntry.SetPlaceHolder("0 = unlimited")
+	studentIDEntry := widget.NewEntry()
+	studentIDEntry.SetText(appSettings.StudentIDPattern)
+	studentIDEntry.SetPlaceHolder(`e.g. [0-9]{9}; blank = any ID`)
+	validatorNames := map[string]string{}
+	var ruleOptions, rulesOn []string
+	for _, v := range state.BuiltinValidators {
+		if v.Required {
+			continue
+		}
+		validatorNames[v.Name] = v.ID
+		ruleOptions = append(ruleOptions, v.Name)
+		if !slices.Contains(appSettings.DisabledValidators, v.ID) {
+			rulesOn = append(rulesOn, v.Name)
+		}
+	}
+	rulesGroup := widget.NewCheckGroup(ruleOptions, nil)
+	rulesGroup.SetSelected(rulesOn)
+
+	archiveEntry := widget.NewEntry()
+	archiveEntry.SetText(strconv.Itoa(appSettings.ArchiveAfterDays))
+	archiveEntry.SetPlaceHolder("0 = never archive")
+	compactButton := widget.NewButton("Compact now", showCompactLogsDialog)
+	importButton := widget.NewButton("Import historical sessions", showImportHistoryDialog)
+	toolbarButton := widget.NewButton("Customize toolbar", showToolbarDialog)
+
+	purposeEntry := widget.NewEntry()
+	purposeEntry.SetText(strings.Join(purposeOptions(), ", "))
+	purposeEntry.SetPlaceHolder("Comma separated")
+	courseEntry := widget.NewEntry()
+	courseEntry.SetText(strings.Join(appSettings.CourseCodes, ", "))
+	courseEntry.SetPlaceHolder("Comma separated, e.g. CS101, GAME220")
+	removalEntry := widget.NewEntry()
+	removalEntry.SetText(strings.Join(appSettings.RemovalReasons, ", "))
+	removalEntry.SetPlaceHolder(strings.Join(defaultRemovalReasons, ", "))
+
+	termLines := make([]string, 0, len(appSettings.Terms))
+	for _, term := range appSettings.Terms {
+		termLines = append(termLines, term.String())
+	}
+	termsEntry := widget.NewMultiLineEntry()
+	termsEntry.SetText(strings.Join(termLines, "\n"))
+	termsEntry.SetPlaceHolder("Fall 2024: 2024-09-01..2024-12-20")
+	termsEntry.SetMinRowsVisible(3)
+	closureLines := make([]string, 0, len(appSettings.Closures))
+	for _, closure := range appSettings.Closures {
+		closureLines = append(closureLines, closure.String())
+	}
+	closuresEntry := widget.NewMultiLineEntry()
+	closuresEntry.SetText(strings.Join(closureLines, "\n"))
+	closuresEntry.SetPlaceHolder("Reading week: 2025-02-17..2025-02-21\nCanada Day: 2025-07-01")
+	closuresEntry.SetMinRowsVisible(3)
+
+	credentialsEntry := widget.NewEntry()
+	credentialsEntry.SetText(appSettings.SheetsCredentialsFile)
+	credentialsEntry.SetPlaceHolder("Path to service-account JSON")
+	spreadsheetEntry := widget.NewEntry()
+	spreadsheetEntry.SetText(appSettings.SpreadsheetID)
+	spreadsheetEntry.SetPlaceHolder("Blank = don't mirror")
+	sheetNameEntry := widget.NewEntry()
+	sheetNameEntry.SetText(appSettings.SheetName)
+	sheetNameEntry.SetPlaceHolder(defaultSheetsSheet)
+	syncButton := widget.NewButton("Sync today", showSyncTodayDialog)
+	if !sheetMirror.enabled() {
+		syncButton.Disable()
+	}
+	diagnosticsButton := widget.NewButton("Run diagnostics", showDiagnosticsDialog)
+	logLevelSelect := widget.NewSelect(logLevelOptions, nil)
+	logLevelSelect.SetSelected(applog.ParseLevel(appSettings.LogLevel).String())
+	dateStyleSelect := newFormatSelect(dateStyleOptions, appSettings.DateStyle)
+	clockSelect := newFormatSelect(clockOptions, appSettings.ClockStyle)
+	weekStartSelect := newFormatSelect(weekStartOptions, appSettings.WeekStart)
+	compactSelect := widget.NewSelect(compactModeOptions, nil)
+	compactSelect.SetSelected(compactModeSetting())
+	uiScaleSelect := widget.NewSelect(uiScaleOptions, nil)
+	uiScaleSelect.SetSelected(scaleOption(appSettings.UIScale))
+	displayScaleSelect := widget.NewSelect(uiScaleOptions, nil)
+	displayScaleSelect.SetSelected(scaleOption(appSettings.DisplayScale))
+	evacuationEntry := widget.NewEntry()
+	evacuationEntry.SetText(appSettings.EvacuationShortcut)
+	evacuationEntry.SetPlaceHolder("e.g. Ctrl+Shift+E; blank = none")
+	displayAddrEntry := widget.NewEntry()
+	displayAddrEntry.SetText(appSettings.DisplayServerAddr)
+	displayAddrEntry.SetPlaceHolder("e.g. :8080; blank = off")
+	dimFromEntry := widget.NewEntry()
+	dimFromEntry.SetText(appSettings.DimFrom)
+	dimFromEntry.SetPlaceHolder("e.g. 19:00; blank = off")
+	dimUntilEntry := widget.NewEntry()
+	dimUntilEntry.SetText(appSettings.DimUntil)
+	dimUntilEntry.SetPlaceHolder("e.g. 07:00")
+	dimMainCheck := widget.NewCheck("Dim this window too", nil)
+	dimMainCheck.SetChecked(appSettings.DimMainWindow)
+	quietFromEntry := widget.NewEntry()
+	quietFromEntry.SetText(appSettings.QuietFrom)
+	quietFromEntry.SetPlaceHolder("e.g. 14:00; blank = off")
+	quietUntilEntry := widget.NewEntry()
+	quietUntilEntry.SetText(appSettings.QuietUntil)
+	quietUntilEntry.SetPlaceHolder("e.g. 16:00")
+	var quietOptions, quietOn []string
+	quietIDs := make(map[string]string)
+	for _, feature := range notifyFeatures {
+		quietIDs[feature.Name] = feature.ID
+		quietOptions = append(quietOptions, feature.Name)
+		if !slices.Contains(appSettings.QuietHoursExempt, feature.ID) {
+			quietOn = append(quietOn, feature.Name)
+		}
+	}
+	quietGroup := widget.NewCheckGroup(quietOptions, nil)
+	quietGroup.SetSelected(quietOn)
+	alertQueueEntry := widget.NewEntry()
+	alertQueueEntry.SetText(strconv.Itoa(appSettings.AlertQueueWaitMinutes))
+	alertQueueEntry.SetPlaceHolder("0 = off")
+	alertBusyEntry := widget.NewEntry()
+	alertBusyEntry.SetText(strconv.Itoa(appSettings.AlertAllBusyMinutes))
+	alertBusyEntry.SetPlaceHolder("0 = off")
+	alertMaintenanceEntry := widget.NewEntry()
+	alertMaintenanceEntry.SetText(strconv.Itoa(appSettings.AlertMaintenanceDevices))
+	alertMaintenanceEntry.SetPlaceHolder("0 = off")
+	alertWebhookEntry := widget.NewEntry()
+	alertWebhookEntry.SetText(appSettings.AlertWebhookURL)
+	alertWebhookEntry.SetPlaceHolder("Blank = notifications only")
+	staffLockCheck := widget.NewCheck("Require the staff PIN for checkouts, removals and edits", nil)
+	staffLockCheck.SetChecked(appSettings.LockDestructiveActions)
+	pinEntry := widget.NewPasswordEntry()
+	pinEntry.SetPlaceHolder("Set a PIN")
+	if appSettings.StaffPINHash != "" {
+		pinEntry.SetPlaceHolder("Unchanged")
+	}
+	pinCacheEntry := widget.NewEntry()
+	pinCacheEntry.SetText(strconv.Itoa(appSettings.PINCacheMinutes))
+	pinCacheEntry.SetPlaceHolder("0 = ask every time")
+	anonymizeCheck := widget.NewCheck("Anonymize exports by default", nil)
+	anonymizeCheck.SetChecked(appSettings.AnonymizeExports)
+	rotateSaltButton := widget.NewButton("Rotate salt", showRotateSaltDialog)
+	retentionEntry := widget.NewEntry()
+	retentionEntry.SetText(strconv.Itoa(appSettings.RetentionMonths))
+	retentionEntry.SetPlaceHolder("0 = keep forever")
+	retentionModeSelect := widget.NewSelect(retentionModeOptions, nil)
+	retentionModeSelect.SetSelected(retentionModeOption(appSettings.RetentionMode))
+	purgeButton := widget.NewButton("Purge old data...", showPurgeDialog)
+	smtpHostEntry := widget.NewEntry()
+	smtpHostEntry.SetText(appSettings.SMTPHost)
+	smtpHostEntry.SetPlaceHolder("e.g. smtp.example.edu; blank = off")
+	smtpPortEntry := widget.NewEntry()
+	smtpPortEntry.SetText(strconv.Itoa(appSettings.SMTPPort))
+	smtpFromEntry := widget.NewEntry()
+	smtpFromEntry.SetText(appSettings.SMTPFrom)
+	smtpFromEntry.SetPlaceHolder("e.g. Lounge <lounge@example.edu>")
+	smtpUserEntry := widget.NewEntry()
+	smtpUserEntry.SetText(appSettings.SMTPUsername)
+	smtpUserEntry.SetPlaceHolder("Blank = no login")
+	smtpPasswordEntry := widget.NewPasswordEntry()
+	smtpPasswordEntry.SetText(appSettings.SMTPPassword)
+	if smtpPasswordFromEnv() {
+		smtpPasswordEntry.SetText("")
+		smtpPasswordEntry.SetPlaceHolder("Set by " + smtpPasswordEnv)
+		smtpPasswordEntry.Disable()
+	}
+	smtpFormConfig := func() (smtpmail.Config, error) {
+		port, err := parseNonNegativeInt("SMTP port", smtpPortEntry.Text)
+		return smtpmail.Config{
+			Host:     strings.TrimSpace(smtpHostEntry.Text),
+			Port:     port,
+			From:     strings.TrimSpace(smtpFromEntry.Text),
+			Username: strings.TrimSpace(smtpUserEntry.Text),
+			Password: smtpFormPassword(smtpPasswordEntry.Text),
+		}, err
+	}
+	backupDirEntry := widget.NewEntry()
+	backupDirEntry.SetText(appSettings.BackupDir)
+	backupDirEntry.SetPlaceHolder("e.g. S:\\Lounge\\Backups; blank = off")
+	backupKeepEntry := widget.NewEntry()
+	backupKeepEntry.SetText(strconv.Itoa(appSettings.BackupKeepDays))
+	backupKeepEntry.SetPlaceHolder("0 = keep all")
+	backupNowButton := widget.NewButton("Back up now", showBackupNowDialog)
+	testEmailButton := widget.NewButton("Send test email", func() {
+		config, err := smtpFormConfig()
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		showTestEmailDialog(config)
+	})
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Max queue length", maxQueueEntry),
+		widget.NewFormItem("Cooldown between sessions (min)", cooldownEntry),
+		widget.NewFormItem("Queue timeout (min)", queueTimeoutEntry),
+		widget.NewFormItem("Console rotation (min)", rotationEntry),
+		widget.NewFormItem("Cleaning after checkout (min)", cleaningEntry),
+		widget.NewFormItem("Away from kiosk (min)", awayEntry),
+		widget.NewFormItem("Session limit (min)", sessionLimitEntry),
+		widget.NewFormItem("Ending soon warning (min)", sessionWarningEntry),
+		widget.NewFormItem("No session limit for purposes", limitExemptEntry),
+		widget.NewFormItem("Streak badge (open days)", streakBadgeEntry),
+		widget.NewFormItem("PC time per person per day (min)", pcCapEntry),
+		widget.NewFormItem("Console time per person per day (min)", consoleCapEntry),
+		widget.NewFormItem("", capBlockCheck),
+		widget.NewFormItem("Guest visits per term", guestLimitEntry),
+		widget.NewFormItem("Student ID pattern", studentIDEntry),
+		widget.NewFormItem("Check-in rules", rulesGroup),
+		widget.NewFormItem("Archive logs after (days)", archiveEntry),
+		widget.NewFormItem("", compactButton),
+		widget.NewFormItem("", toolbarButton),
+		widget.NewFormItem("", importButton),
+		widget.NewFormItem("Purpose options", purposeEntry),
+		widget.NewFormItem("Course codes", courseEntry),
+		widget.NewFormItem("Queue removal reasons", removalEntry),
+		widget.NewFormItem("", widget.NewLabel("Comma separated; end a reason with * if it is not a visit.")),
+		widget.NewFormItem("Terms", termsEntry),
+		widget.NewFormItem("Closures", closuresEntry),
+		widget.NewFormItem("Sheets credentials", credentialsEntry),
+		widget.NewFormItem("Spreadsheet ID", spreadsheetEntry),
+		widget.NewFormItem("Sheet name", sheetNameEntry),
+		widget.NewFormItem("", syncButton),
+		widget.NewFormItem("Privacy", anonymizeCheck),
+		widget.NewFormItem("", rotateSaltButton),
+		widget.NewFormItem("Keep personal data (months)", retentionEntry),
+		widget.NewFormItem("After that", retentionModeSelect),
+		widget.NewFormItem("", purgeButton),
+		widget.NewFormItem("Date format", dateStyleSelect),
+		widget.NewFormItem("Clock", clockSelect),
+		widget.NewFormItem("Week starts on", weekStartSelect),
+		widget.NewFormItem("Compact layout", compactSelect),
+		widget.NewFormItem("Map text size", uiScaleSelect),
+		widget.NewFormItem("Wall display text size", displayScaleSelect),
+		widget.NewFormItem("Evacuation list hotkey", evacuationEntry),
+		widget.NewFormItem("Wall display address", displayAddrEntry),
+		widget.NewFormItem("Dim display from", dimFromEntry),
+		widget.NewFormItem("Dim display until", dimUntilEntry),
+		widget.NewFormItem("", dimMainCheck),
+		widget.NewFormItem("Quiet hours from", quietFromEntry),
+		widget.NewFormItem("Quiet hours until", quietUntilEntry),
+		widget.NewFormItem("Quiet during them", quietGroup),
+		widget.NewFormItem("Alert: queue wait over (min)", alertQueueEntry),
+		widget.NewFormItem("Alert: all PCs busy for (min)", alertBusyEntry),
+		widget.NewFormItem("Alert: devices in maintenance over", alertMaintenanceEntry),
+		widget.NewFormItem("Alert webhook URL", alertWebhookEntry),
+		widget.NewFormItem("Receipt email server", smtpHostEntry),
+		widget.NewFormItem("SMTP port", smtpPortEntry),
+		widget.NewFormItem("Send receipts from", smtpFromEntry),
+		widget.NewFormItem("SMTP username", smtpUserEntry),
+		widget.NewFormItem("SMTP password", smtpPasswordEntry),
+		widget.NewFormItem("", testEmailButton),
+		widget.NewFormItem("Backup folder", backupDirEntry),
+		widget.NewFormItem("Keep backups for (days)", backupKeepEntry),
+		widget.NewFormItem("", backupNowButton),
+		widget.NewFormItem("Staff lock", staffLockCheck),
+		widget.NewFormItem("Staff PIN", pinEntry),
+		widget.NewFormItem("Remember PIN for (min)", pinCacheEntry),
+		widget.NewFormItem("Troubleshooting", diagnosticsButton),
+		widget.NewFormItem("Application log level", logLevelSelect),
+	}
+	dlg := dialog.NewForm("Settings", "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		maxQueue, err := parseNonNegativeInt("Max queue length", maxQueueEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		cooldown, err := parseNonNegativeInt("Cooldown between sessions", cooldownEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		queueTimeout, err := parseNonNegativeInt("Queue timeout", queueTimeoutEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		rotation, err := parseNonNegativeInt("Console rotation", rotationEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		cleaning, err := parseNonNegativeInt("Cleaning after checkout", cleaningEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		away, err := parseNonNegativeInt("Away from kiosk", awayEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		sessionLimit, err := parseNonNegativeInt("Session limit", sessionLimitEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		sessionWarning, err := parseNonNegativeInt("Ending soon warning", sessionWarningEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		streakBadge, err := parseNonNegativeInt("Streak badge", streakBadgeEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		pcCap, err := parseNonNegativeInt("PC time per person per day", pcCapEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		consoleCap, err := parseNonNegativeInt("Console time per person per day", consoleCapEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		guestLimit, err := parseNonNegativeInt("Guest visits per term", guestLimitEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		archiveDays, err := parseNonNegativeInt("Archive logs after", archiveEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		retentionMonths, err := parseNonNegativeInt("Keep personal data", retentionEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		evacuationShortcut := strings.TrimSpace(evacuationEntry.Text)
+		if evacuationShortcut != "" {
+			if _, err := parseShortcut(evacuationShortcut); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+		}
+		alertQueue, err := parseNonNegativeInt("Alert: queue wait", alertQueueEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		alertBusy, err := parseNonNegativeInt("Alert: all PCs busy", alertBusyEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		alertMaintenance, err := parseNonNegativeInt("Alert: devices in maintenance", alertMaintenanceEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		pinCache, err := parseNonNegativeInt("Remember PIN for", pinCacheEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		backupKeep, err := parseNonNegativeInt("Keep backups for", backupKeepEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		smtpConfig, err := smtpFormConfig()
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if smtpConfig.Host != "" {
+			if err := smtpConfig.Validate(); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+		}
+		dimFrom, err := parseScheduleTime("Dim display from", dimFromEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		dimUntil, err := parseScheduleTime("Dim display until", dimUntilEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		quietFrom, err := parseScheduleTime("Quiet hours from", quietFromEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		quietUntil, err := parseScheduleTime("Quiet hours until", quietUntilEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		pin := strings.TrimSpace(pinEntry.Text)
+		if pin != "" && len(pin) < 4 {
+			dialog.ShowError(fmt.Errorf("the staff PIN needs at least 4 characters"), mainWindow)
+			return
+		}
+		if staffLockCheck.Checked && pin == "" && appSettings.StaffPINHash == "" {
+			dialog.ShowError(fmt.Errorf("set a staff PIN to lock destructive actions"), mainWindow)
+			return
+		}
+		studentIDPattern := strings.TrimSpace(studentIDEntry.Text)
+		if studentIDPattern != "" {
+			if _, err := compileStudentIDPattern(studentIDPattern); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+		}
+		terms, err := parseTerms(termsEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		closures, err := parseClosures(closuresEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		appSettings.MaxQueueLength = maxQueue
+		appSettings.CooldownMinutes = cooldown
+		appSettings.QueueTimeoutMinutes = queueTimeout
+		appSettings.ConsoleRotationMinutes = rotation
+		appSettings.CleaningCooldownMinutes = cleaning
+		appSettings.AwayMinutes = away
+		appSettings.SessionLimitMinutes = sessionLimit
+		appSettings.SessionWarningMinutes = sessionWarning
+		appSettings.SessionLimitExemptPurposes = parsePurposeOptions(limitExemptEntry.Text)
+		appSettings.StreakBadgeDays = streakBadge
+		appSettings.PCDailyCapMinutes = pcCap
+		appSettings.ConsoleDailyCapMinutes = consoleCap
+		appSettings.DailyCapBlock = capBlockCheck.Checked
+		appSettings.GuestVisitLimit = guestLimit
+		appSettings.StudentIDPattern = studentIDPattern
+		appSettings.DisabledValidators = nil
+		for _, name := range ruleOptions {
+			if !slices.Contains(rulesGroup.Selected, name) {
+				appSettings.DisabledValidators = append(appSettings.DisabledValidators, validatorNames[name])
+			}
+		}
+		appSettings.ArchiveAfterDays = archiveDays
+		appSettings.PurposeOptions = parsePurposeOptions(purposeEntry.Text)
+		appSettings.CourseCodes = parseCourseCodes(courseEntry.Text)
+		appSettings.RemovalReasons = parseRemovalReasons(removalEntry.Text)
+		appSettings.Terms = terms
+		appSettings.Closures = closures
+		appSettings.SheetsCredentialsFile = strings.TrimSpace(credentialsEntry.Text)
+		appSettings.SpreadsheetID = strings.TrimSpace(spreadsheetEntry.Text)
+		appSettings.SheetName = strings.TrimSpace(sheetNameEntry.Text)
+		appSettings.AnonymizeExports = anonymizeCheck.Checked
+		appSettings.RetentionMonths = retentionMonths
+		appSettings.RetentionMode = retentionModeValue(retentionModeSelect.Selected)
+		appSettings.EvacuationShortcut = evacuationShortcut
+		appSettings.DateStyle = formatSetting(dateStyleSelect)
+		appSettings.ClockStyle = formatSetting(clockSelect)
+		appSettings.WeekStart = formatSetting(weekStartSelect)
+		appSettings.CompactMode = compactSelect.Selected
+		appSettings.UIScale = parseScaleOption(uiScaleSelect.Selected)
+		appSettings.DisplayScale = parseScaleOption(displayScaleSelect.Selected)
+		if appSettings.CompactMode == compactAutomatic {
+			appSettings.CompactMode = ""
+		}
+		appSettings.AlertQueueWaitMinutes = alertQueue
+		appSettings.AlertAllBusyMinutes = alertBusy
+		appSettings.AlertMaintenanceDevices = alertMaintenance
+		appSettings.AlertWebhookURL = strings.TrimSpace(alertWebhookEntry.Text)
+		appSettings.LockDestructiveActions = staffLockCheck.Checked
+		if pin != "" {
+			appSettings.StaffPINHash = hashPIN(pin)
+		}
+		appSettings.PINCacheMinutes = pinCache
+		displayAddrChanged := appSettings.DisplayServerAddr != strings.TrimSpace(displayAddrEntry.Text)
+		appSettings.DisplayServerAddr = strings.TrimSpace(displayAddrEntry.Text)
+		appSettings.DimFrom, appSettings.DimUntil = dimFrom, dimUntil
+		appSettings.DimMainWindow = dimMainCheck.Checked
+		appSettings.QuietFrom, appSettings.QuietUntil = quietFrom, quietUntil
+		appSettings.QuietHoursExempt = nil
+		for _, name := range quietOptions {
+			if !slices.Contains(quietGroup.Selected, name) {
+				appSettings.QuietHoursExempt = append(appSettings.QuietHoursExempt, quietIDs[name])
+			}
+		}
+		appSettings.LogLevel = strings.ToLower(logLevelSelect.Selected)
+		appSettings.SMTPHost, appSettings.SMTPPort, appSettings.SMTPFrom = smtpConfig.Host, smtpConfig.Port, smtpConfig.From
+		if !smtpPasswordFromEnv() && !trainingMode && smtpConfig.Password != appSettings.SMTPPassword {
+			if err := writeSMTPPassword(smtpConfig.Password); err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+		}
+		appSettings.SMTPUsername, appSettings.SMTPPassword = smtpConfig.Username, smtpConfig.Password
+		appSettings.BackupDir = strings.TrimSpace(backupDirEntry.Text)
+		appSettings.BackupKeepDays = backupKeep
+		applySettings()
+		if err := saveSettings(); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if err := configureSheetsMirror(); err != nil {
+			dialog.ShowError(fmt.Errorf("google sheets: %w", err), mainWindow)
+		}
+		if displayAddrChanged {
+			if err := configureDisplayServer(); err != nil {
+				dialog.ShowError(err, mainWindow)
+			}
+		}
+		refreshStatsRangeOptions()
+		applyEvacuationShortcut()
+		refreshStaffLockButton()
+		configureAlerts()
+		configureReceipts()
+		dimManual = false
+		applyDimming()
+		rebuildCheckInPanel()
+		refreshTrigger <- true
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(420, dlg.MinSize().Height))
+	dlg.Show()
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/sheets"
+	"lounge/internal/state"
+)
+
+const (
+	sheetsBatchDelay   = 5 * time.Second
+	sheetsMaxAttempts  = 6
+	sheetsTimeLayout   = "2006-01-02 15:04:05"
+	defaultSheetsSheet = "Log"
+)
+
+var sheetsHeaderRow = []string{"Name", "ID", "Device", "Check In", "Check Out", "Duration", "Purpose", "Test Session"}
+
+// sheetsMirror copies today's log to a Google Sheet. Log changes only record
+// the latest snapshot and wake the worker, so the desk never waits on the
+// network; the worker batches bursts and reconciles the whole day against the
+// sheet, which makes a missed or failed write heal on the next change.
+type sheetsMirror struct {
+	mu      sync.Mutex
+	client  *sheets.Client
+	sheet   string
+	pending []state.LogEntry
+	// anon anonymizes rows before they leave the machine; nil sends them as
+	// logged.
+	anon    *state.Anonymizer
+	wake    chan struct{}
+	started sync.Once
+}
+
+var sheetMirror = &sheetsMirror{wake: make(chan struct{}, 1)}
+
+func sheetsConfigured() bool {
+	return appSettings.SheetsCredentialsFile != "" && appSettings.SpreadsheetID != ""
+}
+
+// configureSheetsMirror (re)builds the client from appSettings. With no
+// credentials configured the mirror is disabled and makes no network calls.
+func configureSheetsMirror() error {
+	var client *sheets.Client
+	if sheetsConfigured() {
+		creds, err := sheets.LoadCredentials(appSettings.SheetsCredentialsFile)
+		if err != nil {
+			sheetMirror.setClient(nil, "", nil)
+			return err
+		}
+		client, err = sheets.NewClient(creds, appSettings.SpreadsheetID)
+		if err != nil {
+			sheetMirror.setClient(nil, "", nil)
+			return err
+		}
+	}
+	sheetName := appSettings.SheetName
+	if sheetName == "" {
+		sheetName = defaultSheetsSheet
+	}
+	anon, err := exportAnonymizerFor(appSettings.AnonymizeExports)
+	if err != nil {
+		sheetMirror.setClient(nil, "", nil)
+		return err
+	}
+	sheetMirror.setClient(client, sheetName, anon)
+	if client != nil {
+		sheetMirror.started.Do(func() { go sheetMirror.run() })
+	}
+	return nil
+}
+
+func (m *sheetsMirror) setClient(client *sheets.Client, sheetName string, anon *state.Anonymizer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.client = client
+	m.sheet = sheetName
+	m.anon = anon
+}
+
+func (m *sheetsMirror) enabled() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.client != nil
+}
+
+// enqueue records today's entries for the next batch. It never blocks.
+func (m *sheetsMirror) enqueue(entries []state.LogEntry) {
+	m.mu.Lock()
+	if m.client == nil {
+		m.mu.Unlock()
+		return
+	}
+	m.pending = append([]state.LogEntry(nil), entries...)
+	m.mu.Unlock()
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *sheetsMirror) run() {
+	for range m.wake {
+		time.Sleep(sheetsBatchDelay)
+		m.mu.Lock()
+		client, sheetName, entries, anon := m.client, m.sheet, m.pending, m.anon
+		m.pending = nil
+		m.mu.Unlock()
+		if client == nil || entries == nil {
+			continue
+		}
+		if anon != nil {
+			entries = anon.Entries(entries)
+		}
+		if err := syncSheetWithBackoff(client, sheetName, entries); err != nil {
+			slog.Error("syncing Google Sheet", "err", err)
+		}
+	}
+}
+
+// syncToday reconciles the sheet against today's log file right away.
+func (m *sheetsMirror) syncToday() error {
+	m.mu.Lock()
+	client, sheetName, anon := m.client, m.sheet, m.anon
+	m.mu.Unlock()
+	if client == nil {
+		return fmt.Errorf("google sheets sync is not configured")
+	}
+	entries, err := store.ReadDailyLogEntriesLocked()
+	if err != nil {
+		return err
+	}
+	if anon != nil {
+		entries = anon.Entries(entries)
+	}
+	return syncSheetWithBackoff(client, sheetName, entries)
+}
+
+func syncSheetWithBackoff(client *sheets.Client, sheetName string, entries []state.LogEntry) error {
+	delay := 2 * time.Second
+	var err error
+	for attempt := 1; attempt <= sheetsMaxAttempts; attempt++ {
+		err = reconcileSheet(client, sheetName, entries)
+		if err == nil || !sheets.Retryable(err) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return err
+}
+
+func sheetRange(sheetName, cells string) string {
+	return "'" + strings.ReplaceAll(sheetName, "'", "''") + "'!" + cells
+}
+
+func sheetRowKey(userID, checkIn string) string { return userID + "|" + checkIn }
+
+func sheetRowForEntry(entry state.LogEntry) []string {
+	device := "Queue"
+	if entry.PCID != 0 {
+		device = entry.DevicePath()
+	}
+	out, duration := "", ""
+	if !entry.CheckOutTime.IsZero() {
+		out = entry.CheckOutTime.Local().Format(sheetsTimeLayout)
+		if d, ok := entry.SessionDuration(entry.CheckOutTime); ok {
+			duration = state.FormatDuration(d)
+		} else {
+			duration = "clock changed"
+		}
+	}
+	test := ""
+	if entry.ExcludeFromStats {
+		test = "yes"
+	}
+	return []string{entry.UserName, entry.UserID, device, entry.CheckInTime.Local().Format(sheetsTimeLayout), out, duration, entry.Purpose, test}
+}
+
+func sheetRowsEqual(a, b []string) bool {
+	for i := range sheetsHeaderRow {
+		var x, y string
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileSheet appends entries missing from the sheet and rewrites rows whose
+// device or checkout changed. Rows are matched on user ID and check-in time.
+func reconcileSheet(client *sheets.Client, sheetName string, entries []state.LogEntry) error {
+	rows, err := client.Values(sheetRange(sheetName, "A:H"))
+	if err != nil {
+		return err
+	}
+	var appends [][]string
+	if len(rows) == 0 {
+		appends = append(appends, sheetsHeaderRow)
+	}
+	rowByKey := make(map[string]int, len(rows))
+	for i, row := range rows {
+		if i == 0 || len(row) < 4 {
+			continue
+		}
+		rowByKey[sheetRowKey(row[1], row[3])] = i
+	}
+	var updates []sheets.ValueRange
+	for _, entry := range entries {
+		if !entry.IsSession() {
+			continue
+		}
+		row := sheetRowForEntry(entry)
+		idx, ok := rowByKey[sheetRowKey(row[1], row[3])]
+		if !ok {
+			appends = append(appends, row)
+			continue
+		}
+		if !sheetRowsEqual(rows[idx], row) {
+			updates = append(updates, sheets.ValueRange{
+				Range:  sheetRange(sheetName, fmt.Sprintf("A%d:H%d", idx+1, idx+1)),
+				Values: [][]string{row},
+			})
+		}
+	}
+	if err := client.BatchUpdate(updates); err != nil {
+		return err
+	}
+	return client.Append(sheetRange(sheetName, "A:H"), appends)
+}
+
+func showSyncTodayDialog() {
+	progress := dialog.NewCustomWithoutButtons("Google Sheets", widget.NewLabel("Syncing today's log..."), mainWindow)
+	progress.Show()
+	go func() {
+		err := sheetMirror.syncToday()
+		fyne.Do(func() {
+			progress.Hide()
+			if err != nil {
+				dialog.ShowError(err, mainWindow)
+				return
+			}
+			dialog.ShowInformation("Google Sheets", "Today's log is in sync.", mainWindow)
+		})
+	}()
+}
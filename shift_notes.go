@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// shiftNoteInitials signs the shift notes written or resolved on this desk.
+// There are no staff logins, so it is whatever the staffer on shift typed
+// last; it is kept until the app closes.
+var shiftNoteInitials string
+
+// shiftNoteText describes a note like "Tue 14:05 AB: printer jammed again".
+// Notes carried from an earlier day show their date.
+func shiftNoteText(note state.ShiftNote) string {
+	when := formatClock(note.Time)
+	if note.Time.Format("2006-01-02") != state.TodaysLogDate() {
+		when = note.Time.Local().Format("Mon "+dateLayout()+" ") + when
+	}
+	text := fmt.Sprintf("%s %s: %s", when, note.By, note.Text)
+	if !note.Open() {
+		text += fmt.Sprintf("  (resolved %s by %s)", formatClock(note.Resolved), note.ResolvedBy)
+	}
+	return text
+}
+
+func showShiftNotesDialog() {
+	initials := widget.NewEntry()
+	initials.SetText(shiftNoteInitials)
+	initials.SetPlaceHolder("Initials")
+	initials.OnChanged = func(text string) { shiftNoteInitials = strings.TrimSpace(text) }
+	noteEntry := widget.NewMultiLineEntry()
+	noteEntry.SetPlaceHolder("e.g. PS5 controller #3 drifting")
+	noteEntry.SetMinRowsVisible(2)
+	noteEntry.Wrapping = fyne.TextWrapWord
+
+	list := container.NewVBox()
+	var show func()
+	show = func() {
+		list.RemoveAll()
+		notes, err := store.ShiftNotes()
+		if err != nil {
+			slog.Error("reading shift notes", "err", err)
+			list.Add(widget.NewLabel("The shift log could not be read: " + err.Error()))
+			return
+		}
+		if len(notes) == 0 {
+			list.Add(widget.NewLabel("No notes yet."))
+		}
+		for _, note := range notes {
+			list.Add(shiftNoteRow(note, show))
+		}
+	}
+	add := widget.NewButton("Add Note", func() {
+		if err := store.AddShiftNote(shiftNoteInitials, noteEntry.Text); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		noteEntry.SetText("")
+		show()
+	})
+	writerOnly(add)
+	show()
+
+	form := container.NewBorder(nil, nil, container.NewGridWrap(fyne.NewSize(90, initials.MinSize().Height), initials), add, noteEntry)
+	content := container.NewBorder(form, nil, nil, nil, container.NewVScroll(list))
+	dlg := dialog.NewCustom("Shift Notes", "Close", content, mainWindow)
+	dlg.Resize(fyne.NewSize(600, 480))
+	dlg.Show()
+}
+
+// shiftNoteRow shows one note, with a Resolve button while it is open.
+func shiftNoteRow(note state.ShiftNote, changed func()) fyne.CanvasObject {
+	label := widget.NewLabel(shiftNoteText(note))
+	label.Wrapping = fyne.TextWrapWord
+	if !note.Open() {
+		label.Importance = widget.LowImportance
+		return label
+	}
+	resolve := widget.NewButton("Resolve", func() {
+		if err := store.ResolveShiftNote(note.ID, shiftNoteInitials); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		changed()
+	})
+	writerOnly(resolve)
+	return container.NewBorder(nil, nil, nil, resolve, label)
+}
+
+// openShiftNotes lists the unresolved shift notes for the handover, or ""
+// when there are none.
+func openShiftNotes() string {
+	notes, err := store.ShiftNotes()
+	if err != nil {
+		slog.Error("reading shift notes", "err", err)
+		return ""
+	}
+	var b strings.Builder
+	for _, note := range notes {
+		if note.Open() {
+			fmt.Fprintf(&b, "  %s\n", shiftNoteText(note))
+		}
+	}
+	return b.String()
+}
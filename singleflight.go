@@ -0,0 +1,49 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// deviceTapDebounce is how long repeat taps on a device are ignored while the
+// dialog the first tap opened is still showing.
+const deviceTapDebounce = 750 * time.Millisecond
+
+// deviceDialogsOpenedAt maps a device ID to when its open dialog was shown.
+var deviceDialogsOpenedAt = map[int]time.Time{}
+
+// newSingleFlightButton returns a button that disables itself while run is in
+// progress, so a fast double-click cannot fire the action twice. run must call
+// done once the action is over, which may be after a follow-up dialog closes.
+func newSingleFlightButton(label string, icon fyne.Resource, run func(done func())) *widget.Button {
+	var btn *widget.Button
+	btn = widget.NewButtonWithIcon(label, icon, func() {
+		if btn.Disabled() {
+			return
+		}
+		btn.Disable()
+		finished := false
+		run(func() {
+			if !finished {
+				finished = true
+				btn.Enable()
+			}
+		})
+	})
+	return btn
+}
+
+// trackDeviceDialog records that dlg was opened by tapping deviceID until it
+// closes.
+func trackDeviceDialog(deviceID int, dlg dialog.Dialog) {
+	deviceDialogsOpenedAt[deviceID] = time.Now()
+	dlg.SetOnClosed(func() { delete(deviceDialogsOpenedAt, deviceID) })
+}
+
+func deviceDialogRecentlyOpened(deviceID int) bool {
+	openedAt, ok := deviceDialogsOpenedAt[deviceID]
+	return ok && time.Since(openedAt) < deviceTapDebounce
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lounge/internal/state"
+)
+
+// smtpPasswordEnv, when set, is the SMTP password; the settings dialog then
+// cannot change it.
+const smtpPasswordEnv = "LOUNGE_SMTP_PASSWORD"
+
+// The SMTP password is kept out of settings.json, which sits in the shared
+// data folder and is copied into every backup. Without smtpPasswordEnv it
+// is stored per user, readable by that user only, under the OS's config
+// folder (e.g. %AppData%\lounge on Windows).
+
+// smtpPasswordFile is where the settings dialog stores the SMTP password, or
+// "" when the OS has no config folder.
+var smtpPasswordFile = func() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "lounge", "smtp-password")
+}
+
+// smtpPasswordFromEnv reports whether smtpPasswordEnv sets the password.
+func smtpPasswordFromEnv() bool {
+	_, ok := os.LookupEnv(smtpPasswordEnv)
+	return ok
+}
+
+// readSMTPPassword returns the SMTP password from smtpPasswordEnv or the
+// password file; "" when neither has one.
+func readSMTPPassword() string {
+	if password, ok := os.LookupEnv(smtpPasswordEnv); ok {
+		return password
+	}
+	path := smtpPasswordFile()
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Error("reading SMTP password", "err", err)
+		}
+		return ""
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// writeSMTPPassword stores password in the password file; "" removes it.
+func writeSMTPPassword(password string) error {
+	path := smtpPasswordFile()
+	if path == "" {
+		return fmt.Errorf("store SMTP password: no config folder; set %s instead", smtpPasswordEnv)
+	}
+	if password == "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove SMTP password: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("store SMTP password: %w", err)
+	}
+	if err := state.WriteFileAtomic(path, []byte(password), 0o600); err != nil {
+		return fmt.Errorf("store SMTP password: %w", err)
+	}
+	return nil
+}
+
+// settingsSMTPPassword is the password older versions kept in settings.json,
+// if data has one.
+func settingsSMTPPassword(data []byte) string {
+	var legacy struct {
+		SMTPPassword string `json:"smtp_password"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return ""
+	}
+	return legacy.SMTPPassword
+}
+
+// moveSMTPPasswordOutOfSettings moves a password left in settings.json by an
+// older version to the password file and rewrites the settings without it.
+// If it cannot be stored it stays where it is, so receipts keep working.
+func moveSMTPPasswordOutOfSettings() {
+	data, err := os.ReadFile(settingsFile)
+	if err != nil {
+		return
+	}
+	legacy := settingsSMTPPassword(data)
+	if legacy == "" {
+		return
+	}
+	if !smtpPasswordFromEnv() && readSMTPPassword() == "" {
+		if err := writeSMTPPassword(legacy); err != nil {
+			slog.Error("moving SMTP password out of settings", "err", err)
+			return
+		}
+	}
+	if err := saveSettings(); err != nil {
+		slog.Error("rewriting settings without the SMTP password", "err", err)
+		return
+	}
+	slog.Info("moved SMTP password out of settings", "file", smtpPasswordFile())
+}
+
+// smtpFormPassword is the password the settings dialog's entry stands for:
+// the environment's when smtpPasswordEnv sets it, which the entry hides.
+func smtpFormPassword(entered string) string {
+	if password, ok := os.LookupEnv(smtpPasswordEnv); ok {
+		return password
+	}
+	return entered
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"lounge/internal/state"
+)
+
+// useSMTPPasswordTestFiles points the settings and password files into a
+// temporary folder, with settings holding settingsJSON.
+func useSMTPPasswordTestFiles(t *testing.T, settingsJSON string) (passwordFile string) {
+	t.Helper()
+	savedSettings, savedPassword, savedStore, savedApp := settingsFile, smtpPasswordFile, store, appSettings
+	t.Cleanup(func() {
+		settingsFile, smtpPasswordFile, store, appSettings = savedSettings, savedPassword, savedStore, savedApp
+	})
+	dir := t.TempDir()
+	settingsFile = filepath.Join(dir, "log", "settings.json")
+	passwordFile = filepath.Join(dir, "config", "lounge", "smtp-password")
+	smtpPasswordFile = func() string { return passwordFile }
+	store = state.NewStore(filepath.Join(dir, "log"), "")
+	if err := os.MkdirAll(filepath.Dir(settingsFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settingsFile, []byte(settingsJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return passwordFile
+}
+
+func TestSMTPPasswordMovesOutOfSettings(t *testing.T) {
+	passwordFile := useSMTPPasswordTestFiles(t, `{"smtp_host": "smtp.example.edu", "smtp_password": "hunter2"}`)
+	loadSettings()
+	if appSettings.SMTPPassword != "hunter2" {
+		t.Fatalf("password before the move = %q", appSettings.SMTPPassword)
+	}
+	moveSMTPPasswordOutOfSettings()
+
+	if data, _ := os.ReadFile(settingsFile); strings.Contains(string(data), "hunter2") {
+		t.Fatalf("settings still hold the password:\n%s", data)
+	}
+	info, err := os.Stat(passwordFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Fatalf("password file mode = %v, want 0600", info.Mode().Perm())
+	}
+	loadSettings()
+	if appSettings.SMTPPassword != "hunter2" || appSettings.SMTPHost != "smtp.example.edu" {
+		t.Fatalf("after the move got host %q, password %q", appSettings.SMTPHost, appSettings.SMTPPassword)
+	}
+}
+
+func TestSMTPPasswordNeverSaved(t *testing.T) {
+	passwordFile := useSMTPPasswordTestFiles(t, `{}`)
+	loadSettings()
+	appSettings.SMTPPassword = "hunter2"
+	if err := saveSettings(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(settingsFile); strings.Contains(string(data), "hunter2") {
+		t.Fatalf("settings hold the password:\n%s", data)
+	}
+	if err := writeSMTPPassword(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(passwordFile); !os.IsNotExist(err) {
+		t.Fatalf("clearing the password left the file: %v", err)
+	}
+}
+
+func TestSMTPPasswordFromEnv(t *testing.T) {
+	passwordFile := useSMTPPasswordTestFiles(t, `{"smtp_password": "old"}`)
+	t.Setenv(smtpPasswordEnv, "from-env")
+	loadSettings()
+	if appSettings.SMTPPassword != "from-env" {
+		t.Fatalf("password = %q, want the environment's", appSettings.SMTPPassword)
+	}
+	moveSMTPPasswordOutOfSettings()
+	if _, err := os.Stat(passwordFile); !os.IsNotExist(err) {
+		t.Fatalf("the environment's password was stored: %v", err)
+	}
+	if data, _ := os.ReadFile(settingsFile); strings.Contains(string(data), "old") {
+		t.Fatalf("settings still hold the old password:\n%s", data)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"log/slog"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// membersLoadingText stands in for search results until the members load.
+const membersLoadingText = "Loading members…"
+
+var (
+	startupProgress *fyne.Container
+	startupActivity *widget.Activity
+	// membersWaiting run once the background load has put the members in
+	// place.
+	membersWaiting []func()
+)
+
+// newStartupProgress sits in the status bar while loadInBackground runs.
+func newStartupProgress() fyne.CanvasObject {
+	startupActivity = widget.NewActivity()
+	startupActivity.Start()
+	startupProgress = container.NewHBox(startupActivity, widget.NewLabel("Loading members and today's log…"))
+	return startupProgress
+}
+
+// loadInBackground reads the member file and the selected day's log off the
+// UI goroutine, so the window opens straight away on a large member file.
+// The state is already loaded; until the members are, searches say so and
+// anything that needs them waits in whenMembersReady.
+func loadInBackground() {
+	date := selectedLogDate
+	go func() {
+		members := store.ReadMembers()
+		entries, err := store.ReadLogEntries(date)
+		dates := store.ListAvailableLogDates()
+		fyne.Do(func() {
+			// A read-only reload may have beaten us to it with newer rows.
+			if !store.MembersLoaded {
+				store.ApplyMembers(members)
+			}
+			if err != nil {
+				slog.Error("updating log cache", "err", err)
+				entries = []state.LogEntry{}
+			}
+			// A check-in in the meantime already brought the log up to date.
+			if todaysLogEntries == nil {
+				rememberTodaysLog(date, entries)
+			}
+			if selectedLogDate == date && currentLogEntries == nil {
+				currentLogEntries = entries
+				refreshDisplayedLogEntries()
+				if logList != nil {
+					logList.Refresh()
+				}
+			}
+			if logDateSelect != nil {
+				logDateSelect.Options = dates
+				logDateSelect.Refresh()
+			}
+			startupActivity.Stop()
+			startupProgress.Hide()
+			waiting := membersWaiting
+			membersWaiting = nil
+			for _, fn := range waiting {
+				fn()
+			}
+			refreshTrigger <- true
+		})
+	}()
+}
+
+// whenMembersReady runs fn now, or once the members have loaded, for actions
+// that would go wrong without them, such as a check-in that adds an unknown
+// ID to the member file.
+func whenMembersReady(fn func()) {
+	if store.MembersLoaded {
+		fn()
+		return
+	}
+	membersWaiting = append(membersWaiting, fn)
+}
+
+// membersStillLoading reports whether the members are still loading and, if
+// so, has rerun repeat the search once they are.
+func membersStillLoading(rerun func()) bool {
+	if store.MembersLoaded {
+		return false
+	}
+	whenMembersReady(rerun)
+	return true
+}
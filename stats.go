@@ -0,0 +1,432 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+const (
+	statsRangeToday    = "Today"
+	statsRangeThisWeek = "This week"
+)
+
+var (
+	occupancyChartWidget *occupancyChart
+	purposeStatsGrid     *fyne.Container
+	purposeStatsHeader   *widget.Label
+	statsRangeSelect     *widget.Select
+	selectedStatsRange   = statsRangeToday
+	// statsIncludeTest counts staff and test sessions in Stats and the
+	// report export.
+	statsIncludeTest bool
+)
+
+// occupancyChart plots a day's occupancy samples as line series drawn with
+// plain canvas lines.
+type occupancyChart struct {
+	widget.BaseWidget
+	samples []state.OccupancySample
+}
+
+func newOccupancyChart() *occupancyChart {
+	c := &occupancyChart{}
+	c.ExtendBaseWidget(c)
+	return c
+}
+
+func (c *occupancyChart) SetSamples(samples []state.OccupancySample) {
+	c.samples = samples
+	c.Refresh()
+}
+
+type occupancyChartRenderer struct {
+	chart   *occupancyChart
+	size    fyne.Size
+	objects []fyne.CanvasObject
+}
+
+func (c *occupancyChart) CreateRenderer() fyne.WidgetRenderer {
+	return &occupancyChartRenderer{chart: c}
+}
+
+func (r *occupancyChartRenderer) Layout(size fyne.Size) {
+	r.size = size
+	r.rebuild()
+}
+
+func (r *occupancyChartRenderer) MinSize() fyne.Size           { return fyne.NewSize(480, 260) }
+func (r *occupancyChartRenderer) Refresh()                     { r.rebuild(); canvas.Refresh(r.chart) }
+func (r *occupancyChartRenderer) Objects() []fyne.CanvasObject { return r.objects }
+func (r *occupancyChartRenderer) Destroy()                     {}
+
+type chartSeries struct {
+	name  string
+	color color.Color
+	value func(state.OccupancySample) int
+}
+
+var occupancySeries = []chartSeries{
+	{name: "PCs occupied", color: lattePrimary, value: func(s state.OccupancySample) int { return s.OccupiedPCs }},
+	{name: "Console players", color: latteGreen, value: func(s state.OccupancySample) int { return s.ConsolePlayers }},
+	{name: "Queue", color: latteAccent, value: func(s state.OccupancySample) int { return s.QueueLength }},
+	{name: "Lounge visitors", color: latteSecondary, value: func(s state.OccupancySample) int { return s.LoungeVisitors }},
+}
+
+func (r *occupancyChartRenderer) rebuild() {
+	const (
+		left   float32 = 36
+		right  float32 = 12
+		top    float32 = 24
+		bottom float32 = 24
+	)
+	r.objects = r.objects[:0]
+	plotW := r.size.Width - left - right
+	plotH := r.size.Height - top - bottom
+	if plotW <= 0 || plotH <= 0 {
+		return
+	}
+
+	maxY := 0
+	for _, d := range store.Devices {
+		if d.Type == "PC" {
+			maxY++
+		}
+	}
+	for _, s := range r.chart.samples {
+		for _, series := range occupancySeries {
+			maxY = max(maxY, series.value(s))
+		}
+	}
+	maxY = max(maxY, 1)
+
+	start, end := chartTimeRange(r.chart.samples)
+	span := float32(end.Sub(start))
+	toPoint := func(s state.OccupancySample, v int) fyne.Position {
+		x := left + plotW*float32(s.Time.Sub(start))/span
+		y := top + plotH - plotH*float32(v)/float32(maxY)
+		return fyne.NewPos(x, y)
+	}
+
+	axisColor := latteOverlay2
+	r.addLine(fyne.NewPos(left, top), fyne.NewPos(left, top+plotH), axisColor, 1)
+	r.addLine(fyne.NewPos(left, top+plotH), fyne.NewPos(left+plotW, top+plotH), axisColor, 1)
+	for _, v := range []int{0, maxY / 2, maxY} {
+		y := top + plotH - plotH*float32(v)/float32(maxY)
+		r.addLine(fyne.NewPos(left, y), fyne.NewPos(left+plotW, y), latteCrust, 1)
+		r.addText(fmt.Sprintf("%d", v), fyne.NewPos(4, y-8), latteSubtext1)
+	}
+	for t := start; !t.After(end); t = t.Add(time.Hour) {
+		x := left + plotW*float32(t.Sub(start))/span
+		r.addText(formatClock(t), fyne.NewPos(x-14, top+plotH+4), latteSubtext1)
+	}
+
+	legendX := left
+	for _, series := range occupancySeries {
+		r.addLine(fyne.NewPos(legendX, 10), fyne.NewPos(legendX+14, 10), series.color, 3)
+		label := r.addText(series.name, fyne.NewPos(legendX+18, 2), latteText)
+		legendX += 18 + label.MinSize().Width + 16
+	}
+
+	if len(r.chart.samples) == 0 {
+		r.addText("No occupancy samples recorded today yet.", fyne.NewPos(left+12, top+plotH/2-8), latteSubtext1)
+		return
+	}
+	for _, series := range occupancySeries {
+		prev := toPoint(r.chart.samples[0], series.value(r.chart.samples[0]))
+		for _, s := range r.chart.samples[1:] {
+			next := toPoint(s, series.value(s))
+			r.addLine(prev, next, series.color, 2)
+			prev = next
+		}
+	}
+}
+
+func (r *occupancyChartRenderer) addLine(from, to fyne.Position, c color.Color, width float32) {
+	line := canvas.NewLine(c)
+	line.StrokeWidth = width
+	line.Position1 = from
+	line.Position2 = to
+	r.objects = append(r.objects, line)
+}
+
+func (r *occupancyChartRenderer) addText(text string, pos fyne.Position, c color.Color) *canvas.Text {
+	label := canvas.NewText(text, c)
+	label.TextSize = 10
+	label.Move(pos)
+	label.Resize(label.MinSize())
+	r.objects = append(r.objects, label)
+	return label
+}
+
+// chartTimeRange spans whole hours around the samples, at least one hour wide.
+func chartTimeRange(samples []state.OccupancySample) (time.Time, time.Time) {
+	if len(samples) == 0 {
+		start := time.Now().Truncate(time.Hour)
+		return start, start.Add(time.Hour)
+	}
+	start := samples[0].Time.Truncate(time.Hour)
+	end := samples[len(samples)-1].Time.Truncate(time.Hour).Add(time.Hour)
+	return start, end
+}
+
+func refreshOccupancyChart() {
+	if occupancyChartWidget == nil {
+		return
+	}
+	samples, err := store.ReadOccupancySamples(state.TodaysLogDate())
+	if err != nil {
+		slog.Error("reading occupancy", "err", err)
+		samples = []state.OccupancySample{}
+	}
+	occupancyChartWidget.SetSamples(samples)
+}
+
+// selectedStatsTerm returns the term picked in the Stats range select, or
+// false for Today. This week is a term from the configured first day of the
+// week through today.
+func selectedStatsTerm() (state.Term, bool) {
+	if selectedStatsRange == statsRangeThisWeek {
+		now := time.Now()
+		return state.Term{Name: statsRangeThisWeek, Start: startOfWeek(now).Format("2006-01-02"), End: now.Format("2006-01-02")}, true
+	}
+	for _, term := range appSettings.Terms {
+		if term.Name == selectedStatsRange {
+			return term, true
+		}
+	}
+	return state.Term{}, false
+}
+
+// statsRangeEntries reads the log entries for the selected Stats range,
+// leaving out test sessions unless statsIncludeTest; excluded counts those
+// left out.
+func statsRangeEntries() (entries []state.LogEntry, excluded int, err error) {
+	if term, ok := selectedStatsTerm(); ok {
+		entries, err = store.ReadLogEntriesInRange(term.Start, term.End)
+	} else {
+		entries, err = store.ReadDailyLogEntriesLocked()
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	store.ClassifyVisitors(entries)
+	if statsIncludeTest {
+		return state.StatsEntries(entries, true), 0, nil
+	}
+	return state.StatsEntries(entries, false), state.TestSessions(entries), nil
+}
+
+// openDayRows are the selected range's averages per open day as label and
+// value, leaving closure days out; Today has none.
+func openDayRows(entries []state.LogEntry) [][2]string {
+	term, ok := selectedStatsTerm()
+	if !ok {
+		return nil
+	}
+	return termOpenDayRows(term, entries)
+}
+
+// termOpenDayRows are openDayRows for any range of days.
+func termOpenDayRows(term state.Term, entries []state.LogEntry) [][2]string {
+	averages := store.OpenDayAverages(term.Start, term.End, entries, time.Now())
+	utilization := "-"
+	if averages.PCUtilization >= 0 {
+		utilization = fmt.Sprintf("%.0f%%", averages.PCUtilization*100)
+	}
+	openDays := fmt.Sprintf("%d", averages.OpenDays)
+	if averages.ClosedDays > 0 {
+		openDays = fmt.Sprintf("%d (%d closed)", averages.OpenDays, averages.ClosedDays)
+	}
+	return [][2]string{
+		{"Open days", openDays},
+		{"Visits per open day", fmt.Sprintf("%.1f", averages.VisitsPerDay)},
+		{"Hours per open day", fmt.Sprintf("%.1f", averages.UsagePerDay.Hours())},
+		{"PC utilization", utilization},
+	}
+}
+
+func refreshStatsRangeOptions() {
+	if statsRangeSelect == nil {
+		return
+	}
+	options := []string{statsRangeToday, statsRangeThisWeek}
+	for _, term := range appSettings.Terms {
+		options = append(options, term.Name)
+	}
+	statsRangeSelect.Options = options
+	if _, ok := selectedStatsTerm(); !ok {
+		selectedStatsRange = statsRangeToday
+	}
+	statsRangeSelect.SetSelected(selectedStatsRange)
+}
+
+// refreshPurposeStats fills the per-purpose table with visits and hours for
+// the selected range.
+func refreshPurposeStats() {
+	if purposeStatsGrid == nil {
+		return
+	}
+	entries, excluded, err := statsRangeEntries()
+	if err != nil {
+		slog.Error("reading log for stats", "err", err)
+		entries = []state.LogEntry{}
+	}
+	if term, ok := selectedStatsTerm(); ok {
+		purposeStatsHeader.SetText(fmt.Sprintf("%s (%s..%s) by Purpose", term.Name, term.Start, term.End))
+	} else {
+		purposeStatsHeader.SetText("Today by Purpose")
+	}
+	bold := fyne.TextStyle{Bold: true}
+	objects := []fyne.CanvasObject{
+		widget.NewLabelWithStyle("Purpose", fyne.TextAlignLeading, bold),
+		widget.NewLabelWithStyle("Visits", fyne.TextAlignTrailing, bold),
+		widget.NewLabelWithStyle("Hours", fyne.TextAlignTrailing, bold),
+	}
+	addRows := func(usages []state.PurposeUsage) {
+		for _, usage := range usages {
+			objects = append(objects,
+				widget.NewLabel(usage.Purpose),
+				widget.NewLabelWithStyle(fmt.Sprintf("%d", usage.Visits), fyne.TextAlignTrailing, fyne.TextStyle{}),
+				widget.NewLabelWithStyle(fmt.Sprintf("%.1f", usage.Usage.Hours()), fyne.TextAlignTrailing, fyne.TextStyle{}),
+			)
+		}
+	}
+	addSection := func(title string, usages []state.PurposeUsage) {
+		objects = append(objects,
+			widget.NewLabelWithStyle(title, fyne.TextAlignLeading, bold),
+			widget.NewLabel(""),
+			widget.NewLabel(""),
+		)
+		addRows(usages)
+	}
+	addRows(state.UsageByPurpose(entries, time.Now()))
+	// Event vs open-hours usage only means something once an event has run.
+	if byEvent := state.UsageByEvent(entries, time.Now()); len(byEvent) > 1 || (len(byEvent) == 1 && byEvent[0].Purpose != state.OpenHours) {
+		addSection("Event", byEvent)
+	}
+	addSection("Source", state.UsageBySource(entries, time.Now()))
+	addSection("Visitor type", state.UsageByVisitorType(entries, time.Now()))
+	// Exempt sessions are worth a section only once someone has one.
+	if byLimit := state.UsageBySessionLimit(entries, time.Now()); len(byLimit) > 1 || (len(byLimit) == 1 && byLimit[0].Purpose == state.LimitExempt) {
+		addSection("Session limit", byLimit)
+	}
+	if statsIncludeLounge {
+		objects = append(objects,
+			widget.NewLabelWithStyle("Lounge visitors (no device)", fyne.TextAlignLeading, bold),
+			widget.NewLabelWithStyle(fmt.Sprintf("%d", state.LoungeVisits(entries)), fyne.TextAlignTrailing, fyne.TextStyle{}),
+			widget.NewLabelWithStyle("-", fyne.TextAlignTrailing, fyne.TextStyle{}),
+		)
+	}
+	if rows := openDayRows(entries); rows != nil {
+		objects = append(objects,
+			widget.NewLabelWithStyle("Per open day", fyne.TextAlignLeading, bold),
+			widget.NewLabel(""),
+			widget.NewLabel(""),
+		)
+		for _, row := range rows {
+			objects = append(objects,
+				widget.NewLabel(row[0]),
+				widget.NewLabelWithStyle(row[1], fyne.TextAlignTrailing, fyne.TextStyle{}),
+				widget.NewLabel(""),
+			)
+		}
+	}
+	if waits := state.QueueWaits(entries); waits.Seated > 0 {
+		objects = append(objects,
+			widget.NewLabelWithStyle("Wait to seat", fyne.TextAlignLeading, bold),
+			widget.NewLabel(""),
+			widget.NewLabel(""),
+		)
+		for _, row := range [][2]string{
+			{"Seated from the queue", fmt.Sprintf("%d", waits.Seated)},
+			{"Median wait", state.FormatMinutes(waits.Median)},
+			{"90th percentile wait", state.FormatMinutes(waits.P90)},
+		} {
+			objects = append(objects,
+				widget.NewLabel(row[0]),
+				widget.NewLabelWithStyle(row[1], fyne.TextAlignTrailing, fyne.TextStyle{}),
+				widget.NewLabel(""),
+			)
+		}
+		if _, ok := selectedStatsTerm(); ok {
+			objects = append(objects, waitPeriodRows("Wait by day", state.QueueWaitsBy(entries, startOfDay), func(t time.Time) string {
+				return t.Format("Mon " + dateLayout())
+			})...)
+			if weeks := state.QueueWaitsBy(entries, startOfWeek); len(weeks) > 1 {
+				objects = append(objects, waitPeriodRows("Wait by week", weeks, func(t time.Time) string {
+					return "Week of " + t.Format(dateLayout())
+				})...)
+			}
+		}
+	}
+	if excluded > 0 {
+		objects = append(objects,
+			widget.NewLabelWithStyle("Test sessions (not counted)", fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
+			widget.NewLabelWithStyle(fmt.Sprintf("%d", excluded), fyne.TextAlignTrailing, fyne.TextStyle{Italic: true}),
+			widget.NewLabelWithStyle("-", fyne.TextAlignTrailing, fyne.TextStyle{}),
+		)
+	}
+	purposeStatsGrid.Objects = objects
+	purposeStatsGrid.Refresh()
+}
+
+func buildStatsView() fyne.CanvasObject {
+	occupancyChartWidget = newOccupancyChart()
+	refreshOccupancyChart()
+	purposeStatsGrid = container.NewGridWithColumns(3)
+	purposeStatsHeader = widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	statsRangeSelect = widget.NewSelect(nil, func(name string) {
+		selectedStatsRange = name
+		refreshPurposeStats()
+	})
+	refreshStatsRangeOptions()
+	refreshPurposeStats()
+	exportButton := widget.NewButtonWithIcon("Export Report", theme.DownloadIcon(), showExportReportDialog)
+	courseButton := widget.NewButton("Course Hours", showCourseReportDialog)
+	streaksButton := widget.NewButton("Streaks CSV", exportStreakLeaderboard)
+	loungeCheck := widget.NewCheck("Lounge visitors", func(on bool) {
+		statsIncludeLounge = on
+		refreshPurposeStats()
+	})
+	loungeCheck.SetChecked(statsIncludeLounge)
+	testCheck := widget.NewCheck("Include test sessions", func(on bool) {
+		statsIncludeTest = on
+		refreshPurposeStats()
+	})
+	testCheck.SetChecked(statsIncludeTest)
+	header := widget.NewLabelWithStyle("Today's Occupancy", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	note := widget.NewLabel(fmt.Sprintf("Sampled every %d minutes.", int(state.OccupancySampleInterval.Minutes())))
+	rangeBar := container.NewHBox(purposeStatsHeader, layout.NewSpacer(), widget.NewLabel("Range:"), statsRangeSelect, loungeCheck, testCheck, courseButton, streaksButton, exportButton)
+	purposeCard := container.NewVBox(widget.NewSeparator(), rangeBar, purposeStatsGrid)
+	return container.NewBorder(container.NewVBox(header, note), purposeCard, nil, nil, occupancyChartWidget)
+}
+
+// waitPeriodRows lists the median and 90th percentile wait of each period
+// under title, labelling each period with label(start).
+func waitPeriodRows(title string, periods []state.PeriodWaits, label func(time.Time) string) []fyne.CanvasObject {
+	bold := fyne.TextStyle{Bold: true}
+	objects := []fyne.CanvasObject{
+		widget.NewLabelWithStyle(title, fyne.TextAlignLeading, bold),
+		widget.NewLabelWithStyle("Median", fyne.TextAlignTrailing, bold),
+		widget.NewLabelWithStyle("90th pct", fyne.TextAlignTrailing, bold),
+	}
+	for _, period := range periods {
+		objects = append(objects,
+			widget.NewLabel(fmt.Sprintf("%s (%d seated)", label(period.Start), period.Seated)),
+			widget.NewLabelWithStyle(state.FormatMinutes(period.Median), fyne.TextAlignTrailing, fyne.TextStyle{}),
+			widget.NewLabelWithStyle(state.FormatMinutes(period.P90), fyne.TextAlignTrailing, fyne.TextStyle{}),
+		)
+	}
+	return objects
+}
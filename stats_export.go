@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"lounge/internal/state"
+)
+
+// statsRange resolves the -from and -to of a stats export. A blank from is
+// January 1 this year and a blank to is today.
+func statsRange(from, to string, now time.Time) (start, end string, err error) {
+	if from == "" {
+		from = now.Format("2006") + "-01-01"
+	}
+	if start, err = parseCommandDate("from", from); err != nil {
+		return "", "", err
+	}
+	if end, err = parseCommandDate("to", to); err != nil {
+		return "", "", err
+	}
+	if start > end {
+		return "", "", fmt.Errorf("-from %s is after -to %s", start, end)
+	}
+	return start, end, nil
+}
+
+// statsJSON is the state.StatsExport of source for from..to as indented
+// JSON, for the stats command; see statsRange for the blank dates.
+func statsJSON(source *state.Store, from, to string, includeTest bool) ([]byte, error) {
+	now := time.Now()
+	start, end, err := statsRange(from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	return exportStatsJSON(source, start, end, includeTest, now)
+}
+
+func exportStatsJSON(source *state.Store, start, end string, includeTest bool, now time.Time) ([]byte, error) {
+	stats, err := source.ExportStats(start, end, includeTest, now)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(stats, "", "  ")
+}
+
+const (
+	// displayStatsMaxDays caps the range /stats reads, since anyone on the
+	// network can ask for it; a longer range keeps its last days.
+	displayStatsMaxDays = 366
+	// displayStatsTTL is how long a /stats answer is served again before
+	// the logs are read afresh.
+	displayStatsTTL = time.Minute
+	// displayStatsCacheSize bounds how many ranges are remembered.
+	displayStatsCacheSize = 16
+)
+
+type cachedStats struct {
+	at   time.Time
+	data []byte
+}
+
+// displayStatsCache holds recent /stats answers by range. Its lock is held
+// while an export runs, so a burst of requests reads the logs once rather
+// than once each.
+var displayStatsCache struct {
+	sync.Mutex
+	byRange map[string]cachedStats
+}
+
+// displayStatsJSON answers /stats. Only the closures, devices and members
+// are copied on the UI goroutine; the logs are read on the caller's, so a
+// long range never freezes the desk.
+func displayStatsJSON(from, to string) ([]byte, error) {
+	now := time.Now()
+	start, end, err := statsRange(from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	start, end = clampStatsRange(start, end, now)
+	key := start + ".." + end
+
+	displayStatsCache.Lock()
+	defer displayStatsCache.Unlock()
+	if cached, ok := displayStatsCache.byRange[key]; ok && now.Sub(cached.at) < displayStatsTTL {
+		return cached.data, nil
+	}
+	var source *state.Store
+	fyne.DoAndWait(func() { source = store.StatsSnapshot() })
+	data, err := exportStatsJSON(source, start, end, false, now)
+	if err != nil {
+		return nil, err
+	}
+	if displayStatsCache.byRange == nil || len(displayStatsCache.byRange) >= displayStatsCacheSize {
+		displayStatsCache.byRange = map[string]cachedStats{}
+	}
+	displayStatsCache.byRange[key] = cachedStats{at: now, data: data}
+	return data, nil
+}
+
+// clampStatsRange ends the range today at the latest and keeps at most its
+// last displayStatsMaxDays days.
+func clampStatsRange(start, end string, now time.Time) (string, string) {
+	if today := now.Format("2006-01-02"); end > today {
+		end = today
+	}
+	if last, err := time.ParseInLocation("2006-01-02", end, time.Local); err == nil {
+		if earliest := last.AddDate(0, 0, 1-displayStatsMaxDays).Format("2006-01-02"); start < earliest {
+			start = earliest
+		}
+	}
+	if start > end {
+		start = end
+	}
+	return start, end
+}
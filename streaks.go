@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+
+	"lounge/internal/state"
+)
+
+// streakText describes a streak for the member dialog, e.g. "5 open days
+// (longest 12; 40 days, 52 visits)".
+func streakText(streak state.Streak) string {
+	if streak.Days == 0 {
+		return "No visits yet"
+	}
+	return fmt.Sprintf("%d open days (longest %d; %d days, %d visits)", streak.Current, streak.Longest, streak.Days, streak.Visits)
+}
+
+// memberStreakText is streakText for memberID, read from the logs.
+func memberStreakText(memberID string) string {
+	streak, err := store.StreakFor(memberID, state.TodaysLogDate())
+	if err != nil {
+		slog.Error("reading attendance streak", "member", memberID, "err", err)
+		return "Unavailable"
+	}
+	return streakText(streak)
+}
+
+func newStreakBadge() *canvas.Text {
+	badge := canvas.NewText("", readableColor(latteYellow))
+	badge.TextStyle = fyne.TextStyle{Bold: true}
+	badge.Hide()
+	return badge
+}
+
+// updateStreakBadge shows the longest current streak among device's
+// members, e.g. "12d", after the name label once it reaches
+// appSettings.StreakBadgeDays.
+func (renderer *deviceStatusRenderer) updateStreakBadge(device state.Device, badge *canvas.Text, name *canvas.Text) {
+	best := 0
+	if appSettings.StreakBadgeDays > 0 && !renderer.widget.blankMap && !renderer.widget.hideOccupants {
+		today := state.TodaysLogDate()
+		for _, u := range store.UsersOnDevice(device.ID) {
+			if store.MemberByID(u.ID) != nil {
+				best = max(best, store.ActiveStreak(u.ID, today))
+			}
+		}
+	}
+	if best < appSettings.StreakBadgeDays || best == 0 {
+		badge.Hide()
+		return
+	}
+	badge.Text = strconv.Itoa(best) + "d"
+	badge.TextSize = name.TextSize * 0.85
+	badge.Refresh()
+	badge.Move(fyne.NewPos(name.Position().X+name.MinSize().Width+2, name.Position().Y))
+	badge.Show()
+}
+
+// exportStreakLeaderboard saves the attendance leaderboard for the range
+// picked on the Stats tab as CSV, longest streak first, anonymized when the
+// setting is on.
+func exportStreakLeaderboard() {
+	term, ok := selectedStatsTerm()
+	if !ok {
+		dialog.ShowError(fmt.Errorf("pick a term or This week in Range to export its leaderboard"), mainWindow)
+		return
+	}
+	streaks, err := store.StreakLeaderboard(term, state.TodaysLogDate())
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	anon, err := exportAnonymizerFor(appSettings.AnonymizeExports)
+	if err != nil {
+		dialog.ShowError(err, mainWindow)
+		return
+	}
+	rows := [][]string{{"rank", "name", "id", "longest_streak", "current_streak", "days", "visits", "last_visit"}}
+	for i, streak := range streaks {
+		if anon != nil {
+			streak.Name, streak.UserID = state.AnonymizeName(streak.Name), anon.ID(streak.UserID)
+		}
+		rows = append(rows, []string{strconv.Itoa(i + 1), streak.Name, streak.UserID, strconv.Itoa(streak.Longest),
+			strconv.Itoa(streak.Current), strconv.Itoa(streak.Days), strconv.Itoa(streak.Visits), streak.LastVisit})
+	}
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+		w := csv.NewWriter(writer)
+		if err := w.WriteAll(rows); err != nil {
+			dialog.ShowError(fmt.Errorf("write leaderboard: %w", err), mainWindow)
+		}
+	}, mainWindow)
+	save.SetFileName(fmt.Sprintf("lounge-streaks-%s.csv", strings.Join(strings.Fields(term.Name), "-")))
+	save.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+	save.Show()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// newTestSessionCheck marks a check-in as staff testing, so Stats leave it
+// out. Staff accounts (members marked Exclude from stats) need not tick it.
+func newTestSessionCheck() *widget.Check {
+	check := widget.NewCheck("Test session (not in stats)", nil)
+	disableWhenReadOnly(check)
+	return check
+}
+
+// TappedSecondary offers to mark a session as a test, or a test as real,
+// after the fact.
+func (c *logEntryCard) TappedSecondary(ev *fyne.PointEvent) {
+	entry := c.entry
+	if !entry.IsSession() || entry.UserID == "" {
+		return
+	}
+	if readOnly {
+		showReadOnlyNotice()
+		return
+	}
+	label := "Exclude from Stats (test session)"
+	if entry.ExcludeFromStats {
+		label = "Include in Stats"
+	}
+	date := selectedLogDate
+	menu := fyne.NewMenu("", fyne.NewMenuItem(label, func() {
+		if err := store.SetExcludeFromStats(date, entry, !entry.ExcludeFromStats); err != nil {
+			dialog.ShowError(err, mainWindow)
+			return
+		}
+		if date != state.TodaysLogDate() {
+			updateCurrentLogEntriesCache()
+			logList.Refresh()
+		}
+		refreshPurposeStats()
+	}))
+	widget.ShowPopUpMenuAtPosition(menu, mainWindow.Canvas(), ev.AbsolutePosition)
+}
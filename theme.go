@@ -20,6 +20,7 @@ var (
 	latteAccent    = color.NRGBA{R: 136, G: 57, B: 239, A: 255}
 	latteGreen     = color.NRGBA{R: 64, G: 160, B: 43, A: 255}
 	latteRed       = color.NRGBA{R: 210, G: 15, B: 57, A: 255}
+	latteYellow    = color.NRGBA{R: 223, G: 142, B: 29, A: 255}
 )
 
 type catppuccinLatteTheme struct{ fyne.Theme }
@@ -71,3 +72,48 @@ func (t *catppuccinLatteTheme) Size(name fyne.ThemeSizeName) float32 {
 		return t.Theme.Size(name)
 	}
 }
+
+var (
+	mochaBase     = color.NRGBA{R: 30, G: 30, B: 46, A: 255}
+	mochaMantle   = color.NRGBA{R: 24, G: 24, B: 37, A: 255}
+	mochaCrust    = color.NRGBA{R: 17, G: 17, B: 27, A: 255}
+	mochaSurface0 = color.NRGBA{R: 49, G: 50, B: 68, A: 255}
+	mochaText     = color.NRGBA{R: 205, G: 214, B: 244, A: 255}
+	mochaOverlay1 = color.NRGBA{R: 127, G: 132, B: 156, A: 255}
+	mochaOverlay2 = color.NRGBA{R: 147, G: 153, B: 178, A: 255}
+	mochaPrimary  = color.NRGBA{R: 137, G: 220, B: 235, A: 255}
+	mochaBlue     = color.NRGBA{R: 137, G: 180, B: 250, A: 255}
+)
+
+// catppuccinMochaTheme is the dark variant the desk switches to while dimmed.
+// Sizes, fonts and icons are Latte's.
+type catppuccinMochaTheme struct{ catppuccinLatteTheme }
+
+func NewCatppuccinMochaTheme() fyne.Theme {
+	return &catppuccinMochaTheme{catppuccinLatteTheme{Theme: theme.DarkTheme()}}
+}
+
+func (t *catppuccinMochaTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	switch name {
+	case theme.ColorNameBackground:
+		return mochaBase
+	case theme.ColorNameForeground:
+		return mochaText
+	case theme.ColorNameDisabled:
+		return mochaOverlay2
+	case theme.ColorNamePlaceHolder:
+		return mochaOverlay1
+	case theme.ColorNameButton:
+		return mochaMantle
+	case theme.ColorNamePrimary:
+		return mochaPrimary
+	case theme.ColorNameFocus, theme.ColorNameSelection:
+		return mochaBlue
+	case theme.ColorNameHover:
+		return mochaSurface0
+	case theme.ColorNameInputBorder, theme.ColorNameSeparator, theme.ColorNameShadow:
+		return mochaCrust
+	default:
+		return t.Theme.Color(name, theme.VariantDark)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+// toolbarAction is an action the toolbar can show as a button. Every action
+// is also in the overflow menu, so hiding its button never makes it
+// unreachable.
+type toolbarAction struct {
+	ID    string
+	Label string
+	Icon  fyne.Resource
+	Run   func()
+	// Writer actions change the lounge state and are off while read-only.
+	Writer bool
+	Danger bool
+}
+
+// toolbarActions returns every action in the toolbar's default order. It is a
+// function because Settings both is an action and can rearrange them.
+func toolbarActions() []toolbarAction {
+	return []toolbarAction{
+		{ID: "check-in", Label: "Check In", Icon: theme.ContentAddIcon(), Run: showCheckInDialog, Writer: true},
+		{ID: "console-check-in", Label: "Console Check-In", Icon: theme.MediaPlayIcon(), Run: showConsoleCheckInDialog, Writer: true},
+		{ID: "check-in-panel", Label: "Check-In Panel", Icon: theme.VisibilityIcon(), Run: toggleCheckInPanel, Writer: true},
+		{ID: "queue-walk-in", Label: "Queue Walk-In", Icon: theme.ListIcon(), Run: showQueueWalkInDialog, Writer: true},
+		{ID: "check-out", Label: "Check Out", Icon: theme.ContentRemoveIcon(), Run: showCheckOutDialog, Writer: true},
+		{ID: "switch-station", Label: "Switch Station", Icon: theme.ViewRefreshIcon(), Run: showSwitchStationDialog, Writer: true},
+		{ID: "reset-layout", Label: "Reset Layout", Icon: theme.ViewRestoreIcon(), Run: showResetLayoutDialog, Writer: true},
+		{ID: "export-layout", Label: "Export Layout", Icon: theme.DownloadIcon(), Run: showExportLayoutDialog},
+		{ID: "export-devices", Label: "Export Devices", Icon: theme.DownloadIcon(), Run: showExportDevicesDialog},
+		{ID: "evacuation", Label: "Evacuation List", Icon: theme.WarningIcon(), Run: showEvacuationList, Danger: true},
+		{ID: "handover", Label: "Shift Handover", Icon: theme.DocumentIcon(), Run: showHandoverDialog},
+		{ID: "shift-notes", Label: "Shift Notes", Icon: theme.DocumentCreateIcon(), Run: showShiftNotesDialog},
+		{ID: "reservations", Label: "Reservations", Icon: theme.CalendarIcon(), Run: showReservationsDialog},
+		{ID: "preregistration", Label: "Pre-Registration", Icon: theme.ListIcon(), Run: showPreregistrationDialog},
+		{ID: "event-mode", Label: "Event Mode", Icon: theme.GridIcon(), Run: showEventModeDialog, Writer: true},
+		{ID: "members", Label: "Members", Icon: theme.AccountIcon(), Run: showMembersDialog},
+		{ID: "settings", Label: "Settings", Icon: theme.SettingsIcon(), Run: func() { requireStaffPIN("Settings", showSettingsDialog) }, Writer: true},
+	}
+}
+
+var (
+	// toolbarButtons holds the buttons chosen in settings, in order.
+	toolbarButtons *fyne.Container
+	// toolbarActionButtons are made once per action, so read-only mode
+	// disables each only once however often the toolbar is rearranged.
+	toolbarActionButtons = map[string]*widget.Button{}
+)
+
+func toolbarActionByID(id string) (toolbarAction, bool) {
+	for _, action := range toolbarActions() {
+		if action.ID == id {
+			return action, true
+		}
+	}
+	return toolbarAction{}, false
+}
+
+// toolbarButtonIDs are the actions shown as buttons, in order: the setting,
+// less repeats and IDs this build does not know, or every action when it is
+// unset.
+func toolbarButtonIDs() []string {
+	if appSettings.ToolbarButtons == nil {
+		actions := toolbarActions()
+		ids := make([]string, len(actions))
+		for i, action := range actions {
+			ids[i] = action.ID
+		}
+		return ids
+	}
+	var ids []string
+	for _, id := range appSettings.ToolbarButtons {
+		if _, ok := toolbarActionByID(id); ok && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// newToolbarButtons builds the configurable part of the toolbar.
+func newToolbarButtons() *fyne.Container {
+	for _, action := range toolbarActions() {
+		button := widget.NewButtonWithIcon(action.Label, action.Icon, action.Run)
+		if action.Danger {
+			button.Importance = widget.DangerImportance
+		}
+		if action.Writer {
+			writerOnly(button)
+		}
+		toolbarActionButtons[action.ID] = button
+	}
+	toolbarButtons = container.NewHBox()
+	refreshToolbar()
+	return toolbarButtons
+}
+
+// refreshToolbar lays the buttons out again after the setting changes.
+func refreshToolbar() {
+	if toolbarButtons == nil {
+		return
+	}
+	toolbarButtons.Objects = nil
+	for _, id := range toolbarButtonIDs() {
+		toolbarButtons.Add(toolbarActionButtons[id])
+	}
+	toolbarButtons.Refresh()
+}
+
+// newToolbarOverflow is the "more" button listing every action.
+func newToolbarOverflow() *widget.Button {
+	var button *widget.Button
+	button = widget.NewButtonWithIcon("", theme.MoreHorizontalIcon(), func() {
+		actions := toolbarActions()
+		items := make([]*fyne.MenuItem, 0, len(actions))
+		for _, action := range actions {
+			item := fyne.NewMenuItem(action.Label, action.Run)
+			item.Icon = action.Icon
+			item.Disabled = action.Writer && readOnly
+			items = append(items, item)
+		}
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(button)
+		widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), mainWindow.Canvas(), pos.Add(fyne.NewPos(0, button.Size().Height)))
+	})
+	return button
+}
+
+// showToolbarDialog picks which actions get a button and in what order; the
+// rest stay in the overflow menu.
+func showToolbarDialog() {
+	order := toolbarButtonIDs()
+	shown := make(map[string]bool, len(order))
+	for _, id := range order {
+		shown[id] = true
+	}
+	for _, action := range toolbarActions() {
+		if !shown[action.ID] {
+			order = append(order, action.ID)
+		}
+	}
+	rows := container.NewVBox()
+	var rebuild func()
+	move := func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+		rebuild()
+	}
+	rebuild = func() {
+		rows.Objects = nil
+		for i, id := range order {
+			action, _ := toolbarActionByID(id)
+			check := widget.NewCheck(action.Label, nil)
+			check.Checked = shown[id]
+			check.OnChanged = func(on bool) { shown[id] = on }
+			up := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() { move(i, i-1) })
+			down := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() { move(i, i+1) })
+			if i == 0 {
+				up.Disable()
+			}
+			if i == len(order)-1 {
+				down.Disable()
+			}
+			rows.Add(container.NewBorder(nil, nil, nil, container.NewHBox(up, down), check))
+		}
+		rows.Refresh()
+	}
+	rebuild()
+	intro := widget.NewLabel("Ticked actions get a toolbar button, in this order. Every action stays in the ... menu.")
+	intro.Wrapping = fyne.TextWrapWord
+	dlg := dialog.NewCustomConfirm("Toolbar", "Save", "Cancel", container.NewBorder(intro, nil, nil, nil, container.NewVScroll(rows)), func(ok bool) {
+		if !ok {
+			return
+		}
+		buttons := []string{}
+		for _, id := range order {
+			if shown[id] {
+				buttons = append(buttons, id)
+			}
+		}
+		appSettings.ToolbarButtons = buttons
+		if err := saveSettings(); err != nil {
+			dialog.ShowError(err, mainWindow)
+		}
+		refreshToolbar()
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(420, 520))
+	dlg.Show()
+}
+
+// showQueueWalkInDialog queues someone with just a name and ID, for when the
+// inline form is out of sight behind another tab.
+func showQueueWalkInDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Full Name")
+	idEntry := widget.NewEntry()
+	idEntry.SetPlaceHolder("ID")
+	noID := widget.NewButton("No ID?", func() {
+		whenMembersReady(func() { idEntry.SetText(state.GeneratedIDPrefix + store.NextMemberID()) })
+	})
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("User ID", container.NewBorder(nil, nil, nil, noID, idEntry)),
+	}
+	var dlg dialog.Dialog
+	dlg = dialog.NewForm("Queue Walk-In", "Add to Queue", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		name, id := strings.TrimSpace(nameEntry.Text), strings.TrimSpace(idEntry.Text)
+		if name == "" || id == "" {
+			dialog.ShowError(fmt.Errorf("name and ID are required"), mainWindow)
+			return
+		}
+		// Back to the form if staff stopped to fix something, like the ID.
+		registerUserWithChecks(name, id, 0, state.RegisterOptions{Source: state.SourceDesk}, store.Event != "", func(queued bool) {
+			if !queued {
+				dlg.Show()
+			}
+		})
+	}, mainWindow)
+	dlg.Resize(fyne.NewSize(400, dlg.MinSize().Height))
+	dlg.Show()
+	mainWindow.Canvas().Focus(nameEntry)
+}
+
+// showResetLayoutDialog puts every station back in the default layout.
+func showResetLayoutDialog() {
+	if deviceLayoutWidget == nil {
+		return
+	}
+	dialog.ShowConfirm("Reset Layout", "Reset all stations to the default layout?", func(ok bool) {
+		if ok {
+			deviceLayoutWidget.ResetLayout()
+		}
+	}, mainWindow)
+}
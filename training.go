@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/applog"
+	"lounge/internal/state"
+)
+
+var (
+	// trainingMode is set by -training: every data file lives in the
+	// sandbox and nothing leaves the computer.
+	trainingMode bool
+	// trainingRoot is the temporary folder holding the sandbox. Each
+	// training session gets its own, so two desks training at once never
+	// share one, and it is removed when the app closes.
+	trainingRoot string
+)
+
+// startTraining points the data files at a new sandbox seeded with a copy of
+// the real data.
+func startTraining() error {
+	root, err := os.MkdirTemp("", "lounge-training-")
+	if err != nil {
+		return fmt.Errorf("create sandbox: %w", err)
+	}
+	dir := filepath.Join(root, "data")
+	if err := seedSandbox(dir); err != nil {
+		os.RemoveAll(root)
+		return err
+	}
+	trainingRoot = root
+	useDataRoot(dir)
+	trainingMode = true
+	slog.Info("training mode", "sandbox", dir)
+	return nil
+}
+
+// seedSandbox copies the member file and the files directly in the log
+// folder (state, logs, settings, layout) to dir, for training or a
+// memory-only desk. The instance lock and the application log stay behind.
+// The folder gets its name only once the copy is complete.
+func seedSandbox(dir string) error {
+	partial := dir + ".partial"
+	os.RemoveAll(partial)
+	if err := os.MkdirAll(filepath.Join(partial, "log"), 0o755); err != nil {
+		return fmt.Errorf("create sandbox: %w", err)
+	}
+	copies := map[string]string{memberFile: filepath.Join(partial, "membership.csv")}
+	entries, err := os.ReadDir(logDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("list data files: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || name == state.LockFileName || strings.HasPrefix(name, applog.FileName) {
+			continue
+		}
+		copies[filepath.Join(logDir, name)] = filepath.Join(partial, "log", name)
+	}
+	for from, to := range copies {
+		data, err := os.ReadFile(from)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil {
+			err = os.WriteFile(to, data, 0o644)
+		}
+		if err != nil {
+			os.RemoveAll(partial)
+			return fmt.Errorf("copy %s to the sandbox: %w", from, err)
+		}
+	}
+	if err := os.Rename(partial, dir); err != nil {
+		os.RemoveAll(partial)
+		return fmt.Errorf("finish sandbox: %w", err)
+	}
+	return nil
+}
+
+func discardTrainingSandbox() {
+	if trainingRoot == "" {
+		return
+	}
+	if err := os.RemoveAll(trainingRoot); err != nil {
+		slog.Error("discarding the training sandbox", "err", err)
+	}
+}
+
+// keepTrainingInside switches off everything in s that reaches beyond the
+// sandbox: backups, receipt emails, the Sheets mirror, alert webhooks and
+// wall displays.
+func keepTrainingInside(s *Settings) {
+	s.BackupDir = ""
+	s.SMTPHost = ""
+	s.SpreadsheetID = ""
+	s.SheetsCredentialsFile = ""
+	s.AlertWebhookURL = ""
+	s.DisplayServerAddr = ""
+}
+
+// newTrainingBanner tells staff they are in the sandbox, above the tabs.
+func newTrainingBanner() fyne.CanvasObject {
+	if !trainingMode {
+		return layout.NewSpacer()
+	}
+	title := canvas.NewText("TRAINING MODE", latteRed)
+	title.TextStyle = fyne.TextStyle{Bold: true}
+	title.TextSize = 18
+	message := widget.NewLabel("Practice freely: changes go to a sandbox copy of the data, and no email, backup, Sheets, alert or wall display is sent.")
+	message.Wrapping = fyne.TextWrapWord
+	exit := widget.NewButtonWithIcon("Exit Training", theme.LogoutIcon(), showExitTrainingDialog)
+	exit.Importance = widget.DangerImportance
+	return container.NewBorder(nil, nil, container.NewCenter(title), exit, message)
+}
+
+// newTrainingWatermark lays "TRAINING MODE" faintly over the whole window.
+// It is a plain canvas object, so taps go through to the controls below.
+func newTrainingWatermark() fyne.CanvasObject {
+	if !trainingMode {
+		return layout.NewSpacer()
+	}
+	mark := canvas.NewText("TRAINING MODE", color.NRGBA{R: latteRed.R, G: latteRed.G, B: latteRed.B, A: 40})
+	mark.TextStyle = fyne.TextStyle{Bold: true}
+	mark.TextSize = 96
+	return container.NewCenter(mark)
+}
+
+// showExitTrainingDialog closes the app from training mode once staff
+// confirm; the sandbox goes with it.
+func showExitTrainingDialog() {
+	dlg := dialog.NewConfirm("Exit Training",
+		"Close the app and discard the training sandbox?\nThe real lounge files were never touched.",
+		func(ok bool) {
+			if ok {
+				shutdown()
+			}
+		}, mainWindow)
+	dlg.SetConfirmText("Discard and exit")
+	dlg.SetConfirmImportance(widget.DangerImportance)
+	dlg.Show()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrainingSandboxIsPrivateAndDiscarded(t *testing.T) {
+	savedLog, savedMembers, savedLayout, savedSettings, savedSalt := logDir, memberFile, deviceLayoutFile, settingsFile, exportSaltFile
+	savedMode, savedRoot := trainingMode, trainingRoot
+	t.Cleanup(func() {
+		logDir, memberFile, deviceLayoutFile, settingsFile, exportSaltFile = savedLog, savedMembers, savedLayout, savedSettings, savedSalt
+		trainingMode, trainingRoot = savedMode, savedRoot
+	})
+	t.Setenv("TMPDIR", t.TempDir())
+	dataRoot := t.TempDir()
+	useDataRoot(dataRoot)
+	if err := os.WriteFile(memberFile, []byte("Name,ID\nAda Lovelace,1001\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two desks training at once each get their own sandbox.
+	var roots []string
+	for range 2 {
+		useDataRoot(dataRoot)
+		if err := startTraining(); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, trainingRoot)
+		if data, err := os.ReadFile(memberFile); err != nil || string(data) != "Name,ID\nAda Lovelace,1001\n" {
+			t.Fatalf("sandbox members = %q, %v", data, err)
+		}
+		if filepath.Dir(filepath.Dir(memberFile)) != trainingRoot {
+			t.Fatalf("members at %s, outside the sandbox %s", memberFile, trainingRoot)
+		}
+	}
+	if roots[0] == roots[1] {
+		t.Fatalf("both sessions use %s", roots[0])
+	}
+
+	for _, root := range roots {
+		trainingRoot = root
+		discardTrainingSandbox()
+		if _, err := os.Stat(root); !os.IsNotExist(err) {
+			t.Fatalf("sandbox %s is still there: %v", root, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dataRoot, "membership.csv")); err != nil {
+		t.Fatalf("the real members are gone: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+)
+
+// uiScaleOptions are the text sizes staff can pick for the map and the queue,
+// and separately for the wall display, as a percentage of the normal size.
+var uiScaleOptions = []string{"100%", "125%", "150%", "175%"}
+
+const (
+	minUIScale = 100
+	maxUIScale = 175
+	// minTextContrast is the WCAG AA ratio for normal text; the map's labels
+	// are small, so they get no large-text allowance.
+	minTextContrast = 4.5
+)
+
+// scaleFactor turns a percentage setting into a multiplier, treating a
+// missing or out-of-range value as the nearest size offered.
+func scaleFactor(percent int) float32 {
+	percent = min(max(percent, minUIScale), maxUIScale)
+	return float32(percent) / 100
+}
+
+// mapScale multiplies the map's and the queue's text sizes and icon limits.
+func mapScale() float32 { return scaleFactor(appSettings.UIScale) }
+
+func scaleOption(percent int) string {
+	return fmt.Sprintf("%d%%", int(scaleFactor(percent)*100+0.5))
+}
+
+func parseScaleOption(option string) int {
+	percent, err := strconv.Atoi(strings.TrimSuffix(option, "%"))
+	if err != nil {
+		return minUIScale
+	}
+	return percent
+}
+
+// relativeLuminance is c's WCAG relative luminance, from 0 for black to 1
+// for white.
+func relativeLuminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	channel := func(v uint32) float64 {
+		s := float64(v) / 0xffff
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b)
+}
+
+// contrastRatio is the WCAG contrast between a and b, from 1 to 21.
+func contrastRatio(a, b color.Color) float64 {
+	la, lb := relativeLuminance(a), relativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// blendColor is a moved t of the way to b.
+func blendColor(a, b color.Color, t float64) color.NRGBA {
+	ca, cb := color.NRGBAModel.Convert(a).(color.NRGBA), color.NRGBAModel.Convert(b).(color.NRGBA)
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5) }
+	return color.NRGBA{R: mix(ca.R, cb.R), G: mix(ca.G, cb.G), B: mix(ca.B, cb.B), A: 255}
+}
+
+// readableColor returns c if it stands out enough against the theme's
+// background, otherwise the first step from c towards the theme's text
+// colour that does, or black or white as a last resort. It follows the
+// theme, so labels stay readable when the dark theme is on.
+func readableColor(c color.Color) color.Color {
+	background := theme.BackgroundColor()
+	if contrastRatio(c, background) >= minTextContrast {
+		return c
+	}
+	foreground := theme.ForegroundColor()
+	for step := 1; step <= 10; step++ {
+		if mixed := blendColor(c, foreground, float64(step)/10); contrastRatio(mixed, background) >= minTextContrast {
+			return mixed
+		}
+	}
+	if relativeLuminance(background) > 0.5 {
+		return color.Black
+	}
+	return color.White
+}
+
+// primaryTextColor is for device numbers and occupant names.
+func primaryTextColor() color.Color { return readableColor(theme.ForegroundColor()) }
+
+// secondaryTextColor is for the quieter line under a label, derived from
+// the theme's placeholder colour rather than a fixed gray.
+func secondaryTextColor() color.Color { return readableColor(theme.PlaceHolderColor()) }
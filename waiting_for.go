@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2/widget"
+
+	"lounge/internal/state"
+)
+
+const waitingForAny = "Any device"
+
+// newWaitingForSelect picks what a queued user waits for: any device, any
+// device of a type, or one device. chosen returns the pick, nil for any.
+func newWaitingForSelect() (sel *widget.Select, chosen func() *state.WaitingFor) {
+	options := []string{waitingForAny}
+	targets := []*state.WaitingFor{nil}
+	for _, deviceType := range []string{"PC", "Console"} {
+		options = append(options, "Any "+deviceType)
+		targets = append(targets, &state.WaitingFor{Type: deviceType})
+	}
+	for _, device := range store.Devices {
+		options = append(options, device.Name())
+		targets = append(targets, &state.WaitingFor{DeviceID: device.ID})
+	}
+	sel = widget.NewSelect(options, nil)
+	sel.SetSelected(waitingForAny)
+	return sel, func() *state.WaitingFor {
+		if i := sel.SelectedIndex(); i > 0 {
+			return targets[i]
+		}
+		return nil
+	}
+}
+
+// waitingForBadge is the short badge on a pending icon, e.g. "PC 7" or
+// "Console"; empty when the user takes any device.
+func waitingForBadge(w *state.WaitingFor) string {
+	switch {
+	case w == nil:
+		return ""
+	case w.DeviceID != 0:
+		if device := store.DeviceByID(w.DeviceID); device != nil {
+			return device.Name()
+		}
+		return fmt.Sprintf("#%d", w.DeviceID)
+	}
+	return w.Type
+}
+
+// waitingForText names what w waits for in a sentence, e.g. "PC 7" or
+// "any Console".
+func waitingForText(w *state.WaitingFor) string {
+	if w != nil && w.DeviceID == 0 {
+		return "any " + w.Type
+	}
+	return waitingForBadge(w)
+}
+
+// seatNextButton seats whoever is next for a free device from its context
+// menu: the first waiting for it in particular, else the first waiting for
+// any device. It is nil when the device is taken or nobody suits it.
+func seatNextButton(device state.Device, onDone func()) *widget.Button {
+	if device.Status != "free" {
+		return nil
+	}
+	next := store.NextQueuedFor(device.ID)
+	if next == nil {
+		return nil
+	}
+	userID := next.ID
+	button := widget.NewButton("Seat "+firstLastNonEmpty(next.Name), func() {
+		onDone()
+		assignQueuedConfirming(userID, device, nil)
+	})
+	disableWhenReadOnly(button)
+	return button
+}
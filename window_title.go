@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	// windowTitleBase leads every title, so window managers and taskbars
+	// keep the window where it was as the counts change.
+	windowTitleBase = "Lounge"
+	// windowTitleInterval throttles title changes.
+	windowTitleInterval = 3 * time.Second
+)
+
+var (
+	windowTitleShown   time.Time
+	windowTitlePending bool
+	// loungeFullNotified is set while the queue waits with every PC busy, so
+	// staff are notified once each time that starts.
+	loungeFullNotified bool
+)
+
+// refreshWindowTitle runs on every refresh and puts the free PCs and the
+// queue in the title, e.g. "Lounge — 3 free PCs · queue 2", so staff see them
+// on the taskbar with the app minimized. Changes within windowTitleInterval
+// of the last one are held back and applied together.
+func refreshWindowTitle() {
+	if mainWindow == nil {
+		return
+	}
+	now := time.Now()
+	if wait := windowTitleInterval - now.Sub(windowTitleShown); wait > 0 {
+		if !windowTitlePending {
+			windowTitlePending = true
+			time.AfterFunc(wait, func() {
+				fyne.Do(func() {
+					windowTitlePending = false
+					refreshWindowTitle()
+				})
+			})
+		}
+		return
+	}
+	free := 0
+	for _, device := range store.Devices {
+		if device.Type == "PC" && device.Status == "free" {
+			free++
+		}
+	}
+	queued := len(store.PendingUsers())
+	pcs := "PCs"
+	if free == 1 {
+		pcs = "PC"
+	}
+	base := windowTitleBase
+	if trainingMode {
+		base = "TRAINING MODE · " + base
+	}
+	title := fmt.Sprintf("%s — %d free %s · queue %d", base, free, pcs, queued)
+	if title != mainWindow.Title() {
+		mainWindow.SetTitle(title)
+		windowTitleShown = now
+	}
+
+	full := queued > 0 && free == 0
+	if full && !loungeFullNotified {
+		notify("lounge-full", "Lounge full",
+			fmt.Sprintf("%d waiting in the queue and no PC is free.", queued))
+	}
+	loungeFullNotified = full
+}